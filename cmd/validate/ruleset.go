@@ -7,6 +7,9 @@ import (
 	"github.com/rs/zerolog"
 	"github.com/spf13/cobra"
 
+	"github.com/dadrus/heimdall/internal/backchannel"
+	"github.com/dadrus/heimdall/internal/cache"
+	"github.com/dadrus/heimdall/internal/callback"
 	"github.com/dadrus/heimdall/internal/config"
 	"github.com/dadrus/heimdall/internal/rules"
 	"github.com/dadrus/heimdall/internal/rules/event"
@@ -64,7 +67,12 @@ func validateRuleSet(cmd *cobra.Command, args []string) error {
 
 	conf.Providers.FileSystem = map[string]any{"src": args[0]}
 
-	mFactory, err := mechanisms.NewFactory(conf, logger)
+	cch, err := cache.NewCache(conf, logger)
+	if err != nil {
+		return err
+	}
+
+	mFactory, err := mechanisms.NewFactory(conf, logger, cch, backchannel.NewRegistry(), callback.NewRegistry())
 	if err != nil {
 		return err
 	}
@@ -78,7 +86,7 @@ func validateRuleSet(cmd *cobra.Command, args []string) error {
 
 	defer close(queue)
 
-	provider, err := filesystem.NewProvider(conf, rules.NewRuleSetProcessor(queue, rFactory, logger), logger)
+	provider, err := filesystem.NewProvider(conf, rules.NewRuleSetProcessor(queue, rFactory, conf, logger), logger)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,73 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package backchannel
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var ErrNoLogoutTokenHandler = errors.New("no logout token handler registered")
+
+// LogoutTokenHandler verifies OpenID Connect back-channel logout tokens and records
+// the logout events these represent.
+type LogoutTokenHandler interface {
+	HandleLogoutToken(ctx context.Context, rawToken string) error
+}
+
+// Registry holds the handlers for logout tokens received via the back-channel logout endpoint.
+// The handlers are registered while the pipeline mechanisms are loaded.
+type Registry struct {
+	mu       sync.RWMutex
+	handlers []LogoutTokenHandler
+}
+
+func NewRegistry() *Registry { return &Registry{} }
+
+// Register makes the given handler available for processing of logout tokens.
+func (r *Registry) Register(handler LogoutTokenHandler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.handlers = append(r.handlers, handler)
+}
+
+// HandleLogoutToken passes the given logout token to the registered handlers. The token is
+// considered being handled if at least one of these succeeds.
+func (r *Registry) HandleLogoutToken(ctx context.Context, rawToken string) error {
+	r.mu.RLock()
+	registered := r.handlers
+	r.mu.RUnlock()
+
+	if len(registered) == 0 {
+		return ErrNoLogoutTokenHandler
+	}
+
+	errs := make([]error, 0, len(registered))
+
+	for _, handler := range registered {
+		err := handler.HandleLogoutToken(ctx, rawToken)
+		if err == nil {
+			return nil
+		}
+
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,96 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package backchannel
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type logoutTokenHandlerFunc func(ctx context.Context, rawToken string) error
+
+func (f logoutTokenHandlerFunc) HandleLogoutToken(ctx context.Context, rawToken string) error {
+	return f(ctx, rawToken)
+}
+
+func TestRegistryHandleLogoutToken(t *testing.T) {
+	t.Parallel()
+
+	errTest := errors.New("test error")
+
+	for _, tc := range []struct {
+		uc       string
+		handlers []LogoutTokenHandler
+		assert   func(t *testing.T, err error)
+	}{
+		{
+			uc: "no handlers registered",
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.ErrorIs(t, err, ErrNoLogoutTokenHandler)
+			},
+		},
+		{
+			uc: "all handlers fail",
+			handlers: []LogoutTokenHandler{
+				logoutTokenHandlerFunc(func(_ context.Context, _ string) error { return errTest }),
+				logoutTokenHandlerFunc(func(_ context.Context, _ string) error { return errTest }),
+			},
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.ErrorIs(t, err, errTest)
+			},
+		},
+		{
+			uc: "one of the handlers succeeds",
+			handlers: []LogoutTokenHandler{
+				logoutTokenHandlerFunc(func(_ context.Context, _ string) error { return errTest }),
+				logoutTokenHandlerFunc(func(_ context.Context, rawToken string) error {
+					assert.Equal(t, "foo", rawToken)
+
+					return nil
+				}),
+			},
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.NoError(t, err)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			registry := NewRegistry()
+
+			for _, handler := range tc.handlers {
+				registry.Register(handler)
+			}
+
+			// WHEN
+			err := registry.HandleLogoutToken(context.Background(), "foo")
+
+			// THEN
+			tc.assert(t, err)
+		})
+	}
+}
@@ -18,9 +18,14 @@ package cache
 
 import (
 	"context"
+	"errors"
 	"time"
 )
 
+// ErrUnsupportedOperation is returned by the operations of a cache, which it is not able
+// to perform, like the atomic ones if caching is disabled.
+var ErrUnsupportedOperation = errors.New("unsupported cache operation")
+
 //go:generate mockery --name Cache --structname CacheMock
 
 type Cache interface {
@@ -28,5 +33,22 @@ type Cache interface {
 
 	Get(ctx context.Context, key string) any
 	Set(ctx context.Context, key string, value any, ttl time.Duration)
+	// SetIfAbsent atomically stores the value only if there is no entry for the given key
+	// yet and reports whether it has been stored. Unlike the other operations, it returns
+	// an error if the cache fails to perform it, so that callers relying on its atomicity
+	// can fail closed.
+	SetIfAbsent(ctx context.Context, key string, value any, ttl time.Duration) (bool, error)
+	// Update atomically replaces the entry for the given key with the value and ttl returned
+	// by the update function, which receives the current value, or nil if there is none. If
+	// the entry is modified concurrently, the function may be called multiple times. So it
+	// must be free of side effects and must not modify the value it receives. Like SetIfAbsent,
+	// it returns an error if the cache fails to perform the update.
+	Update(ctx context.Context, key string, update func(current any) (any, time.Duration)) error
 	Delete(ctx context.Context, key string)
 }
+
+// TTLGetter is implemented by caches, which can report the remaining time to live of an
+// entry together with its value. A remaining ttl of 0 means, the entry does not expire.
+type TTLGetter interface {
+	GetWithTTL(ctx context.Context, key string) (any, time.Duration)
+}
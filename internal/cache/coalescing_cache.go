@@ -0,0 +1,78 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cache
+
+import (
+	"sync/atomic"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// LoadStatistics holds the number of expired values served by GetOrLoad, because a
+// fresh value could not be loaded, and the number of refreshes done in the background.
+type LoadStatistics struct {
+	StaleServed     uint64
+	Refreshes       uint64
+	FailedRefreshes uint64
+}
+
+// coalescingCache decorates a Cache and lets GetOrLoad coalesce concurrent loads of
+// the same entry.
+type coalescingCache struct {
+	Cache
+
+	// the kind of the decorated cache
+	kind Kind
+
+	group           singleflight.Group
+	staleServed     atomic.Uint64
+	refreshes       atomic.Uint64
+	failedRefreshes atomic.Uint64
+}
+
+func newCoalescingCache(cch Cache) *coalescingCache { return &coalescingCache{Cache: cch} }
+
+func (c *coalescingCache) coalesce(key string, load func() (any, error)) (any, bool, error) {
+	value, err, shared := c.group.Do(key, load)
+
+	return value, shared, err
+}
+
+func (c *coalescingCache) coalesceInBackground(key string, load func() (any, error)) {
+	// the returned channel is buffered, so there is no need to read from it
+	c.group.DoChan(key, load)
+}
+
+func (c *coalescingCache) recordStaleServed() { c.staleServed.Add(1) }
+
+func (c *coalescingCache) recordRefresh(err error) {
+	if err != nil {
+		c.failedRefreshes.Add(1)
+	} else {
+		c.refreshes.Add(1)
+	}
+}
+
+func (c *coalescingCache) LoadStatistics() LoadStatistics {
+	return LoadStatistics{
+		StaleServed:     c.staleServed.Load(),
+		Refreshes:       c.refreshes.Load(),
+		FailedRefreshes: c.failedRefreshes.Load(),
+	}
+}
+
+func (c *coalescingCache) Unwrap() Cache { return c.Cache }
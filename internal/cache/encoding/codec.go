@@ -0,0 +1,119 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package encoding
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+var (
+	ErrUnsupportedType = errors.New("unsupported type")
+	ErrMalformedValue  = errors.New("malformed value")
+)
+
+//nolint:gochecknoglobals
+var (
+	typesMu     sync.RWMutex
+	typesByName = map[string]reflect.Type{}
+	namesByType = map[reflect.Type]string{}
+)
+
+type envelope struct {
+	Type  string          `json:"t"`
+	Value json.RawMessage `json:"v"`
+}
+
+//nolint:gochecknoinits
+func init() {
+	RegisterType[[]byte]("bytes")
+	RegisterType[string]("string")
+}
+
+// RegisterType makes values of type T storable in caches, which have to serialize the
+// values they hold, like a redis based one. The given name identifies the type in the
+// serialized form and must therefore be stable and unique. Values of T are serialized
+// using JSON, so T must either have exported fields only, or implement json.Marshaler
+// and json.Unmarshaler.
+func RegisterType[T any](name string) {
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+
+	typesMu.Lock()
+	defer typesMu.Unlock()
+
+	if known, ok := typesByName[name]; ok && known != typ {
+		panic(fmt.Sprintf("cache type name %s is already registered for %s", name, known))
+	}
+
+	typesByName[name] = typ
+	namesByType[typ] = name
+}
+
+// Marshal serializes the given value, which must be of a registered type.
+func Marshal(value any) ([]byte, error) {
+	typesMu.RLock()
+	name, ok := namesByType[reflect.TypeOf(value)]
+	typesMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %T", ErrUnsupportedType, value)
+	}
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(envelope{Type: name, Value: raw})
+}
+
+// Unmarshal restores a value serialized by Marshal.
+func Unmarshal(data []byte) (any, error) {
+	var env envelope
+
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformedValue, err)
+	}
+
+	typesMu.RLock()
+	typ, ok := typesByName[env.Type]
+	typesMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, env.Type)
+	}
+
+	var target reflect.Value
+	if typ.Kind() == reflect.Pointer {
+		target = reflect.New(typ.Elem())
+	} else {
+		target = reflect.New(typ)
+	}
+
+	if err := json.Unmarshal(env.Value, target.Interface()); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformedValue, err)
+	}
+
+	if typ.Kind() == reflect.Pointer {
+		return target.Interface(), nil
+	}
+
+	return target.Elem().Interface(), nil
+}
@@ -0,0 +1,94 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package encoding
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testValue struct {
+	Foo string         `json:"foo"`
+	Bar map[string]any `json:"bar"`
+}
+
+func TestMarshalUnmarshal(t *testing.T) {
+	t.Parallel()
+
+	RegisterType[*testValue]("test_value")
+
+	for _, tc := range []struct {
+		uc    string
+		value any
+	}{
+		{uc: "bytes", value: []byte("foo")},
+		{uc: "string", value: "bar"},
+		{uc: "pointer to struct", value: &testValue{Foo: "foo", Bar: map[string]any{"baz": "zab"}}},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// WHEN
+			data, err := Marshal(tc.value)
+			require.NoError(t, err)
+
+			res, err := Unmarshal(data)
+
+			// THEN
+			require.NoError(t, err)
+			assert.Equal(t, tc.value, res)
+		})
+	}
+}
+
+func TestMarshalUnsupportedType(t *testing.T) {
+	t.Parallel()
+
+	// WHEN
+	_, err := Marshal(42)
+
+	// THEN
+	require.ErrorIs(t, err, ErrUnsupportedType)
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc     string
+		data   []byte
+		expErr error
+	}{
+		{uc: "not serialized by Marshal", data: []byte("foo"), expErr: ErrMalformedValue},
+		{uc: "unknown type", data: []byte(`{"t":"foo","v":"bar"}`), expErr: ErrUnsupportedType},
+		{uc: "malformed value", data: []byte(`{"t":"bytes","v":42}`), expErr: ErrMalformedValue},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// WHEN
+			_, err := Unmarshal(tc.data)
+
+			// THEN
+			require.ErrorIs(t, err, tc.expErr)
+		})
+	}
+}
+
+func TestRegisterTypeWithConflictingName(t *testing.T) {
+	t.Parallel()
+
+	assert.Panics(t, func() { RegisterType[int]("string") })
+}
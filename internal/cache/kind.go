@@ -0,0 +1,53 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cache
+
+// Kind classifies the configured cache by the guarantees it can give to the mechanisms
+// relying on it.
+type Kind int
+
+const (
+	// KindDisabled means, caching is disabled and no entries are kept at all.
+	KindDisabled Kind = iota
+	// KindLocal means, the entries are kept in the memory of each heimdall instance.
+	KindLocal
+	// KindShared means, the entries are shared between all heimdall instances.
+	KindShared
+)
+
+// KindOf returns the Kind of the given cache. For caches created by NewCache, this is the kind
+// of the cache actually instantiated. So, e.g. an unsupported cache type, which results in a
+// disabled cache, is classified as such. Any other cache is considered to be a local one.
+func KindOf(cch Cache) Kind {
+	if cc, ok := cch.(*coalescingCache); ok {
+		return cc.kind
+	}
+
+	return KindLocal
+}
+
+// EvictsEntries returns true if the given cache may evict entries before their TTL expires,
+// as it is the case for an in-memory cache configured with limits.
+func EvictsEntries(cch Cache) bool {
+	if cc, ok := cch.(*coalescingCache); ok {
+		cch = cc.Cache
+	}
+
+	bounded, ok := cch.(interface{ Bounded() bool })
+
+	return ok && bounded.Bounded()
+}
@@ -0,0 +1,291 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+)
+
+// LoadFunc loads a value, which is not present in the cache. It returns the value and
+// the duration, the value should be cached for. A duration of 0 (or less) prevents the
+// value from being cached. The given context is the one, the load should be done in.
+// If the load happens in the background to refresh an entry, the context is detached
+// from the one of the request, which triggered the refresh.
+type LoadFunc[T any] func(ctx context.Context) (T, time.Duration, error)
+
+// LoadOption configures the way GetOrLoad deals with cached values.
+type LoadOption func(opts *loadOptions)
+
+type loadOptions struct {
+	staleIfError time.Duration
+	refreshAhead time.Duration
+}
+
+// WithStaleIfError lets GetOrLoad serve an expired value for the given duration after its
+// expiry, if loading a fresh one fails, because the upstream system could not be reached,
+// did not respond in time, or responded with a server error.
+func WithStaleIfError(grace time.Duration) LoadOption {
+	return func(opts *loadOptions) {
+		if grace > 0 {
+			opts.staleIfError = grace
+		}
+	}
+}
+
+// WithRefreshAhead lets GetOrLoad refresh a cached value in the background if it is used
+// within the given duration before its expiry. The refresh is only done if the cache is
+// capable of coalescing loads, so that there is at most one refresh per entry in flight.
+func WithRefreshAhead(period time.Duration) LoadOption {
+	return func(opts *loadOptions) {
+		if period > 0 {
+			opts.refreshAhead = period
+		}
+	}
+}
+
+func (o *loadOptions) tracksFreshness() bool { return o.staleIfError > 0 || o.refreshAhead > 0 }
+
+// coalescer is implemented by caches, which can coalesce concurrent loads of the same
+// entry.
+type coalescer interface {
+	coalesce(key string, load func() (any, error)) (any, bool, error)
+	coalesceInBackground(key string, load func() (any, error))
+}
+
+// loadRecorder is implemented by caches keeping track of stale values served and of the
+// refreshes done by GetOrLoad.
+type loadRecorder interface {
+	recordStaleServed()
+	recordRefresh(err error)
+}
+
+// GetOrLoad returns the value cached under the given key. If there is no such value,
+// or it is not of the expected type, the value is loaded using the given function and
+// cached afterwards. If supported by the given cache, concurrent loads for the same key
+// are coalesced, so that only one of them hits the system the value is loaded from. The
+// others just receive the result of that load.
+func GetOrLoad[T any](ctx context.Context, cch Cache, key string, load LoadFunc[T], opts ...LoadOption) (T, error) {
+	var options loadOptions
+
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	if options.tracksFreshness() {
+		return getOrLoadTimed(ctx, cch, key, load, &options)
+	}
+
+	if value, ok := lookup[T](ctx, cch, key); ok {
+		return value, nil
+	}
+
+	fetch := func(ctx context.Context) (any, error) {
+		value, ttl, err := load(ctx)
+		if err == nil && ttl > 0 {
+			cch.Set(ctx, key, value, ttl)
+		}
+
+		return value, err
+	}
+
+	value, err := coalescedLoad(ctx, cch, key, fetch, func() (any, bool) { return lookup[T](ctx, cch, key) })
+	res, _ := value.(T)
+
+	return res, err
+}
+
+func getOrLoadTimed[T any](
+	ctx context.Context, cch Cache, key string, load LoadFunc[T], opts *loadOptions,
+) (T, error) {
+	now := time.Now()
+
+	cached, freshUntil, found := lookupTimed[T](ctx, cch, key, now, opts.staleIfError)
+	if found && now.Before(freshUntil) {
+		if opts.refreshAhead > 0 && freshUntil.Sub(now) <= opts.refreshAhead {
+			refreshInBackground(ctx, cch, key, load, opts)
+		}
+
+		return cached, nil
+	}
+
+	value, err := coalescedLoad(ctx, cch, key,
+		func(ctx context.Context) (any, error) { return loadTimed(ctx, cch, key, load, opts) },
+		func() (any, bool) {
+			// only a fresh entry is of interest here
+			value, _, ok := lookupTimed[T](ctx, cch, key, time.Now(), 0)
+
+			return value, ok
+		})
+	if err != nil && found && isUpstreamFailure(err) {
+		zerolog.Ctx(ctx).Warn().Err(err).
+			Time("_fresh_until", freshUntil).
+			Msg("Loading of a fresh value failed. Serving expired entry from cache")
+
+		if recorder, ok := cch.(loadRecorder); ok {
+			recorder.recordStaleServed()
+		}
+
+		return cached, nil
+	}
+
+	res, _ := value.(T)
+
+	return res, err
+}
+
+func loadTimed[T any](ctx context.Context, cch Cache, key string, load LoadFunc[T], opts *loadOptions) (any, error) {
+	value, ttl, err := load(ctx)
+	if err == nil && ttl > 0 {
+		cch.Set(ctx, key, &timedEntry{Value: value, FreshUntil: time.Now().Add(ttl)}, ttl+opts.staleIfError)
+	}
+
+	return value, err
+}
+
+func refreshInBackground[T any](ctx context.Context, cch Cache, key string, load LoadFunc[T], opts *loadOptions) {
+	clsr, ok := cch.(coalescer)
+	if !ok {
+		return
+	}
+
+	// the request, which triggered the refresh, is not going to wait for it
+	ctx = context.WithoutCancel(ctx)
+
+	clsr.coalesceInBackground(key, func() (any, error) {
+		zerolog.Ctx(ctx).Debug().Msg("Refreshing cache entry")
+
+		value, err := loadTimed(ctx, cch, key, load, opts)
+		if err != nil {
+			zerolog.Ctx(ctx).Warn().Err(err).Msg("Refreshing of cache entry failed")
+		}
+
+		if recorder, ok := cch.(loadRecorder); ok {
+			recorder.recordRefresh(err)
+		}
+
+		return value, err
+	})
+}
+
+func coalescedLoad(
+	ctx context.Context,
+	cch Cache,
+	key string,
+	fetch func(ctx context.Context) (any, error),
+	cached func() (any, bool),
+) (any, error) {
+	clsr, ok := cch.(coalescer)
+	if !ok {
+		return fetch(ctx)
+	}
+
+	for {
+		value, shared, err := clsr.coalesce(key, func() (any, error) {
+			// the entry might have been loaded by a just finished previous load
+			if value, ok := cached(); ok {
+				return value, nil
+			}
+
+			return fetch(ctx)
+		})
+
+		// if the load was done on behalf of a request, which has been canceled in the
+		// meantime, the current request has to load the value on its own.
+		if shared && ctx.Err() == nil &&
+			(errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)) {
+			continue
+		}
+
+		return value, err
+	}
+}
+
+func lookup[T any](ctx context.Context, cch Cache, key string) (T, bool) {
+	var value T
+
+	entry := cch.Get(ctx, key)
+	if entry == nil {
+		return value, false
+	}
+
+	value, ok := entry.(T)
+	if !ok {
+		zerolog.Ctx(ctx).Warn().Msg("Wrong object type from cache")
+		cch.Delete(ctx, key)
+
+		return value, false
+	}
+
+	zerolog.Ctx(ctx).Debug().Msg("Reusing entry from cache")
+
+	return value, true
+}
+
+func lookupTimed[T any](
+	ctx context.Context, cch Cache, key string, now time.Time, staleIfError time.Duration,
+) (T, time.Time, bool) {
+	var value T
+
+	entry, ok := lookup[*timedEntry](ctx, cch, key)
+	if !ok {
+		return value, time.Time{}, false
+	}
+
+	value, ok = entry.Value.(T)
+	if !ok {
+		zerolog.Ctx(ctx).Warn().Msg("Wrong object type from cache")
+		cch.Delete(ctx, key)
+
+		return value, time.Time{}, false
+	}
+
+	// the entry might be held by the cache longer than needed, e.g. if the grace period
+	// has been reduced in the meantime
+	if !now.Before(entry.FreshUntil.Add(staleIfError)) {
+		return value, time.Time{}, false
+	}
+
+	return value, entry.FreshUntil, true
+}
+
+// isUpstreamFailure returns true if the given error is caused by an unreachable or failing
+// upstream system. Errors resulting from a response of the upstream system, which is not a
+// server error (like an unauthorized request) must never result in a stale value being
+// served.
+func isUpstreamFailure(err error) bool {
+	if errors.Is(err, heimdall.ErrCommunicationTimeout) {
+		return true
+	}
+
+	if !errors.Is(err, heimdall.ErrCommunication) {
+		return false
+	}
+
+	var respErr *heimdall.UnexpectedResponseError
+	if errors.As(err, &respErr) {
+		return respErr.StatusCode >= http.StatusInternalServerError
+	}
+
+	return true
+}
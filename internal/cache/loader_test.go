@@ -0,0 +1,443 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/cache/memory"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+func TestGetOrLoad(t *testing.T) {
+	t.Parallel()
+
+	errTest := errors.New("test error")
+
+	for _, tc := range []struct {
+		uc        string
+		cached    any
+		loadValue string
+		loadTTL   time.Duration
+		loadErr   error
+		expValue  string
+		expErr    error
+		expLoads  int32
+		expCached any
+	}{
+		{
+			uc:        "value present in cache",
+			cached:    "foo",
+			loadValue: "bar",
+			loadTTL:   time.Minute,
+			expValue:  "foo",
+			expCached: "foo",
+		},
+		{
+			uc:        "value not present in cache",
+			loadValue: "bar",
+			loadTTL:   time.Minute,
+			expValue:  "bar",
+			expLoads:  1,
+			expCached: "bar",
+		},
+		{
+			uc:        "cached value has wrong type",
+			cached:    10,
+			loadValue: "bar",
+			loadTTL:   time.Minute,
+			expValue:  "bar",
+			expLoads:  1,
+			expCached: "bar",
+		},
+		{
+			uc:        "loaded value should not be cached",
+			loadValue: "bar",
+			expValue:  "bar",
+			expLoads:  1,
+		},
+		{
+			uc:       "load fails",
+			loadTTL:  time.Minute,
+			loadErr:  errTest,
+			expErr:   errTest,
+			expLoads: 1,
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			var loads int32
+
+			ctx := context.Background()
+			cch := newCoalescingCache(memory.New())
+
+			if tc.cached != nil {
+				cch.Set(ctx, "key", tc.cached, time.Minute)
+			}
+
+			// WHEN
+			value, err := GetOrLoad(ctx, cch, "key", func(_ context.Context) (string, time.Duration, error) {
+				atomic.AddInt32(&loads, 1)
+
+				return tc.loadValue, tc.loadTTL, tc.loadErr
+			})
+
+			// THEN
+			if tc.expErr != nil {
+				require.ErrorIs(t, err, tc.expErr)
+			} else {
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, tc.expValue, value)
+			assert.Equal(t, tc.expLoads, loads)
+			assert.Equal(t, tc.expCached, cch.Get(ctx, "key"))
+		})
+	}
+}
+
+func TestGetOrLoadCoalescesConcurrentLoads(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	var (
+		loads int32
+		wg    sync.WaitGroup
+	)
+
+	cch := newCoalescingCache(memory.New())
+	release := make(chan struct{})
+	results := make(chan string, 10)
+
+	// WHEN
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			value, err := GetOrLoad(context.Background(), cch, "key", func(_ context.Context) (string, time.Duration, error) {
+				atomic.AddInt32(&loads, 1)
+				<-release
+
+				return "foo", time.Minute, nil
+			})
+
+			assert.NoError(t, err)
+
+			results <- value
+		}()
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(results)
+
+	// THEN
+	assert.Equal(t, int32(1), loads)
+
+	for value := range results {
+		assert.Equal(t, "foo", value)
+	}
+}
+
+func TestGetOrLoadRetriesIfSharedLoadWasCanceled(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	cch := newCoalescingCache(memory.New())
+	leaderCtx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		_, err := GetOrLoad(leaderCtx, cch, "key", func(_ context.Context) (string, time.Duration, error) {
+			close(started)
+			<-leaderCtx.Done()
+
+			return "", 0, leaderCtx.Err()
+		})
+
+		assert.ErrorIs(t, err, context.Canceled)
+	}()
+
+	<-started
+
+	// WHEN
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		cancel()
+	}()
+
+	value, err := GetOrLoad(context.Background(), cch, "key", func(_ context.Context) (string, time.Duration, error) {
+		return "foo", time.Minute, nil
+	})
+
+	// THEN
+	<-done
+
+	require.NoError(t, err)
+	assert.Equal(t, "foo", value)
+}
+
+func TestGetOrLoadWithoutCoalescing(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	cch := memory.New()
+
+	// WHEN
+	value, err := GetOrLoad(context.Background(), cch, "key", func(_ context.Context) (*string, time.Duration, error) {
+		return nil, 0, errors.New("test error")
+	})
+
+	// THEN
+	require.Error(t, err)
+	assert.Nil(t, value)
+}
+
+func TestGetOrLoadWithStaleIfError(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc          string
+		freshUntil  time.Duration
+		loadValue   string
+		loadErr     error
+		expValue    string
+		expErr      error
+		expLoads    int32
+		expStale    uint64
+		expCachedAs string
+	}{
+		{
+			uc:          "fresh value present in cache",
+			freshUntil:  time.Minute,
+			loadValue:   "bar",
+			expValue:    "foo",
+			expCachedAs: "foo",
+		},
+		{
+			uc:          "expired value present in cache and load succeeds",
+			freshUntil:  -10 * time.Second,
+			loadValue:   "bar",
+			expValue:    "bar",
+			expLoads:    1,
+			expCachedAs: "bar",
+		},
+		{
+			uc:          "expired value present in cache and upstream is not reachable",
+			freshUntil:  -10 * time.Second,
+			loadErr:     errorchain.NewWithMessage(heimdall.ErrCommunication, "request failed"),
+			expValue:    "foo",
+			expLoads:    1,
+			expStale:    1,
+			expCachedAs: "foo",
+		},
+		{
+			uc:          "expired value present in cache and upstream does not respond in time",
+			freshUntil:  -10 * time.Second,
+			loadErr:     errorchain.New(heimdall.ErrCommunicationTimeout),
+			expValue:    "foo",
+			expLoads:    1,
+			expStale:    1,
+			expCachedAs: "foo",
+		},
+		{
+			uc:         "expired value present in cache and upstream responds with server error",
+			freshUntil: -10 * time.Second,
+			loadErr: errorchain.New(heimdall.ErrCommunication).
+				CausedBy(&heimdall.UnexpectedResponseError{StatusCode: 503}),
+			expValue:    "foo",
+			expLoads:    1,
+			expStale:    1,
+			expCachedAs: "foo",
+		},
+		{
+			uc:         "expired value present in cache and upstream responds with client error",
+			freshUntil: -10 * time.Second,
+			loadErr: errorchain.New(heimdall.ErrCommunication).
+				CausedBy(&heimdall.UnexpectedResponseError{StatusCode: 401}),
+			expErr:      heimdall.ErrCommunication,
+			expLoads:    1,
+			expCachedAs: "foo",
+		},
+		{
+			uc:          "expired value present in cache and load fails with not communication related error",
+			freshUntil:  -10 * time.Second,
+			loadErr:     errorchain.New(heimdall.ErrAuthentication),
+			expErr:      heimdall.ErrAuthentication,
+			expLoads:    1,
+			expCachedAs: "foo",
+		},
+		{
+			uc:          "value expired longer than the grace period present in cache",
+			freshUntil:  -2 * time.Minute,
+			loadErr:     errorchain.New(heimdall.ErrCommunicationTimeout),
+			expErr:      heimdall.ErrCommunicationTimeout,
+			expLoads:    1,
+			expCachedAs: "foo",
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			var loads int32
+
+			ctx := context.Background()
+			cch := newCoalescingCache(memory.New())
+			cch.Set(ctx, "key", &timedEntry{Value: "foo", FreshUntil: time.Now().Add(tc.freshUntil)}, time.Hour)
+
+			// WHEN
+			value, err := GetOrLoad(ctx, cch, "key", func(_ context.Context) (string, time.Duration, error) {
+				atomic.AddInt32(&loads, 1)
+
+				return tc.loadValue, time.Minute, tc.loadErr
+			}, WithStaleIfError(time.Minute))
+
+			// THEN
+			if tc.expErr != nil {
+				require.ErrorIs(t, err, tc.expErr)
+			} else {
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, tc.expValue, value)
+			assert.Equal(t, tc.expLoads, loads)
+			assert.Equal(t, tc.expStale, cch.LoadStatistics().StaleServed)
+
+			entry, ok := cch.Get(ctx, "key").(*timedEntry)
+			require.True(t, ok)
+			assert.Equal(t, tc.expCachedAs, entry.Value)
+		})
+	}
+}
+
+func TestGetOrLoadWithStaleIfErrorCachesLoadedValueForGracePeriod(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	ctx := context.Background()
+	cch := newCoalescingCache(memory.New())
+
+	// WHEN
+	value, err := GetOrLoad(ctx, cch, "key", func(_ context.Context) (string, time.Duration, error) {
+		return "foo", 50 * time.Millisecond, nil
+	}, WithStaleIfError(time.Minute))
+	require.NoError(t, err)
+
+	time.Sleep(100 * time.Millisecond)
+
+	stale, err := GetOrLoad(ctx, cch, "key", func(_ context.Context) (string, time.Duration, error) {
+		return "", 0, errorchain.New(heimdall.ErrCommunicationTimeout)
+	}, WithStaleIfError(time.Minute))
+
+	// THEN
+	require.NoError(t, err)
+	assert.Equal(t, "foo", value)
+	assert.Equal(t, "foo", stale)
+}
+
+func TestGetOrLoadWithRefreshAhead(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc            string
+		freshUntil    time.Duration
+		loadErr       error
+		expRefreshes  uint64
+		expFailed     uint64
+		expCachedAs   string
+		expRefreshing bool
+	}{
+		{
+			uc:          "value not close to expiry",
+			freshUntil:  time.Minute,
+			expCachedAs: "foo",
+		},
+		{
+			uc:            "value close to expiry is refreshed",
+			freshUntil:    5 * time.Second,
+			expRefreshes:  1,
+			expCachedAs:   "bar",
+			expRefreshing: true,
+		},
+		{
+			uc:            "refresh of value close to expiry fails",
+			freshUntil:    5 * time.Second,
+			loadErr:       errorchain.New(heimdall.ErrCommunication),
+			expFailed:     1,
+			expCachedAs:   "foo",
+			expRefreshing: true,
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			ctx, cancel := context.WithCancel(context.Background())
+			cch := newCoalescingCache(memory.New())
+			cch.Set(ctx, "key", &timedEntry{Value: "foo", FreshUntil: time.Now().Add(tc.freshUntil)}, time.Hour)
+
+			refreshed := make(chan error, 1)
+
+			// WHEN
+			value, err := GetOrLoad(ctx, cch, "key", func(loadCtx context.Context) (string, time.Duration, error) {
+				// the request is done before the refresh happens
+				<-ctx.Done()
+
+				refreshed <- loadCtx.Err()
+
+				return "bar", time.Minute, tc.loadErr
+			}, WithRefreshAhead(10*time.Second))
+
+			cancel()
+
+			// THEN
+			require.NoError(t, err)
+			assert.Equal(t, "foo", value)
+
+			if tc.expRefreshing {
+				select {
+				case err = <-refreshed:
+					require.NoError(t, err)
+				case <-time.After(time.Second):
+					t.Fatal("entry has not been refreshed")
+				}
+			}
+
+			assert.Eventually(t, func() bool {
+				stats := cch.LoadStatistics()
+
+				return stats.Refreshes == tc.expRefreshes && stats.FailedRefreshes == tc.expFailed
+			}, time.Second, 10*time.Millisecond)
+
+			entry, ok := cch.Get(context.Background(), "key").(*timedEntry)
+			require.True(t, ok)
+			assert.Equal(t, tc.expCachedAs, entry.Value)
+		})
+	}
+}
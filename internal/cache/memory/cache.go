@@ -1,4 +1,4 @@
-// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
 //
 // Licensed under the Apache License, Version 2.0 (the "License");
 // you may not use this file except in compliance with the License.
@@ -17,43 +17,366 @@
 package memory
 
 import (
+	"container/list"
 	"context"
+	"errors"
+	"sync"
 	"time"
 
-	"github.com/jellydator/ttlcache/v3"
+	"github.com/dadrus/heimdall/internal/cache/encoding"
 )
 
+const (
+	// entryOverhead is the approximated amount of memory used by an entry in addition
+	// to its key and value.
+	entryOverhead = 128
+	// valueSizeFallback is used as size of values, which size cannot be estimated.
+	valueSizeFallback = 512
+	// maxExpirationCheckInterval defines how long the expiration loop sleeps if there
+	// are no entries with a ttl.
+	maxExpirationCheckInterval = 1 * time.Hour
+)
+
+var ErrValueTooLarge = errors.New("value exceeds the memory budget of the cache")
+
+type entry struct {
+	key       string
+	value     any
+	size      int64
+	expiresAt time.Time
+
+	expIdx    int
+	lruElem   *list.Element
+	lfuIdx    int
+	frequency uint64
+	lastUsed  uint64
+}
+
+func (e *entry) expires() bool { return !e.expiresAt.IsZero() }
+
+// Statistics holds information about the usage of an InMemoryCache.
+type Statistics struct {
+	Hits        uint64
+	Misses      uint64
+	Evictions   uint64
+	Expirations uint64
+	Entries     int
+	// Size is the approximated amount of memory used by the entries. It is only
+	// tracked if the cache is configured with a memory budget and is 0 otherwise.
+	Size int64
+}
+
+// InMemoryCache is a cache.Cache implementation holding the entries in memory. If
+// configured with a maximum amount of entries and/or a memory budget, entries are
+// evicted according to the configured eviction policy whenever one of these limits
+// is exceeded.
 type InMemoryCache struct {
-	c *ttlcache.Cache[string, any]
+	mu         sync.Mutex
+	entries    map[string]*entry
+	expiration expirationQueue
+	policy     evictionPolicy
+	maxEntries int
+	maxSize    int64
+	stats      Statistics
+
+	wakeup chan struct{}
+	stop   chan struct{}
+	done   chan struct{}
 }
 
-func New() *InMemoryCache {
-	return &InMemoryCache{c: ttlcache.New[string, any](ttlcache.WithDisableTouchOnHit[string, any]())}
+func New(opts ...Option) *InMemoryCache {
+	cch := &InMemoryCache{
+		entries: make(map[string]*entry),
+		policy:  newLRUPolicy(),
+		wakeup:  make(chan struct{}, 1),
+	}
+
+	for _, opt := range opts {
+		opt(cch)
+	}
+
+	return cch
 }
 
 func (c *InMemoryCache) Start(_ context.Context) error {
-	go c.c.Start()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.stop != nil {
+		return nil
+	}
+
+	c.stop = make(chan struct{})
+	c.done = make(chan struct{})
+
+	go c.run(c.stop, c.done)
 
 	return nil
 }
 
 func (c *InMemoryCache) Stop(_ context.Context) error {
-	c.c.Stop()
+	c.mu.Lock()
+	stop, done := c.stop, c.done
+	c.stop, c.done = nil, nil
+	c.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-done
+	}
 
 	return nil
 }
 
 func (c *InMemoryCache) Get(_ context.Context, key string) any {
-	item := c.c.Get(key)
-	if item != nil && !item.IsExpired() {
-		return item.Value()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e := c.lookup(key); e != nil {
+		return e.value
 	}
 
 	return nil
 }
 
+// GetWithTTL returns the value of the entry together with its remaining time to live,
+// which is 0, if the entry does not expire.
+func (c *InMemoryCache) GetWithTTL(_ context.Context, key string) (any, time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e := c.lookup(key)
+	if e == nil {
+		return nil, 0
+	}
+
+	if !e.expires() {
+		return e.value, 0
+	}
+
+	return e.value, max(time.Until(e.expiresAt), time.Nanosecond)
+}
+
+func (c *InMemoryCache) lookup(key string) *entry {
+	e, ok := c.entries[key]
+	if ok && e.expires() && !time.Now().Before(e.expiresAt) {
+		c.stats.Expirations++
+		c.removeEntry(e)
+
+		ok = false
+	}
+
+	if !ok {
+		c.stats.Misses++
+
+		return nil
+	}
+
+	c.stats.Hits++
+	c.policy.touch(e)
+
+	return e
+}
+
 func (c *InMemoryCache) Set(_ context.Context, key string, value any, ttl time.Duration) {
-	c.c.Set(key, value, ttl)
+	size := c.sizeOf(key, value)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if existing, ok := c.entries[key]; ok {
+		c.removeEntry(existing)
+	}
+
+	c.store(key, value, size, ttl)
 }
 
-func (c *InMemoryCache) Delete(_ context.Context, key string) { c.c.Delete(key) }
+// SetIfAbsent stores the value only if there is no (not expired) entry for the given key
+// and reports whether the value has been stored.
+func (c *InMemoryCache) SetIfAbsent(_ context.Context, key string, value any, ttl time.Duration) (bool, error) {
+	size := c.sizeOf(key, value)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if existing, ok := c.entries[key]; ok {
+		if !existing.expires() || time.Now().Before(existing.expiresAt) {
+			return false, nil
+		}
+
+		c.stats.Expirations++
+		c.removeEntry(existing)
+	}
+
+	if !c.store(key, value, size, ttl) {
+		return false, ErrValueTooLarge
+	}
+
+	return true, nil
+}
+
+// Update replaces the entry for the given key with the value returned by the update function,
+// which is called while holding the lock of the cache.
+func (c *InMemoryCache) Update(_ context.Context, key string, update func(current any) (any, time.Duration)) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var current any
+
+	if existing, ok := c.entries[key]; ok {
+		if !existing.expires() || time.Now().Before(existing.expiresAt) {
+			current = existing.value
+		} else {
+			c.stats.Expirations++
+		}
+
+		c.removeEntry(existing)
+	}
+
+	value, ttl := update(current)
+
+	if !c.store(key, value, c.sizeOf(key, value), ttl) {
+		return ErrValueTooLarge
+	}
+
+	return nil
+}
+
+func (c *InMemoryCache) sizeOf(key string, value any) int64 {
+	if c.maxSize <= 0 {
+		// approximating the size might require marshalling of the value, which is
+		// only worth it if there is a memory budget to enforce
+		return 0
+	}
+
+	return sizeOf(key, value)
+}
+
+func (c *InMemoryCache) store(key string, value any, size int64, ttl time.Duration) bool {
+	if c.maxSize > 0 && size > c.maxSize {
+		// would evict everything else without fitting into the cache anyway
+		return false
+	}
+
+	// make room for the new entry first, so that it is not the one to be evicted
+	for c.exceedsLimits(1, size) {
+		c.stats.Evictions++
+		c.removeEntry(c.policy.victim())
+	}
+
+	e := &entry{key: key, value: value, size: size, expIdx: -1, lfuIdx: -1}
+	if ttl > 0 {
+		e.expiresAt = time.Now().Add(ttl)
+	}
+
+	c.entries[key] = e
+	c.stats.Size += size
+	c.policy.add(e)
+
+	if e.expires() {
+		c.expiration.push(e)
+
+		if c.expiration.first() == e {
+			select {
+			case c.wakeup <- struct{}{}:
+			default:
+			}
+		}
+	}
+
+	return true
+}
+
+func (c *InMemoryCache) Delete(_ context.Context, key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries[key]; ok {
+		c.removeEntry(e)
+	}
+}
+
+// Bounded returns true if the cache is configured with a maximum amount of entries and/or
+// a memory budget, so that entries may be evicted before they expire.
+func (c *InMemoryCache) Bounded() bool { return c.maxEntries > 0 || c.maxSize > 0 }
+
+// Statistics returns a snapshot of the usage statistics of the cache.
+func (c *InMemoryCache) Statistics() Statistics {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Entries = len(c.entries)
+
+	return stats
+}
+
+func (c *InMemoryCache) exceedsLimits(additionalEntries int, additionalSize int64) bool {
+	return (c.maxEntries > 0 && len(c.entries)+additionalEntries > c.maxEntries) ||
+		(c.maxSize > 0 && c.stats.Size+additionalSize > c.maxSize)
+}
+
+func (c *InMemoryCache) removeEntry(e *entry) {
+	delete(c.entries, e.key)
+	c.policy.remove(e)
+	c.stats.Size -= e.size
+
+	if e.expires() {
+		c.expiration.remove(e)
+	}
+}
+
+func (c *InMemoryCache) removeExpired(now time.Time) time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for {
+		e := c.expiration.first()
+		if e == nil {
+			return maxExpirationCheckInterval
+		}
+
+		if remaining := e.expiresAt.Sub(now); remaining > 0 {
+			return min(remaining, maxExpirationCheckInterval)
+		}
+
+		c.stats.Expirations++
+		c.removeEntry(e)
+	}
+}
+
+func (c *InMemoryCache) run(stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	for {
+		timer := time.NewTimer(c.removeExpired(time.Now()))
+
+		select {
+		case <-stop:
+			timer.Stop()
+
+			return
+		case <-c.wakeup:
+		case <-timer.C:
+		}
+
+		timer.Stop()
+	}
+}
+
+func sizeOf(key string, value any) int64 {
+	size := int64(len(key) + entryOverhead)
+
+	switch val := value.(type) {
+	case []byte:
+		return size + int64(len(val))
+	case string:
+		return size + int64(len(val))
+	default:
+		if data, err := encoding.Marshal(value); err == nil {
+			return size + int64(len(data))
+		}
+
+		return size + valueSizeFallback
+	}
+}
@@ -18,10 +18,15 @@ package memory
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/inhies/go-bytesize"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
 )
 
 func TestCacheUsage(t *testing.T) {
@@ -125,3 +130,307 @@ func TestCacheExpiration(t *testing.T) {
 
 	assert.LessOrEqual(t, hits, 4)
 }
+
+func TestCacheExpirationLoop(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	cache := New()
+	require.NoError(t, cache.Start(context.TODO()))
+
+	defer cache.Stop(context.TODO())
+
+	cache.Set(context.TODO(), "foo", "bar", 100*time.Millisecond)
+	cache.Set(context.TODO(), "bar", "baz", 0)
+
+	// WHEN
+	time.Sleep(300 * time.Millisecond)
+
+	// THEN
+	stats := cache.Statistics()
+	assert.Equal(t, 1, stats.Entries)
+	assert.Equal(t, uint64(1), stats.Expirations)
+	assert.Equal(t, "baz", cache.Get(context.TODO(), "bar"))
+}
+
+func TestCacheEviction(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc      string
+		opts    []Option
+		use     func(t *testing.T, cache *InMemoryCache)
+		present []string
+		absent  []string
+	}{
+		{
+			uc:   "max entries with lru policy",
+			opts: []Option{WithMaxEntries(2)},
+			use: func(t *testing.T, cache *InMemoryCache) {
+				t.Helper()
+
+				cache.Set(context.TODO(), "foo", "1", 10*time.Minute)
+				cache.Set(context.TODO(), "bar", "2", 10*time.Minute)
+				cache.Get(context.TODO(), "foo")
+				cache.Set(context.TODO(), "baz", "3", 10*time.Minute)
+			},
+			present: []string{"foo", "baz"},
+			absent:  []string{"bar"},
+		},
+		{
+			uc:   "max entries with lfu policy",
+			opts: []Option{WithMaxEntries(2), WithLFUEviction()},
+			use: func(t *testing.T, cache *InMemoryCache) {
+				t.Helper()
+
+				cache.Set(context.TODO(), "foo", "1", 10*time.Minute)
+				cache.Set(context.TODO(), "bar", "2", 10*time.Minute)
+				cache.Get(context.TODO(), "bar")
+				cache.Get(context.TODO(), "bar")
+				cache.Get(context.TODO(), "foo")
+				cache.Set(context.TODO(), "baz", "3", 10*time.Minute)
+			},
+			present: []string{"bar", "baz"},
+			absent:  []string{"foo"},
+		},
+		{
+			uc:   "max memory",
+			opts: []Option{WithMaxMemory(bytesize.ByteSize(2*entryOverhead + 100))},
+			use: func(t *testing.T, cache *InMemoryCache) {
+				t.Helper()
+
+				cache.Set(context.TODO(), "foo", make([]byte, 40), 10*time.Minute)
+				cache.Set(context.TODO(), "bar", make([]byte, 40), 10*time.Minute)
+				cache.Set(context.TODO(), "baz", make([]byte, 40), 10*time.Minute)
+			},
+			present: []string{"bar", "baz"},
+			absent:  []string{"foo"},
+		},
+		{
+			uc:   "value exceeding max memory",
+			opts: []Option{WithMaxMemory(bytesize.ByteSize(2 * entryOverhead))},
+			use: func(t *testing.T, cache *InMemoryCache) {
+				t.Helper()
+
+				cache.Set(context.TODO(), "foo", "bar", 10*time.Minute)
+				cache.Set(context.TODO(), "bar", make([]byte, 2*entryOverhead), 10*time.Minute)
+			},
+			present: []string{"foo"},
+			absent:  []string{"bar"},
+		},
+		{
+			uc:   "updating an entry does not evict others",
+			opts: []Option{WithMaxEntries(2)},
+			use: func(t *testing.T, cache *InMemoryCache) {
+				t.Helper()
+
+				cache.Set(context.TODO(), "foo", "1", 10*time.Minute)
+				cache.Set(context.TODO(), "bar", "2", 10*time.Minute)
+				cache.Set(context.TODO(), "foo", "3", 10*time.Minute)
+			},
+			present: []string{"foo", "bar"},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			cache := New(tc.opts...)
+
+			// WHEN
+			tc.use(t, cache)
+
+			// THEN
+			for _, key := range tc.present {
+				assert.NotNil(t, cache.Get(context.TODO(), key), key)
+			}
+
+			for _, key := range tc.absent {
+				assert.Nil(t, cache.Get(context.TODO(), key), key)
+			}
+
+			assert.Equal(t, len(tc.present), cache.Statistics().Entries)
+		})
+	}
+}
+
+func TestCacheStatistics(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	cache := New(WithMaxEntries(1))
+
+	// WHEN
+	cache.Set(context.TODO(), "foo", []byte("bar"), 10*time.Minute)
+	cache.Get(context.TODO(), "foo")
+	cache.Get(context.TODO(), "foo")
+	cache.Get(context.TODO(), "bar")
+	cache.Set(context.TODO(), "bar", "baz", 10*time.Minute)
+	cache.Set(context.TODO(), "baz", "foo", 1*time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	cache.Get(context.TODO(), "baz")
+
+	// THEN
+	assert.Equal(t, Statistics{Hits: 2, Misses: 2, Evictions: 2, Expirations: 1}, cache.Statistics())
+}
+
+func TestCacheGetWithTTL(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	cache := New()
+
+	cache.Set(context.TODO(), "expiring", "foo", 10*time.Minute)
+	cache.Set(context.TODO(), "not-expiring", "bar", 0)
+
+	// WHEN
+	expiringValue, expiringTTL := cache.GetWithTTL(context.TODO(), "expiring")
+	notExpiringValue, notExpiringTTL := cache.GetWithTTL(context.TODO(), "not-expiring")
+	missingValue, missingTTL := cache.GetWithTTL(context.TODO(), "missing")
+
+	// THEN
+	assert.Equal(t, "foo", expiringValue)
+	assert.InDelta(t, 10*time.Minute, expiringTTL, float64(time.Second))
+	assert.Equal(t, "bar", notExpiringValue)
+	assert.Zero(t, notExpiringTTL)
+	assert.Nil(t, missingValue)
+	assert.Zero(t, missingTTL)
+}
+
+func TestCacheSetIfAbsent(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	cache := New(WithMaxMemory(bytesize.ByteSize(entryOverhead + 10)))
+
+	// WHEN
+	stored1, err1 := cache.SetIfAbsent(context.TODO(), "foo", "bar", 10*time.Minute)
+	stored2, err2 := cache.SetIfAbsent(context.TODO(), "foo", "baz", 10*time.Minute)
+	stored3, err3 := cache.SetIfAbsent(context.TODO(), "large", strings.Repeat("x", 100), 10*time.Minute)
+
+	cache.Set(context.TODO(), "expired", "foo", 1*time.Nanosecond)
+	time.Sleep(time.Millisecond)
+
+	stored4, err4 := cache.SetIfAbsent(context.TODO(), "expired", "bar", 10*time.Minute)
+
+	// THEN
+	require.NoError(t, err1)
+	assert.True(t, stored1)
+	require.NoError(t, err2)
+	assert.False(t, stored2)
+	require.ErrorIs(t, err3, ErrValueTooLarge)
+	assert.False(t, stored3)
+	require.NoError(t, err4)
+	assert.True(t, stored4)
+	assert.Equal(t, "bar", cache.Get(context.TODO(), "expired"))
+}
+
+func TestCacheUpdate(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	cache := New(WithMaxMemory(bytesize.KB))
+	increment := func(current any) (any, time.Duration) {
+		value, _ := current.(string)
+
+		return value + "a", 10 * time.Minute
+	}
+
+	cache.Set(context.TODO(), "expired", "foo", 1*time.Nanosecond)
+	time.Sleep(time.Millisecond)
+
+	// WHEN
+	err1 := cache.Update(context.TODO(), "foo", increment)
+	err2 := cache.Update(context.TODO(), "foo", increment)
+	err3 := cache.Update(context.TODO(), "expired", increment)
+	err4 := cache.Update(context.TODO(), "large", func(_ any) (any, time.Duration) {
+		return strings.Repeat("x", 2048), 10 * time.Minute
+	})
+
+	// THEN
+	require.NoError(t, err1)
+	require.NoError(t, err2)
+	require.NoError(t, err3)
+	require.ErrorIs(t, err4, ErrValueTooLarge)
+	assert.Equal(t, "aa", cache.Get(context.TODO(), "foo"))
+	assert.Equal(t, "a", cache.Get(context.TODO(), "expired"))
+	assert.Nil(t, cache.Get(context.TODO(), "large"))
+}
+
+func TestCacheSizeTracking(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	unlimited := New()
+	limited := New(WithMaxMemory(bytesize.MB))
+
+	// WHEN
+	unlimited.Set(context.TODO(), "foo", []byte("bar"), 10*time.Minute)
+	limited.Set(context.TODO(), "foo", []byte("bar"), 10*time.Minute)
+
+	// THEN
+	assert.Zero(t, unlimited.Statistics().Size)
+	assert.Equal(t, int64(len("foo")+entryOverhead+len("bar")), limited.Statistics().Size)
+}
+
+func TestNewCache(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc     string
+		conf   map[string]any
+		assert func(t *testing.T, err error, cache *InMemoryCache)
+	}{
+		{
+			uc: "without configuration",
+			assert: func(t *testing.T, err error, cache *InMemoryCache) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Zero(t, cache.maxEntries)
+				assert.Zero(t, cache.maxSize)
+				assert.IsType(t, &lruPolicy{}, cache.policy)
+			},
+		},
+		{
+			uc:   "with full configuration",
+			conf: map[string]any{"max_entries": 10, "max_memory": "10MB", "eviction_policy": "lfu"},
+			assert: func(t *testing.T, err error, cache *InMemoryCache) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, 10, cache.maxEntries)
+				assert.Equal(t, int64(10*bytesize.MB), cache.maxSize)
+				assert.IsType(t, &lfuPolicy{}, cache.policy)
+			},
+		},
+		{
+			uc:   "with unsupported eviction policy",
+			conf: map[string]any{"eviction_policy": "foo"},
+			assert: func(t *testing.T, err error, _ *InMemoryCache) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "unsupported eviction policy")
+			},
+		},
+		{
+			uc:   "with unsupported properties",
+			conf: map[string]any{"foo": "bar"},
+			assert: func(t *testing.T, err error, _ *InMemoryCache) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed to decode")
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// WHEN
+			cache, err := NewCache(tc.conf)
+
+			// THEN
+			tc.assert(t, err, cache)
+		})
+	}
+}
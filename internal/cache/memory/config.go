@@ -0,0 +1,100 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package memory
+
+import (
+	"github.com/inhies/go-bytesize"
+	"github.com/mitchellh/mapstructure"
+
+	"github.com/dadrus/heimdall/internal/config"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+const (
+	EvictionPolicyLRU = "lru"
+	EvictionPolicyLFU = "lfu"
+)
+
+type Option func(c *InMemoryCache)
+
+// WithMaxEntries limits the amount of entries held by the cache. A value of 0
+// disables the limit.
+func WithMaxEntries(count int) Option {
+	return func(c *InMemoryCache) {
+		if count > 0 {
+			c.maxEntries = count
+		}
+	}
+}
+
+// WithMaxMemory limits the (approximated) amount of memory used by the cache entries.
+// A value of 0 disables the limit.
+func WithMaxMemory(size bytesize.ByteSize) Option {
+	return func(c *InMemoryCache) {
+		if size > 0 {
+			c.maxSize = int64(size)
+		}
+	}
+}
+
+// WithLFUEviction lets the cache evict the least frequently used entries first instead
+// of the least recently used ones.
+func WithLFUEviction() Option {
+	return func(c *InMemoryCache) {
+		c.policy = newLFUPolicy()
+	}
+}
+
+// NewCache creates an InMemoryCache based on the given configuration.
+func NewCache(rawConf map[string]any) (*InMemoryCache, error) {
+	type Config struct {
+		MaxEntries     int               `mapstructure:"max_entries"`
+		MaxMemory      bytesize.ByteSize `mapstructure:"max_memory"`
+		EvictionPolicy string            `mapstructure:"eviction_policy"`
+	}
+
+	var conf Config
+
+	dec, err := mapstructure.NewDecoder(
+		&mapstructure.DecoderConfig{
+			DecodeHook:  config.StringToByteSizeHookFunc(),
+			Result:      &conf,
+			ErrorUnused: true,
+		})
+	if err == nil {
+		err = dec.Decode(rawConf)
+	}
+
+	if err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"failed to decode in-memory cache config").CausedBy(err)
+	}
+
+	opts := []Option{WithMaxEntries(conf.MaxEntries), WithMaxMemory(conf.MaxMemory)}
+
+	switch conf.EvictionPolicy {
+	case "", EvictionPolicyLRU:
+	case EvictionPolicyLFU:
+		opts = append(opts, WithLFUEviction())
+	default:
+		return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+			"unsupported eviction policy %s for in-memory cache", conf.EvictionPolicy)
+	}
+
+	return New(opts...), nil
+}
@@ -0,0 +1,120 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package memory
+
+import (
+	"container/heap"
+	"container/list"
+)
+
+// evictionPolicy decides which entry has to be removed from the cache if it exceeds
+// its capacity.
+type evictionPolicy interface {
+	add(e *entry)
+	touch(e *entry)
+	remove(e *entry)
+	victim() *entry
+}
+
+// lruPolicy evicts the least recently used entry.
+type lruPolicy struct {
+	l *list.List
+}
+
+func newLRUPolicy() *lruPolicy { return &lruPolicy{l: list.New()} }
+
+func (p *lruPolicy) add(e *entry)    { e.lruElem = p.l.PushFront(e) }
+func (p *lruPolicy) touch(e *entry)  { p.l.MoveToFront(e.lruElem) }
+func (p *lruPolicy) remove(e *entry) { p.l.Remove(e.lruElem) }
+
+func (p *lruPolicy) victim() *entry {
+	if elem := p.l.Back(); elem != nil {
+		return elem.Value.(*entry) // nolint: forcetypeassert
+	}
+
+	return nil
+}
+
+// lfuPolicy evicts the least frequently used entry. If there are multiple entries with
+// the same usage frequency, the least recently used one among these is evicted.
+type lfuPolicy struct {
+	q    lfuQueue
+	tick uint64
+}
+
+func newLFUPolicy() *lfuPolicy { return &lfuPolicy{} }
+
+func (p *lfuPolicy) add(e *entry) {
+	p.tick++
+	e.frequency = 1
+	e.lastUsed = p.tick
+
+	heap.Push(&p.q, e)
+}
+
+func (p *lfuPolicy) touch(e *entry) {
+	p.tick++
+	e.frequency++
+	e.lastUsed = p.tick
+
+	heap.Fix(&p.q, e.lfuIdx)
+}
+
+func (p *lfuPolicy) remove(e *entry) { heap.Remove(&p.q, e.lfuIdx) }
+
+func (p *lfuPolicy) victim() *entry {
+	if len(p.q) == 0 {
+		return nil
+	}
+
+	return p.q[0]
+}
+
+type lfuQueue []*entry
+
+func (q lfuQueue) Len() int { return len(q) }
+
+func (q lfuQueue) Less(i, j int) bool {
+	if q[i].frequency != q[j].frequency {
+		return q[i].frequency < q[j].frequency
+	}
+
+	return q[i].lastUsed < q[j].lastUsed
+}
+
+func (q lfuQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].lfuIdx = i
+	q[j].lfuIdx = j
+}
+
+func (q *lfuQueue) Push(x any) {
+	e := x.(*entry) // nolint: forcetypeassert
+	e.lfuIdx = len(*q)
+	*q = append(*q, e)
+}
+
+func (q *lfuQueue) Pop() any {
+	old := *q
+	last := len(old) - 1
+	e := old[last]
+	old[last] = nil
+	e.lfuIdx = -1
+	*q = old[:last]
+
+	return e
+}
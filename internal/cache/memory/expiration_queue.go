@@ -0,0 +1,60 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package memory
+
+import "container/heap"
+
+// expirationQueue holds the entries having a ttl ordered by their expiration time.
+// The entry at index 0 expires first.
+type expirationQueue []*entry
+
+func (q *expirationQueue) push(e *entry)   { heap.Push(q, e) }
+func (q *expirationQueue) remove(e *entry) { heap.Remove(q, e.expIdx) }
+
+func (q expirationQueue) first() *entry {
+	if len(q) == 0 {
+		return nil
+	}
+
+	return q[0]
+}
+
+func (q expirationQueue) Len() int           { return len(q) }
+func (q expirationQueue) Less(i, j int) bool { return q[i].expiresAt.Before(q[j].expiresAt) }
+
+func (q expirationQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].expIdx = i
+	q[j].expIdx = j
+}
+
+func (q *expirationQueue) Push(x any) {
+	e := x.(*entry) // nolint: forcetypeassert
+	e.expIdx = len(*q)
+	*q = append(*q, e)
+}
+
+func (q *expirationQueue) Pop() any {
+	old := *q
+	last := len(old) - 1
+	e := old[last]
+	old[last] = nil
+	e.expIdx = -1
+	*q = old[:last]
+
+	return e
+}
@@ -137,6 +137,61 @@ func (_c *CacheMock_Set_Call) RunAndReturn(run func(context.Context, string, int
 	return _c
 }
 
+// SetIfAbsent provides a mock function with given fields: ctx, key, value, ttl
+func (_m *CacheMock) SetIfAbsent(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error) {
+	ret := _m.Called(ctx, key, value, ttl)
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, interface{}, time.Duration) (bool, error)); ok {
+		return rf(ctx, key, value, ttl)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, interface{}, time.Duration) bool); ok {
+		r0 = rf(ctx, key, value, ttl)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, interface{}, time.Duration) error); ok {
+		r1 = rf(ctx, key, value, ttl)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CacheMock_SetIfAbsent_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetIfAbsent'
+type CacheMock_SetIfAbsent_Call struct {
+	*mock.Call
+}
+
+// SetIfAbsent is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+//   - value interface{}
+//   - ttl time.Duration
+func (_e *CacheMock_Expecter) SetIfAbsent(ctx interface{}, key interface{}, value interface{}, ttl interface{}) *CacheMock_SetIfAbsent_Call {
+	return &CacheMock_SetIfAbsent_Call{Call: _e.mock.On("SetIfAbsent", ctx, key, value, ttl)}
+}
+
+func (_c *CacheMock_SetIfAbsent_Call) Run(run func(ctx context.Context, key string, value interface{}, ttl time.Duration)) *CacheMock_SetIfAbsent_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(interface{}), args[3].(time.Duration))
+	})
+	return _c
+}
+
+func (_c *CacheMock_SetIfAbsent_Call) Return(_a0 bool, _a1 error) *CacheMock_SetIfAbsent_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *CacheMock_SetIfAbsent_Call) RunAndReturn(run func(context.Context, string, interface{}, time.Duration) (bool, error)) *CacheMock_SetIfAbsent_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Start provides a mock function with given fields: ctx
 func (_m *CacheMock) Start(ctx context.Context) error {
 	ret := _m.Called(ctx)
@@ -221,6 +276,50 @@ func (_c *CacheMock_Stop_Call) RunAndReturn(run func(context.Context) error) *Ca
 	return _c
 }
 
+// Update provides a mock function with given fields: ctx, key, update
+func (_m *CacheMock) Update(ctx context.Context, key string, update func(interface{}) (interface{}, time.Duration)) error {
+	ret := _m.Called(ctx, key, update)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, func(interface{}) (interface{}, time.Duration)) error); ok {
+		r0 = rf(ctx, key, update)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CacheMock_Update_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Update'
+type CacheMock_Update_Call struct {
+	*mock.Call
+}
+
+// Update is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+//   - update func(interface{}) (interface{}, time.Duration)
+func (_e *CacheMock_Expecter) Update(ctx interface{}, key interface{}, update interface{}) *CacheMock_Update_Call {
+	return &CacheMock_Update_Call{Call: _e.mock.On("Update", ctx, key, update)}
+}
+
+func (_c *CacheMock_Update_Call) Run(run func(ctx context.Context, key string, update func(interface{}) (interface{}, time.Duration))) *CacheMock_Update_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(func(interface{}) (interface{}, time.Duration)))
+	})
+	return _c
+}
+
+func (_c *CacheMock_Update_Call) Return(_a0 error) *CacheMock_Update_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *CacheMock_Update_Call) RunAndReturn(run func(context.Context, string, func(interface{}) (interface{}, time.Duration)) error) *CacheMock_Update_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 type mockConstructorTestingTNewCacheMock interface {
 	mock.TestingT
 	Cleanup(func())
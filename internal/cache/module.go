@@ -18,31 +18,93 @@ package cache
 
 import (
 	"context"
+	"time"
 
 	"github.com/rs/zerolog"
 	"go.uber.org/fx"
 
 	"github.com/dadrus/heimdall/internal/cache/memory"
+	"github.com/dadrus/heimdall/internal/cache/redis"
 	"github.com/dadrus/heimdall/internal/config"
+	"github.com/dadrus/heimdall/internal/x"
 )
 
 //nolint:gochecknoglobals
 var Module = fx.Provide(
 	fx.Annotate(
-		newCache,
+		NewCache,
 		fx.OnStart(func(ctx context.Context, cch Cache) error { return cch.Start(ctx) }),
 		fx.OnStop(func(ctx context.Context, cch Cache) error { return cch.Stop(ctx) }),
 	),
 )
 
-func newCache(conf *config.Configuration, logger zerolog.Logger) Cache {
-	if len(conf.Cache.Type) == 0 {
+const defaultLocalCacheTTL = 10 * time.Second
+
+// NewCache creates the cache according to the given configuration.
+func NewCache(conf *config.Configuration, logger zerolog.Logger) (Cache, error) {
+	var (
+		cch  Cache
+		err  error
+		kind Kind
+	)
+
+	switch conf.Cache.Type {
+	case "", "in-memory":
 		logger.Info().Msg("Instantiating in memory cache")
 
-		return memory.New()
+		cch, err = memory.NewCache(conf.Cache.Config)
+		kind = KindLocal
+	case "redis":
+		logger.Info().Msg("Instantiating redis cache")
+
+		cch, err = redis.NewStandaloneCache(conf.Cache.Config)
+		kind = KindShared
+	case "redis-cluster":
+		logger.Info().Msg("Instantiating redis cluster cache")
+
+		cch, err = redis.NewClusterCache(conf.Cache.Config)
+		kind = KindShared
+	case "redis-sentinel":
+		logger.Info().Msg("Instantiating redis sentinel cache")
+
+		cch, err = redis.NewSentinelCache(conf.Cache.Config)
+		kind = KindShared
+	case "noop":
+		logger.Info().Msg("Cache is disabled")
+
+		cch = noopCache{}
+		kind = KindDisabled
+	default:
+		// previous versions disabled the cache for any type other than the default one.
+		// This behavior is kept to not break existing configurations.
+		logger.Warn().Str("_type", conf.Cache.Type).
+			Msg("Unsupported cache type. Cache is disabled. Use \"noop\" to disable the cache explicitly")
+
+		cch = noopCache{}
+		kind = KindDisabled
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if localConf := conf.Cache.Local; localConf != nil {
+		if kind != KindShared {
+			logger.Warn().Msg("Local cache is only supported in front of a shared cache. Ignoring its configuration")
+		} else {
+			logger.Info().Msg("Instantiating local in memory cache in front of the shared one")
+
+			local, err := memory.NewCache(localConf.Config)
+			if err != nil {
+				return nil, err
+			}
+
+			cch = newTieredCache(local, cch, x.IfThenElse(localConf.TTL > 0, localConf.TTL, defaultLocalCacheTTL))
+		}
 	}
 
-	logger.Info().Msg("Cache is disabled")
+	coalescing := newCoalescingCache(cch)
+	coalescing.kind = kind
 
-	return noopCache{}
+	return coalescing, nil
 }
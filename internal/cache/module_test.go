@@ -18,12 +18,16 @@ package cache
 
 import (
 	"testing"
+	"time"
 
 	"github.com/rs/zerolog/log"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/dadrus/heimdall/internal/cache/memory"
+	"github.com/dadrus/heimdall/internal/cache/redis"
 	"github.com/dadrus/heimdall/internal/config"
+	"github.com/dadrus/heimdall/internal/heimdall"
 )
 
 func TestNewCache(t *testing.T) {
@@ -32,33 +36,196 @@ func TestNewCache(t *testing.T) {
 	for _, tc := range []struct {
 		uc     string
 		conf   *config.Configuration
-		assert func(t *testing.T, cch Cache)
+		assert func(t *testing.T, err error, cch Cache)
 	}{
 		{
 			uc:   "in memory cache",
 			conf: &config.Configuration{},
-			assert: func(t *testing.T, cch Cache) {
+			assert: func(t *testing.T, err error, cch Cache) {
 				t.Helper()
 
-				assert.IsType(t, &memory.InMemoryCache{}, cch)
+				require.NoError(t, err)
+				require.IsType(t, &coalescingCache{}, cch)
+				assert.IsType(t, &memory.InMemoryCache{}, cch.(*coalescingCache).Cache) //nolint:forcetypeassert
+				assert.Equal(t, KindLocal, KindOf(cch))
+				assert.False(t, EvictsEntries(cch))
 			},
 		},
 		{
-			uc:   "disabled cache",
+			uc: "in memory cache with limits",
+			conf: &config.Configuration{Cache: config.CacheConfig{
+				Type:   "in-memory",
+				Config: map[string]any{"max_entries": 10},
+			}},
+			assert: func(t *testing.T, err error, cch Cache) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, KindLocal, KindOf(cch))
+				assert.True(t, EvictsEntries(cch))
+			},
+		},
+		{
+			uc:   "explicitly configured in memory cache",
+			conf: &config.Configuration{Cache: config.CacheConfig{Type: "in-memory"}},
+			assert: func(t *testing.T, err error, cch Cache) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.IsType(t, &coalescingCache{}, cch)
+				assert.IsType(t, &memory.InMemoryCache{}, cch.(*coalescingCache).Cache) //nolint:forcetypeassert
+			},
+		},
+		{
+			uc:   "bad in memory cache configuration",
+			conf: &config.Configuration{Cache: config.CacheConfig{Type: "in-memory", Config: map[string]any{"max_entries": "foo"}}},
+			assert: func(t *testing.T, err error, _ Cache) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+			},
+		},
+		{
+			uc:   "redis cache",
+			conf: &config.Configuration{Cache: config.CacheConfig{Type: "redis", Config: map[string]any{"address": "foo:6379"}}},
+			assert: func(t *testing.T, err error, cch Cache) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.IsType(t, &coalescingCache{}, cch)
+				assert.IsType(t, &redis.Cache{}, cch.(*coalescingCache).Cache) //nolint:forcetypeassert
+				assert.Equal(t, KindShared, KindOf(cch))
+			},
+		},
+		{
+			uc:   "redis cluster cache",
+			conf: &config.Configuration{Cache: config.CacheConfig{Type: "redis-cluster", Config: map[string]any{"nodes": []string{"foo:6379"}}}},
+			assert: func(t *testing.T, err error, cch Cache) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.IsType(t, &coalescingCache{}, cch)
+				assert.IsType(t, &redis.Cache{}, cch.(*coalescingCache).Cache) //nolint:forcetypeassert
+			},
+		},
+		{
+			uc: "redis sentinel cache",
+			conf: &config.Configuration{Cache: config.CacheConfig{
+				Type:   "redis-sentinel",
+				Config: map[string]any{"nodes": []string{"foo:26379"}, "master": "bar"},
+			}},
+			assert: func(t *testing.T, err error, cch Cache) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.IsType(t, &coalescingCache{}, cch)
+				assert.IsType(t, &redis.Cache{}, cch.(*coalescingCache).Cache) //nolint:forcetypeassert
+			},
+		},
+		{
+			uc: "redis cache with local cache",
+			conf: &config.Configuration{Cache: config.CacheConfig{
+				Type:   "redis",
+				Config: map[string]any{"address": "foo:6379"},
+				Local:  &config.LocalCacheConfig{Config: map[string]any{"max_entries": 10}},
+			}},
+			assert: func(t *testing.T, err error, cch Cache) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.IsType(t, &coalescingCache{}, cch)
+
+				tiered := cch.(*coalescingCache).Cache //nolint:forcetypeassert
+				require.IsType(t, &tieredCache{}, tiered)
+				assert.IsType(t, &memory.InMemoryCache{}, tiered.(*tieredCache).local) //nolint:forcetypeassert
+				assert.IsType(t, &redis.Cache{}, tiered.(*tieredCache).shared)         //nolint:forcetypeassert
+				assert.Equal(t, defaultLocalCacheTTL, tiered.(*tieredCache).ttl)       //nolint:forcetypeassert
+				assert.Equal(t, KindShared, KindOf(cch))
+				assert.False(t, EvictsEntries(cch))
+			},
+		},
+		{
+			uc: "redis cache with bad local cache configuration",
+			conf: &config.Configuration{Cache: config.CacheConfig{
+				Type:   "redis",
+				Config: map[string]any{"address": "foo:6379"},
+				Local:  &config.LocalCacheConfig{Config: map[string]any{"foo": "bar"}},
+			}},
+			assert: func(t *testing.T, err error, _ Cache) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+			},
+		},
+		{
+			uc: "local cache is ignored for in memory cache",
+			conf: &config.Configuration{Cache: config.CacheConfig{
+				Local: &config.LocalCacheConfig{TTL: 1 * time.Minute},
+			}},
+			assert: func(t *testing.T, err error, cch Cache) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.IsType(t, &coalescingCache{}, cch)
+				assert.IsType(t, &memory.InMemoryCache{}, cch.(*coalescingCache).Cache) //nolint:forcetypeassert
+			},
+		},
+		{
+			uc:   "bad redis cache configuration",
+			conf: &config.Configuration{Cache: config.CacheConfig{Type: "redis"}},
+			assert: func(t *testing.T, err error, _ Cache) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+			},
+		},
+		{
+			uc:   "unsupported cache type",
 			conf: &config.Configuration{Cache: config.CacheConfig{Type: "foo"}},
-			assert: func(t *testing.T, cch Cache) {
+			assert: func(t *testing.T, err error, cch Cache) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.IsType(t, &coalescingCache{}, cch)
+				assert.IsType(t, noopCache{}, cch.(*coalescingCache).Cache) //nolint:forcetypeassert
+				assert.Equal(t, KindDisabled, KindOf(cch))
+			},
+		},
+		{
+			uc:   "disabled cache",
+			conf: &config.Configuration{Cache: config.CacheConfig{Type: "noop"}},
+			assert: func(t *testing.T, err error, cch Cache) {
 				t.Helper()
 
-				assert.IsType(t, noopCache{}, cch)
+				require.NoError(t, err)
+				require.IsType(t, &coalescingCache{}, cch)
+				assert.IsType(t, noopCache{}, cch.(*coalescingCache).Cache) //nolint:forcetypeassert
+				assert.Equal(t, KindDisabled, KindOf(cch))
 			},
 		},
 	} {
 		t.Run("case="+tc.uc, func(t *testing.T) {
 			// WHEN
-			cch := newCache(tc.conf, log.Logger)
+			cch, err := NewCache(tc.conf, log.Logger)
 
 			// THEN
-			tc.assert(t, cch)
+			tc.assert(t, err, cch)
 		})
 	}
 }
+
+func TestKindOfCacheNotCreatedByNewCache(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, KindLocal, KindOf(memory.New()))
+}
+
+func TestEvictsEntriesOfCacheNotCreatedByNewCache(t *testing.T) {
+	t.Parallel()
+
+	assert.False(t, EvictsEntries(memory.New()))
+	assert.True(t, EvictsEntries(memory.New(memory.WithMaxEntries(10))))
+}
@@ -28,3 +28,11 @@ func (noopCache) Set(_ context.Context, _ string, _ any, _ time.Duration) {}
 func (noopCache) Delete(_ context.Context, _ string)                      {}
 func (noopCache) Start(_ context.Context) error                           { return nil }
 func (noopCache) Stop(_ context.Context) error                            { return nil }
+
+func (noopCache) SetIfAbsent(_ context.Context, _ string, _ any, _ time.Duration) (bool, error) {
+	return false, ErrUnsupportedOperation
+}
+
+func (noopCache) Update(_ context.Context, _ string, _ func(current any) (any, time.Duration)) error {
+	return ErrUnsupportedOperation
+}
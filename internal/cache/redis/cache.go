@@ -0,0 +1,280 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package redis
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+
+	"github.com/dadrus/heimdall/internal/cache/encoding"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+// maxUpdateAttempts limits the number of attempts of an optimistic transaction done by Update.
+const maxUpdateAttempts = 10
+
+// Cache is a cache.Cache implementation backed by a standalone redis server, a redis
+// cluster, or a redis deployment managed by redis sentinel. The values are stored in
+// the serialized form created by the encoding package, so that these can be shared
+// between multiple heimdall instances.
+type Cache struct {
+	c goredis.UniversalClient
+}
+
+func NewStandaloneCache(rawConf map[string]any) (*Cache, error) {
+	var conf standaloneConfig
+	if err := decodeConfig(rawConf, &conf); err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"failed to decode redis cache config").CausedBy(err)
+	}
+
+	if len(conf.Address) == 0 {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"no address configured for redis cache")
+	}
+
+	tlsConf, err := conf.TLS.toTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Cache{
+		c: goredis.NewClient(&goredis.Options{
+			Addr:      conf.Address,
+			DB:        conf.DB,
+			Username:  conf.Credentials.Username,
+			Password:  conf.Credentials.Password,
+			TLSConfig: tlsConf,
+		}),
+	}, nil
+}
+
+func NewClusterCache(rawConf map[string]any) (*Cache, error) {
+	var conf clusterConfig
+	if err := decodeConfig(rawConf, &conf); err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"failed to decode redis-cluster cache config").CausedBy(err)
+	}
+
+	if len(conf.Nodes) == 0 {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"no nodes configured for redis-cluster cache")
+	}
+
+	tlsConf, err := conf.TLS.toTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Cache{
+		c: goredis.NewClusterClient(&goredis.ClusterOptions{
+			Addrs:     conf.Nodes,
+			Username:  conf.Credentials.Username,
+			Password:  conf.Credentials.Password,
+			TLSConfig: tlsConf,
+		}),
+	}, nil
+}
+
+func NewSentinelCache(rawConf map[string]any) (*Cache, error) {
+	var conf sentinelConfig
+	if err := decodeConfig(rawConf, &conf); err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"failed to decode redis-sentinel cache config").CausedBy(err)
+	}
+
+	if len(conf.Nodes) == 0 {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"no nodes configured for redis-sentinel cache")
+	}
+
+	if len(conf.Master) == 0 {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"no master configured for redis-sentinel cache")
+	}
+
+	tlsConf, err := conf.TLS.toTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Cache{
+		c: goredis.NewFailoverClient(&goredis.FailoverOptions{
+			MasterName:    conf.Master,
+			SentinelAddrs: conf.Nodes,
+			DB:            conf.DB,
+			Username:      conf.Credentials.Username,
+			Password:      conf.Credentials.Password,
+			TLSConfig:     tlsConf,
+		}),
+	}, nil
+}
+
+func (c *Cache) Start(ctx context.Context) error {
+	if err := c.c.Ping(ctx).Err(); err != nil {
+		return errorchain.NewWithMessage(heimdall.ErrInternal, "failed to connect to redis").CausedBy(err)
+	}
+
+	return nil
+}
+
+func (c *Cache) Stop(_ context.Context) error { return c.c.Close() }
+
+func (c *Cache) Get(ctx context.Context, key string) any {
+	data, err := c.c.Get(ctx, key).Bytes()
+	if err != nil {
+		if !errors.Is(err, goredis.Nil) {
+			zerolog.Ctx(ctx).Warn().Err(err).Msg("Failed to retrieve entry from redis cache")
+		}
+
+		return nil
+	}
+
+	value, err := encoding.Unmarshal(data)
+	if err != nil {
+		zerolog.Ctx(ctx).Warn().Err(err).Msg("Failed to decode entry retrieved from redis cache")
+
+		return nil
+	}
+
+	return value
+}
+
+// GetWithTTL returns the value of the entry together with its remaining time to live,
+// which is 0, if the entry does not expire. Both are retrieved in a single round trip.
+func (c *Cache) GetWithTTL(ctx context.Context, key string) (any, time.Duration) {
+	var (
+		get *goredis.StringCmd
+		ttl *goredis.DurationCmd
+	)
+
+	_, err := c.c.Pipelined(ctx, func(pipe goredis.Pipeliner) error {
+		get = pipe.Get(ctx, key)
+		ttl = pipe.PTTL(ctx, key)
+
+		return nil
+	})
+	if err != nil {
+		if !errors.Is(err, goredis.Nil) {
+			zerolog.Ctx(ctx).Warn().Err(err).Msg("Failed to retrieve entry from redis cache")
+		}
+
+		return nil, 0
+	}
+
+	value, err := encoding.Unmarshal([]byte(get.Val()))
+	if err != nil {
+		zerolog.Ctx(ctx).Warn().Err(err).Msg("Failed to decode entry retrieved from redis cache")
+
+		return nil, 0
+	}
+
+	// negative values mean, the key does not expire (-1), or has expired meanwhile (-2)
+	remaining := ttl.Val()
+	if remaining == -2 {
+		return nil, 0
+	}
+
+	return value, max(remaining, 0)
+}
+
+func (c *Cache) Set(ctx context.Context, key string, value any, ttl time.Duration) {
+	data, err := encoding.Marshal(value)
+	if err != nil {
+		zerolog.Ctx(ctx).Warn().Err(err).Msg("Failed to encode entry for redis cache")
+
+		return
+	}
+
+	if err = c.c.Set(ctx, key, data, ttl).Err(); err != nil {
+		zerolog.Ctx(ctx).Warn().Err(err).Msg("Failed to store entry in redis cache")
+	}
+}
+
+// SetIfAbsent stores the value using SET NX, so that only one of the concurrent callers,
+// regardless of the heimdall instance, succeeds.
+func (c *Cache) SetIfAbsent(ctx context.Context, key string, value any, ttl time.Duration) (bool, error) {
+	data, err := encoding.Marshal(value)
+	if err != nil {
+		return false, err
+	}
+
+	stored, err := c.c.SetNX(ctx, key, data, ttl).Result()
+	if err != nil {
+		return false, errorchain.NewWithMessage(heimdall.ErrInternal, "failed to store entry in redis cache").
+			CausedBy(err)
+	}
+
+	return stored, nil
+}
+
+// Update uses an optimistic transaction (WATCH/MULTI/EXEC) to replace the entry, which is
+// retried if the entry has been modified concurrently, regardless of the heimdall instance.
+func (c *Cache) Update(ctx context.Context, key string, update func(current any) (any, time.Duration)) error {
+	txf := func(tx *goredis.Tx) error {
+		var current any
+
+		data, err := tx.Get(ctx, key).Bytes()
+		if err == nil {
+			// entries, which can't be decoded, are treated as absent and overwritten
+			current, _ = encoding.Unmarshal(data)
+		} else if !errors.Is(err, goredis.Nil) {
+			return err
+		}
+
+		value, ttl := update(current)
+
+		encoded, err := encoding.Marshal(value)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+			pipe.Set(ctx, key, encoded, ttl)
+
+			return nil
+		})
+
+		return err
+	}
+
+	for attempt := 0; attempt < maxUpdateAttempts; attempt++ {
+		err := c.c.Watch(ctx, txf, key)
+		if err == nil {
+			return nil
+		}
+
+		if !errors.Is(err, goredis.TxFailedErr) {
+			return errorchain.NewWithMessage(heimdall.ErrInternal, "failed to update entry in redis cache").
+				CausedBy(err)
+		}
+	}
+
+	return errorchain.NewWithMessage(heimdall.ErrInternal,
+		"failed to update entry in redis cache due to too many concurrent updates")
+}
+
+func (c *Cache) Delete(ctx context.Context, key string) {
+	if err := c.c.Del(ctx, key).Err(); err != nil {
+		zerolog.Ctx(ctx).Warn().Err(err).Msg("Failed to delete entry from redis cache")
+	}
+}
@@ -0,0 +1,469 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/cache/encoding"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/x"
+)
+
+type testValue struct {
+	Foo string `json:"foo"`
+}
+
+//nolint:gochecknoinits
+func init() {
+	encoding.RegisterType[*testValue]("redis.test_value")
+}
+
+func TestNewCache(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc     string
+		create func(conf map[string]any) (*Cache, error)
+		conf   map[string]any
+		assert func(t *testing.T, err error, cch *Cache)
+	}{
+		{
+			uc:     "standalone with unsupported properties",
+			create: NewStandaloneCache,
+			conf:   map[string]any{"address": "foo:6379", "foo": "bar"},
+			assert: func(t *testing.T, err error, _ *Cache) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed to decode")
+			},
+		},
+		{
+			uc:     "standalone without address",
+			create: NewStandaloneCache,
+			conf:   map[string]any{"db": 1},
+			assert: func(t *testing.T, err error, _ *Cache) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "no address")
+			},
+		},
+		{
+			uc:     "standalone with not existing key store",
+			create: NewStandaloneCache,
+			conf: map[string]any{
+				"address": "foo:6379",
+				"tls":     map[string]any{"key_store": map[string]any{"path": "/no/such/file.pem"}},
+			},
+			assert: func(t *testing.T, err error, _ *Cache) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed loading keystore")
+			},
+		},
+		{
+			uc:     "standalone with full configuration",
+			create: NewStandaloneCache,
+			conf: map[string]any{
+				"address":     "foo:6379",
+				"db":          2,
+				"credentials": map[string]any{"username": "foo", "password": "bar"},
+				"tls":         map[string]any{"min_version": "TLS1.2"},
+			},
+			assert: func(t *testing.T, err error, cch *Cache) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, cch)
+			},
+		},
+		{
+			uc:     "cluster without nodes",
+			create: NewClusterCache,
+			conf:   map[string]any{"credentials": map[string]any{"username": "foo", "password": "bar"}},
+			assert: func(t *testing.T, err error, _ *Cache) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "no nodes")
+			},
+		},
+		{
+			uc:     "cluster with nodes",
+			create: NewClusterCache,
+			conf:   map[string]any{"nodes": "foo:6379,bar:6379"},
+			assert: func(t *testing.T, err error, cch *Cache) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, cch)
+			},
+		},
+		{
+			uc:     "sentinel without nodes",
+			create: NewSentinelCache,
+			conf:   map[string]any{"master": "foo"},
+			assert: func(t *testing.T, err error, _ *Cache) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "no nodes")
+			},
+		},
+		{
+			uc:     "sentinel without master",
+			create: NewSentinelCache,
+			conf:   map[string]any{"nodes": []string{"foo:26379"}},
+			assert: func(t *testing.T, err error, _ *Cache) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "no master")
+			},
+		},
+		{
+			uc:     "sentinel with nodes and master",
+			create: NewSentinelCache,
+			conf:   map[string]any{"nodes": []string{"foo:26379"}, "master": "foo", "db": 1},
+			assert: func(t *testing.T, err error, cch *Cache) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, cch)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// WHEN
+			cch, err := tc.create(tc.conf)
+
+			// THEN
+			tc.assert(t, err, cch)
+		})
+	}
+}
+
+func TestCacheUsage(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	srv := miniredis.RunT(t)
+	srv.RequireAuth("secret")
+
+	cch, err := NewStandaloneCache(map[string]any{
+		"address":     srv.Addr(),
+		"credentials": map[string]any{"password": "secret"},
+		"tls":         map[string]any{"disabled": true},
+	})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	require.NoError(t, cch.Start(ctx))
+	defer cch.Stop(ctx)
+
+	for _, tc := range []struct {
+		uc     string
+		key    string
+		value  any
+		action func(t *testing.T, cch *Cache, key string)
+		assert func(t *testing.T, value any)
+	}{
+		{
+			uc:    "retrieve bytes",
+			key:   "bytes",
+			value: []byte("foo"),
+			assert: func(t *testing.T, value any) {
+				t.Helper()
+
+				assert.Equal(t, []byte("foo"), value)
+			},
+		},
+		{
+			uc:    "retrieve string",
+			key:   "string",
+			value: "foo",
+			assert: func(t *testing.T, value any) {
+				t.Helper()
+
+				assert.Equal(t, "foo", value)
+			},
+		},
+		{
+			uc:    "retrieve registered type",
+			key:   "struct",
+			value: &testValue{Foo: "bar"},
+			assert: func(t *testing.T, value any) {
+				t.Helper()
+
+				assert.Equal(t, &testValue{Foo: "bar"}, value)
+			},
+		},
+		{
+			uc:    "value of not registered type is not stored",
+			key:   "int",
+			value: 10,
+			assert: func(t *testing.T, value any) {
+				t.Helper()
+
+				assert.Nil(t, value)
+				assert.False(t, srv.Exists("int"))
+			},
+		},
+		{
+			uc:    "retrieve expired value",
+			key:   "expired",
+			value: "foo",
+			action: func(t *testing.T, _ *Cache, _ string) {
+				t.Helper()
+
+				srv.FastForward(2 * time.Minute)
+			},
+			assert: func(t *testing.T, value any) {
+				t.Helper()
+
+				assert.Nil(t, value)
+			},
+		},
+		{
+			uc:    "retrieve deleted value",
+			key:   "deleted",
+			value: "foo",
+			action: func(t *testing.T, cch *Cache, key string) {
+				t.Helper()
+
+				cch.Delete(context.Background(), key)
+			},
+			assert: func(t *testing.T, value any) {
+				t.Helper()
+
+				assert.Nil(t, value)
+			},
+		},
+		{
+			uc:    "retrieve value not stored by heimdall",
+			key:   "foreign",
+			value: "foo",
+			action: func(t *testing.T, _ *Cache, key string) {
+				t.Helper()
+
+				require.NoError(t, srv.Set(key, "bar"))
+			},
+			assert: func(t *testing.T, value any) {
+				t.Helper()
+
+				assert.Nil(t, value)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			action := x.IfThenElse(tc.action != nil, tc.action, func(t *testing.T, _ *Cache, _ string) { t.Helper() })
+
+			cch.Set(ctx, tc.key, tc.value, 1*time.Minute)
+			action(t, cch, tc.key)
+
+			// WHEN
+			value := cch.Get(ctx, tc.key)
+
+			// THEN
+			tc.assert(t, value)
+		})
+	}
+}
+
+func TestCacheGetWithTTL(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	srv := miniredis.RunT(t)
+
+	cch, err := NewStandaloneCache(map[string]any{"address": srv.Addr(), "tls": map[string]any{"disabled": true}})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	require.NoError(t, cch.Start(ctx))
+	defer cch.Stop(ctx)
+
+	cch.Set(ctx, "expiring", "foo", 1*time.Minute)
+	cch.Set(ctx, "not-expiring", "bar", 0)
+	require.NoError(t, srv.Set("foreign", "baz"))
+
+	srv.FastForward(20 * time.Second)
+
+	// WHEN
+	expiringValue, expiringTTL := cch.GetWithTTL(ctx, "expiring")
+	notExpiringValue, notExpiringTTL := cch.GetWithTTL(ctx, "not-expiring")
+	foreignValue, foreignTTL := cch.GetWithTTL(ctx, "foreign")
+	missingValue, missingTTL := cch.GetWithTTL(ctx, "missing")
+
+	// THEN
+	assert.Equal(t, "foo", expiringValue)
+	assert.Equal(t, 40*time.Second, expiringTTL)
+	assert.Equal(t, "bar", notExpiringValue)
+	assert.Zero(t, notExpiringTTL)
+	assert.Nil(t, foreignValue)
+	assert.Zero(t, foreignTTL)
+	assert.Nil(t, missingValue)
+	assert.Zero(t, missingTTL)
+}
+
+func TestCacheSetIfAbsent(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	srv := miniredis.RunT(t)
+
+	cch, err := NewStandaloneCache(map[string]any{"address": srv.Addr(), "tls": map[string]any{"disabled": true}})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	require.NoError(t, cch.Start(ctx))
+	defer cch.Stop(ctx)
+
+	// WHEN
+	stored1, err1 := cch.SetIfAbsent(ctx, "foo", "bar", 1*time.Minute)
+	stored2, err2 := cch.SetIfAbsent(ctx, "foo", "baz", 1*time.Minute)
+	_, err3 := cch.SetIfAbsent(ctx, "int", 10, 1*time.Minute)
+
+	srv.Close()
+
+	_, err4 := cch.SetIfAbsent(ctx, "bar", "foo", 1*time.Minute)
+
+	// THEN
+	require.NoError(t, err1)
+	assert.True(t, stored1)
+	require.NoError(t, err2)
+	assert.False(t, stored2)
+	require.Error(t, err3)
+	require.Error(t, err4)
+	require.ErrorIs(t, err4, heimdall.ErrInternal)
+}
+
+func TestCacheUpdate(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	srv := miniredis.RunT(t)
+
+	cch, err := NewStandaloneCache(map[string]any{"address": srv.Addr(), "tls": map[string]any{"disabled": true}})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	require.NoError(t, cch.Start(ctx))
+	defer cch.Stop(ctx)
+
+	increment := func(current any) (any, time.Duration) {
+		value, _ := current.(*testValue)
+		if value == nil {
+			return &testValue{Foo: "a"}, 1 * time.Minute
+		}
+
+		return &testValue{Foo: value.Foo + "a"}, 1 * time.Minute
+	}
+
+	require.NoError(t, srv.Set("garbage", "not decodable"))
+
+	// WHEN
+	err1 := cch.Update(ctx, "foo", increment)
+	err2 := cch.Update(ctx, "foo", increment)
+	err3 := cch.Update(ctx, "garbage", increment)
+	err4 := cch.Update(ctx, "int", func(_ any) (any, time.Duration) { return 10, 1 * time.Minute })
+	value1 := cch.Get(ctx, "foo")
+	value2 := cch.Get(ctx, "garbage")
+
+	srv.Close()
+
+	err5 := cch.Update(ctx, "foo", increment)
+
+	// THEN
+	require.NoError(t, err1)
+	require.NoError(t, err2)
+	require.NoError(t, err3)
+	require.Error(t, err4)
+	require.ErrorIs(t, err5, heimdall.ErrInternal)
+	assert.Equal(t, &testValue{Foo: "aa"}, value1)
+	assert.Equal(t, &testValue{Foo: "a"}, value2)
+}
+
+func TestClusterCacheUsage(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	srv := miniredis.RunT(t)
+
+	cch, err := NewClusterCache(map[string]any{"nodes": []string{srv.Addr()}, "tls": map[string]any{"disabled": true}})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	require.NoError(t, cch.Start(ctx))
+	defer cch.Stop(ctx)
+
+	// WHEN
+	cch.Set(ctx, "foo", &testValue{Foo: "bar"}, 1*time.Minute)
+	value := cch.Get(ctx, "foo")
+
+	// THEN
+	assert.Equal(t, &testValue{Foo: "bar"}, value)
+}
+
+func TestCacheWithUnavailableServer(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	srv := miniredis.RunT(t)
+
+	cch, err := NewStandaloneCache(map[string]any{"address": srv.Addr(), "tls": map[string]any{"disabled": true}})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	require.NoError(t, cch.Start(ctx))
+	defer cch.Stop(ctx)
+
+	srv.Close()
+
+	// WHEN
+	err = cch.Start(ctx)
+	cch.Set(ctx, "foo", "bar", 1*time.Minute)
+	value := cch.Get(ctx, "foo")
+	cch.Delete(ctx, "foo")
+
+	// THEN
+	require.Error(t, err)
+	require.ErrorIs(t, err, heimdall.ErrInternal)
+	assert.Nil(t, value)
+}
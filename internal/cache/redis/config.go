@@ -0,0 +1,146 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package redis
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+
+	"github.com/mitchellh/mapstructure"
+
+	"github.com/dadrus/heimdall/internal/config"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/keystore"
+	"github.com/dadrus/heimdall/internal/truststore"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+type credentials struct {
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+}
+
+type tlsConfig struct {
+	config.TLS `mapstructure:",squash"`
+
+	TrustStore string `mapstructure:"trust_store"`
+	Disabled   bool   `mapstructure:"disabled"`
+}
+
+type baseConfig struct {
+	Credentials credentials `mapstructure:"credentials"`
+	TLS         tlsConfig   `mapstructure:"tls"`
+}
+
+type standaloneConfig struct {
+	baseConfig `mapstructure:",squash"`
+
+	Address string `mapstructure:"address"`
+	DB      int    `mapstructure:"db"`
+}
+
+type clusterConfig struct {
+	baseConfig `mapstructure:",squash"`
+
+	Nodes []string `mapstructure:"nodes"`
+}
+
+type sentinelConfig struct {
+	baseConfig `mapstructure:",squash"`
+
+	Nodes  []string `mapstructure:"nodes"`
+	Master string   `mapstructure:"master"`
+	DB     int      `mapstructure:"db"`
+}
+
+func decodeConfig(input any, output any) error {
+	dec, err := mapstructure.NewDecoder(
+		&mapstructure.DecoderConfig{
+			DecodeHook: mapstructure.ComposeDecodeHookFunc(
+				mapstructure.StringToSliceHookFunc(","),
+				config.DecodeTLSCipherSuiteHookFunc,
+				config.DecodeTLSMinVersionHookFunc,
+			),
+			Result:      output,
+			ErrorUnused: true,
+		})
+	if err != nil {
+		return err
+	}
+
+	return dec.Decode(input)
+}
+
+func (c tlsConfig) toTLSConfig() (*tls.Config, error) {
+	if c.Disabled {
+		return nil, nil //nolint:nilnil
+	}
+
+	// nolint:gosec
+	// configuration ensures, TLS versions below 1.2 are not possible
+	cfg := &tls.Config{MinVersion: c.MinVersion.OrDefault()}
+
+	if cfg.MinVersion != tls.VersionTLS13 {
+		cfg.CipherSuites = c.CipherSuites.OrDefault()
+	}
+
+	if len(c.TrustStore) != 0 {
+		trustStore, err := truststore.NewTrustStoreFromPEMFile(c.TrustStore, true)
+		if err != nil {
+			return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration, "failed loading trust store").
+				CausedBy(err)
+		}
+
+		if len(trustStore) == 0 {
+			return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+				"trust store does not contain any certificates")
+		}
+
+		cfg.RootCAs = x509.NewCertPool()
+		for _, cert := range trustStore {
+			cfg.RootCAs.AddCert(cert)
+		}
+	}
+
+	if len(c.KeyStore.Path) == 0 {
+		return cfg, nil
+	}
+
+	ks, err := keystore.NewKeyStoreFromPEMFile(c.KeyStore.Path, c.KeyStore.Password)
+	if err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration, "failed loading keystore").
+			CausedBy(err)
+	}
+
+	entry := ks.Entries()[0]
+	if len(c.KeyID) != 0 {
+		if entry, err = ks.GetKey(c.KeyID); err != nil {
+			return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+				"failed retrieving key from key store").CausedBy(err)
+		}
+	}
+
+	cert, err := keystore.ToTLSCertificate(entry)
+	if err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"key store entry is not suitable for TLS").CausedBy(err)
+	}
+
+	cfg.Certificates = []tls.Certificate{cert}
+
+	return cfg, nil
+}
@@ -0,0 +1,189 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package redis
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/config"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/x/pkix/pemx"
+	"github.com/dadrus/heimdall/internal/x/testsupport"
+)
+
+func TestTLSConfigToTLSConfig(t *testing.T) {
+	t.Parallel()
+
+	privKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+
+	cert, err := testsupport.NewCertificateBuilder(testsupport.WithValidity(time.Now(), 10*time.Hour),
+		testsupport.WithSerialNumber(big.NewInt(1)),
+		testsupport.WithSubject(pkix.Name{CommonName: "test client", Organization: []string{"Test"}}),
+		testsupport.WithSubjectPubKey(&privKey.PublicKey, x509.ECDSAWithSHA384),
+		testsupport.WithSelfSigned(),
+		testsupport.WithSignaturePrivKey(privKey)).
+		Build()
+	require.NoError(t, err)
+
+	pemBytes, err := pemx.BuildPEM(
+		pemx.WithECDSAPrivateKey(privKey, pemx.WithHeader("X-Key-ID", "key1")),
+		pemx.WithX509Certificate(cert),
+	)
+	require.NoError(t, err)
+
+	keyStorePath := filepath.Join(t.TempDir(), "keystore.pem")
+	require.NoError(t, os.WriteFile(keyStorePath, pemBytes, 0o600))
+
+	trustStorePEM, err := pemx.BuildPEM(pemx.WithX509Certificate(cert))
+	require.NoError(t, err)
+
+	trustStorePath := filepath.Join(t.TempDir(), "truststore.pem")
+	require.NoError(t, os.WriteFile(trustStorePath, trustStorePEM, 0o600))
+
+	emptyTrustStorePath := filepath.Join(t.TempDir(), "empty.pem")
+	require.NoError(t, os.WriteFile(emptyTrustStorePath, []byte{}, 0o600))
+
+	for _, tc := range []struct {
+		uc     string
+		conf   tlsConfig
+		assert func(t *testing.T, err error, conf *tls.Config)
+	}{
+		{
+			uc:   "disabled",
+			conf: tlsConfig{Disabled: true},
+			assert: func(t *testing.T, err error, conf *tls.Config) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Nil(t, conf)
+			},
+		},
+		{
+			uc: "defaults",
+			assert: func(t *testing.T, err error, conf *tls.Config) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, conf)
+				assert.Equal(t, uint16(tls.VersionTLS13), conf.MinVersion)
+				assert.Empty(t, conf.CipherSuites)
+				assert.Empty(t, conf.Certificates)
+			},
+		},
+		{
+			uc:   "TLS 1.2 with default cipher suites",
+			conf: tlsConfig{TLS: config.TLS{MinVersion: tls.VersionTLS12}},
+			assert: func(t *testing.T, err error, conf *tls.Config) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, conf)
+				assert.Equal(t, uint16(tls.VersionTLS12), conf.MinVersion)
+				assert.Equal(t, config.TLSCipherSuites{}.OrDefault(), conf.CipherSuites)
+			},
+		},
+		{
+			uc: "client certificate with unknown key id",
+			conf: tlsConfig{TLS: config.TLS{
+				KeyStore: config.KeyStore{Path: keyStorePath},
+				KeyID:    "foo",
+			}},
+			assert: func(t *testing.T, err error, _ *tls.Config) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed retrieving key")
+			},
+		},
+		{
+			uc: "client certificate",
+			conf: tlsConfig{TLS: config.TLS{
+				KeyStore: config.KeyStore{Path: keyStorePath},
+				KeyID:    "key1",
+			}},
+			assert: func(t *testing.T, err error, conf *tls.Config) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, conf)
+				require.Len(t, conf.Certificates, 1)
+				assert.Equal(t, cert, conf.Certificates[0].Leaf)
+			},
+		},
+		{
+			uc:   "not existing trust store",
+			conf: tlsConfig{TrustStore: "/no/such/file.pem"},
+			assert: func(t *testing.T, err error, _ *tls.Config) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed loading trust store")
+			},
+		},
+		{
+			uc:   "empty trust store",
+			conf: tlsConfig{TrustStore: emptyTrustStorePath},
+			assert: func(t *testing.T, err error, _ *tls.Config) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "does not contain any certificates")
+			},
+		},
+		{
+			uc:   "custom trust store",
+			conf: tlsConfig{TrustStore: trustStorePath},
+			assert: func(t *testing.T, err error, conf *tls.Config) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, conf)
+				require.NotNil(t, conf.RootCAs)
+
+				pool := x509.NewCertPool()
+				pool.AddCert(cert)
+				assert.True(t, pool.Equal(conf.RootCAs))
+				assert.Empty(t, conf.Certificates)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// WHEN
+			conf, err := tc.conf.toTLSConfig()
+
+			// THEN
+			tc.assert(t, err, conf)
+		})
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/dadrus/heimdall/internal/x"
+)
+
+// tieredCache fronts a shared cache with a local one. Entries found in the shared cache
+// are kept in the local cache for at most the configured ttl. If the shared cache reports
+// the remaining lifetime of its entries (see TTLGetter), the local ttl is capped by it,
+// so that a local entry never outlives the corresponding one in the shared cache unless
+// the latter is deleted or overwritten.
+type tieredCache struct {
+	local  Cache
+	shared Cache
+	ttl    time.Duration
+}
+
+func newTieredCache(local, shared Cache, ttl time.Duration) *tieredCache {
+	return &tieredCache{local: local, shared: shared, ttl: ttl}
+}
+
+func (c *tieredCache) Start(ctx context.Context) error {
+	return errors.Join(c.local.Start(ctx), c.shared.Start(ctx))
+}
+
+func (c *tieredCache) Stop(ctx context.Context) error {
+	return errors.Join(c.local.Stop(ctx), c.shared.Stop(ctx))
+}
+
+func (c *tieredCache) Get(ctx context.Context, key string) any {
+	if value := c.local.Get(ctx, key); value != nil {
+		return value
+	}
+
+	getter, ok := c.shared.(TTLGetter)
+	if !ok {
+		value := c.shared.Get(ctx, key)
+		if value != nil {
+			c.local.Set(ctx, key, value, c.ttl)
+		}
+
+		return value
+	}
+
+	value, remaining := getter.GetWithTTL(ctx, key)
+	if value != nil {
+		c.local.Set(ctx, key, value, x.IfThenElse(remaining > 0, min(remaining, c.ttl), c.ttl))
+	}
+
+	return value
+}
+
+func (c *tieredCache) Set(ctx context.Context, key string, value any, ttl time.Duration) {
+	c.shared.Set(ctx, key, value, ttl)
+	c.local.Set(ctx, key, value, min(ttl, c.ttl))
+}
+
+// SetIfAbsent bypasses the local cache for the check, as only the shared cache can decide
+// atomically, whether an entry is present.
+func (c *tieredCache) SetIfAbsent(ctx context.Context, key string, value any, ttl time.Duration) (bool, error) {
+	stored, err := c.shared.SetIfAbsent(ctx, key, value, ttl)
+	if err != nil || !stored {
+		return stored, err
+	}
+
+	c.local.Set(ctx, key, value, min(ttl, c.ttl))
+
+	return true, nil
+}
+
+// Update bypasses the local cache, as entries updated this way are typically modified
+// frequently and their local copies would be outdated immediately.
+func (c *tieredCache) Update(ctx context.Context, key string, update func(current any) (any, time.Duration)) error {
+	c.local.Delete(ctx, key)
+
+	return c.shared.Update(ctx, key, update)
+}
+
+func (c *tieredCache) Delete(ctx context.Context, key string) {
+	c.local.Delete(ctx, key)
+	c.shared.Delete(ctx, key)
+}
+
+// Unwrap returns the local cache, which is the only one with observable usage.
+func (c *tieredCache) Unwrap() Cache { return c.local }
@@ -0,0 +1,174 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/cache/memory"
+)
+
+func TestTieredCache(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		uc     string
+		setup  func(t *testing.T, cch *tieredCache)
+		assert func(t *testing.T, cch *tieredCache)
+	}{
+		{
+			uc: "set stores value in both caches",
+			setup: func(t *testing.T, cch *tieredCache) {
+				t.Helper()
+
+				cch.Set(ctx, "foo", "bar", 10*time.Minute)
+			},
+			assert: func(t *testing.T, cch *tieredCache) {
+				t.Helper()
+
+				assert.Equal(t, "bar", cch.local.Get(ctx, "foo"))
+				assert.Equal(t, "bar", cch.shared.Get(ctx, "foo"))
+				assert.Equal(t, "bar", cch.Get(ctx, "foo"))
+			},
+		},
+		{
+			uc: "local entries do not outlive the configured ttl",
+			setup: func(t *testing.T, cch *tieredCache) {
+				t.Helper()
+
+				cch.Set(ctx, "foo", "bar", 10*time.Minute)
+				time.Sleep(150 * time.Millisecond)
+			},
+			assert: func(t *testing.T, cch *tieredCache) {
+				t.Helper()
+
+				assert.Nil(t, cch.local.Get(ctx, "foo"))
+				assert.Equal(t, "bar", cch.Get(ctx, "foo"))
+			},
+		},
+		{
+			uc: "value from shared cache is kept locally",
+			setup: func(t *testing.T, cch *tieredCache) {
+				t.Helper()
+
+				cch.shared.Set(ctx, "foo", "bar", 10*time.Minute)
+				require.Equal(t, "bar", cch.Get(ctx, "foo"))
+				cch.shared.Delete(ctx, "foo")
+			},
+			assert: func(t *testing.T, cch *tieredCache) {
+				t.Helper()
+
+				assert.Equal(t, "bar", cch.Get(ctx, "foo"))
+			},
+		},
+		{
+			uc: "local entries do not outlive the shared ones",
+			setup: func(t *testing.T, cch *tieredCache) {
+				t.Helper()
+
+				cch.shared.Set(ctx, "foo", "bar", 30*time.Millisecond)
+				require.Equal(t, "bar", cch.Get(ctx, "foo"))
+				time.Sleep(50 * time.Millisecond)
+			},
+			assert: func(t *testing.T, cch *tieredCache) {
+				t.Helper()
+
+				assert.Nil(t, cch.local.Get(ctx, "foo"))
+				assert.Nil(t, cch.Get(ctx, "foo"))
+			},
+		},
+		{
+			uc: "set if absent is decided by the shared cache",
+			setup: func(t *testing.T, cch *tieredCache) {
+				t.Helper()
+
+				cch.local.Set(ctx, "foo", "bar", 10*time.Minute)
+
+				stored, err := cch.SetIfAbsent(ctx, "foo", "baz", 10*time.Minute)
+				require.NoError(t, err)
+				require.True(t, stored)
+
+				stored, err = cch.SetIfAbsent(ctx, "foo", "qux", 10*time.Minute)
+				require.NoError(t, err)
+				require.False(t, stored)
+			},
+			assert: func(t *testing.T, cch *tieredCache) {
+				t.Helper()
+
+				assert.Equal(t, "baz", cch.local.Get(ctx, "foo"))
+				assert.Equal(t, "baz", cch.shared.Get(ctx, "foo"))
+			},
+		},
+		{
+			uc: "update bypasses the local cache",
+			setup: func(t *testing.T, cch *tieredCache) {
+				t.Helper()
+
+				cch.Set(ctx, "foo", 1, 10*time.Minute)
+
+				require.NoError(t, cch.Update(ctx, "foo", func(current any) (any, time.Duration) {
+					value, _ := current.(int)
+
+					return value + 1, 10 * time.Minute
+				}))
+			},
+			assert: func(t *testing.T, cch *tieredCache) {
+				t.Helper()
+
+				assert.Nil(t, cch.local.Get(ctx, "foo"))
+				assert.Equal(t, 2, cch.shared.Get(ctx, "foo"))
+			},
+		},
+		{
+			uc: "delete removes value from both caches",
+			setup: func(t *testing.T, cch *tieredCache) {
+				t.Helper()
+
+				cch.Set(ctx, "foo", "bar", 10*time.Minute)
+				cch.Delete(ctx, "foo")
+			},
+			assert: func(t *testing.T, cch *tieredCache) {
+				t.Helper()
+
+				assert.Nil(t, cch.local.Get(ctx, "foo"))
+				assert.Nil(t, cch.shared.Get(ctx, "foo"))
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			cch := newTieredCache(memory.New(), memory.New(), 100*time.Millisecond)
+
+			require.NoError(t, cch.Start(ctx))
+
+			defer cch.Stop(ctx)
+
+			// WHEN
+			tc.setup(t, cch)
+
+			// THEN
+			tc.assert(t, cch)
+		})
+	}
+}
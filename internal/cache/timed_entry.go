@@ -0,0 +1,70 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cache
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/dadrus/heimdall/internal/cache/encoding"
+)
+
+// by intention. Used only during application bootstrap
+//
+//nolint:gochecknoinits
+func init() {
+	encoding.RegisterType[*timedEntry]("cache.timed_entry")
+}
+
+// timedEntry is used by GetOrLoad to keep track of the time, a cached value is fresh until,
+// if it is allowed to be served after that time, or should be refreshed before.
+type timedEntry struct {
+	Value      any
+	FreshUntil time.Time
+}
+
+type timedEntryJSON struct {
+	Value      json.RawMessage `json:"v"`
+	FreshUntil time.Time       `json:"f"`
+}
+
+func (e *timedEntry) MarshalJSON() ([]byte, error) {
+	raw, err := encoding.Marshal(e.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(timedEntryJSON{Value: raw, FreshUntil: e.FreshUntil})
+}
+
+func (e *timedEntry) UnmarshalJSON(data []byte) error {
+	var entry timedEntryJSON
+
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return err
+	}
+
+	value, err := encoding.Unmarshal(entry.Value)
+	if err != nil {
+		return err
+	}
+
+	e.Value = value
+	e.FreshUntil = entry.FreshUntil
+
+	return nil
+}
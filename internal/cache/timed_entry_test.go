@@ -0,0 +1,57 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/cache/encoding"
+)
+
+func TestTimedEntryEncoding(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	entry := &timedEntry{Value: []byte("foo"), FreshUntil: time.Now().Round(0).UTC()}
+
+	// WHEN
+	raw, err := encoding.Marshal(entry)
+	require.NoError(t, err)
+
+	value, err := encoding.Unmarshal(raw)
+	require.NoError(t, err)
+
+	// THEN
+	decoded, ok := value.(*timedEntry)
+	require.True(t, ok)
+	assert.Equal(t, entry.Value, decoded.Value)
+	assert.True(t, entry.FreshUntil.Equal(decoded.FreshUntil))
+}
+
+func TestTimedEntryEncodingOfUnsupportedValue(t *testing.T) {
+	t.Parallel()
+
+	// WHEN
+	_, err := encoding.Marshal(&timedEntry{Value: 10})
+
+	// THEN
+	require.ErrorIs(t, err, encoding.ErrUnsupportedType)
+}
@@ -0,0 +1,102 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package callback
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+)
+
+var (
+	ErrEndpointAlreadyRegistered = errors.New("endpoint already registered")
+	ErrInvalidEndpoint           = errors.New("endpoint must be an absolute url")
+)
+
+// Handler handles the requests to an endpoint, which is served by heimdall itself on behalf
+// of a mechanism, like the redirect_uri of a relying party. As such requests are not
+// forwarded to any upstream service, the handler answers them by returning an error, like
+// heimdall.RedirectError, which is rendered by the error handler of the service.
+type Handler interface {
+	HandleRequest(ctx heimdall.Context) error
+}
+
+type HandlerFunc func(ctx heimdall.Context) error
+
+func (f HandlerFunc) HandleRequest(ctx heimdall.Context) error { return f(ctx) }
+
+// Endpoint associates the url of an endpoint served by heimdall with its handler.
+type Endpoint struct {
+	URL     *url.URL
+	Handler Handler
+}
+
+// Registry holds the handlers of the endpoints served by heimdall itself. These are taken
+// into account by the proxy and decision services before the rules are looked up. The
+// endpoints are identified by their scheme, host and path, so that an endpoint does not
+// shadow the rules for the same path on other hosts.
+type Registry struct {
+	mu       sync.RWMutex
+	handlers map[string]Handler
+}
+
+func NewRegistry() *Registry { return &Registry{handlers: make(map[string]Handler)} }
+
+func (r *Registry) Register(endpoint *url.URL, handler Handler) error {
+	if len(endpoint.Scheme) == 0 || len(endpoint.Host) == 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidEndpoint, endpoint)
+	}
+
+	key := endpointKey(endpoint)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.handlers[key]; ok {
+		return fmt.Errorf("%w: %s", ErrEndpointAlreadyRegistered, key)
+	}
+
+	r.handlers[key] = handler
+
+	return nil
+}
+
+func (r *Registry) Handler(requestURL *url.URL) (Handler, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	handler, ok := r.handlers[endpointKey(requestURL)]
+
+	return handler, ok
+}
+
+// endpointKey returns the scheme, the host without the default port of the scheme and the
+// path of the given url in a normalized form.
+func endpointKey(endpoint *url.URL) string {
+	scheme := strings.ToLower(endpoint.Scheme)
+	host := strings.ToLower(endpoint.Host)
+
+	if port := endpoint.Port(); (port == "80" && scheme == "http") || (port == "443" && scheme == "https") {
+		host = strings.TrimSuffix(host, ":"+port)
+	}
+
+	return scheme + "://" + host + endpoint.Path
+}
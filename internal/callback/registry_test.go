@@ -0,0 +1,66 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package callback
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+)
+
+func TestRegistry(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	registry := NewRegistry()
+	handler := HandlerFunc(func(_ heimdall.Context) error { return heimdall.ErrAuthentication })
+
+	parse := func(rawURL string) *url.URL {
+		t.Helper()
+
+		endpoint, err := url.Parse(rawURL)
+		require.NoError(t, err)
+
+		return endpoint
+	}
+
+	// WHEN
+	err1 := registry.Register(parse("https://foo.bar/foo"), handler)
+	err2 := registry.Register(parse("https://Foo.bar:443/foo"), handler)
+	err3 := registry.Register(parse("/foo"), handler)
+	registered, found := registry.Handler(parse("https://foo.bar/foo?bar=baz"))
+	_, foundWithPort := registry.Handler(parse("https://foo.bar:443/foo"))
+	_, otherPath := registry.Handler(parse("https://foo.bar/bar"))
+	_, otherHost := registry.Handler(parse("https://bar.foo/foo"))
+	_, otherScheme := registry.Handler(parse("http://foo.bar/foo"))
+
+	// THEN
+	require.NoError(t, err1)
+	require.ErrorIs(t, err2, ErrEndpointAlreadyRegistered)
+	require.ErrorContains(t, err2, "https://foo.bar/foo")
+	require.ErrorIs(t, err3, ErrInvalidEndpoint)
+	assert.True(t, found)
+	assert.True(t, foundWithPort)
+	assert.False(t, otherPath)
+	assert.False(t, otherHost)
+	assert.False(t, otherScheme)
+	require.ErrorIs(t, registered.HandleRequest(nil), heimdall.ErrAuthentication)
+}
@@ -16,6 +16,15 @@
 
 package config
 
+import "time"
+
 type CacheConfig struct {
-	Type string `koanf:"type"`
+	Type   string            `koanf:"type"`
+	Config map[string]any    `koanf:"config,omitempty"`
+	Local  *LocalCacheConfig `koanf:"local,omitempty"`
+}
+
+type LocalCacheConfig struct {
+	TTL    time.Duration  `koanf:"ttl"`
+	Config map[string]any `koanf:"config,omitempty"`
 }
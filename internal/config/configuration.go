@@ -19,6 +19,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/mitchellh/mapstructure"
 
@@ -35,6 +36,7 @@ type Configuration struct { //nolint:musttag
 	Cache      CacheConfig          `koanf:"cache"`
 	Prototypes *MechanismPrototypes `koanf:"mechanisms,omitempty"`
 	Default    *DefaultRule         `koanf:"default_rule,omitempty"`
+	Rules      RulesConfig          `koanf:"rules"`
 	Providers  RuleProviders        `koanf:"providers,omitempty"`
 }
 
@@ -44,12 +46,14 @@ func NewConfiguration(envPrefix EnvVarPrefix, configFile ConfigurationPath) (*Co
 
 	opts := []parser.Option{
 		parser.WithDecodeHookFunc(mapstructure.StringToTimeDurationHookFunc()),
+		parser.WithDecodeHookFunc(mapstructure.StringToTimeHookFunc(time.RFC3339)),
 		parser.WithDecodeHookFunc(mapstructure.StringToSliceHookFunc(",")),
-		parser.WithDecodeHookFunc(stringToByteSizeHookFunc()),
+		parser.WithDecodeHookFunc(StringToByteSizeHookFunc()),
 		parser.WithDecodeHookFunc(logLevelDecodeHookFunc),
 		parser.WithDecodeHookFunc(logFormatDecodeHookFunc),
 		parser.WithDecodeHookFunc(DecodeTLSCipherSuiteHookFunc),
 		parser.WithDecodeHookFunc(DecodeTLSMinVersionHookFunc),
+		parser.WithDecodeHookFunc(DecodeClientAuthModeHookFunc),
 		parser.WithEnvPrefix(string(envPrefix)),
 		parser.WithDefaultConfigFilename("heimdall.yaml"),
 		parser.WithConfigFile(string(configFile)),
@@ -105,5 +105,8 @@ func defaultConfig() Configuration {
 			Name: "heimdall",
 		},
 		Prototypes: &MechanismPrototypes{},
+		Rules: RulesConfig{
+			AllowHeader: true,
+		},
 	}
 }
@@ -128,7 +128,26 @@ func DecodeTLSMinVersionHookFunc(from reflect.Type, to reflect.Type, data any) (
 	}
 }
 
-func stringToByteSizeHookFunc() mapstructure.DecodeHookFunc {
+func DecodeClientAuthModeHookFunc(from reflect.Type, to reflect.Type, data any) (any, error) {
+	if from.Kind() != reflect.String || to != reflect.TypeOf(ClientAuthMode(0)) {
+		return data, nil
+	}
+
+	switch data {
+	case "none":
+		return ClientAuthMode(tls.NoClientCert), nil
+	case "request":
+		return ClientAuthMode(tls.RequestClientCert), nil
+	case "require":
+		return ClientAuthMode(tls.RequireAnyClientCert), nil
+	case "verify":
+		return ClientAuthMode(tls.RequireAndVerifyClientCert), nil
+	default:
+		return data, errorchain.NewWithMessagef(heimdall.ErrConfiguration, "client auth mode %s is unsupported", data)
+	}
+}
+
+func StringToByteSizeHookFunc() mapstructure.DecodeHookFunc {
 	return func(f reflect.Type, t reflect.Type, data interface{}) (interface{}, error) {
 		if f.Kind() != reflect.String {
 			return data, nil
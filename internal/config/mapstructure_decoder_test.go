@@ -255,6 +255,91 @@ func TestDecodeTLSMinVersion(t *testing.T) {
 	}
 }
 
+func TestDecodeClientAuthMode(t *testing.T) {
+	t.Parallel()
+
+	type Type struct {
+		Mode ClientAuthMode `mapstructure:"mode"`
+	}
+
+	for _, tc := range []struct {
+		uc     string
+		config []byte
+		assert func(t *testing.T, err error, mode ClientAuthMode)
+	}{
+		{
+			uc:     "unsupported mode",
+			config: []byte(`mode: foo`),
+			assert: func(t *testing.T, err error, _ ClientAuthMode) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "unsupported")
+			},
+		},
+		{
+			uc:     "none mode",
+			config: []byte(`mode: none`),
+			assert: func(t *testing.T, err error, mode ClientAuthMode) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, ClientAuthMode(tls.NoClientCert), mode)
+			},
+		},
+		{
+			uc:     "request mode",
+			config: []byte(`mode: request`),
+			assert: func(t *testing.T, err error, mode ClientAuthMode) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, ClientAuthMode(tls.RequestClientCert), mode)
+			},
+		},
+		{
+			uc:     "require mode",
+			config: []byte(`mode: require`),
+			assert: func(t *testing.T, err error, mode ClientAuthMode) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, ClientAuthMode(tls.RequireAnyClientCert), mode)
+			},
+		},
+		{
+			uc:     "verify mode",
+			config: []byte(`mode: verify`),
+			assert: func(t *testing.T, err error, mode ClientAuthMode) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, ClientAuthMode(tls.RequireAndVerifyClientCert), mode)
+			},
+		},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			// GIVEN
+			var typ Type
+
+			dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+				DecodeHook: DecodeClientAuthModeHookFunc,
+				Result:     &typ,
+			})
+			require.NoError(t, err)
+
+			conf, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			// WHEN
+			err = dec.Decode(conf)
+
+			// THEN
+			tc.assert(t, err, typ.Mode)
+		})
+	}
+}
+
 func TestStringToByteSizeHookFunc(t *testing.T) {
 	t.Parallel()
 
@@ -275,7 +360,7 @@ func TestStringToByteSizeHookFunc(t *testing.T) {
 			var typ Type
 
 			dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
-				DecodeHook: stringToByteSizeHookFunc(),
+				DecodeHook: StringToByteSizeHookFunc(),
 				Result:     &typ,
 			})
 			require.NoError(t, err)
@@ -76,13 +76,14 @@ type ResponseOverride struct {
 type RespondConfig struct {
 	Verbose bool `koanf:"verbose"`
 	With    struct {
-		Accepted            ResponseOverride `koanf:"accepted"`
-		ArgumentError       ResponseOverride `koanf:"argument_error"`
-		AuthenticationError ResponseOverride `koanf:"authentication_error"`
-		AuthorizationError  ResponseOverride `koanf:"authorization_error"`
-		BadMethodError      ResponseOverride `koanf:"method_error"`
-		CommunicationError  ResponseOverride `koanf:"communication_error"`
-		InternalError       ResponseOverride `koanf:"internal_error"`
-		NoRuleError         ResponseOverride `koanf:"no_rule_error"`
+		Accepted             ResponseOverride `koanf:"accepted"`
+		ArgumentError        ResponseOverride `koanf:"argument_error"`
+		AuthenticationError  ResponseOverride `koanf:"authentication_error"`
+		AuthorizationError   ResponseOverride `koanf:"authorization_error"`
+		BadMethodError       ResponseOverride `koanf:"method_error"`
+		CommunicationError   ResponseOverride `koanf:"communication_error"`
+		InternalError        ResponseOverride `koanf:"internal_error"`
+		NoRuleError          ResponseOverride `koanf:"no_rule_error"`
+		TooManyRequestsError ResponseOverride `koanf:"too_many_requests_error"`
 	} `koanf:"with"`
 }
@@ -16,8 +16,18 @@
 
 package config
 
+import "time"
+
 type SignerConfig struct {
-	Name     string   `koanf:"name"`
-	KeyStore KeyStore `koanf:"key_store"`
-	KeyID    string   `koanf:"key_id"`
+	Name     string       `koanf:"name"`
+	KeyStore KeyStore     `koanf:"key_store"`
+	KeyID    string       `koanf:"key_id"`
+	Rotation *KeyRotation `koanf:"rotation,omitempty"`
+}
+
+type KeyRotation struct {
+	Watch       bool          `koanf:"watch"`
+	NextKeyID   string        `koanf:"next_key_id"`
+	ActivateAt  time.Time     `koanf:"activate_at"`
+	GracePeriod time.Duration `koanf:"grace_period"`
 }
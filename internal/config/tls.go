@@ -16,7 +16,10 @@
 
 package config
 
-import "crypto/tls"
+import (
+	"crypto/tls"
+	"time"
+)
 
 type TLSCipherSuites []uint16
 
@@ -50,4 +53,22 @@ type TLS struct {
 	KeyID        string          `koanf:"key_id"        mapstructure:"key_id"`
 	CipherSuites TLSCipherSuites `koanf:"cipher_suites" mapstructure:"cipher_suites"`
 	MinVersion   TLSMinVersion   `koanf:"min_version"   mapstructure:"min_version"`
+	ClientAuth   *ClientAuth     `koanf:"client_auth,omitempty" mapstructure:"client_auth"`
+}
+
+// ClientAuthMode holds the tls.ClientAuthType the server applies to the certificates
+// presented by the clients.
+type ClientAuthMode tls.ClientAuthType
+
+type ClientAuth struct {
+	Mode       ClientAuthMode   `koanf:"mode"                 mapstructure:"mode"`
+	TrustStore string           `koanf:"trust_store"          mapstructure:"trust_store"`
+	Revocation *RevocationCheck `koanf:"revocation,omitempty" mapstructure:"revocation"`
+}
+
+type RevocationCheck struct {
+	CRLs        []string       `koanf:"crls"         mapstructure:"crls"`
+	OCSP        bool           `koanf:"ocsp"         mapstructure:"ocsp"`
+	OCSPTimeout *time.Duration `koanf:"ocsp_timeout" mapstructure:"ocsp_timeout"`
+	SoftFail    bool           `koanf:"soft_fail"    mapstructure:"soft_fail"`
 }
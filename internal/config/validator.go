@@ -18,6 +18,7 @@ package config
 
 import (
 	"os"
+	"time"
 
 	"github.com/knadh/koanf/maps"
 	"github.com/santhosh-tekuri/jsonschema/v5"
@@ -56,10 +57,29 @@ func ValidateConfig(configPath string) error {
 
 	maps.IntfaceKeysToStrings(conf)
 
-	err = schema.Validate(conf)
+	err = schema.Validate(timestampsToStrings(conf))
 	if err != nil {
 		return errorchain.New(heimdall.ErrConfiguration).CausedBy(err)
 	}
 
 	return nil
 }
+
+// timestampsToStrings converts the timestamps, the yaml parser creates from unquoted
+// values, back to strings, as these are not known to the JSON schema validator.
+func timestampsToStrings(value any) any {
+	switch val := value.(type) {
+	case time.Time:
+		return val.Format(time.RFC3339Nano)
+	case map[string]any:
+		for k, v := range val {
+			val[k] = timestampsToStrings(v)
+		}
+	case []any:
+		for idx, v := range val {
+			val[idx] = timestampsToStrings(v)
+		}
+	}
+
+	return value
+}
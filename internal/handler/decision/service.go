@@ -58,6 +58,7 @@ func newService(
 		errorhandler.WithAuthorizationErrorCode(cfg.Respond.With.AuthorizationError.Code),
 		errorhandler.WithCommunicationErrorCode(cfg.Respond.With.CommunicationError.Code),
 		errorhandler.WithMethodErrorCode(cfg.Respond.With.BadMethodError.Code),
+		errorhandler.WithTooManyRequestsErrorCode(cfg.Respond.With.TooManyRequestsError.Code),
 		errorhandler.WithNoRuleErrorCode(cfg.Respond.With.NoRuleError.Code),
 		errorhandler.WithInternalServerErrorCode(cfg.Respond.With.InternalError.Code),
 	)
@@ -18,6 +18,7 @@ package grpcv3
 
 import (
 	"context"
+	"crypto/x509"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -29,10 +30,13 @@ import (
 	"google.golang.org/genproto/googleapis/rpc/status"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 
 	"github.com/dadrus/heimdall/internal/heimdall"
 	"github.com/dadrus/heimdall/internal/rules/mechanisms/contenttype"
 	"github.com/dadrus/heimdall/internal/x"
+	"github.com/dadrus/heimdall/internal/x/httpx"
+	"github.com/dadrus/heimdall/internal/x/pkix"
 )
 
 type RequestContext struct {
@@ -40,15 +44,17 @@ type RequestContext struct {
 	ips             []string
 	reqMethod       string
 	reqHeaders      map[string]string
-	reqURL          *url.URL
+	reqURL          *heimdall.URL
 	reqBody         string
 	reqRawBody      []byte
+	peerCert        string
 	upstreamHeaders http.Header
 	upstreamCookies map[string]string
 	jwtSigner       heimdall.JWTSigner
 	err             error
 
-	savedBody any
+	savedBody   any
+	clientCerts []*x509.Certificate
 }
 
 func NewRequestContext(ctx context.Context, req *envoy_auth.CheckRequest, signer heimdall.JWTSigner) *RequestContext {
@@ -61,20 +67,30 @@ func NewRequestContext(ctx context.Context, req *envoy_auth.CheckRequest, signer
 		}
 	}
 
+	var peerAddr string
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		peerAddr = httpx.IPFromHostPort(p.Addr.String())
+	}
+
+	// like with the HTTP based handlers, the last entry is the address of the peer heimdall has
+	// received the request from. Everything else is taken from the forwarded metadata.
+	clientIPs = append(clientIPs, peerAddr)
+
 	return &RequestContext{
 		ctx:        ctx,
 		ips:        clientIPs,
 		reqMethod:  req.GetAttributes().GetRequest().GetHttp().GetMethod(),
 		reqHeaders: canonicalizeHeaders(req.GetAttributes().GetRequest().GetHttp().GetHeaders()),
-		reqURL: &url.URL{
+		reqURL: &heimdall.URL{URL: url.URL{
 			Scheme:   req.GetAttributes().GetRequest().GetHttp().GetScheme(),
 			Host:     req.GetAttributes().GetRequest().GetHttp().GetHost(),
 			Path:     req.GetAttributes().GetRequest().GetHttp().GetPath(),
 			RawQuery: req.GetAttributes().GetRequest().GetHttp().GetQuery(),
 			Fragment: req.GetAttributes().GetRequest().GetHttp().GetFragment(),
-		},
+		}},
 		reqBody:         req.GetAttributes().GetRequest().GetHttp().GetBody(),
 		reqRawBody:      req.GetAttributes().GetRequest().GetHttp().GetRawBody(),
+		peerCert:        req.GetAttributes().GetSource().GetCertificate(),
 		jwtSigner:       signer,
 		upstreamHeaders: make(http.Header),
 		upstreamCookies: make(map[string]string),
@@ -101,7 +117,9 @@ func (r *RequestContext) Request() *heimdall.Request {
 }
 
 func (r *RequestContext) Headers() map[string]string { return r.reqHeaders }
-func (r *RequestContext) Header(name string) string  { return r.reqHeaders[name] }
+func (r *RequestContext) Header(name string) string {
+	return r.reqHeaders[http.CanonicalHeaderKey(name)]
+}
 
 func (r *RequestContext) Cookie(name string) string {
 	values, ok := r.reqHeaders["Cookie"]
@@ -140,6 +158,33 @@ func (r *RequestContext) Body() any {
 	return r.savedBody
 }
 
+func (r *RequestContext) RawBody() []byte { return r.reqRawBody }
+
+// ClientCertificates returns the certificate of the downstream peer envoy has received and
+// verified. It is only available if envoy is configured to include it into the check request.
+func (r *RequestContext) ClientCertificates() []*x509.Certificate {
+	if r.clientCerts != nil || len(r.peerCert) == 0 {
+		return r.clientCerts
+	}
+
+	// envoy sends the PEM encoded certificate in url encoded form
+	pemBytes, err := url.PathUnescape(r.peerCert)
+	if err != nil {
+		zerolog.Ctx(r.ctx).Warn().Err(err).Msg("Failed to decode peer certificate")
+
+		return nil
+	}
+
+	r.clientCerts, err = pkix.ParseCertificates([]byte(pemBytes))
+	if err != nil {
+		zerolog.Ctx(r.ctx).Warn().Err(err).Msg("Failed to parse peer certificate")
+
+		return nil
+	}
+
+	return r.clientCerts
+}
+
 func (r *RequestContext) AppContext() context.Context             { return r.ctx }
 func (r *RequestContext) SetPipelineError(err error)              { r.err = err }
 func (r *RequestContext) AddHeaderForUpstream(name, value string) { r.upstreamHeaders.Add(name, value) }
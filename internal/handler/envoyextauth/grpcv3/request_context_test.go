@@ -19,9 +19,12 @@ package grpcv3
 import (
 	"context"
 	"errors"
+	"net"
 	"net/http"
+	"net/url"
 	"strings"
 	"testing"
+	"time"
 
 	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	envoy_auth "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
@@ -29,9 +32,12 @@ import (
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 
 	"github.com/dadrus/heimdall/internal/heimdall"
 	"github.com/dadrus/heimdall/internal/heimdall/mocks"
+	"github.com/dadrus/heimdall/internal/x/pkix/pemx"
+	"github.com/dadrus/heimdall/internal/x/testsupport"
 )
 
 func TestNewRequestContext(t *testing.T) {
@@ -64,9 +70,9 @@ func TestNewRequestContext(t *testing.T) {
 	md.Set("x-forwarded-for", "127.0.0.1", "192.168.1.1")
 
 	ctx := NewRequestContext(
-		metadata.NewIncomingContext(
-			context.Background(),
-			md,
+		peer.NewContext(
+			metadata.NewIncomingContext(context.Background(), md),
+			&peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.10.10.10"), Port: 12345}},
 		),
 		checkReq,
 		mocks.NewJWTSignerMock(t),
@@ -83,12 +89,93 @@ func TestNewRequestContext(t *testing.T) {
 	require.Equal(t, map[string]any{"content": []string{"heimdall"}}, ctx.Request().Body())
 	require.Len(t, ctx.Request().Headers(), 3)
 	require.Equal(t, "barfoo", ctx.Request().Header("X-Foo-Bar"))
+	require.Equal(t, "barfoo", ctx.Request().Header("x-foo-bar"))
 	require.Equal(t, "foo", ctx.Request().Cookie("bar"))
 	require.Equal(t, "baz", ctx.Request().Cookie("foo"))
 	require.Empty(t, ctx.Request().Cookie("baz"))
 	require.NotNil(t, ctx.AppContext())
 	require.NotNil(t, ctx.Signer())
-	assert.Equal(t, []string{"127.0.0.1", "192.168.1.1"}, ctx.Request().ClientIPAddresses)
+	assert.Equal(t, []string{"127.0.0.1", "192.168.1.1", "10.10.10.10"}, ctx.Request().ClientIPAddresses)
+}
+
+func TestRequestContextClientIPAddressesEndWithPeerAddress(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	md := metadata.New(nil)
+	md.Set("x-forwarded-for", "10.10.10.10")
+
+	for _, tc := range []struct {
+		uc       string
+		ctx      context.Context
+		expected []string
+	}{
+		{
+			uc: "with peer",
+			ctx: peer.NewContext(
+				metadata.NewIncomingContext(context.Background(), md),
+				&peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("192.168.1.1"), Port: 12345}},
+			),
+			expected: []string{"10.10.10.10", "192.168.1.1"},
+		},
+		{
+			uc:       "without peer",
+			ctx:      metadata.NewIncomingContext(context.Background(), md),
+			expected: []string{"10.10.10.10", ""},
+		},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			// WHEN
+			ctx := NewRequestContext(tc.ctx, &envoy_auth.CheckRequest{}, mocks.NewJWTSignerMock(t))
+
+			// THEN
+			assert.Equal(t, tc.expected, ctx.Request().ClientIPAddresses)
+		})
+	}
+}
+
+func TestRequestContextClientCertificates(t *testing.T) {
+	t.Parallel()
+
+	ca, err := testsupport.NewRootCA("Test CA", time.Hour)
+	require.NoError(t, err)
+
+	pemBytes, err := pemx.BuildPEM(pemx.WithX509Certificate(ca.Certificate))
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		uc          string
+		certificate string
+		expected    int
+	}{
+		{uc: "no certificate forwarded"},
+		{uc: "malformed certificate forwarded", certificate: "%zz"},
+		{uc: "url encoded pem certificate forwarded", certificate: url.PathEscape(string(pemBytes)), expected: 1},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			// GIVEN
+			checkReq := &envoy_auth.CheckRequest{
+				Attributes: &envoy_auth.AttributeContext{
+					Source: &envoy_auth.AttributeContext_Peer{Certificate: tc.certificate},
+					Request: &envoy_auth.AttributeContext_Request{
+						Http: &envoy_auth.AttributeContext_HttpRequest{},
+					},
+				},
+			}
+
+			ctx := NewRequestContext(context.Background(), checkReq, mocks.NewJWTSignerMock(t))
+
+			// WHEN
+			certs := ctx.Request().ClientCertificates()
+
+			// THEN
+			require.Len(t, certs, tc.expected)
+
+			if tc.expected != 0 {
+				assert.Equal(t, ca.Certificate.Raw, certs[0].Raw)
+			}
+		})
+	}
 }
 
 func TestFinalizeRequestContext(t *testing.T) {
@@ -320,9 +407,11 @@ func TestRequestContextBody(t *testing.T) {
 
 			// WHEN
 			data := ctx.Request().Body()
+			raw := ctx.Request().RawBody()
 
 			// THEN
 			assert.Equal(t, tc.expect, data)
+			assert.Equal(t, tc.body, raw)
 		})
 	}
 }
@@ -73,6 +73,7 @@ func newService(
 			errorhandler.WithAuthorizationErrorCode(service.Respond.With.AuthorizationError.Code),
 			errorhandler.WithCommunicationErrorCode(service.Respond.With.CommunicationError.Code),
 			errorhandler.WithMethodErrorCode(service.Respond.With.BadMethodError.Code),
+			errorhandler.WithTooManyRequestsErrorCode(service.Respond.With.TooManyRequestsError.Code),
 			errorhandler.WithNoRuleErrorCode(service.Respond.With.NoRuleError.Code),
 			errorhandler.WithInternalServerErrorCode(service.Respond.With.InternalError.Code),
 		),
@@ -0,0 +1,74 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package listener
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+
+	"github.com/dadrus/heimdall/internal/config"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/truststore"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+func configureClientAuth(cfg *tls.Config, conf *config.ClientAuth) error {
+	cfg.ClientAuth = tls.ClientAuthType(conf.Mode)
+
+	if cfg.ClientAuth != tls.RequireAndVerifyClientCert {
+		// the certificates are neither verified, nor checked for revocation. So configuring
+		// any of these is a mistake, which would give a false sense of security
+		if len(conf.TrustStore) != 0 || conf.Revocation != nil {
+			return errorchain.NewWithMessage(heimdall.ErrConfiguration,
+				"trust store and revocation check are only supported if client certificates are verified")
+		}
+
+		return nil
+	}
+
+	if len(conf.TrustStore) == 0 {
+		return errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"client certificate verification requires a trust store")
+	}
+
+	trustStore, err := truststore.NewTrustStoreFromPEMFile(conf.TrustStore, true)
+	if err != nil {
+		return errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"failed loading client auth trust store").CausedBy(err)
+	}
+
+	if len(trustStore) == 0 {
+		return errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"client auth trust store does not contain any certificates")
+	}
+
+	cfg.ClientCAs = x509.NewCertPool()
+	for _, cert := range trustStore {
+		cfg.ClientCAs.AddCert(cert)
+	}
+
+	if conf.Revocation != nil {
+		checker, err := newRevocationChecker(conf.Revocation)
+		if err != nil {
+			return err
+		}
+
+		cfg.VerifyConnection = checker.verifyConnection
+	}
+
+	return nil
+}
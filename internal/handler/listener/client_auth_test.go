@@ -0,0 +1,823 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package listener
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ocsp"
+
+	"github.com/dadrus/heimdall/internal/config"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/x/pkix/pemx"
+	"github.com/dadrus/heimdall/internal/x/testsupport"
+)
+
+func handshake(t *testing.T, tlsConf *config.TLS, clientCert *tls.Certificate, rootCAs *x509.CertPool) error {
+	t.Helper()
+
+	ln, err := New("tcp", "127.0.0.1:0", tlsConf)
+	require.NoError(t, err)
+
+	defer ln.Close()
+
+	result := make(chan error, 1)
+
+	go func() {
+		con, err := ln.Accept()
+		if err != nil {
+			result <- err
+
+			return
+		}
+
+		defer con.Close()
+
+		tlsCon, ok := con.(*tls.Conn)
+		require.True(t, ok)
+
+		result <- tlsCon.Handshake()
+	}()
+
+	clientConf := &tls.Config{RootCAs: rootCAs, MinVersion: tls.VersionTLS13}
+	if clientCert != nil {
+		// send the certificate even if it is not issued by one of the CAs accepted by the server
+		clientConf.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return clientCert, nil
+		}
+	}
+
+	con, err := tls.Dial("tcp", ln.Addr().String(), clientConf)
+	if err == nil {
+		// with TLS 1.3 the client does not wait for the server to verify its certificate
+		_, _ = io.ReadAll(con)
+		con.Close()
+	}
+
+	return <-result
+}
+
+func TestNewListenerWithClientAuth(t *testing.T) { //nolint:maintidx
+	t.Parallel()
+
+	testDir := t.TempDir()
+
+	// PKI
+	rootCA, err := testsupport.NewRootCA("Test Root CA", time.Hour*24)
+	require.NoError(t, err)
+
+	otherCA, err := testsupport.NewRootCA("Other Root CA", time.Hour*24)
+	require.NoError(t, err)
+
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(rootCA.Certificate)
+
+	ocspStatus := map[string]int{}
+	ocspResponder := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		data, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+
+		ocspReq, err := ocsp.ParseRequest(data)
+		require.NoError(t, err)
+
+		status, ok := ocspStatus[ocspReq.SerialNumber.String()]
+		if !ok {
+			rw.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		resp, err := ocsp.CreateResponse(rootCA.Certificate, rootCA.Certificate, ocsp.Response{
+			Status:       status,
+			SerialNumber: ocspReq.SerialNumber,
+			ThisUpdate:   time.Now().Add(-time.Minute),
+			NextUpdate:   time.Now().Add(time.Hour),
+			RevokedAt:    time.Now().Add(-time.Minute),
+		}, rootCA.PrivKey)
+		require.NoError(t, err)
+
+		rw.Header().Set("Content-Type", "application/ocsp-response")
+		_, err = rw.Write(resp)
+		require.NoError(t, err)
+	}))
+	defer ocspResponder.Close()
+
+	issueClientCert := func(ca *testsupport.CA) (*tls.Certificate, *x509.Certificate) {
+		privKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+		require.NoError(t, err)
+
+		cert, err := ca.IssueCertificate(
+			testsupport.WithSubject(pkix.Name{CommonName: "client"}),
+			testsupport.WithValidity(time.Now(), time.Hour),
+			testsupport.WithSubjectPubKey(&privKey.PublicKey, x509.ECDSAWithSHA384),
+			testsupport.WithExtendedKeyUsage(x509.ExtKeyUsageClientAuth),
+			testsupport.WithOCSPServers([]string{ocspResponder.URL}))
+		require.NoError(t, err)
+
+		return &tls.Certificate{Certificate: [][]byte{cert.Raw}, PrivateKey: privKey}, cert
+	}
+
+	// server key store
+	serverPrivKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+
+	serverCert, err := rootCA.IssueCertificate(
+		testsupport.WithSubject(pkix.Name{CommonName: "server"}),
+		testsupport.WithValidity(time.Now(), time.Hour),
+		testsupport.WithSubjectPubKey(&serverPrivKey.PublicKey, x509.ECDSAWithSHA384),
+		testsupport.WithIPAddresses([]net.IP{net.ParseIP("127.0.0.1")}))
+	require.NoError(t, err)
+
+	keyStorePEM, err := pemx.BuildPEM(
+		pemx.WithECDSAPrivateKey(serverPrivKey),
+		pemx.WithX509Certificate(serverCert),
+	)
+	require.NoError(t, err)
+
+	keyStorePath := filepath.Join(testDir, "keystore.pem")
+	require.NoError(t, os.WriteFile(keyStorePath, keyStorePEM, 0o600))
+
+	// client trust store
+	trustStorePEM, err := pemx.BuildPEM(pemx.WithX509Certificate(rootCA.Certificate))
+	require.NoError(t, err)
+
+	trustStorePath := filepath.Join(testDir, "truststore.pem")
+	require.NoError(t, os.WriteFile(trustStorePath, trustStorePEM, 0o600))
+
+	// client certificates
+	validCert, validX509Cert := issueClientCert(rootCA)
+	revokedCert, revokedX509Cert := issueClientCert(rootCA)
+	unknownCert, _ := issueClientCert(rootCA)
+	untrustedCert, _ := issueClientCert(otherCA)
+
+	ocspStatus[validX509Cert.SerialNumber.String()] = ocsp.Good
+	ocspStatus[revokedX509Cert.SerialNumber.String()] = ocsp.Revoked
+
+	// CRL
+	crl, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:     big.NewInt(1),
+		ThisUpdate: time.Now().Add(-time.Minute),
+		NextUpdate: time.Now().Add(time.Hour),
+		RevokedCertificateEntries: []x509.RevocationListEntry{
+			{SerialNumber: revokedX509Cert.SerialNumber, RevocationTime: time.Now().Add(-time.Minute)},
+		},
+	}, rootCA.Certificate, rootCA.PrivKey)
+	require.NoError(t, err)
+
+	crlPath := filepath.Join(testDir, "crl.pem")
+	require.NoError(t, os.WriteFile(crlPath, pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: crl}), 0o600))
+
+	derCRLPath := filepath.Join(testDir, "crl.der")
+	require.NoError(t, os.WriteFile(derCRLPath, crl, 0o600))
+
+	outdatedCRL, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:     big.NewInt(2),
+		ThisUpdate: time.Now().Add(-2 * time.Hour),
+		NextUpdate: time.Now().Add(-time.Hour),
+	}, rootCA.Certificate, rootCA.PrivKey)
+	require.NoError(t, err)
+
+	outdatedCRLPath := filepath.Join(testDir, "outdated_crl.der")
+	require.NoError(t, os.WriteFile(outdatedCRLPath, outdatedCRL, 0o600))
+
+	tlsConf := func(clientAuth *config.ClientAuth) *config.TLS {
+		return &config.TLS{KeyStore: config.KeyStore{Path: keyStorePath}, ClientAuth: clientAuth}
+	}
+
+	for _, tc := range []struct {
+		uc         string
+		clientAuth *config.ClientAuth
+		clientCert *tls.Certificate
+		assert     func(t *testing.T, err error)
+	}{
+		{
+			uc:         "verification without trust store",
+			clientAuth: &config.ClientAuth{Mode: config.ClientAuthMode(tls.RequireAndVerifyClientCert)},
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "requires a trust store")
+			},
+		},
+		{
+			uc: "verification with not existing trust store",
+			clientAuth: &config.ClientAuth{
+				Mode:       config.ClientAuthMode(tls.RequireAndVerifyClientCert),
+				TrustStore: "/no/such/file",
+			},
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed loading client auth trust store")
+			},
+		},
+		{
+			uc: "verification with not existing CRL",
+			clientAuth: &config.ClientAuth{
+				Mode:       config.ClientAuthMode(tls.RequireAndVerifyClientCert),
+				TrustStore: trustStorePath,
+				Revocation: &config.RevocationCheck{CRLs: []string{"/no/such/file"}},
+			},
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed loading CRL")
+			},
+		},
+		{
+			uc: "requiring client certificate with trust store",
+			clientAuth: &config.ClientAuth{
+				Mode:       config.ClientAuthMode(tls.RequireAnyClientCert),
+				TrustStore: trustStorePath,
+			},
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "only supported if client certificates are verified")
+			},
+		},
+		{
+			uc: "requesting client certificate with revocation check",
+			clientAuth: &config.ClientAuth{
+				Mode:       config.ClientAuthMode(tls.RequestClientCert),
+				Revocation: &config.RevocationCheck{OCSP: true},
+			},
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "only supported if client certificates are verified")
+			},
+		},
+		{
+			uc:         "requesting client certificate, which is not presented",
+			clientAuth: &config.ClientAuth{Mode: config.ClientAuthMode(tls.RequestClientCert)},
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.NoError(t, err)
+			},
+		},
+		{
+			uc:         "requiring client certificate, which is not presented",
+			clientAuth: &config.ClientAuth{Mode: config.ClientAuthMode(tls.RequireAnyClientCert)},
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+			},
+		},
+		{
+			uc:         "requiring client certificate, which is not trusted",
+			clientAuth: &config.ClientAuth{Mode: config.ClientAuthMode(tls.RequireAnyClientCert)},
+			clientCert: untrustedCert,
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.NoError(t, err)
+			},
+		},
+		{
+			uc: "verifying client certificate, which is not presented",
+			clientAuth: &config.ClientAuth{
+				Mode:       config.ClientAuthMode(tls.RequireAndVerifyClientCert),
+				TrustStore: trustStorePath,
+			},
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+			},
+		},
+		{
+			uc: "verifying client certificate, which is not trusted",
+			clientAuth: &config.ClientAuth{
+				Mode:       config.ClientAuthMode(tls.RequireAndVerifyClientCert),
+				TrustStore: trustStorePath,
+			},
+			clientCert: untrustedCert,
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "certificate signed by unknown authority")
+			},
+		},
+		{
+			uc: "verifying trusted client certificate without revocation check",
+			clientAuth: &config.ClientAuth{
+				Mode:       config.ClientAuthMode(tls.RequireAndVerifyClientCert),
+				TrustStore: trustStorePath,
+			},
+			clientCert: revokedCert,
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.NoError(t, err)
+			},
+		},
+		{
+			uc: "verifying client certificate revoked according to PEM encoded CRL",
+			clientAuth: &config.ClientAuth{
+				Mode:       config.ClientAuthMode(tls.RequireAndVerifyClientCert),
+				TrustStore: trustStorePath,
+				Revocation: &config.RevocationCheck{CRLs: []string{crlPath}},
+			},
+			clientCert: revokedCert,
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, ErrCertificateRevoked)
+				assert.Contains(t, err.Error(), "CRL")
+			},
+		},
+		{
+			uc: "verifying not revoked client certificate using DER encoded CRL",
+			clientAuth: &config.ClientAuth{
+				Mode:       config.ClientAuthMode(tls.RequireAndVerifyClientCert),
+				TrustStore: trustStorePath,
+				Revocation: &config.RevocationCheck{CRLs: []string{derCRLPath}},
+			},
+			clientCert: validCert,
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.NoError(t, err)
+			},
+		},
+		{
+			uc: "verifying client certificate using outdated CRL",
+			clientAuth: &config.ClientAuth{
+				Mode:       config.ClientAuthMode(tls.RequireAndVerifyClientCert),
+				TrustStore: trustStorePath,
+				Revocation: &config.RevocationCheck{CRLs: []string{outdatedCRLPath}},
+			},
+			clientCert: validCert,
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, ErrRevocationStatusUnknown)
+				assert.Contains(t, err.Error(), "outdated")
+			},
+		},
+		{
+			uc: "verifying client certificate using outdated CRL and soft fail",
+			clientAuth: &config.ClientAuth{
+				Mode:       config.ClientAuthMode(tls.RequireAndVerifyClientCert),
+				TrustStore: trustStorePath,
+				Revocation: &config.RevocationCheck{CRLs: []string{outdatedCRLPath}, SoftFail: true},
+			},
+			clientCert: validCert,
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.NoError(t, err)
+			},
+		},
+		{
+			uc: "verifying client certificate revoked according to OCSP",
+			clientAuth: &config.ClientAuth{
+				Mode:       config.ClientAuthMode(tls.RequireAndVerifyClientCert),
+				TrustStore: trustStorePath,
+				Revocation: &config.RevocationCheck{OCSP: true},
+			},
+			clientCert: revokedCert,
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, ErrCertificateRevoked)
+				assert.Contains(t, err.Error(), "OCSP")
+			},
+		},
+		{
+			uc: "verifying good client certificate using OCSP",
+			clientAuth: &config.ClientAuth{
+				Mode:       config.ClientAuthMode(tls.RequireAndVerifyClientCert),
+				TrustStore: trustStorePath,
+				Revocation: &config.RevocationCheck{OCSP: true},
+			},
+			clientCert: validCert,
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.NoError(t, err)
+			},
+		},
+		{
+			uc: "verifying client certificate with failing OCSP responder",
+			clientAuth: &config.ClientAuth{
+				Mode:       config.ClientAuthMode(tls.RequireAndVerifyClientCert),
+				TrustStore: trustStorePath,
+				Revocation: &config.RevocationCheck{OCSP: true},
+			},
+			clientCert: unknownCert,
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, ErrRevocationStatusUnknown)
+			},
+		},
+		{
+			uc: "verifying client certificate with failing OCSP responder and soft fail",
+			clientAuth: &config.ClientAuth{
+				Mode:       config.ClientAuthMode(tls.RequireAndVerifyClientCert),
+				TrustStore: trustStorePath,
+				Revocation: &config.RevocationCheck{OCSP: true, SoftFail: true},
+			},
+			clientCert: unknownCert,
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.NoError(t, err)
+			},
+		},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			conf := tlsConf(tc.clientAuth)
+
+			if _, err := New("tcp", "127.0.0.1:0", conf); err != nil {
+				tc.assert(t, err)
+
+				return
+			}
+
+			tc.assert(t, handshake(t, conf, tc.clientCert, rootCAs))
+		})
+	}
+}
+
+func TestRevocationCheckerReloadsModifiedCRLs(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	rootCA, err := testsupport.NewRootCA("Test Root CA", time.Hour*24)
+	require.NoError(t, err)
+
+	cert, err := rootCA.IssueCertificate(
+		testsupport.WithSubject(pkix.Name{CommonName: "client"}),
+		testsupport.WithValidity(time.Now(), time.Hour),
+		testsupport.WithSubjectPubKey(&rootCA.PrivKey.PublicKey, x509.ECDSAWithSHA384),
+		testsupport.WithExtendedKeyUsage(x509.ExtKeyUsageClientAuth))
+	require.NoError(t, err)
+
+	createCRL := func(number int64, revoked ...x509.RevocationListEntry) []byte {
+		crl, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+			Number:                    big.NewInt(number),
+			ThisUpdate:                time.Now().Add(-time.Minute),
+			NextUpdate:                time.Now().Add(time.Hour),
+			RevokedCertificateEntries: revoked,
+		}, rootCA.Certificate, rootCA.PrivKey)
+		require.NoError(t, err)
+
+		return crl
+	}
+
+	crlPath := filepath.Join(t.TempDir(), "crl.der")
+	require.NoError(t, os.WriteFile(crlPath, createCRL(1), 0o600))
+
+	checker, err := newRevocationChecker(&config.RevocationCheck{CRLs: []string{crlPath}})
+	require.NoError(t, err)
+
+	chains := [][]*x509.Certificate{{cert, rootCA.Certificate}}
+	require.NoError(t, checker.verifyConnection(tls.ConnectionState{VerifiedChains: chains}))
+
+	require.NoError(t, os.WriteFile(crlPath, createCRL(2, x509.RevocationListEntry{
+		SerialNumber: cert.SerialNumber, RevocationTime: time.Now(),
+	}), 0o600))
+	require.NoError(t, os.Chtimes(crlPath, time.Now(), time.Now().Add(time.Second)))
+
+	// WHEN
+	errBeforeReload := checker.verifyConnection(tls.ConnectionState{VerifiedChains: chains})
+
+	checker.crlsLoadedAt = time.Now().Add(-crlReloadInterval)
+	errAfterReload := checker.verifyConnection(tls.ConnectionState{VerifiedChains: chains})
+
+	// THEN
+	require.NoError(t, errBeforeReload)
+	require.ErrorIs(t, errAfterReload, ErrCertificateRevoked)
+}
+
+func TestRevocationCheckerVerifiesResumedSessions(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	rootCA, err := testsupport.NewRootCA("Test Root CA", time.Hour*24)
+	require.NoError(t, err)
+
+	issueCert := func(opts ...testsupport.CertificateBuilderOption) tls.Certificate {
+		privKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+		require.NoError(t, err)
+
+		cert, err := rootCA.IssueCertificate(append(opts,
+			testsupport.WithValidity(time.Now(), time.Hour),
+			testsupport.WithSubjectPubKey(&privKey.PublicKey, x509.ECDSAWithSHA384))...)
+		require.NoError(t, err)
+
+		return tls.Certificate{Certificate: [][]byte{cert.Raw}, PrivateKey: privKey, Leaf: cert}
+	}
+
+	serverCert := issueCert(
+		testsupport.WithSubject(pkix.Name{CommonName: "server"}),
+		testsupport.WithIPAddresses([]net.IP{net.ParseIP("127.0.0.1")}))
+	clientCert := issueCert(
+		testsupport.WithSubject(pkix.Name{CommonName: "client"}),
+		testsupport.WithExtendedKeyUsage(x509.ExtKeyUsageClientAuth))
+
+	createCRL := func(number int64, revoked ...x509.RevocationListEntry) []byte {
+		crl, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+			Number:                    big.NewInt(number),
+			ThisUpdate:                time.Now().Add(-time.Minute),
+			NextUpdate:                time.Now().Add(time.Hour),
+			RevokedCertificateEntries: revoked,
+		}, rootCA.Certificate, rootCA.PrivKey)
+		require.NoError(t, err)
+
+		return crl
+	}
+
+	crlPath := filepath.Join(t.TempDir(), "crl.der")
+	require.NoError(t, os.WriteFile(crlPath, createCRL(1), 0o600))
+
+	checker, err := newRevocationChecker(&config.RevocationCheck{CRLs: []string{crlPath}})
+	require.NoError(t, err)
+
+	certPool := x509.NewCertPool()
+	certPool.AddCert(rootCA.Certificate)
+
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates:     []tls.Certificate{serverCert},
+		ClientAuth:       tls.RequireAndVerifyClientCert,
+		ClientCAs:        certPool,
+		VerifyConnection: checker.verifyConnection,
+		MinVersion:       tls.VersionTLS13,
+	})
+	require.NoError(t, err)
+
+	defer ln.Close()
+
+	clientConf := &tls.Config{
+		RootCAs:            certPool,
+		Certificates:       []tls.Certificate{clientCert},
+		ClientSessionCache: tls.NewLRUClientSessionCache(1),
+		MinVersion:         tls.VersionTLS13,
+	}
+
+	handshake := func() (bool, error) {
+		result := make(chan error, 1)
+		resumed := make(chan bool, 1)
+
+		go func() {
+			con, err := ln.Accept()
+			if err != nil {
+				resumed <- false
+				result <- err
+
+				return
+			}
+
+			defer con.Close()
+
+			tlsCon, ok := con.(*tls.Conn)
+			require.True(t, ok)
+
+			err = tlsCon.Handshake()
+			resumed <- tlsCon.ConnectionState().DidResume
+			result <- err
+		}()
+
+		con, err := tls.Dial("tcp", ln.Addr().String(), clientConf)
+		if err == nil {
+			// reading lets the client receive the session ticket
+			_, _ = io.ReadAll(con)
+			con.Close()
+		}
+
+		return <-resumed, <-result
+	}
+
+	_, err = handshake()
+	require.NoError(t, err)
+
+	resumed, err := handshake()
+	require.NoError(t, err)
+	require.True(t, resumed)
+
+	require.NoError(t, os.WriteFile(crlPath, createCRL(2, x509.RevocationListEntry{
+		SerialNumber: clientCert.Leaf.SerialNumber, RevocationTime: time.Now(),
+	}), 0o600))
+	require.NoError(t, os.Chtimes(crlPath, time.Now(), time.Now().Add(time.Second)))
+
+	checker.crlsLoadedAt = time.Now().Add(-crlReloadInterval)
+
+	// WHEN
+	_, err = handshake()
+
+	// THEN
+	require.ErrorIs(t, err, ErrCertificateRevoked)
+}
+
+func TestRevocationCheckerCachesFailedOCSPLookups(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	rootCA, err := testsupport.NewRootCA("Test Root CA", time.Hour*24)
+	require.NoError(t, err)
+
+	var requests atomic.Int32
+
+	ocspResponder := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		rw.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ocspResponder.Close()
+
+	cert, err := rootCA.IssueCertificate(
+		testsupport.WithSubject(pkix.Name{CommonName: "client"}),
+		testsupport.WithValidity(time.Now(), time.Hour),
+		testsupport.WithSubjectPubKey(&rootCA.PrivKey.PublicKey, x509.ECDSAWithSHA384),
+		testsupport.WithExtendedKeyUsage(x509.ExtKeyUsageClientAuth),
+		testsupport.WithOCSPServers([]string{ocspResponder.URL, ocspResponder.URL}))
+	require.NoError(t, err)
+
+	checker, err := newRevocationChecker(&config.RevocationCheck{OCSP: true})
+	require.NoError(t, err)
+
+	chains := [][]*x509.Certificate{{cert, rootCA.Certificate}}
+
+	// WHEN
+	err1 := checker.verifyConnection(tls.ConnectionState{VerifiedChains: chains})
+	err2 := checker.verifyConnection(tls.ConnectionState{VerifiedChains: chains})
+
+	// THEN
+	require.ErrorIs(t, err1, ErrRevocationStatusUnknown)
+	require.ErrorIs(t, err2, ErrRevocationStatusUnknown)
+	assert.Equal(t, int32(2), requests.Load())
+}
+
+func TestRevocationCheckerRejectsOutdatedOCSPResponses(t *testing.T) {
+	t.Parallel()
+
+	rootCA, err := testsupport.NewRootCA("Test Root CA", time.Hour*24)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		uc         string
+		thisUpdate time.Time
+		nextUpdate time.Time
+		softFail   bool
+		assert     func(t *testing.T, err error)
+	}{
+		{
+			uc:         "response valid at the current time",
+			thisUpdate: time.Now().Add(-time.Minute),
+			nextUpdate: time.Now().Add(time.Hour),
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.NoError(t, err)
+			},
+		},
+		{
+			uc:         "response without next update",
+			thisUpdate: time.Now().Add(-time.Minute),
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.NoError(t, err)
+			},
+		},
+		{
+			uc:         "response valid within the allowed clock skew",
+			thisUpdate: time.Now().Add(ocspClockSkew / 2),
+			nextUpdate: time.Now().Add(time.Hour),
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.NoError(t, err)
+			},
+		},
+		{
+			uc:         "response issued in the future",
+			thisUpdate: time.Now().Add(time.Hour),
+			nextUpdate: time.Now().Add(2 * time.Hour),
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, ErrRevocationStatusUnknown)
+				assert.Contains(t, err.Error(), "not valid at the current time")
+			},
+		},
+		{
+			uc:         "outdated response",
+			thisUpdate: time.Now().Add(-2 * time.Hour),
+			nextUpdate: time.Now().Add(-time.Hour),
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, ErrRevocationStatusUnknown)
+				assert.Contains(t, err.Error(), "not valid at the current time")
+			},
+		},
+		{
+			uc:         "outdated response and soft fail",
+			thisUpdate: time.Now().Add(-2 * time.Hour),
+			nextUpdate: time.Now().Add(-time.Hour),
+			softFail:   true,
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.NoError(t, err)
+			},
+		},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			// GIVEN
+			ocspResponder := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				data, err := io.ReadAll(req.Body)
+				require.NoError(t, err)
+
+				ocspReq, err := ocsp.ParseRequest(data)
+				require.NoError(t, err)
+
+				resp, err := ocsp.CreateResponse(rootCA.Certificate, rootCA.Certificate, ocsp.Response{
+					Status:       ocsp.Good,
+					SerialNumber: ocspReq.SerialNumber,
+					ThisUpdate:   tc.thisUpdate,
+					NextUpdate:   tc.nextUpdate,
+				}, rootCA.PrivKey)
+				require.NoError(t, err)
+
+				rw.Header().Set("Content-Type", "application/ocsp-response")
+				_, err = rw.Write(resp)
+				require.NoError(t, err)
+			}))
+			defer ocspResponder.Close()
+
+			cert, err := rootCA.IssueCertificate(
+				testsupport.WithSubject(pkix.Name{CommonName: "client"}),
+				testsupport.WithValidity(time.Now(), time.Hour),
+				testsupport.WithSubjectPubKey(&rootCA.PrivKey.PublicKey, x509.ECDSAWithSHA384),
+				testsupport.WithExtendedKeyUsage(x509.ExtKeyUsageClientAuth),
+				testsupport.WithOCSPServers([]string{ocspResponder.URL}))
+			require.NoError(t, err)
+
+			checker, err := newRevocationChecker(&config.RevocationCheck{OCSP: true, SoftFail: tc.softFail})
+			require.NoError(t, err)
+
+			// WHEN
+			err = checker.verifyConnection(tls.ConnectionState{
+				VerifiedChains: [][]*x509.Certificate{{cert, rootCA.Certificate}},
+			})
+
+			// THEN
+			tc.assert(t, err)
+		})
+	}
+}
@@ -145,5 +145,11 @@ func newTLSListener(tlsConf *config.TLS, listener net.Listener) (net.Listener, e
 		cfg.CipherSuites = tlsConf.CipherSuites.OrDefault()
 	}
 
+	if tlsConf.ClientAuth != nil {
+		if err = configureClientAuth(cfg, tlsConf.ClientAuth); err != nil {
+			return nil, err
+		}
+	}
+
 	return tls.NewListener(listener, cfg), nil
 }
@@ -0,0 +1,390 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package listener
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/dadrus/heimdall/internal/config"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+const (
+	defaultOCSPTimeout      = 5 * time.Second
+	defaultOCSPCacheTTL     = 1 * time.Minute
+	ocspFailureCacheTTL     = 30 * time.Second
+	ocspClockSkew           = 5 * time.Minute
+	crlReloadInterval       = 1 * time.Minute
+	maxOCSPResponseSize     = 64 * 1024
+	pemBlockTypeCRL         = "X509 CRL"
+	ocspRequestContentType  = "application/ocsp-request"
+	ocspResponseContentType = "application/ocsp-response"
+)
+
+var (
+	ErrCertificateRevoked      = errors.New("certificate revoked")
+	ErrRevocationStatusUnknown = errors.New("revocation status unknown")
+)
+
+// crlSource holds a CRL together with the modification time of the file it has been loaded from.
+type crlSource struct {
+	path    string
+	modTime time.Time
+	crl     *x509.RevocationList
+}
+
+// reload loads the CRL again if the file has been modified. If that fails, the previously
+// loaded CRL is kept. If it is outdated, the checks relying on it will fail.
+func (s *crlSource) reload() {
+	fInfo, err := os.Stat(s.path)
+	if err != nil || fInfo.ModTime().Equal(s.modTime) {
+		return
+	}
+
+	if crl, err := loadCRL(s.path); err == nil {
+		s.crl = crl
+		s.modTime = fInfo.ModTime()
+	}
+}
+
+// ocspResult is a cached result of an OCSP lookup. Failed lookups are cached as well, to not
+// block every handshake of the affected clients until the responder becomes available again.
+type ocspResult struct {
+	resp  *ocsp.Response
+	err   error
+	until time.Time
+}
+
+type revocationChecker struct {
+	ocsp     bool
+	softFail bool
+	timeout  time.Duration
+	client   *http.Client
+
+	crlMu        sync.RWMutex
+	crls         []*crlSource
+	crlsLoadedAt time.Time
+
+	ocspMu      sync.Mutex
+	ocspResults map[string]*ocspResult
+	ocspLookups singleflight.Group
+}
+
+func newRevocationChecker(conf *config.RevocationCheck) (*revocationChecker, error) {
+	crls := make([]*crlSource, len(conf.CRLs))
+
+	for idx, path := range conf.CRLs {
+		fInfo, err := os.Stat(path)
+		if err != nil {
+			return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+				"failed loading CRL from %s", path).CausedBy(err)
+		}
+
+		crl, err := loadCRL(path)
+		if err != nil {
+			return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+				"failed loading CRL from %s", path).CausedBy(err)
+		}
+
+		crls[idx] = &crlSource{path: path, modTime: fInfo.ModTime(), crl: crl}
+	}
+
+	timeout := defaultOCSPTimeout
+	if conf.OCSPTimeout != nil {
+		timeout = *conf.OCSPTimeout
+	}
+
+	return &revocationChecker{
+		crls:         crls,
+		crlsLoadedAt: time.Now(),
+		ocsp:         conf.OCSP,
+		softFail:     conf.SoftFail,
+		timeout:      timeout,
+		client:       &http.Client{},
+		ocspResults:  make(map[string]*ocspResult),
+	}, nil
+}
+
+func loadCRL(path string) (*x509.RevocationList, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// CRLs are accepted in PEM and in DER encoding
+	if block, _ := pem.Decode(data); block != nil {
+		if block.Type != pemBlockTypeCRL {
+			return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+				"unexpected PEM block '%s'", block.Type)
+		}
+
+		data = block.Bytes
+	}
+
+	return x509.ParseRevocationList(data)
+}
+
+// verifyConnection is called by the TLS stack after the peer certificate has been verified
+// and checks the certificates of the first verified chain for revocation. The certificate of
+// the trust anchor is not checked. Unlike tls.Config.VerifyPeerCertificate, it is called for
+// resumed sessions as well, so that a certificate revoked in the meantime is rejected.
+func (c *revocationChecker) verifyConnection(state tls.ConnectionState) error {
+	if len(state.VerifiedChains) == 0 {
+		return nil
+	}
+
+	chain := state.VerifiedChains[0]
+	now := time.Now()
+	crls := c.currentCRLs(now)
+
+	for idx := 0; idx < len(chain)-1; idx++ {
+		if err := c.checkCRLs(crls, chain[idx], chain[idx+1], now); err != nil {
+			return err
+		}
+	}
+
+	if c.ocsp && len(chain) > 1 {
+		return c.checkOCSP(chain[0], chain[1])
+	}
+
+	return nil
+}
+
+// currentCRLs returns the loaded CRLs. These are reloaded from the files they have been loaded
+// from, if the latter have been modified, but not more often than every crlReloadInterval.
+func (c *revocationChecker) currentCRLs(now time.Time) []*x509.RevocationList {
+	c.crlMu.RLock()
+	due := now.Sub(c.crlsLoadedAt) >= crlReloadInterval
+	c.crlMu.RUnlock()
+
+	if due {
+		c.crlMu.Lock()
+		if now.Sub(c.crlsLoadedAt) >= crlReloadInterval {
+			for _, src := range c.crls {
+				src.reload()
+			}
+
+			c.crlsLoadedAt = now
+		}
+		c.crlMu.Unlock()
+	}
+
+	c.crlMu.RLock()
+	defer c.crlMu.RUnlock()
+
+	crls := make([]*x509.RevocationList, len(c.crls))
+	for idx, src := range c.crls {
+		crls[idx] = src.crl
+	}
+
+	return crls
+}
+
+func (c *revocationChecker) checkCRLs(crls []*x509.RevocationList, cert, issuer *x509.Certificate, now time.Time) error {
+	for _, crl := range crls {
+		if !bytes.Equal(crl.RawIssuer, cert.RawIssuer) || crl.CheckSignatureFrom(issuer) != nil {
+			continue
+		}
+
+		// an outdated CRL does not reflect the certificates revoked since its next update
+		if !crl.NextUpdate.IsZero() && now.After(crl.NextUpdate) {
+			if c.softFail {
+				continue
+			}
+
+			return errorchain.NewWithMessagef(ErrRevocationStatusUnknown,
+				"CRL issued by '%s' is outdated since %s",
+				crl.Issuer.String(), crl.NextUpdate.Format(time.RFC3339))
+		}
+
+		for _, entry := range crl.RevokedCertificateEntries {
+			if entry.SerialNumber.Cmp(cert.SerialNumber) == 0 {
+				return errorchain.NewWithMessagef(ErrCertificateRevoked,
+					"certificate with DN='%s' and SN=%s is revoked according to CRL",
+					cert.Subject.String(), cert.SerialNumber.String())
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *revocationChecker) checkOCSP(cert, issuer *x509.Certificate) error {
+	if len(cert.OCSPServer) == 0 {
+		return nil
+	}
+
+	resp, err := c.ocspResponse(cert, issuer)
+	if err != nil {
+		if c.softFail {
+			return nil
+		}
+
+		return errorchain.NewWithMessagef(ErrRevocationStatusUnknown,
+			"failed to retrieve OCSP response for certificate with DN='%s' and SN=%s",
+			cert.Subject.String(), cert.SerialNumber.String()).CausedBy(err)
+	}
+
+	switch resp.Status {
+	case ocsp.Good:
+		return nil
+	case ocsp.Revoked:
+		return errorchain.NewWithMessagef(ErrCertificateRevoked,
+			"certificate with DN='%s' and SN=%s is revoked according to OCSP",
+			cert.Subject.String(), cert.SerialNumber.String())
+	default:
+		if c.softFail {
+			return nil
+		}
+
+		return errorchain.NewWithMessagef(ErrRevocationStatusUnknown,
+			"OCSP responder does not know certificate with DN='%s' and SN=%s",
+			cert.Subject.String(), cert.SerialNumber.String())
+	}
+}
+
+// ocspResponse returns the cached result of the OCSP lookup for the given certificate, or
+// performs the lookup. Concurrent handshakes with the same certificate share a single lookup,
+// which is bounded by the configured timeout, regardless of the number of responders.
+func (c *revocationChecker) ocspResponse(cert, issuer *x509.Certificate) (*ocsp.Response, error) {
+	digest := sha256.Sum256(issuer.RawSubjectPublicKeyInfo)
+	key := hex.EncodeToString(digest[:]) + ":" + cert.SerialNumber.String()
+
+	c.ocspMu.Lock()
+	result, ok := c.ocspResults[key]
+	c.ocspMu.Unlock()
+
+	if !ok || !time.Now().Before(result.until) {
+		value, _, _ := c.ocspLookups.Do(key, func() (any, error) {
+			return c.lookupOCSP(key, cert, issuer), nil
+		})
+
+		result = value.(*ocspResult) // nolint: forcetypeassert
+	}
+
+	return result.resp, result.err
+}
+
+func (c *revocationChecker) lookupOCSP(key string, cert, issuer *x509.Certificate) *ocspResult {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	var (
+		resp *ocsp.Response
+		req  []byte
+		err  error
+	)
+
+	if req, err = ocsp.CreateRequest(cert, issuer, nil); err == nil {
+		for _, server := range cert.OCSPServer {
+			if resp, err = c.queryOCSPResponder(ctx, server, req, cert, issuer); err == nil {
+				break
+			}
+		}
+	}
+
+	now := time.Now()
+	result := &ocspResult{resp: resp, err: err}
+
+	switch {
+	case err != nil:
+		result.until = now.Add(ocspFailureCacheTTL)
+	case !resp.NextUpdate.IsZero():
+		result.until = resp.NextUpdate
+	default:
+		// the responder has always newer information available
+		result.until = now.Add(defaultOCSPCacheTTL)
+	}
+
+	c.ocspMu.Lock()
+	defer c.ocspMu.Unlock()
+
+	for k, v := range c.ocspResults {
+		if !now.Before(v.until) {
+			delete(c.ocspResults, k)
+		}
+	}
+
+	c.ocspResults[key] = result
+
+	return result
+}
+
+func (c *revocationChecker) queryOCSPResponder(
+	ctx context.Context, server string, ocspReq []byte, cert, issuer *x509.Certificate,
+) (*ocsp.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, server, bytes.NewReader(ocspReq))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", ocspRequestContentType)
+
+	httpResp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, &heimdall.UnexpectedResponseError{StatusCode: httpResp.StatusCode}
+	}
+
+	if contentType := httpResp.Header.Get("Content-Type"); contentType != ocspResponseContentType {
+		return nil, errorchain.NewWithMessagef(heimdall.ErrCommunication,
+			"unexpected content type '%s' of the OCSP response", contentType)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(httpResp.Body, maxOCSPResponseSize))
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := ocsp.ParseResponseForCert(data, cert, issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	// an outdated response might have been replayed and does not reflect a revocation,
+	// which took place since then
+	now := time.Now()
+	if resp.ThisUpdate.After(now.Add(ocspClockSkew)) ||
+		(!resp.NextUpdate.IsZero() && resp.NextUpdate.Before(now.Add(-ocspClockSkew))) {
+		return nil, errorchain.NewWithMessagef(heimdall.ErrCommunication,
+			"OCSP response valid from %s to %s is not valid at the current time",
+			resp.ThisUpdate.Format(time.RFC3339), resp.NextUpdate.Format(time.RFC3339))
+	}
+
+	return resp, nil
+}
@@ -17,6 +17,7 @@
 package management
 
 const (
-	EndpointHealth = "/.well-known/health"
-	EndpointJWKS   = "/.well-known/jwks"
+	EndpointHealth            = "/.well-known/health"
+	EndpointJWKS              = "/.well-known/jwks"
+	EndpointBackchannelLogout = "/backchannel-logout"
 )
@@ -25,14 +25,18 @@ import (
 	"github.com/justinas/alice"
 	"github.com/rs/zerolog"
 
+	"github.com/dadrus/heimdall/internal/backchannel"
 	"github.com/dadrus/heimdall/internal/handler/middleware/http/errorhandler"
 	"github.com/dadrus/heimdall/internal/handler/middleware/http/methodfilter"
 	"github.com/dadrus/heimdall/internal/heimdall"
 )
 
-func newManagementHandler(signer heimdall.JWTSigner, eh errorhandler.ErrorHandler) http.Handler {
+func newManagementHandler(
+	signer heimdall.JWTSigner, registry *backchannel.Registry, eh errorhandler.ErrorHandler,
+) http.Handler {
 	mh := &handler{
 		s:  signer,
+		r:  registry,
 		eh: eh,
 	}
 
@@ -44,12 +48,16 @@ func newManagementHandler(signer heimdall.JWTSigner, eh errorhandler.ErrorHandle
 	mux.Handle(EndpointJWKS,
 		alice.New(methodfilter.New(http.MethodGet)).
 			Then(etag.Handler(http.HandlerFunc(mh.jwks), false)))
+	mux.Handle(EndpointBackchannelLogout,
+		alice.New(methodfilter.New(http.MethodPost)).
+			Then(http.HandlerFunc(mh.backchannelLogout)))
 
 	return mux
 }
 
 type handler struct {
 	s  heimdall.JWTSigner
+	r  *backchannel.Registry
 	eh errorhandler.ErrorHandler
 }
 
@@ -84,3 +92,44 @@ func (h *handler) health(rw http.ResponseWriter, req *http.Request) {
 	rw.Header().Set("Content-Type", "application/json")
 	_, _ = rw.Write(res)
 }
+
+// backchannelLogout implements an endpoint receiving logout tokens according to
+// https://openid.net/specs/openid-connect-backchannel-1_0.html
+func (h *handler) backchannelLogout(rw http.ResponseWriter, req *http.Request) {
+	type logoutError struct {
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+
+	rw.Header().Set("Cache-Control", "no-store")
+
+	logoutToken := req.PostFormValue("logout_token")
+	if len(logoutToken) == 0 {
+		h.writeLogoutError(rw, req, logoutError{Error: "invalid_request", ErrorDescription: "no logout token present"})
+
+		return
+	}
+
+	if err := h.r.HandleLogoutToken(req.Context(), logoutToken); err != nil {
+		zerolog.Ctx(req.Context()).Warn().Err(err).Msg("Failed to process logout token")
+		h.writeLogoutError(rw, req, logoutError{Error: "invalid_request", ErrorDescription: "invalid logout token"})
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+}
+
+func (h *handler) writeLogoutError(rw http.ResponseWriter, req *http.Request, logoutErr any) {
+	res, err := json.Marshal(logoutErr)
+	if err != nil {
+		zerolog.Ctx(req.Context()).Error().Err(err).Msg("Failed to marshal logout error object")
+		h.eh.HandleError(rw, req, err)
+
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusBadRequest)
+	_, _ = rw.Write(res)
+}
@@ -22,6 +22,8 @@ import (
 	"github.com/rs/zerolog"
 	"go.uber.org/fx"
 
+	"github.com/dadrus/heimdall/internal/backchannel"
+	"github.com/dadrus/heimdall/internal/cache"
 	"github.com/dadrus/heimdall/internal/config"
 	"github.com/dadrus/heimdall/internal/handler/fxlcm"
 	"github.com/dadrus/heimdall/internal/heimdall"
@@ -38,14 +40,16 @@ var Module = fx.Invoke( // nolint: gochecknoglobals
 func newLifecycleManager(
 	conf *config.Configuration,
 	logger zerolog.Logger,
+	cch cache.Cache,
 	signer heimdall.JWTSigner,
+	registry *backchannel.Registry,
 ) *fxlcm.LifecycleManager {
 	cfg := conf.Serve.Management
 
 	return &fxlcm.LifecycleManager{
 		ServiceName:    "Management",
 		ServiceAddress: cfg.Address(),
-		Server:         newService(conf, logger, signer),
+		Server:         newService(conf, cch, logger, signer, registry),
 		Logger:         logger,
 		TLSConf:        cfg.TLS,
 	}
@@ -26,8 +26,11 @@ import (
 	"github.com/rs/zerolog"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 
+	"github.com/dadrus/heimdall/internal/backchannel"
+	"github.com/dadrus/heimdall/internal/cache"
 	"github.com/dadrus/heimdall/internal/config"
 	"github.com/dadrus/heimdall/internal/handler/middleware/http/accesslog"
+	cachemiddleware "github.com/dadrus/heimdall/internal/handler/middleware/http/cache"
 	"github.com/dadrus/heimdall/internal/handler/middleware/http/dump"
 	errorhandler2 "github.com/dadrus/heimdall/internal/handler/middleware/http/errorhandler"
 	"github.com/dadrus/heimdall/internal/handler/middleware/http/logger"
@@ -42,8 +45,10 @@ import (
 
 func newService(
 	conf *config.Configuration,
+	cch cache.Cache,
 	log zerolog.Logger,
 	signer heimdall.JWTSigner,
+	registry *backchannel.Registry,
 ) *http.Server {
 	cfg := conf.Serve.Management
 	eh := errorhandler2.New()
@@ -82,7 +87,8 @@ func newService(
 			},
 			func() func(http.Handler) http.Handler { return passthrough.New },
 		),
-	).Then(newManagementHandler(signer, eh))
+		cachemiddleware.New(cch),
+	).Then(newManagementHandler(signer, registry, eh))
 
 	return &http.Server{
 		Handler:        hc,
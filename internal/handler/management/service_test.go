@@ -23,8 +23,11 @@ import (
 	"crypto/rand"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"errors"
 	"io"
 	"net/http"
+	"net/url"
+	"strings"
 	"testing"
 	"time"
 
@@ -33,6 +36,9 @@ import (
 	"github.com/rs/zerolog/log"
 	"github.com/stretchr/testify/suite"
 
+	"github.com/dadrus/heimdall/internal/backchannel"
+	"github.com/dadrus/heimdall/internal/cache"
+	"github.com/dadrus/heimdall/internal/cache/memory"
 	"github.com/dadrus/heimdall/internal/config"
 	"github.com/dadrus/heimdall/internal/handler/listener"
 	"github.com/dadrus/heimdall/internal/heimdall/mocks"
@@ -48,10 +54,11 @@ type ServiceTestSuite struct {
 	ee1     *testsupport.EndEntity
 	ee2     *testsupport.EndEntity
 
-	srv    *http.Server
-	ks     keystore.KeyStore
-	signer *mocks.JWTSignerMock
-	addr   string
+	srv      *http.Server
+	ks       keystore.KeyStore
+	signer   *mocks.JWTSignerMock
+	registry *backchannel.Registry
+	addr     string
 }
 
 func (suite *ServiceTestSuite) SetupSuite() {
@@ -128,7 +135,8 @@ func (suite *ServiceTestSuite) SetupTest() {
 	suite.addr = "http://" + listener.Addr().String()
 
 	suite.signer = mocks.NewJWTSignerMock(suite.T())
-	suite.srv = newService(conf, log.Logger, suite.signer)
+	suite.registry = backchannel.NewRegistry()
+	suite.srv = newService(conf, memory.New(), log.Logger, suite.signer, suite.registry)
 
 	go func() {
 		err = suite.srv.Serve(listener)
@@ -268,3 +276,75 @@ func (suite *ServiceTestSuite) TestHealthRequest() {
 
 	suite.JSONEq(`{ "status": "ok"}`, string(rawResp))
 }
+
+type logoutTokenHandlerFunc func(ctx context.Context, rawToken string) error
+
+func (f logoutTokenHandlerFunc) HandleLogoutToken(ctx context.Context, rawToken string) error {
+	return f(ctx, rawToken)
+}
+
+func (suite *ServiceTestSuite) TestBackchannelLogoutRequest() {
+	suite.registry.Register(logoutTokenHandlerFunc(func(ctx context.Context, rawToken string) error {
+		if rawToken != "valid-token" {
+			return errors.New("invalid token")
+		}
+
+		// the cache must be available to record the logout event
+		cache.Ctx(ctx).Set(ctx, "logout", rawToken, time.Minute)
+
+		return nil
+	}))
+
+	for _, tc := range []struct {
+		uc       string
+		token    string
+		code     int
+		response string
+	}{
+		{
+			uc:       "without logout token",
+			code:     http.StatusBadRequest,
+			response: `{"error":"invalid_request", "error_description":"no logout token present"}`,
+		},
+		{
+			uc:       "with invalid logout token",
+			token:    "invalid-token",
+			code:     http.StatusBadRequest,
+			response: `{"error":"invalid_request", "error_description":"invalid logout token"}`,
+		},
+		{
+			uc:    "with valid logout token",
+			token: "valid-token",
+			code:  http.StatusOK,
+		},
+	} {
+		suite.Run(tc.uc, func() {
+			// GIVEN
+			client := &http.Client{Transport: &http.Transport{}}
+			req, err := http.NewRequestWithContext(context.TODO(), http.MethodPost,
+				suite.addr+EndpointBackchannelLogout,
+				strings.NewReader(url.Values{"logout_token": []string{tc.token}}.Encode()))
+			suite.Require().NoError(err)
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+			// WHEN
+			resp, err := client.Do(req)
+
+			// THEN
+			suite.Require().NoError(err)
+			suite.Require().Equal(tc.code, resp.StatusCode)
+			suite.Equal("no-store", resp.Header.Get("Cache-Control"))
+
+			defer resp.Body.Close()
+
+			rawResp, err := io.ReadAll(resp.Body)
+			suite.Require().NoError(err)
+
+			if len(tc.response) != 0 {
+				suite.JSONEq(tc.response, string(rawResp))
+			} else {
+				suite.Empty(rawResp)
+			}
+		})
+	}
+}
@@ -23,11 +23,12 @@ import (
 )
 
 var defaultOptions = opts{ //nolint:gochecknoglobals
-	authenticationError: responseWith(codes.Unauthenticated, http.StatusUnauthorized),
-	authorizationError:  responseWith(codes.PermissionDenied, http.StatusForbidden),
-	communicationError:  responseWith(codes.DeadlineExceeded, http.StatusBadGateway),
-	preconditionError:   responseWith(codes.InvalidArgument, http.StatusBadRequest),
-	badMethodError:      responseWith(codes.InvalidArgument, http.StatusMethodNotAllowed),
-	noRuleError:         responseWith(codes.NotFound, http.StatusNotFound),
-	internalError:       responseWith(codes.Internal, http.StatusInternalServerError),
+	authenticationError:  responseWith(codes.Unauthenticated, http.StatusUnauthorized),
+	authorizationError:   responseWith(codes.PermissionDenied, http.StatusForbidden),
+	communicationError:   responseWith(codes.DeadlineExceeded, http.StatusBadGateway),
+	preconditionError:    responseWith(codes.InvalidArgument, http.StatusBadRequest),
+	badMethodError:       responseWith(codes.InvalidArgument, http.StatusMethodNotAllowed),
+	tooManyRequestsError: responseWith(codes.ResourceExhausted, http.StatusTooManyRequests),
+	noRuleError:          responseWith(codes.NotFound, http.StatusNotFound),
+	internalError:        responseWith(codes.Internal, http.StatusInternalServerError),
 }
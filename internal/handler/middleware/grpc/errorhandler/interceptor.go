@@ -19,6 +19,7 @@ package errorhandler
 import (
 	"context"
 	"errors"
+	"strings"
 
 	envoy_core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	envoy_auth "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
@@ -60,7 +61,9 @@ func (h *interceptor) intercept(
 
 	switch {
 	case errors.Is(err, heimdall.ErrAuthentication):
-		return h.authenticationError(err, h.verboseErrors, acceptType(req))
+		resp, respErr := h.authenticationError(err, h.verboseErrors, acceptType(req))
+
+		return withWWWAuthenticateHeader(resp, err), respErr
 	case errors.Is(err, heimdall.ErrAuthorization):
 		return h.authorizationError(err, h.verboseErrors, acceptType(req))
 	case errors.Is(err, heimdall.ErrCommunicationTimeout) || errors.Is(err, heimdall.ErrCommunication):
@@ -68,7 +71,13 @@ func (h *interceptor) intercept(
 	case errors.Is(err, heimdall.ErrArgument):
 		return h.preconditionError(err, h.verboseErrors, acceptType(req))
 	case errors.Is(err, heimdall.ErrMethodNotAllowed):
-		return h.badMethodError(err, h.verboseErrors, acceptType(req))
+		resp, respErr := h.badMethodError(err, h.verboseErrors, acceptType(req))
+
+		return withAllowHeader(resp, err), respErr
+	case errors.Is(err, heimdall.ErrTooManyRequests):
+		resp, respErr := h.tooManyRequestsError(err, h.verboseErrors, acceptType(req))
+
+		return withRetryAfterHeader(resp, err), respErr
 	case errors.Is(err, heimdall.ErrNoRuleFound):
 		return h.noRuleError(err, h.verboseErrors, acceptType(req))
 	case errors.Is(err, &heimdall.RedirectError{}):
@@ -76,19 +85,27 @@ func (h *interceptor) intercept(
 
 		errors.As(err, &redirectError)
 
+		headers := []*envoy_core.HeaderValueOption{
+			{
+				Header: &envoy_core.HeaderValue{
+					Key:   "Location",
+					Value: redirectError.RedirectTo,
+				},
+			},
+		}
+
+		for _, cookie := range redirectError.Cookies {
+			headers = append(headers, &envoy_core.HeaderValueOption{
+				Header: &envoy_core.HeaderValue{Key: "Set-Cookie", Value: cookie.String()},
+			})
+		}
+
 		return &envoy_auth.CheckResponse{
 			Status: &status.Status{Code: int32(codes.FailedPrecondition)},
 			HttpResponse: &envoy_auth.CheckResponse_DeniedResponse{
 				DeniedResponse: &envoy_auth.DeniedHttpResponse{
-					Status: &envoy_type.HttpStatus{Code: envoy_type.StatusCode(redirectError.Code)},
-					Headers: []*envoy_core.HeaderValueOption{
-						{
-							Header: &envoy_core.HeaderValue{
-								Key:   "Location",
-								Value: redirectError.RedirectTo,
-							},
-						},
-					},
+					Status:  &envoy_type.HttpStatus{Code: envoy_type.StatusCode(redirectError.Code)},
+					Headers: headers,
 				},
 			},
 		}, nil
@@ -101,6 +118,63 @@ func (h *interceptor) intercept(
 	}
 }
 
+func withAllowHeader(resp any, err error) any {
+	var methodErr *heimdall.MethodNotAllowedError
+	if !errors.As(err, &methodErr) || len(methodErr.AllowedMethods) == 0 {
+		return resp
+	}
+
+	checkResp, ok := resp.(*envoy_auth.CheckResponse)
+	if !ok || checkResp.GetDeniedResponse() == nil {
+		return resp
+	}
+
+	deniedResponse := checkResp.GetDeniedResponse()
+	deniedResponse.Headers = append(deniedResponse.Headers, &envoy_core.HeaderValueOption{
+		Header: &envoy_core.HeaderValue{Key: "Allow", Value: strings.Join(methodErr.AllowedMethods, ", ")},
+	})
+
+	return resp
+}
+
+func withRetryAfterHeader(resp any, err error) any {
+	var limitErr *heimdall.TooManyRequestsError
+	if !errors.As(err, &limitErr) {
+		return resp
+	}
+
+	checkResp, ok := resp.(*envoy_auth.CheckResponse)
+	if !ok || checkResp.GetDeniedResponse() == nil {
+		return resp
+	}
+
+	deniedResponse := checkResp.GetDeniedResponse()
+	deniedResponse.Headers = append(deniedResponse.Headers, &envoy_core.HeaderValueOption{
+		Header: &envoy_core.HeaderValue{Key: "Retry-After", Value: limitErr.RetryAfterSeconds()},
+	})
+
+	return resp
+}
+
+func withWWWAuthenticateHeader(resp any, err error) any {
+	var challengeErr *heimdall.AuthenticationChallengeError
+	if !errors.As(err, &challengeErr) {
+		return resp
+	}
+
+	checkResp, ok := resp.(*envoy_auth.CheckResponse)
+	if !ok || checkResp.GetDeniedResponse() == nil {
+		return resp
+	}
+
+	deniedResponse := checkResp.GetDeniedResponse()
+	deniedResponse.Headers = append(deniedResponse.Headers, &envoy_core.HeaderValueOption{
+		Header: &envoy_core.HeaderValue{Key: "WWW-Authenticate", Value: challengeErr.Challenge},
+	})
+
+	return resp
+}
+
 func acceptType(req any) string {
 	if req, ok := req.(*envoy_auth.CheckRequest); ok {
 		return req.GetAttributes().GetRequest().GetHttp().GetHeaders()["accept"]
@@ -21,6 +21,7 @@ import (
 	"net"
 	"net/http"
 	"testing"
+	"time"
 
 	envoy_auth "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
 	envoy_type "github.com/envoyproxy/go-control-plane/envoy/type/v3"
@@ -35,6 +36,7 @@ import (
 
 	"github.com/dadrus/heimdall/internal/handler/middleware/grpc/mocks"
 	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
 )
 
 func TestErrorInterceptor(t *testing.T) {
@@ -47,6 +49,10 @@ func TestErrorInterceptor(t *testing.T) {
 		expGRPCCode codes.Code
 		expHTTPCode envoy_type.StatusCode
 		expBody     string
+		expAllow    string
+		expRetry    string
+		expCookie   string
+		expAuthn    string
 	}{
 		{
 			uc:          "no error",
@@ -61,6 +67,15 @@ func TestErrorInterceptor(t *testing.T) {
 			expGRPCCode: codes.Unauthenticated,
 			expHTTPCode: http.StatusUnauthorized,
 		},
+		{
+			uc:          "authentication error with challenge",
+			interceptor: New(),
+			err: errorchain.New(heimdall.ErrAuthentication).
+				CausedBy(&heimdall.AuthenticationChallengeError{Challenge: `DPoP error="invalid_dpop_proof"`}),
+			expGRPCCode: codes.Unauthenticated,
+			expHTTPCode: http.StatusUnauthorized,
+			expAuthn:    `DPoP error="invalid_dpop_proof"`,
+		},
 		{
 			uc:          "authentication error overridden",
 			interceptor: New(WithAuthenticationErrorCode(http.StatusContinue)),
@@ -186,6 +201,38 @@ func TestErrorInterceptor(t *testing.T) {
 			expHTTPCode: http.StatusMethodNotAllowed,
 			expBody:     "<p>method not allowed</p>",
 		},
+		{
+			uc:          "method error with allowed methods",
+			interceptor: New(),
+			err: errorchain.New(heimdall.ErrMethodNotAllowed).
+				CausedBy(&heimdall.MethodNotAllowedError{AllowedMethods: []string{http.MethodGet, http.MethodPost}}),
+			expGRPCCode: codes.InvalidArgument,
+			expHTTPCode: http.StatusMethodNotAllowed,
+			expAllow:    "GET, POST",
+		},
+		{
+			uc:          "too many requests error default",
+			interceptor: New(),
+			err:         heimdall.ErrTooManyRequests,
+			expGRPCCode: codes.ResourceExhausted,
+			expHTTPCode: http.StatusTooManyRequests,
+		},
+		{
+			uc:          "too many requests error overridden",
+			interceptor: New(WithTooManyRequestsErrorCode(http.StatusServiceUnavailable)),
+			err:         heimdall.ErrTooManyRequests,
+			expGRPCCode: codes.ResourceExhausted,
+			expHTTPCode: http.StatusServiceUnavailable,
+		},
+		{
+			uc:          "too many requests error with retry after",
+			interceptor: New(),
+			err: errorchain.New(heimdall.ErrTooManyRequests).
+				CausedBy(&heimdall.TooManyRequestsError{RetryAfter: 30 * time.Second}),
+			expGRPCCode: codes.ResourceExhausted,
+			expHTTPCode: http.StatusTooManyRequests,
+			expRetry:    "30",
+		},
 		{
 			uc:          "no rule error default",
 			interceptor: New(),
@@ -215,6 +262,18 @@ func TestErrorInterceptor(t *testing.T) {
 			expGRPCCode: codes.FailedPrecondition,
 			expHTTPCode: http.StatusFound,
 		},
+		{
+			uc:          "redirect error with cookies",
+			interceptor: New(),
+			err: &heimdall.RedirectError{
+				RedirectTo: "http://foo.local",
+				Code:       http.StatusFound,
+				Cookies:    []*http.Cookie{{Name: "foo", Value: "bar", Path: "/"}},
+			},
+			expGRPCCode: codes.FailedPrecondition,
+			expHTTPCode: http.StatusFound,
+			expCookie:   "foo=bar; Path=/",
+		},
 		{
 			uc:          "redirect error verbose",
 			interceptor: New(WithVerboseErrors(true)),
@@ -302,6 +361,26 @@ func TestErrorInterceptor(t *testing.T) {
 				require.NotNil(t, deniedResp)
 				assert.Equal(t, tc.expHTTPCode, deniedResp.GetStatus().GetCode())
 				assert.Equal(t, tc.expBody, deniedResp.GetBody())
+
+				var allow, retry, cookie, authn string
+
+				for _, header := range deniedResp.GetHeaders() {
+					switch header.GetHeader().GetKey() {
+					case "Allow":
+						allow = header.GetHeader().GetValue()
+					case "Retry-After":
+						retry = header.GetHeader().GetValue()
+					case "Set-Cookie":
+						cookie = header.GetHeader().GetValue()
+					case "WWW-Authenticate":
+						authn = header.GetHeader().GetValue()
+					}
+				}
+
+				assert.Equal(t, tc.expAllow, allow)
+				assert.Equal(t, tc.expRetry, retry)
+				assert.Equal(t, tc.expCookie, cookie)
+				assert.Equal(t, tc.expAuthn, authn)
 			}
 		})
 	}
@@ -19,14 +19,15 @@ package errorhandler
 import "google.golang.org/grpc/codes"
 
 type opts struct {
-	verboseErrors       bool
-	authenticationError func(err error, verbose bool, mimeType string) (any, error)
-	authorizationError  func(err error, verbose bool, mimeType string) (any, error)
-	communicationError  func(err error, verbose bool, mimeType string) (any, error)
-	preconditionError   func(err error, verbose bool, mimeType string) (any, error)
-	badMethodError      func(err error, verbose bool, mimeType string) (any, error)
-	noRuleError         func(err error, verbose bool, mimeType string) (any, error)
-	internalError       func(err error, verbose bool, mimeType string) (any, error)
+	verboseErrors        bool
+	authenticationError  func(err error, verbose bool, mimeType string) (any, error)
+	authorizationError   func(err error, verbose bool, mimeType string) (any, error)
+	communicationError   func(err error, verbose bool, mimeType string) (any, error)
+	preconditionError    func(err error, verbose bool, mimeType string) (any, error)
+	badMethodError       func(err error, verbose bool, mimeType string) (any, error)
+	tooManyRequestsError func(err error, verbose bool, mimeType string) (any, error)
+	noRuleError          func(err error, verbose bool, mimeType string) (any, error)
+	internalError        func(err error, verbose bool, mimeType string) (any, error)
 }
 
 type Option func(*opts)
@@ -79,6 +80,14 @@ func WithMethodErrorCode(code int) Option {
 	}
 }
 
+func WithTooManyRequestsErrorCode(code int) Option {
+	return func(o *opts) {
+		if code > 0 {
+			o.tooManyRequestsError = responseWith(codes.ResourceExhausted, code)
+		}
+	}
+}
+
 func WithNoRuleErrorCode(code int) Option {
 	return func(o *opts) {
 		if code > 0 {
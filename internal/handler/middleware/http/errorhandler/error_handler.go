@@ -19,6 +19,7 @@ package errorhandler
 import (
 	"errors"
 	"net/http"
+	"strings"
 
 	"github.com/rs/zerolog"
 
@@ -51,6 +52,11 @@ func (h *errorHandler) HandleError(rw http.ResponseWriter, req *http.Request, er
 
 	switch {
 	case errors.Is(err, heimdall.ErrAuthentication):
+		var challengeErr *heimdall.AuthenticationChallengeError
+		if errors.As(err, &challengeErr) {
+			rw.Header().Set("WWW-Authenticate", challengeErr.Challenge)
+		}
+
 		h.onAuthenticationError(rw, req, err)
 	case errors.Is(err, heimdall.ErrAuthorization):
 		h.onAuthorizationError(rw, req, err)
@@ -59,7 +65,19 @@ func (h *errorHandler) HandleError(rw http.ResponseWriter, req *http.Request, er
 	case errors.Is(err, heimdall.ErrArgument):
 		h.onPreconditionError(rw, req, err)
 	case errors.Is(err, heimdall.ErrMethodNotAllowed):
+		var methodErr *heimdall.MethodNotAllowedError
+		if errors.As(err, &methodErr) && len(methodErr.AllowedMethods) != 0 {
+			rw.Header().Set("Allow", strings.Join(methodErr.AllowedMethods, ", "))
+		}
+
 		h.onBadMethodError(rw, req, err)
+	case errors.Is(err, heimdall.ErrTooManyRequests):
+		var limitErr *heimdall.TooManyRequestsError
+		if errors.As(err, &limitErr) {
+			rw.Header().Set("Retry-After", limitErr.RetryAfterSeconds())
+		}
+
+		h.onTooManyRequestsError(rw, req, err)
 	case errors.Is(err, heimdall.ErrNoRuleFound):
 		h.onNoRuleError(rw, req, err)
 	case errors.Is(err, &heimdall.RedirectError{}):
@@ -67,6 +85,10 @@ func (h *errorHandler) HandleError(rw http.ResponseWriter, req *http.Request, er
 
 		errors.As(err, &redirectError)
 
+		for _, cookie := range redirectError.Cookies {
+			http.SetCookie(rw, cookie)
+		}
+
 		rw.Header().Set("Location", redirectError.RedirectTo)
 		rw.WriteHeader(redirectError.Code)
 
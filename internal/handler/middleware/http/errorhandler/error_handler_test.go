@@ -20,6 +20,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -31,12 +32,16 @@ func TestHandlerHandle(t *testing.T) {
 	t.Parallel()
 
 	for _, tc := range []struct {
-		uc      string
-		handler ErrorHandler
-		err     error
-		expCode int
-		accept  string
-		expBody string
+		uc        string
+		handler   ErrorHandler
+		err       error
+		expCode   int
+		accept    string
+		expBody   string
+		expAllow  string
+		expRetry  string
+		expCookie string
+		expAuthn  string
 	}{
 		{
 			uc:      "authentication error default",
@@ -50,6 +55,14 @@ func TestHandlerHandle(t *testing.T) {
 			err:     errorchain.New(heimdall.ErrAuthentication),
 			expCode: http.StatusContinue,
 		},
+		{
+			uc:      "authentication error with challenge",
+			handler: New(),
+			err: errorchain.New(heimdall.ErrAuthentication).
+				CausedBy(&heimdall.AuthenticationChallengeError{Challenge: `DPoP error="invalid_dpop_proof"`}),
+			expCode:  http.StatusUnauthorized,
+			expAuthn: `DPoP error="invalid_dpop_proof"`,
+		},
 		{
 			uc:      "authentication error verbose without mime type set",
 			handler: New(WithVerboseErrors(true)),
@@ -155,6 +168,34 @@ func TestHandlerHandle(t *testing.T) {
 			expCode: http.StatusMethodNotAllowed,
 			expBody: "<p>method not allowed</p>",
 		},
+		{
+			uc:      "method error with allowed methods",
+			handler: New(),
+			err: errorchain.New(heimdall.ErrMethodNotAllowed).
+				CausedBy(&heimdall.MethodNotAllowedError{AllowedMethods: []string{http.MethodGet, http.MethodPost}}),
+			expCode:  http.StatusMethodNotAllowed,
+			expAllow: "GET, POST",
+		},
+		{
+			uc:      "too many requests error default",
+			handler: New(),
+			err:     errorchain.New(heimdall.ErrTooManyRequests),
+			expCode: http.StatusTooManyRequests,
+		},
+		{
+			uc:      "too many requests error overridden",
+			handler: New(WithTooManyRequestsErrorCode(http.StatusServiceUnavailable)),
+			err:     errorchain.New(heimdall.ErrTooManyRequests),
+			expCode: http.StatusServiceUnavailable,
+		},
+		{
+			uc:      "too many requests error with retry after",
+			handler: New(),
+			err: errorchain.New(heimdall.ErrTooManyRequests).
+				CausedBy(&heimdall.TooManyRequestsError{RetryAfter: 1500 * time.Millisecond}),
+			expCode:  http.StatusTooManyRequests,
+			expRetry: "2",
+		},
 		{
 			uc:      "no rule error default",
 			handler: New(),
@@ -180,6 +221,17 @@ func TestHandlerHandle(t *testing.T) {
 			err:     &heimdall.RedirectError{RedirectTo: "http://foo.local", Code: http.StatusFound},
 			expCode: http.StatusFound,
 		},
+		{
+			uc:      "redirect error with cookies",
+			handler: New(),
+			err: &heimdall.RedirectError{
+				RedirectTo: "http://foo.local",
+				Code:       http.StatusFound,
+				Cookies:    []*http.Cookie{{Name: "foo", Value: "bar", Path: "/"}},
+			},
+			expCode:   http.StatusFound,
+			expCookie: "foo=bar; Path=/",
+		},
 		{
 			uc:      "redirect error verbose without mime type",
 			handler: New(WithVerboseErrors(true)),
@@ -219,6 +271,10 @@ func TestHandlerHandle(t *testing.T) {
 
 			assert.Equal(t, tc.expCode, recorder.Code)
 			assert.Equal(t, tc.expBody, recorder.Body.String())
+			assert.Equal(t, tc.expAllow, recorder.Header().Get("Allow"))
+			assert.Equal(t, tc.expRetry, recorder.Header().Get("Retry-After"))
+			assert.Equal(t, tc.expCookie, recorder.Header().Get("Set-Cookie"))
+			assert.Equal(t, tc.expAuthn, recorder.Header().Get("WWW-Authenticate"))
 		})
 	}
 }
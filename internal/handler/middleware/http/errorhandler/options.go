@@ -21,14 +21,15 @@ import (
 )
 
 type opts struct {
-	verboseErrors         bool
-	onAuthenticationError func(rw http.ResponseWriter, req *http.Request, err error)
-	onAuthorizationError  func(rw http.ResponseWriter, req *http.Request, err error)
-	onCommunicationError  func(rw http.ResponseWriter, req *http.Request, err error)
-	onPreconditionError   func(rw http.ResponseWriter, req *http.Request, err error)
-	onBadMethodError      func(rw http.ResponseWriter, req *http.Request, err error)
-	onNoRuleError         func(rw http.ResponseWriter, req *http.Request, err error)
-	onInternalError       func(rw http.ResponseWriter, req *http.Request, err error)
+	verboseErrors          bool
+	onAuthenticationError  func(rw http.ResponseWriter, req *http.Request, err error)
+	onAuthorizationError   func(rw http.ResponseWriter, req *http.Request, err error)
+	onCommunicationError   func(rw http.ResponseWriter, req *http.Request, err error)
+	onPreconditionError    func(rw http.ResponseWriter, req *http.Request, err error)
+	onBadMethodError       func(rw http.ResponseWriter, req *http.Request, err error)
+	onTooManyRequestsError func(rw http.ResponseWriter, req *http.Request, err error)
+	onNoRuleError          func(rw http.ResponseWriter, req *http.Request, err error)
+	onInternalError        func(rw http.ResponseWriter, req *http.Request, err error)
 }
 
 type Option func(*opts)
@@ -81,6 +82,14 @@ func WithMethodErrorCode(code int) Option {
 	}
 }
 
+func WithTooManyRequestsErrorCode(code int) Option {
+	return func(o *opts) {
+		if code != 0 {
+			o.onTooManyRequestsError = errorWriter(o, code)
+		}
+	}
+}
+
 func WithNoRuleErrorCode(code int) Option {
 	return func(o *opts) {
 		if code != 0 {
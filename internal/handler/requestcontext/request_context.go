@@ -19,10 +19,10 @@ package requestcontext
 import (
 	"bytes"
 	"context"
+	"crypto/x509"
 	"io"
 	"net/http"
 	"net/textproto"
-	"net/url"
 	"strings"
 
 	"github.com/dadrus/heimdall/internal/heimdall"
@@ -33,7 +33,7 @@ import (
 
 type RequestContext struct {
 	reqMethod       string
-	reqURL          *url.URL
+	reqURL          *heimdall.URL
 	upstreamHeaders http.Header
 	upstreamCookies map[string]string
 	jwtSigner       heimdall.JWTSigner
@@ -42,6 +42,7 @@ type RequestContext struct {
 
 	// the following properties are created lazy and cached
 
+	rawBody   []byte
 	savedBody any
 	hmdlReq   *heimdall.Request
 	headers   map[string]string
@@ -51,7 +52,7 @@ func New(signer heimdall.JWTSigner, req *http.Request) *RequestContext {
 	return &RequestContext{
 		jwtSigner:       signer,
 		reqMethod:       extractMethod(req),
-		reqURL:          extractURL(req),
+		reqURL:          &heimdall.URL{URL: *extractURL(req)},
 		upstreamHeaders: make(http.Header),
 		upstreamCookies: make(map[string]string),
 		req:             req,
@@ -89,24 +90,12 @@ func (r *RequestContext) Headers() map[string]string {
 }
 
 func (r *RequestContext) Body() any {
-	if r.req.Body == nil || r.req.Body == http.NoBody {
-		return ""
-	}
-
 	if r.savedBody == nil {
-		// drain body by reading its contents into memory and preserving
-		var buf bytes.Buffer
-		if _, err := buf.ReadFrom(r.req.Body); err != nil {
+		body := r.RawBody()
+		if len(body) == 0 {
 			return ""
 		}
 
-		if err := r.req.Body.Close(); err != nil {
-			return ""
-		}
-
-		body := buf.Bytes()
-		r.req.Body = io.NopCloser(bytes.NewReader(body))
-
 		decoder, err := contenttype.NewDecoder(r.Header("Content-Type"))
 		if err != nil {
 			r.savedBody = string(body)
@@ -127,6 +116,40 @@ func (r *RequestContext) Body() any {
 	return r.savedBody
 }
 
+func (r *RequestContext) RawBody() []byte {
+	if r.req.Body == nil || r.req.Body == http.NoBody {
+		return nil
+	}
+
+	if r.rawBody == nil {
+		// drain body by reading its contents into memory and preserving
+		var buf bytes.Buffer
+		if _, err := buf.ReadFrom(r.req.Body); err != nil {
+			return nil
+		}
+
+		if err := r.req.Body.Close(); err != nil {
+			return nil
+		}
+
+		r.rawBody = buf.Bytes()
+		r.req.Body = io.NopCloser(bytes.NewReader(r.rawBody))
+	}
+
+	return r.rawBody
+}
+
+// ClientCertificates returns the verified certificate chain of the client. The certificates
+// presented by the client are not returned if the listener has not been configured to verify
+// these, as anyone can present any certificate.
+func (r *RequestContext) ClientCertificates() []*x509.Certificate {
+	if r.req.TLS == nil || len(r.req.TLS.VerifiedChains) == 0 {
+		return nil
+	}
+
+	return r.req.TLS.VerifiedChains[0]
+}
+
 func (r *RequestContext) Request() *heimdall.Request {
 	if r.hmdlReq == nil {
 		r.hmdlReq = &heimdall.Request{
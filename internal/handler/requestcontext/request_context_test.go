@@ -18,6 +18,8 @@ package requestcontext
 
 import (
 	"bytes"
+	"crypto/tls"
+	"crypto/x509"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -173,6 +175,33 @@ func TestRequestContextCookie(t *testing.T) {
 	assert.Empty(t, value2)
 }
 
+func TestRequestContextClientCertificates(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	cert := &x509.Certificate{Raw: []byte("foo")}
+	caCert := &x509.Certificate{Raw: []byte("bar")}
+
+	plainReq := httptest.NewRequest(http.MethodGet, "http://foo.bar/test", nil)
+	tlsReq := httptest.NewRequest(http.MethodGet, "https://foo.bar/test", nil)
+	tlsReq.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+	verifiedReq := httptest.NewRequest(http.MethodGet, "https://foo.bar/test", nil)
+	verifiedReq.TLS = &tls.ConnectionState{
+		PeerCertificates: []*x509.Certificate{cert},
+		VerifiedChains:   [][]*x509.Certificate{{cert, caCert}},
+	}
+
+	// WHEN
+	plainCerts := New(nil, plainReq).Request().ClientCertificates()
+	tlsCerts := New(nil, tlsReq).Request().ClientCertificates()
+	verifiedCerts := New(nil, verifiedReq).Request().ClientCertificates()
+
+	// THEN
+	assert.Empty(t, plainCerts)
+	assert.Empty(t, tlsCerts)
+	assert.Equal(t, []*x509.Certificate{cert, caCert}, verifiedCerts)
+}
+
 func TestRequestContextBody(t *testing.T) {
 	t.Parallel()
 
@@ -181,6 +210,7 @@ func TestRequestContextBody(t *testing.T) {
 		ct     string
 		body   io.Reader
 		expect any
+		raw    []byte
 	}{
 		{
 			uc:     "No body",
@@ -199,30 +229,35 @@ func TestRequestContextBody(t *testing.T) {
 			ct:     "application/json",
 			body:   bytes.NewBufferString("foo: bar"),
 			expect: "foo: bar",
+			raw:    []byte("foo: bar"),
 		},
 		{
 			uc:     "x-www-form-urlencoded encoded",
 			ct:     "application/x-www-form-urlencoded; charset=utf-8",
 			body:   bytes.NewBufferString("content=heimdall"),
 			expect: map[string]any{"content": []string{"heimdall"}},
+			raw:    []byte("content=heimdall"),
 		},
 		{
 			uc:     "json encoded",
 			ct:     "application/json; charset=utf-8",
 			body:   bytes.NewBufferString(`{ "content": "heimdall" }`),
 			expect: map[string]any{"content": "heimdall"},
+			raw:    []byte(`{ "content": "heimdall" }`),
 		},
 		{
 			uc:     "yaml encoded",
 			ct:     "application/yaml; charset=utf-8",
 			body:   bytes.NewBufferString("content: heimdall"),
 			expect: map[string]any{"content": "heimdall"},
+			raw:    []byte("content: heimdall"),
 		},
 		{
 			uc:     "plain text",
 			ct:     "text/plain",
 			body:   bytes.NewBufferString("content=heimdall"),
 			expect: "content=heimdall",
+			raw:    []byte("content=heimdall"),
 		},
 	} {
 		t.Run(tc.uc, func(t *testing.T) {
@@ -234,9 +269,11 @@ func TestRequestContextBody(t *testing.T) {
 
 			// WHEN
 			data := ctx.Request().Body()
+			raw := ctx.Request().RawBody()
 
 			// THEN
 			assert.Equal(t, tc.expect, data)
+			assert.Equal(t, string(tc.raw), string(raw))
 		})
 	}
 }
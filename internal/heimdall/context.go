@@ -18,6 +18,7 @@ package heimdall
 
 import (
 	"context"
+	"crypto/x509"
 	"net/url"
 )
 
@@ -43,12 +44,22 @@ type RequestFunctions interface {
 	Cookie(name string) string
 	Headers() map[string]string
 	Body() any
+	RawBody() []byte
+	ClientCertificates() []*x509.Certificate
 }
 
 type Request struct {
 	RequestFunctions
 
 	Method            string
-	URL               *url.URL
+	URL               *URL
 	ClientIPAddresses []string
 }
+
+// URL is the url of the request together with the values captured by the named
+// expressions of the url pattern of the matched rule.
+type URL struct {
+	url.URL
+
+	Captures map[string]string
+}
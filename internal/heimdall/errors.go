@@ -18,7 +18,11 @@ package heimdall
 
 import (
 	"errors"
+	"net/http"
 	"reflect"
+	"strconv"
+	"strings"
+	"time"
 )
 
 var (
@@ -31,14 +35,65 @@ var (
 	ErrInternal             = errors.New("internal error")
 	ErrMethodNotAllowed     = errors.New("method not allowed")
 	ErrNoRuleFound          = errors.New("no rule found")
+	ErrTooManyRequests      = errors.New("too many requests")
 )
 
 type RedirectError struct {
 	Message    string
 	Code       int
 	RedirectTo string
+	// Cookies holds the cookies to be set on the client together with the redirect
+	Cookies []*http.Cookie
 }
 
 func (e *RedirectError) Error() string { return e.Message }
 
 func (e *RedirectError) Is(target error) bool { return reflect.TypeOf(e) == reflect.TypeOf(target) }
+
+// MethodNotAllowedError is used as cause of ErrMethodNotAllowed errors and carries the
+// methods accepted by the rules, which matched the request, but not its method.
+type MethodNotAllowedError struct {
+	AllowedMethods []string
+}
+
+func (e *MethodNotAllowedError) Error() string {
+	return "allowed methods: " + strings.Join(e.AllowedMethods, ", ")
+}
+
+// TooManyRequestsError is used as cause of ErrTooManyRequests errors and carries the
+// duration the client should wait before sending the next request.
+type TooManyRequestsError struct {
+	RetryAfter time.Duration
+}
+
+func (e *TooManyRequestsError) Error() string {
+	return "retry after: " + e.RetryAfter.String()
+}
+
+// RetryAfterSeconds returns the value for the Retry-After header. The duration is
+// rounded up to full seconds as the header does not allow fractions.
+func (e *TooManyRequestsError) RetryAfterSeconds() string {
+	seconds := int64((e.RetryAfter + time.Second - 1) / time.Second)
+
+	return strconv.FormatInt(max(seconds, 1), 10)
+}
+
+// AuthenticationChallengeError is used as cause of ErrAuthentication errors and carries the
+// challenge to be sent to the client in the WWW-Authenticate header.
+type AuthenticationChallengeError struct {
+	Challenge string
+}
+
+func (e *AuthenticationChallengeError) Error() string {
+	return "challenge: " + e.Challenge
+}
+
+// UnexpectedResponseError is used as cause of ErrCommunication errors, if the response
+// of a remote system has a status code not expected by the mechanism.
+type UnexpectedResponseError struct {
+	StatusCode int
+}
+
+func (e *UnexpectedResponseError) Error() string {
+	return "unexpected response code: " + strconv.Itoa(e.StatusCode)
+}
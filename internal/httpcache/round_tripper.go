@@ -44,12 +44,15 @@ type RoundTripper struct {
 }
 
 func (rt *RoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
-	resp, err := rt.cachedResponse(req)
-	if err == nil {
-		return resp, nil
+	// a request with the no-cache directive must not be satisfied from the cache
+	// (see RFC 7234, section 5.2.1.4), but its response can still be stored
+	if !requiresRevalidation(req) {
+		if resp, err := rt.cachedResponse(req); err == nil {
+			return resp, nil
+		}
 	}
 
-	resp, err = rt.Transport.RoundTrip(req)
+	resp, err := rt.Transport.RoundTrip(req)
 	if err != nil {
 		return nil, err
 	}
@@ -100,6 +103,18 @@ func (rt *RoundTripper) cacheResponse(req *http.Request, resp *http.Response) {
 	cch.Set(ctx, cacheKey(req), respDump, time.Until(expires))
 }
 
+func requiresRevalidation(req *http.Request) bool {
+	for _, value := range req.Header.Values("Cache-Control") {
+		for _, directive := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(directive), "no-cache") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
 func cacheKey(req *http.Request) string {
 	hash := sha256.New()
 
@@ -55,11 +55,13 @@ func TestRoundTripperRoundTrip(t *testing.T) {
 		uc               string
 		setExpiresHeader bool
 		defaultTTL       time.Duration
+		noCache          bool
 		requestCounts    int
 	}{
 		{uc: "should cache response with expires header set", setExpiresHeader: true, requestCounts: 1},
 		{uc: "should not cache response without default cache ttl", requestCounts: 4},
 		{uc: "should cache response with default cache ttl without other headers", defaultTTL: 10 * time.Second, requestCounts: 1},
+		{uc: "should not use cached response for requests with no-cache directive", setExpiresHeader: true, noCache: true, requestCounts: 4},
 	} {
 		t.Run(tc.uc, func(t *testing.T) {
 			// GIVEN
@@ -77,6 +79,10 @@ func TestRoundTripperRoundTrip(t *testing.T) {
 			req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
 			require.NoError(t, err)
 
+			if tc.noCache {
+				req.Header.Set("Cache-Control", "no-cache")
+			}
+
 			for c := 0; c < 4; c++ {
 				resp, err := client.Do(req)
 				require.NoError(t, err)
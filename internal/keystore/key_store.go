@@ -221,6 +221,11 @@ func readPEMContents(data []byte) []*pem.Block {
 
 	for {
 		block, next = pem.Decode(next)
+		if block == nil {
+			// no (further) pem data present
+			break
+		}
+
 		blocks = append(blocks, block)
 
 		if len(next) == 0 {
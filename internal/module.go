@@ -20,7 +20,9 @@ import (
 	"github.com/rs/zerolog"
 	"go.uber.org/fx"
 
+	"github.com/dadrus/heimdall/internal/backchannel"
 	"github.com/dadrus/heimdall/internal/cache"
+	"github.com/dadrus/heimdall/internal/callback"
 	"github.com/dadrus/heimdall/internal/config"
 	"github.com/dadrus/heimdall/internal/handler/management"
 	"github.com/dadrus/heimdall/internal/handler/metrics"
@@ -41,6 +43,8 @@ var Module = fx.Options( //nolint:gochecknoglobals
 	}),
 	otel.Module,
 	cache.Module,
+	backchannel.Module,
+	callback.Module,
 	signer.Module,
 	mechanisms.Module,
 	rules.Module,
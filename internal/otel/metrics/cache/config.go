@@ -0,0 +1,48 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cache
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+)
+
+type config struct {
+	provider metric.MeterProvider
+}
+
+type Option func(conf *config)
+
+func WithMeterProvider(provider metric.MeterProvider) Option {
+	return func(conf *config) {
+		if provider != nil {
+			conf.provider = provider
+		}
+	}
+}
+
+func newConfig(opts ...Option) *config {
+	conf := config{
+		provider: otel.GetMeterProvider(),
+	}
+
+	for _, opt := range opts {
+		opt(&conf)
+	}
+
+	return &conf
+}
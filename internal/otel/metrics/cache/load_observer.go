@@ -0,0 +1,91 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cache
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/dadrus/heimdall/internal/cache"
+	"github.com/dadrus/heimdall/version"
+)
+
+const resultAttrKey = attribute.Key("result")
+
+// LoadStatisticsProvider is implemented by caches keeping track of expired values served
+// and of refreshes done in the background.
+type LoadStatisticsProvider interface {
+	LoadStatistics() cache.LoadStatistics
+}
+
+type loadObserver struct {
+	meter metric.Meter
+	cache LoadStatisticsProvider
+}
+
+// StartLoadObserver initializes reporting of the metrics about expired values served from
+// and entries refreshed in the given cache.
+func StartLoadObserver(cch LoadStatisticsProvider, opts ...Option) error {
+	conf := newConfig(opts...)
+
+	lo := &loadObserver{
+		meter: conf.provider.Meter(
+			"github.com/dadrus/heimdall/internal/otel/metrics/cache",
+			metric.WithInstrumentationVersion(version.Version),
+		),
+		cache: cch,
+	}
+
+	return lo.register()
+}
+
+func (lo *loadObserver) register() error {
+	staleServed, err := lo.meter.Int64ObservableCounter(
+		"cache.stale_served",
+		metric.WithDescription("Number of expired entries served, because a fresh value could not be loaded"),
+	)
+	if err != nil {
+		return err
+	}
+
+	refreshes, err := lo.meter.Int64ObservableCounter(
+		"cache.refreshes",
+		metric.WithDescription("Number of entries refreshed in the background before their expiry"),
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = lo.meter.RegisterCallback(
+		func(_ context.Context, observer metric.Observer) error {
+			stats := lo.cache.LoadStatistics()
+
+			observer.ObserveInt64(staleServed, int64(stats.StaleServed))
+			observer.ObserveInt64(refreshes, int64(stats.Refreshes),
+				metric.WithAttributes(resultAttrKey.String("success")))
+			observer.ObserveInt64(refreshes, int64(stats.FailedRefreshes),
+				metric.WithAttributes(resultAttrKey.String("failure")))
+
+			return nil
+		},
+		staleServed, refreshes,
+	)
+
+	return err
+}
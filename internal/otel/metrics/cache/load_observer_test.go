@@ -0,0 +1,76 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/resource"
+
+	"github.com/dadrus/heimdall/internal/cache"
+)
+
+type loadStatisticsProvider cache.LoadStatistics
+
+func (p loadStatisticsProvider) LoadStatistics() cache.LoadStatistics { return cache.LoadStatistics(p) }
+
+func TestCacheLoadObserver(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	provider := loadStatisticsProvider{StaleServed: 3, Refreshes: 5, FailedRefreshes: 1}
+
+	exp := metric.NewManualReader()
+
+	meterProvider := metric.NewMeterProvider(
+		metric.WithResource(resource.Default()),
+		metric.WithReader(exp),
+	)
+
+	// WHEN
+	err := StartLoadObserver(provider, WithMeterProvider(meterProvider))
+
+	// THEN
+	require.NoError(t, err)
+
+	var rm metricdata.ResourceMetrics
+	err = exp.Collect(context.TODO(), &rm)
+	require.NoError(t, err)
+
+	require.Len(t, rm.ScopeMetrics, 1)
+
+	values := map[string]map[string]int64{}
+
+	for _, mtr := range rm.ScopeMetrics[0].Metrics {
+		data := mtr.Data.(metricdata.Sum[int64]) // nolint: forcetypeassert
+
+		values[mtr.Name] = map[string]int64{}
+
+		for _, dp := range data.DataPoints {
+			result, _ := dp.Attributes.Value(resultAttrKey)
+			values[mtr.Name][result.AsString()] = dp.Value
+		}
+	}
+
+	assert.Equal(t, map[string]int64{"": 3}, values["cache.stale_served"])
+	assert.Equal(t, map[string]int64{"success": 5, "failure": 1}, values["cache.refreshes"])
+}
@@ -0,0 +1,117 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cache
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/dadrus/heimdall/internal/cache/memory"
+	"github.com/dadrus/heimdall/version"
+)
+
+const reasonAttrKey = attribute.Key("reason")
+
+// StatisticsProvider is implemented by caches keeping track of their usage.
+type StatisticsProvider interface {
+	Statistics() memory.Statistics
+}
+
+type usageObserver struct {
+	meter metric.Meter
+	cache StatisticsProvider
+}
+
+// Start initializes reporting of the usage metrics of the given cache.
+func Start(cch StatisticsProvider, opts ...Option) error {
+	conf := newConfig(opts...)
+
+	uo := &usageObserver{
+		meter: conf.provider.Meter(
+			"github.com/dadrus/heimdall/internal/otel/metrics/cache",
+			metric.WithInstrumentationVersion(version.Version),
+		),
+		cache: cch,
+	}
+
+	return uo.register()
+}
+
+func (uo *usageObserver) register() error {
+	hits, err := uo.meter.Int64ObservableCounter(
+		"cache.hits",
+		metric.WithDescription("Number of cache lookups, which found an entry"),
+	)
+	if err != nil {
+		return err
+	}
+
+	misses, err := uo.meter.Int64ObservableCounter(
+		"cache.misses",
+		metric.WithDescription("Number of cache lookups, which did not find an entry"),
+	)
+	if err != nil {
+		return err
+	}
+
+	evictions, err := uo.meter.Int64ObservableCounter(
+		"cache.evictions",
+		metric.WithDescription("Number of entries removed from the cache because of capacity limits or expiration"),
+	)
+	if err != nil {
+		return err
+	}
+
+	entries, err := uo.meter.Int64ObservableUpDownCounter(
+		"cache.entries",
+		metric.WithDescription("Number of entries held by the cache"),
+	)
+	if err != nil {
+		return err
+	}
+
+	size, err := uo.meter.Int64ObservableUpDownCounter(
+		"cache.size",
+		metric.WithDescription("Approximated amount of memory used by the cache entries"),
+		metric.WithUnit("By"),
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = uo.meter.RegisterCallback(
+		func(_ context.Context, observer metric.Observer) error {
+			stats := uo.cache.Statistics()
+
+			observer.ObserveInt64(hits, int64(stats.Hits))
+			observer.ObserveInt64(misses, int64(stats.Misses))
+			observer.ObserveInt64(evictions, int64(stats.Evictions),
+				metric.WithAttributes(reasonAttrKey.String("capacity")))
+			observer.ObserveInt64(evictions, int64(stats.Expirations),
+				metric.WithAttributes(reasonAttrKey.String("expiration")))
+			observer.ObserveInt64(entries, int64(stats.Entries))
+			observer.ObserveInt64(size, stats.Size)
+
+			return nil
+		},
+		hits, misses, evictions, entries, size,
+	)
+
+	return err
+}
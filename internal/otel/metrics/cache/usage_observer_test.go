@@ -0,0 +1,83 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/inhies/go-bytesize"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/resource"
+
+	"github.com/dadrus/heimdall/internal/cache/memory"
+)
+
+func TestCacheUsageObserver(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	cch := memory.New(memory.WithMaxEntries(1), memory.WithMaxMemory(bytesize.MB))
+
+	cch.Set(context.TODO(), "foo", "bar", 10*time.Minute)
+	cch.Get(context.TODO(), "foo")
+	cch.Get(context.TODO(), "bar")
+	cch.Set(context.TODO(), "bar", "baz", 10*time.Minute)
+
+	exp := metric.NewManualReader()
+
+	meterProvider := metric.NewMeterProvider(
+		metric.WithResource(resource.Default()),
+		metric.WithReader(exp),
+	)
+
+	// WHEN
+	err := Start(cch, WithMeterProvider(meterProvider))
+
+	// THEN
+	require.NoError(t, err)
+
+	var rm metricdata.ResourceMetrics
+	err = exp.Collect(context.TODO(), &rm)
+	require.NoError(t, err)
+
+	require.Len(t, rm.ScopeMetrics, 1)
+
+	values := map[string]map[string]int64{}
+
+	for _, mtr := range rm.ScopeMetrics[0].Metrics {
+		data := mtr.Data.(metricdata.Sum[int64]) // nolint: forcetypeassert
+
+		values[mtr.Name] = map[string]int64{}
+
+		for _, dp := range data.DataPoints {
+			reason, _ := dp.Attributes.Value(reasonAttrKey)
+			values[mtr.Name][reason.AsString()] = dp.Value
+		}
+	}
+
+	assert.Equal(t, map[string]int64{"": 1}, values["cache.hits"])
+	assert.Equal(t, map[string]int64{"": 1}, values["cache.misses"])
+	assert.Equal(t, map[string]int64{"capacity": 1, "expiration": 0}, values["cache.evictions"])
+	assert.Equal(t, map[string]int64{"": 1}, values["cache.entries"])
+	assert.Equal(t, map[string]int64{"": cch.Statistics().Size}, values["cache.size"])
+	assert.Positive(t, cch.Statistics().Size)
+}
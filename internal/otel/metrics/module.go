@@ -21,8 +21,10 @@ import (
 	"go.opentelemetry.io/contrib/instrumentation/runtime"
 	"go.uber.org/fx"
 
+	"github.com/dadrus/heimdall/internal/cache"
 	"github.com/dadrus/heimdall/internal/config"
 	"github.com/dadrus/heimdall/internal/keystore"
+	cachemetrics "github.com/dadrus/heimdall/internal/otel/metrics/cache"
 	"github.com/dadrus/heimdall/internal/otel/metrics/certificate"
 	"github.com/dadrus/heimdall/internal/x"
 )
@@ -31,8 +33,31 @@ var Module = fx.Options( // nolint: gochecknoglobals
 	fx.Invoke(runtime.Start),
 	fx.Invoke(host.Start),
 	fx.Invoke(monitorCertificateExpiry),
+	fx.Invoke(monitorCacheUsage),
 )
 
+func monitorCacheUsage(cch cache.Cache) error {
+	if provider, ok := cch.(cachemetrics.LoadStatisticsProvider); ok {
+		if err := cachemetrics.StartLoadObserver(provider); err != nil {
+			return err
+		}
+	}
+
+	for {
+		if provider, ok := cch.(cachemetrics.StatisticsProvider); ok {
+			return cachemetrics.Start(provider)
+		}
+
+		// caches can be decorated, like by a local cache in front of a shared one
+		wrapper, ok := cch.(interface{ Unwrap() cache.Cache })
+		if !ok {
+			return nil
+		}
+
+		cch = wrapper.Unwrap()
+	}
+}
+
 func monitorCertificateExpiry(conf *config.Configuration) error {
 	var (
 		decisionSrvKS   keystore.KeyStore
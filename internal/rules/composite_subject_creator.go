@@ -41,6 +41,12 @@ func (ca compositeSubjectCreator) Execute(ctx heimdall.Context) (*subject.Subjec
 		if err != nil {
 			logger.Info().Err(err).Msg("Pipeline step execution failed")
 
+			// a redirect is the response expected by the client and must not be replaced by
+			// the result of the next authenticator
+			if errors.Is(err, &heimdall.RedirectError{}) {
+				break
+			}
+
 			if (errors.Is(err, heimdall.ErrArgument) || a.IsFallbackOnErrorAllowed()) && idx < len(ca) {
 				logger.Info().Msg("Falling back to next configured one.")
 
@@ -18,13 +18,29 @@ package rules
 
 import (
 	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"testing"
+	"time"
 
+	"github.com/go-jose/go-jose/v3"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	"github.com/dadrus/heimdall/internal/cache"
+	"github.com/dadrus/heimdall/internal/cache/memory"
 	"github.com/dadrus/heimdall/internal/heimdall"
 	"github.com/dadrus/heimdall/internal/heimdall/mocks"
+	"github.com/dadrus/heimdall/internal/rules/mechanisms/authenticators"
 	"github.com/dadrus/heimdall/internal/rules/mechanisms/subject"
 	rulemocks "github.com/dadrus/heimdall/internal/rules/mocks"
 	"github.com/dadrus/heimdall/internal/x/testsupport"
@@ -106,6 +122,22 @@ func TestCompositeSubjectCreatorExecution(t *testing.T) {
 				require.NoError(t, err)
 			},
 		},
+		{
+			uc: "without fallback as first authenticator responds with a redirect",
+			configureMocks: func(t *testing.T, ctx heimdall.Context, first *rulemocks.SubjectCreatorMock,
+				second *rulemocks.SubjectCreatorMock, _ *subject.Subject,
+			) {
+				t.Helper()
+
+				first.EXPECT().Execute(ctx).Return(nil, &heimdall.RedirectError{RedirectTo: "http://foo.local"})
+			},
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, &heimdall.RedirectError{})
+			},
+		},
 	} {
 		t.Run("case="+tc.uc, func(t *testing.T) {
 			// GIVEN
@@ -132,3 +164,103 @@ func TestCompositeSubjectCreatorExecution(t *testing.T) {
 		})
 	}
 }
+
+func TestCompositeSubjectCreatorWithoutFallbackOnSenderConstraintViolation(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	signingKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	dpopKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	jwks, err := json.Marshal(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+		{Key: signingKey.Public(), KeyID: "foo", Algorithm: string(jose.ES256), Use: "sig"},
+	}})
+	require.NoError(t, err)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, err := w.Write(jwks)
+		assert.NoError(t, err)
+	}))
+	defer srv.Close()
+
+	thumbprint, err := (&jose.JSONWebKey{Key: dpopKey.Public()}).Thumbprint(crypto.SHA256)
+	require.NoError(t, err)
+
+	sign := func(key *ecdsa.PrivateKey, opts *jose.SignerOptions, claims map[string]any) string {
+		signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.ES256, Key: key}, opts)
+		require.NoError(t, err)
+
+		payload, err := json.Marshal(claims)
+		require.NoError(t, err)
+
+		jws, err := signer.Sign(payload)
+		require.NoError(t, err)
+
+		token, err := jws.CompactSerialize()
+		require.NoError(t, err)
+
+		return token
+	}
+
+	accessToken := sign(signingKey, (&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", "foo"),
+		map[string]any{
+			"sub": "foo",
+			"iss": "foobar",
+			"iat": time.Now().Unix() - 1,
+			"exp": time.Now().Unix() + 60,
+			"cnf": map[string]any{"jkt": base64.RawURLEncoding.EncodeToString(thumbprint)},
+		})
+
+	atDigest := sha256.Sum256([]byte(accessToken))
+	proof := sign(dpopKey, (&jose.SignerOptions{EmbedJWK: true}).WithType("dpop+jwt"),
+		map[string]any{
+			"jti": "some-id",
+			"htm": http.MethodGet,
+			"htu": "https://foo.bar/api/resource",
+			"iat": time.Now().Unix(),
+			"ath": base64.RawURLEncoding.EncodeToString(atDigest[:]),
+		})
+
+	jwtAuth, err := authenticators.CreatePrototype("jwt", authenticators.AuthenticatorJwt, map[string]any{
+		"jwks_endpoint": map[string]any{"url": srv.URL},
+		"assertions":    map[string]any{"issuers": []string{"foobar"}},
+		"dpop":          map[string]any{"required": true},
+	})
+	require.NoError(t, err)
+
+	anonAuth, err := authenticators.CreatePrototype("anon", authenticators.AuthenticatorAnonymous, nil)
+	require.NoError(t, err)
+
+	reqf := mocks.NewRequestFunctionsMock(t)
+	reqf.EXPECT().Header(mock.Anything).RunAndReturn(func(name string) string {
+		return map[string]string{"Authorization": "DPoP " + accessToken, "DPoP": proof}[name]
+	})
+	reqf.EXPECT().Body().Return(nil).Maybe()
+
+	ctx := mocks.NewContextMock(t)
+	ctx.EXPECT().AppContext().Return(cache.WithContext(context.Background(), memory.New()))
+	ctx.EXPECT().Request().Return(&heimdall.Request{
+		RequestFunctions: reqf,
+		Method:           http.MethodGet,
+		URL:              &heimdall.URL{URL: url.URL{Scheme: "https", Host: "foo.bar", Path: "/api/resource"}},
+	})
+
+	auth := compositeSubjectCreator{jwtAuth, anonAuth}
+
+	sub, err := auth.Execute(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "foo", sub.ID)
+
+	// WHEN
+	sub, err = auth.Execute(ctx)
+
+	// THEN
+	require.Error(t, err)
+	require.ErrorIs(t, err, heimdall.ErrAuthentication)
+	require.ErrorContains(t, err, "already been used")
+	assert.Nil(t, sub)
+}
@@ -27,7 +27,10 @@ type Backend struct {
 	URLRewriter *URLRewriter `json:"rewrite" yaml:"rewrite"`
 }
 
-func (f *Backend) CreateURL(value *url.URL) *url.URL {
+// CreateURL creates the url of the upstream service from the given request url. The
+// captures are the values captured by the named expressions of the url pattern of the
+// rule, which can be referenced in the rewrite definition.
+func (f *Backend) CreateURL(value *url.URL, captures map[string]string) *url.URL {
 	upstreamURL := &url.URL{
 		Scheme:   value.Scheme,
 		Host:     f.Host,
@@ -37,7 +40,7 @@ func (f *Backend) CreateURL(value *url.URL) *url.URL {
 	}
 
 	if f.URLRewriter != nil {
-		f.URLRewriter.Rewrite(upstreamURL)
+		f.URLRewriter.Rewrite(upstreamURL, captures)
 	}
 
 	return upstreamURL
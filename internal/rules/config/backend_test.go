@@ -51,6 +51,12 @@ func TestUpstreamURLFactoryCreateURL(t *testing.T) {
 			original: "http://foo.bar/foo/%5Bid%5D?baz=bar&bar=foo&foo=baz",
 			expected: "http://bar.foo/foo/%5Bid%5D?baz=bar&bar=foo&foo=baz",
 		},
+		{
+			uc:       "set host and rewrite path using captures",
+			factory:  &Backend{Host: "bar.foo", URLRewriter: &URLRewriter{PathPrefixToAdd: "/items/<:id>"}},
+			original: "http://foo.bar/details",
+			expected: "http://bar.foo/items/1/details",
+		},
 	} {
 		t.Run(tc.uc, func(t *testing.T) {
 			// GIVEN
@@ -58,7 +64,7 @@ func TestUpstreamURLFactoryCreateURL(t *testing.T) {
 			require.NoError(t, err)
 
 			// WHEN
-			result := tc.factory.CreateURL(requestURL)
+			result := tc.factory.CreateURL(requestURL, map[string]string{"id": "1"})
 
 			// THEN
 			assert.Equal(t, tc.expected, result.String())
@@ -74,8 +74,14 @@ func matcherDecodeHookFunc(from reflect.Type, to reflect.Type, data any) (any, e
 		}
 	}
 
-	return Matcher{
-		URL:      urlValue,
-		Strategy: x.IfThenElse(strategyPresent, strategyValue, "glob"),
-	}, nil
+	// the remaining properties are decoded by mapstructure itself
+	matcher := make(map[string]any, len(values))
+	for key, value := range values {
+		matcher[key] = value
+	}
+
+	matcher["url"] = urlValue
+	matcher["strategy"] = x.IfThenElse(strategyPresent, strategyValue, "glob")
+
+	return matcher, nil
 }
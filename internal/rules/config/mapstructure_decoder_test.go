@@ -146,6 +146,48 @@ match:
 				assert.Equal(t, "regex", matcher.Strategy)
 			},
 		},
+		{
+			uc: "specified as structured type with additional request conditions",
+			config: []byte(`
+match: 
+  url: foo.bar
+  host: <*>.example.com
+  headers:
+    Content-Type: [ "application/json", "application/<*>+json" ]
+  query_params:
+    version: [ "1" ]
+  client_networks: [ "10.0.0.0/8" ]
+  expression: Request.Header("X-Foo") != ""
+`),
+			assert: func(t *testing.T, err error, matcher *Matcher) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "foo.bar", matcher.URL)
+				assert.Equal(t, "glob", matcher.Strategy)
+				assert.Equal(t, "<*>.example.com", matcher.Host)
+				assert.Equal(t, map[string][]string{
+					"Content-Type": {"application/json", "application/<*>+json"},
+				}, matcher.Headers)
+				assert.Equal(t, map[string][]string{"version": {"1"}}, matcher.QueryParams)
+				assert.Equal(t, []string{"10.0.0.0/8"}, matcher.ClientNetworks)
+				assert.Equal(t, `Request.Header("X-Foo") != ""`, matcher.Expression)
+			},
+		},
+		{
+			uc: "specified as structured type with unsupported property",
+			config: []byte(`
+match: 
+  url: foo.bar
+  foo: bar
+`),
+			assert: func(t *testing.T, err error, matcher *Matcher) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "invalid keys: foo")
+			},
+		},
 	} {
 		t.Run(tc.uc, func(t *testing.T) {
 			// GIVEN
@@ -23,8 +23,13 @@ import (
 )
 
 type Matcher struct {
-	URL      string `json:"url"      yaml:"url"`
-	Strategy string `json:"strategy" yaml:"strategy"`
+	URL            string              `json:"url"             yaml:"url"`
+	Strategy       string              `json:"strategy"        yaml:"strategy"`
+	Host           string              `json:"host"            yaml:"host"`
+	Headers        map[string][]string `json:"headers"         yaml:"headers"`
+	QueryParams    map[string][]string `json:"query_params"    yaml:"query_params"`
+	ClientNetworks []string            `json:"client_networks" yaml:"client_networks"`
+	Expression     string              `json:"expression"      yaml:"expression"`
 }
 
 func (m *Matcher) UnmarshalJSON(data []byte) error {
@@ -44,3 +49,31 @@ func (m *Matcher) UnmarshalJSON(data []byte) error {
 
 	return DecodeConfig(rawData, m)
 }
+
+func (m *Matcher) DeepCopyInto(out *Matcher) {
+	*out = *m
+
+	if m.Headers != nil {
+		out.Headers = deepCopyMultiValueMap(m.Headers)
+	}
+
+	if m.QueryParams != nil {
+		out.QueryParams = deepCopyMultiValueMap(m.QueryParams)
+	}
+
+	if m.ClientNetworks != nil {
+		out.ClientNetworks = make([]string, len(m.ClientNetworks))
+		copy(out.ClientNetworks, m.ClientNetworks)
+	}
+}
+
+func deepCopyMultiValueMap(in map[string][]string) map[string][]string {
+	out := make(map[string][]string, len(in))
+
+	for key, values := range in {
+		out[key] = make([]string, len(values))
+		copy(out[key], values)
+	}
+
+	return out
+}
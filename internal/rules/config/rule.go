@@ -31,6 +31,7 @@ const (
 type Rule struct {
 	ID                     string                   `json:"id"                    yaml:"id"`
 	EncodedSlashesHandling EncodedSlashesHandling   `json:"allow_encoded_slashes" yaml:"allow_encoded_slashes" validate:"omitempty,oneof=off on no_decode"` //nolint:lll,tagalign
+	Priority               int                      `json:"priority"              yaml:"priority"`
 	RuleMatcher            Matcher                  `json:"match"                 yaml:"match"`
 	Backend                *Backend                 `json:"forward_to"            yaml:"forward_to"`
 	Methods                []string                 `json:"methods"               yaml:"methods"`
@@ -40,7 +41,7 @@ type Rule struct {
 
 func (in *Rule) DeepCopyInto(out *Rule) {
 	*out = *in
-	out.RuleMatcher = in.RuleMatcher
+	in.RuleMatcher.DeepCopyInto(&out.RuleMatcher)
 
 	if in.Backend != nil {
 		in, out := in.Backend, out.Backend
@@ -19,11 +19,14 @@ package config
 import (
 	"fmt"
 	"net/url"
+	"regexp"
 	"strings"
 
 	"github.com/dadrus/heimdall/internal/x"
 )
 
+var captureReference = regexp.MustCompile(`<:[A-Za-z_][A-Za-z0-9_]*>`)
+
 type PrefixCutter string
 
 func (c PrefixCutter) CutFrom(value string) string {
@@ -72,14 +75,16 @@ type URLRewriter struct {
 	QueryParamsToRemove QueryParamsRemover `json:"strip_query_parameters" yaml:"strip_query_parameters"`
 }
 
-func (r *URLRewriter) Rewrite(value *url.URL) {
+// Rewrite rewrites the given url. Path segments of the form ":name" in the path prefixes
+// to strip and to add are replaced by the values of the corresponding captures.
+func (r *URLRewriter) Rewrite(value *url.URL, captures map[string]string) {
 	value.Scheme = x.IfThenElseExec(
 		len(r.Scheme) != 0,
 		func() string { return r.Scheme },
 		func() string { return value.Scheme },
 	)
 
-	rawPath := r.transformPath(value.EscapedPath())
+	rawPath := r.transformPath(value.EscapedPath(), captures)
 	if len(value.RawPath) != 0 {
 		// if the original url path had url encoded parts
 		value.RawPath = rawPath
@@ -94,10 +99,30 @@ func (r *URLRewriter) Rewrite(value *url.URL) {
 	value.RawQuery = r.transformQuery(value.RawQuery)
 }
 
-func (r *URLRewriter) transformPath(value string) string {
-	return r.PathPrefixToAdd.AddTo(r.PathPrefixToCut.CutFrom(value))
+func (r *URLRewriter) transformPath(value string, captures map[string]string) string {
+	cutter := PrefixCutter(expandCaptures(string(r.PathPrefixToCut), captures))
+	adder := PrefixAdder(expandCaptures(string(r.PathPrefixToAdd), captures))
+
+	return adder.AddTo(cutter.CutFrom(value))
 }
 
 func (r *URLRewriter) transformQuery(value string) string {
 	return r.QueryParamsToRemove.RemoveFrom(value)
 }
+
+// expandCaptures replaces the references of the form "<:name>" in the given value with
+// the url encoded value of the corresponding capture. References to unknown captures are
+// left untouched.
+func expandCaptures(value string, captures map[string]string) string {
+	if len(captures) == 0 || !strings.Contains(value, "<:") {
+		return value
+	}
+
+	return captureReference.ReplaceAllStringFunc(value, func(reference string) string {
+		if capture, ok := captures[reference[2:len(reference)-1]]; ok {
+			return url.PathEscape(capture)
+		}
+
+		return reference
+	})
+}
@@ -68,6 +68,7 @@ func TestURLRewriterRewrite(t *testing.T) {
 		uc       string
 		original string
 		rewriter *URLRewriter
+		captures map[string]string
 		expected string
 	}{
 		{
@@ -129,6 +130,33 @@ func TestURLRewriterRewrite(t *testing.T) {
 			},
 			expected: "https://foo.bar/baz/bar?baz=bar",
 		},
+		{
+			uc:       "rewrite path prefixes using captures",
+			original: "http://foo.bar/tenants/acme/orders/1",
+			rewriter: &URLRewriter{
+				PathPrefixToCut: "/tenants/<:tenant>",
+				PathPrefixToAdd: "/<:tenant>/api/<:unknown>",
+			},
+			captures: map[string]string{"tenant": "acme", "id": "1"},
+			expected: "http://foo.bar/acme/api/%3C:unknown%3E/orders/1",
+		},
+		{
+			uc:       "rewrite path prefix using captures requiring encoding",
+			original: "http://foo.bar/orders/1",
+			rewriter: &URLRewriter{PathPrefixToAdd: "/<:tenant>"},
+			captures: map[string]string{"tenant": "foo/bar"},
+			expected: "http://foo.bar/foo%2Fbar/orders/1",
+		},
+		{
+			uc:       "rewrite path prefixes with literal colon segments",
+			original: "http://foo.bar/tenants/:tenant/orders/1",
+			rewriter: &URLRewriter{
+				PathPrefixToCut: "/tenants/:tenant",
+				PathPrefixToAdd: "/:tenant",
+			},
+			captures: map[string]string{"tenant": "acme"},
+			expected: "http://foo.bar/:tenant/orders/1",
+		},
 	} {
 		t.Run(tc.uc, func(t *testing.T) {
 			// GIVEN
@@ -136,7 +164,7 @@ func TestURLRewriterRewrite(t *testing.T) {
 			require.NoError(t, err)
 
 			// WHEN
-			tc.rewriter.Rewrite(requestURL)
+			tc.rewriter.Rewrite(requestURL, tc.captures)
 
 			// THEN
 			assert.Equal(t, tc.expected, requestURL.String())
@@ -0,0 +1,169 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package authenticators
+
+import (
+	"context"
+
+	"github.com/rs/zerolog"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/rules/mechanisms/authenticators/extractors"
+	"github.com/dadrus/heimdall/internal/rules/mechanisms/subject"
+	"github.com/dadrus/heimdall/internal/x"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+// by intention. Used only during application bootstrap
+//
+//nolint:gochecknoinits
+func init() {
+	registerTypeFactory(
+		func(id string, typ string, conf map[string]any) (bool, Authenticator, error) {
+			if typ != AuthenticatorAPIKey {
+				return false, nil, nil
+			}
+
+			auth, err := newAPIKeyAuthenticator(id, conf)
+
+			return true, auth, err
+		})
+}
+
+type apiKeyAuthenticator struct {
+	id                   string
+	ads                  extractors.AuthDataExtractStrategy
+	credentials          *credentialsFile
+	allowFallbackOnError bool
+}
+
+func newAPIKeyAuthenticator(id string, rawConfig map[string]any) (*apiKeyAuthenticator, error) {
+	type Config struct {
+		CredentialsFile      string                              `mapstructure:"credentials_file"        validate:"required"`
+		KeySource            extractors.CompositeExtractStrategy `mapstructure:"key_source"`
+		AllowFallbackOnError bool                                `mapstructure:"allow_fallback_on_error"`
+	}
+
+	var conf Config
+	if err := decodeConfig(AuthenticatorAPIKey, rawConfig, &conf); err != nil {
+		return nil, err
+	}
+
+	credentials, err := newCredentialsFile(conf.CredentialsFile, parseAPIKeyCredentials)
+	if err != nil {
+		return nil, err
+	}
+
+	return &apiKeyAuthenticator{
+		id: id,
+		ads: x.IfThenElseExec(conf.KeySource == nil,
+			func() extractors.CompositeExtractStrategy {
+				return extractors.CompositeExtractStrategy{
+					extractors.HeaderValueExtractStrategy{Name: "X-API-Key"},
+				}
+			},
+			func() extractors.CompositeExtractStrategy { return conf.KeySource },
+		),
+		credentials:          credentials,
+		allowFallbackOnError: conf.AllowFallbackOnError,
+	}, nil
+}
+
+func (a *apiKeyAuthenticator) Execute(ctx heimdall.Context) (*subject.Subject, error) {
+	logger := zerolog.Ctx(ctx.AppContext())
+	logger.Debug().Str("_id", a.id).Msg("Authenticating using api_key authenticator")
+
+	apiKey, err := a.ads.GetAuthData(ctx)
+	if err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrAuthentication, "no API key present").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	entry := a.credentials.findBySecret(*logger, apiKey)
+	if entry == nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrAuthentication, "invalid API key").
+			WithErrorContext(a)
+	}
+
+	return &subject.Subject{ID: entry.id, Attributes: entry.attributesCopy()}, nil
+}
+
+func (a *apiKeyAuthenticator) WithConfig(rawConfig map[string]any) (Authenticator, error) {
+	// this authenticator allows redefinition of the key source and the fallback behavior only
+	if len(rawConfig) == 0 {
+		return a, nil
+	}
+
+	type Config struct {
+		KeySource            extractors.CompositeExtractStrategy `mapstructure:"key_source"`
+		AllowFallbackOnError *bool                               `mapstructure:"allow_fallback_on_error"`
+	}
+
+	var conf Config
+	if err := decodeConfig(AuthenticatorAPIKey, rawConfig, &conf); err != nil {
+		return nil, err
+	}
+
+	return &apiKeyAuthenticator{
+		id: a.id,
+		ads: x.IfThenElseExec(conf.KeySource == nil,
+			func() extractors.AuthDataExtractStrategy { return a.ads },
+			func() extractors.AuthDataExtractStrategy { return conf.KeySource },
+		),
+		credentials: a.credentials,
+		allowFallbackOnError: x.IfThenElseExec(conf.AllowFallbackOnError != nil,
+			func() bool { return *conf.AllowFallbackOnError },
+			func() bool { return a.allowFallbackOnError }),
+	}, nil
+}
+
+// Stop releases the watcher of the credentials file.
+func (a *apiKeyAuthenticator) Stop(ctx context.Context) error {
+	return a.credentials.stop(ctx)
+}
+
+func (a *apiKeyAuthenticator) IsFallbackOnErrorAllowed() bool {
+	return a.allowFallbackOnError
+}
+
+func (a *apiKeyAuthenticator) ID() string {
+	return a.id
+}
+
+func parseAPIKeyCredentials(data []byte) (map[string]*credentialsEntry, error) {
+	type Entry struct {
+		ID         string         `yaml:"id"`
+		KeyHash    string         `yaml:"key_hash"`
+		Attributes map[string]any `yaml:"attributes"`
+	}
+
+	var entries []Entry
+
+	if err := decodeCredentialsYAML(data, &entries); err != nil {
+		return nil, err
+	}
+
+	records := make([]credentialsRecord, len(entries))
+	for idx, entry := range entries {
+		records[idx] = credentialsRecord{ID: entry.ID, Hash: entry.KeyHash, Attributes: entry.Attributes}
+	}
+
+	return newCredentialsEntries(records, newSHA256Hash)
+}
@@ -0,0 +1,320 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package authenticators
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/heimdall/mocks"
+	"github.com/dadrus/heimdall/internal/rules/mechanisms/authenticators/extractors"
+	"github.com/dadrus/heimdall/internal/rules/mechanisms/subject"
+	"github.com/dadrus/heimdall/internal/x/testsupport"
+)
+
+func createAPIKeyCredentialsFile(t *testing.T) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "api-keys.yaml")
+	err := os.WriteFile(path, []byte(`
+- id: client-1
+  key_hash: `+sha256TestHash("secret-key-1")+`
+  attributes:
+    scopes: [read, write]
+- id: client-2
+  key_hash: `+sha256TestHash("secret-key-2")+`
+`), 0o600)
+	require.NoError(t, err)
+
+	return path
+}
+
+func TestCreateAPIKeyAuthenticator(t *testing.T) {
+	t.Parallel()
+
+	credentialsFile := createAPIKeyCredentialsFile(t)
+
+	bcryptHashedKeysFile := filepath.Join(t.TempDir(), "api-keys.yaml")
+	err := os.WriteFile(bcryptHashedKeysFile, []byte(`
+- id: client-1
+  key_hash: $2a$10$YALD/CfM8N91.rQxY1U8r.VY9WHrt2Dz1fO2S6Ak3OfxVtTcwiX/.
+`), 0o600)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		uc     string
+		id     string
+		config []byte
+		assert func(t *testing.T, err error, auth *apiKeyAuthenticator)
+	}{
+		{
+			uc: "without credentials file",
+			assert: func(t *testing.T, err error, _ *apiKeyAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "'credentials_file' is a required field")
+			},
+		},
+		{
+			uc: "with unexpected config attribute",
+			config: []byte(`
+credentials_file: ` + credentialsFile + `
+foo: bar`),
+			assert: func(t *testing.T, err error, _ *apiKeyAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+			},
+		},
+		{
+			uc:     "with invalid credentials file",
+			config: []byte(`credentials_file: ` + filepath.Join(t.TempDir(), "missing.yaml")),
+			assert: func(t *testing.T, err error, _ *apiKeyAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed to load credentials")
+			},
+		},
+		{
+			uc:     "with credentials file containing a bcrypt hash",
+			config: []byte(`credentials_file: ` + bcryptHashedKeysFile),
+			assert: func(t *testing.T, err error, _ *apiKeyAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "SHA-256")
+			},
+		},
+		{
+			uc:     "with minimal valid configuration",
+			id:     "auth1",
+			config: []byte(`credentials_file: ` + credentialsFile),
+			assert: func(t *testing.T, err error, auth *apiKeyAuthenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				assert.Equal(t, "auth1", auth.ID())
+				assert.False(t, auth.IsFallbackOnErrorAllowed())
+				assert.Equal(t, extractors.CompositeExtractStrategy{
+					extractors.HeaderValueExtractStrategy{Name: "X-API-Key"},
+				}, auth.ads)
+				require.NotNil(t, auth.credentials)
+				assert.Len(t, auth.credentials.entries, 2)
+				assert.Equal(t, map[string]any{"scopes": []any{"read", "write"}},
+					auth.credentials.entries["client-1"].attributes)
+			},
+		},
+		{
+			uc: "with full configuration",
+			id: "auth1",
+			config: []byte(`
+credentials_file: ` + credentialsFile + `
+key_source:
+  - query_parameter: api_key
+allow_fallback_on_error: true`),
+			assert: func(t *testing.T, err error, auth *apiKeyAuthenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				assert.Equal(t, "auth1", auth.ID())
+				assert.True(t, auth.IsFallbackOnErrorAllowed())
+				assert.Equal(t, extractors.CompositeExtractStrategy{
+					&extractors.QueryParameterExtractStrategy{Name: "api_key"},
+				}, auth.ads)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			conf, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			// WHEN
+			auth, err := newAPIKeyAuthenticator(tc.id, conf)
+
+			// THEN
+			tc.assert(t, err, auth)
+		})
+	}
+}
+
+func TestCreateAPIKeyAuthenticatorFromPrototypeConfig(t *testing.T) {
+	t.Parallel()
+
+	credentialsFile := createAPIKeyCredentialsFile(t)
+
+	for _, tc := range []struct {
+		uc     string
+		config []byte
+		assert func(t *testing.T, err error, prototype *apiKeyAuthenticator, configured *apiKeyAuthenticator)
+	}{
+		{
+			uc: "without new configuration",
+			assert: func(t *testing.T, err error, prototype *apiKeyAuthenticator, configured *apiKeyAuthenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, prototype, configured)
+			},
+		},
+		{
+			uc:     "with credentials file",
+			config: []byte(`credentials_file: ` + credentialsFile),
+			assert: func(t *testing.T, err error, _ *apiKeyAuthenticator, _ *apiKeyAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+			},
+		},
+		{
+			uc: "with key source and fallback on error",
+			config: []byte(`
+key_source:
+  - header: X-Api-Token
+allow_fallback_on_error: true`),
+			assert: func(t *testing.T, err error, prototype *apiKeyAuthenticator, configured *apiKeyAuthenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.NotEqual(t, prototype, configured)
+				assert.Equal(t, prototype.ID(), configured.ID())
+				assert.Equal(t, prototype.credentials, configured.credentials)
+				assert.NotEqual(t, prototype.ads, configured.ads)
+				assert.True(t, configured.IsFallbackOnErrorAllowed())
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			prototype, err := newAPIKeyAuthenticator("auth2",
+				map[string]any{"credentials_file": credentialsFile})
+			require.NoError(t, err)
+
+			conf, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			// WHEN
+			auth, err := prototype.WithConfig(conf)
+
+			// THEN
+			var configured *apiKeyAuthenticator
+			if err == nil {
+				configured = auth.(*apiKeyAuthenticator) // nolint: forcetypeassert
+			}
+
+			tc.assert(t, err, prototype, configured)
+		})
+	}
+}
+
+func TestAPIKeyAuthenticatorExecute(t *testing.T) {
+	t.Parallel()
+
+	auth, err := newAPIKeyAuthenticator("auth3",
+		map[string]any{"credentials_file": createAPIKeyCredentialsFile(t)})
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		uc     string
+		apiKey string
+		assert func(t *testing.T, err error, sub *subject.Subject)
+	}{
+		{
+			uc: "without api key",
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.Contains(t, err.Error(), "no API key present")
+				assert.Nil(t, sub)
+			},
+		},
+		{
+			uc:     "with unknown api key",
+			apiKey: "foo",
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.Contains(t, err.Error(), "invalid API key")
+
+				var identifier interface{ ID() string }
+				require.ErrorAs(t, err, &identifier)
+				assert.Equal(t, "auth3", identifier.ID())
+
+				assert.Nil(t, sub)
+			},
+		},
+		{
+			uc:     "with api key having attributes",
+			apiKey: "secret-key-1",
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, sub)
+				assert.Equal(t, "client-1", sub.ID)
+				assert.Equal(t, map[string]any{"scopes": []any{"read", "write"}}, sub.Attributes)
+			},
+		},
+		{
+			uc:     "with api key without attributes",
+			apiKey: "secret-key-2",
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, sub)
+				assert.Equal(t, "client-2", sub.ID)
+				assert.Empty(t, sub.Attributes)
+				assert.NotNil(t, sub.Attributes)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			fnt := mocks.NewRequestFunctionsMock(t)
+			fnt.EXPECT().Header("X-API-Key").Return(tc.apiKey)
+
+			ctx := mocks.NewContextMock(t)
+			ctx.EXPECT().AppContext().Return(context.Background())
+			ctx.EXPECT().Request().Return(&heimdall.Request{RequestFunctions: fnt})
+
+			// WHEN
+			sub, err := auth.Execute(ctx)
+
+			// THEN
+			tc.assert(t, err, sub)
+		})
+	}
+}
@@ -26,8 +26,8 @@ import (
 func TestCreateAuthenticatorPrototype(t *testing.T) {
 	t.Parallel()
 
-	// there are seven authenticators implemented, which should have been registered
-	require.Len(t, authenticatorTypeFactories, 6)
+	// there are eleven authenticators implemented, which should have been registered
+	require.Len(t, authenticatorTypeFactories, 11)
 
 	for _, tc := range []struct {
 		uc     string
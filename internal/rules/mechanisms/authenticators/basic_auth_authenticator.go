@@ -17,9 +17,11 @@
 package authenticators
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/hex"
+	"path/filepath"
 	"strings"
 
 	"github.com/rs/zerolog"
@@ -56,13 +58,15 @@ type basicAuthAuthenticator struct {
 	id                   string
 	userID               string
 	password             string
+	credentials          *credentialsFile
 	allowFallbackOnError bool
 }
 
 func newBasicAuthAuthenticator(id string, rawConfig map[string]any) (*basicAuthAuthenticator, error) {
 	type Config struct {
-		UserID               string `mapstructure:"user_id"                 validate:"required"`
-		Password             string `mapstructure:"password"                validate:"required"`
+		UserID               string `mapstructure:"user_id"                 validate:"required_without=CredentialsFile,excluded_with=CredentialsFile"` //nolint:lll
+		Password             string `mapstructure:"password"                validate:"required_without=CredentialsFile,excluded_with=CredentialsFile"` //nolint:lll
+		CredentialsFile      string `mapstructure:"credentials_file"`
 		AllowFallbackOnError bool   `mapstructure:"allow_fallback_on_error"`
 	}
 
@@ -76,6 +80,17 @@ func newBasicAuthAuthenticator(id string, rawConfig map[string]any) (*basicAuthA
 		allowFallbackOnError: conf.AllowFallbackOnError,
 	}
 
+	if len(conf.CredentialsFile) != 0 {
+		credentials, err := newCredentialsFile(conf.CredentialsFile, basicAuthCredentialsParser(conf.CredentialsFile))
+		if err != nil {
+			return nil, err
+		}
+
+		auth.credentials = credentials
+
+		return &auth, nil
+	}
+
 	// rewrite user id and password as hashes to mitigate potential side-channel attacks
 	// during credentials check
 	md := sha256.New()
@@ -117,6 +132,17 @@ func (a *basicAuthAuthenticator) Execute(ctx heimdall.Context) (*subject.Subject
 			WithErrorContext(a)
 	}
 
+	if a.credentials != nil {
+		entry := a.credentials.find(*logger, userIDAndPassword[0], userIDAndPassword[1])
+		if entry == nil {
+			return nil, errorchain.
+				NewWithMessage(heimdall.ErrAuthentication, "invalid user credentials").
+				WithErrorContext(a)
+		}
+
+		return &subject.Subject{ID: entry.id, Attributes: entry.attributesCopy()}, nil
+	}
+
 	md := sha256.New()
 	md.Write(stringx.ToBytes(userIDAndPassword[0]))
 	userID := hex.EncodeToString(md.Sum(nil))
@@ -154,6 +180,22 @@ func (a *basicAuthAuthenticator) WithConfig(rawConfig map[string]any) (Authentic
 		return nil, err
 	}
 
+	if a.credentials != nil {
+		if len(conf.UserID) != 0 || len(conf.Password) != 0 {
+			return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+				"credentials loaded from a credentials file cannot be overridden").
+				WithErrorContext(a)
+		}
+
+		return &basicAuthAuthenticator{
+			id:          a.id,
+			credentials: a.credentials,
+			allowFallbackOnError: x.IfThenElseExec(conf.AllowFallbackOnError != nil,
+				func() bool { return *conf.AllowFallbackOnError },
+				func() bool { return a.allowFallbackOnError }),
+		}, nil
+	}
+
 	return &basicAuthAuthenticator{
 		id: a.id,
 		userID: x.IfThenElseExec(len(conf.UserID) != 0,
@@ -180,6 +222,57 @@ func (a *basicAuthAuthenticator) WithConfig(rawConfig map[string]any) (Authentic
 	}, nil
 }
 
+func basicAuthCredentialsParser(path string) credentialsParser {
+	if ext := filepath.Ext(path); ext == ".yaml" || ext == ".yml" {
+		return func(data []byte) (map[string]*credentialsEntry, error) {
+			type Entry struct {
+				UserID     string         `yaml:"user_id"`
+				Password   string         `yaml:"password"`
+				Attributes map[string]any `yaml:"attributes"`
+			}
+
+			var entries []Entry
+
+			if err := decodeCredentialsYAML(data, &entries); err != nil {
+				return nil, err
+			}
+
+			records := make([]credentialsRecord, len(entries))
+			for idx, entry := range entries {
+				records[idx] = credentialsRecord{ID: entry.UserID, Hash: entry.Password, Attributes: entry.Attributes}
+			}
+
+			return newCredentialsEntries(records, newSecretHash)
+		}
+	}
+
+	// htpasswd like format: one <user-id>:<password hash> pair per line
+	return func(data []byte) (map[string]*credentialsEntry, error) {
+		var records []credentialsRecord
+
+		for _, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			if len(line) == 0 || strings.HasPrefix(line, "#") {
+				continue
+			}
+
+			userID, hash, _ := strings.Cut(line, ":")
+			records = append(records, credentialsRecord{ID: userID, Hash: hash})
+		}
+
+		return newCredentialsEntries(records, newSecretHash)
+	}
+}
+
+// Stop releases the watcher of the credentials file, if configured.
+func (a *basicAuthAuthenticator) Stop(ctx context.Context) error {
+	if a.credentials == nil {
+		return nil
+	}
+
+	return a.credentials.stop(ctx)
+}
+
 func (a *basicAuthAuthenticator) IsFallbackOnErrorAllowed() bool {
 	return a.allowFallbackOnError
 }
@@ -21,10 +21,14 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/hex"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
 
 	"github.com/dadrus/heimdall/internal/heimdall"
 	"github.com/dadrus/heimdall/internal/heimdall/mocks"
@@ -35,6 +39,31 @@ import (
 func TestCreateBasicAuthAuthenticator(t *testing.T) {
 	t.Parallel()
 
+	testDir := t.TempDir()
+	htpasswdFile := filepath.Join(testDir, ".htpasswd")
+	yamlFile := filepath.Join(testDir, "users.yaml")
+	invalidFile := filepath.Join(testDir, "invalid.yaml")
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("bar"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	err = os.WriteFile(htpasswdFile, []byte("# users\nfoo:"+string(hash)+"\n"), 0o600)
+	require.NoError(t, err)
+
+	err = os.WriteFile(yamlFile, []byte(`
+- user_id: foo
+  password: `+string(hash)+`
+  attributes:
+    group: admin
+`), 0o600)
+	require.NoError(t, err)
+
+	err = os.WriteFile(invalidFile, []byte(`
+- user_id: foo
+  password: bar
+`), 0o600)
+	require.NoError(t, err)
+
 	for _, tc := range []struct {
 		uc     string
 		id     string
@@ -119,6 +148,80 @@ user_id: foo`),
 				assert.Nil(t, auth)
 			},
 		},
+		{
+			uc:     "valid configuration with htpasswd credentials file",
+			id:     "auth1",
+			config: []byte(`credentials_file: ` + htpasswdFile),
+			assert: func(t *testing.T, err error, auth *basicAuthAuthenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				assert.Empty(t, auth.userID)
+				assert.Empty(t, auth.password)
+				require.NotNil(t, auth.credentials)
+				require.Len(t, auth.credentials.entries, 1)
+				assert.Equal(t, "foo", auth.credentials.entries["foo"].id)
+				assert.False(t, auth.IsFallbackOnErrorAllowed())
+				assert.Equal(t, "auth1", auth.ID())
+			},
+		},
+		{
+			uc:     "valid configuration with yaml credentials file",
+			id:     "auth1",
+			config: []byte(`credentials_file: ` + yamlFile),
+			assert: func(t *testing.T, err error, auth *basicAuthAuthenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				require.NotNil(t, auth.credentials)
+				require.Len(t, auth.credentials.entries, 1)
+				assert.Equal(t, map[string]any{"group": "admin"}, auth.credentials.entries["foo"].attributes)
+				assert.Equal(t, "auth1", auth.ID())
+			},
+		},
+		{
+			uc: "credentials file together with user_id and password",
+			config: []byte(`
+credentials_file: ` + htpasswdFile + `
+user_id: foo
+password: bar`),
+			assert: func(t *testing.T, err error, auth *basicAuthAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+
+				assert.Nil(t, auth)
+			},
+		},
+		{
+			uc:     "not existing credentials file",
+			config: []byte(`credentials_file: ` + filepath.Join(testDir, "missing")),
+			assert: func(t *testing.T, err error, auth *basicAuthAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed to load credentials")
+
+				assert.Nil(t, auth)
+			},
+		},
+		{
+			uc:     "credentials file with plain text password",
+			config: []byte(`credentials_file: ` + invalidFile),
+			assert: func(t *testing.T, err error, auth *basicAuthAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "unsupported secret hash")
+
+				assert.Nil(t, auth)
+			},
+		},
 		{
 			uc: "with unexpected config attribute",
 			config: []byte(`
@@ -151,6 +254,13 @@ foo: bar`),
 func TestCreateBasicAuthAuthenticatorFromPrototype(t *testing.T) {
 	t.Parallel()
 
+	hash, err := bcrypt.GenerateFromPassword([]byte("bar"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	credentialsFile := filepath.Join(t.TempDir(), ".htpasswd")
+	err = os.WriteFile(credentialsFile, []byte("foo:"+string(hash)), 0o600)
+	require.NoError(t, err)
+
 	for _, tc := range []struct {
 		uc              string
 		id              string
@@ -302,6 +412,39 @@ password: baz`),
 				assert.Equal(t, value, configured.password)
 			},
 		},
+		{
+			uc:              "credentials file based prototype with fallback on error set to true",
+			id:              "auth2",
+			prototypeConfig: []byte(`credentials_file: ` + credentialsFile),
+			config: []byte(`
+allow_fallback_on_error: true
+`),
+			assert: func(t *testing.T, err error, prototype *basicAuthAuthenticator, configured *basicAuthAuthenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, prototype.credentials, configured.credentials)
+				assert.True(t, configured.IsFallbackOnErrorAllowed())
+				assert.False(t, prototype.IsFallbackOnErrorAllowed())
+				assert.Equal(t, "auth2", configured.ID())
+			},
+		},
+		{
+			uc:              "credentials file based prototype with user_id and password",
+			id:              "auth2",
+			prototypeConfig: []byte(`credentials_file: ` + credentialsFile),
+			config: []byte(`
+user_id: foo
+password: baz`),
+			assert: func(t *testing.T, err error, _ *basicAuthAuthenticator, configured *basicAuthAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "cannot be overridden")
+				assert.Nil(t, configured)
+			},
+		},
 	} {
 		t.Run("case="+tc.uc, func(t *testing.T) {
 			pc, err := testsupport.DecodeTestConfig(tc.prototypeConfig)
@@ -318,7 +461,7 @@ password: baz`),
 
 			// THEN
 			baa, ok := auth.(*basicAuthAuthenticator)
-			require.True(t, ok)
+			require.True(t, ok || auth == nil)
 
 			tc.assert(t, err, prototype, baa)
 		})
@@ -516,3 +659,219 @@ password: bar`))
 		})
 	}
 }
+
+type countingSecretHash struct {
+	secretHash
+
+	calls int
+}
+
+func (h *countingSecretHash) Matches(secret string) bool {
+	h.calls++
+
+	return h.secretHash.Matches(secret)
+}
+
+func TestBasicAuthAuthenticatorExecuteWithCredentialsFile(t *testing.T) {
+	t.Parallel()
+
+	fooHash, err := bcrypt.GenerateFromPassword([]byte("bar"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	bazHash, err := bcrypt.GenerateFromPassword([]byte("qux"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		uc          string
+		credentials string
+		update      string
+		assert      func(t *testing.T, auth *basicAuthAuthenticator, ctx func(creds string) heimdall.Context)
+	}{
+		{
+			uc: "valid credentials with attributes",
+			credentials: `
+- user_id: foo
+  password: ` + string(fooHash) + `
+  attributes:
+    group: admin
+`,
+			assert: func(t *testing.T, auth *basicAuthAuthenticator, ctx func(creds string) heimdall.Context) {
+				t.Helper()
+
+				for i := 0; i < 2; i++ {
+					sub, err := auth.Execute(ctx("foo:bar"))
+					require.NoError(t, err)
+
+					assert.Equal(t, "foo", sub.ID)
+					assert.Equal(t, map[string]any{"group": "admin"}, sub.Attributes)
+
+					// attributes of the subject must not affect the loaded credentials
+					sub.Attributes["group"] = "guest"
+				}
+			},
+		},
+		{
+			uc:          "unknown user",
+			credentials: `[{"user_id": "foo", "password": "` + string(fooHash) + `"}]`,
+			assert: func(t *testing.T, auth *basicAuthAuthenticator, ctx func(creds string) heimdall.Context) {
+				t.Helper()
+
+				dummy := &countingSecretHash{secretHash: auth.credentials.dummy}
+				auth.credentials.dummy = dummy
+
+				sub, err := auth.Execute(ctx("baz:bar"))
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.Contains(t, err.Error(), "invalid user credentials")
+				assert.Nil(t, sub)
+
+				// the password of an unknown user is hashed as well
+				assert.Equal(t, 1, dummy.calls)
+			},
+		},
+		{
+			uc:          "wrong password",
+			credentials: `[{"user_id": "foo", "password": "` + string(fooHash) + `"}]`,
+			assert: func(t *testing.T, auth *basicAuthAuthenticator, ctx func(creds string) heimdall.Context) {
+				t.Helper()
+
+				sub, err := auth.Execute(ctx("foo:baz"))
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.Contains(t, err.Error(), "invalid user credentials")
+				assert.Nil(t, sub)
+			},
+		},
+		{
+			uc:          "credentials are reloaded on file change",
+			credentials: `[{"user_id": "foo", "password": "` + string(fooHash) + `"}]`,
+			update:      `[{"user_id": "baz", "password": "` + string(bazHash) + `"}]`,
+			assert: func(t *testing.T, auth *basicAuthAuthenticator, ctx func(creds string) heimdall.Context) {
+				t.Helper()
+
+				assert.EventuallyWithT(t, func(c *assert.CollectT) {
+					sub, err := auth.Execute(ctx("baz:qux"))
+					if assert.NoError(c, err) {
+						assert.Equal(c, "baz", sub.ID)
+					}
+				}, 2*time.Second, 10*time.Millisecond)
+
+				_, err := auth.Execute(ctx("foo:bar"))
+				require.Error(t, err)
+			},
+		},
+		{
+			uc:          "current credentials are kept if the updated file is invalid",
+			credentials: `[{"user_id": "foo", "password": "` + string(fooHash) + `"}]`,
+			update:      `[{"user_id": "baz", "password": "qux"}]`,
+			assert: func(t *testing.T, auth *basicAuthAuthenticator, ctx func(creds string) heimdall.Context) {
+				t.Helper()
+
+				require.Eventually(t, auth.credentials.stale.Load,
+					2*time.Second, 10*time.Millisecond)
+
+				sub, err := auth.Execute(ctx("foo:bar"))
+				require.NoError(t, err)
+				assert.Equal(t, "foo", sub.ID)
+
+				_, err = auth.Execute(ctx("baz:qux"))
+				require.Error(t, err)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			credentialsFile := filepath.Join(t.TempDir(), "users.yaml")
+			err := os.WriteFile(credentialsFile, []byte(tc.credentials), 0o600)
+			require.NoError(t, err)
+
+			auth, err := newBasicAuthAuthenticator("auth", map[string]any{"credentials_file": credentialsFile})
+			require.NoError(t, err)
+
+			createCtx := func(creds string) heimdall.Context {
+				fnt := mocks.NewRequestFunctionsMock(t)
+				fnt.EXPECT().Header("Authorization").
+					Return("Basic " + base64.StdEncoding.EncodeToString([]byte(creds)))
+
+				ctx := mocks.NewContextMock(t)
+				ctx.EXPECT().AppContext().Return(context.Background())
+				ctx.EXPECT().Request().Return(&heimdall.Request{RequestFunctions: fnt})
+
+				return ctx
+			}
+
+			if len(tc.update) != 0 {
+				err = os.WriteFile(credentialsFile, []byte(tc.update), 0o600)
+				require.NoError(t, err)
+			}
+
+			// WHEN & THEN
+			tc.assert(t, auth, createCtx)
+		})
+	}
+}
+
+func TestBasicAuthAuthenticatorStop(t *testing.T) {
+	t.Parallel()
+
+	fooHash, err := bcrypt.GenerateFromPassword([]byte("bar"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	credentials := `[{"user_id": "foo", "password": "` + string(fooHash) + `"}]`
+
+	for _, tc := range []struct {
+		uc     string
+		config func(t *testing.T) map[string]any
+		assert func(t *testing.T, auth *basicAuthAuthenticator)
+	}{
+		{
+			uc: "without credentials file",
+			config: func(t *testing.T) map[string]any {
+				t.Helper()
+
+				return map[string]any{"user_id": "foo", "password": "bar"}
+			},
+			assert: func(t *testing.T, _ *basicAuthAuthenticator) { t.Helper() },
+		},
+		{
+			uc: "with credentials file",
+			config: func(t *testing.T) map[string]any {
+				t.Helper()
+
+				credentialsFile := filepath.Join(t.TempDir(), "users.yaml")
+				err := os.WriteFile(credentialsFile, []byte(credentials), 0o600)
+				require.NoError(t, err)
+
+				return map[string]any{"credentials_file": credentialsFile}
+			},
+			assert: func(t *testing.T, auth *basicAuthAuthenticator) {
+				t.Helper()
+
+				// the watch goroutine has terminated
+				select {
+				case <-auth.credentials.done:
+				default:
+					t.Error("credentials file is still watched")
+				}
+
+				err := os.WriteFile(auth.credentials.path, []byte(credentials), 0o600)
+				require.NoError(t, err)
+
+				assert.Never(t, auth.credentials.stale.Load, 200*time.Millisecond, 10*time.Millisecond)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			auth, err := newBasicAuthAuthenticator("auth", tc.config(t))
+			require.NoError(t, err)
+
+			// WHEN
+			err = auth.Stop(context.Background())
+
+			// THEN
+			require.NoError(t, err)
+			tc.assert(t, auth)
+		})
+	}
+}
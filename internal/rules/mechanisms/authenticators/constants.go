@@ -17,10 +17,15 @@
 package authenticators
 
 const (
-	AuthenticatorUnauthorized        = "unauthorized"
-	AuthenticatorBasicAuth           = "basic_auth"
-	AuthenticatorAnonymous           = "anonymous"
-	AuthenticatorOAuth2Introspection = "oauth2_introspection"
-	AuthenticatorJwt                 = "jwt"
-	AuthenticatorGeneric             = "generic"
+	AuthenticatorUnauthorized          = "unauthorized"
+	AuthenticatorBasicAuth             = "basic_auth"
+	AuthenticatorAnonymous             = "anonymous"
+	AuthenticatorOAuth2Introspection   = "oauth2_introspection"
+	AuthenticatorJwt                   = "jwt"
+	AuthenticatorGeneric               = "generic"
+	AuthenticatorX509                  = "x509"
+	AuthenticatorOIDC                  = "oidc"
+	AuthenticatorK8sTokenReview        = "kubernetes_token_review"
+	AuthenticatorHTTPMessageSignatures = "http_message_signatures"
+	AuthenticatorAPIKey                = "api_key"
 )
@@ -0,0 +1,309 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package authenticators
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/rs/zerolog"
+	"gopkg.in/yaml.v3"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+	"github.com/dadrus/heimdall/internal/x/stringx"
+)
+
+var (
+	errDuplicateCredentials   = errors.New("duplicate credentials entry")
+	errMissingCredentialsData = errors.New("missing id or secret hash")
+	errNoCredentials          = errors.New("no credentials defined")
+)
+
+// credentialsRecord is the format independent representation of an entry
+// read from a credentials file.
+type credentialsRecord struct {
+	ID         string
+	Hash       string
+	Attributes map[string]any
+}
+
+type credentialsEntry struct {
+	id         string
+	hash       secretHash
+	attributes map[string]any
+}
+
+func (e *credentialsEntry) attributesCopy() map[string]any {
+	if e.attributes == nil {
+		return make(map[string]any)
+	}
+
+	return maps.Clone(e.attributes)
+}
+
+type credentialsParser func(data []byte) (map[string]*credentialsEntry, error)
+
+// credentialsFile holds the hashed credentials read from a file. The file is watched for changes
+// and reloaded on next use if it has been modified. If the modified file cannot be loaded, the
+// previously loaded credentials are kept. The watcher is released by calling stop.
+type credentialsFile struct {
+	path  string
+	parse credentialsParser
+	stale atomic.Bool
+
+	mu      sync.RWMutex
+	digest  []byte
+	entries map[string]*credentialsEntry
+	// entries with SHA-256 hashed secrets indexed by the digest, used to find API keys
+	byDigest map[sha256Hash]*credentialsEntry
+	// the hash of an arbitrary entry, used to verify secrets of unknown ids to not
+	// reveal whether an id is known by the time it takes to answer a request
+	dummy secretHash
+	// holds digests of already successfully verified secrets to avoid the expensive
+	// hash computation on each request. Reset on each reload.
+	verified map[[sha256.Size]byte]*credentialsEntry
+
+	w    *fsnotify.Watcher
+	done chan struct{}
+}
+
+func newCredentialsFile(path string, parse credentialsParser) (*credentialsFile, error) {
+	cf := &credentialsFile{path: path, parse: parse}
+
+	if err := cf.load(); err != nil {
+		return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+			"failed to load credentials from %s", path).CausedBy(err)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrInternal,
+			"failed to instantiate credentials file watcher").CausedBy(err)
+	}
+
+	// the directory is watched, as the file itself might be replaced, like it is the
+	// case with kubernetes secrets mounted as volumes
+	if err = watcher.Add(filepath.Dir(path)); err != nil {
+		watcher.Close()
+
+		return nil, errorchain.NewWithMessagef(heimdall.ErrInternal,
+			"failed to watch credentials file %s", path).CausedBy(err)
+	}
+
+	cf.w = watcher
+	cf.done = make(chan struct{})
+
+	go cf.watch()
+
+	return cf, nil
+}
+
+func (cf *credentialsFile) watch() {
+	defer close(cf.done)
+
+	for {
+		select {
+		case _, ok := <-cf.w.Events:
+			if !ok {
+				return
+			}
+
+			cf.stale.Store(true)
+		case _, ok := <-cf.w.Errors:
+			if !ok {
+				return
+			}
+
+			cf.stale.Store(true)
+		}
+	}
+}
+
+func (cf *credentialsFile) stop(ctx context.Context) error {
+	if err := cf.w.Close(); err != nil {
+		return err
+	}
+
+	select {
+	case <-cf.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (cf *credentialsFile) load() error {
+	contents, err := os.ReadFile(cf.path)
+	if err != nil {
+		return err
+	}
+
+	digest := sha256.Sum256(contents)
+
+	cf.mu.RLock()
+	unchanged := bytes.Equal(cf.digest, digest[:])
+	cf.mu.RUnlock()
+
+	if unchanged {
+		return nil
+	}
+
+	entries, err := cf.parse(contents)
+	if err != nil {
+		return err
+	}
+
+	byDigest := make(map[sha256Hash]*credentialsEntry)
+
+	var dummy secretHash
+
+	for _, entry := range entries {
+		dummy = entry.hash
+
+		if hash, ok := entry.hash.(sha256Hash); ok {
+			byDigest[hash] = entry
+		}
+	}
+
+	cf.mu.Lock()
+	cf.digest = digest[:]
+	cf.entries = entries
+	cf.byDigest = byDigest
+	cf.dummy = dummy
+	cf.verified = make(map[[sha256.Size]byte]*credentialsEntry)
+	cf.mu.Unlock()
+
+	return nil
+}
+
+func (cf *credentialsFile) refresh(logger zerolog.Logger) {
+	if !cf.stale.CompareAndSwap(true, false) {
+		return
+	}
+
+	if err := cf.load(); err != nil {
+		logger.Warn().Err(err).Str("_path", cf.path).
+			Msg("Failed to reload credentials file. Keeping the current credentials")
+
+		return
+	}
+
+	logger.Debug().Str("_path", cf.path).Msg("Credentials file loaded")
+}
+
+// find returns the entry identified by the given id if the secret matches its hash.
+func (cf *credentialsFile) find(logger zerolog.Logger, id, secret string) *credentialsEntry {
+	cf.refresh(logger)
+
+	cf.mu.RLock()
+	entry := cf.entries[id]
+	dummy := cf.dummy
+	cf.mu.RUnlock()
+
+	if entry == nil {
+		// the hash computation is the expensive part of the verification and is done
+		// for unknown ids as well
+		dummy.Matches(secret)
+
+		return nil
+	}
+
+	key := sha256.Sum256(stringx.ToBytes(id + ":" + secret))
+
+	cf.mu.RLock()
+	verified := cf.verified[key] == entry
+	digest := cf.digest
+	cf.mu.RUnlock()
+
+	if verified {
+		return entry
+	}
+
+	if !entry.hash.Matches(secret) {
+		return nil
+	}
+
+	cf.mu.Lock()
+	// the file might have been reloaded in the meantime
+	if bytes.Equal(digest, cf.digest) {
+		cf.verified[key] = entry
+	}
+	cf.mu.Unlock()
+
+	return entry
+}
+
+// findBySecret returns the entry, the SHA-256 hash of which matches the given secret.
+func (cf *credentialsFile) findBySecret(logger zerolog.Logger, secret string) *credentialsEntry {
+	cf.refresh(logger)
+
+	cf.mu.RLock()
+	defer cf.mu.RUnlock()
+
+	return cf.byDigest[sha256.Sum256(stringx.ToBytes(secret))]
+}
+
+func newCredentialsEntries(
+	records []credentialsRecord, newHash func(value string) (secretHash, error),
+) (map[string]*credentialsEntry, error) {
+	if len(records) == 0 {
+		return nil, errNoCredentials
+	}
+
+	entries := make(map[string]*credentialsEntry, len(records))
+
+	for idx, record := range records {
+		if len(record.ID) == 0 || len(record.Hash) == 0 {
+			return nil, fmt.Errorf("entry %d: %w", idx, errMissingCredentialsData)
+		}
+
+		if _, exists := entries[record.ID]; exists {
+			return nil, fmt.Errorf("%w for %s", errDuplicateCredentials, record.ID)
+		}
+
+		hash, err := newHash(record.Hash)
+		if err != nil {
+			return nil, fmt.Errorf("entry for %s: %w", record.ID, err)
+		}
+
+		entries[record.ID] = &credentialsEntry{id: record.ID, hash: hash, attributes: record.Attributes}
+	}
+
+	return entries, nil
+}
+
+func decodeCredentialsYAML(data []byte, out any) error {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+
+	if err := dec.Decode(out); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	return nil
+}
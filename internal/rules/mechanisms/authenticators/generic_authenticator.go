@@ -17,6 +17,7 @@
 package authenticators
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"errors"
@@ -32,6 +33,7 @@ import (
 	"github.com/dadrus/heimdall/internal/heimdall"
 	"github.com/dadrus/heimdall/internal/rules/endpoint"
 	"github.com/dadrus/heimdall/internal/rules/mechanisms/authenticators/extractors"
+	"github.com/dadrus/heimdall/internal/rules/mechanisms/forwarding"
 	"github.com/dadrus/heimdall/internal/rules/mechanisms/subject"
 	"github.com/dadrus/heimdall/internal/rules/mechanisms/template"
 	"github.com/dadrus/heimdall/internal/x"
@@ -64,6 +66,8 @@ type genericAuthenticator struct {
 	fwdCookies           []string
 	sf                   SubjectFactory
 	ttl                  time.Duration
+	staleIfError         time.Duration
+	refreshAhead         time.Duration
 	sessionLifespanConf  *SessionLifespanConfig
 	allowFallbackOnError bool
 }
@@ -78,6 +82,8 @@ func newGenericAuthenticator(id string, rawConfig map[string]any) (*genericAuthe
 		Payload               template.Template                   `mapstructure:"payload"`
 		SessionLifespanConfig *SessionLifespanConfig              `mapstructure:"session_lifespan"`
 		CacheTTL              *time.Duration                      `mapstructure:"cache_ttl"`
+		StaleIfError          time.Duration                       `mapstructure:"stale_if_error"`
+		RefreshAhead          time.Duration                       `mapstructure:"refresh_ahead"`
 		AllowFallbackOnError  bool                                `mapstructure:"allow_fallback_on_error"`
 	}
 
@@ -97,6 +103,8 @@ func newGenericAuthenticator(id string, rawConfig map[string]any) (*genericAuthe
 		ttl: x.IfThenElseExec(conf.CacheTTL != nil,
 			func() time.Duration { return *conf.CacheTTL },
 			func() time.Duration { return 0 }),
+		staleIfError:         conf.StaleIfError,
+		refreshAhead:         conf.RefreshAhead,
 		allowFallbackOnError: conf.AllowFallbackOnError,
 		sessionLifespanConf:  conf.SessionLifespanConfig,
 	}, nil
@@ -131,13 +139,15 @@ func (a *genericAuthenticator) Execute(ctx heimdall.Context) (*subject.Subject,
 }
 
 func (a *genericAuthenticator) WithConfig(config map[string]any) (Authenticator, error) {
-	// this authenticator allows ttl to be redefined on the rule level
+	// this authenticator allows ttl and the cache policy to be redefined on the rule level
 	if len(config) == 0 {
 		return a, nil
 	}
 
 	type Config struct {
 		CacheTTL             *time.Duration `mapstructure:"cache_ttl"`
+		StaleIfError         *time.Duration `mapstructure:"stale_if_error"`
+		RefreshAhead         *time.Duration `mapstructure:"refresh_ahead"`
 		AllowFallbackOnError *bool          `mapstructure:"allow_fallback_on_error"`
 	}
 
@@ -157,6 +167,12 @@ func (a *genericAuthenticator) WithConfig(config map[string]any) (Authenticator,
 		ttl: x.IfThenElseExec(conf.CacheTTL != nil,
 			func() time.Duration { return *conf.CacheTTL },
 			func() time.Duration { return a.ttl }),
+		staleIfError: x.IfThenElseExec(conf.StaleIfError != nil,
+			func() time.Duration { return *conf.StaleIfError },
+			func() time.Duration { return a.staleIfError }),
+		refreshAhead: x.IfThenElseExec(conf.RefreshAhead != nil,
+			func() time.Duration { return *conf.RefreshAhead },
+			func() time.Duration { return a.refreshAhead }),
 		allowFallbackOnError: x.IfThenElseExec(conf.AllowFallbackOnError != nil,
 			func() bool { return *conf.AllowFallbackOnError },
 			func() bool { return a.allowFallbackOnError }),
@@ -173,60 +189,75 @@ func (a *genericAuthenticator) ID() string {
 }
 
 func (a *genericAuthenticator) getSubjectInformation(ctx heimdall.Context, authData string) ([]byte, error) {
-	logger := zerolog.Ctx(ctx.AppContext())
-	cch := cache.Ctx(ctx.AppContext())
-
-	var (
-		cacheKey       string
-		cacheEntry     any
-		cachedResponse []byte
-		ok             bool
-		session        *SessionLifespan
-	)
-
-	if a.ttl > 0 {
-		cacheKey = a.calculateCacheKey(authData)
-		cacheEntry = cch.Get(ctx.AppContext(), cacheKey)
+	// the values to forward are taken from the request up front, as the load
+	// might happen in the background, after the request has been completed
+	fwd := forwarding.Collect(ctx, a.fwdHeaders, a.fwdCookies)
+	load := func(appCtx context.Context) ([]byte, time.Duration, error) {
+		return a.loadSubjectInformation(appCtx, authData, fwd)
 	}
 
-	if cacheEntry != nil {
-		if cachedResponse, ok = cacheEntry.([]byte); !ok {
-			logger.Warn().Msg("Wrong object type from cache")
-			cch.Delete(ctx.AppContext(), cacheKey)
-		} else {
-			logger.Debug().Msg("Reusing subject information from cache")
+	if a.ttl <= 0 {
+		payload, _, err := load(ctx.AppContext())
 
-			return cachedResponse, nil
-		}
+		return payload, err
 	}
 
-	payload, err := a.fetchSubjectInformation(ctx, authData)
+	payload, err := cache.GetOrLoad(ctx.AppContext(), cache.Ctx(ctx.AppContext()),
+		a.calculateCacheKey(authData), load,
+		cache.WithStaleIfError(a.staleIfError), cache.WithRefreshAhead(a.refreshAhead))
 	if err != nil {
 		return nil, err
 	}
 
-	if a.sessionLifespanConf != nil {
-		session, err = a.sessionLifespanConf.CreateSessionLifespan(payload)
-		if err != nil {
-			return nil, errorchain.New(heimdall.ErrInternal).WithErrorContext(a).CausedBy(err)
+	if a.staleIfError > 0 {
+		// a stale response must not extend the lifespan of the session
+		if _, err = a.assertSessionLifespan(payload); err != nil {
+			return nil, err
 		}
+	}
 
-		if session != nil {
-			if err = session.Assert(); err != nil {
-				return nil, errorchain.New(heimdall.ErrAuthentication).WithErrorContext(a).CausedBy(err)
-			}
-		}
+	return payload, nil
+}
+
+func (a *genericAuthenticator) loadSubjectInformation(
+	ctx context.Context, authData string, fwd *forwarding.Values,
+) ([]byte, time.Duration, error) {
+	payload, err := a.fetchSubjectInformation(ctx, authData, fwd)
+	if err != nil {
+		return nil, 0, err
 	}
 
-	if cacheTTL := a.getCacheTTL(session); cacheTTL > 0 {
-		cch.Set(ctx.AppContext(), cacheKey, payload, cacheTTL)
+	session, err := a.assertSessionLifespan(payload)
+	if err != nil {
+		return nil, 0, err
 	}
 
-	return payload, nil
+	return payload, a.getCacheTTL(session), nil
 }
 
-func (a *genericAuthenticator) fetchSubjectInformation(ctx heimdall.Context, authData string) ([]byte, error) {
-	req, err := a.createRequest(ctx, authData)
+func (a *genericAuthenticator) assertSessionLifespan(payload []byte) (*SessionLifespan, error) {
+	if a.sessionLifespanConf == nil {
+		return nil, nil //nolint:nilnil
+	}
+
+	session, err := a.sessionLifespanConf.CreateSessionLifespan(payload)
+	if err != nil {
+		return nil, errorchain.New(heimdall.ErrInternal).WithErrorContext(a).CausedBy(err)
+	}
+
+	if session != nil {
+		if err = session.Assert(); err != nil {
+			return nil, errorchain.New(heimdall.ErrAuthentication).WithErrorContext(a).CausedBy(err)
+		}
+	}
+
+	return session, nil
+}
+
+func (a *genericAuthenticator) fetchSubjectInformation(
+	ctx context.Context, authData string, fwd *forwarding.Values,
+) ([]byte, error) {
+	req, err := a.createRequest(ctx, authData, fwd)
 	if err != nil {
 		return nil, err
 	}
@@ -254,9 +285,9 @@ func (a *genericAuthenticator) fetchSubjectInformation(ctx heimdall.Context, aut
 	return a.readResponse(resp)
 }
 
-func (a *genericAuthenticator) createRequest(ctx heimdall.Context, authData string) (*http.Request, error) {
-	logger := zerolog.Ctx(ctx.AppContext())
-
+func (a *genericAuthenticator) createRequest(
+	ctx context.Context, authData string, fwd *forwarding.Values,
+) (*http.Request, error) {
 	var body io.Reader
 
 	templateData := map[string]any{
@@ -274,7 +305,7 @@ func (a *genericAuthenticator) createRequest(ctx heimdall.Context, authData stri
 		body = strings.NewReader(value)
 	}
 
-	req, err := a.e.CreateRequest(ctx.AppContext(), body,
+	req, err := a.e.CreateRequest(ctx, body,
 		endpoint.RenderFunc(func(value string) (string, error) {
 			tpl, err := template.New(value)
 			if err != nil {
@@ -292,33 +323,16 @@ func (a *genericAuthenticator) createRequest(ctx heimdall.Context, authData stri
 			CausedBy(err)
 	}
 
-	for _, headerName := range a.fwdHeaders {
-		headerValue := ctx.Request().Header(headerName)
-		if len(headerValue) == 0 {
-			logger.Warn().Str("_header", headerName).
-				Msg("Header not present in the request but configured to be forwarded")
-		} else {
-			req.Header.Add(headerName, headerValue)
-		}
-	}
-
-	for _, cookieName := range a.fwdCookies {
-		cookieValue := ctx.Request().Cookie(cookieName)
-		if len(cookieValue) == 0 {
-			logger.Warn().Str("_cookie", cookieName).
-				Msg("Cookie not present in the request but configured to be forwarded")
-		} else {
-			req.AddCookie(&http.Cookie{Name: cookieName, Value: cookieValue})
-		}
-	}
+	fwd.AddTo(ctx, req)
 
 	return req, nil
 }
 
 func (a *genericAuthenticator) readResponse(resp *http.Response) ([]byte, error) {
 	if !(resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices) {
-		return nil, errorchain.NewWithMessagef(heimdall.ErrCommunication,
-			"unexpected response code: %v", resp.StatusCode).WithErrorContext(a)
+		return nil, errorchain.New(heimdall.ErrCommunication).
+			WithErrorContext(a).
+			CausedBy(&heimdall.UnexpectedResponseError{StatusCode: resp.StatusCode})
 	}
 
 	rawData, err := io.ReadAll(resp.Body)
@@ -30,6 +30,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/dadrus/heimdall/internal/cache"
+	"github.com/dadrus/heimdall/internal/cache/memory"
 	"github.com/dadrus/heimdall/internal/cache/mocks"
 	"github.com/dadrus/heimdall/internal/heimdall"
 	heimdallmocks "github.com/dadrus/heimdall/internal/heimdall/mocks"
@@ -215,6 +216,32 @@ cache_ttl: 5s`),
 				assert.Equal(t, "auth1", auth.ID())
 			},
 		},
+		{
+			uc: "with valid configuration, enabled cache and cache policy",
+			id: "auth1",
+			config: []byte(`
+identity_info_endpoint:
+  url: http://test.com
+  method: POST
+authentication_data_source:
+  - cookie: foo-cookie
+subject:
+  id: some_template
+cache_ttl: 5m
+stale_if_error: 1m
+refresh_ahead: 30s`),
+			assertError: func(t *testing.T, err error, auth *genericAuthenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				require.NotNil(t, auth)
+				assert.Equal(t, 5*time.Minute, auth.ttl)
+				assert.Equal(t, time.Minute, auth.staleIfError)
+				assert.Equal(t, 30*time.Second, auth.refreshAhead)
+				assert.Equal(t, "auth1", auth.ID())
+			},
+		},
 		{
 			uc: "with valid configuration enabling fallback on errors and header forwarding",
 			id: "auth1",
@@ -486,6 +513,36 @@ cache_ttl: 15s`),
 				assert.Equal(t, "auth2", configured.ID())
 			},
 		},
+		{
+			uc: "prototype config with cache policy, config with partially different cache policy",
+			id: "auth2",
+			prototypeConfig: []byte(`
+identity_info_endpoint:
+  url: http://test.com
+  method: POST
+authentication_data_source:
+  - header: foo-header
+subject:
+  id: some_template
+cache_ttl: 5m
+stale_if_error: 1m
+refresh_ahead: 30s`),
+			config: []byte(`
+stale_if_error: 10m`),
+			assert: func(t *testing.T, err error, prototype *genericAuthenticator,
+				configured *genericAuthenticator,
+			) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				assert.Equal(t, prototype.ttl, configured.ttl)
+				assert.Equal(t, time.Minute, prototype.staleIfError)
+				assert.Equal(t, 10*time.Minute, configured.staleIfError)
+				assert.Equal(t, prototype.refreshAhead, configured.refreshAhead)
+				assert.Equal(t, "auth2", configured.ID())
+			},
+		},
 		{
 			uc: "prototype with session lifespan config and empty target config",
 			id: "auth2",
@@ -1432,6 +1489,97 @@ func TestGenericAuthenticatorExecute(t *testing.T) {
 	}
 }
 
+func TestGenericAuthenticatorExecuteWithStaleIfError(t *testing.T) {
+	t.Parallel()
+
+	var (
+		responseCode    int
+		responseContent string
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(responseCode)
+		_, err := w.Write([]byte(responseContent))
+		require.NoError(t, err)
+	}))
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		uc              string
+		responseCode    int
+		responseContent string
+		assert          func(t *testing.T, err error, sub *subject.Subject)
+	}{
+		{
+			uc:           "stale response is served if the endpoint fails",
+			responseCode: http.StatusServiceUnavailable,
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "foo", sub.ID)
+			},
+		},
+		{
+			uc:           "stale response is not served if the endpoint rejects the authentication data",
+			responseCode: http.StatusUnauthorized,
+			assert: func(t *testing.T, err error, _ *subject.Subject) {
+				t.Helper()
+
+				require.ErrorIs(t, err, heimdall.ErrCommunication)
+				require.ErrorContains(t, err, "unexpected response code: 401")
+			},
+		},
+		{
+			uc:              "stale response is not served if the session is no longer active",
+			responseCode:    http.StatusOK,
+			responseContent: `{ "user_id": "bar", "active": false }`,
+			assert: func(t *testing.T, err error, _ *subject.Subject) {
+				t.Helper()
+
+				require.ErrorIs(t, err, heimdall.ErrAuthentication)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			auth := &genericAuthenticator{
+				id:                  "auth",
+				e:                   endpoint.Endpoint{URL: srv.URL, Method: http.MethodGet},
+				sf:                  &SubjectInfo{IDFrom: "user_id"},
+				ttl:                 50 * time.Millisecond,
+				staleIfError:        time.Minute,
+				sessionLifespanConf: &SessionLifespanConfig{ActiveField: "active"},
+			}
+
+			ads := mocks2.NewAuthDataExtractStrategyMock(t)
+			ads.EXPECT().GetAuthData(mock.Anything).Return("session_token", nil)
+			auth.ads = ads
+
+			ctx := heimdallmocks.NewContextMock(t)
+			ctx.EXPECT().AppContext().Return(cache.WithContext(context.Background(), memory.New()))
+
+			responseCode = http.StatusOK
+			responseContent = `{ "user_id": "foo", "active": true }`
+
+			_, err := auth.Execute(ctx)
+			require.NoError(t, err)
+
+			time.Sleep(100 * time.Millisecond)
+
+			responseCode = tc.responseCode
+			responseContent = tc.responseContent
+
+			// WHEN
+			sub, err := auth.Execute(ctx)
+
+			// THEN
+			tc.assert(t, err, sub)
+		})
+	}
+}
+
 func TestGenericAuthenticatorGetCacheTTL(t *testing.T) {
 	t.Parallel()
 
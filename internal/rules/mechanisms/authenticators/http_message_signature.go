@@ -0,0 +1,480 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package authenticators
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+
+	"github.com/go-jose/go-jose/v3"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/x"
+	"github.com/dadrus/heimdall/internal/x/structuredfields"
+)
+
+// algorithms from the HTTP Signature Algorithms registry, see RFC 9421, section 6.2.2
+const (
+	httpSigAlgRSAPSSSHA512    = "rsa-pss-sha512"
+	httpSigAlgRSAV15SHA256    = "rsa-v1_5-sha256"
+	httpSigAlgHMACSHA256      = "hmac-sha256"
+	httpSigAlgECDSAP256SHA256 = "ecdsa-p256-sha256"
+	httpSigAlgECDSAP384SHA384 = "ecdsa-p384-sha384"
+	httpSigAlgEd25519         = "ed25519"
+)
+
+var (
+	errHTTPSignatureBase        = errors.New("failed to create signature base")
+	errHTTPSignatureInvalid     = errors.New("signature verification failed")
+	errHTTPSignatureAlgorithm   = errors.New("unusable signature algorithm")
+	errContentDigestMismatch    = errors.New("content digest mismatch")
+	errContentDigestUnsupported = errors.New("no supported digest algorithm")
+
+	// maps the JWA algorithms to the corresponding algorithms used for http message signatures
+	jwaToHTTPSigAlgs = map[string]string{ //nolint:gochecknoglobals
+		string(jose.PS512): httpSigAlgRSAPSSSHA512,
+		string(jose.RS256): httpSigAlgRSAV15SHA256,
+		string(jose.HS256): httpSigAlgHMACSHA256,
+		string(jose.ES256): httpSigAlgECDSAP256SHA256,
+		string(jose.ES384): httpSigAlgECDSAP384SHA384,
+		string(jose.EdDSA): httpSigAlgEd25519,
+	}
+)
+
+// componentIdentifier is a component covered by an http message signature as defined
+// in RFC 9421, section 2.
+type componentIdentifier struct {
+	Name   string
+	Params structuredfields.Params
+}
+
+func (c componentIdentifier) item() structuredfields.Item {
+	return structuredfields.Item{Value: c.Name, Params: c.Params}
+}
+
+func (c componentIdentifier) String() string {
+	var builder strings.Builder
+
+	c.item().Serialize(&builder)
+
+	return builder.String()
+}
+
+// signatureInput holds the covered components and the signature parameters of a single
+// signature as conveyed by the Signature-Input header, see RFC 9421, section 4.1.
+type signatureInput struct {
+	Label      string
+	Components []componentIdentifier
+	Params     structuredfields.Params
+}
+
+func (s *signatureInput) serialize() string {
+	var builder strings.Builder
+
+	list := structuredfields.InnerList{Params: s.Params}
+	for _, component := range s.Components {
+		list.Items = append(list.Items, component.item())
+	}
+
+	list.Serialize(&builder)
+
+	return builder.String()
+}
+
+func (s *signatureInput) stringParam(key string) (string, error) {
+	value, present := s.Params.Get(key)
+	if !present {
+		return "", nil
+	}
+
+	str, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("%w: '%s' parameter must be a string", structuredfields.ErrMalformed, key)
+	}
+
+	return str, nil
+}
+
+func (s *signatureInput) intParam(key string) (int64, bool, error) {
+	value, present := s.Params.Get(key)
+	if !present {
+		return 0, false, nil
+	}
+
+	val, ok := value.(int64)
+	if !ok {
+		return 0, false, fmt.Errorf("%w: '%s' parameter must be an integer", structuredfields.ErrMalformed, key)
+	}
+
+	return val, true, nil
+}
+
+// parseSignatureInputs parses the value of the Signature-Input header. The order of the
+// signatures is preserved.
+func parseSignatureInputs(value string) ([]*signatureInput, error) {
+	members, err := structuredfields.ParseDictionary(value)
+	if err != nil {
+		return nil, err
+	}
+
+	inputs := make([]*signatureInput, 0, len(members))
+
+	for _, member := range members {
+		list, ok := member.Value.(structuredfields.InnerList)
+		if !ok {
+			return nil, fmt.Errorf("%w: signature input '%s' is not an inner list",
+				structuredfields.ErrMalformed, member.Key)
+		}
+
+		input := &signatureInput{Label: member.Key, Params: list.Params}
+
+		for _, component := range list.Items {
+			name, ok := component.Value.(string)
+			if !ok {
+				return nil, fmt.Errorf("%w: component identifiers must be strings", structuredfields.ErrMalformed)
+			}
+
+			input.Components = append(input.Components, componentIdentifier{Name: name, Params: component.Params})
+		}
+
+		inputs = append(inputs, input)
+	}
+
+	return inputs, nil
+}
+
+// parseByteSequenceDictionary parses dictionaries, which members are byte sequences, like
+// the values of the Signature and the Content-Digest headers.
+func parseByteSequenceDictionary(value string) (map[string][]byte, error) {
+	members, err := structuredfields.ParseDictionary(value)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string][]byte, len(members))
+
+	for _, member := range members {
+		var data []byte
+
+		if item, ok := member.Value.(structuredfields.Item); ok {
+			data, _ = item.Value.([]byte)
+		}
+
+		if data == nil {
+			return nil, fmt.Errorf("%w: value of '%s' is not a byte sequence", structuredfields.ErrMalformed, member.Key)
+		}
+
+		result[member.Key] = data
+	}
+
+	return result, nil
+}
+
+// createSignatureBase creates the signature base for the given signature input
+// as defined in RFC 9421, section 2.5.
+func createSignatureBase(req *heimdall.Request, input *signatureInput) ([]byte, error) {
+	var builder strings.Builder
+
+	seen := make(map[string]bool, len(input.Components))
+
+	for _, component := range input.Components {
+		identifier := component.String()
+		if seen[identifier] {
+			return nil, fmt.Errorf("%w: component %s is covered multiple times", errHTTPSignatureBase, identifier)
+		}
+
+		seen[identifier] = true
+
+		value, err := componentValue(req, component)
+		if err != nil {
+			return nil, err
+		}
+
+		if strings.ContainsAny(value, "\r\n") {
+			return nil, fmt.Errorf("%w: value of component %s contains line breaks", errHTTPSignatureBase, identifier)
+		}
+
+		builder.WriteString(identifier)
+		builder.WriteString(": ")
+		builder.WriteString(value)
+		builder.WriteByte('\n')
+	}
+
+	builder.WriteString(`"@signature-params": `)
+	builder.WriteString(input.serialize())
+
+	return []byte(builder.String()), nil
+}
+
+// normalizedAuthority returns the authority of the given uri as defined in RFC 9421, section
+// 2.2.3, which is the lowercased host without the default port of the scheme.
+func normalizedAuthority(uri *url.URL) string {
+	authority := strings.ToLower(uri.Host)
+
+	if port := uri.Port(); (port == "80" && strings.EqualFold(uri.Scheme, "http")) ||
+		(port == "443" && strings.EqualFold(uri.Scheme, "https")) {
+		authority = strings.TrimSuffix(authority, ":"+port)
+	}
+
+	return authority
+}
+
+func componentValue(req *heimdall.Request, component componentIdentifier) (string, error) {
+	if component.Name == "@query-param" {
+		return queryParamValue(req, component)
+	}
+
+	if len(component.Params) != 0 {
+		return "", fmt.Errorf("%w: parameters of component %s are not supported",
+			errHTTPSignatureBase, component)
+	}
+
+	uri := req.URL.URL
+	uri.Fragment = ""
+
+	switch component.Name {
+	case "@method":
+		return req.Method, nil
+	case "@target-uri":
+		return uri.String(), nil
+	case "@authority":
+		return normalizedAuthority(&uri), nil
+	case "@scheme":
+		return strings.ToLower(uri.Scheme), nil
+	case "@request-target":
+		return uri.RequestURI(), nil
+	case "@path":
+		if path := uri.EscapedPath(); len(path) != 0 {
+			return path, nil
+		}
+
+		return "/", nil
+	case "@query":
+		return "?" + uri.RawQuery, nil
+	}
+
+	if strings.HasPrefix(component.Name, "@") {
+		return "", fmt.Errorf("%w: unsupported derived component %s", errHTTPSignatureBase, component)
+	}
+
+	if component.Name != strings.ToLower(component.Name) {
+		return "", fmt.Errorf("%w: field name %s is not lowercased", errHTTPSignatureBase, component)
+	}
+
+	value := strings.TrimSpace(req.Header(component.Name))
+	if len(value) == 0 {
+		return "", fmt.Errorf("%w: covered field %s is not present", errHTTPSignatureBase, component)
+	}
+
+	return value, nil
+}
+
+func queryParamValue(req *heimdall.Request, component componentIdentifier) (string, error) {
+	rawName, _ := component.Params.Get("name")
+
+	encodedName, ok := rawName.(string)
+	if !ok || len(component.Params) != 1 {
+		return "", fmt.Errorf("%w: component %s requires exactly the name parameter",
+			errHTTPSignatureBase, component)
+	}
+
+	name, err := url.QueryUnescape(encodedName)
+	if err != nil {
+		return "", fmt.Errorf("%w: malformed name parameter of component %s: %w",
+			errHTTPSignatureBase, component, err)
+	}
+
+	values := req.URL.Query()[name]
+	if len(values) != 1 {
+		return "", fmt.Errorf("%w: query parameter referenced by %s is not present or not unique",
+			errHTTPSignatureBase, component)
+	}
+
+	return strings.ReplaceAll(url.QueryEscape(values[0]), "+", "%20"), nil
+}
+
+// httpSignatureAlgorithm determines the algorithm to be used for the verification of the signature
+// using the given key. The algorithm requested by the alg signature parameter, if present, must be
+// compatible with the algorithm the key is bound to.
+func httpSignatureAlgorithm(requested string, key *jose.JSONWebKey) (string, error) {
+	var keyAlg string
+
+	if len(key.Algorithm) != 0 {
+		alg, ok := jwaToHTTPSigAlgs[key.Algorithm]
+		if !ok {
+			return "", fmt.Errorf("%w: algorithm %s of the key is not supported",
+				errHTTPSignatureAlgorithm, key.Algorithm)
+		}
+
+		keyAlg = alg
+	} else {
+		switch typedKey := key.Key.(type) {
+		case *ecdsa.PublicKey:
+			keyAlg = x.IfThenElse(typedKey.Curve == elliptic.P256(), httpSigAlgECDSAP256SHA256,
+				x.IfThenElse(typedKey.Curve == elliptic.P384(), httpSigAlgECDSAP384SHA384, ""))
+		case ed25519.PublicKey:
+			keyAlg = httpSigAlgEd25519
+		case []byte:
+			keyAlg = httpSigAlgHMACSHA256
+		}
+	}
+
+	switch {
+	case len(requested) == 0 && len(keyAlg) == 0:
+		return "", fmt.Errorf("%w: the algorithm can neither be derived from the signature, nor from the key",
+			errHTTPSignatureAlgorithm)
+	case len(requested) == 0:
+		return keyAlg, nil
+	case len(keyAlg) == 0 || keyAlg == requested:
+		return requested, nil
+	default:
+		return "", fmt.Errorf("%w: requested algorithm %s does not match the algorithm %s of the key",
+			errHTTPSignatureAlgorithm, requested, keyAlg)
+	}
+}
+
+// verifyHTTPSignature verifies the signature over the given signature base using the given algorithm
+// as defined in RFC 9421, section 3.3.
+func verifyHTTPSignature(alg string, key any, base, signature []byte) error { //nolint:cyclop
+	var err error
+
+	switch alg {
+	case httpSigAlgRSAPSSSHA512:
+		pubKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%w: %s requires an rsa key", errHTTPSignatureAlgorithm, alg)
+		}
+
+		digest := sha512.Sum512(base)
+		err = rsa.VerifyPSS(pubKey, crypto.SHA512, digest[:], signature,
+			&rsa.PSSOptions{SaltLength: sha512.Size, Hash: crypto.SHA512})
+	case httpSigAlgRSAV15SHA256:
+		pubKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%w: %s requires an rsa key", errHTTPSignatureAlgorithm, alg)
+		}
+
+		digest := sha256.Sum256(base)
+		err = rsa.VerifyPKCS1v15(pubKey, crypto.SHA256, digest[:], signature)
+	case httpSigAlgECDSAP256SHA256:
+		digest := sha256.Sum256(base)
+		err = verifyECDSASignature(alg, key, elliptic.P256(), digest[:], signature)
+	case httpSigAlgECDSAP384SHA384:
+		digest := sha512.Sum384(base)
+		err = verifyECDSASignature(alg, key, elliptic.P384(), digest[:], signature)
+	case httpSigAlgEd25519:
+		pubKey, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return fmt.Errorf("%w: %s requires an ed25519 key", errHTTPSignatureAlgorithm, alg)
+		}
+
+		if !ed25519.Verify(pubKey, base, signature) {
+			err = errHTTPSignatureInvalid
+		}
+	case httpSigAlgHMACSHA256:
+		secret, ok := key.([]byte)
+		if !ok {
+			return fmt.Errorf("%w: %s requires a symmetric key", errHTTPSignatureAlgorithm, alg)
+		}
+
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(base)
+
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			err = errHTTPSignatureInvalid
+		}
+	default:
+		return fmt.Errorf("%w: %s is not supported", errHTTPSignatureAlgorithm, alg)
+	}
+
+	if err != nil && !errors.Is(err, errHTTPSignatureAlgorithm) {
+		return fmt.Errorf("%w: %w", errHTTPSignatureInvalid, err)
+	}
+
+	return err
+}
+
+func verifyECDSASignature(alg string, key any, curve elliptic.Curve, digest, signature []byte) error {
+	pubKey, ok := key.(*ecdsa.PublicKey)
+	if !ok || pubKey.Curve != curve {
+		return fmt.Errorf("%w: %s requires an ecdsa key on the %s curve",
+			errHTTPSignatureAlgorithm, alg, curve.Params().Name)
+	}
+
+	// the signature is the concatenation of r and s, each in the size of the curve
+	size := (curve.Params().BitSize + 7) / 8 //nolint:gomnd
+	if len(signature) != 2*size {
+		return errHTTPSignatureInvalid
+	}
+
+	if !ecdsa.Verify(pubKey, digest,
+		new(big.Int).SetBytes(signature[:size]), new(big.Int).SetBytes(signature[size:])) {
+		return errHTTPSignatureInvalid
+	}
+
+	return nil
+}
+
+// verifyContentDigest verifies the digests from the Content-Digest header, as defined in
+// RFC 9530, against the given body. All digests using supported algorithms must match.
+func verifyContentDigest(value string, body []byte) error {
+	digests, err := parseByteSequenceDictionary(value)
+	if err != nil {
+		return err
+	}
+
+	var verified bool
+
+	for alg, expected := range digests {
+		var actual []byte
+
+		switch alg {
+		case "sha-256":
+			sum := sha256.Sum256(body)
+			actual = sum[:]
+		case "sha-512":
+			sum := sha512.Sum512(body)
+			actual = sum[:]
+		default:
+			continue
+		}
+
+		if !bytes.Equal(expected, actual) {
+			return fmt.Errorf("%w: %s digest does not match the body", errContentDigestMismatch, alg)
+		}
+
+		verified = true
+	}
+
+	if !verified {
+		return errContentDigestUnsupported
+	}
+
+	return nil
+}
@@ -0,0 +1,610 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package authenticators
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/rs/zerolog"
+
+	"github.com/dadrus/heimdall/internal/cache"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/rules/endpoint"
+	"github.com/dadrus/heimdall/internal/rules/mechanisms/subject"
+	"github.com/dadrus/heimdall/internal/x"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+	"github.com/dadrus/heimdall/internal/x/pkix"
+	"github.com/dadrus/heimdall/internal/x/stringx"
+)
+
+const (
+	defaultHTTPSignatureMaxAge   = 5 * time.Minute
+	defaultHTTPSignatureCacheTTL = 10 * time.Minute
+
+	// httpSignatureTimeLeeway is the tolerated clock skew between the signer and heimdall
+	httpSignatureTimeLeeway = 10 * time.Second
+
+	pemBlockTypePublicKey   = "PUBLIC KEY"
+	pemBlockTypeCertificate = "CERTIFICATE"
+)
+
+// by intention. Used only during application bootstrap
+//
+//nolint:gochecknoinits
+func init() {
+	registerTypeFactory(
+		func(id string, typ string, conf map[string]any) (bool, Authenticator, error) {
+			if typ != AuthenticatorHTTPMessageSignatures {
+				return false, nil, nil
+			}
+
+			auth, err := newHTTPMessageSignatureAuthenticator(id, conf)
+
+			return true, auth, err
+		})
+}
+
+type HTTPSignatureKeyStore struct {
+	Path string `mapstructure:"path" validate:"required"`
+}
+
+type httpMessageSignatureAuthenticator struct {
+	id                   string
+	ep                   *endpoint.Endpoint
+	keys                 *jose.JSONWebKeySet
+	label                string
+	requiredComponents   []string
+	allowedAlgorithms    []string
+	maxAge               time.Duration
+	ttl                  time.Duration
+	sf                   SubjectFactory
+	allowFallbackOnError bool
+}
+
+func newHTTPMessageSignatureAuthenticator( // nolint: funlen
+	id string, rawConfig map[string]any,
+) (*httpMessageSignatureAuthenticator, error) {
+	type Config struct {
+		JWKSEndpoint         *endpoint.Endpoint     `mapstructure:"jwks_endpoint"           validate:"required_without=KeyStore,excluded_with=KeyStore"`         //nolint:lll,tagalign
+		KeyStore             *HTTPSignatureKeyStore `mapstructure:"key_store"               validate:"required_without=JWKSEndpoint,excluded_with=JWKSEndpoint"` //nolint:lll,tagalign
+		SignatureLabel       string                 `mapstructure:"signature_label"`
+		RequiredComponents   []string               `mapstructure:"required_components"`
+		AllowedAlgorithms    []string               `mapstructure:"allowed_algorithms"      validate:"dive,oneof=rsa-pss-sha512 rsa-v1_5-sha256 hmac-sha256 ecdsa-p256-sha256 ecdsa-p384-sha384 ed25519"` //nolint:lll,tagalign
+		MaxAge               *time.Duration         `mapstructure:"max_age"`
+		SubjectInfo          SubjectInfo            `mapstructure:"subject"                 validate:"-"`
+		CacheTTL             *time.Duration         `mapstructure:"cache_ttl"`
+		AllowFallbackOnError bool                   `mapstructure:"allow_fallback_on_error"`
+	}
+
+	var conf Config
+	if err := decodeConfig(AuthenticatorHTTPMessageSignatures, rawConfig, &conf); err != nil {
+		return nil, err
+	}
+
+	var keys *jose.JSONWebKeySet
+
+	if conf.KeyStore != nil {
+		var err error
+
+		if keys, err = loadHTTPSignatureKeys(conf.KeyStore.Path); err != nil {
+			return nil, err
+		}
+	}
+
+	if conf.JWKSEndpoint != nil {
+		if conf.JWKSEndpoint.Headers == nil {
+			conf.JWKSEndpoint.Headers = make(map[string]string)
+		}
+
+		if _, ok := conf.JWKSEndpoint.Headers["Accept"]; !ok {
+			conf.JWKSEndpoint.Headers["Accept"] = "application/json"
+		}
+
+		if len(conf.JWKSEndpoint.Method) == 0 {
+			conf.JWKSEndpoint.Method = http.MethodGet
+		}
+	}
+
+	if len(conf.RequiredComponents) == 0 {
+		conf.RequiredComponents = []string{"@method", "@target-uri"}
+	}
+
+	if len(conf.AllowedAlgorithms) == 0 {
+		conf.AllowedAlgorithms = []string{
+			httpSigAlgRSAPSSSHA512, httpSigAlgRSAV15SHA256,
+			httpSigAlgECDSAP256SHA256, httpSigAlgECDSAP384SHA384, httpSigAlgEd25519,
+		}
+	}
+
+	if len(conf.SubjectInfo.IDFrom) == 0 {
+		conf.SubjectInfo.IDFrom = "key_id"
+	}
+
+	return &httpMessageSignatureAuthenticator{
+		id:                 id,
+		ep:                 conf.JWKSEndpoint,
+		keys:               keys,
+		label:              conf.SignatureLabel,
+		requiredComponents: conf.RequiredComponents,
+		allowedAlgorithms:  conf.AllowedAlgorithms,
+		maxAge: x.IfThenElseExec(conf.MaxAge != nil,
+			func() time.Duration { return *conf.MaxAge },
+			func() time.Duration { return defaultHTTPSignatureMaxAge }),
+		ttl: x.IfThenElseExec(conf.CacheTTL != nil,
+			func() time.Duration { return *conf.CacheTTL },
+			func() time.Duration { return defaultHTTPSignatureCacheTTL }),
+		sf:                   &conf.SubjectInfo,
+		allowFallbackOnError: conf.AllowFallbackOnError,
+	}, nil
+}
+
+func (a *httpMessageSignatureAuthenticator) Execute(ctx heimdall.Context) (*subject.Subject, error) {
+	logger := zerolog.Ctx(ctx.AppContext())
+	logger.Debug().Str("_id", a.id).Msg("Authenticating using http message signatures authenticator")
+
+	input, signature, err := a.signature(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := a.verifySignature(ctx, input, signature)
+	if err != nil {
+		return nil, err
+	}
+
+	rawData, err := json.Marshal(info)
+	if err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrInternal, "failed to marshal signature information").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	sub, err := a.sf.CreateSubject(rawData)
+	if err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrInternal, "failed to extract subject information from signature information").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	return sub, nil
+}
+
+func (a *httpMessageSignatureAuthenticator) WithConfig(config map[string]any) (Authenticator, error) {
+	// this authenticator allows the required components, the signature age, the cache ttl
+	// and the fallback behavior to be redefined on the rule level
+	if len(config) == 0 {
+		return a, nil
+	}
+
+	type Config struct {
+		RequiredComponents   []string       `mapstructure:"required_components"`
+		MaxAge               *time.Duration `mapstructure:"max_age"`
+		CacheTTL             *time.Duration `mapstructure:"cache_ttl"`
+		AllowFallbackOnError *bool          `mapstructure:"allow_fallback_on_error"`
+	}
+
+	var conf Config
+	if err := decodeConfig(AuthenticatorHTTPMessageSignatures, config, &conf); err != nil {
+		return nil, err
+	}
+
+	return &httpMessageSignatureAuthenticator{
+		id:                a.id,
+		ep:                a.ep,
+		keys:              a.keys,
+		label:             a.label,
+		allowedAlgorithms: a.allowedAlgorithms,
+		requiredComponents: x.IfThenElse(len(conf.RequiredComponents) != 0,
+			conf.RequiredComponents, a.requiredComponents),
+		maxAge: x.IfThenElseExec(conf.MaxAge != nil,
+			func() time.Duration { return *conf.MaxAge },
+			func() time.Duration { return a.maxAge }),
+		ttl: x.IfThenElseExec(conf.CacheTTL != nil,
+			func() time.Duration { return *conf.CacheTTL },
+			func() time.Duration { return a.ttl }),
+		sf: a.sf,
+		allowFallbackOnError: x.IfThenElseExec(conf.AllowFallbackOnError != nil,
+			func() bool { return *conf.AllowFallbackOnError },
+			func() bool { return a.allowFallbackOnError }),
+	}, nil
+}
+
+func (a *httpMessageSignatureAuthenticator) IsFallbackOnErrorAllowed() bool {
+	return a.allowFallbackOnError
+}
+
+func (a *httpMessageSignatureAuthenticator) ID() string {
+	return a.id
+}
+
+// signature returns the signature input and the signature value to verify. If a label is
+// configured, the signature with that label is used. Otherwise, the first signature is used.
+func (a *httpMessageSignatureAuthenticator) signature(ctx heimdall.Context) (*signatureInput, []byte, error) {
+	rawInputs := ctx.Request().Header("Signature-Input")
+	rawSignatures := ctx.Request().Header("Signature")
+
+	if len(rawInputs) == 0 || len(rawSignatures) == 0 {
+		return nil, nil, errorchain.
+			NewWithMessage(heimdall.ErrAuthentication, "no http message signature present").
+			WithErrorContext(a)
+	}
+
+	inputs, err := parseSignatureInputs(rawInputs)
+	if err != nil {
+		return nil, nil, errorchain.
+			NewWithMessage(heimdall.ErrAuthentication, "failed to parse Signature-Input header").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	signatures, err := parseByteSequenceDictionary(rawSignatures)
+	if err != nil {
+		return nil, nil, errorchain.
+			NewWithMessage(heimdall.ErrAuthentication, "failed to parse Signature header").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	idx := slices.IndexFunc(inputs, func(input *signatureInput) bool {
+		return len(a.label) == 0 || input.Label == a.label
+	})
+	if idx < 0 {
+		return nil, nil, errorchain.
+			NewWithMessagef(heimdall.ErrAuthentication, "no signature labeled '%s' present", a.label).
+			WithErrorContext(a)
+	}
+
+	signature, ok := signatures[inputs[idx].Label]
+	if !ok {
+		return nil, nil, errorchain.
+			NewWithMessagef(heimdall.ErrAuthentication,
+				"no signature value present for the signature labeled '%s'", inputs[idx].Label).
+			WithErrorContext(a)
+	}
+
+	return inputs[idx], signature, nil
+}
+
+func (a *httpMessageSignatureAuthenticator) verifySignature( // nolint: funlen, cyclop
+	ctx heimdall.Context, input *signatureInput, signature []byte,
+) (map[string]any, error) {
+	components := make([]string, len(input.Components))
+	for idx, component := range input.Components {
+		components[idx] = component.Name
+	}
+
+	for _, required := range a.requiredComponents {
+		if !slices.Contains(components, required) {
+			return nil, errorchain.
+				NewWithMessagef(heimdall.ErrAuthentication,
+					"signature does not cover the required component '%s'", required).
+				WithErrorContext(a)
+		}
+	}
+
+	info := map[string]any{"label": input.Label, "components": components}
+
+	if err := a.verifyValidity(input, info); err != nil {
+		return nil, err
+	}
+
+	keyID, err := input.stringParam("keyid")
+	if err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrAuthentication, "signature has an invalid keyid parameter").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	if len(keyID) == 0 {
+		return nil, errorchain.NewWithMessage(heimdall.ErrAuthentication, "signature has no keyid parameter").
+			WithErrorContext(a)
+	}
+
+	requestedAlg, err := input.stringParam("alg")
+	if err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrAuthentication, "signature has an invalid alg parameter").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	jwk, err := a.getKey(ctx, keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	alg, err := httpSignatureAlgorithm(requestedAlg, jwk)
+	if err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrAuthentication, "failed to determine signature algorithm").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	if !slices.Contains(a.allowedAlgorithms, alg) {
+		return nil, errorchain.NewWithMessagef(heimdall.ErrAuthentication, "%s algorithm is not allowed", alg).
+			WithErrorContext(a)
+	}
+
+	base, err := createSignatureBase(ctx.Request(), input)
+	if err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrAuthentication, "failed to create signature base").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	if err = verifyHTTPSignature(alg, jwk.Key, base, signature); err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrAuthentication, "failed to verify http message signature").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	// the signature covers the digest only. So the body must match it
+	if slices.Contains(components, "content-digest") {
+		if err = verifyContentDigest(ctx.Request().Header("Content-Digest"), ctx.Request().RawBody()); err != nil {
+			return nil, errorchain.NewWithMessage(heimdall.ErrAuthentication, "failed to verify content digest").
+				WithErrorContext(a).
+				CausedBy(err)
+		}
+	}
+
+	info["key_id"] = keyID
+	info["algorithm"] = alg
+
+	if len(jwk.Certificates) != 0 {
+		info["certificate"] = pkix.CertificateInfo(jwk.Certificates[0])
+	}
+
+	return info, nil
+}
+
+func (a *httpMessageSignatureAuthenticator) verifyValidity(input *signatureInput, info map[string]any) error {
+	now := time.Now()
+
+	created, present, err := input.intParam("created")
+	if err != nil {
+		return errorchain.NewWithMessage(heimdall.ErrAuthentication, "signature has an invalid created parameter").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	if !present {
+		return errorchain.NewWithMessage(heimdall.ErrAuthentication, "signature has no created parameter").
+			WithErrorContext(a)
+	}
+
+	createdAt := time.Unix(created, 0)
+	if createdAt.After(now.Add(httpSignatureTimeLeeway)) {
+		return errorchain.NewWithMessage(heimdall.ErrAuthentication, "signature is created in the future").
+			WithErrorContext(a)
+	}
+
+	if a.maxAge > 0 && now.Sub(createdAt) > a.maxAge+httpSignatureTimeLeeway {
+		return errorchain.NewWithMessage(heimdall.ErrAuthentication, "signature is too old").
+			WithErrorContext(a)
+	}
+
+	info["created"] = created
+
+	expires, present, err := input.intParam("expires")
+	if err != nil {
+		return errorchain.NewWithMessage(heimdall.ErrAuthentication, "signature has an invalid expires parameter").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	if present {
+		if now.After(time.Unix(expires, 0).Add(httpSignatureTimeLeeway)) {
+			return errorchain.NewWithMessage(heimdall.ErrAuthentication, "signature is expired").
+				WithErrorContext(a)
+		}
+
+		info["expires"] = expires
+	}
+
+	return nil
+}
+
+func (a *httpMessageSignatureAuthenticator) getKey(ctx heimdall.Context, keyID string) (*jose.JSONWebKey, error) {
+	if a.keys != nil {
+		return a.selectKey(a.keys, keyID)
+	}
+
+	load := func(loadCtx context.Context) (*jose.JSONWebKey, time.Duration, error) {
+		jwk, err := a.fetchKey(loadCtx, keyID)
+
+		return jwk, a.ttl, err
+	}
+
+	if a.ttl <= 0 {
+		jwk, _, err := load(ctx.AppContext())
+
+		return jwk, err
+	}
+
+	return cache.GetOrLoad(ctx.AppContext(), cache.Ctx(ctx.AppContext()), a.calculateCacheKey(keyID), load)
+}
+
+func (a *httpMessageSignatureAuthenticator) fetchKey(ctx context.Context, keyID string) (*jose.JSONWebKey, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("Retrieving JWKS from configured endpoint")
+
+	req, err := a.ep.CreateRequest(ctx, nil, nil)
+	if err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrInternal, "failed creating request").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	resp, err := a.ep.CreateClient(req.URL.Hostname()).Do(req)
+	if err != nil {
+		var clientErr *url.Error
+		if errors.As(err, &clientErr) && clientErr.Timeout() {
+			return nil, errorchain.
+				NewWithMessage(heimdall.ErrCommunicationTimeout, "request to JWKS endpoint timed out").
+				WithErrorContext(a).
+				CausedBy(err)
+		}
+
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrCommunication, "request to JWKS endpoint failed").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, errorchain.
+			NewWithMessagef(heimdall.ErrCommunication, "unexpected response. code: %v", resp.StatusCode).
+			WithErrorContext(a)
+	}
+
+	var jwks jose.JSONWebKeySet
+	if err = json.NewDecoder(resp.Body).Decode(&jwks); err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrInternal, "failed to unmarshal received jwks").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	return a.selectKey(&jwks, keyID)
+}
+
+func (a *httpMessageSignatureAuthenticator) selectKey(jwks *jose.JSONWebKeySet, keyID string) (*jose.JSONWebKey, error) {
+	keys := jwks.Key(keyID)
+	if len(keys) != 1 {
+		return nil, errorchain.
+			NewWithMessagef(heimdall.ErrAuthentication,
+				"no (unique) key found for the keyid='%s' referenced in the signature", keyID).
+			WithErrorContext(a)
+	}
+
+	jwk := keys[0]
+	if _, symmetric := jwk.Key.([]byte); !symmetric && !jwk.IsPublic() {
+		jwk = jwk.Public()
+	}
+
+	return &jwk, nil
+}
+
+func (a *httpMessageSignatureAuthenticator) calculateCacheKey(keyID string) string {
+	digest := sha256.New()
+	digest.Write(stringx.ToBytes(AuthenticatorHTTPMessageSignatures))
+	digest.Write(a.ep.Hash())
+	digest.Write(stringx.ToBytes(keyID))
+
+	return hex.EncodeToString(digest.Sum(nil))
+}
+
+// loadHTTPSignatureKeys loads the public keys and certificates from the given PEM file. The key id
+// is taken from the X-Key-ID header of the PEM block. If not present, the subject key identifier
+// of the certificate, respectively the one calculated from the public key is used.
+func loadHTTPSignatureKeys(path string) (*jose.JSONWebKeySet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration, "failed to read key store").
+			CausedBy(err)
+	}
+
+	var (
+		jwks  jose.JSONWebKeySet
+		block *pem.Block
+		rest  = data
+	)
+
+	for idx := 1; ; idx++ {
+		if block, rest = pem.Decode(rest); block == nil {
+			break
+		}
+
+		jwk, err := createHTTPSignatureKey(block)
+		if err != nil {
+			return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+				"failed to load %d entry of the key store", idx).CausedBy(err)
+		}
+
+		if len(jwks.Key(jwk.KeyID)) != 0 {
+			return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+				"duplicate entry for key_id=%s found in the key store", jwk.KeyID)
+		}
+
+		jwks.Keys = append(jwks.Keys, *jwk)
+	}
+
+	if len(jwks.Keys) == 0 {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration, "key store does not contain any keys")
+	}
+
+	return &jwks, nil
+}
+
+func createHTTPSignatureKey(block *pem.Block) (*jose.JSONWebKey, error) {
+	var (
+		jwk         jose.JSONWebKey
+		keyIDSource []byte
+	)
+
+	switch block.Type {
+	case pemBlockTypePublicKey:
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+
+		jwk.Key = key
+	case pemBlockTypeCertificate:
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+
+		jwk.Key = cert.PublicKey
+		jwk.Certificates = []*x509.Certificate{cert}
+		keyIDSource = cert.SubjectKeyId
+	default:
+		return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration, "unsupported entry '%s'", block.Type)
+	}
+
+	jwk.KeyID = block.Headers["X-Key-ID"]
+	if len(jwk.KeyID) == 0 {
+		if len(keyIDSource) == 0 {
+			var err error
+
+			if keyIDSource, err = pkix.SubjectKeyID(jwk.Key); err != nil {
+				return nil, err
+			}
+		}
+
+		jwk.KeyID = hex.EncodeToString(keyIDSource)
+	}
+
+	return &jwk, nil
+}
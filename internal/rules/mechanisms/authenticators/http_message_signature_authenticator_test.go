@@ -0,0 +1,950 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package authenticators
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/cache"
+	"github.com/dadrus/heimdall/internal/cache/memory"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/heimdall/mocks"
+	"github.com/dadrus/heimdall/internal/rules/mechanisms/subject"
+	"github.com/dadrus/heimdall/internal/x/testsupport"
+)
+
+type httpSignatureTestKeys struct {
+	keyStorePath string
+	ecKey        *ecdsa.PrivateKey
+	edKey        ed25519.PrivateKey
+	edCert       *x509.Certificate
+}
+
+func createHTTPSignatureTestKeys(t *testing.T) *httpSignatureTestKeys {
+	t.Helper()
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	edPub, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	rootCA, err := testsupport.NewRootCA("Test Root CA", time.Hour*24)
+	require.NoError(t, err)
+
+	edCert, err := rootCA.IssueCertificate(
+		testsupport.WithSubject(pkix.Name{CommonName: "partner-2", Organization: []string{"Partner"}}),
+		testsupport.WithValidity(time.Now(), time.Hour),
+		testsupport.WithSubjectPubKey(edPub, x509.ECDSAWithSHA384),
+		testsupport.WithGeneratedSubjectKeyID(),
+		testsupport.WithKeyUsage(x509.KeyUsageDigitalSignature))
+	require.NoError(t, err)
+
+	rawECKey, err := x509.MarshalPKIXPublicKey(&ecKey.PublicKey)
+	require.NoError(t, err)
+
+	pemBytes := pem.EncodeToMemory(&pem.Block{
+		Type: "PUBLIC KEY", Headers: map[string]string{"X-Key-ID": "partner-1"}, Bytes: rawECKey,
+	})
+	pemBytes = append(pemBytes, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: edCert.Raw})...)
+
+	keyStorePath := t.TempDir() + "/keys.pem"
+	require.NoError(t, os.WriteFile(keyStorePath, pemBytes, 0o600))
+
+	return &httpSignatureTestKeys{keyStorePath: keyStorePath, ecKey: ecKey, edKey: edKey, edCert: edCert}
+}
+
+type httpSignatureTestRequest struct {
+	method  string
+	url     string
+	headers map[string]string
+	body    []byte
+}
+
+func (r *httpSignatureTestRequest) create(t *testing.T) *heimdall.Request {
+	t.Helper()
+
+	uri, err := url.Parse(r.url)
+	require.NoError(t, err)
+
+	reqf := mocks.NewRequestFunctionsMock(t)
+	reqf.EXPECT().Header(mock.Anything).RunAndReturn(func(name string) string {
+		return r.headers[strings.ToLower(name)]
+	}).Maybe()
+	reqf.EXPECT().RawBody().Return(r.body).Maybe()
+
+	return &heimdall.Request{RequestFunctions: reqf, Method: r.method, URL: &heimdall.URL{URL: *uri}}
+}
+
+// sign adds the Signature-Input and the Signature headers for the given signature input to the request.
+func (r *httpSignatureTestRequest) sign(t *testing.T, input, alg string, key any) {
+	t.Helper()
+
+	inputs, err := parseSignatureInputs(input)
+	require.NoError(t, err)
+
+	base, err := createSignatureBase(r.create(t), inputs[0])
+	require.NoError(t, err)
+
+	signature := signHTTPMessage(t, alg, key, base)
+
+	r.headers["signature-input"] = input
+	r.headers["signature"] = inputs[0].Label + "=:" + base64.StdEncoding.EncodeToString(signature) + ":"
+}
+
+func TestCreateHTTPMessageSignatureAuthenticator(t *testing.T) {
+	t.Parallel()
+
+	keys := createHTTPSignatureTestKeys(t)
+
+	rawKey, err := x509.MarshalPKIXPublicKey(&keys.ecKey.PublicKey)
+	require.NoError(t, err)
+
+	duplicatesPath := t.TempDir() + "/duplicates.pem"
+	block := &pem.Block{Type: "PUBLIC KEY", Headers: map[string]string{"X-Key-ID": "foo"}, Bytes: rawKey}
+	require.NoError(t, os.WriteFile(duplicatesPath,
+		append(pem.EncodeToMemory(block), pem.EncodeToMemory(block)...), 0o600))
+
+	privateKeyPath := t.TempDir() + "/private.pem"
+	rawPrivKey, err := x509.MarshalECPrivateKey(keys.ecKey)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(privateKeyPath,
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: rawPrivKey}), 0o600))
+
+	emptyPath := t.TempDir() + "/empty.pem"
+	require.NoError(t, os.WriteFile(emptyPath, []byte("foo"), 0o600))
+
+	for _, tc := range []struct {
+		uc     string
+		id     string
+		config []byte
+		assert func(t *testing.T, err error, auth *httpMessageSignatureAuthenticator)
+	}{
+		{
+			uc: "without key source",
+			assert: func(t *testing.T, err error, _ *httpMessageSignatureAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				require.ErrorContains(t, err, "'jwks_endpoint' is a required field")
+			},
+		},
+		{
+			uc: "with jwks endpoint and key store",
+			config: []byte(`
+jwks_endpoint:
+  url: http://foo.bar
+key_store:
+  path: ` + keys.keyStorePath),
+			assert: func(t *testing.T, err error, _ *httpMessageSignatureAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				require.ErrorContains(t, err, "'key_store' is an excluded field")
+			},
+		},
+		{
+			uc: "with unsupported algorithm",
+			config: []byte(`
+jwks_endpoint:
+  url: http://foo.bar
+allowed_algorithms:
+  - rsa-pss-sha256
+`),
+			assert: func(t *testing.T, err error, _ *httpMessageSignatureAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				require.ErrorContains(t, err, "'allowed_algorithms'[0] must be one of")
+			},
+		},
+		{
+			uc: "with not existing key store",
+			config: []byte(`
+key_store:
+  path: /does/not/exist.pem
+`),
+			assert: func(t *testing.T, err error, _ *httpMessageSignatureAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				require.ErrorContains(t, err, "failed to read key store")
+			},
+		},
+		{
+			uc:     "with key store containing private keys",
+			config: []byte(`key_store: { path: ` + privateKeyPath + ` }`),
+			assert: func(t *testing.T, err error, _ *httpMessageSignatureAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				require.ErrorContains(t, err, "unsupported entry 'EC PRIVATE KEY'")
+			},
+		},
+		{
+			uc:     "with key store containing duplicate key ids",
+			config: []byte(`key_store: { path: ` + duplicatesPath + ` }`),
+			assert: func(t *testing.T, err error, _ *httpMessageSignatureAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				require.ErrorContains(t, err, "duplicate entry for key_id=foo")
+			},
+		},
+		{
+			uc:     "with key store without keys",
+			config: []byte(`key_store: { path: ` + emptyPath + ` }`),
+			assert: func(t *testing.T, err error, _ *httpMessageSignatureAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				require.ErrorContains(t, err, "does not contain any keys")
+			},
+		},
+		{
+			uc: "with minimal jwks endpoint based configuration",
+			id: "auth1",
+			config: []byte(`
+jwks_endpoint:
+  url: http://foo.bar
+`),
+			assert: func(t *testing.T, err error, auth *httpMessageSignatureAuthenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				assert.Equal(t, "auth1", auth.ID())
+				require.NotNil(t, auth.ep)
+				assert.Equal(t, http.MethodGet, auth.ep.Method)
+				assert.Equal(t, "application/json", auth.ep.Headers["Accept"])
+				assert.Nil(t, auth.keys)
+				assert.Empty(t, auth.label)
+				assert.Equal(t, []string{"@method", "@target-uri"}, auth.requiredComponents)
+				assert.Equal(t, []string{
+					httpSigAlgRSAPSSSHA512, httpSigAlgRSAV15SHA256,
+					httpSigAlgECDSAP256SHA256, httpSigAlgECDSAP384SHA384, httpSigAlgEd25519,
+				}, auth.allowedAlgorithms)
+				assert.Equal(t, defaultHTTPSignatureMaxAge, auth.maxAge)
+				assert.Equal(t, defaultHTTPSignatureCacheTTL, auth.ttl)
+				assert.Equal(t, &SubjectInfo{IDFrom: "key_id"}, auth.sf)
+				assert.False(t, auth.IsFallbackOnErrorAllowed())
+			},
+		},
+		{
+			uc: "with full key store based configuration",
+			id: "auth2",
+			config: []byte(`
+key_store:
+  path: ` + keys.keyStorePath + `
+signature_label: partner
+required_components:
+  - "@method"
+  - "@path"
+  - content-digest
+allowed_algorithms:
+  - ed25519
+max_age: 1m
+subject:
+  id: certificate.subject.common_name
+allow_fallback_on_error: true
+`),
+			assert: func(t *testing.T, err error, auth *httpMessageSignatureAuthenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				assert.Equal(t, "auth2", auth.ID())
+				assert.Nil(t, auth.ep)
+				require.NotNil(t, auth.keys)
+				require.Len(t, auth.keys.Keys, 2)
+
+				ecKey := auth.keys.Key("partner-1")
+				require.Len(t, ecKey, 1)
+				assert.Equal(t, &keys.ecKey.PublicKey, ecKey[0].Key)
+				assert.Empty(t, ecKey[0].Certificates)
+
+				edKey := auth.keys.Key(hex.EncodeToString(keys.edCert.SubjectKeyId))
+				require.Len(t, edKey, 1)
+				assert.Equal(t, keys.edKey.Public(), edKey[0].Key)
+				assert.Equal(t, []*x509.Certificate{keys.edCert}, edKey[0].Certificates)
+
+				assert.Equal(t, "partner", auth.label)
+				assert.Equal(t, []string{"@method", "@path", "content-digest"}, auth.requiredComponents)
+				assert.Equal(t, []string{httpSigAlgEd25519}, auth.allowedAlgorithms)
+				assert.Equal(t, time.Minute, auth.maxAge)
+				assert.Equal(t, &SubjectInfo{IDFrom: "certificate.subject.common_name"}, auth.sf)
+				assert.True(t, auth.IsFallbackOnErrorAllowed())
+			},
+		},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			conf, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			// WHEN
+			auth, err := newHTTPMessageSignatureAuthenticator(tc.id, conf)
+
+			// THEN
+			tc.assert(t, err, auth)
+		})
+	}
+}
+
+func TestCreateHTTPMessageSignatureAuthenticatorFromPrototypeConfig(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc     string
+		config []byte
+		assert func(t *testing.T, err error, prototype, configured *httpMessageSignatureAuthenticator)
+	}{
+		{
+			uc: "without target config",
+			assert: func(t *testing.T, err error, prototype, configured *httpMessageSignatureAuthenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, prototype, configured)
+			},
+		},
+		{
+			uc: "with all overridable properties redefined",
+			config: []byte(`
+required_components: [ "@method", "@path" ]
+max_age: 30s
+cache_ttl: 0s
+allow_fallback_on_error: true
+`),
+			assert: func(t *testing.T, err error, prototype, configured *httpMessageSignatureAuthenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.NotEqual(t, prototype, configured)
+				assert.Equal(t, prototype.ID(), configured.ID())
+				assert.Equal(t, prototype.ep, configured.ep)
+				assert.Equal(t, prototype.keys, configured.keys)
+				assert.Equal(t, prototype.label, configured.label)
+				assert.Equal(t, prototype.allowedAlgorithms, configured.allowedAlgorithms)
+				assert.Equal(t, prototype.sf, configured.sf)
+				assert.Equal(t, []string{"@method", "@path"}, configured.requiredComponents)
+				assert.Equal(t, 30*time.Second, configured.maxAge)
+				assert.Equal(t, time.Duration(0), configured.ttl)
+				assert.True(t, configured.IsFallbackOnErrorAllowed())
+			},
+		},
+		{
+			uc:     "with only fallback redefined",
+			config: []byte(`allow_fallback_on_error: true`),
+			assert: func(t *testing.T, err error, prototype, configured *httpMessageSignatureAuthenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, prototype.requiredComponents, configured.requiredComponents)
+				assert.Equal(t, prototype.maxAge, configured.maxAge)
+				assert.Equal(t, prototype.ttl, configured.ttl)
+				assert.False(t, prototype.IsFallbackOnErrorAllowed())
+				assert.True(t, configured.IsFallbackOnErrorAllowed())
+			},
+		},
+		{
+			uc:     "with not overridable property",
+			config: []byte(`signature_label: foo`),
+			assert: func(t *testing.T, err error, _, _ *httpMessageSignatureAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				require.ErrorContains(t, err, "invalid keys: signature_label")
+			},
+		},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			conf, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			prototype, err := newHTTPMessageSignatureAuthenticator("auth1",
+				map[string]any{"jwks_endpoint": map[string]any{"url": "http://foo.bar"}})
+			require.NoError(t, err)
+
+			// WHEN
+			auth, err := prototype.WithConfig(conf)
+
+			// THEN
+			var (
+				configured *httpMessageSignatureAuthenticator
+				ok         bool
+			)
+
+			if err == nil {
+				configured, ok = auth.(*httpMessageSignatureAuthenticator)
+				require.True(t, ok)
+			}
+
+			tc.assert(t, err, prototype, configured)
+		})
+	}
+}
+
+func TestHTTPMessageSignatureAuthenticatorExecute(t *testing.T) {
+	t.Parallel()
+
+	keys := createHTTPSignatureTestKeys(t)
+	edKeyID := hex.EncodeToString(keys.edCert.SubjectKeyId)
+	body := []byte(`{"event": "created"}`)
+	bodyDigest := sha256.Sum256(body)
+	contentDigest := "sha-256=:" + base64.StdEncoding.EncodeToString(bodyDigest[:]) + ":"
+
+	sigInput := func(label, components, params string) string {
+		return fmt.Sprintf(`%s=(%s);created=%d;%s`, label, components, time.Now().Unix(), params)
+	}
+
+	for _, tc := range []struct {
+		uc      string
+		config  []byte
+		prepare func(t *testing.T, req *httpSignatureTestRequest)
+		assert  func(t *testing.T, err error, sub *subject.Subject)
+	}{
+		{
+			uc:      "no signature present",
+			prepare: func(t *testing.T, _ *httpSignatureTestRequest) { t.Helper() },
+			assert: func(t *testing.T, err error, _ *subject.Subject) {
+				t.Helper()
+
+				require.ErrorIs(t, err, heimdall.ErrAuthentication)
+				require.ErrorContains(t, err, "no http message signature present")
+			},
+		},
+		{
+			uc: "malformed Signature-Input header",
+			prepare: func(t *testing.T, req *httpSignatureTestRequest) {
+				t.Helper()
+
+				req.headers["signature-input"] = `sig1="@method"`
+				req.headers["signature"] = "sig1=:Zm9v:"
+			},
+			assert: func(t *testing.T, err error, _ *subject.Subject) {
+				t.Helper()
+
+				require.ErrorIs(t, err, heimdall.ErrAuthentication)
+				require.ErrorContains(t, err, "failed to parse Signature-Input header")
+			},
+		},
+		{
+			uc: "malformed Signature header",
+			prepare: func(t *testing.T, req *httpSignatureTestRequest) {
+				t.Helper()
+
+				req.headers["signature-input"] = `sig1=("@method")`
+				req.headers["signature"] = `sig1="foo"`
+			},
+			assert: func(t *testing.T, err error, _ *subject.Subject) {
+				t.Helper()
+
+				require.ErrorIs(t, err, heimdall.ErrAuthentication)
+				require.ErrorContains(t, err, "failed to parse Signature header")
+			},
+		},
+		{
+			uc:     "configured signature label not present",
+			config: []byte(`signature_label: partner`),
+			prepare: func(t *testing.T, req *httpSignatureTestRequest) {
+				t.Helper()
+
+				req.sign(t, sigInput("sig1", `"@method" "@target-uri"`, `keyid="partner-1"`),
+					httpSigAlgECDSAP256SHA256, keys.ecKey)
+			},
+			assert: func(t *testing.T, err error, _ *subject.Subject) {
+				t.Helper()
+
+				require.ErrorIs(t, err, heimdall.ErrAuthentication)
+				require.ErrorContains(t, err, "no signature labeled 'partner' present")
+			},
+		},
+		{
+			uc: "no signature value for the signature input",
+			prepare: func(t *testing.T, req *httpSignatureTestRequest) {
+				t.Helper()
+
+				req.sign(t, sigInput("sig1", `"@method" "@target-uri"`, `keyid="partner-1"`),
+					httpSigAlgECDSAP256SHA256, keys.ecKey)
+				req.headers["signature"] = strings.Replace(req.headers["signature"], "sig1", "sig2", 1)
+			},
+			assert: func(t *testing.T, err error, _ *subject.Subject) {
+				t.Helper()
+
+				require.ErrorIs(t, err, heimdall.ErrAuthentication)
+				require.ErrorContains(t, err, "no signature value present for the signature labeled 'sig1'")
+			},
+		},
+		{
+			uc: "required component not covered",
+			prepare: func(t *testing.T, req *httpSignatureTestRequest) {
+				t.Helper()
+
+				req.sign(t, sigInput("sig1", `"@method" "@path"`, `keyid="partner-1"`),
+					httpSigAlgECDSAP256SHA256, keys.ecKey)
+			},
+			assert: func(t *testing.T, err error, _ *subject.Subject) {
+				t.Helper()
+
+				require.ErrorIs(t, err, heimdall.ErrAuthentication)
+				require.ErrorContains(t, err, "does not cover the required component '@target-uri'")
+			},
+		},
+		{
+			uc: "created parameter missing",
+			prepare: func(t *testing.T, req *httpSignatureTestRequest) {
+				t.Helper()
+
+				req.sign(t, `sig1=("@method" "@target-uri");keyid="partner-1"`, httpSigAlgECDSAP256SHA256, keys.ecKey)
+			},
+			assert: func(t *testing.T, err error, _ *subject.Subject) {
+				t.Helper()
+
+				require.ErrorIs(t, err, heimdall.ErrAuthentication)
+				require.ErrorContains(t, err, "signature has no created parameter")
+			},
+		},
+		{
+			uc: "signature created in the future",
+			prepare: func(t *testing.T, req *httpSignatureTestRequest) {
+				t.Helper()
+
+				req.sign(t, fmt.Sprintf(`sig1=("@method" "@target-uri");created=%d;keyid="partner-1"`,
+					time.Now().Add(time.Minute).Unix()), httpSigAlgECDSAP256SHA256, keys.ecKey)
+			},
+			assert: func(t *testing.T, err error, _ *subject.Subject) {
+				t.Helper()
+
+				require.ErrorIs(t, err, heimdall.ErrAuthentication)
+				require.ErrorContains(t, err, "created in the future")
+			},
+		},
+		{
+			uc:     "signature too old",
+			config: []byte(`max_age: 1m`),
+			prepare: func(t *testing.T, req *httpSignatureTestRequest) {
+				t.Helper()
+
+				req.sign(t, fmt.Sprintf(`sig1=("@method" "@target-uri");created=%d;keyid="partner-1"`,
+					time.Now().Add(-2*time.Minute).Unix()), httpSigAlgECDSAP256SHA256, keys.ecKey)
+			},
+			assert: func(t *testing.T, err error, _ *subject.Subject) {
+				t.Helper()
+
+				require.ErrorIs(t, err, heimdall.ErrAuthentication)
+				require.ErrorContains(t, err, "signature is too old")
+			},
+		},
+		{
+			uc: "signature expired",
+			prepare: func(t *testing.T, req *httpSignatureTestRequest) {
+				t.Helper()
+
+				req.sign(t, sigInput("sig1", `"@method" "@target-uri"`,
+					fmt.Sprintf(`expires=%d;keyid="partner-1"`, time.Now().Add(-time.Minute).Unix())),
+					httpSigAlgECDSAP256SHA256, keys.ecKey)
+			},
+			assert: func(t *testing.T, err error, _ *subject.Subject) {
+				t.Helper()
+
+				require.ErrorIs(t, err, heimdall.ErrAuthentication)
+				require.ErrorContains(t, err, "signature is expired")
+			},
+		},
+		{
+			uc: "keyid parameter missing",
+			prepare: func(t *testing.T, req *httpSignatureTestRequest) {
+				t.Helper()
+
+				req.sign(t, sigInput("sig1", `"@method" "@target-uri"`, `alg="ecdsa-p256-sha256"`),
+					httpSigAlgECDSAP256SHA256, keys.ecKey)
+			},
+			assert: func(t *testing.T, err error, _ *subject.Subject) {
+				t.Helper()
+
+				require.ErrorIs(t, err, heimdall.ErrAuthentication)
+				require.ErrorContains(t, err, "signature has no keyid parameter")
+			},
+		},
+		{
+			uc: "unknown key",
+			prepare: func(t *testing.T, req *httpSignatureTestRequest) {
+				t.Helper()
+
+				req.sign(t, sigInput("sig1", `"@method" "@target-uri"`, `keyid="partner-3"`),
+					httpSigAlgECDSAP256SHA256, keys.ecKey)
+			},
+			assert: func(t *testing.T, err error, _ *subject.Subject) {
+				t.Helper()
+
+				require.ErrorIs(t, err, heimdall.ErrAuthentication)
+				require.ErrorContains(t, err, "no (unique) key found for the keyid='partner-3'")
+			},
+		},
+		{
+			uc: "requested algorithm does not match the key",
+			prepare: func(t *testing.T, req *httpSignatureTestRequest) {
+				t.Helper()
+
+				req.sign(t, sigInput("sig1", `"@method" "@target-uri"`, `keyid="partner-1";alg="ed25519"`),
+					httpSigAlgECDSAP256SHA256, keys.ecKey)
+			},
+			assert: func(t *testing.T, err error, _ *subject.Subject) {
+				t.Helper()
+
+				require.ErrorIs(t, err, heimdall.ErrAuthentication)
+				require.ErrorContains(t, err, "failed to determine signature algorithm")
+			},
+		},
+		{
+			uc:     "algorithm not allowed",
+			config: []byte(`allowed_algorithms: [ ed25519 ]`),
+			prepare: func(t *testing.T, req *httpSignatureTestRequest) {
+				t.Helper()
+
+				req.sign(t, sigInput("sig1", `"@method" "@target-uri"`, `keyid="partner-1"`),
+					httpSigAlgECDSAP256SHA256, keys.ecKey)
+			},
+			assert: func(t *testing.T, err error, _ *subject.Subject) {
+				t.Helper()
+
+				require.ErrorIs(t, err, heimdall.ErrAuthentication)
+				require.ErrorContains(t, err, "ecdsa-p256-sha256 algorithm is not allowed")
+			},
+		},
+		{
+			uc: "covered header not present",
+			prepare: func(t *testing.T, req *httpSignatureTestRequest) {
+				t.Helper()
+
+				req.sign(t, sigInput("sig1", `"@method" "@target-uri" "x-event-id"`, `keyid="partner-1"`),
+					httpSigAlgECDSAP256SHA256, keys.ecKey)
+				delete(req.headers, "x-event-id")
+			},
+			assert: func(t *testing.T, err error, _ *subject.Subject) {
+				t.Helper()
+
+				require.ErrorIs(t, err, heimdall.ErrAuthentication)
+				require.ErrorContains(t, err, "failed to create signature base")
+			},
+		},
+		{
+			uc: "tampered request",
+			prepare: func(t *testing.T, req *httpSignatureTestRequest) {
+				t.Helper()
+
+				req.sign(t, sigInput("sig1", `"@method" "@target-uri" "x-event-id"`, `keyid="partner-1"`),
+					httpSigAlgECDSAP256SHA256, keys.ecKey)
+				req.headers["x-event-id"] = "4712"
+			},
+			assert: func(t *testing.T, err error, _ *subject.Subject) {
+				t.Helper()
+
+				require.ErrorIs(t, err, heimdall.ErrAuthentication)
+				require.ErrorContains(t, err, "failed to verify http message signature")
+			},
+		},
+		{
+			uc: "tampered body",
+			prepare: func(t *testing.T, req *httpSignatureTestRequest) {
+				t.Helper()
+
+				req.sign(t, sigInput("sig1", `"@method" "@target-uri" "content-digest"`, `keyid="partner-1"`),
+					httpSigAlgECDSAP256SHA256, keys.ecKey)
+				req.body = []byte(`{"event": "deleted"}`)
+			},
+			assert: func(t *testing.T, err error, _ *subject.Subject) {
+				t.Helper()
+
+				require.ErrorIs(t, err, heimdall.ErrAuthentication)
+				require.ErrorContains(t, err, "failed to verify content digest")
+			},
+		},
+		{
+			uc: "valid signature using public key from the key store",
+			prepare: func(t *testing.T, req *httpSignatureTestRequest) {
+				t.Helper()
+
+				req.sign(t, sigInput("sig1", `"@method" "@target-uri" "content-digest"`, `keyid="partner-1"`),
+					httpSigAlgECDSAP256SHA256, keys.ecKey)
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "partner-1", sub.ID)
+				assert.Equal(t, "partner-1", sub.Attributes["key_id"])
+				assert.Equal(t, httpSigAlgECDSAP256SHA256, sub.Attributes["algorithm"])
+				assert.Equal(t, "sig1", sub.Attributes["label"])
+				assert.Equal(t, []any{"@method", "@target-uri", "content-digest"}, sub.Attributes["components"])
+				assert.NotEmpty(t, sub.Attributes["created"])
+				assert.NotContains(t, sub.Attributes, "expires")
+				assert.NotContains(t, sub.Attributes, "certificate")
+			},
+		},
+		{
+			uc: "valid signature with configured label using certificate from the key store",
+			config: []byte(`
+signature_label: partner
+required_components: [ "@method", "@path", content-digest ]
+subject:
+  id: certificate.subject.common_name
+`),
+			prepare: func(t *testing.T, req *httpSignatureTestRequest) {
+				t.Helper()
+
+				req.sign(t, sigInput("partner", `"@method" "@path" "@authority" "content-digest"`,
+					fmt.Sprintf(`expires=%d;keyid="%s";alg="ed25519"`, time.Now().Add(time.Minute).Unix(), edKeyID)),
+					httpSigAlgEd25519, keys.edKey)
+
+				// the first signature is not the one configured
+				req.headers["signature-input"] = `sig1=("@method");keyid="foo", ` + req.headers["signature-input"]
+				req.headers["signature"] = `sig1=:Zm9v:, ` + req.headers["signature"]
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "partner-2", sub.ID)
+				assert.Equal(t, edKeyID, sub.Attributes["key_id"])
+				assert.Equal(t, httpSigAlgEd25519, sub.Attributes["algorithm"])
+				assert.Equal(t, "partner", sub.Attributes["label"])
+				assert.NotEmpty(t, sub.Attributes["expires"])
+				require.Contains(t, sub.Attributes, "certificate")
+
+				certInfo, ok := sub.Attributes["certificate"].(map[string]any)
+				require.True(t, ok)
+				assert.Equal(t, "CN=partner-2,O=Partner", certInfo["subject"].(map[string]any)["dn"])
+			},
+		},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			// GIVEN
+			conf, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			if conf == nil {
+				conf = map[string]any{}
+			}
+
+			conf["key_store"] = map[string]any{"path": keys.keyStorePath}
+
+			auth, err := newHTTPMessageSignatureAuthenticator("auth1", conf)
+			require.NoError(t, err)
+
+			req := &httpSignatureTestRequest{
+				method: http.MethodPost,
+				url:    "https://heimdall.example.com/webhooks/partner?event=created",
+				headers: map[string]string{
+					"content-type":   "application/json",
+					"content-digest": contentDigest,
+					"x-event-id":     "4711",
+				},
+				body: body,
+			}
+
+			tc.prepare(t, req)
+
+			ctx := mocks.NewContextMock(t)
+			ctx.EXPECT().AppContext().Return(context.Background())
+			ctx.EXPECT().Request().Return(req.create(t)).Maybe()
+
+			// WHEN
+			sub, err := auth.Execute(ctx)
+
+			// THEN
+			tc.assert(t, err, sub)
+		})
+	}
+}
+
+func TestHTTPMessageSignatureAuthenticatorExecuteWithJWKSEndpoint(t *testing.T) {
+	t.Parallel()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	secret := []byte("supersecretsupersecretsupersecret")
+
+	for _, tc := range []struct {
+		uc           string
+		config       []byte
+		cacheEnabled bool
+		serverStatus int
+		prepare      func(t *testing.T, req *httpSignatureTestRequest)
+		assert       func(t *testing.T, err error, sub *subject.Subject, requests int)
+	}{
+		{
+			uc:           "jwks endpoint responds with an error",
+			serverStatus: http.StatusInternalServerError,
+			prepare: func(t *testing.T, req *httpSignatureTestRequest) {
+				t.Helper()
+
+				req.sign(t, fmt.Sprintf(`sig1=("@method" "@target-uri");created=%d;keyid="rsa-key"`,
+					time.Now().Unix()), httpSigAlgRSAPSSSHA512, rsaKey)
+			},
+			assert: func(t *testing.T, err error, _ *subject.Subject, requests int) {
+				t.Helper()
+
+				require.ErrorIs(t, err, heimdall.ErrCommunication)
+				require.ErrorContains(t, err, "unexpected response")
+				assert.Equal(t, 1, requests)
+			},
+		},
+		{
+			uc:           "valid signature using rsa key from the jwks endpoint with cache",
+			cacheEnabled: true,
+			prepare: func(t *testing.T, req *httpSignatureTestRequest) {
+				t.Helper()
+
+				req.sign(t, fmt.Sprintf(`sig1=("@method" "@target-uri");created=%d;keyid="rsa-key"`,
+					time.Now().Unix()), httpSigAlgRSAPSSSHA512, rsaKey)
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject, requests int) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "rsa-key", sub.ID)
+				assert.Equal(t, httpSigAlgRSAPSSSHA512, sub.Attributes["algorithm"])
+				assert.Equal(t, 1, requests)
+			},
+		},
+		{
+			uc:     "valid signature using symmetric key from the jwks endpoint",
+			config: []byte(`allowed_algorithms: [ hmac-sha256 ]`),
+			prepare: func(t *testing.T, req *httpSignatureTestRequest) {
+				t.Helper()
+
+				req.sign(t, fmt.Sprintf(`sig1=("@method" "@target-uri");created=%d;keyid="hmac-key"`,
+					time.Now().Unix()), httpSigAlgHMACSHA256, secret)
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject, requests int) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "hmac-key", sub.ID)
+				assert.Equal(t, httpSigAlgHMACSHA256, sub.Attributes["algorithm"])
+				assert.Equal(t, 1, requests)
+			},
+		},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			// GIVEN
+			var requests int
+
+			srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				requests++
+
+				assert.Equal(t, http.MethodGet, req.Method)
+
+				if tc.serverStatus != 0 {
+					rw.WriteHeader(tc.serverStatus)
+
+					return
+				}
+
+				rawJWKS, err := json.Marshal(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+					{KeyID: "rsa-key", Algorithm: string(jose.PS512), Key: rsaKey},
+					{KeyID: "hmac-key", Algorithm: string(jose.HS256), Key: secret},
+				}})
+				require.NoError(t, err)
+
+				rw.Header().Set("Content-Type", "application/json")
+				_, err = rw.Write(rawJWKS)
+				require.NoError(t, err)
+			}))
+			defer srv.Close()
+
+			conf, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			if conf == nil {
+				conf = map[string]any{}
+			}
+
+			conf["jwks_endpoint"] = map[string]any{"url": srv.URL}
+			if !tc.cacheEnabled {
+				conf["cache_ttl"] = "0s"
+			}
+
+			auth, err := newHTTPMessageSignatureAuthenticator("auth1", conf)
+			require.NoError(t, err)
+
+			req := &httpSignatureTestRequest{
+				method:  http.MethodPost,
+				url:     "https://heimdall.example.com/webhooks/partner",
+				headers: map[string]string{},
+			}
+
+			tc.prepare(t, req)
+
+			cch := memory.New()
+
+			execute := func() (*subject.Subject, error) {
+				ctx := mocks.NewContextMock(t)
+				ctx.EXPECT().AppContext().Return(cache.WithContext(context.Background(), cch))
+				ctx.EXPECT().Request().Return(req.create(t))
+
+				return auth.Execute(ctx)
+			}
+
+			// WHEN
+			sub, err := execute()
+
+			if err == nil && tc.cacheEnabled {
+				// the second request is served from the cache
+				sub, err = execute()
+			}
+
+			// THEN
+			tc.assert(t, err, sub, requests)
+		})
+	}
+}
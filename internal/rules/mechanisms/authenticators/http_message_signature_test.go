@@ -0,0 +1,622 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package authenticators
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"net/url"
+	"testing"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/heimdall/mocks"
+	"github.com/dadrus/heimdall/internal/x/structuredfields"
+)
+
+// the ed25519 test key and the signature of the request from RFC 9421, appendix B.2.6
+const (
+	rfc9421Ed25519PublicKey = `-----BEGIN PUBLIC KEY-----
+MCowBQYDK2VwAyEAJrQLj5P/89iXES9+vFgrIy29clF9CC/oPPsw3c5D0bs=
+-----END PUBLIC KEY-----
+`
+	rfc9421SignatureInput = `sig-b26=("date" "@method" "@path" "@authority" "content-type" "content-length")` +
+		`;created=1618884473;keyid="test-key-ed25519"`
+	rfc9421Signature = `sig-b26=:wqcAqbmYJ2ji2glfAMaRy4gruYYnx2nEFN2HN6jrnDnQCK1u02Gb04v9EDgwUPiu4A0w6vuQv5lIp5WPpBKRCw==:`
+	rfc9421Body      = `{"hello": "world"}`
+	rfc9421Digest    = `sha-512=:WZDPaVn/7XgHaAy8pmojAkGWoRx2UFChF41A2svX+TaPm+AbwAgBWnrIiYllu7BNNyealdVLvRwEmTHWXvJwew==:`
+)
+
+func rfc9421Request(t *testing.T) *heimdall.Request {
+	t.Helper()
+
+	reqf := mocks.NewRequestFunctionsMock(t)
+	reqf.EXPECT().Header("date").Return("Tue, 20 Apr 2021 02:07:55 GMT").Maybe()
+	reqf.EXPECT().Header("content-type").Return("application/json").Maybe()
+	reqf.EXPECT().Header("content-length").Return("18").Maybe()
+	reqf.EXPECT().Header("content-digest").Return(rfc9421Digest).Maybe()
+	reqf.EXPECT().Header("x-empty").Return("").Maybe()
+
+	uri, err := url.Parse("https://example.com/foo?param=Value&Pet=dog&pet=cat&pet=mouse&q=a%20b#frag")
+	require.NoError(t, err)
+
+	return &heimdall.Request{RequestFunctions: reqf, Method: "POST", URL: &heimdall.URL{URL: *uri}}
+}
+
+func signHTTPMessage(t *testing.T, alg string, key any, base []byte) []byte {
+	t.Helper()
+
+	var (
+		signature []byte
+		err       error
+	)
+
+	switch alg {
+	case httpSigAlgRSAPSSSHA512:
+		digest := sha512.Sum512(base)
+		signature, err = rsa.SignPSS(rand.Reader, key.(*rsa.PrivateKey), crypto.SHA512, digest[:],
+			&rsa.PSSOptions{SaltLength: sha512.Size})
+	case httpSigAlgRSAV15SHA256:
+		digest := sha256.Sum256(base)
+		signature, err = rsa.SignPKCS1v15(rand.Reader, key.(*rsa.PrivateKey), crypto.SHA256, digest[:])
+	case httpSigAlgECDSAP256SHA256, httpSigAlgECDSAP384SHA384:
+		privKey := key.(*ecdsa.PrivateKey)
+		size := (privKey.Curve.Params().BitSize + 7) / 8
+
+		var digest []byte
+		if alg == httpSigAlgECDSAP256SHA256 {
+			sum := sha256.Sum256(base)
+			digest = sum[:]
+		} else {
+			sum := sha512.Sum384(base)
+			digest = sum[:]
+		}
+
+		r, s, sigErr := ecdsa.Sign(rand.Reader, privKey, digest)
+		require.NoError(t, sigErr)
+
+		signature = make([]byte, 2*size)
+		r.FillBytes(signature[:size])
+		s.FillBytes(signature[size:])
+	case httpSigAlgEd25519:
+		signature = ed25519.Sign(key.(ed25519.PrivateKey), base)
+	case httpSigAlgHMACSHA256:
+		mac := hmac.New(sha256.New, key.([]byte))
+		mac.Write(base)
+		signature = mac.Sum(nil)
+	}
+
+	require.NoError(t, err)
+
+	return signature
+}
+
+func TestParseSignatureInputs(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc     string
+		value  string
+		assert func(t *testing.T, err error, inputs []*signatureInput)
+	}{
+		{
+			uc:    "multiple signatures",
+			value: `sig1=("@method" "@query-param";name="foo" "content-digest");created=1618884473;keyid="key-1";alg="ed25519", sig2=();expires=1618884480;foo=?0;bar;baz=tok/en`, //nolint:lll
+			assert: func(t *testing.T, err error, inputs []*signatureInput) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.Len(t, inputs, 2)
+
+				assert.Equal(t, "sig1", inputs[0].Label)
+				assert.Equal(t, []componentIdentifier{
+					{Name: "@method"},
+					{Name: "@query-param", Params: structuredfields.Params{{Key: "name", Value: "foo"}}},
+					{Name: "content-digest"},
+				}, inputs[0].Components)
+				assert.Equal(t, structuredfields.Params{
+					{Key: "created", Value: int64(1618884473)},
+					{Key: "keyid", Value: "key-1"},
+					{Key: "alg", Value: "ed25519"},
+				}, inputs[0].Params)
+				assert.Equal(t,
+					`("@method" "@query-param";name="foo" "content-digest");created=1618884473;keyid="key-1";alg="ed25519"`,
+					inputs[0].serialize())
+
+				assert.Equal(t, "sig2", inputs[1].Label)
+				assert.Empty(t, inputs[1].Components)
+				assert.Equal(t, `();expires=1618884480;foo=?0;bar;baz=tok/en`, inputs[1].serialize())
+			},
+		},
+		{
+			uc:    "member is not an inner list",
+			value: `sig1="@method"`,
+			assert: func(t *testing.T, err error, _ []*signatureInput) {
+				t.Helper()
+
+				require.ErrorIs(t, err, structuredfields.ErrMalformed)
+				require.ErrorContains(t, err, "is not an inner list")
+			},
+		},
+		{
+			uc:    "component identifier is not a string",
+			value: `sig1=(method)`,
+			assert: func(t *testing.T, err error, _ []*signatureInput) {
+				t.Helper()
+
+				require.ErrorIs(t, err, structuredfields.ErrMalformed)
+				require.ErrorContains(t, err, "must be strings")
+			},
+		},
+		{
+			uc:    "unterminated inner list",
+			value: `sig1=("@method" "@path"`,
+			assert: func(t *testing.T, err error, _ []*signatureInput) {
+				t.Helper()
+
+				require.ErrorIs(t, err, structuredfields.ErrMalformed)
+				require.ErrorContains(t, err, "unterminated inner list")
+			},
+		},
+		{
+			uc:    "unterminated string",
+			value: `sig1=("@method)`,
+			assert: func(t *testing.T, err error, _ []*signatureInput) {
+				t.Helper()
+
+				require.ErrorIs(t, err, structuredfields.ErrMalformed)
+				require.ErrorContains(t, err, "unterminated string")
+			},
+		},
+		{
+			uc:    "decimal parameter",
+			value: `sig1=("@method");created=1.5`,
+			assert: func(t *testing.T, err error, _ []*signatureInput) {
+				t.Helper()
+
+				require.ErrorIs(t, err, structuredfields.ErrMalformed)
+				require.ErrorContains(t, err, "decimals are not supported")
+			},
+		},
+		{
+			uc:    "items not separated by spaces",
+			value: `sig1=("@method""@path")`,
+			assert: func(t *testing.T, err error, _ []*signatureInput) {
+				t.Helper()
+
+				require.ErrorIs(t, err, structuredfields.ErrMalformed)
+				require.ErrorContains(t, err, "unexpected character in inner list")
+			},
+		},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			// WHEN
+			inputs, err := parseSignatureInputs(tc.value)
+
+			// THEN
+			tc.assert(t, err, inputs)
+		})
+	}
+}
+
+func TestParseByteSequenceDictionary(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc     string
+		value  string
+		assert func(t *testing.T, err error, result map[string][]byte)
+	}{
+		{
+			uc:    "multiple members",
+			value: "sha-256=:Zm9v:, sha-512=:YmFy:;foo=1",
+			assert: func(t *testing.T, err error, result map[string][]byte) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, map[string][]byte{"sha-256": []byte("foo"), "sha-512": []byte("bar")}, result)
+			},
+		},
+		{
+			uc:    "member which is not a byte sequence",
+			value: `sig1="foo"`,
+			assert: func(t *testing.T, err error, _ map[string][]byte) {
+				t.Helper()
+
+				require.ErrorIs(t, err, structuredfields.ErrMalformed)
+				require.ErrorContains(t, err, "not a byte sequence")
+			},
+		},
+		{
+			uc:    "member which is an inner list",
+			value: `sig1=(:Zm9v:)`,
+			assert: func(t *testing.T, err error, _ map[string][]byte) {
+				t.Helper()
+
+				require.ErrorIs(t, err, structuredfields.ErrMalformed)
+				require.ErrorContains(t, err, "not a byte sequence")
+			},
+		},
+		{
+			uc:    "malformed dictionary",
+			value: "sig1=:Zm9v",
+			assert: func(t *testing.T, err error, _ map[string][]byte) {
+				t.Helper()
+
+				require.ErrorIs(t, err, structuredfields.ErrMalformed)
+			},
+		},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			// WHEN
+			result, err := parseByteSequenceDictionary(tc.value)
+
+			// THEN
+			tc.assert(t, err, result)
+		})
+	}
+}
+
+func TestSignatureInputParams(t *testing.T) {
+	t.Parallel()
+
+	inputs, err := parseSignatureInputs(`sig1=();created=1618884473;keyid="key-1";alg=ed25519;expires="soon"`)
+	require.NoError(t, err)
+
+	input := inputs[0]
+
+	keyID, err := input.stringParam("keyid")
+	require.NoError(t, err)
+	assert.Equal(t, "key-1", keyID)
+
+	nonce, err := input.stringParam("nonce")
+	require.NoError(t, err)
+	assert.Empty(t, nonce)
+
+	_, err = input.stringParam("alg")
+	require.ErrorContains(t, err, "'alg' parameter must be a string")
+
+	created, present, err := input.intParam("created")
+	require.NoError(t, err)
+	assert.True(t, present)
+	assert.Equal(t, int64(1618884473), created)
+
+	_, present, err = input.intParam("tag")
+	require.NoError(t, err)
+	assert.False(t, present)
+
+	_, _, err = input.intParam("expires")
+	require.ErrorContains(t, err, "'expires' parameter must be an integer")
+}
+
+func TestCreateSignatureBase(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc     string
+		input  string
+		assert func(t *testing.T, err error, base string)
+	}{
+		{
+			uc:    "signature from RFC 9421, appendix B.2.6",
+			input: rfc9421SignatureInput,
+			assert: func(t *testing.T, err error, base string) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, `"date": Tue, 20 Apr 2021 02:07:55 GMT
+"@method": POST
+"@path": /foo
+"@authority": example.com
+"content-type": application/json
+"content-length": 18
+"@signature-params": ("date" "@method" "@path" "@authority" "content-type" "content-length");created=1618884473;keyid="test-key-ed25519"`, base) //nolint:lll
+			},
+		},
+		{
+			uc:    "all supported derived components",
+			input: `sig1=("@target-uri" "@scheme" "@request-target" "@query" "@query-param";name="q" "@query-param";name="Pet")`,
+			assert: func(t *testing.T, err error, base string) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, `"@target-uri": https://example.com/foo?param=Value&Pet=dog&pet=cat&pet=mouse&q=a%20b
+"@scheme": https
+"@request-target": /foo?param=Value&Pet=dog&pet=cat&pet=mouse&q=a%20b
+"@query": ?param=Value&Pet=dog&pet=cat&pet=mouse&q=a%20b
+"@query-param";name="q": a%20b
+"@query-param";name="Pet": dog
+"@signature-params": ("@target-uri" "@scheme" "@request-target" "@query" "@query-param";name="q" "@query-param";name="Pet")`, base) //nolint:lll
+			},
+		},
+		{
+			uc:    "component covered multiple times",
+			input: `sig1=("@method" "@method")`,
+			assert: func(t *testing.T, err error, _ string) {
+				t.Helper()
+
+				require.ErrorIs(t, err, errHTTPSignatureBase)
+				require.ErrorContains(t, err, "covered multiple times")
+			},
+		},
+		{
+			uc:    "covered field not present",
+			input: `sig1=("x-empty")`,
+			assert: func(t *testing.T, err error, _ string) {
+				t.Helper()
+
+				require.ErrorIs(t, err, errHTTPSignatureBase)
+				require.ErrorContains(t, err, "is not present")
+			},
+		},
+		{
+			uc:    "covered field not lowercased",
+			input: `sig1=("Content-Type")`,
+			assert: func(t *testing.T, err error, _ string) {
+				t.Helper()
+
+				require.ErrorIs(t, err, errHTTPSignatureBase)
+				require.ErrorContains(t, err, "not lowercased")
+			},
+		},
+		{
+			uc:    "unsupported derived component",
+			input: `sig1=("@status")`,
+			assert: func(t *testing.T, err error, _ string) {
+				t.Helper()
+
+				require.ErrorIs(t, err, errHTTPSignatureBase)
+				require.ErrorContains(t, err, "unsupported derived component")
+			},
+		},
+		{
+			uc:    "component parameters",
+			input: `sig1=("content-type";sf)`,
+			assert: func(t *testing.T, err error, _ string) {
+				t.Helper()
+
+				require.ErrorIs(t, err, errHTTPSignatureBase)
+				require.ErrorContains(t, err, "are not supported")
+			},
+		},
+		{
+			uc:    "query param without name",
+			input: `sig1=("@query-param")`,
+			assert: func(t *testing.T, err error, _ string) {
+				t.Helper()
+
+				require.ErrorIs(t, err, errHTTPSignatureBase)
+				require.ErrorContains(t, err, "requires exactly the name parameter")
+			},
+		},
+		{
+			uc:    "query param not unique",
+			input: `sig1=("@query-param";name="pet")`,
+			assert: func(t *testing.T, err error, _ string) {
+				t.Helper()
+
+				require.ErrorIs(t, err, errHTTPSignatureBase)
+				require.ErrorContains(t, err, "not present or not unique")
+			},
+		},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			inputs, err := parseSignatureInputs(tc.input)
+			require.NoError(t, err)
+
+			// WHEN
+			base, err := createSignatureBase(rfc9421Request(t), inputs[0])
+
+			// THEN
+			tc.assert(t, err, string(base))
+		})
+	}
+}
+
+func TestNormalizedAuthority(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uri      string
+		expected string
+	}{
+		{uri: "https://Example.COM/foo", expected: "example.com"},
+		{uri: "https://example.com:443/foo", expected: "example.com"},
+		{uri: "http://example.com:80/foo", expected: "example.com"},
+		{uri: "http://example.com:443/foo", expected: "example.com:443"},
+		{uri: "https://example.com:8443/foo", expected: "example.com:8443"},
+		{uri: "https://[::1]:443/foo", expected: "[::1]"},
+		{uri: "https://[::1]:8443/foo", expected: "[::1]:8443"},
+	} {
+		t.Run(tc.uri, func(t *testing.T) {
+			uri, err := url.Parse(tc.uri)
+			require.NoError(t, err)
+
+			// WHEN
+			authority := normalizedAuthority(uri)
+
+			// THEN
+			assert.Equal(t, tc.expected, authority)
+		})
+	}
+}
+
+func TestHTTPSignatureAlgorithm(t *testing.T) {
+	t.Parallel()
+
+	ecP256, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	ecP521, err := ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	require.NoError(t, err)
+
+	edPub, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		uc        string
+		requested string
+		key       *jose.JSONWebKey
+		expected  string
+		errMsg    string
+	}{
+		{uc: "derived from ecdsa key", key: &jose.JSONWebKey{Key: &ecP256.PublicKey}, expected: httpSigAlgECDSAP256SHA256},
+		{uc: "derived from ed25519 key", key: &jose.JSONWebKey{Key: edPub}, expected: httpSigAlgEd25519},
+		{uc: "derived from symmetric key", key: &jose.JSONWebKey{Key: []byte("foo")}, expected: httpSigAlgHMACSHA256},
+		{uc: "derived from key algorithm", key: &jose.JSONWebKey{Key: &rsaKey.PublicKey, Algorithm: "PS512"}, expected: httpSigAlgRSAPSSSHA512},
+		{uc: "requested for rsa key", requested: httpSigAlgRSAV15SHA256, key: &jose.JSONWebKey{Key: &rsaKey.PublicKey}, expected: httpSigAlgRSAV15SHA256},
+		{uc: "requested matches key", requested: httpSigAlgEd25519, key: &jose.JSONWebKey{Key: edPub}, expected: httpSigAlgEd25519},
+		{uc: "not derivable from rsa key", key: &jose.JSONWebKey{Key: &rsaKey.PublicKey}, errMsg: "can neither be derived"},
+		{uc: "not derivable from unsupported curve", key: &jose.JSONWebKey{Key: &ecP521.PublicKey}, errMsg: "can neither be derived"},
+		{uc: "unsupported key algorithm", key: &jose.JSONWebKey{Key: &rsaKey.PublicKey, Algorithm: "RS512"}, errMsg: "RS512 of the key is not supported"},
+		{uc: "requested does not match key", requested: httpSigAlgRSAPSSSHA512, key: &jose.JSONWebKey{Key: edPub}, errMsg: "does not match"},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			// WHEN
+			alg, err := httpSignatureAlgorithm(tc.requested, tc.key)
+
+			// THEN
+			if len(tc.errMsg) != 0 {
+				require.ErrorIs(t, err, errHTTPSignatureAlgorithm)
+				require.ErrorContains(t, err, tc.errMsg)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.expected, alg)
+			}
+		})
+	}
+}
+
+func TestVerifyHTTPSignature(t *testing.T) {
+	t.Parallel()
+
+	block, _ := pem.Decode([]byte(rfc9421Ed25519PublicKey))
+	rfcKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	require.NoError(t, err)
+
+	rfcInputs, err := parseSignatureInputs(rfc9421SignatureInput)
+	require.NoError(t, err)
+
+	rfcSignatures, err := parseByteSequenceDictionary(rfc9421Signature)
+	require.NoError(t, err)
+
+	rfcBase, err := createSignatureBase(rfc9421Request(t), rfcInputs[0])
+	require.NoError(t, err)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	ecP256, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	ecP384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+
+	edPub, edPriv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	secret := []byte("supersecretsupersecretsupersecret")
+	base := []byte(`"@method": POST`)
+
+	for _, tc := range []struct {
+		uc        string
+		alg       string
+		key       any
+		base      []byte
+		signature []byte
+		err       error
+	}{
+		{uc: "RFC 9421 ed25519 example", alg: httpSigAlgEd25519, key: rfcKey, base: rfcBase, signature: rfcSignatures["sig-b26"]},
+		{uc: "RFC 9421 ed25519 example with modified base", alg: httpSigAlgEd25519, key: rfcKey, base: append(rfcBase, ' '), signature: rfcSignatures["sig-b26"], err: errHTTPSignatureInvalid},
+		{uc: "rsa-pss-sha512", alg: httpSigAlgRSAPSSSHA512, key: &rsaKey.PublicKey, base: base, signature: signHTTPMessage(t, httpSigAlgRSAPSSSHA512, rsaKey, base)},
+		{uc: "rsa-v1_5-sha256", alg: httpSigAlgRSAV15SHA256, key: &rsaKey.PublicKey, base: base, signature: signHTTPMessage(t, httpSigAlgRSAV15SHA256, rsaKey, base)},
+		{uc: "rsa-v1_5-sha256 with rsa-pss signature", alg: httpSigAlgRSAV15SHA256, key: &rsaKey.PublicKey, base: base, signature: signHTTPMessage(t, httpSigAlgRSAPSSSHA512, rsaKey, base), err: errHTTPSignatureInvalid},
+		{uc: "ecdsa-p256-sha256", alg: httpSigAlgECDSAP256SHA256, key: &ecP256.PublicKey, base: base, signature: signHTTPMessage(t, httpSigAlgECDSAP256SHA256, ecP256, base)},
+		{uc: "ecdsa-p384-sha384", alg: httpSigAlgECDSAP384SHA384, key: &ecP384.PublicKey, base: base, signature: signHTTPMessage(t, httpSigAlgECDSAP384SHA384, ecP384, base)},
+		{uc: "ecdsa-p384-sha384 with truncated signature", alg: httpSigAlgECDSAP384SHA384, key: &ecP384.PublicKey, base: base, signature: signHTTPMessage(t, httpSigAlgECDSAP384SHA384, ecP384, base)[1:], err: errHTTPSignatureInvalid},
+		{uc: "ecdsa-p384-sha384 with p256 key", alg: httpSigAlgECDSAP384SHA384, key: &ecP256.PublicKey, base: base, signature: signHTTPMessage(t, httpSigAlgECDSAP256SHA256, ecP256, base), err: errHTTPSignatureAlgorithm},
+		{uc: "ed25519", alg: httpSigAlgEd25519, key: edPub, base: base, signature: signHTTPMessage(t, httpSigAlgEd25519, edPriv, base)},
+		{uc: "ed25519 with rsa key", alg: httpSigAlgEd25519, key: &rsaKey.PublicKey, base: base, signature: signHTTPMessage(t, httpSigAlgEd25519, edPriv, base), err: errHTTPSignatureAlgorithm},
+		{uc: "hmac-sha256", alg: httpSigAlgHMACSHA256, key: secret, base: base, signature: signHTTPMessage(t, httpSigAlgHMACSHA256, secret, base)},
+		{uc: "hmac-sha256 with wrong secret", alg: httpSigAlgHMACSHA256, key: []byte("foo"), base: base, signature: signHTTPMessage(t, httpSigAlgHMACSHA256, secret, base), err: errHTTPSignatureInvalid},
+		{uc: "rsa-pss-sha512 with ecdsa key", alg: httpSigAlgRSAPSSSHA512, key: &ecP256.PublicKey, base: base, signature: []byte("foo"), err: errHTTPSignatureAlgorithm},
+		{uc: "unsupported algorithm", alg: "rsa-pss-sha256", key: &rsaKey.PublicKey, base: base, signature: []byte("foo"), err: errHTTPSignatureAlgorithm},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			// WHEN
+			err := verifyHTTPSignature(tc.alg, tc.key, tc.base, tc.signature)
+
+			// THEN
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestVerifyContentDigest(t *testing.T) {
+	t.Parallel()
+
+	sha256Sum := sha256.Sum256([]byte(rfc9421Body))
+	sha256Digest := "sha-256=:" + base64.StdEncoding.EncodeToString(sha256Sum[:]) + ":"
+
+	for _, tc := range []struct {
+		uc    string
+		value string
+		body  string
+		err   error
+	}{
+		{uc: "sha-512 digest from RFC 9421", value: rfc9421Digest, body: rfc9421Body},
+		{uc: "sha-256 and sha-512 digests", value: sha256Digest + ", " + rfc9421Digest, body: rfc9421Body},
+		{uc: "unsupported algorithm ignored", value: "md5=:Zm9v:, " + sha256Digest, body: rfc9421Body},
+		{uc: "modified body", value: rfc9421Digest, body: `{"hello": "world!"}`, err: errContentDigestMismatch},
+		{uc: "one of the digests does not match", value: sha256Digest + ", sha-512=:Zm9v:", body: rfc9421Body, err: errContentDigestMismatch},
+		{uc: "only unsupported algorithms", value: "md5=:Zm9v:", body: rfc9421Body, err: errContentDigestUnsupported},
+		{uc: "no digest", body: rfc9421Body, err: errContentDigestUnsupported},
+		{uc: "malformed digest", value: "sha-256=foo", body: rfc9421Body, err: structuredfields.ErrMalformed},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			// WHEN
+			err := verifyContentDigest(tc.value, []byte(tc.body))
+
+			// THEN
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package authenticators
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+
+	"github.com/dadrus/heimdall/internal/config"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/keystore"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+// headerCompression is the JWE header parameter indicating compression of the plaintext.
+const headerCompression jose.HeaderKey = "zip"
+
+type JWEDecryptionConfig struct {
+	KeyStore                    config.KeyStore `mapstructure:"key_store"`
+	KeyManagementAlgorithms     []string        `mapstructure:"key_management_algorithms"`
+	ContentEncryptionAlgorithms []string        `mapstructure:"content_encryption_algorithms"`
+}
+
+func defaultKeyManagementAlgorithms() []string {
+	// RSA PKCS v1.5 is not allowed by intention
+	return []string{
+		// RSA-OAEP
+		string(jose.RSA_OAEP), string(jose.RSA_OAEP_256),
+		// ECDH-ES
+		string(jose.ECDH_ES), string(jose.ECDH_ES_A128KW), string(jose.ECDH_ES_A192KW), string(jose.ECDH_ES_A256KW),
+	}
+}
+
+func defaultContentEncryptionAlgorithms() []string {
+	return []string{
+		// AES GCM
+		string(jose.A128GCM), string(jose.A192GCM), string(jose.A256GCM),
+		// AES CBC with HMAC SHA-2
+		string(jose.A128CBC_HS256), string(jose.A192CBC_HS384), string(jose.A256CBC_HS512),
+	}
+}
+
+// jweDecrypter decrypts nested (signed, then encrypted) JWTs using the keys from the
+// configured key store.
+type jweDecrypter struct {
+	ks            keystore.KeyStore
+	keyAlgorithms []string
+	encAlgorithms []string
+}
+
+func newJWEDecrypter(conf *JWEDecryptionConfig) (*jweDecrypter, error) {
+	if conf == nil {
+		return nil, nil //nolint:nilnil
+	}
+
+	if len(conf.KeyStore.Path) == 0 {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"'path' of the decryption key store is required")
+	}
+
+	ks, err := keystore.NewKeyStoreFromPEMFile(conf.KeyStore.Path, conf.KeyStore.Password)
+	if err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"failed loading decryption key store").CausedBy(err)
+	}
+
+	if len(conf.KeyManagementAlgorithms) == 0 {
+		conf.KeyManagementAlgorithms = defaultKeyManagementAlgorithms()
+	}
+
+	if len(conf.ContentEncryptionAlgorithms) == 0 {
+		conf.ContentEncryptionAlgorithms = defaultContentEncryptionAlgorithms()
+	}
+
+	return &jweDecrypter{
+		ks:            ks,
+		keyAlgorithms: conf.KeyManagementAlgorithms,
+		encAlgorithms: conf.ContentEncryptionAlgorithms,
+	}, nil
+}
+
+// isEncrypted reports whether the given token uses the JWE compact serialization,
+// which, unlike the JWS one, consists of five parts.
+func isEncrypted(rawToken string) bool {
+	return strings.Count(rawToken, ".") == 4 //nolint:gomnd
+}
+
+func (d *jweDecrypter) decrypt(rawToken string) (*jwt.JSONWebToken, error) {
+	nested, err := jwt.ParseSignedAndEncrypted(rawToken)
+	if err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrArgument, "failed to parse encrypted JWT").
+			CausedBy(err)
+	}
+
+	header := nested.Headers[0]
+	enc, _ := header.ExtraHeaders["enc"].(string)
+
+	if !slices.Contains(d.keyAlgorithms, header.Algorithm) {
+		return nil, errorchain.NewWithMessagef(heimdall.ErrArgument,
+			"%s key management algorithm is not allowed", header.Algorithm)
+	}
+
+	if !slices.Contains(d.encAlgorithms, enc) {
+		return nil, errorchain.NewWithMessagef(heimdall.ErrArgument,
+			"%s content encryption algorithm is not allowed", enc)
+	}
+
+	// compressed content is decompressed before the signature of the nested JWT can be verified,
+	// which would allow anyone knowing the public key to send decompression bombs
+	if _, compressed := header.ExtraHeaders[headerCompression]; compressed {
+		return nil, errorchain.NewWithMessage(heimdall.ErrArgument, "compressed JWTs are not supported")
+	}
+
+	entries := d.ks.Entries()
+
+	if len(header.KeyID) != 0 {
+		entry, err := d.ks.GetKey(header.KeyID)
+		if err != nil {
+			return nil, errorchain.NewWithMessage(heimdall.ErrArgument, "no matching decryption key").
+				CausedBy(err)
+		}
+
+		entries = []*keystore.Entry{entry}
+	}
+
+	for _, entry := range entries {
+		if token, err := nested.Decrypt(entry.PrivateKey); err == nil {
+			return token, nil
+		}
+	}
+
+	return nil, errorchain.NewWithMessage(heimdall.ErrArgument,
+		"none of the available keys could be used to decrypt the JWT")
+}
@@ -0,0 +1,278 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package authenticators
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"os"
+	"testing"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/config"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/keystore"
+	"github.com/dadrus/heimdall/internal/x/pkix/pemx"
+)
+
+func encryptJWT(
+	t *testing.T, rawJWT string, key crypto.PublicKey, kid string,
+	alg jose.KeyAlgorithm, enc jose.ContentEncryption,
+) string {
+	t.Helper()
+
+	opts := (&jose.EncrypterOptions{}).WithContentType("JWT")
+
+	encrypter, err := jose.NewEncrypter(enc, jose.Recipient{Algorithm: alg, Key: key, KeyID: kid}, opts)
+	require.NoError(t, err)
+
+	jwe, err := encrypter.Encrypt([]byte(rawJWT))
+	require.NoError(t, err)
+
+	token, err := jwe.CompactSerialize()
+	require.NoError(t, err)
+
+	return token
+}
+
+func TestNewJWEDecrypter(t *testing.T) {
+	t.Parallel()
+
+	privKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	pemBytes, err := pemx.BuildPEM(pemx.WithRSAPrivateKey(privKey))
+	require.NoError(t, err)
+
+	keyStorePath := t.TempDir() + "/keys.pem"
+	require.NoError(t, os.WriteFile(keyStorePath, pemBytes, 0o600))
+
+	for _, tc := range []struct {
+		uc     string
+		conf   *JWEDecryptionConfig
+		assert func(t *testing.T, err error, decrypter *jweDecrypter)
+	}{
+		{
+			uc: "without configuration",
+			assert: func(t *testing.T, err error, decrypter *jweDecrypter) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Nil(t, decrypter)
+			},
+		},
+		{
+			uc:   "without key store path",
+			conf: &JWEDecryptionConfig{},
+			assert: func(t *testing.T, err error, _ *jweDecrypter) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "'path'")
+			},
+		},
+		{
+			uc:   "with not existing key store",
+			conf: &JWEDecryptionConfig{KeyStore: config.KeyStore{Path: "/no/such/file.pem"}},
+			assert: func(t *testing.T, err error, _ *jweDecrypter) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed loading decryption key store")
+			},
+		},
+		{
+			uc:   "with defaults",
+			conf: &JWEDecryptionConfig{KeyStore: config.KeyStore{Path: keyStorePath}},
+			assert: func(t *testing.T, err error, decrypter *jweDecrypter) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, decrypter)
+				assert.Len(t, decrypter.ks.Entries(), 1)
+				assert.ElementsMatch(t, defaultKeyManagementAlgorithms(), decrypter.keyAlgorithms)
+				assert.ElementsMatch(t, defaultContentEncryptionAlgorithms(), decrypter.encAlgorithms)
+			},
+		},
+		{
+			uc: "with configured algorithms",
+			conf: &JWEDecryptionConfig{
+				KeyStore:                    config.KeyStore{Path: keyStorePath},
+				KeyManagementAlgorithms:     []string{"RSA-OAEP-256"},
+				ContentEncryptionAlgorithms: []string{"A256GCM"},
+			},
+			assert: func(t *testing.T, err error, decrypter *jweDecrypter) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, decrypter)
+				assert.Equal(t, []string{"RSA-OAEP-256"}, decrypter.keyAlgorithms)
+				assert.Equal(t, []string{"A256GCM"}, decrypter.encAlgorithms)
+			},
+		},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			// WHEN
+			decrypter, err := newJWEDecrypter(tc.conf)
+
+			// THEN
+			tc.assert(t, err, decrypter)
+		})
+	}
+}
+
+func TestJWEDecrypterDecrypt(t *testing.T) {
+	t.Parallel()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	pemBytes, err := pemx.BuildPEM(
+		pemx.WithRSAPrivateKey(rsaKey, pemx.WithHeader("X-Key-ID", "rsa")),
+		pemx.WithECDSAPrivateKey(ecKey, pemx.WithHeader("X-Key-ID", "ec")),
+	)
+	require.NoError(t, err)
+
+	ks, err := keystore.NewKeyStoreFromPEMBytes(pemBytes, "")
+	require.NoError(t, err)
+
+	decrypter := &jweDecrypter{
+		ks:            ks,
+		keyAlgorithms: defaultKeyManagementAlgorithms(),
+		encAlgorithms: []string{string(jose.A256GCM)},
+	}
+
+	signingKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.ES384, Key: signingKey},
+		(&jose.SignerOptions{}).WithType("JWT"))
+	require.NoError(t, err)
+
+	jws, err := signer.Sign([]byte(`{"sub": "foo"}`))
+	require.NoError(t, err)
+
+	rawJWT, err := jws.CompactSerialize()
+	require.NoError(t, err)
+
+	plainEncrypter, err := jose.NewEncrypter(jose.A256GCM,
+		jose.Recipient{Algorithm: jose.RSA_OAEP_256, Key: &rsaKey.PublicKey}, nil)
+	require.NoError(t, err)
+
+	plainJWE, err := plainEncrypter.Encrypt([]byte(rawJWT))
+	require.NoError(t, err)
+
+	notNestedJWT, err := plainJWE.CompactSerialize()
+	require.NoError(t, err)
+
+	compressingEncrypter, err := jose.NewEncrypter(jose.A256GCM,
+		jose.Recipient{Algorithm: jose.RSA_OAEP_256, Key: &rsaKey.PublicKey, KeyID: "rsa"},
+		(&jose.EncrypterOptions{Compression: jose.DEFLATE}).WithContentType("JWT"))
+	require.NoError(t, err)
+
+	compressedJWE, err := compressingEncrypter.Encrypt([]byte(rawJWT))
+	require.NoError(t, err)
+
+	compressedJWT, err := compressedJWE.CompactSerialize()
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		uc          string
+		token       string
+		errContains string
+	}{
+		{
+			uc:          "malformed token",
+			token:       "a.b.c.d.e",
+			errContains: "failed to parse encrypted JWT",
+		},
+		{
+			uc:          "encrypted, but not nested token",
+			token:       notNestedJWT,
+			errContains: "failed to parse encrypted JWT",
+		},
+		{
+			uc:          "not allowed key management algorithm",
+			token:       encryptJWT(t, rawJWT, &rsaKey.PublicKey, "rsa", jose.RSA1_5, jose.A256GCM),
+			errContains: "RSA1_5 key management algorithm is not allowed",
+		},
+		{
+			uc:          "not allowed content encryption algorithm",
+			token:       encryptJWT(t, rawJWT, &rsaKey.PublicKey, "rsa", jose.RSA_OAEP, jose.A128GCM),
+			errContains: "A128GCM content encryption algorithm is not allowed",
+		},
+		{
+			uc:          "compressed token",
+			token:       compressedJWT,
+			errContains: "compressed JWTs are not supported",
+		},
+		{
+			uc:          "unknown key id",
+			token:       encryptJWT(t, rawJWT, &rsaKey.PublicKey, "foo", jose.RSA_OAEP, jose.A256GCM),
+			errContains: "no matching decryption key",
+		},
+		{
+			uc:          "encrypted for another key",
+			token:       encryptJWT(t, rawJWT, &otherKey.PublicKey, "", jose.RSA_OAEP, jose.A256GCM),
+			errContains: "none of the available keys",
+		},
+		{
+			uc:    "encrypted with referenced rsa key",
+			token: encryptJWT(t, rawJWT, &rsaKey.PublicKey, "rsa", jose.RSA_OAEP_256, jose.A256GCM),
+		},
+		{
+			uc:    "encrypted with not referenced ec key",
+			token: encryptJWT(t, rawJWT, &ecKey.PublicKey, "", jose.ECDH_ES_A256KW, jose.A256GCM),
+		},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			require.True(t, isEncrypted(tc.token))
+
+			// WHEN
+			token, err := decrypter.decrypt(tc.token)
+
+			// THEN
+			if len(tc.errContains) != 0 {
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrArgument)
+				assert.Contains(t, err.Error(), tc.errContains)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			claims := map[string]any{}
+			require.NoError(t, token.Claims(&signingKey.PublicKey, &claims))
+			assert.Equal(t, "foo", claims["sub"])
+		})
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package authenticators
+
+import (
+	"github.com/go-jose/go-jose/v3"
+)
+
+// keySet is the cached representation of a JWKS. It holds the keys, which passed the
+// validation, and the reasons, the remaining keys have been rejected for. The whole key
+// set is cached, so that refreshing it makes the keys added to, respectively removes the
+// keys removed from the JWKS by the issuer.
+type keySet struct {
+	Keys        []jose.JSONWebKey `json:"keys"`
+	InvalidKeys map[string]string `json:"invalid_keys,omitempty"`
+}
+
+func (ks *keySet) key(keyID string) []jose.JSONWebKey {
+	var keys []jose.JSONWebKey
+
+	for _, key := range ks.Keys {
+		if key.KeyID == keyID {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
+
+func (ks *keySet) contains(keyID string) bool {
+	_, invalid := ks.InvalidKeys[keyID]
+
+	return invalid || len(ks.key(keyID)) != 0
+}
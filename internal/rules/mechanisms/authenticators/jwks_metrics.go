@@ -0,0 +1,87 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package authenticators
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+
+	"github.com/dadrus/heimdall/internal/x"
+	"github.com/dadrus/heimdall/version"
+)
+
+const (
+	jwksInstrumentationName = "github.com/dadrus/heimdall/internal/rules/mechanisms/authenticators"
+
+	authenticatorIDAttrKey = attribute.Key("authenticator_id")
+	jwksResultAttrKey      = attribute.Key("result")
+
+	kidMissResolved    = "resolved"
+	kidMissUnresolved  = "unresolved"
+	kidMissRateLimited = "rate_limited"
+)
+
+// jwksMetrics records the fetches of key sets from JWKS endpoints and the usage of
+// key ids, which were not present in the retrieved key sets.
+type jwksMetrics struct {
+	fetches   metric.Int64Counter
+	kidMisses metric.Int64Counter
+}
+
+func newJWKSMetrics(provider metric.MeterProvider) *jwksMetrics {
+	meter := provider.Meter(jwksInstrumentationName, metric.WithInstrumentationVersion(version.Version))
+	noopMeter := noop.NewMeterProvider().Meter(jwksInstrumentationName)
+
+	fetches, err := meter.Int64Counter("jwks.fetches",
+		metric.WithDescription("Number of key set fetches from JWKS endpoints"))
+	if err != nil {
+		fetches, _ = noopMeter.Int64Counter("jwks.fetches")
+	}
+
+	kidMisses, err := meter.Int64Counter("jwks.kid_misses",
+		metric.WithDescription("Number of JWTs referencing a key id not present in the retrieved key set"))
+	if err != nil {
+		kidMisses, _ = noopMeter.Int64Counter("jwks.kid_misses")
+	}
+
+	return &jwksMetrics{fetches: fetches, kidMisses: kidMisses}
+}
+
+func (m *jwksMetrics) recordFetch(ctx context.Context, authenticatorID string, err error) {
+	if m == nil {
+		return
+	}
+
+	m.fetches.Add(ctx, 1, metric.WithAttributes(
+		authenticatorIDAttrKey.String(authenticatorID),
+		jwksResultAttrKey.String(x.IfThenElse(err == nil, "success", "failure")),
+	))
+}
+
+func (m *jwksMetrics) recordKidMiss(ctx context.Context, authenticatorID, result string) {
+	if m == nil {
+		return
+	}
+
+	m.kidMisses.Add(ctx, 1, metric.WithAttributes(
+		authenticatorIDAttrKey.String(authenticatorID),
+		jwksResultAttrKey.String(result),
+	))
+}
@@ -0,0 +1,64 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package authenticators
+
+import (
+	"sync"
+	"time"
+)
+
+// jwksRefetchLimiter limits the refetches of key sets, which are triggered by JWTs
+// referencing a key id not present in the key set retrieved so far. Without it, every
+// JWT with a made up key id would result in a request to the JWKS endpoint.
+type jwksRefetchLimiter struct {
+	interval time.Duration
+
+	mut         sync.Mutex
+	lastRefetch map[string]time.Time
+}
+
+func newJWKSRefetchLimiter(interval time.Duration) *jwksRefetchLimiter {
+	return &jwksRefetchLimiter{interval: interval, lastRefetch: make(map[string]time.Time)}
+}
+
+// allow reports whether the key set identified by the given key can be refetched now.
+// If so, the refetch is accounted for.
+func (l *jwksRefetchLimiter) allow(keySetID string) bool {
+	if l == nil || l.interval <= 0 {
+		return false
+	}
+
+	l.mut.Lock()
+	defer l.mut.Unlock()
+
+	now := time.Now()
+
+	if last, ok := l.lastRefetch[keySetID]; ok && now.Sub(last) < l.interval {
+		return false
+	}
+
+	// forget about key sets, which can be refetched anyway
+	for id, last := range l.lastRefetch {
+		if now.Sub(last) >= l.interval {
+			delete(l.lastRefetch, id)
+		}
+	}
+
+	l.lastRefetch[keySetID] = now
+
+	return true
+}
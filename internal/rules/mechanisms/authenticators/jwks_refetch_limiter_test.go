@@ -0,0 +1,88 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package authenticators
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJWKSRefetchLimiterAllow(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc     string
+		assert func(t *testing.T)
+	}{
+		{
+			uc: "nil limiter",
+			assert: func(t *testing.T) {
+				t.Helper()
+
+				var limiter *jwksRefetchLimiter
+
+				assert.False(t, limiter.allow("foo"))
+			},
+		},
+		{
+			uc: "disabled limiter",
+			assert: func(t *testing.T) {
+				t.Helper()
+
+				limiter := newJWKSRefetchLimiter(0)
+
+				assert.False(t, limiter.allow("foo"))
+			},
+		},
+		{
+			uc: "refetches are limited per key set",
+			assert: func(t *testing.T) {
+				t.Helper()
+
+				limiter := newJWKSRefetchLimiter(time.Hour)
+
+				assert.True(t, limiter.allow("foo"))
+				assert.False(t, limiter.allow("foo"))
+				assert.True(t, limiter.allow("bar"))
+				assert.False(t, limiter.allow("bar"))
+			},
+		},
+		{
+			uc: "refetch is allowed again after the interval",
+			assert: func(t *testing.T) {
+				t.Helper()
+
+				limiter := newJWKSRefetchLimiter(50 * time.Millisecond)
+
+				assert.True(t, limiter.allow("foo"))
+				assert.False(t, limiter.allow("foo"))
+
+				time.Sleep(60 * time.Millisecond)
+
+				assert.True(t, limiter.allow("bar"))
+				assert.NotContains(t, limiter.lastRefetch, "foo")
+				assert.True(t, limiter.allow("foo"))
+			},
+		},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			tc.assert(t)
+		})
+	}
+}
@@ -31,8 +31,11 @@ import (
 	"github.com/go-jose/go-jose/v3/jwt"
 	"github.com/goccy/go-json"
 	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel"
 
+	"github.com/dadrus/heimdall/internal/backchannel"
 	"github.com/dadrus/heimdall/internal/cache"
+	"github.com/dadrus/heimdall/internal/cache/encoding"
 	"github.com/dadrus/heimdall/internal/heimdall"
 	"github.com/dadrus/heimdall/internal/rules/endpoint"
 	"github.com/dadrus/heimdall/internal/rules/mechanisms/authenticators/extractors"
@@ -46,7 +49,10 @@ import (
 	"github.com/dadrus/heimdall/internal/x/stringx"
 )
 
-const defaultJWTAuthenticatorTTL = 10 * time.Minute
+const (
+	defaultJWTAuthenticatorTTL = 10 * time.Minute
+	defaultJWKSRefetchInterval = 1 * time.Minute
+)
 
 // by intention. Used only during application bootstrap
 //
@@ -62,6 +68,10 @@ func init() {
 
 			return true, auth, err
 		})
+
+	encoding.RegisterType[*jose.JSONWebKey]("jose.json_web_key")
+	encoding.RegisterType[*keySet]("jwt_authenticator.key_set")
+	encoding.RegisterType[*denyList]("jwt_authenticator.deny_list")
 }
 
 type jwtAuthenticator struct {
@@ -74,6 +84,12 @@ type jwtAuthenticator struct {
 	allowFallbackOnError bool
 	trustStore           truststore.TrustStore
 	validateJWKCert      bool
+	sc                   *senderConstraints
+	decrypter            *jweDecrypter
+	refreshAhead         time.Duration
+	refetchLimiter       *jwksRefetchLimiter
+	metrics              *jwksMetrics
+	rev                  *tokenRevocation
 }
 
 func newJwtAuthenticator(id string, rawConfig map[string]any) (*jwtAuthenticator, error) { // nolint: funlen
@@ -87,6 +103,12 @@ func newJwtAuthenticator(id string, rawConfig map[string]any) (*jwtAuthenticator
 		AllowFallbackOnError bool                                `mapstructure:"allow_fallback_on_error"`
 		ValidateJWK          *bool                               `mapstructure:"validate_jwk"`
 		TrustStore           truststore.TrustStore               `mapstructure:"trust_store"`
+		DPoP                 *DPoPConfig                         `mapstructure:"dpop"`
+		CertificateBinding   *CertificateBindingConfig           `mapstructure:"certificate_binding"`
+		Decryption           *JWEDecryptionConfig                `mapstructure:"decryption"`
+		RefreshAhead         time.Duration                       `mapstructure:"refresh_ahead"`
+		RefetchInterval      *time.Duration                      `mapstructure:"unknown_kid_refetch_interval"`
+		Revocation           *RevocationConfig                   `mapstructure:"revocation"`
 	}
 
 	var conf Config
@@ -111,18 +133,17 @@ func newJwtAuthenticator(id string, rawConfig map[string]any) (*jwtAuthenticator
 		conf.SubjectInfo.IDFrom = "sub"
 	}
 
+	decrypter, err := newJWEDecrypter(conf.Decryption)
+	if err != nil {
+		return nil, err
+	}
+
 	validateJWKCert := x.IfThenElseExec(conf.ValidateJWK != nil,
 		func() bool { return *conf.ValidateJWK },
 		func() bool { return true })
 
 	ads := x.IfThenElseExec(conf.AuthDataSource == nil,
-		func() extractors.CompositeExtractStrategy {
-			return extractors.CompositeExtractStrategy{
-				extractors.HeaderValueExtractStrategy{Name: "Authorization", Scheme: "Bearer"},
-				extractors.QueryParameterExtractStrategy{Name: "access_token"},
-				extractors.BodyParameterExtractStrategy{Name: "access_token"},
-			}
-		},
+		func() extractors.CompositeExtractStrategy { return accessTokenExtractStrategies(conf.DPoP) },
 		func() extractors.CompositeExtractStrategy { return conf.AuthDataSource },
 	)
 
@@ -151,7 +172,7 @@ func newJwtAuthenticator(id string, rawConfig map[string]any) (*jwtAuthenticator
 		},
 	)
 
-	return &jwtAuthenticator{
+	auth := &jwtAuthenticator{
 		id:                   id,
 		r:                    resolver,
 		a:                    conf.Assertions,
@@ -161,7 +182,17 @@ func newJwtAuthenticator(id string, rawConfig map[string]any) (*jwtAuthenticator
 		allowFallbackOnError: conf.AllowFallbackOnError,
 		validateJWKCert:      validateJWKCert,
 		trustStore:           conf.TrustStore,
-	}, nil
+		sc:                   newSenderConstraints(conf.DPoP, conf.CertificateBinding),
+		decrypter:            decrypter,
+		refreshAhead:         conf.RefreshAhead,
+		refetchLimiter: newJWKSRefetchLimiter(x.IfThenElseExec(conf.RefetchInterval != nil,
+			func() time.Duration { return *conf.RefetchInterval },
+			func() time.Duration { return defaultJWKSRefetchInterval })),
+		metrics: newJWKSMetrics(otel.GetMeterProvider()),
+		rev:     newTokenRevocation(conf.Revocation),
+	}
+
+	return auth, nil
 }
 
 func (a *jwtAuthenticator) Execute(ctx heimdall.Context) (*subject.Subject, error) {
@@ -176,7 +207,7 @@ func (a *jwtAuthenticator) Execute(ctx heimdall.Context) (*subject.Subject, erro
 			CausedBy(err)
 	}
 
-	token, err := jwt.ParseSigned(jwtAd)
+	token, err := a.parseToken(jwtAd)
 	if err != nil {
 		return nil, errorchain.
 			NewWithMessage(heimdall.ErrAuthentication, "failed to parse JWT").
@@ -185,11 +216,27 @@ func (a *jwtAuthenticator) Execute(ctx heimdall.Context) (*subject.Subject, erro
 			CausedBy(err)
 	}
 
-	rawClaims, err := a.verifyToken(ctx, token)
+	rawClaims, err := a.verifyToken(ctx.AppContext(), token, a.a)
 	if err != nil {
 		return nil, err
 	}
 
+	if err = a.sc.verify(ctx, jwtAd, rawClaims); err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrAuthentication, "sender constraint validation failed").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	if err = a.rev.check(ctx.AppContext(), rawClaims); err != nil {
+		return nil, errorchain.
+			NewWithMessage(
+				x.IfThenElse(errors.Is(err, errTokenRevoked), heimdall.ErrAuthentication, heimdall.ErrInternal),
+				"token revocation check failed").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
 	sub, err := a.sf.CreateSubject(rawClaims)
 	if err != nil {
 		return nil, errorchain.
@@ -211,6 +258,7 @@ func (a *jwtAuthenticator) WithConfig(config map[string]any) (Authenticator, err
 		Assertions           *oauth2.Expectation `mapstructure:"assertions"              validate:"-"`
 		CacheTTL             *time.Duration      `mapstructure:"cache_ttl"`
 		AllowFallbackOnError *bool               `mapstructure:"allow_fallback_on_error"`
+		RefreshAhead         *time.Duration      `mapstructure:"refresh_ahead"`
 	}
 
 	var conf Config
@@ -230,6 +278,14 @@ func (a *jwtAuthenticator) WithConfig(config map[string]any) (Authenticator, err
 			func() bool { return a.allowFallbackOnError }),
 		validateJWKCert: a.validateJWKCert,
 		trustStore:      a.trustStore,
+		sc:              a.sc,
+		decrypter:       a.decrypter,
+		refreshAhead: x.IfThenElseExec(conf.RefreshAhead != nil,
+			func() time.Duration { return *conf.RefreshAhead },
+			func() time.Duration { return a.refreshAhead }),
+		refetchLimiter: a.refetchLimiter,
+		metrics:        a.metrics,
+		rev:            a.rev,
 	}, nil
 }
 
@@ -241,6 +297,54 @@ func (a *jwtAuthenticator) ID() string {
 	return a.id
 }
 
+func (a *jwtAuthenticator) CacheDependency() string {
+	purposes := make([]string, 0, 2) //nolint:gomnd
+
+	if purpose := a.sc.cacheDependency(); len(purpose) != 0 {
+		purposes = append(purposes, purpose)
+	}
+
+	if purpose := a.rev.cacheDependency(); len(purpose) != 0 {
+		purposes = append(purposes, purpose)
+	}
+
+	return strings.Join(purposes, " and ")
+}
+
+// LogoutTokenHandler returns the authenticator itself, if it is configured to process
+// back-channel logout tokens.
+func (a *jwtAuthenticator) LogoutTokenHandler() backchannel.LogoutTokenHandler {
+	if a.rev == nil || a.rev.logout == nil {
+		return nil
+	}
+
+	return a
+}
+
+// HandleLogoutToken verifies the given OpenID Connect back-channel logout token using the keys
+// of the issuers trusted by this authenticator and records the logout event.
+func (a *jwtAuthenticator) HandleLogoutToken(ctx context.Context, rawToken string) error {
+	token, err := a.parseToken(rawToken)
+	if err != nil {
+		return errorchain.NewWithMessage(errInvalidLogoutToken, "failed to parse logout token").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	rawClaims, err := a.verifyToken(ctx, token, oauth2.Expectation{
+		TrustedIssuers:    a.a.TrustedIssuers,
+		TargetAudiences:   a.rev.logout.Audience,
+		AllowedAlgorithms: a.a.AllowedAlgorithms,
+		ScopesMatcher:     oauth2.NoopMatcher{},
+		ValidityLeeway:    a.a.ValidityLeeway,
+	})
+	if err != nil {
+		return err
+	}
+
+	return a.rev.recordLogout(ctx, rawClaims)
+}
+
 func (a *jwtAuthenticator) isCacheEnabled() bool {
 	// cache is enabled if ttl is not configured (in that case the ttl value from either
 	// the jwk cert (if available) or the defaultTTL is used), or if ttl is configured and
@@ -284,8 +388,8 @@ func (a *jwtAuthenticator) getCacheTTL(key *jose.JSONWebKey) time.Duration {
 	}
 }
 
-func (a *jwtAuthenticator) serverMetadata(ctx heimdall.Context, claims map[string]any) (oauth2.ServerMetadata, error) {
-	metadata, err := a.r.Get(ctx.AppContext(), map[string]any{"TokenIssuer": claims["iss"]})
+func (a *jwtAuthenticator) serverMetadata(ctx context.Context, claims map[string]any) (oauth2.ServerMetadata, error) {
+	metadata, err := a.r.Get(ctx, map[string]any{"TokenIssuer": claims["iss"]})
 	if err != nil {
 		return oauth2.ServerMetadata{}, errorchain.NewWithMessage(heimdall.ErrInternal,
 			"failed retrieving oauth2 server metadata").CausedBy(err).WithErrorContext(a)
@@ -300,7 +404,17 @@ func (a *jwtAuthenticator) serverMetadata(ctx heimdall.Context, claims map[strin
 	return metadata, nil
 }
 
-func (a *jwtAuthenticator) verifyToken(ctx heimdall.Context, token *jwt.JSONWebToken) (json.RawMessage, error) {
+func (a *jwtAuthenticator) parseToken(rawToken string) (*jwt.JSONWebToken, error) {
+	if a.decrypter != nil && isEncrypted(rawToken) {
+		return a.decrypter.decrypt(rawToken)
+	}
+
+	return jwt.ParseSigned(rawToken)
+}
+
+func (a *jwtAuthenticator) verifyToken(
+	ctx context.Context, token *jwt.JSONWebToken, expectation oauth2.Expectation,
+) (json.RawMessage, error) {
 	claims := map[string]any{}
 	if err := token.UnsafeClaimsWithoutVerification(&claims); err != nil {
 		return nil, errorchain.NewWithMessage(heimdall.ErrInternal, "failed to deserialize JWT").
@@ -314,7 +428,7 @@ func (a *jwtAuthenticator) verifyToken(ctx heimdall.Context, token *jwt.JSONWebT
 	}
 
 	// configured assertions take precedence over those available in the metadata
-	assertions := a.a.Merge(&oauth2.Expectation{
+	assertions := expectation.Merge(&oauth2.Expectation{
 		TrustedIssuers: []string{metadata.Issuer},
 	})
 
@@ -331,23 +445,23 @@ func (a *jwtAuthenticator) verifyToken(ctx heimdall.Context, token *jwt.JSONWebT
 }
 
 func (a *jwtAuthenticator) verifyTokenWithoutKID(
-	ctx heimdall.Context,
+	ctx context.Context,
 	token *jwt.JSONWebToken,
 	tokenClaims map[string]any,
 	ep *endpoint.Endpoint,
 	assertions *oauth2.Expectation,
 ) (json.RawMessage, error) {
-	logger := zerolog.Ctx(ctx.AppContext())
+	logger := zerolog.Ctx(ctx)
 	logger.Info().Msg("No kid present in the JWT")
 
 	var rawClaims json.RawMessage
 
-	req, err := a.createRequest(ctx.AppContext(), ep, tokenClaims)
+	req, err := a.createRequest(ctx, ep, tokenClaims)
 	if err != nil {
 		return nil, err
 	}
 
-	jwks, err := a.fetchJWKS(ctx.AppContext(), ep.CreateClient(req.URL.Hostname()), req)
+	jwks, err := a.fetchJWKS(ctx, ep.CreateClient(req.URL.Hostname()), req)
 	if err != nil {
 		return nil, err
 	}
@@ -379,69 +493,137 @@ func (a *jwtAuthenticator) verifyTokenWithoutKID(
 }
 
 func (a *jwtAuthenticator) getKey(
-	ctx heimdall.Context, keyID string, tokenClaims map[string]any, ep *endpoint.Endpoint,
+	ctx context.Context, keyID string, tokenClaims map[string]any, ep *endpoint.Endpoint,
 ) (*jose.JSONWebKey, error) {
-	cch := cache.Ctx(ctx.AppContext())
-	logger := zerolog.Ctx(ctx.AppContext())
+	req, err := a.createRequest(ctx, ep, tokenClaims)
+	if err != nil {
+		return nil, err
+	}
 
-	var (
-		cacheKey   string
-		cacheEntry any
-		jwk        *jose.JSONWebKey
-		jwks       *jose.JSONWebKeySet
-		err        error
-		ok         bool
-	)
+	cacheKey := a.calculateCacheKey(ep, req.URL.String(), "")
 
-	req, err := a.createRequest(ctx.AppContext(), ep, tokenClaims)
+	keys, err := a.getKeySet(ctx, req, ep, cacheKey, false)
 	if err != nil {
 		return nil, err
 	}
 
-	if a.isCacheEnabled() {
-		cacheKey = a.calculateCacheKey(ep, req.URL.String(), keyID)
-		cacheEntry = cch.Get(ctx.AppContext(), cacheKey)
+	if !keys.contains(keyID) {
+		// the keys might have been rotated, but the cached key set, or the
+		// one received from the http cache is an outdated version
+		if keys, err = a.refetchKeySet(ctx, req, ep, cacheKey, keyID, keys); err != nil {
+			return nil, err
+		}
+	}
+
+	if reason, ok := keys.InvalidKeys[keyID]; ok {
+		return nil, errorchain.
+			NewWithMessagef(heimdall.ErrAuthentication, "JWK for keyID=%s is invalid: %s", keyID, reason).
+			WithErrorContext(a)
+	}
+
+	matching := keys.key(keyID)
+	if len(matching) != 1 {
+		return nil, errorchain.
+			NewWithMessagef(heimdall.ErrAuthentication,
+				"no (unique) key found for the keyID='%s' referenced in the JWT", keyID).
+			WithErrorContext(a)
 	}
 
-	if cacheEntry != nil {
-		if jwk, ok = cacheEntry.(*jose.JSONWebKey); !ok {
-			logger.Warn().Msg("Wrong object type from cache")
-			cch.Delete(ctx.AppContext(), cacheKey)
-		} else {
-			logger.Debug().Msg("Reusing JWK from cache")
+	return &matching[0], nil
+}
+
+func (a *jwtAuthenticator) getKeySet(
+	ctx context.Context, req *http.Request, ep *endpoint.Endpoint, cacheKey string, bypassHTTPCache bool,
+) (*keySet, error) {
+	load := func(loadCtx context.Context) (*keySet, time.Duration, error) {
+		// the key set might be loaded in the background, so the request
+		// must not be bound to the context of the request to heimdall
+		loadReq := req.Clone(loadCtx)
+		if bypassHTTPCache {
+			loadReq.Header.Set("Cache-Control", "no-cache")
 		}
+
+		jwks, err := a.fetchJWKS(loadCtx, ep.CreateClient(req.URL.Hostname()), loadReq)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		keys := a.newKeySet(loadCtx, jwks)
+
+		return keys, a.getKeySetCacheTTL(keys), nil
+	}
+
+	if !a.isCacheEnabled() {
+		keys, _, err := load(ctx)
+
+		return keys, err
+	}
+
+	cch := cache.Ctx(ctx)
+	if bypassHTTPCache {
+		cch.Delete(ctx, cacheKey)
 	}
 
-	if jwk != nil {
-		return jwk, nil
+	return cache.GetOrLoad(ctx, cch, cacheKey, load, cache.WithRefreshAhead(a.refreshAhead))
+}
+
+func (a *jwtAuthenticator) refetchKeySet(
+	ctx context.Context, req *http.Request, ep *endpoint.Endpoint, cacheKey, keyID string, current *keySet,
+) (*keySet, error) {
+	logger := zerolog.Ctx(ctx)
+
+	if !a.refetchLimiter.allow(cacheKey) {
+		logger.Debug().Str("_key_id", keyID).Msg("Unknown key id. Refetching of JWKS is disabled or rate limited")
+		a.metrics.recordKidMiss(ctx, a.id, kidMissRateLimited)
+
+		return current, nil
 	}
 
-	jwks, err = a.fetchJWKS(ctx.AppContext(), ep.CreateClient(req.URL.Hostname()), req)
+	logger.Debug().Str("_key_id", keyID).Msg("Unknown key id. Refetching JWKS")
+
+	keys, err := a.getKeySet(ctx, req, ep, cacheKey, true)
 	if err != nil {
 		return nil, err
 	}
 
-	keys := jwks.Key(keyID)
-	if len(keys) != 1 {
-		return nil, errorchain.
-			NewWithMessagef(heimdall.ErrAuthentication,
-				"no (unique) key found for the keyID='%s' referenced in the JWT", keyID).
-			WithErrorContext(a)
-	}
+	a.metrics.recordKidMiss(ctx, a.id, x.IfThenElse(keys.contains(keyID), kidMissResolved, kidMissUnresolved))
 
-	jwk = &keys[0]
-	if err = a.validateJWK(jwk); err != nil {
-		return nil, errorchain.
-			NewWithMessagef(heimdall.ErrAuthentication, "JWK for keyID=%s is invalid", keyID).
-			WithErrorContext(a).
-			CausedBy(err)
+	return keys, nil
+}
+
+func (a *jwtAuthenticator) newKeySet(ctx context.Context, jwks *jose.JSONWebKeySet) *keySet {
+	keys := &keySet{Keys: make([]jose.JSONWebKey, 0, len(jwks.Keys))}
+
+	for idx := range jwks.Keys {
+		jwk := jwks.Keys[idx]
+
+		if err := a.validateJWK(&jwk); err != nil {
+			zerolog.Ctx(ctx).Info().Err(err).Str("_key_id", jwk.KeyID).Msg("JWK is invalid")
+
+			if keys.InvalidKeys == nil {
+				keys.InvalidKeys = make(map[string]string)
+			}
+
+			keys.InvalidKeys[jwk.KeyID] = err.Error()
+
+			continue
+		}
+
+		keys.Keys = append(keys.Keys, jwk)
 	}
 
-	if cacheTTL := a.getCacheTTL(jwk); cacheTTL > 0 {
-		cch.Set(ctx.AppContext(), cacheKey, jwk, cacheTTL)
+	return keys
+}
+
+func (a *jwtAuthenticator) getKeySetCacheTTL(keys *keySet) time.Duration {
+	// the key set is cached not longer than each of its keys could be
+	ttl := a.getCacheTTL(&jose.JSONWebKey{})
+
+	for idx := range keys.Keys {
+		ttl = min(ttl, a.getCacheTTL(&keys.Keys[idx]))
 	}
 
-	return jwk, nil
+	return ttl
 }
 
 func (a *jwtAuthenticator) fetchJWKS(
@@ -453,6 +635,8 @@ func (a *jwtAuthenticator) fetchJWKS(
 
 	resp, err := client.Do(req)
 	if err != nil {
+		a.metrics.recordFetch(ctx, a.id, err)
+
 		var clientErr *url.Error
 		if errors.As(err, &clientErr) && clientErr.Timeout() {
 			return nil, errorchain.
@@ -469,7 +653,10 @@ func (a *jwtAuthenticator) fetchJWKS(
 
 	defer resp.Body.Close()
 
-	return a.readJWKS(resp)
+	jwks, err := a.readJWKS(resp)
+	a.metrics.recordFetch(ctx, a.id, err)
+
+	return jwks, err
 }
 
 func (a *jwtAuthenticator) createRequest(
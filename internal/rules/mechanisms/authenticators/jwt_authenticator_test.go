@@ -37,8 +37,11 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
 
 	"github.com/dadrus/heimdall/internal/cache"
+	"github.com/dadrus/heimdall/internal/cache/memory"
 	"github.com/dadrus/heimdall/internal/cache/mocks"
 	"github.com/dadrus/heimdall/internal/heimdall"
 	heimdallmocks "github.com/dadrus/heimdall/internal/heimdall/mocks"
@@ -137,6 +140,67 @@ subject:
 				require.ErrorContains(t, err, "'issuers' is a required field")
 			},
 		},
+		{
+			uc: "revocation deny list configured with file and endpoint",
+			config: []byte(`
+jwks_endpoint:
+  url: http://test.com
+assertions:
+  issuers:
+    - foobar
+revocation:
+  deny_list:
+    file: /tmp/deny-list.yaml
+    endpoint:
+      url: http://deny.list
+`),
+			assert: func(t *testing.T, err error, _ *jwtAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "'deny_list'.'file' is an excluded field")
+			},
+		},
+		{
+			uc: "revocation back-channel logout configured without audience",
+			config: []byte(`
+jwks_endpoint:
+  url: http://test.com
+assertions:
+  issuers:
+    - foobar
+revocation:
+  backchannel_logout:
+    ttl: 1h
+`),
+			assert: func(t *testing.T, err error, _ *jwtAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "'audience' is a required field")
+			},
+		},
+		{
+			uc: "decryption configured with not existing key store",
+			config: []byte(`
+jwks_endpoint:
+  url: http://test.com
+assertions:
+  issuers:
+    - foobar
+decryption:
+  key_store:
+    path: /no/such/file.pem`),
+			assert: func(t *testing.T, err error, _ *jwtAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				require.ErrorContains(t, err, "decryption key store")
+			},
+		},
 		{
 			uc: "minimal jwks endpoint based configuration with malformed jwks endpoint",
 			id: "auth1",
@@ -215,10 +279,52 @@ assertions:
 				assert.True(t, auth.validateJWKCert)
 				assert.Empty(t, auth.trustStore)
 
+				// sender constraint settings
+				assert.Nil(t, auth.sc)
+
+				// key set refresh settings
+				assert.Zero(t, auth.refreshAhead)
+				require.NotNil(t, auth.refetchLimiter)
+				assert.Equal(t, time.Minute, auth.refetchLimiter.interval)
+				assert.NotNil(t, auth.metrics)
+
 				// handler id
 				assert.Equal(t, "auth1", auth.ID())
 			},
 		},
+		{
+			uc: "jwks endpoint based configuration with sender constraints",
+			id: "auth1",
+			config: []byte(`
+jwks_endpoint:
+  url: http://test.com
+assertions:
+  issuers:
+    - foobar
+dpop:
+  required: true
+certificate_binding:
+  required: false`),
+			assert: func(t *testing.T, err error, auth *jwtAuthenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				// token extractor settings
+				assert.Len(t, auth.ads, 4)
+				assert.Contains(t, auth.ads, extractors.HeaderValueExtractStrategy{Name: "Authorization", Scheme: "Bearer"})
+				assert.Contains(t, auth.ads, extractors.HeaderValueExtractStrategy{Name: "Authorization", Scheme: "DPoP"})
+
+				// sender constraint settings
+				require.NotNil(t, auth.sc)
+				require.NotNil(t, auth.sc.dpop)
+				assert.True(t, auth.sc.dpop.Required)
+				assert.Equal(t, time.Minute, *auth.sc.dpop.MaxProofAge)
+				assert.Len(t, auth.sc.dpop.AllowedAlgorithms, 6)
+				require.NotNil(t, auth.sc.certBinding)
+				assert.False(t, auth.sc.certBinding.Required)
+			},
+		},
 		{
 			uc: "minimal jwks endpoint based configuration with cache",
 			id: "auth1",
@@ -313,6 +419,8 @@ subject:
   id: some_claim
 allow_fallback_on_error: true
 validate_jwk: false
+refresh_ahead: 1m
+unknown_kid_refetch_interval: 0s
 trust_store: ` + trustStorePath),
 			assert: func(t *testing.T, err error, auth *jwtAuthenticator) {
 				t.Helper()
@@ -366,6 +474,10 @@ trust_store: ` + trustStorePath),
 				assert.False(t, auth.validateJWKCert)
 				assert.Contains(t, auth.trustStore, rootCA1.Certificate)
 
+				// key set refresh settings
+				assert.Equal(t, time.Minute, auth.refreshAhead)
+				assert.Zero(t, auth.refetchLimiter.interval)
+
 				// handler id
 				assert.Equal(t, "auth1", auth.ID())
 			},
@@ -577,7 +689,8 @@ assertions:
     - barfoo
   allowed_algorithms:
     - ES512
-cache_ttl: 5s`),
+cache_ttl: 5s
+refresh_ahead: 2s`),
 			assert: func(t *testing.T, err error, prototype *jwtAuthenticator, configured *jwtAuthenticator) {
 				t.Helper()
 
@@ -599,6 +712,8 @@ cache_ttl: 5s`),
 				assert.Equal(t, prototype.IsFallbackOnErrorAllowed(), configured.IsFallbackOnErrorAllowed())
 				assert.Equal(t, prototype.validateJWKCert, configured.validateJWKCert)
 				assert.Equal(t, prototype.trustStore, configured.trustStore)
+				assert.Equal(t, 2*time.Second, configured.refreshAhead)
+				assert.Equal(t, prototype.refetchLimiter, configured.refetchLimiter)
 
 				assert.Equal(t, "auth2", configured.ID())
 			},
@@ -868,6 +983,8 @@ func TestJwtAuthenticatorExecute(t *testing.T) {
 	audience := "bar"
 
 	jwtSignedWithKeyOnlyJWK := createJWT(t, keyOnlyEntry, subjectID, issuer, audience, true)
+	encryptedJWTSignedWithKeyOnlyJWK := encryptJWT(t, jwtSignedWithKeyOnlyJWK,
+		keyRSAEntry.PrivateKey.Public(), keyRSAEntry.KeyID, jose.RSA_OAEP_256, jose.A256GCM)
 
 	jwtSignedWithKeyAndCertJWK := createJWT(t, keyAndCertEntry, subjectID, issuer, audience, true)
 	jwtWithoutKIDSignedWithKeyAndCertJWK := createJWT(t, keyAndCertEntry, subjectID, issuer, audience, false)
@@ -1330,16 +1447,16 @@ func TestJwtAuthenticatorExecute(t *testing.T) {
 					URL:     jwksSrv.URL,
 					Headers: map[string]string{"Accept": "application/json"},
 				}
-				cacheKey := auth.calculateCacheKey(ep, jwksSrv.URL, kidKeyWithoutCert)
+				cacheKey := auth.calculateCacheKey(ep, jwksSrv.URL, "")
 
 				var jwks jose.JSONWebKeySet
 				err := json.Unmarshal(jwksWithOneKeyOnlyEntry, &jwks)
 				require.NoError(t, err)
 
-				keys := jwks.Key(kidKeyWithoutCert)
+				keys := &keySet{Keys: jwks.Keys}
 
 				ads.EXPECT().GetAuthData(ctx).Return(jwtSignedWithKeyOnlyJWK, nil)
-				cch.EXPECT().Get(mock.Anything, cacheKey).Return(&keys[0])
+				cch.EXPECT().Get(mock.Anything, cacheKey).Return(keys)
 			},
 			assert: func(t *testing.T, err error, sub *subject.Subject) {
 				t.Helper()
@@ -1384,16 +1501,18 @@ func TestJwtAuthenticatorExecute(t *testing.T) {
 					URL:     jwksSrv.URL,
 					Headers: map[string]string{"Accept": "application/json"},
 				}
-				cacheKey := auth.calculateCacheKey(ep, jwksSrv.URL, kidKeyWithCert)
+				cacheKey := auth.calculateCacheKey(ep, jwksSrv.URL, "")
 
 				var jwks jose.JSONWebKeySet
 				err := json.Unmarshal(jwksWithOneKeyOnlyEntry, &jwks)
 				require.NoError(t, err)
 
-				keys := jwks.Key(kidKeyWithoutCert)
+				// the cached key set contains a different key for the key id referenced in the JWT
+				keys := &keySet{Keys: jwks.Keys}
+				keys.Keys[0].KeyID = kidKeyWithCert
 
 				ads.EXPECT().GetAuthData(ctx).Return(jwtSignedWithKeyAndCertJWK, nil)
-				cch.EXPECT().Get(mock.Anything, cacheKey).Return(&keys[0])
+				cch.EXPECT().Get(mock.Anything, cacheKey).Return(keys)
 			},
 			assert: func(t *testing.T, err error, sub *subject.Subject) {
 				t.Helper()
@@ -1438,16 +1557,16 @@ func TestJwtAuthenticatorExecute(t *testing.T) {
 					URL:     jwksSrv.URL,
 					Headers: map[string]string{"Accept": "application/json"},
 				}
-				cacheKey := auth.calculateCacheKey(ep, jwksSrv.URL, kidKeyWithoutCert)
+				cacheKey := auth.calculateCacheKey(ep, jwksSrv.URL, "")
 
 				var jwks jose.JSONWebKeySet
 				err := json.Unmarshal(jwksWithOneKeyOnlyEntry, &jwks)
 				require.NoError(t, err)
 
-				keys := jwks.Key(kidKeyWithoutCert)
+				keys := &keySet{Keys: jwks.Keys}
 
 				ads.EXPECT().GetAuthData(ctx).Return(jwtSignedWithKeyOnlyJWK, nil)
-				cch.EXPECT().Get(mock.Anything, cacheKey).Return(&keys[0])
+				cch.EXPECT().Get(mock.Anything, cacheKey).Return(keys)
 			},
 			assert: func(t *testing.T, err error, sub *subject.Subject) {
 				t.Helper()
@@ -1497,16 +1616,16 @@ func TestJwtAuthenticatorExecute(t *testing.T) {
 					URL:     jwksSrv.URL,
 					Headers: map[string]string{"Accept": "application/json"},
 				}
-				cacheKey := auth.calculateCacheKey(ep, jwksSrv.URL, kidKeyWithoutCert)
+				cacheKey := auth.calculateCacheKey(ep, jwksSrv.URL, "")
 
 				var jwks jose.JSONWebKeySet
 				err := json.Unmarshal(jwksWithOneKeyOnlyEntry, &jwks)
 				require.NoError(t, err)
 
-				keys := jwks.Key(kidKeyWithoutCert)
+				keys := &keySet{Keys: jwks.Keys}
 
 				ads.EXPECT().GetAuthData(ctx).Return(jwtSignedWithKeyOnlyJWK, nil)
-				cch.EXPECT().Get(mock.Anything, cacheKey).Return(&keys[0])
+				cch.EXPECT().Get(mock.Anything, cacheKey).Return(keys)
 			},
 			assert: func(t *testing.T, err error, sub *subject.Subject) {
 				t.Helper()
@@ -1556,16 +1675,85 @@ func TestJwtAuthenticatorExecute(t *testing.T) {
 					URL:     jwksSrv.URL,
 					Headers: map[string]string{"Accept": "application/json"},
 				}
-				cacheKey := auth.calculateCacheKey(ep, jwksSrv.URL, kidKeyWithoutCert)
+				cacheKey := auth.calculateCacheKey(ep, jwksSrv.URL, "")
 
 				var jwks jose.JSONWebKeySet
 				err := json.Unmarshal(jwksWithOneKeyOnlyEntry, &jwks)
 				require.NoError(t, err)
 
-				keys := jwks.Key(kidKeyWithoutCert)
+				keys := &keySet{Keys: jwks.Keys}
 
 				ads.EXPECT().GetAuthData(ctx).Return(jwtSignedWithKeyOnlyJWK, nil)
-				cch.EXPECT().Get(mock.Anything, cacheKey).Return(&keys[0])
+				cch.EXPECT().Get(mock.Anything, cacheKey).Return(keys)
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				assert.False(t, jwksEndpointCalled)
+				assert.False(t, metadataEndpointCalled)
+
+				require.NoError(t, err)
+
+				require.NotNil(t, sub)
+				assert.Equal(t, subjectID, sub.ID)
+				assert.Len(t, sub.Attributes, 8)
+				assert.Len(t, sub.Attributes["aud"], 1)
+				assert.Contains(t, sub.Attributes["aud"], audience)
+				assert.Contains(t, sub.Attributes, "exp")
+				assert.Contains(t, sub.Attributes, "iat")
+				assert.Contains(t, sub.Attributes, "nbf")
+				assert.Equal(t, issuer, sub.Attributes["iss"])
+				assert.Contains(t, sub.Attributes["scp"], "foo")
+				assert.Contains(t, sub.Attributes["scp"], "bar")
+				assert.Equal(t, subjectID, sub.Attributes["sub"])
+			},
+		},
+		{
+			uc: "successful with positive cache hit using encrypted JWT",
+			authenticator: &jwtAuthenticator{
+				r: oauth2.ResolverAdapterFunc(func(_ context.Context, _ map[string]any) (oauth2.ServerMetadata, error) {
+					return oauth2.ServerMetadata{
+						JWKSEndpoint: &endpoint.Endpoint{
+							URL:     jwksSrv.URL,
+							Headers: map[string]string{"Accept": "application/json"},
+						},
+					}, nil
+				}),
+				a: oauth2.Expectation{
+					AllowedAlgorithms: []string{"ES384"},
+					TrustedIssuers:    []string{issuer},
+					ScopesMatcher:     oauth2.ExactScopeStrategyMatcher{},
+				},
+				sf:  &SubjectInfo{IDFrom: "sub"},
+				ttl: &tenSecondsTTL,
+				decrypter: &jweDecrypter{
+					ks:            ks,
+					keyAlgorithms: defaultKeyManagementAlgorithms(),
+					encAlgorithms: defaultContentEncryptionAlgorithms(),
+				},
+			},
+			configureMocks: func(t *testing.T,
+				ctx *heimdallmocks.ContextMock,
+				cch *mocks.CacheMock,
+				ads *mocks2.AuthDataExtractStrategyMock,
+				auth *jwtAuthenticator,
+			) {
+				t.Helper()
+
+				ep := &endpoint.Endpoint{
+					URL:     jwksSrv.URL,
+					Headers: map[string]string{"Accept": "application/json"},
+				}
+				cacheKey := auth.calculateCacheKey(ep, jwksSrv.URL, "")
+
+				var jwks jose.JSONWebKeySet
+				err := json.Unmarshal(jwksWithOneKeyOnlyEntry, &jwks)
+				require.NoError(t, err)
+
+				keys := &keySet{Keys: jwks.Keys}
+
+				ads.EXPECT().GetAuthData(ctx).Return(encryptedJWTSignedWithKeyOnlyJWK, nil)
+				cch.EXPECT().Get(mock.Anything, cacheKey).Return(keys)
 			},
 			assert: func(t *testing.T, err error, sub *subject.Subject) {
 				t.Helper()
@@ -1620,17 +1808,17 @@ func TestJwtAuthenticatorExecute(t *testing.T) {
 					URL:     jwksSrv.URL + "/{{ .TokenIssuer }}",
 					Headers: map[string]string{"Accept": "application/json"},
 				}
-				cacheKey := auth.calculateCacheKey(ep, fmt.Sprintf("%s/%s", jwksSrv.URL, issuer), kidKeyWithoutCert)
+				cacheKey := auth.calculateCacheKey(ep, fmt.Sprintf("%s/%s", jwksSrv.URL, issuer), "")
 
 				var jwks jose.JSONWebKeySet
 				err := json.Unmarshal(jwksWithOneKeyOnlyEntry, &jwks)
 				require.NoError(t, err)
 
-				keys := jwks.Key(kidKeyWithoutCert)
+				keys := &keySet{Keys: jwks.Keys}
 
 				ads.EXPECT().GetAuthData(ctx).Return(jwtSignedWithKeyOnlyJWK, nil)
 				cch.EXPECT().Get(mock.Anything, cacheKey).Return(nil)
-				cch.EXPECT().Set(mock.Anything, cacheKey, &keys[0], *auth.ttl)
+				cch.EXPECT().Set(mock.Anything, cacheKey, keys, *auth.ttl)
 			},
 			instructServer: func(t *testing.T) {
 				t.Helper()
@@ -1697,17 +1885,17 @@ func TestJwtAuthenticatorExecute(t *testing.T) {
 					URL:     jwksSrv.URL,
 					Headers: map[string]string{"Accept": "application/json"},
 				}
-				cacheKey := auth.calculateCacheKey(ep, jwksSrv.URL, kidKeyWithCert)
+				cacheKey := auth.calculateCacheKey(ep, jwksSrv.URL, "")
 
 				var jwks jose.JSONWebKeySet
 				err := json.Unmarshal(jwksWithOneEntryWithKeyOnlyAndOneWithCertificate, &jwks)
 				require.NoError(t, err)
 
-				keys := jwks.Key(kidKeyWithCert)
+				keys := &keySet{Keys: jwks.Keys}
 
 				ads.EXPECT().GetAuthData(ctx).Return(jwtSignedWithKeyAndCertJWK, nil)
 				cch.EXPECT().Get(mock.Anything, cacheKey).Return(nil)
-				cch.EXPECT().Set(mock.Anything, cacheKey, &keys[0], *auth.ttl)
+				cch.EXPECT().Set(mock.Anything, cacheKey, keys, *auth.ttl)
 			},
 			instructServer: func(t *testing.T) {
 				t.Helper()
@@ -1769,17 +1957,17 @@ func TestJwtAuthenticatorExecute(t *testing.T) {
 					Headers: map[string]string{"Accept": "application/json"},
 					Method:  http.MethodGet,
 				}
-				cacheKey := auth.calculateCacheKey(ep, jwksSrv.URL, kidKeyWithCert)
+				cacheKey := auth.calculateCacheKey(ep, jwksSrv.URL, "")
 
 				var jwks jose.JSONWebKeySet
 				err := json.Unmarshal(jwksWithOneEntryWithKeyOnlyAndOneWithCertificate, &jwks)
 				require.NoError(t, err)
 
-				keys := jwks.Key(kidKeyWithCert)
+				keys := &keySet{Keys: jwks.Keys}
 
 				ads.EXPECT().GetAuthData(ctx).Return(jwtSignedWithKeyAndCertJWK, nil)
 				cch.EXPECT().Get(mock.Anything, mock.Anything).Return(nil)
-				cch.EXPECT().Set(mock.Anything, cacheKey, &keys[0], *auth.ttl)
+				cch.EXPECT().Set(mock.Anything, cacheKey, keys, *auth.ttl)
 				// http cache
 				cch.EXPECT().Set(mock.Anything, mock.Anything, mock.Anything, mock.MatchedBy(
 					func(ttl time.Duration) bool { return ttl.Round(time.Minute) == 30*time.Minute },
@@ -1863,7 +2051,7 @@ func TestJwtAuthenticatorExecute(t *testing.T) {
 					URL:     jwksSrv.URL,
 					Headers: map[string]string{"Accept": "application/json"},
 				}
-				cacheKey := auth.calculateCacheKey(ep, jwksSrv.URL, kidKeyWithCert)
+				cacheKey := auth.calculateCacheKey(ep, jwksSrv.URL, "")
 
 				var jwks jose.JSONWebKeySet
 				err := json.Unmarshal(jwksWithOneEntryWithKeyOnlyAndOneWithCertificate, &jwks)
@@ -1871,6 +2059,11 @@ func TestJwtAuthenticatorExecute(t *testing.T) {
 
 				ads.EXPECT().GetAuthData(ctx).Return(jwtSignedWithKeyAndCertJWK, nil)
 				cch.EXPECT().Get(mock.Anything, cacheKey).Return(nil)
+				// the key set is cached with the invalid key being marked as such
+				cch.EXPECT().Set(mock.Anything, cacheKey, mock.MatchedBy(func(keys *keySet) bool {
+					return len(keys.Keys) == 1 && keys.Keys[0].KeyID == kidKeyWithoutCert &&
+						len(keys.InvalidKeys[kidKeyWithCert]) != 0
+				}), *auth.ttl)
 			},
 			instructServer: func(t *testing.T) {
 				t.Helper()
@@ -1932,17 +2125,17 @@ func TestJwtAuthenticatorExecute(t *testing.T) {
 					URL:     jwksSrv.URL,
 					Headers: map[string]string{"Accept": "application/json"},
 				}
-				cacheKey := auth.calculateCacheKey(ep, jwksSrv.URL, kidKeyWithCert)
+				cacheKey := auth.calculateCacheKey(ep, jwksSrv.URL, "")
 
 				var jwks jose.JSONWebKeySet
 				err := json.Unmarshal(jwksWithOneEntryWithKeyOnlyAndOneWithCertificate, &jwks)
 				require.NoError(t, err)
 
-				keys := jwks.Key(kidKeyWithCert)
+				keys := &keySet{Keys: jwks.Keys}
 
 				ads.EXPECT().GetAuthData(ctx).Return(jwtSignedWithKeyAndCertJWK, nil)
 				cch.EXPECT().Get(mock.Anything, cacheKey).Return(nil)
-				cch.EXPECT().Set(mock.Anything, cacheKey, &keys[0], *auth.ttl)
+				cch.EXPECT().Set(mock.Anything, cacheKey, keys, *auth.ttl)
 			},
 			instructServer: func(t *testing.T) {
 				t.Helper()
@@ -2008,18 +2201,18 @@ func TestJwtAuthenticatorExecute(t *testing.T) {
 					URL:     jwksSrv.URL,
 					Headers: map[string]string{"Accept": "application/json"},
 				}
-				cacheKey := auth.calculateCacheKey(ep, jwksSrv.URL, kidKeyWithoutCert)
+				cacheKey := auth.calculateCacheKey(ep, jwksSrv.URL, "")
 
 				var jwks jose.JSONWebKeySet
 				err := json.Unmarshal(jwksWithOneKeyOnlyEntry, &jwks)
 				require.NoError(t, err)
 
-				keys := jwks.Key(kidKeyWithoutCert)
+				keys := &keySet{Keys: jwks.Keys}
 
 				ads.EXPECT().GetAuthData(ctx).Return(jwtSignedWithKeyOnlyJWK, nil)
 				cch.EXPECT().Get(mock.Anything, cacheKey).Return("Hi Foo")
 				cch.EXPECT().Delete(mock.Anything, cacheKey)
-				cch.EXPECT().Set(mock.Anything, cacheKey, &keys[0], *auth.ttl)
+				cch.EXPECT().Set(mock.Anything, cacheKey, keys, *auth.ttl)
 			},
 			instructServer: func(t *testing.T) {
 				t.Helper()
@@ -2613,3 +2806,129 @@ func TestJwtAuthenticatorGetCacheTTL(t *testing.T) {
 		})
 	}
 }
+
+func TestJwtAuthenticatorRefetchesJWKSOnUnknownKeyID(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	ks := createKS(t)
+	keyOnlyEntry, err := ks.GetKey(kidKeyWithoutCert)
+	require.NoError(t, err)
+	keyAndCertEntry, err := ks.GetKey(kidKeyWithCert)
+	require.NoError(t, err)
+
+	unknownKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+
+	unknownEntry := &keystore.Entry{
+		KeyID: "unknown", Alg: keystore.AlgECDSA, KeySize: 384, PrivateKey: unknownKey,
+	}
+
+	var (
+		rotated       bool
+		requestsCount int
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requestsCount++
+
+		keys := []jose.JSONWebKey{keyOnlyEntry.JWK()}
+		if rotated {
+			keys = append(keys, keyAndCertEntry.JWK())
+		}
+
+		jwks, err := json.Marshal(jose.JSONWebKeySet{Keys: keys})
+		require.NoError(t, err)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "max-age=300")
+		_, err = w.Write(jwks)
+		require.NoError(t, err)
+	}))
+	defer srv.Close()
+
+	conf, err := testsupport.DecodeTestConfig([]byte(fmt.Sprintf(`
+jwks_endpoint:
+  url: %s
+  http_cache:
+    enabled: true
+assertions:
+  issuers:
+    - foobar
+  allowed_algorithms:
+    - ES384
+validate_jwk: false
+unknown_kid_refetch_interval: 1h
+`, srv.URL)))
+	require.NoError(t, err)
+
+	auth, err := newJwtAuthenticator("auth1", conf)
+	require.NoError(t, err)
+
+	reader := sdkmetric.NewManualReader()
+	auth.metrics = newJWKSMetrics(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+
+	cch := memory.New()
+
+	authenticate := func(t *testing.T, token string) error {
+		t.Helper()
+
+		ads := mocks2.NewAuthDataExtractStrategyMock(t)
+		ctx := heimdallmocks.NewContextMock(t)
+		ctx.EXPECT().AppContext().Return(cache.WithContext(context.Background(), cch))
+		ads.EXPECT().GetAuthData(ctx).Return(token, nil)
+
+		auth.ads = ads
+
+		_, err := auth.Execute(ctx)
+
+		return err
+	}
+
+	// WHEN & THEN
+	// known key
+	require.NoError(t, authenticate(t, createJWT(t, keyOnlyEntry, "foo", "foobar", "bar", true)))
+	assert.Equal(t, 1, requestsCount)
+
+	// key added to the key set, but the key set is served from the http cache first
+	rotated = true
+
+	require.NoError(t, authenticate(t, createJWT(t, keyAndCertEntry, "foo", "foobar", "bar", true)))
+	assert.Equal(t, 2, requestsCount)
+
+	// unknown key, refetching is rate limited
+	err = authenticate(t, createJWT(t, unknownEntry, "foo", "foobar", "bar", true))
+	require.Error(t, err)
+	require.ErrorIs(t, err, heimdall.ErrAuthentication)
+	require.ErrorContains(t, err, "no (unique) key found")
+	assert.Equal(t, 2, requestsCount)
+
+	// the whole key set has been cached on refetch, so both keys are known now
+	require.NoError(t, authenticate(t, createJWT(t, keyOnlyEntry, "foo", "foobar", "bar", true)))
+	require.NoError(t, authenticate(t, createJWT(t, keyAndCertEntry, "foo", "foobar", "bar", true)))
+	assert.Equal(t, 2, requestsCount)
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.TODO(), &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+
+	values := map[string]map[string]int64{}
+
+	for _, mtr := range rm.ScopeMetrics[0].Metrics {
+		data := mtr.Data.(metricdata.Sum[int64]) // nolint: forcetypeassert
+
+		values[mtr.Name] = map[string]int64{}
+
+		for _, dp := range data.DataPoints {
+			result, _ := dp.Attributes.Value(jwksResultAttrKey)
+			id, _ := dp.Attributes.Value(authenticatorIDAttrKey)
+
+			assert.Equal(t, "auth1", id.AsString())
+
+			values[mtr.Name][result.AsString()] = dp.Value
+		}
+	}
+
+	assert.Equal(t, map[string]int64{"success": 2}, values["jwks.fetches"])
+	assert.Equal(t, map[string]int64{kidMissResolved: 1, kidMissRateLimited: 1}, values["jwks.kid_misses"])
+}
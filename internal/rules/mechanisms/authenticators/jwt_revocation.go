@@ -0,0 +1,332 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package authenticators
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/dadrus/heimdall/internal/cache"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/rules/endpoint"
+	"github.com/dadrus/heimdall/internal/rules/mechanisms/oauth2"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+	"github.com/dadrus/heimdall/internal/x/stringx"
+)
+
+const (
+	defaultDenyListRefreshInterval = 1 * time.Minute
+	defaultLogoutEventTTL          = 24 * time.Hour
+
+	backchannelLogoutEvent = "http://schemas.openid.net/event/backchannel-logout"
+)
+
+var (
+	errTokenRevoked       = errors.New("token has been revoked")
+	errInvalidLogoutToken = errors.New("invalid logout token")
+)
+
+type RevocationConfig struct {
+	DenyList          *DenyListConfig          `mapstructure:"deny_list"`
+	BackchannelLogout *BackchannelLogoutConfig `mapstructure:"backchannel_logout"`
+}
+
+type DenyListConfig struct {
+	File            string             `mapstructure:"file"             validate:"required_without=Endpoint,excluded_with=Endpoint"` //nolint:lll,tagalign
+	Endpoint        *endpoint.Endpoint `mapstructure:"endpoint"         validate:"required_without=File,excluded_with=File"`         //nolint:lll,tagalign
+	RefreshInterval time.Duration      `mapstructure:"refresh_interval"`
+}
+
+type BackchannelLogoutConfig struct {
+	Audience []string      `mapstructure:"audience" validate:"required,gt=0"`
+	TTL      time.Duration `mapstructure:"ttl"`
+}
+
+// denyList holds the values of the jti, sub and sid claims of revoked tokens.
+type denyList struct {
+	JTI stringSet `json:"jti" yaml:"jti"`
+	Sub stringSet `json:"sub" yaml:"sub"`
+	SID stringSet `json:"sid" yaml:"sid"`
+}
+
+func (dl *denyList) contains(claims *revocationClaims) bool {
+	return dl.JTI.contains(claims.ID) || dl.Sub.contains(claims.Subject) || dl.SID.contains(claims.SessionID)
+}
+
+// stringSet is read from a yaml or json list, but kept as a set to allow constant time lookups.
+type stringSet map[string]struct{}
+
+func (s *stringSet) UnmarshalYAML(value *yaml.Node) error {
+	var values []string
+	if err := value.Decode(&values); err != nil {
+		return err
+	}
+
+	set := make(stringSet, len(values))
+	for _, v := range values {
+		set[v] = struct{}{}
+	}
+
+	*s = set
+
+	return nil
+}
+
+func (s stringSet) contains(value string) bool {
+	if len(value) == 0 {
+		return false
+	}
+
+	_, ok := s[value]
+
+	return ok
+}
+
+type revocationClaims struct {
+	Issuer    string              `json:"iss"`
+	Subject   string              `json:"sub"`
+	SessionID string              `json:"sid"`
+	ID        string              `json:"jti"`
+	IssuedAt  *oauth2.NumericDate `json:"iat"`
+}
+
+// tokenRevocation checks whether a token has been revoked, either by being listed in a deny list,
+// or by a logout event received via the OpenID Connect back-channel logout. Both, the deny list
+// and the logout events are kept in the cache, so all heimdall instances sharing a (distributed)
+// cache honor them.
+type tokenRevocation struct {
+	denyList    *DenyListConfig
+	denyListKey string
+	logout      *BackchannelLogoutConfig
+}
+
+func newTokenRevocation(conf *RevocationConfig) *tokenRevocation {
+	if conf == nil || (conf.DenyList == nil && conf.BackchannelLogout == nil) {
+		return nil
+	}
+
+	rev := &tokenRevocation{denyList: conf.DenyList, logout: conf.BackchannelLogout}
+
+	if dl := rev.denyList; dl != nil {
+		if dl.RefreshInterval <= 0 {
+			dl.RefreshInterval = defaultDenyListRefreshInterval
+		}
+
+		digest := sha256.New()
+		digest.Write(stringx.ToBytes("jwt_authenticator.deny_list"))
+
+		if dl.Endpoint != nil {
+			if len(dl.Endpoint.Method) == 0 {
+				dl.Endpoint.Method = http.MethodGet
+			}
+
+			digest.Write(dl.Endpoint.Hash())
+		} else {
+			digest.Write(stringx.ToBytes(dl.File))
+		}
+
+		rev.denyListKey = hex.EncodeToString(digest.Sum(nil))
+	}
+
+	if rev.logout != nil && rev.logout.TTL <= 0 {
+		rev.logout.TTL = defaultLogoutEventTTL
+	}
+
+	return rev
+}
+
+// cacheDependency returns what the token revocation relies on the cache for, if anything. The
+// deny list is only cached to reduce the load, but logout events are held in the cache only.
+func (r *tokenRevocation) cacheDependency() string {
+	if r == nil || r.logout == nil {
+		return ""
+	}
+
+	return "back-channel logout"
+}
+
+func (r *tokenRevocation) check(ctx context.Context, rawClaims json.RawMessage) error {
+	if r == nil {
+		return nil
+	}
+
+	var claims revocationClaims
+	if err := json.Unmarshal(rawClaims, &claims); err != nil {
+		return errorchain.NewWithMessage(heimdall.ErrInternal, "failed to unmarshal claims").CausedBy(err)
+	}
+
+	if r.denyList != nil {
+		dl, err := r.loadDenyList(ctx)
+		if err != nil {
+			return err
+		}
+
+		if dl.contains(&claims) {
+			return errTokenRevoked
+		}
+	}
+
+	if r.logout != nil && r.loggedOut(ctx, &claims) {
+		return errTokenRevoked
+	}
+
+	return nil
+}
+
+func (r *tokenRevocation) loadDenyList(ctx context.Context) (*denyList, error) {
+	return cache.GetOrLoad(ctx, cache.Ctx(ctx), r.denyListKey,
+		func(ctx context.Context) (*denyList, time.Duration, error) {
+			dl, err := r.fetchDenyList(ctx)
+
+			return dl, r.denyList.RefreshInterval, err
+		},
+		cache.WithStaleIfError(r.denyList.RefreshInterval))
+}
+
+func (r *tokenRevocation) fetchDenyList(ctx context.Context) (*denyList, error) {
+	var (
+		data []byte
+		err  error
+	)
+
+	if r.denyList.Endpoint != nil {
+		data, err = r.denyList.Endpoint.SendRequest(ctx, nil, nil)
+	} else {
+		data, err = os.ReadFile(r.denyList.File)
+		if err != nil {
+			err = errorchain.NewWithMessage(heimdall.ErrInternal, "failed to read deny list").CausedBy(err)
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var dl denyList
+
+	// yaml is a superset of json, so both formats are supported
+	if err = yaml.Unmarshal(data, &dl); err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrInternal, "failed to parse deny list").CausedBy(err)
+	}
+
+	return &dl, nil
+}
+
+func (r *tokenRevocation) loggedOut(ctx context.Context, claims *revocationClaims) bool {
+	cch := cache.Ctx(ctx)
+
+	for kind, value := range map[string]string{"sid": claims.SessionID, "sub": claims.Subject} {
+		if len(value) == 0 {
+			continue
+		}
+
+		entry, ok := cch.Get(ctx, logoutEventKey(claims.Issuer, kind, value)).(string)
+		if !ok {
+			continue
+		}
+
+		loggedOutAt, err := strconv.ParseInt(entry, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		// tokens issued after the logout are not affected
+		if claims.IssuedAt == nil || claims.IssuedAt.Time().Unix() <= loggedOutAt {
+			return true
+		}
+	}
+
+	return false
+}
+
+// recordLogout validates the claims of an already verified logout token as described in
+// https://openid.net/specs/openid-connect-backchannel-1_0.html#Validation and records the
+// logout event in the cache. The jti of the logout token is recorded as well to reject replays.
+func (r *tokenRevocation) recordLogout(ctx context.Context, rawClaims json.RawMessage) error {
+	var claims struct {
+		revocationClaims
+
+		Events map[string]json.RawMessage `json:"events"`
+		Nonce  *string                    `json:"nonce"`
+	}
+
+	if err := json.Unmarshal(rawClaims, &claims); err != nil {
+		return errorchain.NewWithMessage(errInvalidLogoutToken, "failed to unmarshal claims").CausedBy(err)
+	}
+
+	if _, ok := claims.Events[backchannelLogoutEvent]; !ok {
+		return errorchain.NewWithMessage(errInvalidLogoutToken, "no back-channel logout event present")
+	}
+
+	if claims.Nonce != nil {
+		return errorchain.NewWithMessage(errInvalidLogoutToken, "nonce claim is not allowed")
+	}
+
+	if claims.IssuedAt == nil {
+		return errorchain.NewWithMessage(errInvalidLogoutToken, "iat claim is missing")
+	}
+
+	// if a session id is present, only that session is affected, otherwise all sessions of the subject
+	kind, value := "sid", claims.SessionID
+	if len(value) == 0 {
+		kind, value = "sub", claims.Subject
+	}
+
+	if len(value) == 0 {
+		return errorchain.NewWithMessage(errInvalidLogoutToken, "neither sub nor sid claim present")
+	}
+
+	if len(claims.ID) == 0 {
+		return errorchain.NewWithMessage(errInvalidLogoutToken, "jti claim is missing")
+	}
+
+	cch := cache.Ctx(ctx)
+
+	stored, err := cch.SetIfAbsent(ctx, logoutEventKey(claims.Issuer, "jti", claims.ID), claims.ID, r.logout.TTL)
+	if err != nil {
+		return errorchain.NewWithMessage(heimdall.ErrInternal, "failed to check logout token for replay").
+			CausedBy(err)
+	}
+
+	if !stored {
+		return errorchain.NewWithMessage(errInvalidLogoutToken, "logout token has already been used")
+	}
+
+	cch.Set(ctx, logoutEventKey(claims.Issuer, kind, value),
+		strconv.FormatInt(time.Now().Unix(), 10), r.logout.TTL)
+
+	return nil
+}
+
+func logoutEventKey(issuer, kind, value string) string {
+	digest := sha256.New()
+	digest.Write(stringx.ToBytes("jwt_authenticator.logout_event"))
+	digest.Write(stringx.ToBytes(issuer))
+	digest.Write(stringx.ToBytes(kind))
+	digest.Write(stringx.ToBytes(value))
+
+	return hex.EncodeToString(digest.Sum(nil))
+}
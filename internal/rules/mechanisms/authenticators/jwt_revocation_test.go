@@ -0,0 +1,523 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package authenticators
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/cache"
+	"github.com/dadrus/heimdall/internal/cache/encoding"
+	"github.com/dadrus/heimdall/internal/cache/memory"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	heimdallmocks "github.com/dadrus/heimdall/internal/heimdall/mocks"
+	"github.com/dadrus/heimdall/internal/keystore"
+	"github.com/dadrus/heimdall/internal/rules/endpoint"
+	mocks2 "github.com/dadrus/heimdall/internal/rules/mechanisms/authenticators/extractors/mocks"
+	"github.com/dadrus/heimdall/internal/x/testsupport"
+)
+
+func TestNewTokenRevocation(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc     string
+		config *RevocationConfig
+		assert func(t *testing.T, rev *tokenRevocation)
+	}{
+		{
+			uc: "without configuration",
+			assert: func(t *testing.T, rev *tokenRevocation) {
+				t.Helper()
+
+				assert.Nil(t, rev)
+			},
+		},
+		{
+			uc:     "with empty configuration",
+			config: &RevocationConfig{},
+			assert: func(t *testing.T, rev *tokenRevocation) {
+				t.Helper()
+
+				assert.Nil(t, rev)
+			},
+		},
+		{
+			uc: "with deny list endpoint and back-channel logout using defaults",
+			config: &RevocationConfig{
+				DenyList:          &DenyListConfig{Endpoint: &endpoint.Endpoint{URL: "http://deny.list"}},
+				BackchannelLogout: &BackchannelLogoutConfig{Audience: []string{"foo"}},
+			},
+			assert: func(t *testing.T, rev *tokenRevocation) {
+				t.Helper()
+
+				require.NotNil(t, rev)
+				assert.Equal(t, defaultDenyListRefreshInterval, rev.denyList.RefreshInterval)
+				assert.Equal(t, http.MethodGet, rev.denyList.Endpoint.Method)
+				assert.NotEmpty(t, rev.denyListKey)
+				assert.Equal(t, defaultLogoutEventTTL, rev.logout.TTL)
+			},
+		},
+		{
+			uc: "with deny list file and configured refresh interval",
+			config: &RevocationConfig{
+				DenyList: &DenyListConfig{File: "/foo/bar", RefreshInterval: 10 * time.Second},
+			},
+			assert: func(t *testing.T, rev *tokenRevocation) {
+				t.Helper()
+
+				require.NotNil(t, rev)
+				assert.Equal(t, 10*time.Second, rev.denyList.RefreshInterval)
+				assert.NotEmpty(t, rev.denyListKey)
+				assert.Nil(t, rev.logout)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			tc.assert(t, newTokenRevocation(tc.config))
+		})
+	}
+}
+
+func TestTokenRevocationCheckWithDenyList(t *testing.T) {
+	t.Parallel()
+
+	denyListFile := filepath.Join(t.TempDir(), "deny-list.yaml")
+	err := os.WriteFile(denyListFile, []byte(`
+jti: [ revoked-jti ]
+sub: [ revoked-sub ]
+sid: [ revoked-sid ]
+`), 0o600)
+	require.NoError(t, err)
+
+	var (
+		requestCount int
+		statusCode   int
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		requestCount++
+
+		assert.Equal(t, http.MethodGet, req.Method)
+
+		if statusCode != http.StatusOK {
+			rw.WriteHeader(statusCode)
+
+			return
+		}
+
+		rw.Header().Set("Content-Type", "application/json")
+		_, err := rw.Write([]byte(`{"jti": ["revoked-jti"]}`))
+		assert.NoError(t, err)
+	}))
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		uc         string
+		denyList   *DenyListConfig
+		statusCode int
+		claims     string
+		assert     func(t *testing.T, err error)
+	}{
+		{
+			uc:       "token not listed in deny list file",
+			denyList: &DenyListConfig{File: denyListFile},
+			claims:   `{"jti": "foo", "sub": "bar", "sid": "baz"}`,
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.NoError(t, err)
+			},
+		},
+		{
+			uc:       "jti listed in deny list file",
+			denyList: &DenyListConfig{File: denyListFile},
+			claims:   `{"jti": "revoked-jti", "sub": "bar"}`,
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.ErrorIs(t, err, errTokenRevoked)
+			},
+		},
+		{
+			uc:       "sub listed in deny list file",
+			denyList: &DenyListConfig{File: denyListFile},
+			claims:   `{"jti": "foo", "sub": "revoked-sub"}`,
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.ErrorIs(t, err, errTokenRevoked)
+			},
+		},
+		{
+			uc:       "sid listed in deny list file",
+			denyList: &DenyListConfig{File: denyListFile},
+			claims:   `{"jti": "foo", "sub": "bar", "sid": "revoked-sid"}`,
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.ErrorIs(t, err, errTokenRevoked)
+			},
+		},
+		{
+			uc:       "not existing deny list file",
+			denyList: &DenyListConfig{File: filepath.Join(t.TempDir(), "missing.yaml")},
+			claims:   `{"jti": "foo"}`,
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.ErrorIs(t, err, heimdall.ErrInternal)
+				require.ErrorContains(t, err, "failed to read deny list")
+			},
+		},
+		{
+			uc:         "jti listed in deny list served by an endpoint",
+			denyList:   &DenyListConfig{Endpoint: &endpoint.Endpoint{URL: srv.URL}},
+			statusCode: http.StatusOK,
+			claims:     `{"jti": "revoked-jti"}`,
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.ErrorIs(t, err, errTokenRevoked)
+			},
+		},
+		{
+			uc:         "deny list endpoint responds with an error",
+			denyList:   &DenyListConfig{Endpoint: &endpoint.Endpoint{URL: srv.URL}},
+			statusCode: http.StatusInternalServerError,
+			claims:     `{"jti": "foo"}`,
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.ErrorIs(t, err, heimdall.ErrCommunication)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			requestCount = 0
+			statusCode = tc.statusCode
+			rev := newTokenRevocation(&RevocationConfig{DenyList: tc.denyList})
+			ctx := cache.WithContext(context.Background(), memory.New())
+
+			// WHEN
+			err := rev.check(ctx, json.RawMessage(tc.claims))
+
+			// THEN
+			tc.assert(t, err)
+
+			if tc.denyList.Endpoint != nil && tc.statusCode == http.StatusOK {
+				// the deny list is served from the cache
+				tc.assert(t, rev.check(ctx, json.RawMessage(tc.claims)))
+				assert.Equal(t, 1, requestCount)
+			}
+		})
+	}
+}
+
+func TestDenyListEncoding(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	r := &tokenRevocation{denyList: &DenyListConfig{File: filepath.Join(t.TempDir(), "deny-list.yaml")}}
+	err := os.WriteFile(r.denyList.File, []byte(`{"jti": ["foo", "bar"], "sub": ["baz"]}`), 0o600)
+	require.NoError(t, err)
+
+	dl, err := r.fetchDenyList(context.Background())
+	require.NoError(t, err)
+
+	// WHEN
+	data, err := encoding.Marshal(dl)
+	require.NoError(t, err)
+
+	value, err := encoding.Unmarshal(data)
+
+	// THEN
+	require.NoError(t, err)
+	assert.Equal(t, dl, value)
+
+	restored, ok := value.(*denyList)
+	require.True(t, ok)
+	assert.True(t, restored.contains(&revocationClaims{ID: "bar"}))
+	assert.True(t, restored.contains(&revocationClaims{ID: "qux", Subject: "baz"}))
+	assert.False(t, restored.contains(&revocationClaims{ID: "qux", Subject: "foo"}))
+	assert.False(t, restored.contains(&revocationClaims{}))
+}
+
+func TestTokenRevocationRecordLogout(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now().Unix()
+
+	for _, tc := range []struct {
+		uc     string
+		claims string
+		assert func(t *testing.T, err error, rev *tokenRevocation, ctx context.Context)
+	}{
+		{
+			uc:     "without back-channel logout event",
+			claims: fmt.Sprintf(`{"iss": "foo", "sub": "bar", "iat": %d, "events": {}}`, now),
+			assert: func(t *testing.T, err error, _ *tokenRevocation, _ context.Context) {
+				t.Helper()
+
+				require.ErrorIs(t, err, errInvalidLogoutToken)
+				require.ErrorContains(t, err, "no back-channel logout event")
+			},
+		},
+		{
+			uc: "with nonce",
+			claims: fmt.Sprintf(`{"iss": "foo", "sub": "bar", "iat": %d, "nonce": "baz", "events": {"%s": {}}}`,
+				now, backchannelLogoutEvent),
+			assert: func(t *testing.T, err error, _ *tokenRevocation, _ context.Context) {
+				t.Helper()
+
+				require.ErrorIs(t, err, errInvalidLogoutToken)
+				require.ErrorContains(t, err, "nonce")
+			},
+		},
+		{
+			uc:     "without iat",
+			claims: fmt.Sprintf(`{"iss": "foo", "sub": "bar", "events": {"%s": {}}}`, backchannelLogoutEvent),
+			assert: func(t *testing.T, err error, _ *tokenRevocation, _ context.Context) {
+				t.Helper()
+
+				require.ErrorIs(t, err, errInvalidLogoutToken)
+				require.ErrorContains(t, err, "iat")
+			},
+		},
+		{
+			uc:     "without sub and sid",
+			claims: fmt.Sprintf(`{"iss": "foo", "iat": %d, "events": {"%s": {}}}`, now, backchannelLogoutEvent),
+			assert: func(t *testing.T, err error, _ *tokenRevocation, _ context.Context) {
+				t.Helper()
+
+				require.ErrorIs(t, err, errInvalidLogoutToken)
+				require.ErrorContains(t, err, "neither sub nor sid")
+			},
+		},
+		{
+			uc: "logout of all sessions of a subject",
+			claims: fmt.Sprintf(`{"iss": "foo", "sub": "bar", "jti": "qux", "iat": %d, "events": {"%s": {}}}`,
+				now, backchannelLogoutEvent),
+			assert: func(t *testing.T, err error, rev *tokenRevocation, ctx context.Context) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				// tokens of the subject issued before the logout are revoked
+				require.ErrorIs(t, rev.check(ctx, json.RawMessage(
+					fmt.Sprintf(`{"iss": "foo", "sub": "bar", "sid": "baz", "iat": %d}`, now-10))), errTokenRevoked)
+				require.ErrorIs(t, rev.check(ctx, json.RawMessage(
+					`{"iss": "foo", "sub": "bar"}`)), errTokenRevoked)
+
+				// tokens issued after the logout, of other subjects or by other issuers are not affected
+				require.NoError(t, rev.check(ctx, json.RawMessage(
+					fmt.Sprintf(`{"iss": "foo", "sub": "bar", "iat": %d}`, now+10))))
+				require.NoError(t, rev.check(ctx, json.RawMessage(
+					fmt.Sprintf(`{"iss": "foo", "sub": "baz", "iat": %d}`, now-10))))
+				require.NoError(t, rev.check(ctx, json.RawMessage(
+					fmt.Sprintf(`{"iss": "bar", "sub": "bar", "iat": %d}`, now-10))))
+			},
+		},
+		{
+			uc: "logout of a particular session",
+			claims: fmt.Sprintf(`{"iss": "foo", "sub": "bar", "sid": "baz", "jti": "qux", "iat": %d, "events": {"%s": {}}}`,
+				now, backchannelLogoutEvent),
+			assert: func(t *testing.T, err error, rev *tokenRevocation, ctx context.Context) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				require.ErrorIs(t, rev.check(ctx, json.RawMessage(
+					fmt.Sprintf(`{"iss": "foo", "sub": "bar", "sid": "baz", "iat": %d}`, now-10))), errTokenRevoked)
+
+				// other sessions of the subject are not affected
+				require.NoError(t, rev.check(ctx, json.RawMessage(
+					fmt.Sprintf(`{"iss": "foo", "sub": "bar", "sid": "qux", "iat": %d}`, now-10))))
+			},
+		},
+		{
+			uc:     "without jti",
+			claims: fmt.Sprintf(`{"iss": "foo", "sub": "bar", "iat": %d, "events": {"%s": {}}}`, now, backchannelLogoutEvent),
+			assert: func(t *testing.T, err error, _ *tokenRevocation, _ context.Context) {
+				t.Helper()
+
+				require.ErrorIs(t, err, errInvalidLogoutToken)
+				require.ErrorContains(t, err, "jti")
+			},
+		},
+		{
+			uc: "replayed logout token",
+			claims: fmt.Sprintf(`{"iss": "foo", "sub": "bar", "jti": "qux", "iat": %d, "events": {"%s": {}}}`,
+				now, backchannelLogoutEvent),
+			assert: func(t *testing.T, err error, rev *tokenRevocation, ctx context.Context) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				err = rev.recordLogout(ctx, json.RawMessage(fmt.Sprintf(
+					`{"iss": "foo", "sub": "baz", "jti": "qux", "iat": %d, "events": {"%s": {}}}`,
+					now, backchannelLogoutEvent)))
+				require.ErrorIs(t, err, errInvalidLogoutToken)
+				require.ErrorContains(t, err, "already been used")
+
+				// the replayed token has no effect
+				require.NoError(t, rev.check(ctx, json.RawMessage(
+					fmt.Sprintf(`{"iss": "foo", "sub": "baz", "iat": %d}`, now-10))))
+
+				// the same jti of another issuer is not a replay
+				require.NoError(t, rev.recordLogout(ctx, json.RawMessage(fmt.Sprintf(
+					`{"iss": "bar", "sub": "bar", "jti": "qux", "iat": %d, "events": {"%s": {}}}`,
+					now, backchannelLogoutEvent))))
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			rev := newTokenRevocation(&RevocationConfig{
+				BackchannelLogout: &BackchannelLogoutConfig{Audience: []string{"foo"}},
+			})
+			ctx := cache.WithContext(context.Background(), memory.New())
+
+			// WHEN
+			err := rev.recordLogout(ctx, json.RawMessage(tc.claims))
+
+			// THEN
+			tc.assert(t, err, rev, ctx)
+		})
+	}
+}
+
+func createLogoutToken(t *testing.T, keyEntry *keystore.Entry, claims map[string]any) string {
+	t.Helper()
+
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: keyEntry.JOSEAlgorithm(), Key: keyEntry.PrivateKey},
+		(&jose.SignerOptions{}).WithType("logout+jwt").WithHeader("kid", keyEntry.KeyID))
+	require.NoError(t, err)
+
+	rawJWT, err := jwt.Signed(signer).Claims(claims).CompactSerialize()
+	require.NoError(t, err)
+
+	return rawJWT
+}
+
+func TestJwtAuthenticatorHandleLogoutToken(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	ks := createKS(t)
+	keyEntry, err := ks.GetKey(kidKeyWithoutCert)
+	require.NoError(t, err)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		jwks, err := json.Marshal(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{keyEntry.JWK()}})
+		assert.NoError(t, err)
+
+		rw.Header().Set("Content-Type", "application/json")
+		_, err = rw.Write(jwks)
+		assert.NoError(t, err)
+	}))
+	defer srv.Close()
+
+	conf, err := testsupport.DecodeTestConfig([]byte(fmt.Sprintf(`
+jwks_endpoint:
+  url: %s
+assertions:
+  issuers:
+    - foobar
+  allowed_algorithms:
+    - ES384
+revocation:
+  backchannel_logout:
+    audience:
+      - client-1
+`, srv.URL)))
+	require.NoError(t, err)
+
+	auth, err := newJwtAuthenticator("auth1", conf)
+	require.NoError(t, err)
+	require.Equal(t, auth, auth.LogoutTokenHandler())
+	require.Equal(t, "back-channel logout", auth.CacheDependency())
+
+	appCtx := cache.WithContext(context.Background(), memory.New())
+
+	authenticate := func(t *testing.T, token string) error {
+		t.Helper()
+
+		ads := mocks2.NewAuthDataExtractStrategyMock(t)
+		ctx := heimdallmocks.NewContextMock(t)
+		ctx.EXPECT().AppContext().Return(appCtx)
+		ads.EXPECT().GetAuthData(ctx).Return(token, nil)
+
+		configured := *auth
+		configured.ads = ads
+
+		_, err := configured.Execute(ctx)
+
+		return err
+	}
+
+	accessToken := createJWT(t, keyEntry, "foo", "foobar", "bar", true)
+	logoutClaims := func(aud, iss string) map[string]any {
+		return map[string]any{
+			"iss":    iss,
+			"aud":    aud,
+			"sub":    "foo",
+			"iat":    time.Now().Unix(),
+			"jti":    "logout-1",
+			"events": map[string]any{backchannelLogoutEvent: map[string]any{}},
+		}
+	}
+
+	// WHEN & THEN
+	require.NoError(t, authenticate(t, accessToken))
+
+	err = auth.HandleLogoutToken(appCtx, "foo.bar.baz")
+	require.ErrorIs(t, err, errInvalidLogoutToken)
+
+	err = auth.HandleLogoutToken(appCtx, createLogoutToken(t, keyEntry, logoutClaims("client-2", "foobar")))
+	require.ErrorIs(t, err, heimdall.ErrAuthentication)
+	require.NoError(t, authenticate(t, accessToken))
+
+	err = auth.HandleLogoutToken(appCtx, createLogoutToken(t, keyEntry, logoutClaims("client-1", "barfoo")))
+	require.ErrorIs(t, err, heimdall.ErrAuthentication)
+	require.NoError(t, authenticate(t, accessToken))
+
+	logoutToken := createLogoutToken(t, keyEntry, logoutClaims("client-1", "foobar"))
+	err = auth.HandleLogoutToken(appCtx, logoutToken)
+	require.NoError(t, err)
+
+	err = auth.HandleLogoutToken(appCtx, logoutToken)
+	require.ErrorIs(t, err, errInvalidLogoutToken)
+
+	err = authenticate(t, accessToken)
+	require.ErrorIs(t, err, heimdall.ErrAuthentication)
+	require.ErrorIs(t, err, errTokenRevoked)
+}
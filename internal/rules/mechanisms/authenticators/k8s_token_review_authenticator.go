@@ -0,0 +1,320 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package authenticators
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"time"
+
+	"github.com/go-jose/go-jose/v3/jwt"
+	"github.com/goccy/go-json"
+	"github.com/rs/zerolog"
+	authv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	authv1client "k8s.io/client-go/kubernetes/typed/authentication/v1"
+	"k8s.io/client-go/rest"
+
+	"github.com/dadrus/heimdall/internal/cache"
+	"github.com/dadrus/heimdall/internal/cache/encoding"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/rules/mechanisms/authenticators/extractors"
+	"github.com/dadrus/heimdall/internal/rules/mechanisms/subject"
+	"github.com/dadrus/heimdall/internal/x"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+	"github.com/dadrus/heimdall/internal/x/stringx"
+)
+
+const (
+	defaultK8sTokenReviewTTL = 1 * time.Minute
+	// rejected tokens are cached for a short time only to protect the API server
+	// from clients retrying with the same invalid token.
+	k8sTokenReviewRejectionTTL = 10 * time.Second
+)
+
+// by intention. Used only during application bootstrap
+//
+//nolint:gochecknoinits
+func init() {
+	registerTypeFactory(
+		func(id string, typ string, conf map[string]any) (bool, Authenticator, error) {
+			if typ != AuthenticatorK8sTokenReview {
+				return false, nil, nil
+			}
+
+			auth, err := newK8sTokenReviewAuthenticator(id, conf, rest.InClusterConfig)
+
+			return true, auth, err
+		})
+
+	encoding.RegisterType[*tokenReviewResult]("k8s_token_review_authenticator.result")
+}
+
+// tokenReviewResult is the outcome of a token review. Either the Payload with the user
+// information is set, if the token has been accepted, or the Rejection with the reason why not.
+type tokenReviewResult struct {
+	Payload   []byte `json:"payload,omitempty"`
+	Rejection string `json:"rejection,omitempty"`
+}
+
+// k8sConfigFactory is the same factory the kubernetes rule provider uses to
+// connect to the cluster, heimdall is running in.
+type k8sConfigFactory func() (*rest.Config, error)
+
+type k8sTokenReviewAuthenticator struct {
+	id                   string
+	reviewer             authv1client.TokenReviewInterface
+	audiences            []string
+	ads                  extractors.AuthDataExtractStrategy
+	sf                   SubjectFactory
+	ttl                  time.Duration
+	allowFallbackOnError bool
+}
+
+func newK8sTokenReviewAuthenticator(
+	id string, rawConfig map[string]any, k8sCF k8sConfigFactory,
+) (*k8sTokenReviewAuthenticator, error) {
+	type Config struct {
+		Audiences            []string                            `mapstructure:"audiences"               validate:"required,gt=0"` //nolint:lll,tagalign
+		AuthDataSource       extractors.CompositeExtractStrategy `mapstructure:"token_source"`
+		SubjectInfo          SubjectInfo                         `mapstructure:"subject"                 validate:"-"`
+		CacheTTL             *time.Duration                      `mapstructure:"cache_ttl"`
+		AllowFallbackOnError bool                                `mapstructure:"allow_fallback_on_error"`
+	}
+
+	var conf Config
+	if err := decodeConfig(AuthenticatorK8sTokenReview, rawConfig, &conf); err != nil {
+		return nil, err
+	}
+
+	k8sConf, err := k8sCF()
+	if err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"failed to load kubernetes client configuration").CausedBy(err)
+	}
+
+	client, err := authv1client.NewForConfig(k8sConf)
+	if err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"failed creating client for connecting to kubernetes cluster").CausedBy(err)
+	}
+
+	if len(conf.SubjectInfo.IDFrom) == 0 {
+		conf.SubjectInfo.IDFrom = "username"
+	}
+
+	ads := x.IfThenElseExec(conf.AuthDataSource == nil,
+		func() extractors.CompositeExtractStrategy {
+			return extractors.CompositeExtractStrategy{
+				extractors.HeaderValueExtractStrategy{Name: "Authorization", Scheme: "Bearer"},
+			}
+		},
+		func() extractors.CompositeExtractStrategy { return conf.AuthDataSource },
+	)
+
+	return &k8sTokenReviewAuthenticator{
+		id:        id,
+		reviewer:  client.TokenReviews(),
+		audiences: conf.Audiences,
+		ads:       ads,
+		sf:        &conf.SubjectInfo,
+		ttl: x.IfThenElseExec(conf.CacheTTL != nil,
+			func() time.Duration { return *conf.CacheTTL },
+			func() time.Duration { return defaultK8sTokenReviewTTL }),
+		allowFallbackOnError: conf.AllowFallbackOnError,
+	}, nil
+}
+
+func (a *k8sTokenReviewAuthenticator) Execute(ctx heimdall.Context) (*subject.Subject, error) {
+	logger := zerolog.Ctx(ctx.AppContext())
+	logger.Debug().Str("_id", a.id).Msg("Authenticating using kubernetes token review authenticator")
+
+	token, err := a.ads.GetAuthData(ctx)
+	if err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrAuthentication, "no service account token present").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	payload, err := a.getSubjectInformation(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	sub, err := a.sf.CreateSubject(payload)
+	if err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrInternal, "failed to extract subject information from token review").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	return sub, nil
+}
+
+func (a *k8sTokenReviewAuthenticator) WithConfig(config map[string]any) (Authenticator, error) {
+	// this authenticator allows ttl and the fallback behavior to be redefined on the rule level
+	if len(config) == 0 {
+		return a, nil
+	}
+
+	type Config struct {
+		CacheTTL             *time.Duration `mapstructure:"cache_ttl"`
+		AllowFallbackOnError *bool          `mapstructure:"allow_fallback_on_error"`
+	}
+
+	var conf Config
+	if err := decodeConfig(AuthenticatorK8sTokenReview, config, &conf); err != nil {
+		return nil, err
+	}
+
+	return &k8sTokenReviewAuthenticator{
+		id:        a.id,
+		reviewer:  a.reviewer,
+		audiences: a.audiences,
+		ads:       a.ads,
+		sf:        a.sf,
+		ttl: x.IfThenElseExec(conf.CacheTTL != nil,
+			func() time.Duration { return *conf.CacheTTL },
+			func() time.Duration { return a.ttl }),
+		allowFallbackOnError: x.IfThenElseExec(conf.AllowFallbackOnError != nil,
+			func() bool { return *conf.AllowFallbackOnError },
+			func() bool { return a.allowFallbackOnError }),
+	}, nil
+}
+
+func (a *k8sTokenReviewAuthenticator) IsFallbackOnErrorAllowed() bool {
+	return a.allowFallbackOnError
+}
+
+func (a *k8sTokenReviewAuthenticator) ID() string {
+	return a.id
+}
+
+func (a *k8sTokenReviewAuthenticator) getSubjectInformation(ctx heimdall.Context, token string) ([]byte, error) {
+	load := func(loadCtx context.Context) (*tokenReviewResult, time.Duration, error) {
+		result, err := a.reviewToken(loadCtx, token)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		if len(result.Rejection) != 0 {
+			return result, min(a.ttl, k8sTokenReviewRejectionTTL), nil
+		}
+
+		return result, a.getCacheTTL(token), nil
+	}
+
+	var (
+		result *tokenReviewResult
+		err    error
+	)
+
+	if a.ttl <= 0 {
+		result, _, err = load(ctx.AppContext())
+	} else {
+		result, err = cache.GetOrLoad(ctx.AppContext(), cache.Ctx(ctx.AppContext()), a.calculateCacheKey(token), load)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result.Rejection) != 0 {
+		return nil, errorchain.NewWithMessage(heimdall.ErrAuthentication, result.Rejection).WithErrorContext(a)
+	}
+
+	return result.Payload, nil
+}
+
+func (a *k8sTokenReviewAuthenticator) reviewToken(ctx context.Context, token string) (*tokenReviewResult, error) {
+	review, err := a.reviewer.Create(ctx, &authv1.TokenReview{
+		Spec: authv1.TokenReviewSpec{Token: token, Audiences: a.audiences},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrCommunication, "token review request failed").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	status := review.Status
+	if !status.Authenticated {
+		return &tokenReviewResult{Rejection: "token is not valid: " + status.Error}, nil
+	}
+
+	// as recommended by the TokenReview API, it is verified the API server is audience aware
+	// and has not just validated the token against its own audience
+	if !slices.ContainsFunc(status.Audiences, func(aud string) bool { return slices.Contains(a.audiences, aud) }) {
+		return &tokenReviewResult{Rejection: "token is not issued for any of the expected audiences"}, nil
+	}
+
+	extra := make(map[string][]string, len(status.User.Extra))
+	for key, values := range status.User.Extra {
+		extra[key] = values
+	}
+
+	payload, err := json.Marshal(map[string]any{
+		"username":  status.User.Username,
+		"uid":       status.User.UID,
+		"groups":    x.IfThenElse(status.User.Groups != nil, status.User.Groups, []string{}),
+		"extra":     extra,
+		"audiences": status.Audiences,
+	})
+	if err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrInternal, "failed to marshal token review result").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	return &tokenReviewResult{Payload: payload}, nil
+}
+
+func (a *k8sTokenReviewAuthenticator) getCacheTTL(token string) time.Duration {
+	// service account tokens are JWTs. The token has already been verified by the API server,
+	// so its expiration can be used to ensure the result is not cached longer than the token is valid.
+	parsed, err := jwt.ParseSigned(token)
+	if err != nil {
+		return a.ttl
+	}
+
+	var claims jwt.Claims
+	if err = parsed.UnsafeClaimsWithoutVerification(&claims); err != nil || claims.Expiry == nil {
+		return a.ttl
+	}
+
+	expiresIn := time.Until(claims.Expiry.Time())
+
+	return x.IfThenElse(expiresIn > 0, min(a.ttl, expiresIn), 0)
+}
+
+func (a *k8sTokenReviewAuthenticator) calculateCacheKey(token string) string {
+	digest := sha256.New()
+	digest.Write(stringx.ToBytes(AuthenticatorK8sTokenReview))
+
+	for _, aud := range a.audiences {
+		digest.Write(stringx.ToBytes(aud))
+	}
+
+	digest.Write(stringx.ToBytes(token))
+
+	return hex.EncodeToString(digest.Sum(nil))
+}
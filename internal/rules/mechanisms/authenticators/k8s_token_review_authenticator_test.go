@@ -0,0 +1,471 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package authenticators
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authv1 "k8s.io/api/authentication/v1"
+	"k8s.io/client-go/rest"
+
+	"github.com/dadrus/heimdall/internal/cache"
+	"github.com/dadrus/heimdall/internal/cache/memory"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/heimdall/mocks"
+	"github.com/dadrus/heimdall/internal/rules/mechanisms/authenticators/extractors"
+	"github.com/dadrus/heimdall/internal/rules/mechanisms/subject"
+	"github.com/dadrus/heimdall/internal/x"
+	"github.com/dadrus/heimdall/internal/x/testsupport"
+)
+
+func k8sTestConfigFactory(host string) k8sConfigFactory {
+	return func() (*rest.Config, error) { return &rest.Config{Host: host}, nil }
+}
+
+func createServiceAccountToken(t *testing.T, expiresIn time.Duration) string {
+	t.Helper()
+
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.ES256, Key: privKey}, nil)
+	require.NoError(t, err)
+
+	token, err := jwt.Signed(signer).Claims(jwt.Claims{
+		Subject:  "system:serviceaccount:foo:bar",
+		Audience: jwt.Audience{"heimdall"},
+		Expiry:   jwt.NewNumericDate(time.Now().Add(expiresIn)),
+	}).CompactSerialize()
+	require.NoError(t, err)
+
+	return token
+}
+
+func TestCreateK8sTokenReviewAuthenticator(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc     string
+		config []byte
+		k8sCF  k8sConfigFactory
+		assert func(t *testing.T, err error, auth *k8sTokenReviewAuthenticator)
+	}{
+		{
+			uc: "without audiences",
+			assert: func(t *testing.T, err error, _ *k8sTokenReviewAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				require.ErrorContains(t, err, "'audiences' is a required field")
+			},
+		},
+		{
+			uc:     "with unsupported properties",
+			config: []byte(`{ audiences: [ heimdall ], foo: bar }`),
+			assert: func(t *testing.T, err error, _ *k8sTokenReviewAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				require.ErrorContains(t, err, "invalid keys: foo")
+			},
+		},
+		{
+			uc:     "outside of a kubernetes cluster",
+			config: []byte(`audiences: [ heimdall ]`),
+			k8sCF:  func() (*rest.Config, error) { return nil, rest.ErrNotInCluster },
+			assert: func(t *testing.T, err error, _ *k8sTokenReviewAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				require.ErrorIs(t, err, rest.ErrNotInCluster)
+			},
+		},
+		{
+			uc:     "with minimal valid configuration",
+			config: []byte(`audiences: [ heimdall ]`),
+			assert: func(t *testing.T, err error, auth *k8sTokenReviewAuthenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "auth1", auth.ID())
+				assert.NotNil(t, auth.reviewer)
+				assert.Equal(t, []string{"heimdall"}, auth.audiences)
+				assert.Equal(t, extractors.CompositeExtractStrategy{
+					extractors.HeaderValueExtractStrategy{Name: "Authorization", Scheme: "Bearer"},
+				}, auth.ads)
+				assert.Equal(t, &SubjectInfo{IDFrom: "username"}, auth.sf)
+				assert.Equal(t, defaultK8sTokenReviewTTL, auth.ttl)
+				assert.False(t, auth.IsFallbackOnErrorAllowed())
+			},
+		},
+		{
+			uc: "with full configuration",
+			config: []byte(`
+audiences: [ heimdall, foo ]
+token_source:
+  - header: X-Service-Token
+subject:
+  id: uid
+cache_ttl: 5m
+allow_fallback_on_error: true
+`),
+			assert: func(t *testing.T, err error, auth *k8sTokenReviewAuthenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, []string{"heimdall", "foo"}, auth.audiences)
+				assert.Equal(t, extractors.CompositeExtractStrategy{
+					&extractors.HeaderValueExtractStrategy{Name: "X-Service-Token"},
+				}, auth.ads)
+				assert.Equal(t, &SubjectInfo{IDFrom: "uid"}, auth.sf)
+				assert.Equal(t, 5*time.Minute, auth.ttl)
+				assert.True(t, auth.IsFallbackOnErrorAllowed())
+			},
+		},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			conf, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			k8sCF := tc.k8sCF
+			if k8sCF == nil {
+				k8sCF = k8sTestConfigFactory("https://kubernetes.default.svc")
+			}
+
+			// WHEN
+			auth, err := newK8sTokenReviewAuthenticator("auth1", conf, k8sCF)
+
+			// THEN
+			tc.assert(t, err, auth)
+		})
+	}
+}
+
+func TestCreateK8sTokenReviewAuthenticatorFromPrototypeConfig(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc     string
+		config []byte
+		assert func(t *testing.T, err error, prototype, configured *k8sTokenReviewAuthenticator)
+	}{
+		{
+			uc: "without target config",
+			assert: func(t *testing.T, err error, prototype, configured *k8sTokenReviewAuthenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, prototype, configured)
+			},
+		},
+		{
+			uc:     "with cache ttl and fallback redefined",
+			config: []byte(`{ cache_ttl: 0s, allow_fallback_on_error: true }`),
+			assert: func(t *testing.T, err error, prototype, configured *k8sTokenReviewAuthenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.NotEqual(t, prototype, configured)
+				assert.Equal(t, prototype.ID(), configured.ID())
+				assert.Equal(t, prototype.reviewer, configured.reviewer)
+				assert.Equal(t, prototype.audiences, configured.audiences)
+				assert.Equal(t, prototype.ads, configured.ads)
+				assert.Equal(t, prototype.sf, configured.sf)
+				assert.Equal(t, defaultK8sTokenReviewTTL, prototype.ttl)
+				assert.Equal(t, time.Duration(0), configured.ttl)
+				assert.False(t, prototype.IsFallbackOnErrorAllowed())
+				assert.True(t, configured.IsFallbackOnErrorAllowed())
+			},
+		},
+		{
+			uc:     "with audiences redefined",
+			config: []byte(`audiences: [ foo ]`),
+			assert: func(t *testing.T, err error, _, _ *k8sTokenReviewAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				require.ErrorContains(t, err, "invalid keys: audiences")
+			},
+		},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			conf, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			prototype, err := newK8sTokenReviewAuthenticator("auth1",
+				map[string]any{"audiences": []string{"heimdall"}},
+				k8sTestConfigFactory("https://kubernetes.default.svc"))
+			require.NoError(t, err)
+
+			// WHEN
+			auth, err := prototype.WithConfig(conf)
+
+			// THEN
+			var (
+				configured *k8sTokenReviewAuthenticator
+				ok         bool
+			)
+
+			if err == nil {
+				configured, ok = auth.(*k8sTokenReviewAuthenticator)
+				require.True(t, ok)
+			}
+
+			tc.assert(t, err, prototype, configured)
+		})
+	}
+}
+
+func TestK8sTokenReviewAuthenticatorExecute(t *testing.T) {
+	t.Parallel()
+
+	token := createServiceAccountToken(t, time.Hour)
+
+	for _, tc := range []struct {
+		uc           string
+		token        string
+		cacheEnabled bool
+		reviewToken  func(t *testing.T, review *authv1.TokenReview) (int, *authv1.TokenReviewStatus)
+		assert       func(t *testing.T, err error, sub *subject.Subject, reviews int)
+	}{
+		{
+			uc: "no token present",
+			assert: func(t *testing.T, err error, _ *subject.Subject, reviews int) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrAuthentication)
+				require.ErrorContains(t, err, "no service account token present")
+				assert.Zero(t, reviews)
+			},
+		},
+		{
+			uc:    "token review request fails",
+			token: token,
+			reviewToken: func(t *testing.T, _ *authv1.TokenReview) (int, *authv1.TokenReviewStatus) {
+				t.Helper()
+
+				return http.StatusForbidden, nil
+			},
+			assert: func(t *testing.T, err error, _ *subject.Subject, reviews int) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrCommunication)
+				require.ErrorContains(t, err, "token review request failed")
+				assert.Equal(t, 1, reviews)
+			},
+		},
+		{
+			uc:    "token not authenticated",
+			token: token,
+			reviewToken: func(t *testing.T, _ *authv1.TokenReview) (int, *authv1.TokenReviewStatus) {
+				t.Helper()
+
+				return http.StatusCreated, &authv1.TokenReviewStatus{Error: "token expired"}
+			},
+			assert: func(t *testing.T, err error, _ *subject.Subject, reviews int) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrAuthentication)
+				require.ErrorContains(t, err, "token is not valid: token expired")
+				assert.Equal(t, 1, reviews)
+			},
+		},
+		{
+			uc:           "failed token review request is not cached",
+			token:        token,
+			cacheEnabled: true,
+			reviewToken: func(t *testing.T, _ *authv1.TokenReview) (int, *authv1.TokenReviewStatus) {
+				t.Helper()
+
+				return http.StatusInternalServerError, nil
+			},
+			assert: func(t *testing.T, err error, _ *subject.Subject, reviews int) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrCommunication)
+				assert.Equal(t, 2, reviews)
+			},
+		},
+		{
+			uc:           "token not authenticated and rejection cached",
+			token:        token,
+			cacheEnabled: true,
+			reviewToken: func(t *testing.T, _ *authv1.TokenReview) (int, *authv1.TokenReviewStatus) {
+				t.Helper()
+
+				return http.StatusCreated, &authv1.TokenReviewStatus{Error: "token expired"}
+			},
+			assert: func(t *testing.T, err error, _ *subject.Subject, reviews int) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrAuthentication)
+				require.ErrorContains(t, err, "token is not valid: token expired")
+				assert.Equal(t, 1, reviews)
+			},
+		},
+		{
+			uc:    "token authenticated for the audience of the API server only",
+			token: token,
+			reviewToken: func(t *testing.T, _ *authv1.TokenReview) (int, *authv1.TokenReviewStatus) {
+				t.Helper()
+
+				return http.StatusCreated, &authv1.TokenReviewStatus{
+					Authenticated: true,
+					User:          authv1.UserInfo{Username: "system:serviceaccount:foo:bar"},
+				}
+			},
+			assert: func(t *testing.T, err error, _ *subject.Subject, _ int) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrAuthentication)
+				require.ErrorContains(t, err, "not issued for any of the expected audiences")
+			},
+		},
+		{
+			uc:           "token authenticated and result cached",
+			token:        token,
+			cacheEnabled: true,
+			reviewToken: func(t *testing.T, review *authv1.TokenReview) (int, *authv1.TokenReviewStatus) {
+				t.Helper()
+
+				assert.Equal(t, token, review.Spec.Token)
+				assert.Equal(t, []string{"heimdall"}, review.Spec.Audiences)
+
+				return http.StatusCreated, &authv1.TokenReviewStatus{
+					Authenticated: true,
+					Audiences:     []string{"heimdall"},
+					User: authv1.UserInfo{
+						Username: "system:serviceaccount:foo:bar",
+						UID:      "4711",
+						Groups:   []string{"system:serviceaccounts", "system:serviceaccounts:foo"},
+						Extra: map[string]authv1.ExtraValue{
+							"authentication.kubernetes.io/pod-name": {"bar-1234"},
+						},
+					},
+				}
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject, reviews int) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, 1, reviews)
+				assert.Equal(t, "system:serviceaccount:foo:bar", sub.ID)
+				assert.Equal(t, map[string]any{
+					"username":  "system:serviceaccount:foo:bar",
+					"uid":       "4711",
+					"groups":    []any{"system:serviceaccounts", "system:serviceaccounts:foo"},
+					"extra":     map[string]any{"authentication.kubernetes.io/pod-name": []any{"bar-1234"}},
+					"audiences": []any{"heimdall"},
+				}, sub.Attributes)
+			},
+		},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			// GIVEN
+			var reviews int
+
+			srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				reviews++
+
+				assert.Equal(t, http.MethodPost, req.Method)
+				assert.Equal(t, "/apis/authentication.k8s.io/v1/tokenreviews", req.URL.Path)
+
+				var review authv1.TokenReview
+				require.NoError(t, json.NewDecoder(req.Body).Decode(&review))
+
+				code, status := tc.reviewToken(t, &review)
+				if status != nil {
+					review.Status = *status
+				}
+
+				rawReview, err := json.Marshal(&review)
+				require.NoError(t, err)
+
+				rw.Header().Set("Content-Type", "application/json")
+				rw.WriteHeader(code)
+				_, err = rw.Write(rawReview)
+				require.NoError(t, err)
+			}))
+			defer srv.Close()
+
+			conf := map[string]any{"audiences": []string{"heimdall"}}
+			if !tc.cacheEnabled {
+				conf["cache_ttl"] = "0s"
+			}
+
+			auth, err := newK8sTokenReviewAuthenticator("auth1", conf, k8sTestConfigFactory(srv.URL))
+			require.NoError(t, err)
+
+			cch := memory.New()
+
+			execute := func() (*subject.Subject, error) {
+				reqf := mocks.NewRequestFunctionsMock(t)
+				reqf.EXPECT().Header("Authorization").Return(x.IfThenElse(len(tc.token) != 0, "Bearer "+tc.token, ""))
+
+				ctx := mocks.NewContextMock(t)
+				ctx.EXPECT().AppContext().Return(cache.WithContext(context.Background(), cch))
+				ctx.EXPECT().Request().Return(&heimdall.Request{RequestFunctions: reqf})
+
+				return auth.Execute(ctx)
+			}
+
+			// WHEN
+			sub, err := execute()
+
+			if tc.cacheEnabled {
+				// the second request is served from the cache, if the result of the first one has been cached
+				sub, err = execute()
+			}
+
+			// THEN
+			tc.assert(t, err, sub, reviews)
+		})
+	}
+}
+
+func TestK8sTokenReviewAuthenticatorGetCacheTTL(t *testing.T) {
+	t.Parallel()
+
+	auth := &k8sTokenReviewAuthenticator{ttl: 5 * time.Minute}
+
+	assert.Equal(t, 5*time.Minute, auth.getCacheTTL("foo"))
+	assert.Equal(t, 5*time.Minute, auth.getCacheTTL(createServiceAccountToken(t, time.Hour)))
+	assert.InDelta(t, time.Minute, auth.getCacheTTL(createServiceAccountToken(t, time.Minute)), float64(time.Second))
+	assert.Equal(t, time.Duration(0), auth.getCacheTTL(createServiceAccountToken(t, -time.Minute)))
+}
@@ -68,6 +68,7 @@ type oauth2IntrospectionAuthenticator struct {
 	ads                  extractors.AuthDataExtractStrategy
 	ttl                  *time.Duration
 	allowFallbackOnError bool
+	sc                   *senderConstraints
 }
 
 func newOAuth2IntrospectionAuthenticator( // nolint: funlen
@@ -81,6 +82,8 @@ func newOAuth2IntrospectionAuthenticator( // nolint: funlen
 		AuthDataSource        extractors.CompositeExtractStrategy `mapstructure:"token_source"`
 		CacheTTL              *time.Duration                      `mapstructure:"cache_ttl"`
 		AllowFallbackOnError  bool                                `mapstructure:"allow_fallback_on_error"`
+		DPoP                  *DPoPConfig                         `mapstructure:"dpop"`
+		CertificateBinding    *CertificateBindingConfig           `mapstructure:"certificate_binding"`
 	}
 
 	var conf Config
@@ -106,13 +109,7 @@ func newOAuth2IntrospectionAuthenticator( // nolint: funlen
 	}
 
 	ads := x.IfThenElseExec(conf.AuthDataSource == nil,
-		func() extractors.CompositeExtractStrategy {
-			return extractors.CompositeExtractStrategy{
-				extractors.HeaderValueExtractStrategy{Name: "Authorization", Scheme: "Bearer"},
-				extractors.QueryParameterExtractStrategy{Name: "access_token"},
-				extractors.BodyParameterExtractStrategy{Name: "access_token"},
-			}
-		},
+		func() extractors.CompositeExtractStrategy { return accessTokenExtractStrategies(conf.DPoP) },
 		func() extractors.CompositeExtractStrategy { return conf.AuthDataSource },
 	)
 
@@ -153,6 +150,7 @@ func newOAuth2IntrospectionAuthenticator( // nolint: funlen
 		sf:                   &conf.SubjectInfo,
 		ttl:                  conf.CacheTTL,
 		allowFallbackOnError: conf.AllowFallbackOnError,
+		sc:                   newSenderConstraints(conf.DPoP, conf.CertificateBinding),
 	}, nil
 }
 
@@ -173,6 +171,13 @@ func (a *oauth2IntrospectionAuthenticator) Execute(ctx heimdall.Context) (*subje
 		return nil, err
 	}
 
+	if err = a.sc.verify(ctx, accessToken, rawResp); err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrAuthentication, "sender constraint validation failed").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
 	sub, err := a.sf.CreateSubject(rawResp)
 	if err != nil {
 		return nil, errorchain.
@@ -212,6 +217,7 @@ func (a *oauth2IntrospectionAuthenticator) WithConfig(rawConfig map[string]any)
 		allowFallbackOnError: x.IfThenElseExec(conf.AllowFallbackOnError != nil,
 			func() bool { return *conf.AllowFallbackOnError },
 			func() bool { return a.allowFallbackOnError }),
+		sc: a.sc,
 	}, nil
 }
 
@@ -223,6 +229,10 @@ func (a *oauth2IntrospectionAuthenticator) ID() string {
 	return a.id
 }
 
+func (a *oauth2IntrospectionAuthenticator) CacheDependency() string {
+	return a.sc.cacheDependency()
+}
+
 func (a *oauth2IntrospectionAuthenticator) serverMetadata(
 	ctx heimdall.Context, claims map[string]any,
 ) (oauth2.ServerMetadata, error) {
@@ -260,16 +270,8 @@ func (a *oauth2IntrospectionAuthenticator) extractTokenClaims(token string) (map
 }
 
 func (a *oauth2IntrospectionAuthenticator) getSubjectInformation(ctx heimdall.Context, token string) ([]byte, error) {
-	cch := cache.Ctx(ctx.AppContext())
 	logger := zerolog.Ctx(ctx.AppContext())
 
-	var (
-		cacheKey       string
-		cacheEntry     any
-		cachedResponse []byte
-		ok             bool
-	)
-
 	claims, err := a.extractTokenClaims(token)
 	if err != nil {
 		logger.Debug().Err(err).Msg("Could not extract issuer information from token.")
@@ -285,29 +287,30 @@ func (a *oauth2IntrospectionAuthenticator) getSubjectInformation(ctx heimdall.Co
 		return nil, err
 	}
 
-	if a.isCacheEnabled() {
-		cacheKey = a.calculateCacheKey(metadata.IntrospectionEndpoint, req.URL.String(), token)
-		cacheEntry = cch.Get(ctx.AppContext(), cacheKey)
+	introspect := func(_ context.Context) ([]byte, time.Duration, error) {
+		return a.introspectToken(ctx, metadata, req)
 	}
 
-	if cacheEntry != nil {
-		if cachedResponse, ok = cacheEntry.([]byte); !ok {
-			logger.Warn().Msg("Wrong object type from cache")
-			cch.Delete(ctx.AppContext(), cacheKey)
-		} else {
-			logger.Debug().Msg("Reusing introspection response from cache")
+	if !a.isCacheEnabled() {
+		rawResp, _, err := introspect(ctx.AppContext())
 
-			return cachedResponse, nil
-		}
+		return rawResp, err
 	}
 
+	return cache.GetOrLoad(ctx.AppContext(), cache.Ctx(ctx.AppContext()),
+		a.calculateCacheKey(metadata.IntrospectionEndpoint, req.URL.String(), token), introspect)
+}
+
+func (a *oauth2IntrospectionAuthenticator) introspectToken(
+	ctx heimdall.Context, metadata oauth2.ServerMetadata, req *http.Request,
+) ([]byte, time.Duration, error) {
 	introspectResp, rawResp, err := a.fetchTokenIntrospectionResponse(
 		ctx,
 		metadata.IntrospectionEndpoint.CreateClient(req.URL.Hostname()),
 		req,
 	)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	// configured assertions take precedence over those available in the metadata
@@ -316,17 +319,13 @@ func (a *oauth2IntrospectionAuthenticator) getSubjectInformation(ctx heimdall.Co
 	})
 
 	if err = introspectResp.Validate(assertions); err != nil {
-		return nil, errorchain.
+		return nil, 0, errorchain.
 			NewWithMessage(heimdall.ErrAuthentication, "access token does not satisfy assertion conditions").
 			WithErrorContext(a).
 			CausedBy(err)
 	}
 
-	if cacheTTL := a.getCacheTTL(introspectResp); cacheTTL > 0 {
-		cch.Set(ctx.AppContext(), cacheKey, rawResp, cacheTTL)
-	}
-
-	return rawResp, nil
+	return rawResp, a.getCacheTTL(introspectResp), nil
 }
 
 func (a *oauth2IntrospectionAuthenticator) createRequest(
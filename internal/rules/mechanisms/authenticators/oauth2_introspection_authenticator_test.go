@@ -181,9 +181,47 @@ assertions:
 
 				assert.False(t, auth.IsFallbackOnErrorAllowed())
 
+				// assert sender constraints
+				assert.Nil(t, auth.sc)
+
 				assert.Equal(t, "auth1", auth.ID())
 			},
 		},
+		{
+			uc: "with introspection endpoint based config with sender constraints",
+			id: "auth1",
+			config: []byte(`
+introspection_endpoint:
+  url: http://foobar.local
+assertions:
+  issuers:
+    - foobar
+dpop:
+  max_proof_age: 30s
+  allowed_algorithms:
+    - ES256
+certificate_binding:
+  required: true
+`),
+			assert: func(t *testing.T, err error, auth *oauth2IntrospectionAuthenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				// assert token extractor settings
+				assert.Len(t, auth.ads, 4)
+				assert.Contains(t, auth.ads, extractors.HeaderValueExtractStrategy{Name: "Authorization", Scheme: "DPoP"})
+
+				// assert sender constraints
+				require.NotNil(t, auth.sc)
+				require.NotNil(t, auth.sc.dpop)
+				assert.False(t, auth.sc.dpop.Required)
+				assert.Equal(t, 30*time.Second, *auth.sc.dpop.MaxProofAge)
+				assert.Equal(t, []string{"ES256"}, auth.sc.dpop.AllowedAlgorithms)
+				require.NotNil(t, auth.sc.certBinding)
+				assert.True(t, auth.sc.certBinding.Required)
+			},
+		},
 		{
 			uc: "with valid introspection endpoint based config with overwrites",
 			id: "auth1",
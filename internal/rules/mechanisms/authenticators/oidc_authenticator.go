@@ -0,0 +1,644 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package authenticators
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-jose/go-jose/v3/jwt"
+	"github.com/goccy/go-json"
+	"github.com/rs/zerolog"
+	"github.com/tidwall/gjson"
+
+	"github.com/dadrus/heimdall/internal/callback"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/rules/endpoint/authstrategy"
+	"github.com/dadrus/heimdall/internal/rules/mechanisms/oauth2"
+	"github.com/dadrus/heimdall/internal/rules/mechanisms/subject"
+	"github.com/dadrus/heimdall/internal/x"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+	"github.com/dadrus/heimdall/internal/x/stringx"
+)
+
+const (
+	defaultOIDCLoginStateTTL      = 10 * time.Minute
+	defaultOIDCSessionLifespan    = 5 * time.Minute
+	oidcRandomValueLength         = 32
+	oidcCodeChallengeMethodS256   = "S256"
+	oidcSessionCookiePurpose      = "session"
+	oidcLoginStateCookiePurpose   = "login_state"
+	oidcOpenIDScope               = "openid"
+	oidcDefaultPostLogoutRedirect = "/"
+	// the minimum size of a cookie browsers have to support according to RFC 6265, section 6.1
+	maxCookieSize = 4096
+)
+
+// by intention. Used only during application bootstrap
+//
+//nolint:gochecknoinits
+func init() {
+	registerTypeFactory(
+		func(id string, typ string, conf map[string]any) (bool, Authenticator, error) {
+			if typ != AuthenticatorOIDC {
+				return false, nil, nil
+			}
+
+			auth, err := newOIDCAuthenticator(id, conf)
+
+			return true, auth, err
+		})
+}
+
+type OIDCClientConfig struct {
+	ID     string `mapstructure:"id"     validate:"required"`
+	Secret string `mapstructure:"secret"`
+}
+
+type OIDCLogoutConfig struct {
+	Path                  string `mapstructure:"path"                     validate:"required"`
+	PostLogoutRedirectURI string `mapstructure:"post_logout_redirect_uri" validate:"omitempty,url"`
+}
+
+type oidcTokenResponse struct {
+	IDToken      string `json:"id_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+type oidcAuthenticator struct {
+	id                   string
+	r                    oauth2.ServerMetadataResolver
+	client               OIDCClientConfig
+	scopes               []string
+	redirectURI          *url.URL
+	logout               *OIDCLogoutConfig
+	session              *OIDCSessionConfig
+	sessionCodec         *cookieCodec
+	loginStateCodec      *cookieCodec
+	idTokenVerifier      *jwtAuthenticator
+	sf                   SubjectFactory
+	allowFallbackOnError bool
+}
+
+func newOIDCAuthenticator(id string, rawConfig map[string]any) (*oidcAuthenticator, error) { // nolint: funlen
+	type Config struct {
+		MetadataEndpoint     *oauth2.MetadataEndpoint `mapstructure:"metadata_endpoint"       validate:"required"`
+		Client               OIDCClientConfig         `mapstructure:"client"                  validate:"required"`
+		Scopes               []string                 `mapstructure:"scopes"`
+		RedirectURI          string                   `mapstructure:"redirect_uri"            validate:"required,url"`
+		Logout               *OIDCLogoutConfig        `mapstructure:"logout"`
+		Session              OIDCSessionConfig        `mapstructure:"session"                 validate:"required"`
+		Assertions           oauth2.Expectation       `mapstructure:"assertions"              validate:"-"`
+		SubjectInfo          SubjectInfo              `mapstructure:"subject"                 validate:"-"`
+		AllowFallbackOnError bool                     `mapstructure:"allow_fallback_on_error"`
+	}
+
+	var conf Config
+	if err := decodeConfig(AuthenticatorOIDC, rawConfig, &conf); err != nil {
+		return nil, err
+	}
+
+	redirectURI, err := url.Parse(conf.RedirectURI)
+	if err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration, "failed to parse redirect_uri").
+			CausedBy(err)
+	}
+
+	if conf.Logout != nil && conf.Logout.Path == redirectURI.Path {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"logout path must differ from the path of the redirect_uri")
+	}
+
+	if !slices.Contains(conf.Scopes, oidcOpenIDScope) {
+		conf.Scopes = append([]string{oidcOpenIDScope}, conf.Scopes...)
+	}
+
+	if len(conf.SubjectInfo.IDFrom) == 0 {
+		conf.SubjectInfo.IDFrom = "sub"
+	}
+
+	idTokenVerifier, err := newIDTokenVerifier(id, rawConfig, conf.Client.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	conf.Session.init()
+
+	return &oidcAuthenticator{
+		id:                   id,
+		r:                    conf.MetadataEndpoint,
+		client:               conf.Client,
+		scopes:               conf.Scopes,
+		redirectURI:          redirectURI,
+		logout:               conf.Logout,
+		session:              &conf.Session,
+		sessionCodec:         newCookieCodec(conf.Session.Secret, oidcSessionCookiePurpose),
+		loginStateCodec:      newCookieCodec(conf.Session.Secret, oidcLoginStateCookiePurpose),
+		idTokenVerifier:      idTokenVerifier,
+		sf:                   &conf.SubjectInfo,
+		allowFallbackOnError: conf.AllowFallbackOnError,
+	}, nil
+}
+
+func (a *oidcAuthenticator) Execute(ctx heimdall.Context) (*subject.Subject, error) {
+	logger := zerolog.Ctx(ctx.AppContext())
+	logger.Debug().Str("_id", a.id).Msg("Authenticating using OIDC authenticator")
+
+	req := ctx.Request()
+
+	sess, err := a.readSession(ctx)
+	if err == nil {
+		if !sess.expired() {
+			return a.createSubject(sess)
+		}
+
+		err = errorchain.NewWithMessage(heimdall.ErrAuthentication, "session expired").WithErrorContext(a)
+	}
+
+	// only navigations can be answered with a redirect without losing the request
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return nil, err
+	}
+
+	if sess != nil && len(sess.RefreshToken) != 0 {
+		renewed, err := a.refreshSession(ctx, sess)
+		if err == nil {
+			return nil, a.redirectWithSession(renewed, req.URL.String())
+		}
+
+		logger.Info().Err(err).Msg("Failed to renew the session. Starting new login")
+	}
+
+	return nil, a.startLogin(ctx)
+}
+
+func (a *oidcAuthenticator) WithConfig(config map[string]any) (Authenticator, error) {
+	// this authenticator allows only the fallback behavior to be redefined on the rule level
+	if len(config) == 0 {
+		return a, nil
+	}
+
+	type Config struct {
+		AllowFallbackOnError *bool `mapstructure:"allow_fallback_on_error"`
+	}
+
+	var conf Config
+	if err := decodeConfig(AuthenticatorOIDC, config, &conf); err != nil {
+		return nil, err
+	}
+
+	return &oidcAuthenticator{
+		id:              a.id,
+		r:               a.r,
+		client:          a.client,
+		scopes:          a.scopes,
+		redirectURI:     a.redirectURI,
+		logout:          a.logout,
+		session:         a.session,
+		sessionCodec:    a.sessionCodec,
+		loginStateCodec: a.loginStateCodec,
+		idTokenVerifier: a.idTokenVerifier,
+		sf:              a.sf,
+		allowFallbackOnError: x.IfThenElseExec(conf.AllowFallbackOnError != nil,
+			func() bool { return *conf.AllowFallbackOnError },
+			func() bool { return a.allowFallbackOnError }),
+	}, nil
+}
+
+// Endpoints returns the handlers of the redirect_uri and of the logout endpoint. These are
+// served by heimdall independent of the rules, which make use of this authenticator. The
+// logout endpoint is served on the same scheme and host as the redirect_uri.
+func (a *oidcAuthenticator) Endpoints() []callback.Endpoint {
+	endpoints := []callback.Endpoint{
+		{URL: a.redirectURI, Handler: callback.HandlerFunc(a.handleCallback)},
+	}
+
+	if a.logout != nil {
+		endpoints = append(endpoints, callback.Endpoint{
+			URL:     a.redirectURI.ResolveReference(&url.URL{Path: a.logout.Path}),
+			Handler: callback.HandlerFunc(a.handleLogout),
+		})
+	}
+
+	return endpoints
+}
+
+func (a *oidcAuthenticator) IsFallbackOnErrorAllowed() bool {
+	return a.allowFallbackOnError
+}
+
+func (a *oidcAuthenticator) ID() string {
+	return a.id
+}
+
+func (a *oidcAuthenticator) readSession(ctx heimdall.Context) (*oidcSession, error) {
+	value := ctx.Request().Cookie(a.session.CookieName)
+	if len(value) == 0 {
+		return nil, errorchain.NewWithMessage(heimdall.ErrAuthentication, "no session cookie present").
+			WithErrorContext(a)
+	}
+
+	var sess oidcSession
+	if err := a.sessionCodec.decode(value, &sess); err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrAuthentication, "invalid session cookie").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	return &sess, nil
+}
+
+func (a *oidcAuthenticator) createSubject(sess *oidcSession) (*subject.Subject, error) {
+	sub, err := a.sf.CreateSubject(sess.Claims)
+	if err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrInternal, "failed to extract subject information from session").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	return sub, nil
+}
+
+func (a *oidcAuthenticator) startLogin(ctx heimdall.Context) error {
+	metadata, err := a.serverMetadata(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(metadata.AuthorizationEndpoint) == 0 {
+		return errorchain.NewWithMessage(heimdall.ErrInternal,
+			"received server metadata does not contain the required authorization_endpoint").
+			WithErrorContext(a)
+	}
+
+	authURL, err := url.Parse(metadata.AuthorizationEndpoint)
+	if err != nil {
+		return errorchain.NewWithMessage(heimdall.ErrInternal, "failed to parse authorization_endpoint").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	state, err := newOIDCLoginState(ctx.Request().URL.String())
+	if err != nil {
+		return errorchain.NewWithMessage(heimdall.ErrInternal, "failed to create login state").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	value, err := a.loginStateCodec.encode(state)
+	if err != nil {
+		return errorchain.NewWithMessage(heimdall.ErrInternal, "failed to encode login state").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	challenge := sha256.Sum256(stringx.ToBytes(state.CodeVerifier))
+
+	query := authURL.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", a.client.ID)
+	query.Set("redirect_uri", a.redirectURI.String())
+	query.Set("scope", strings.Join(a.scopes, " "))
+	query.Set("state", state.State)
+	query.Set("nonce", state.Nonce)
+	query.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	query.Set("code_challenge_method", oidcCodeChallengeMethodS256)
+	authURL.RawQuery = query.Encode()
+
+	return &heimdall.RedirectError{
+		Message:    "redirect to the authorization endpoint",
+		Code:       http.StatusFound,
+		RedirectTo: authURL.String(),
+		Cookies:    []*http.Cookie{a.session.loginStateCookie(value, defaultOIDCLoginStateTTL)},
+	}
+}
+
+func (a *oidcAuthenticator) handleCallback(ctx heimdall.Context) error {
+	logger := zerolog.Ctx(ctx.AppContext())
+	logger.Debug().Str("_id", a.id).Msg("Handling authorization response")
+
+	req := ctx.Request()
+	query := req.URL.Query()
+
+	if errCode := query.Get("error"); len(errCode) != 0 {
+		return errorchain.NewWithMessagef(heimdall.ErrAuthentication,
+			"authorization request failed with %s: %s", errCode, query.Get("error_description")).
+			WithErrorContext(a)
+	}
+
+	var state oidcLoginState
+	if err := a.loginStateCodec.decode(req.Cookie(a.session.loginStateCookieName()), &state); err != nil {
+		return errorchain.NewWithMessage(heimdall.ErrAuthentication, "no valid login state present").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	if state.expired() {
+		return errorchain.NewWithMessage(heimdall.ErrAuthentication, "login state expired").
+			WithErrorContext(a)
+	}
+
+	if subtle.ConstantTimeCompare(stringx.ToBytes(state.State), stringx.ToBytes(query.Get("state"))) != 1 {
+		return errorchain.NewWithMessage(heimdall.ErrAuthentication, "state mismatch").
+			WithErrorContext(a)
+	}
+
+	code := query.Get("code")
+	if len(code) == 0 {
+		return errorchain.NewWithMessage(heimdall.ErrAuthentication, "no authorization code present").
+			WithErrorContext(a)
+	}
+
+	tokens, err := a.requestTokens(ctx, url.Values{
+		"grant_type":    []string{"authorization_code"},
+		"code":          []string{code},
+		"redirect_uri":  []string{a.redirectURI.String()},
+		"code_verifier": []string{state.CodeVerifier},
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(tokens.IDToken) == 0 {
+		return errorchain.NewWithMessage(heimdall.ErrAuthentication, "token response does not contain an id token").
+			WithErrorContext(a)
+	}
+
+	claims, err := a.verifyIDToken(ctx, tokens.IDToken)
+	if err != nil {
+		return err
+	}
+
+	if nonce := gjson.GetBytes(claims, "nonce").String(); subtle.ConstantTimeCompare(
+		stringx.ToBytes(state.Nonce), stringx.ToBytes(nonce)) != 1 {
+		return errorchain.NewWithMessage(heimdall.ErrAuthentication, "nonce mismatch").
+			WithErrorContext(a)
+	}
+
+	return a.redirectWithSession(&oidcSession{
+		Claims:       claims,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresAt:    sessionExpiry(tokens, claims),
+	}, state.ReturnTo, expiredCookie(a.session.loginStateCookie("", 0)))
+}
+
+func (a *oidcAuthenticator) handleLogout(ctx heimdall.Context) error {
+	logger := zerolog.Ctx(ctx.AppContext())
+	logger.Debug().Str("_id", a.id).Msg("Handling logout")
+
+	req := ctx.Request()
+
+	// a logout must not be triggered by simply following a link or loading an image
+	if req.Method != http.MethodPost {
+		return errorchain.NewWithMessage(heimdall.ErrMethodNotAllowed, "logout requires a POST request").
+			WithErrorContext(a).
+			CausedBy(&heimdall.MethodNotAllowedError{AllowedMethods: []string{http.MethodPost}})
+	}
+
+	if !a.fromSameOrigin(req) {
+		return errorchain.NewWithMessage(heimdall.ErrArgument, "cross-site logout request rejected").
+			WithErrorContext(a)
+	}
+
+	target := x.IfThenElse(len(a.logout.PostLogoutRedirectURI) != 0,
+		a.logout.PostLogoutRedirectURI, oidcDefaultPostLogoutRedirect)
+
+	// the session is terminated locally even if the OP cannot be involved
+	metadata, err := a.serverMetadata(ctx)
+	if err != nil {
+		logger.Warn().Err(err).Msg("Failed to retrieve server metadata. Skipping RP-initiated logout")
+	} else if len(metadata.EndSessionEndpoint) != 0 {
+		if endSessionURL, err := url.Parse(metadata.EndSessionEndpoint); err != nil {
+			logger.Warn().Err(err).Msg("Failed to parse end_session_endpoint. Skipping RP-initiated logout")
+		} else {
+			query := endSessionURL.Query()
+			query.Set("client_id", a.client.ID)
+
+			if len(a.logout.PostLogoutRedirectURI) != 0 {
+				query.Set("post_logout_redirect_uri", a.logout.PostLogoutRedirectURI)
+			}
+
+			endSessionURL.RawQuery = query.Encode()
+			target = endSessionURL.String()
+		}
+	}
+
+	return &heimdall.RedirectError{
+		Message:    "logout",
+		Code:       http.StatusFound,
+		RedirectTo: target,
+		Cookies:    []*http.Cookie{expiredCookie(a.session.sessionCookie(""))},
+	}
+}
+
+// fromSameOrigin checks whether the request has been sent from a page of the origin the
+// redirect_uri belongs to, to prevent cross-site request forgery.
+func (a *oidcAuthenticator) fromSameOrigin(req *heimdall.Request) bool {
+	origin := req.Header("Origin")
+	if len(origin) == 0 {
+		// not all browsers send the Origin header with same-origin requests
+		referer, err := url.Parse(req.Header("Referer"))
+		if err != nil || len(referer.Host) == 0 {
+			return false
+		}
+
+		origin = referer.Scheme + "://" + referer.Host
+	}
+
+	return origin == a.redirectURI.Scheme+"://"+a.redirectURI.Host
+}
+
+func (a *oidcAuthenticator) refreshSession(ctx heimdall.Context, sess *oidcSession) (*oidcSession, error) {
+	tokens, err := a.requestTokens(ctx, url.Values{
+		"grant_type":    []string{"refresh_token"},
+		"refresh_token": []string{sess.RefreshToken},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	claims := sess.Claims
+
+	// the OP may issue a new id token while refreshing the tokens
+	if len(tokens.IDToken) != 0 {
+		claims, err = a.verifyIDToken(ctx, tokens.IDToken)
+		if err != nil {
+			return nil, err
+		}
+
+		if gjson.GetBytes(claims, "sub").String() != gjson.GetBytes(sess.Claims, "sub").String() {
+			return nil, errorchain.NewWithMessage(heimdall.ErrAuthentication,
+				"subject of the refreshed id token does not match the subject of the session").
+				WithErrorContext(a)
+		}
+	}
+
+	return &oidcSession{
+		Claims:       claims,
+		RefreshToken: x.IfThenElse(len(tokens.RefreshToken) != 0, tokens.RefreshToken, sess.RefreshToken),
+		ExpiresAt:    sessionExpiry(tokens, claims),
+	}, nil
+}
+
+func (a *oidcAuthenticator) redirectWithSession(
+	sess *oidcSession, target string, cookies ...*http.Cookie,
+) error {
+	value, err := a.sessionCodec.encode(sess)
+	if err != nil {
+		return errorchain.NewWithMessage(heimdall.ErrInternal, "failed to encode session").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	cookie := a.session.sessionCookie(value)
+	if size := len(cookie.String()); size > maxCookieSize {
+		// browsers silently drop such cookies, which would result in an endless login loop
+		return errorchain.NewWithMessagef(heimdall.ErrInternal,
+			"session cookie of %d bytes exceeds the limit of %d bytes", size, maxCookieSize).
+			WithErrorContext(a)
+	}
+
+	return &heimdall.RedirectError{
+		Message:    "session established",
+		Code:       http.StatusFound,
+		RedirectTo: target,
+		Cookies:    append([]*http.Cookie{cookie}, cookies...),
+	}
+}
+
+func (a *oidcAuthenticator) requestTokens(ctx heimdall.Context, form url.Values) (*oidcTokenResponse, error) {
+	metadata, err := a.serverMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if metadata.TokenEndpoint == nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrInternal,
+			"received server metadata does not contain the required token_endpoint").
+			WithErrorContext(a)
+	}
+
+	ep := *metadata.TokenEndpoint
+	if len(a.client.Secret) != 0 {
+		// client_secret_basic as defined in RFC 6749, section 2.3.1
+		ep.AuthStrategy = &authstrategy.BasicAuth{
+			User:     url.QueryEscape(a.client.ID),
+			Password: url.QueryEscape(a.client.Secret),
+		}
+	} else {
+		form.Set("client_id", a.client.ID)
+	}
+
+	rawResp, err := ep.SendRequest(ctx.AppContext(), strings.NewReader(form.Encode()), nil)
+	if err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrAuthentication, "token request failed").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	var resp oidcTokenResponse
+	if err = json.Unmarshal(rawResp, &resp); err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrInternal, "failed to unmarshal token response").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	return &resp, nil
+}
+
+func (a *oidcAuthenticator) verifyIDToken(ctx heimdall.Context, rawToken string) (json.RawMessage, error) {
+	token, err := jwt.ParseSigned(rawToken)
+	if err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrAuthentication, "failed to parse id token").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	return a.idTokenVerifier.verifyToken(ctx.AppContext(), token, a.idTokenVerifier.a)
+}
+
+func (a *oidcAuthenticator) serverMetadata(ctx heimdall.Context) (oauth2.ServerMetadata, error) {
+	metadata, err := a.r.Get(ctx.AppContext(), nil)
+	if err != nil {
+		return oauth2.ServerMetadata{}, errorchain.NewWithMessage(heimdall.ErrInternal,
+			"failed retrieving oauth2 server metadata").CausedBy(err).WithErrorContext(a)
+	}
+
+	return metadata, nil
+}
+
+// newIDTokenVerifier creates the jwt authenticator used to verify the id tokens, as their
+// validation is the same as the one of any other JWT issued by the OP.
+func newIDTokenVerifier(id string, rawConfig map[string]any, clientID string) (*jwtAuthenticator, error) {
+	assertions := map[string]any{}
+	if configured, ok := rawConfig["assertions"].(map[string]any); ok {
+		maps.Copy(assertions, configured)
+	}
+
+	if _, ok := assertions["audience"]; !ok {
+		// id tokens are issued for the client
+		assertions["audience"] = []string{clientID}
+	}
+
+	return newJwtAuthenticator(id, map[string]any{
+		"metadata_endpoint": rawConfig["metadata_endpoint"],
+		"assertions":        assertions,
+	})
+}
+
+func newOIDCLoginState(returnTo string) (*oidcLoginState, error) {
+	values := make([]string, 3) //nolint:gomnd
+
+	for idx := range values {
+		buf := make([]byte, oidcRandomValueLength)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+
+		values[idx] = base64.RawURLEncoding.EncodeToString(buf)
+	}
+
+	return &oidcLoginState{
+		State:        values[0],
+		Nonce:        values[1],
+		CodeVerifier: values[2],
+		ReturnTo:     returnTo,
+		ExpiresAt:    time.Now().Add(defaultOIDCLoginStateTTL).Unix(),
+	}, nil
+}
+
+func sessionExpiry(tokens *oidcTokenResponse, claims json.RawMessage) int64 {
+	if tokens.ExpiresIn > 0 {
+		return time.Now().Unix() + tokens.ExpiresIn
+	}
+
+	if exp := gjson.GetBytes(claims, "exp").Int(); exp > 0 {
+		return exp
+	}
+
+	return time.Now().Add(defaultOIDCSessionLifespan).Unix()
+}
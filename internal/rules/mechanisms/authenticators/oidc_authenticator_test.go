@@ -0,0 +1,1098 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package authenticators
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/cache"
+	"github.com/dadrus/heimdall/internal/cache/memory"
+	"github.com/dadrus/heimdall/internal/callback"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/heimdall/mocks"
+	"github.com/dadrus/heimdall/internal/keystore"
+	"github.com/dadrus/heimdall/internal/rules/mechanisms/oauth2"
+	"github.com/dadrus/heimdall/internal/rules/mechanisms/subject"
+	"github.com/dadrus/heimdall/internal/x/testsupport"
+)
+
+const oidcTestSessionSecret = "a-not-so-secret-secret-with-32-or-more-characters"
+
+// oidcTestProvider is a minimal stand-in for an OpenID Provider
+type oidcTestProvider struct {
+	srv *httptest.Server
+	key *keystore.Entry
+
+	nonce        string
+	subject      string
+	refreshToken string
+	failTokens   bool
+
+	tokenRequests []url.Values
+	basicAuth     []string
+}
+
+func newOIDCTestProvider(t *testing.T) *oidcTestProvider {
+	t.Helper()
+
+	privKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+
+	op := &oidcTestProvider{
+		key:          &keystore.Entry{KeyID: "op", Alg: keystore.AlgECDSA, KeySize: 384, PrivateKey: privKey},
+		subject:      "foo",
+		refreshToken: "refresh-token",
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(rw http.ResponseWriter, _ *http.Request) {
+		writeJSON(t, rw, map[string]any{
+			"issuer":                 op.srv.URL,
+			"jwks_uri":               op.srv.URL + "/jwks",
+			"authorization_endpoint": op.srv.URL + "/authorize?prompt=login",
+			"token_endpoint":         op.srv.URL + "/token",
+			"end_session_endpoint":   op.srv.URL + "/logout",
+		})
+	})
+	mux.HandleFunc("/jwks", func(rw http.ResponseWriter, _ *http.Request) {
+		writeJSON(t, rw, jose.JSONWebKeySet{Keys: []jose.JSONWebKey{op.key.JWK()}})
+	})
+	mux.HandleFunc("/token", func(rw http.ResponseWriter, req *http.Request) {
+		require.NoError(t, req.ParseForm())
+
+		user, password, _ := req.BasicAuth()
+		op.basicAuth = []string{user, password}
+		op.tokenRequests = append(op.tokenRequests, req.PostForm)
+
+		if op.failTokens {
+			rw.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		writeJSON(t, rw, map[string]any{
+			"access_token":  "access-token",
+			"token_type":    "Bearer",
+			"id_token":      op.idToken(t),
+			"refresh_token": op.refreshToken,
+			"expires_in":    300,
+		})
+	})
+
+	op.srv = httptest.NewServer(mux)
+	t.Cleanup(op.srv.Close)
+
+	return op
+}
+
+func (op *oidcTestProvider) idToken(t *testing.T) string {
+	t.Helper()
+
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: op.key.JOSEAlgorithm(), Key: op.key.PrivateKey},
+		(&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", op.key.KeyID))
+	require.NoError(t, err)
+
+	now := time.Now()
+	claims := map[string]any{
+		"iss":   op.srv.URL,
+		"sub":   op.subject,
+		"aud":   "heimdall",
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+		"email": "foo@bar.baz",
+	}
+
+	if len(op.nonce) != 0 {
+		claims["nonce"] = op.nonce
+	}
+
+	token, err := jwt.Signed(signer).Claims(claims).CompactSerialize()
+	require.NoError(t, err)
+
+	return token
+}
+
+func writeJSON(t *testing.T, rw http.ResponseWriter, value any) {
+	t.Helper()
+
+	rawValue, err := json.Marshal(value)
+	require.NoError(t, err)
+
+	rw.Header().Set("Content-Type", "application/json")
+	_, err = rw.Write(rawValue)
+	require.NoError(t, err)
+}
+
+func oidcTestConfig(t *testing.T, op *oidcTestProvider) map[string]any {
+	t.Helper()
+
+	conf, err := testsupport.DecodeTestConfig([]byte(fmt.Sprintf(`
+metadata_endpoint:
+  url: %s/.well-known/openid-configuration
+client:
+  id: heimdall
+  secret: "secret:1"
+scopes:
+  - profile
+redirect_uri: https://app.local/oauth2/callback
+logout:
+  path: /oauth2/logout
+  post_logout_redirect_uri: https://app.local/
+session:
+  secret: %s
+`, op.srv.URL, oidcTestSessionSecret)))
+	require.NoError(t, err)
+
+	return conf
+}
+
+func findCookie(cookies []*http.Cookie, name string) *http.Cookie {
+	for _, cookie := range cookies {
+		if cookie.Name == name {
+			return cookie
+		}
+	}
+
+	return nil
+}
+
+func TestCreateOIDCAuthenticator(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc     string
+		config []byte
+		assert func(t *testing.T, err error, auth *oidcAuthenticator)
+	}{
+		{
+			uc: "without configuration",
+			assert: func(t *testing.T, err error, _ *oidcAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				require.ErrorContains(t, err, "'metadata_endpoint' is a required field")
+			},
+		},
+		{
+			uc: "with too short session secret",
+			config: []byte(`
+metadata_endpoint:
+  url: https://op.local/.well-known/openid-configuration
+client:
+  id: heimdall
+redirect_uri: https://app.local/oauth2/callback
+session:
+  secret: foo
+`),
+			assert: func(t *testing.T, err error, _ *oidcAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				require.ErrorContains(t, err, "'session'.'secret' must be at least 32 characters")
+			},
+		},
+		{
+			uc: "with unsupported same_site value",
+			config: []byte(`
+metadata_endpoint:
+  url: https://op.local/.well-known/openid-configuration
+client:
+  id: heimdall
+redirect_uri: https://app.local/oauth2/callback
+session:
+  secret: ` + oidcTestSessionSecret + `
+  same_site: foo
+`),
+			assert: func(t *testing.T, err error, _ *oidcAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				require.ErrorContains(t, err, "'session'.'same_site' must be one of")
+			},
+		},
+		{
+			uc: "with logout path equal to the path of the redirect uri",
+			config: []byte(`
+metadata_endpoint:
+  url: https://op.local/.well-known/openid-configuration
+client:
+  id: heimdall
+redirect_uri: https://app.local/oauth2/callback
+logout:
+  path: /oauth2/callback
+session:
+  secret: ` + oidcTestSessionSecret + `
+`),
+			assert: func(t *testing.T, err error, _ *oidcAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				require.ErrorContains(t, err, "logout path must differ")
+			},
+		},
+		{
+			uc: "with unsupported properties",
+			config: []byte(`
+metadata_endpoint:
+  url: https://op.local/.well-known/openid-configuration
+client:
+  id: heimdall
+redirect_uri: https://app.local/oauth2/callback
+session:
+  secret: ` + oidcTestSessionSecret + `
+foo: bar
+`),
+			assert: func(t *testing.T, err error, _ *oidcAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				require.ErrorContains(t, err, "invalid keys: foo")
+			},
+		},
+		{
+			uc: "with minimal valid configuration",
+			config: []byte(`
+metadata_endpoint:
+  url: https://op.local/.well-known/openid-configuration
+client:
+  id: heimdall
+redirect_uri: https://app.local/oauth2/callback
+session:
+  secret: ` + oidcTestSessionSecret + `
+`),
+			assert: func(t *testing.T, err error, auth *oidcAuthenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "auth1", auth.ID())
+				assert.NotNil(t, auth.r)
+				assert.Equal(t, "heimdall", auth.client.ID)
+				assert.Empty(t, auth.client.Secret)
+				assert.Equal(t, []string{"openid"}, auth.scopes)
+				assert.Equal(t, "/oauth2/callback", auth.redirectURI.Path)
+				assert.Nil(t, auth.logout)
+				require.Len(t, auth.Endpoints(), 1)
+				assert.Equal(t, "https://app.local/oauth2/callback", auth.Endpoints()[0].URL.String())
+				assert.Equal(t, defaultOIDCSessionCookieName, auth.session.CookieName)
+				assert.Equal(t, "/", auth.session.Path)
+				assert.True(t, *auth.session.Secure)
+				assert.Equal(t, http.SameSiteLaxMode, auth.session.sameSite())
+				assert.NotEqual(t, auth.sessionCodec.key, auth.loginStateCodec.key)
+				assert.Equal(t, []string{"heimdall"}, auth.idTokenVerifier.a.TargetAudiences)
+				assert.ElementsMatch(t, defaultAllowedAlgorithms(), auth.idTokenVerifier.a.AllowedAlgorithms)
+				assert.Equal(t, oauth2.NoopMatcher{}, auth.idTokenVerifier.a.ScopesMatcher)
+				assert.Equal(t, &SubjectInfo{IDFrom: "sub"}, auth.sf)
+				assert.False(t, auth.IsFallbackOnErrorAllowed())
+			},
+		},
+		{
+			uc: "with full configuration",
+			config: []byte(`
+metadata_endpoint:
+  url: https://op.local/.well-known/openid-configuration
+client:
+  id: heimdall
+  secret: foo
+scopes:
+  - email
+  - openid
+redirect_uri: https://app.local/oauth2/callback
+logout:
+  path: /oauth2/logout
+  post_logout_redirect_uri: https://app.local/
+session:
+  cookie_name: foo
+  domain: app.local
+  path: /app
+  secure: false
+  same_site: strict
+  secret: ` + oidcTestSessionSecret + `
+assertions:
+  audience:
+    - bar
+  allowed_algorithms:
+    - ES256
+subject:
+  id: email
+allow_fallback_on_error: true
+`),
+			assert: func(t *testing.T, err error, auth *oidcAuthenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "foo", auth.client.Secret)
+				assert.Equal(t, []string{"email", "openid"}, auth.scopes)
+				assert.Equal(t, &OIDCLogoutConfig{
+					Path: "/oauth2/logout", PostLogoutRedirectURI: "https://app.local/",
+				}, auth.logout)
+				require.Len(t, auth.Endpoints(), 2)
+				assert.Equal(t, "https://app.local/oauth2/logout", auth.Endpoints()[1].URL.String())
+				assert.Equal(t, "foo", auth.session.CookieName)
+				assert.Equal(t, "foo_login", auth.session.loginStateCookieName())
+				assert.Equal(t, "app.local", auth.session.Domain)
+				assert.Equal(t, "/app", auth.session.Path)
+				assert.False(t, *auth.session.Secure)
+				assert.Equal(t, http.SameSiteStrictMode, auth.session.sameSite())
+				assert.Equal(t, []string{"bar"}, auth.idTokenVerifier.a.TargetAudiences)
+				assert.Equal(t, []string{"ES256"}, auth.idTokenVerifier.a.AllowedAlgorithms)
+				assert.Equal(t, &SubjectInfo{IDFrom: "email"}, auth.sf)
+				assert.True(t, auth.IsFallbackOnErrorAllowed())
+			},
+		},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			conf, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			// WHEN
+			auth, err := newOIDCAuthenticator("auth1", conf)
+
+			// THEN
+			tc.assert(t, err, auth)
+		})
+	}
+}
+
+func TestCreateOIDCAuthenticatorFromPrototypeConfig(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc     string
+		config []byte
+		assert func(t *testing.T, err error, prototype, configured *oidcAuthenticator)
+	}{
+		{
+			uc: "without target config",
+			assert: func(t *testing.T, err error, prototype, configured *oidcAuthenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, prototype, configured)
+			},
+		},
+		{
+			uc:     "with fallback redefined",
+			config: []byte(`allow_fallback_on_error: true`),
+			assert: func(t *testing.T, err error, prototype, configured *oidcAuthenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.NotEqual(t, prototype, configured)
+				assert.Equal(t, prototype.ID(), configured.ID())
+				assert.Equal(t, prototype.r, configured.r)
+				assert.Equal(t, prototype.session, configured.session)
+				assert.Equal(t, prototype.sessionCodec, configured.sessionCodec)
+				assert.Equal(t, prototype.idTokenVerifier, configured.idTokenVerifier)
+				assert.False(t, prototype.IsFallbackOnErrorAllowed())
+				assert.True(t, configured.IsFallbackOnErrorAllowed())
+			},
+		},
+		{
+			uc:     "with scopes redefined",
+			config: []byte(`scopes: [ foo ]`),
+			assert: func(t *testing.T, err error, _, _ *oidcAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				require.ErrorContains(t, err, "invalid keys: scopes")
+			},
+		},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			conf, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			prototype, err := newOIDCAuthenticator("auth1", oidcTestConfig(t, newOIDCTestProvider(t)))
+			require.NoError(t, err)
+
+			// WHEN
+			auth, err := prototype.WithConfig(conf)
+
+			// THEN
+			var (
+				configured *oidcAuthenticator
+				ok         bool
+			)
+
+			if err == nil {
+				configured, ok = auth.(*oidcAuthenticator)
+				require.True(t, ok)
+			}
+
+			tc.assert(t, err, prototype, configured)
+		})
+	}
+}
+
+func TestOIDCAuthenticatorExecute(t *testing.T) { //nolint:maintidx
+	t.Parallel()
+
+	encodeSession := func(t *testing.T, auth *oidcAuthenticator, sess *oidcSession) string {
+		t.Helper()
+
+		value, err := auth.sessionCodec.encode(sess)
+		require.NoError(t, err)
+
+		return value
+	}
+
+	encodeLoginState := func(t *testing.T, auth *oidcAuthenticator, state *oidcLoginState) string {
+		t.Helper()
+
+		value, err := auth.loginStateCodec.encode(state)
+		require.NoError(t, err)
+
+		return value
+	}
+
+	assertLoginRedirect := func(t *testing.T, err error, auth *oidcAuthenticator, op *oidcTestProvider, returnTo string) {
+		t.Helper()
+
+		var redirectErr *heimdall.RedirectError
+
+		require.ErrorAs(t, err, &redirectErr)
+		assert.Equal(t, http.StatusFound, redirectErr.Code)
+
+		authURL, err := url.Parse(redirectErr.RedirectTo)
+		require.NoError(t, err)
+
+		query := authURL.Query()
+		assert.Equal(t, op.srv.URL+"/authorize", fmt.Sprintf("%s://%s%s", authURL.Scheme, authURL.Host, authURL.Path))
+		assert.Equal(t, "login", query.Get("prompt"))
+		assert.Equal(t, "code", query.Get("response_type"))
+		assert.Equal(t, "heimdall", query.Get("client_id"))
+		assert.Equal(t, "https://app.local/oauth2/callback", query.Get("redirect_uri"))
+		assert.Equal(t, "openid profile", query.Get("scope"))
+		assert.Equal(t, "S256", query.Get("code_challenge_method"))
+
+		require.Len(t, redirectErr.Cookies, 1)
+		cookie := redirectErr.Cookies[0]
+		assert.Equal(t, "heimdall_session_login", cookie.Name)
+		assert.Equal(t, "/", cookie.Path)
+		assert.True(t, cookie.HttpOnly)
+		assert.True(t, cookie.Secure)
+		assert.Equal(t, http.SameSiteLaxMode, cookie.SameSite)
+		assert.Equal(t, int(defaultOIDCLoginStateTTL.Seconds()), cookie.MaxAge)
+
+		var state oidcLoginState
+		require.NoError(t, auth.loginStateCodec.decode(cookie.Value, &state))
+
+		challenge := sha256.Sum256([]byte(state.CodeVerifier))
+
+		assert.Equal(t, returnTo, state.ReturnTo)
+		assert.Equal(t, state.State, query.Get("state"))
+		assert.Equal(t, state.Nonce, query.Get("nonce"))
+		assert.Equal(t, base64.RawURLEncoding.EncodeToString(challenge[:]), query.Get("code_challenge"))
+		assert.False(t, state.expired())
+	}
+
+	for _, tc := range []struct {
+		uc             string
+		method         string
+		requestURL     string
+		configureOP    func(t *testing.T, op *oidcTestProvider)
+		configureMocks func(t *testing.T, auth *oidcAuthenticator, reqf *mocks.RequestFunctionsMock)
+		assert         func(t *testing.T, err error, sub *subject.Subject, auth *oidcAuthenticator, op *oidcTestProvider)
+	}{
+		{
+			uc:         "no session on a non navigation request",
+			method:     http.MethodPost,
+			requestURL: "https://app.local/api",
+			configureMocks: func(t *testing.T, _ *oidcAuthenticator, reqf *mocks.RequestFunctionsMock) {
+				t.Helper()
+
+				reqf.EXPECT().Cookie("heimdall_session").Return("")
+			},
+			assert: func(t *testing.T, err error, _ *subject.Subject, _ *oidcAuthenticator, op *oidcTestProvider) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrAuthentication)
+				require.ErrorContains(t, err, "no session cookie present")
+				assert.Empty(t, op.tokenRequests)
+			},
+		},
+		{
+			uc:         "no session on a navigation request starts login",
+			method:     http.MethodGet,
+			requestURL: "https://app.local/foo?bar=baz",
+			configureMocks: func(t *testing.T, _ *oidcAuthenticator, reqf *mocks.RequestFunctionsMock) {
+				t.Helper()
+
+				reqf.EXPECT().Cookie("heimdall_session").Return("")
+			},
+			assert: func(t *testing.T, err error, _ *subject.Subject, auth *oidcAuthenticator, op *oidcTestProvider) {
+				t.Helper()
+
+				assertLoginRedirect(t, err, auth, op, "https://app.local/foo?bar=baz")
+			},
+		},
+		{
+			uc:         "invalid session on a navigation request starts login",
+			method:     http.MethodGet,
+			requestURL: "https://app.local/foo",
+			configureMocks: func(t *testing.T, _ *oidcAuthenticator, reqf *mocks.RequestFunctionsMock) {
+				t.Helper()
+
+				reqf.EXPECT().Cookie("heimdall_session").Return("foo.bar.baz.bar.foo")
+			},
+			assert: func(t *testing.T, err error, _ *subject.Subject, auth *oidcAuthenticator, op *oidcTestProvider) {
+				t.Helper()
+
+				assertLoginRedirect(t, err, auth, op, "https://app.local/foo")
+			},
+		},
+		{
+			uc:         "valid session",
+			method:     http.MethodPost,
+			requestURL: "https://app.local/api",
+			configureMocks: func(t *testing.T, auth *oidcAuthenticator, reqf *mocks.RequestFunctionsMock) {
+				t.Helper()
+
+				reqf.EXPECT().Cookie("heimdall_session").Return(encodeSession(t, auth, &oidcSession{
+					Claims:    []byte(`{"sub":"foo","email":"foo@bar.baz"}`),
+					ExpiresAt: time.Now().Add(time.Minute).Unix(),
+				}))
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject, _ *oidcAuthenticator, op *oidcTestProvider) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "foo", sub.ID)
+				assert.Equal(t, map[string]any{"sub": "foo", "email": "foo@bar.baz"}, sub.Attributes)
+				assert.Empty(t, op.tokenRequests)
+			},
+		},
+		{
+			uc:         "expired session on a non navigation request",
+			method:     http.MethodPost,
+			requestURL: "https://app.local/api",
+			configureMocks: func(t *testing.T, auth *oidcAuthenticator, reqf *mocks.RequestFunctionsMock) {
+				t.Helper()
+
+				reqf.EXPECT().Cookie("heimdall_session").Return(encodeSession(t, auth, &oidcSession{
+					Claims:       []byte(`{"sub":"foo"}`),
+					RefreshToken: "old-refresh-token",
+					ExpiresAt:    time.Now().Add(-time.Minute).Unix(),
+				}))
+			},
+			assert: func(t *testing.T, err error, _ *subject.Subject, _ *oidcAuthenticator, op *oidcTestProvider) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrAuthentication)
+				require.ErrorContains(t, err, "session expired")
+				assert.Empty(t, op.tokenRequests)
+			},
+		},
+		{
+			uc:         "expired session renewed using the refresh token",
+			method:     http.MethodGet,
+			requestURL: "https://app.local/foo",
+			configureOP: func(t *testing.T, op *oidcTestProvider) {
+				t.Helper()
+
+				op.refreshToken = "new-refresh-token"
+			},
+			configureMocks: func(t *testing.T, auth *oidcAuthenticator, reqf *mocks.RequestFunctionsMock) {
+				t.Helper()
+
+				reqf.EXPECT().Cookie("heimdall_session").Return(encodeSession(t, auth, &oidcSession{
+					Claims:       []byte(`{"sub":"foo"}`),
+					RefreshToken: "old-refresh-token",
+					ExpiresAt:    time.Now().Add(-time.Minute).Unix(),
+				}))
+			},
+			assert: func(t *testing.T, err error, _ *subject.Subject, auth *oidcAuthenticator, op *oidcTestProvider) {
+				t.Helper()
+
+				var redirectErr *heimdall.RedirectError
+
+				require.ErrorAs(t, err, &redirectErr)
+				assert.Equal(t, http.StatusFound, redirectErr.Code)
+				assert.Equal(t, "https://app.local/foo", redirectErr.RedirectTo)
+
+				require.Len(t, op.tokenRequests, 1)
+				assert.Equal(t, "refresh_token", op.tokenRequests[0].Get("grant_type"))
+				assert.Equal(t, "old-refresh-token", op.tokenRequests[0].Get("refresh_token"))
+				assert.Equal(t, []string{"heimdall", "secret%3A1"}, op.basicAuth)
+
+				cookie := findCookie(redirectErr.Cookies, "heimdall_session")
+				require.NotNil(t, cookie)
+
+				var sess oidcSession
+				require.NoError(t, auth.sessionCodec.decode(cookie.Value, &sess))
+
+				assert.Equal(t, "new-refresh-token", sess.RefreshToken)
+				assert.False(t, sess.expired())
+				assert.Contains(t, string(sess.Claims), `"email":"foo@bar.baz"`)
+			},
+		},
+		{
+			uc:         "expired session renewal rejected due to subject change",
+			method:     http.MethodGet,
+			requestURL: "https://app.local/foo",
+			configureOP: func(t *testing.T, op *oidcTestProvider) {
+				t.Helper()
+
+				op.subject = "bar"
+			},
+			configureMocks: func(t *testing.T, auth *oidcAuthenticator, reqf *mocks.RequestFunctionsMock) {
+				t.Helper()
+
+				reqf.EXPECT().Cookie("heimdall_session").Return(encodeSession(t, auth, &oidcSession{
+					Claims:       []byte(`{"sub":"foo"}`),
+					RefreshToken: "old-refresh-token",
+					ExpiresAt:    time.Now().Add(-time.Minute).Unix(),
+				}))
+			},
+			assert: func(t *testing.T, err error, _ *subject.Subject, auth *oidcAuthenticator, op *oidcTestProvider) {
+				t.Helper()
+
+				require.Len(t, op.tokenRequests, 1)
+				assertLoginRedirect(t, err, auth, op, "https://app.local/foo")
+			},
+		},
+		{
+			uc:         "expired session with failing renewal starts login",
+			method:     http.MethodGet,
+			requestURL: "https://app.local/foo",
+			configureOP: func(t *testing.T, op *oidcTestProvider) {
+				t.Helper()
+
+				op.failTokens = true
+			},
+			configureMocks: func(t *testing.T, auth *oidcAuthenticator, reqf *mocks.RequestFunctionsMock) {
+				t.Helper()
+
+				reqf.EXPECT().Cookie("heimdall_session").Return(encodeSession(t, auth, &oidcSession{
+					Claims:       []byte(`{"sub":"foo"}`),
+					RefreshToken: "old-refresh-token",
+					ExpiresAt:    time.Now().Add(-time.Minute).Unix(),
+				}))
+			},
+			assert: func(t *testing.T, err error, _ *subject.Subject, auth *oidcAuthenticator, op *oidcTestProvider) {
+				t.Helper()
+
+				require.Len(t, op.tokenRequests, 1)
+				assertLoginRedirect(t, err, auth, op, "https://app.local/foo")
+			},
+		},
+		{
+			uc:         "callback with error response",
+			method:     http.MethodGet,
+			requestURL: "https://app.local/oauth2/callback?error=access_denied&error_description=denied",
+			assert: func(t *testing.T, err error, _ *subject.Subject, _ *oidcAuthenticator, _ *oidcTestProvider) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrAuthentication)
+				require.ErrorContains(t, err, "authorization request failed with access_denied: denied")
+			},
+		},
+		{
+			uc:         "callback without login state",
+			method:     http.MethodGet,
+			requestURL: "https://app.local/oauth2/callback?code=foo&state=bar",
+			configureMocks: func(t *testing.T, _ *oidcAuthenticator, reqf *mocks.RequestFunctionsMock) {
+				t.Helper()
+
+				reqf.EXPECT().Cookie("heimdall_session_login").Return("")
+			},
+			assert: func(t *testing.T, err error, _ *subject.Subject, _ *oidcAuthenticator, _ *oidcTestProvider) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrAuthentication)
+				require.ErrorContains(t, err, "no valid login state present")
+			},
+		},
+		{
+			uc:         "callback with expired login state",
+			method:     http.MethodGet,
+			requestURL: "https://app.local/oauth2/callback?code=foo&state=bar",
+			configureMocks: func(t *testing.T, auth *oidcAuthenticator, reqf *mocks.RequestFunctionsMock) {
+				t.Helper()
+
+				reqf.EXPECT().Cookie("heimdall_session_login").Return(encodeLoginState(t, auth, &oidcLoginState{
+					State: "bar", Nonce: "baz", CodeVerifier: "verifier", ReturnTo: "https://app.local/foo",
+					ExpiresAt: time.Now().Add(-time.Minute).Unix(),
+				}))
+			},
+			assert: func(t *testing.T, err error, _ *subject.Subject, _ *oidcAuthenticator, op *oidcTestProvider) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrAuthentication)
+				require.ErrorContains(t, err, "login state expired")
+				assert.Empty(t, op.tokenRequests)
+			},
+		},
+		{
+			uc:         "callback with state mismatch",
+			method:     http.MethodGet,
+			requestURL: "https://app.local/oauth2/callback?code=foo&state=foo",
+			configureMocks: func(t *testing.T, auth *oidcAuthenticator, reqf *mocks.RequestFunctionsMock) {
+				t.Helper()
+
+				reqf.EXPECT().Cookie("heimdall_session_login").Return(encodeLoginState(t, auth, &oidcLoginState{
+					State: "bar", Nonce: "baz", CodeVerifier: "verifier", ReturnTo: "https://app.local/foo",
+					ExpiresAt: time.Now().Add(time.Minute).Unix(),
+				}))
+			},
+			assert: func(t *testing.T, err error, _ *subject.Subject, _ *oidcAuthenticator, op *oidcTestProvider) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrAuthentication)
+				require.ErrorContains(t, err, "state mismatch")
+				assert.Empty(t, op.tokenRequests)
+			},
+		},
+		{
+			uc:         "callback with failing code exchange",
+			method:     http.MethodGet,
+			requestURL: "https://app.local/oauth2/callback?code=foo&state=bar",
+			configureOP: func(t *testing.T, op *oidcTestProvider) {
+				t.Helper()
+
+				op.failTokens = true
+			},
+			configureMocks: func(t *testing.T, auth *oidcAuthenticator, reqf *mocks.RequestFunctionsMock) {
+				t.Helper()
+
+				reqf.EXPECT().Cookie("heimdall_session_login").Return(encodeLoginState(t, auth, &oidcLoginState{
+					State: "bar", Nonce: "baz", CodeVerifier: "verifier", ReturnTo: "https://app.local/foo",
+					ExpiresAt: time.Now().Add(time.Minute).Unix(),
+				}))
+			},
+			assert: func(t *testing.T, err error, _ *subject.Subject, _ *oidcAuthenticator, op *oidcTestProvider) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrAuthentication)
+				require.ErrorContains(t, err, "token request failed")
+				assert.Len(t, op.tokenRequests, 1)
+			},
+		},
+		{
+			uc:         "callback with nonce mismatch",
+			method:     http.MethodGet,
+			requestURL: "https://app.local/oauth2/callback?code=foo&state=bar",
+			configureOP: func(t *testing.T, op *oidcTestProvider) {
+				t.Helper()
+
+				op.nonce = "foo"
+			},
+			configureMocks: func(t *testing.T, auth *oidcAuthenticator, reqf *mocks.RequestFunctionsMock) {
+				t.Helper()
+
+				reqf.EXPECT().Cookie("heimdall_session_login").Return(encodeLoginState(t, auth, &oidcLoginState{
+					State: "bar", Nonce: "baz", CodeVerifier: "verifier", ReturnTo: "https://app.local/foo",
+					ExpiresAt: time.Now().Add(time.Minute).Unix(),
+				}))
+			},
+			assert: func(t *testing.T, err error, _ *subject.Subject, _ *oidcAuthenticator, _ *oidcTestProvider) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrAuthentication)
+				require.ErrorContains(t, err, "nonce mismatch")
+			},
+		},
+		{
+			uc:         "successful callback",
+			method:     http.MethodGet,
+			requestURL: "https://app.local/oauth2/callback?code=foo&state=bar",
+			configureOP: func(t *testing.T, op *oidcTestProvider) {
+				t.Helper()
+
+				op.nonce = "baz"
+			},
+			configureMocks: func(t *testing.T, auth *oidcAuthenticator, reqf *mocks.RequestFunctionsMock) {
+				t.Helper()
+
+				reqf.EXPECT().Cookie("heimdall_session_login").Return(encodeLoginState(t, auth, &oidcLoginState{
+					State: "bar", Nonce: "baz", CodeVerifier: "verifier", ReturnTo: "https://app.local/foo",
+					ExpiresAt: time.Now().Add(time.Minute).Unix(),
+				}))
+			},
+			assert: func(t *testing.T, err error, _ *subject.Subject, auth *oidcAuthenticator, op *oidcTestProvider) {
+				t.Helper()
+
+				var redirectErr *heimdall.RedirectError
+
+				require.ErrorAs(t, err, &redirectErr)
+				assert.Equal(t, http.StatusFound, redirectErr.Code)
+				assert.Equal(t, "https://app.local/foo", redirectErr.RedirectTo)
+
+				require.Len(t, op.tokenRequests, 1)
+				assert.Equal(t, url.Values{
+					"grant_type":    []string{"authorization_code"},
+					"code":          []string{"foo"},
+					"redirect_uri":  []string{"https://app.local/oauth2/callback"},
+					"code_verifier": []string{"verifier"},
+				}, op.tokenRequests[0])
+				assert.Equal(t, []string{"heimdall", "secret%3A1"}, op.basicAuth)
+
+				require.Len(t, redirectErr.Cookies, 2)
+
+				loginCookie := findCookie(redirectErr.Cookies, "heimdall_session_login")
+				require.NotNil(t, loginCookie)
+				assert.Empty(t, loginCookie.Value)
+				assert.Negative(t, loginCookie.MaxAge)
+
+				sessionCookie := findCookie(redirectErr.Cookies, "heimdall_session")
+				require.NotNil(t, sessionCookie)
+				assert.True(t, sessionCookie.HttpOnly)
+				assert.True(t, sessionCookie.Secure)
+				assert.Equal(t, "/", sessionCookie.Path)
+
+				var sess oidcSession
+				require.NoError(t, auth.sessionCodec.decode(sessionCookie.Value, &sess))
+
+				assert.Equal(t, "refresh-token", sess.RefreshToken)
+				assert.False(t, sess.expired())
+
+				sub, err := auth.createSubject(&sess)
+				require.NoError(t, err)
+				assert.Equal(t, "foo", sub.ID)
+				assert.Equal(t, "foo@bar.baz", sub.Attributes["email"])
+			},
+		},
+		{
+			uc:         "callback resulting in a too big session cookie",
+			method:     http.MethodGet,
+			requestURL: "https://app.local/oauth2/callback?code=foo&state=bar",
+			configureOP: func(t *testing.T, op *oidcTestProvider) {
+				t.Helper()
+
+				// random data can not be compressed
+				buf := make([]byte, maxCookieSize)
+				_, err := rand.Read(buf)
+				require.NoError(t, err)
+
+				op.nonce = "baz"
+				op.refreshToken = base64.RawURLEncoding.EncodeToString(buf)
+			},
+			configureMocks: func(t *testing.T, auth *oidcAuthenticator, reqf *mocks.RequestFunctionsMock) {
+				t.Helper()
+
+				reqf.EXPECT().Cookie("heimdall_session_login").Return(encodeLoginState(t, auth, &oidcLoginState{
+					State: "bar", Nonce: "baz", CodeVerifier: "verifier", ReturnTo: "https://app.local/foo",
+					ExpiresAt: time.Now().Add(time.Minute).Unix(),
+				}))
+			},
+			assert: func(t *testing.T, err error, _ *subject.Subject, _ *oidcAuthenticator, _ *oidcTestProvider) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrInternal)
+				require.ErrorContains(t, err, "exceeds the limit of 4096 bytes")
+			},
+		},
+		{
+			uc:         "logout using GET",
+			method:     http.MethodGet,
+			requestURL: "https://app.local/oauth2/logout",
+			assert: func(t *testing.T, err error, _ *subject.Subject, _ *oidcAuthenticator, _ *oidcTestProvider) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrMethodNotAllowed)
+
+				var mna *heimdall.MethodNotAllowedError
+				require.ErrorAs(t, err, &mna)
+				assert.Equal(t, []string{http.MethodPost}, mna.AllowedMethods)
+			},
+		},
+		{
+			uc:         "cross-site logout",
+			method:     http.MethodPost,
+			requestURL: "https://app.local/oauth2/logout",
+			configureMocks: func(t *testing.T, _ *oidcAuthenticator, reqf *mocks.RequestFunctionsMock) {
+				t.Helper()
+
+				reqf.EXPECT().Header("Origin").Return("https://evil.local")
+			},
+			assert: func(t *testing.T, err error, _ *subject.Subject, _ *oidcAuthenticator, _ *oidcTestProvider) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrArgument)
+				require.ErrorContains(t, err, "cross-site logout request rejected")
+			},
+		},
+		{
+			uc:         "logout without origin and referer",
+			method:     http.MethodPost,
+			requestURL: "https://app.local/oauth2/logout",
+			configureMocks: func(t *testing.T, _ *oidcAuthenticator, reqf *mocks.RequestFunctionsMock) {
+				t.Helper()
+
+				reqf.EXPECT().Header("Origin").Return("")
+				reqf.EXPECT().Header("Referer").Return("")
+			},
+			assert: func(t *testing.T, err error, _ *subject.Subject, _ *oidcAuthenticator, _ *oidcTestProvider) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrArgument)
+				require.ErrorContains(t, err, "cross-site logout request rejected")
+			},
+		},
+		{
+			uc:         "logout with referer from the same origin",
+			method:     http.MethodPost,
+			requestURL: "https://app.local/oauth2/logout",
+			configureMocks: func(t *testing.T, _ *oidcAuthenticator, reqf *mocks.RequestFunctionsMock) {
+				t.Helper()
+
+				reqf.EXPECT().Header("Origin").Return("")
+				reqf.EXPECT().Header("Referer").Return("https://app.local/profile")
+			},
+			assert: func(t *testing.T, err error, _ *subject.Subject, _ *oidcAuthenticator, _ *oidcTestProvider) {
+				t.Helper()
+
+				var redirectErr *heimdall.RedirectError
+
+				require.ErrorAs(t, err, &redirectErr)
+				assert.Equal(t, http.StatusFound, redirectErr.Code)
+			},
+		},
+		{
+			uc:         "logout",
+			method:     http.MethodPost,
+			requestURL: "https://app.local/oauth2/logout",
+			configureMocks: func(t *testing.T, _ *oidcAuthenticator, reqf *mocks.RequestFunctionsMock) {
+				t.Helper()
+
+				reqf.EXPECT().Header("Origin").Return("https://app.local")
+			},
+			assert: func(t *testing.T, err error, _ *subject.Subject, _ *oidcAuthenticator, op *oidcTestProvider) {
+				t.Helper()
+
+				var redirectErr *heimdall.RedirectError
+
+				require.ErrorAs(t, err, &redirectErr)
+				assert.Equal(t, http.StatusFound, redirectErr.Code)
+				assert.Equal(t, op.srv.URL+"/logout?"+url.Values{
+					"client_id":                []string{"heimdall"},
+					"post_logout_redirect_uri": []string{"https://app.local/"},
+				}.Encode(), redirectErr.RedirectTo)
+
+				require.Len(t, redirectErr.Cookies, 1)
+				assert.Equal(t, "heimdall_session", redirectErr.Cookies[0].Name)
+				assert.Empty(t, redirectErr.Cookies[0].Value)
+				assert.Negative(t, redirectErr.Cookies[0].MaxAge)
+			},
+		},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			// GIVEN
+			op := newOIDCTestProvider(t)
+			if tc.configureOP != nil {
+				tc.configureOP(t, op)
+			}
+
+			auth, err := newOIDCAuthenticator("auth1", oidcTestConfig(t, op))
+			require.NoError(t, err)
+
+			requestURL, err := url.Parse(tc.requestURL)
+			require.NoError(t, err)
+
+			reqf := mocks.NewRequestFunctionsMock(t)
+			if tc.configureMocks != nil {
+				tc.configureMocks(t, auth, reqf)
+			}
+
+			ctx := mocks.NewContextMock(t)
+			ctx.EXPECT().AppContext().Return(cache.WithContext(context.Background(), memory.New()))
+			ctx.EXPECT().Request().Return(&heimdall.Request{
+				RequestFunctions: reqf,
+				Method:           tc.method,
+				URL:              &heimdall.URL{URL: *requestURL},
+			})
+
+			// WHEN
+			var sub *subject.Subject
+
+			endpoints := callback.NewRegistry()
+			for _, endpoint := range auth.Endpoints() {
+				require.NoError(t, endpoints.Register(endpoint.URL, endpoint.Handler))
+			}
+
+			if handler, ok := endpoints.Handler(requestURL); ok {
+				err = handler.HandleRequest(ctx)
+			} else {
+				sub, err = auth.Execute(ctx)
+			}
+
+			// THEN
+			tc.assert(t, err, sub, auth, op)
+		})
+	}
+}
+
+func TestOIDCSessionExpiry(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+
+	assert.InDelta(t, now.Add(time.Minute).Unix(),
+		sessionExpiry(&oidcTokenResponse{ExpiresIn: 60}, []byte(`{"exp":1}`)), 1)
+	assert.Equal(t, int64(1000), sessionExpiry(&oidcTokenResponse{}, []byte(`{"exp":1000}`)))
+	assert.InDelta(t, now.Add(defaultOIDCSessionLifespan).Unix(),
+		sessionExpiry(&oidcTokenResponse{}, []byte(`{}`)), 1)
+
+	// just to make sure the login state values are unique
+	state1, err := newOIDCLoginState("foo")
+	require.NoError(t, err)
+	state2, err := newOIDCLoginState("foo")
+	require.NoError(t, err)
+
+	assert.NotEqual(t, state1.State, state2.State)
+	assert.NotEqual(t, state1.Nonce, state2.Nonce)
+	assert.NotEqual(t, state1.CodeVerifier, state2.CodeVerifier)
+	assert.Len(t, state1.CodeVerifier, 43)
+	assert.True(t, strings.HasPrefix(state1.ReturnTo, "foo"))
+}
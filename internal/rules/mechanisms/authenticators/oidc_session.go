@@ -0,0 +1,195 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package authenticators
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"net/http"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/goccy/go-json"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/x"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+	"github.com/dadrus/heimdall/internal/x/stringx"
+)
+
+const (
+	defaultOIDCSessionCookieName = "heimdall_session"
+	oidcLoginStateCookieSuffix   = "_login"
+)
+
+type OIDCSessionConfig struct {
+	CookieName string `mapstructure:"cookie_name"`
+	Domain     string `mapstructure:"domain"`
+	Path       string `mapstructure:"path"`
+	Secure     *bool  `mapstructure:"secure"`
+	SameSite   string `mapstructure:"same_site"   validate:"omitempty,oneof=lax strict none"`
+	Secret     string `mapstructure:"secret"      validate:"required,min=32"`
+}
+
+func (c *OIDCSessionConfig) init() {
+	c.CookieName = x.IfThenElse(len(c.CookieName) != 0, c.CookieName, defaultOIDCSessionCookieName)
+	c.Path = x.IfThenElse(len(c.Path) != 0, c.Path, "/")
+
+	if c.Secure == nil {
+		secure := true
+		c.Secure = &secure
+	}
+}
+
+// loginStateCookieName returns the name of the cookie holding the login state. There is a single
+// cookie only, which is overwritten by every login started. Otherwise, each request of a not yet
+// authenticated user would set a further cookie, letting them pile up until the request headers
+// exceed the limits of the servers. As a consequence, only the most recent of parallel logins,
+// e.g. started in different tabs, can be completed.
+func (c *OIDCSessionConfig) loginStateCookieName() string {
+	return c.CookieName + oidcLoginStateCookieSuffix
+}
+
+func (c *OIDCSessionConfig) sameSite() http.SameSite {
+	switch c.SameSite {
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteLaxMode
+	}
+}
+
+func (c *OIDCSessionConfig) sessionCookie(value string) *http.Cookie {
+	return &http.Cookie{
+		Name:     c.CookieName,
+		Value:    value,
+		Domain:   c.Domain,
+		Path:     c.Path,
+		Secure:   *c.Secure,
+		HttpOnly: true,
+		SameSite: c.sameSite(),
+	}
+}
+
+func (c *OIDCSessionConfig) loginStateCookie(value string, ttl time.Duration) *http.Cookie {
+	return &http.Cookie{
+		Name:     c.loginStateCookieName(),
+		Value:    value,
+		Domain:   c.Domain,
+		Path:     "/",
+		MaxAge:   int(ttl.Seconds()),
+		Secure:   *c.Secure,
+		HttpOnly: true,
+		// the cookie must be sent with the redirect from the OP to the callback endpoint
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+func expiredCookie(cookie *http.Cookie) *http.Cookie {
+	cookie.Value = ""
+	cookie.MaxAge = -1
+
+	return cookie
+}
+
+type oidcSession struct {
+	Claims       json.RawMessage `json:"claims"`
+	RefreshToken string          `json:"refresh_token,omitempty"`
+	ExpiresAt    int64           `json:"expires_at"`
+}
+
+func (s *oidcSession) expired() bool { return time.Now().Unix() >= s.ExpiresAt }
+
+type oidcLoginState struct {
+	State        string `json:"state"`
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"code_verifier"`
+	ReturnTo     string `json:"return_to"`
+	ExpiresAt    int64  `json:"expires_at"`
+}
+
+func (s *oidcLoginState) expired() bool { return time.Now().Unix() >= s.ExpiresAt }
+
+// cookieCodec protects the values of the cookies issued by the oidc authenticator. The values are
+// serialized as compact JWEs using direct encryption with a key derived from the configured secret
+// and the purpose of the cookie, so that a cookie issued for one purpose cannot be used for another one.
+type cookieCodec struct {
+	key []byte
+}
+
+func newCookieCodec(secret, purpose string) *cookieCodec {
+	mac := hmac.New(sha256.New, stringx.ToBytes(secret))
+	mac.Write(stringx.ToBytes(purpose))
+
+	return &cookieCodec{key: mac.Sum(nil)}
+}
+
+func (c *cookieCodec) encode(value any) (string, error) {
+	rawValue, err := json.Marshal(value)
+	if err != nil {
+		return "", errorchain.NewWithMessage(heimdall.ErrInternal, "failed to marshal cookie value").
+			CausedBy(err)
+	}
+
+	encrypter, err := jose.NewEncrypter(jose.A256GCM,
+		jose.Recipient{Algorithm: jose.DIRECT, Key: c.key},
+		&jose.EncrypterOptions{Compression: jose.DEFLATE})
+	if err != nil {
+		return "", errorchain.NewWithMessage(heimdall.ErrInternal, "failed to create cookie encrypter").
+			CausedBy(err)
+	}
+
+	obj, err := encrypter.Encrypt(rawValue)
+	if err != nil {
+		return "", errorchain.NewWithMessage(heimdall.ErrInternal, "failed to encrypt cookie value").
+			CausedBy(err)
+	}
+
+	return obj.CompactSerialize()
+}
+
+func (c *cookieCodec) decode(value string, target any) error {
+	if len(value) == 0 {
+		return errorchain.NewWithMessage(heimdall.ErrArgument, "no cookie value present")
+	}
+
+	obj, err := jose.ParseEncrypted(value)
+	if err != nil {
+		return errorchain.NewWithMessage(heimdall.ErrArgument, "failed to parse cookie value").
+			CausedBy(err)
+	}
+
+	if obj.Header.Algorithm != string(jose.DIRECT) {
+		return errorchain.NewWithMessagef(heimdall.ErrArgument,
+			"unexpected key management algorithm %s", obj.Header.Algorithm)
+	}
+
+	rawValue, err := obj.Decrypt(c.key)
+	if err != nil {
+		return errorchain.NewWithMessage(heimdall.ErrArgument, "failed to decrypt cookie value").
+			CausedBy(err)
+	}
+
+	if err = json.Unmarshal(rawValue, target); err != nil {
+		return errorchain.NewWithMessage(heimdall.ErrArgument, "failed to unmarshal cookie value").
+			CausedBy(err)
+	}
+
+	return nil
+}
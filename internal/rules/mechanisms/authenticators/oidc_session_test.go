@@ -0,0 +1,114 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package authenticators
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+)
+
+func TestCookieCodec(t *testing.T) {
+	t.Parallel()
+
+	const secret = "a-not-so-secret-secret-with-32-or-more-characters"
+
+	codec := newCookieCodec(secret, oidcSessionCookiePurpose)
+	session := &oidcSession{Claims: []byte(`{"sub":"foo"}`), RefreshToken: "bar", ExpiresAt: 1000}
+
+	encoded, err := codec.encode(session)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		uc     string
+		codec  *cookieCodec
+		value  string
+		assert func(t *testing.T, err error, decoded *oidcSession)
+	}{
+		{
+			uc:    "successful round trip",
+			codec: codec,
+			value: encoded,
+			assert: func(t *testing.T, err error, decoded *oidcSession) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, session, decoded)
+			},
+		},
+		{
+			uc:    "value encoded for another purpose",
+			codec: newCookieCodec(secret, oidcLoginStateCookiePurpose),
+			value: encoded,
+			assert: func(t *testing.T, err error, _ *oidcSession) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrArgument)
+				require.ErrorContains(t, err, "failed to decrypt")
+			},
+		},
+		{
+			uc:    "value encoded using another secret",
+			codec: newCookieCodec(secret+"-foo", oidcSessionCookiePurpose),
+			value: encoded,
+			assert: func(t *testing.T, err error, _ *oidcSession) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrArgument)
+				require.ErrorContains(t, err, "failed to decrypt")
+			},
+		},
+		{
+			uc:    "empty value",
+			codec: codec,
+			assert: func(t *testing.T, err error, _ *oidcSession) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrArgument)
+				require.ErrorContains(t, err, "no cookie value")
+			},
+		},
+		{
+			uc:    "malformed value",
+			codec: codec,
+			value: "foo.bar",
+			assert: func(t *testing.T, err error, _ *oidcSession) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrArgument)
+				require.ErrorContains(t, err, "failed to parse")
+			},
+		},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			// WHEN
+			var decoded oidcSession
+
+			err := tc.codec.decode(tc.value, &decoded)
+
+			// THEN
+			tc.assert(t, err, &decoded)
+		})
+	}
+}
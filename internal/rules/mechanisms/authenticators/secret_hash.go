@@ -0,0 +1,140 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package authenticators
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/dadrus/heimdall/internal/x/stringx"
+)
+
+var errUnsupportedSecretHash = errors.New("unsupported secret hash")
+
+type secretHash interface {
+	Matches(secret string) bool
+}
+
+// newSecretHash creates a secretHash from its textual representation. Supported are
+// bcrypt hashes ($2a$, $2b$, $2y$) and argon2 hashes in the PHC string format
+// ($argon2id$v=19$m=65536,t=3,p=4$<salt>$<hash>).
+func newSecretHash(value string) (secretHash, error) {
+	switch {
+	case strings.HasPrefix(value, "$2a$"), strings.HasPrefix(value, "$2b$"), strings.HasPrefix(value, "$2y$"):
+		if _, err := bcrypt.Cost(stringx.ToBytes(value)); err != nil {
+			return nil, fmt.Errorf("%w: %w", errUnsupportedSecretHash, err)
+		}
+
+		return bcryptHash(value), nil
+	case strings.HasPrefix(value, "$argon2id$"), strings.HasPrefix(value, "$argon2i$"):
+		return newArgon2Hash(value)
+	default:
+		return nil, errUnsupportedSecretHash
+	}
+}
+
+type bcryptHash string
+
+func (h bcryptHash) Matches(secret string) bool {
+	return bcrypt.CompareHashAndPassword(stringx.ToBytes(string(h)), stringx.ToBytes(secret)) == nil
+}
+
+type argon2Hash struct {
+	variant string
+	memory  uint32
+	time    uint32
+	threads uint8
+	salt    []byte
+	hash    []byte
+}
+
+func newArgon2Hash(value string) (*argon2Hash, error) {
+	// expected format: $<variant>$v=<version>$m=<memory>,t=<time>,p=<threads>$<salt>$<hash>
+	parts := strings.Split(value, "$")
+	if len(parts) != 6 { //nolint:gomnd
+		return nil, fmt.Errorf("%w: malformed argon2 hash", errUnsupportedSecretHash)
+	}
+
+	var (
+		version int
+		hash    argon2Hash
+		err     error
+	)
+
+	hash.variant = parts[1]
+
+	if _, err = fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, fmt.Errorf("%w: unsupported argon2 version", errUnsupportedSecretHash)
+	}
+
+	if _, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &hash.memory, &hash.time, &hash.threads); err != nil ||
+		hash.time == 0 || hash.threads == 0 {
+		return nil, fmt.Errorf("%w: malformed argon2 parameters", errUnsupportedSecretHash)
+	}
+
+	if hash.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return nil, fmt.Errorf("%w: malformed argon2 salt: %w", errUnsupportedSecretHash, err)
+	}
+
+	if hash.hash, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(hash.hash) == 0 {
+		return nil, fmt.Errorf("%w: malformed argon2 hash value", errUnsupportedSecretHash)
+	}
+
+	return &hash, nil
+}
+
+func (h *argon2Hash) Matches(secret string) bool {
+	var computed []byte
+
+	keyLen := uint32(len(h.hash))
+
+	if h.variant == "argon2id" {
+		computed = argon2.IDKey(stringx.ToBytes(secret), h.salt, h.time, h.memory, h.threads, keyLen)
+	} else {
+		computed = argon2.Key(stringx.ToBytes(secret), h.salt, h.time, h.memory, h.threads, keyLen)
+	}
+
+	return subtle.ConstantTimeCompare(computed, h.hash) == 1
+}
+
+// sha256Hash is used for API keys only. As these are random values with a high entropy, a
+// fast hash is sufficient, which allows looking up the entry an API key belongs to by its digest.
+type sha256Hash [sha256.Size]byte
+
+// newSHA256Hash creates a sha256Hash from the hex encoded SHA-256 digest of a secret.
+func newSHA256Hash(value string) (secretHash, error) {
+	decoded, err := hex.DecodeString(value)
+	if err != nil || len(decoded) != sha256.Size {
+		return nil, fmt.Errorf("%w: expected hex encoded SHA-256 digest", errUnsupportedSecretHash)
+	}
+
+	return sha256Hash(decoded), nil
+}
+
+func (h sha256Hash) Matches(secret string) bool {
+	digest := sha256.Sum256(stringx.ToBytes(secret))
+
+	return subtle.ConstantTimeCompare(digest[:], h[:]) == 1
+}
@@ -0,0 +1,219 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package authenticators
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func argon2TestHash(variant, secret string) string {
+	salt := []byte("somesaltvalue")
+
+	var hash []byte
+	if variant == "argon2id" {
+		hash = argon2.IDKey([]byte(secret), salt, 1, 1024, 1, 32)
+	} else {
+		hash = argon2.Key([]byte(secret), salt, 1, 1024, 1, 32)
+	}
+
+	return fmt.Sprintf("$%s$v=%d$m=1024,t=1,p=1$%s$%s", variant, argon2.Version,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(hash))
+}
+
+func sha256TestHash(secret string) string {
+	digest := sha256.Sum256([]byte(secret))
+
+	return hex.EncodeToString(digest[:])
+}
+
+func TestNewSecretHash(t *testing.T) {
+	t.Parallel()
+
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("foo"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		uc     string
+		value  string
+		assert func(t *testing.T, err error, hash secretHash)
+	}{
+		{
+			uc:    "bcrypt hash",
+			value: string(bcryptHash),
+			assert: func(t *testing.T, err error, hash secretHash) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.True(t, hash.Matches("foo"))
+				assert.False(t, hash.Matches("bar"))
+			},
+		},
+		{
+			uc:    "argon2id hash",
+			value: argon2TestHash("argon2id", "foo"),
+			assert: func(t *testing.T, err error, hash secretHash) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.True(t, hash.Matches("foo"))
+				assert.False(t, hash.Matches("bar"))
+			},
+		},
+		{
+			uc:    "argon2i hash",
+			value: argon2TestHash("argon2i", "foo"),
+			assert: func(t *testing.T, err error, hash secretHash) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.True(t, hash.Matches("foo"))
+				assert.False(t, hash.Matches("bar"))
+			},
+		},
+		{
+			uc:    "malformed bcrypt hash",
+			value: "$2y$10$foo",
+			assert: func(t *testing.T, err error, _ secretHash) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, errUnsupportedSecretHash)
+			},
+		},
+		{
+			uc:    "argon2 hash with unsupported version",
+			value: "$argon2id$v=16$m=1024,t=1,p=1$c2FsdA$aGFzaA",
+			assert: func(t *testing.T, err error, _ secretHash) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, errUnsupportedSecretHash)
+				assert.Contains(t, err.Error(), "version")
+			},
+		},
+		{
+			uc:    "argon2 hash with malformed parameters",
+			value: "$argon2id$v=19$m=1024,t=0,p=1$c2FsdA$aGFzaA",
+			assert: func(t *testing.T, err error, _ secretHash) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, errUnsupportedSecretHash)
+				assert.Contains(t, err.Error(), "parameters")
+			},
+		},
+		{
+			uc:    "argon2 hash with malformed salt",
+			value: "$argon2id$v=19$m=1024,t=1,p=1$!!$aGFzaA",
+			assert: func(t *testing.T, err error, _ secretHash) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, errUnsupportedSecretHash)
+				assert.Contains(t, err.Error(), "salt")
+			},
+		},
+		{
+			uc:    "argon2 hash with missing parts",
+			value: "$argon2id$v=19$m=1024,t=1,p=1$c2FsdA",
+			assert: func(t *testing.T, err error, _ secretHash) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, errUnsupportedSecretHash)
+			},
+		},
+		{
+			uc:    "plain text value",
+			value: "foo",
+			assert: func(t *testing.T, err error, _ secretHash) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, errUnsupportedSecretHash)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// WHEN
+			hash, err := newSecretHash(tc.value)
+
+			// THEN
+			tc.assert(t, err, hash)
+		})
+	}
+}
+
+func TestNewSHA256Hash(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc     string
+		value  string
+		assert func(t *testing.T, err error, hash secretHash)
+	}{
+		{
+			uc:    "hex encoded sha256 digest",
+			value: sha256TestHash("foo"),
+			assert: func(t *testing.T, err error, hash secretHash) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.True(t, hash.Matches("foo"))
+				assert.False(t, hash.Matches("bar"))
+			},
+		},
+		{
+			uc:    "bcrypt hash",
+			value: "$2a$10$YALD/CfM8N91.rQxY1U8r.VY9WHrt2Dz1fO2S6Ak3OfxVtTcwiX/.",
+			assert: func(t *testing.T, err error, _ secretHash) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, errUnsupportedSecretHash)
+				assert.Contains(t, err.Error(), "SHA-256")
+			},
+		},
+		{
+			uc:    "digest of wrong length",
+			value: "abcdef",
+			assert: func(t *testing.T, err error, _ secretHash) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, errUnsupportedSecretHash)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// WHEN
+			hash, err := newSHA256Hash(tc.value)
+
+			// THEN
+			tc.assert(t, err, hash)
+		})
+	}
+}
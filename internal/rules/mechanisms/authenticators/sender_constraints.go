@@ -0,0 +1,313 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package authenticators
+
+import (
+	"crypto"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/tidwall/gjson"
+
+	"github.com/dadrus/heimdall/internal/cache"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/rules/mechanisms/authenticators/extractors"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+const (
+	defaultDPoPMaxProofAge = 1 * time.Minute
+	dpopProofType          = "dpop+jwt"
+	dpopAuthScheme         = "DPoP"
+	dpopProofChallenge     = `DPoP error="invalid_dpop_proof"`
+)
+
+type DPoPConfig struct {
+	Required          bool           `mapstructure:"required"`
+	MaxProofAge       *time.Duration `mapstructure:"max_proof_age"`
+	AllowedAlgorithms []string       `mapstructure:"allowed_algorithms"`
+}
+
+type CertificateBindingConfig struct {
+	Required bool `mapstructure:"required"`
+}
+
+type dpopProofClaims struct {
+	JTI         string `json:"jti"`
+	HTTPMethod  string `json:"htm"`
+	HTTPURI     string `json:"htu"`
+	IssuedAt    int64  `json:"iat"`
+	AccessToken string `json:"ath"`
+}
+
+// senderConstraints verifies the binding of an access token to the client presenting it,
+// either by the means of DPoP proofs (RFC 9449) or mutual TLS (RFC 8705).
+type senderConstraints struct {
+	dpop        *DPoPConfig
+	certBinding *CertificateBindingConfig
+}
+
+func newSenderConstraints(dpop *DPoPConfig, certBinding *CertificateBindingConfig) *senderConstraints {
+	if dpop == nil && certBinding == nil {
+		return nil
+	}
+
+	if dpop != nil {
+		if dpop.MaxProofAge == nil {
+			maxAge := defaultDPoPMaxProofAge
+			dpop.MaxProofAge = &maxAge
+		}
+
+		if len(dpop.AllowedAlgorithms) == 0 {
+			dpop.AllowedAlgorithms = defaultAllowedAlgorithms()
+		}
+	}
+
+	return &senderConstraints{dpop: dpop, certBinding: certBinding}
+}
+
+// accessTokenExtractStrategies returns the strategies used to extract the access token if no
+// auth data source is configured. DPoP bound access tokens are sent using the DPoP authorization
+// scheme, which is only accepted if DPoP is enabled.
+func accessTokenExtractStrategies(dpop *DPoPConfig) extractors.CompositeExtractStrategy {
+	strategies := extractors.CompositeExtractStrategy{
+		extractors.HeaderValueExtractStrategy{Name: "Authorization", Scheme: "Bearer"},
+		extractors.QueryParameterExtractStrategy{Name: "access_token"},
+		extractors.BodyParameterExtractStrategy{Name: "access_token"},
+	}
+
+	if dpop != nil {
+		strategies = append(strategies,
+			extractors.HeaderValueExtractStrategy{Name: "Authorization", Scheme: dpopAuthScheme})
+	}
+
+	return strategies
+}
+
+// cacheDependency returns what the sender constraints rely on the cache for, if anything.
+func (sc *senderConstraints) cacheDependency() string {
+	if sc == nil || sc.dpop == nil {
+		return ""
+	}
+
+	return "DPoP proof replay protection"
+}
+
+func (sc *senderConstraints) verify(ctx heimdall.Context, accessToken string, rawClaims []byte) error {
+	if sc == nil {
+		return nil
+	}
+
+	if sc.certBinding != nil {
+		if err := sc.verifyCertificateBinding(ctx, gjson.GetBytes(rawClaims, `cnf.x5t\#S256`).String()); err != nil {
+			return err
+		}
+	}
+
+	if sc.dpop != nil {
+		if err := sc.verifyDPoPBinding(ctx, accessToken, gjson.GetBytes(rawClaims, "cnf.jkt").String()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (sc *senderConstraints) verifyCertificateBinding(ctx heimdall.Context, thumbprint string) error {
+	if len(thumbprint) == 0 {
+		if sc.certBinding.Required {
+			return errorchain.NewWithMessage(heimdall.ErrAuthentication,
+				"access token is not bound to a client certificate")
+		}
+
+		return nil
+	}
+
+	certs := ctx.Request().ClientCertificates()
+	if len(certs) == 0 {
+		return errorchain.NewWithMessage(heimdall.ErrAuthentication,
+			"access token is bound to a client certificate, but no client certificate present")
+	}
+
+	digest := sha256.Sum256(certs[0].Raw)
+	expected := base64.RawURLEncoding.EncodeToString(digest[:])
+
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(thumbprint)) != 1 {
+		return errorchain.NewWithMessage(heimdall.ErrAuthentication,
+			"access token is bound to a different client certificate")
+	}
+
+	return nil
+}
+
+func (sc *senderConstraints) verifyDPoPBinding(ctx heimdall.Context, accessToken, thumbprint string) error {
+	req := ctx.Request()
+	usesDPoPScheme := strings.HasPrefix(req.Header("Authorization"), dpopAuthScheme+" ")
+
+	if len(thumbprint) == 0 {
+		if sc.dpop.Required {
+			return errorchain.NewWithMessage(heimdall.ErrAuthentication, "access token is not DPoP bound")
+		}
+
+		if usesDPoPScheme {
+			return errorchain.NewWithMessage(heimdall.ErrAuthentication,
+				"DPoP authorization scheme used with an access token not bound to a DPoP key")
+		}
+
+		return nil
+	}
+
+	if !usesDPoPScheme {
+		return errorchain.NewWithMessage(heimdall.ErrAuthentication,
+			"DPoP bound access token must be presented using the DPoP authorization scheme")
+	}
+
+	proof := req.Header("DPoP")
+	if len(proof) == 0 {
+		return invalidDPoPProof("no DPoP proof present")
+	}
+
+	if strings.Contains(proof, ",") {
+		return invalidDPoPProof("multiple DPoP proofs present")
+	}
+
+	jwk, claims, err := sc.parseDPoPProof(proof)
+	if err != nil {
+		return err
+	}
+
+	if err = sc.verifyDPoPClaims(req, accessToken, claims); err != nil {
+		return err
+	}
+
+	keyThumbprint, err := jwk.Thumbprint(crypto.SHA256)
+	if err != nil {
+		return invalidDPoPProof("failed to calculate DPoP key thumbprint").
+			CausedBy(err)
+	}
+
+	if subtle.ConstantTimeCompare(
+		[]byte(base64.RawURLEncoding.EncodeToString(keyThumbprint)), []byte(thumbprint)) != 1 {
+		return errorchain.NewWithMessage(heimdall.ErrAuthentication,
+			"access token is bound to a different DPoP key")
+	}
+
+	return sc.checkReplay(ctx, thumbprint, claims.JTI)
+}
+
+func (sc *senderConstraints) parseDPoPProof(proof string) (*jose.JSONWebKey, *dpopProofClaims, error) {
+	jws, err := jose.ParseSigned(proof)
+	if err != nil {
+		return nil, nil, invalidDPoPProof("failed to parse DPoP proof").
+			CausedBy(err)
+	}
+
+	if len(jws.Signatures) != 1 {
+		return nil, nil, invalidDPoPProof("DPoP proof must have exactly one signature")
+	}
+
+	header := jws.Signatures[0].Header
+
+	if typ, _ := header.ExtraHeaders[jose.HeaderType].(string); typ != dpopProofType {
+		return nil, nil, invalidDPoPProof("DPoP proof has unexpected type '%s'", typ)
+	}
+
+	if !slices.Contains(sc.dpop.AllowedAlgorithms, header.Algorithm) {
+		return nil, nil, invalidDPoPProof("DPoP proof signature algorithm '%s' is not allowed", header.Algorithm)
+	}
+
+	if header.JSONWebKey == nil || !header.JSONWebKey.Valid() || !header.JSONWebKey.IsPublic() {
+		return nil, nil, invalidDPoPProof("DPoP proof does not contain a valid public key")
+	}
+
+	payload, err := jws.Verify(header.JSONWebKey)
+	if err != nil {
+		return nil, nil, invalidDPoPProof("DPoP proof signature verification failed").CausedBy(err)
+	}
+
+	var claims dpopProofClaims
+	if err = json.Unmarshal(payload, &claims); err != nil {
+		return nil, nil, invalidDPoPProof("failed to parse DPoP proof claims").
+			CausedBy(err)
+	}
+
+	return header.JSONWebKey, &claims, nil
+}
+
+func (sc *senderConstraints) verifyDPoPClaims(req *heimdall.Request, accessToken string, claims *dpopProofClaims) error {
+	if len(claims.JTI) == 0 {
+		return invalidDPoPProof("DPoP proof does not contain a jti claim")
+	}
+
+	if claims.HTTPMethod != req.Method {
+		return invalidDPoPProof("DPoP proof htm claim does not match the request method")
+	}
+
+	htu, err := url.Parse(claims.HTTPURI)
+	if err != nil ||
+		!strings.EqualFold(htu.Scheme, req.URL.Scheme) ||
+		!strings.EqualFold(htu.Host, req.URL.Host) ||
+		htu.EscapedPath() != req.URL.EscapedPath() {
+		return invalidDPoPProof("DPoP proof htu claim does not match the request URL")
+	}
+
+	issuedAt := time.Unix(claims.IssuedAt, 0)
+	if age := time.Since(issuedAt); claims.IssuedAt == 0 || age > *sc.dpop.MaxProofAge || -age > *sc.dpop.MaxProofAge {
+		return invalidDPoPProof("DPoP proof iat claim is outside of the acceptable time window")
+	}
+
+	digest := sha256.Sum256([]byte(accessToken))
+	if subtle.ConstantTimeCompare(
+		[]byte(base64.RawURLEncoding.EncodeToString(digest[:])), []byte(claims.AccessToken)) != 1 {
+		return invalidDPoPProof("DPoP proof ath claim does not match the access token")
+	}
+
+	return nil
+}
+
+func (sc *senderConstraints) checkReplay(ctx heimdall.Context, thumbprint, jti string) error {
+	digest := sha256.Sum256([]byte(thumbprint + ":" + jti))
+	key := "dpop_jti:" + hex.EncodeToString(digest[:])
+
+	// proofs are accepted within max_proof_age in both directions around iat
+	stored, err := cache.Ctx(ctx.AppContext()).SetIfAbsent(ctx.AppContext(), key, jti, 2**sc.dpop.MaxProofAge)
+	if err != nil {
+		return errorchain.NewWithMessage(heimdall.ErrInternal, "failed to check DPoP proof for replay").
+			CausedBy(err)
+	}
+
+	if !stored {
+		return invalidDPoPProof("DPoP proof has already been used")
+	}
+
+	return nil
+}
+
+// invalidDPoPProof creates an authentication error, which results in a challenge telling the client,
+// the DPoP proof has been rejected (see RFC 9449, section 7.1).
+func invalidDPoPProof(format string, args ...any) *errorchain.ErrorChain {
+	return errorchain.NewWithMessagef(heimdall.ErrAuthentication, format, args...).
+		CausedBy(&heimdall.AuthenticationChallengeError{Challenge: dpopProofChallenge})
+}
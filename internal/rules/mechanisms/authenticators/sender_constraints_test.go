@@ -0,0 +1,370 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package authenticators
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/cache"
+	"github.com/dadrus/heimdall/internal/cache/memory"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/heimdall/mocks"
+	"github.com/dadrus/heimdall/internal/x"
+	"github.com/dadrus/heimdall/internal/x/testsupport"
+)
+
+func sha256Base64(data []byte) string {
+	digest := sha256.Sum256(data)
+
+	return base64.RawURLEncoding.EncodeToString(digest[:])
+}
+
+func createDPoPProof(t *testing.T, key *ecdsa.PrivateKey, typ string, claims map[string]any) string {
+	t.Helper()
+
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.ES256, Key: key},
+		(&jose.SignerOptions{EmbedJWK: true}).WithType(jose.ContentType(typ)),
+	)
+	require.NoError(t, err)
+
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+
+	jws, err := signer.Sign(payload)
+	require.NoError(t, err)
+
+	proof, err := jws.CompactSerialize()
+	require.NoError(t, err)
+
+	return proof
+}
+
+func TestSenderConstraintsVerify(t *testing.T) {
+	t.Parallel()
+
+	const accessToken = "some.access.token"
+
+	dpopKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	jwk := jose.JSONWebKey{Key: dpopKey.Public()}
+	thumbprint, err := jwk.Thumbprint(crypto.SHA256)
+	require.NoError(t, err)
+
+	jkt := base64.RawURLEncoding.EncodeToString(thumbprint)
+
+	ca, err := testsupport.NewRootCA("Test Root CA", time.Hour)
+	require.NoError(t, err)
+
+	certKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	clientCert, err := ca.IssueCertificate(
+		testsupport.WithSubjectPubKey(&certKey.PublicKey, x509.ECDSAWithSHA256),
+		testsupport.WithValidity(time.Now(), time.Hour))
+	require.NoError(t, err)
+
+	proofClaims := func(mods ...func(claims map[string]any)) map[string]any {
+		claims := map[string]any{
+			"jti": "some-id",
+			"htm": http.MethodGet,
+			"htu": "https://foo.bar/api/resource",
+			"iat": time.Now().Unix(),
+			"ath": sha256Base64([]byte(accessToken)),
+		}
+
+		for _, mod := range mods {
+			mod(claims)
+		}
+
+		return claims
+	}
+
+	requestURL, err := url.Parse("https://foo.bar/api/resource?foo=bar")
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		uc           string
+		dpop         *DPoPConfig
+		certBinding  *CertificateBindingConfig
+		claims       string
+		headers      map[string]string
+		clientCerts  []*x509.Certificate
+		replay       bool
+		noCache      bool
+		errContains  string
+		invalidProof bool
+	}{
+		{
+			uc:     "no constraints configured",
+			claims: `{"cnf": {"jkt": "foo"}}`,
+		},
+		{
+			uc:          "not bound token with optional certificate binding",
+			certBinding: &CertificateBindingConfig{},
+			claims:      `{}`,
+		},
+		{
+			uc:          "not bound token with required certificate binding",
+			certBinding: &CertificateBindingConfig{Required: true},
+			claims:      `{}`,
+			errContains: "not bound to a client certificate",
+		},
+		{
+			uc:          "certificate bound token without client certificate",
+			certBinding: &CertificateBindingConfig{},
+			claims:      `{"cnf": {"x5t#S256": "` + sha256Base64(clientCert.Raw) + `"}}`,
+			errContains: "no client certificate present",
+		},
+		{
+			uc:          "certificate bound token with other client certificate",
+			certBinding: &CertificateBindingConfig{},
+			claims:      `{"cnf": {"x5t#S256": "` + sha256Base64(ca.Certificate.Raw) + `"}}`,
+			clientCerts: []*x509.Certificate{clientCert},
+			errContains: "bound to a different client certificate",
+		},
+		{
+			uc:          "certificate bound token with matching client certificate",
+			certBinding: &CertificateBindingConfig{Required: true},
+			claims:      `{"cnf": {"x5t#S256": "` + sha256Base64(clientCert.Raw) + `"}}`,
+			clientCerts: []*x509.Certificate{clientCert},
+		},
+		{
+			uc:      "not bound token with optional dpop",
+			dpop:    &DPoPConfig{},
+			claims:  `{}`,
+			headers: map[string]string{"Authorization": "Bearer " + accessToken},
+		},
+		{
+			uc:          "not bound token with required dpop",
+			dpop:        &DPoPConfig{Required: true},
+			claims:      `{}`,
+			headers:     map[string]string{"Authorization": "Bearer " + accessToken},
+			errContains: "not DPoP bound",
+		},
+		{
+			uc:          "not bound token presented using dpop scheme",
+			dpop:        &DPoPConfig{},
+			claims:      `{}`,
+			headers:     map[string]string{"Authorization": "DPoP " + accessToken},
+			errContains: "not bound to a DPoP key",
+		},
+		{
+			uc:          "dpop bound token presented using bearer scheme",
+			dpop:        &DPoPConfig{},
+			claims:      `{"cnf": {"jkt": "` + jkt + `"}}`,
+			headers:     map[string]string{"Authorization": "Bearer " + accessToken},
+			errContains: "must be presented using the DPoP authorization scheme",
+		},
+		{
+			uc:           "dpop bound token without proof",
+			dpop:         &DPoPConfig{},
+			claims:       `{"cnf": {"jkt": "` + jkt + `"}}`,
+			headers:      map[string]string{"Authorization": "DPoP " + accessToken},
+			errContains:  "no DPoP proof present",
+			invalidProof: true,
+		},
+		{
+			uc:     "dpop bound token with multiple proofs",
+			dpop:   &DPoPConfig{},
+			claims: `{"cnf": {"jkt": "` + jkt + `"}}`,
+			headers: map[string]string{
+				"Authorization": "DPoP " + accessToken,
+				"DPoP": createDPoPProof(t, dpopKey, "dpop+jwt", proofClaims()) + "," +
+					createDPoPProof(t, dpopKey, "dpop+jwt", proofClaims()),
+			},
+			errContains:  "multiple DPoP proofs present",
+			invalidProof: true,
+		},
+		{
+			uc:     "dpop proof with wrong type",
+			dpop:   &DPoPConfig{},
+			claims: `{"cnf": {"jkt": "` + jkt + `"}}`,
+			headers: map[string]string{
+				"Authorization": "DPoP " + accessToken,
+				"DPoP":          createDPoPProof(t, dpopKey, "JWT", proofClaims()),
+			},
+			errContains:  "unexpected type",
+			invalidProof: true,
+		},
+		{
+			uc:     "dpop proof with not allowed algorithm",
+			dpop:   &DPoPConfig{AllowedAlgorithms: []string{"PS256"}},
+			claims: `{"cnf": {"jkt": "` + jkt + `"}}`,
+			headers: map[string]string{
+				"Authorization": "DPoP " + accessToken,
+				"DPoP":          createDPoPProof(t, dpopKey, "dpop+jwt", proofClaims()),
+			},
+			errContains:  "algorithm 'ES256' is not allowed",
+			invalidProof: true,
+		},
+		{
+			uc:     "dpop proof for other method",
+			dpop:   &DPoPConfig{},
+			claims: `{"cnf": {"jkt": "` + jkt + `"}}`,
+			headers: map[string]string{
+				"Authorization": "DPoP " + accessToken,
+				"DPoP": createDPoPProof(t, dpopKey, "dpop+jwt",
+					proofClaims(func(claims map[string]any) { claims["htm"] = http.MethodPost })),
+			},
+			errContains:  "htm claim",
+			invalidProof: true,
+		},
+		{
+			uc:     "dpop proof for other url",
+			dpop:   &DPoPConfig{},
+			claims: `{"cnf": {"jkt": "` + jkt + `"}}`,
+			headers: map[string]string{
+				"Authorization": "DPoP " + accessToken,
+				"DPoP": createDPoPProof(t, dpopKey, "dpop+jwt",
+					proofClaims(func(claims map[string]any) { claims["htu"] = "https://foo.bar/api/other" })),
+			},
+			errContains:  "htu claim",
+			invalidProof: true,
+		},
+		{
+			uc:     "expired dpop proof",
+			dpop:   &DPoPConfig{},
+			claims: `{"cnf": {"jkt": "` + jkt + `"}}`,
+			headers: map[string]string{
+				"Authorization": "DPoP " + accessToken,
+				"DPoP": createDPoPProof(t, dpopKey, "dpop+jwt",
+					proofClaims(func(claims map[string]any) { claims["iat"] = time.Now().Add(-2 * time.Minute).Unix() })),
+			},
+			errContains:  "iat claim",
+			invalidProof: true,
+		},
+		{
+			uc:     "dpop proof for other access token",
+			dpop:   &DPoPConfig{},
+			claims: `{"cnf": {"jkt": "` + jkt + `"}}`,
+			headers: map[string]string{
+				"Authorization": "DPoP " + accessToken,
+				"DPoP": createDPoPProof(t, dpopKey, "dpop+jwt",
+					proofClaims(func(claims map[string]any) { claims["ath"] = sha256Base64([]byte("foo")) })),
+			},
+			errContains:  "ath claim",
+			invalidProof: true,
+		},
+		{
+			uc:     "dpop proof signed with other key",
+			dpop:   &DPoPConfig{},
+			claims: `{"cnf": {"jkt": "` + jkt + `"}}`,
+			headers: map[string]string{
+				"Authorization": "DPoP " + accessToken,
+				"DPoP":          createDPoPProof(t, otherKey, "dpop+jwt", proofClaims()),
+			},
+			errContains: "bound to a different DPoP key",
+		},
+		{
+			uc:     "replayed dpop proof",
+			dpop:   &DPoPConfig{},
+			claims: `{"cnf": {"jkt": "` + jkt + `"}}`,
+			headers: map[string]string{
+				"Authorization": "DPoP " + accessToken,
+				"DPoP":          createDPoPProof(t, dpopKey, "dpop+jwt", proofClaims()),
+			},
+			replay:       true,
+			errContains:  "already been used",
+			invalidProof: true,
+		},
+		{
+			uc:     "dpop proof replay check without cache",
+			dpop:   &DPoPConfig{},
+			claims: `{"cnf": {"jkt": "` + jkt + `"}}`,
+			headers: map[string]string{
+				"Authorization": "DPoP " + accessToken,
+				"DPoP":          createDPoPProof(t, dpopKey, "dpop+jwt", proofClaims()),
+			},
+			noCache:     true,
+			errContains: "failed to check DPoP proof for replay",
+		},
+		{
+			uc:     "valid dpop proof",
+			dpop:   &DPoPConfig{Required: true},
+			claims: `{"cnf": {"jkt": "` + jkt + `"}}`,
+			headers: map[string]string{
+				"Authorization": "DPoP " + accessToken,
+				"DPoP":          createDPoPProof(t, dpopKey, "dpop+jwt", proofClaims()),
+			},
+		},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			// GIVEN
+			reqf := mocks.NewRequestFunctionsMock(t)
+			reqf.EXPECT().Header(mock.Anything).RunAndReturn(func(name string) string {
+				return tc.headers[name]
+			}).Maybe()
+			reqf.EXPECT().ClientCertificates().Return(tc.clientCerts).Maybe()
+
+			ctx := mocks.NewContextMock(t)
+			ctx.EXPECT().AppContext().Return(x.IfThenElse(tc.noCache,
+				context.Background(), cache.WithContext(context.Background(), memory.New()))).Maybe()
+			ctx.EXPECT().Request().Return(&heimdall.Request{
+				RequestFunctions: reqf,
+				Method:           http.MethodGet,
+				URL:              &heimdall.URL{URL: *requestURL},
+			}).Maybe()
+
+			sc := newSenderConstraints(tc.dpop, tc.certBinding)
+
+			if tc.replay {
+				require.NoError(t, sc.verify(ctx, accessToken, []byte(tc.claims)))
+			}
+
+			// WHEN
+			err := sc.verify(ctx, accessToken, []byte(tc.claims))
+
+			// THEN
+			if len(tc.errContains) == 0 {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				require.ErrorIs(t, err, x.IfThenElse(tc.noCache, heimdall.ErrInternal, heimdall.ErrAuthentication))
+				require.NotErrorIs(t, err, heimdall.ErrArgument)
+				assert.Contains(t, err.Error(), tc.errContains)
+
+				var challengeErr *heimdall.AuthenticationChallengeError
+				if assert.Equal(t, tc.invalidProof, errors.As(err, &challengeErr)) && tc.invalidProof {
+					assert.Equal(t, `DPoP error="invalid_dpop_proof"`, challengeErr.Challenge)
+				}
+			}
+		})
+	}
+}
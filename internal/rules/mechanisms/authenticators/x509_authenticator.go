@@ -0,0 +1,322 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package authenticators
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"github.com/yl2chen/cidranger"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/rules/mechanisms/subject"
+	"github.com/dadrus/heimdall/internal/truststore"
+	"github.com/dadrus/heimdall/internal/x"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+	"github.com/dadrus/heimdall/internal/x/httpx"
+	"github.com/dadrus/heimdall/internal/x/pkix"
+)
+
+// by intention. Used only during application bootstrap
+//
+//nolint:gochecknoinits
+func init() {
+	registerTypeFactory(
+		func(id string, typ string, conf map[string]any) (bool, Authenticator, error) {
+			if typ != AuthenticatorX509 {
+				return false, nil, nil
+			}
+
+			auth, err := newX509Authenticator(id, conf)
+
+			return true, auth, err
+		})
+}
+
+type x509Authenticator struct {
+	id                   string
+	trustStore           truststore.TrustStore
+	certificateHeader    string
+	trustedProxies       cidranger.Ranger
+	sf                   SubjectFactory
+	allowFallbackOnError bool
+}
+
+func newX509Authenticator(id string, rawConfig map[string]any) (*x509Authenticator, error) {
+	type Config struct {
+		TrustStore           truststore.TrustStore `mapstructure:"trust_store"             validate:"required"`
+		CertificateHeader    string                `mapstructure:"certificate_header"`
+		TrustedProxies       []string              `mapstructure:"trusted_proxies"         validate:"required_with=CertificateHeader,excluded_without=CertificateHeader"` //nolint:lll,tagalign
+		SubjectInfo          SubjectInfo           `mapstructure:"subject"                 validate:"-"`
+		AllowFallbackOnError bool                  `mapstructure:"allow_fallback_on_error"`
+	}
+
+	var conf Config
+	if err := decodeConfig(AuthenticatorX509, rawConfig, &conf); err != nil {
+		return nil, err
+	}
+
+	if len(conf.SubjectInfo.IDFrom) == 0 {
+		conf.SubjectInfo.IDFrom = "subject.dn"
+	}
+
+	trustedProxies, err := newTrustedProxies(conf.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
+	return &x509Authenticator{
+		id:                   id,
+		trustStore:           conf.TrustStore,
+		certificateHeader:    conf.CertificateHeader,
+		trustedProxies:       trustedProxies,
+		sf:                   &conf.SubjectInfo,
+		allowFallbackOnError: conf.AllowFallbackOnError,
+	}, nil
+}
+
+func (a *x509Authenticator) Execute(ctx heimdall.Context) (*subject.Subject, error) {
+	logger := zerolog.Ctx(ctx.AppContext())
+	logger.Debug().Str("_id", a.id).Msg("Authenticating using x509 authenticator")
+
+	certs, err := a.clientCertificates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(certs) == 0 {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrAuthentication, "no client certificate present").
+			WithErrorContext(a)
+	}
+
+	if err = pkix.ValidateCertificate(certs[0],
+		pkix.WithIntermediateCACertificates(certs[1:]),
+		pkix.WithRootCACertificates(a.trustStore),
+		pkix.WithExtendedKeyUsage(x509.ExtKeyUsageClientAuth),
+	); err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrAuthentication, "client certificate is not trusted").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	rawData, err := json.Marshal(pkix.CertificateInfo(certs[0]))
+	if err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrInternal, "failed to marshal client certificate information").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	sub, err := a.sf.CreateSubject(rawData)
+	if err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrInternal, "failed to extract subject information from client certificate").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	return sub, nil
+}
+
+func (a *x509Authenticator) WithConfig(config map[string]any) (Authenticator, error) {
+	// this authenticator allows only the fallback behavior to be redefined on the rule level
+	if len(config) == 0 {
+		return a, nil
+	}
+
+	type Config struct {
+		AllowFallbackOnError *bool `mapstructure:"allow_fallback_on_error"`
+	}
+
+	var conf Config
+	if err := decodeConfig(AuthenticatorX509, config, &conf); err != nil {
+		return nil, err
+	}
+
+	return &x509Authenticator{
+		id:                a.id,
+		trustStore:        a.trustStore,
+		certificateHeader: a.certificateHeader,
+		trustedProxies:    a.trustedProxies,
+		sf:                a.sf,
+		allowFallbackOnError: x.IfThenElseExec(conf.AllowFallbackOnError != nil,
+			func() bool { return *conf.AllowFallbackOnError },
+			func() bool { return a.allowFallbackOnError }),
+	}, nil
+}
+
+func (a *x509Authenticator) IsFallbackOnErrorAllowed() bool {
+	return a.allowFallbackOnError
+}
+
+func (a *x509Authenticator) ID() string {
+	return a.id
+}
+
+func (a *x509Authenticator) clientCertificates(ctx heimdall.Context) ([]*x509.Certificate, error) {
+	if len(a.certificateHeader) == 0 {
+		return ctx.Request().ClientCertificates(), nil
+	}
+
+	// the header can be set by anyone. So it is only accepted from the configured proxies
+	if !a.fromTrustedProxy(ctx.Request().ClientIPAddresses) {
+		return nil, errorchain.
+			NewWithMessagef(heimdall.ErrAuthentication,
+				"'%s' header received from an untrusted source", a.certificateHeader).
+			WithErrorContext(a)
+	}
+
+	value := ctx.Request().Header(a.certificateHeader)
+	if len(value) == 0 {
+		return nil, nil
+	}
+
+	certs, err := parseForwardedCertificates(value)
+	if err != nil {
+		return nil, errorchain.
+			NewWithMessagef(heimdall.ErrAuthentication,
+				"failed to parse client certificate from the '%s' header", a.certificateHeader).
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	return certs, nil
+}
+
+// fromTrustedProxy checks the address of the peer heimdall has received the request from,
+// which is the last entry of the given client ip addresses.
+func (a *x509Authenticator) fromTrustedProxy(clientIPs []string) bool {
+	if len(clientIPs) == 0 {
+		return false
+	}
+
+	addr := clientIPs[len(clientIPs)-1]
+	if ip := httpx.IPFromHostPort(addr); len(ip) != 0 {
+		addr = ip
+	}
+
+	ip := net.ParseIP(strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]"))
+	if ip == nil {
+		return false
+	}
+
+	trusted, err := a.trustedProxies.Contains(ip)
+
+	return err == nil && trusted
+}
+
+func newTrustedProxies(proxies []string) (cidranger.Ranger, error) {
+	ranger := cidranger.NewPCTrieRanger()
+
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			proxy += x.IfThenElse(strings.Contains(proxy, ":"), "/128", "/32")
+		}
+
+		_, ipNet, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+				"failed parsing trusted proxy %s", proxy).CausedBy(err)
+		}
+
+		if err = ranger.Insert(cidranger.NewBasicRangerEntry(*ipNet)); err != nil {
+			return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+				"failed to add trusted proxy %s", proxy).CausedBy(err)
+		}
+	}
+
+	return ranger, nil
+}
+
+// parseForwardedCertificates parses the certificates from the value of a header set by a TLS
+// terminating proxy. Supported are the x-forwarded-client-cert header format used by envoy and
+// others, as well as the url encoded PEM representation of the certificate, as e.g. forwarded
+// by nginx. In case of the x-forwarded-client-cert format, the last element is used, as it is
+// the only one added by the proxy in front of heimdall. All preceding elements could have been
+// sent by the client (e.g. if envoy is configured to use APPEND_FORWARD). If available, the
+// Chain entry is preferred over the Cert entry.
+func parseForwardedCertificates(value string) ([]*x509.Certificate, error) {
+	encoded := value
+
+	if strings.Contains(value, "Cert=") || strings.Contains(value, "Chain=") {
+		elements := splitQuoted(value, ',')
+		element := parseXFCCElement(elements[len(elements)-1])
+
+		encoded = x.IfThenElse(len(element["chain"]) != 0, element["chain"], element["cert"])
+	}
+
+	pemBytes, err := url.PathUnescape(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	return pkix.ParseCertificates([]byte(pemBytes))
+}
+
+func parseXFCCElement(element string) map[string]string {
+	pairs := splitQuoted(element, ';')
+	result := make(map[string]string, len(pairs))
+
+	for _, pair := range pairs {
+		key, value, found := strings.Cut(pair, "=")
+		if !found {
+			continue
+		}
+
+		value = strings.TrimSpace(value)
+		if len(value) > 1 && value[0] == '"' && value[len(value)-1] == '"' {
+			value = strings.ReplaceAll(value[1:len(value)-1], `\"`, `"`)
+		}
+
+		result[strings.ToLower(strings.TrimSpace(key))] = value
+	}
+
+	return result
+}
+
+// splitQuoted splits the given value by the given separator ignoring the separators
+// appearing in quoted parts of it.
+func splitQuoted(value string, sep byte) []string {
+	var (
+		parts   []string
+		quoted  bool
+		escaped bool
+		start   int
+	)
+
+	for idx := 0; idx < len(value); idx++ {
+		switch {
+		case escaped:
+			escaped = false
+		case value[idx] == '\\':
+			escaped = true
+		case value[idx] == '"':
+			quoted = !quoted
+		case value[idx] == sep && !quoted:
+			parts = append(parts, value[start:idx])
+			start = idx + 1
+		}
+	}
+
+	return append(parts, value[start:])
+}
@@ -0,0 +1,623 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package authenticators
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/heimdall/mocks"
+	"github.com/dadrus/heimdall/internal/rules/mechanisms/subject"
+	"github.com/dadrus/heimdall/internal/x/pkix/pemx"
+	"github.com/dadrus/heimdall/internal/x/testsupport"
+)
+
+func createX509TestTrustStore(t *testing.T, ca *testsupport.CA) string {
+	t.Helper()
+
+	pemBytes, err := pemx.BuildPEM(pemx.WithX509Certificate(ca.Certificate))
+	require.NoError(t, err)
+
+	trustStorePath := t.TempDir() + "/trust_store.pem"
+	require.NoError(t, os.WriteFile(trustStorePath, pemBytes, 0o600))
+
+	return trustStorePath
+}
+
+func TestCreateX509Authenticator(t *testing.T) {
+	t.Parallel()
+
+	rootCA, err := testsupport.NewRootCA("Test Root CA", time.Hour*24)
+	require.NoError(t, err)
+
+	trustStorePath := createX509TestTrustStore(t, rootCA)
+
+	for _, tc := range []struct {
+		uc     string
+		id     string
+		config []byte
+		assert func(t *testing.T, err error, auth *x509Authenticator)
+	}{
+		{
+			uc:     "without trust store",
+			config: []byte(`certificate_header: X-Forwarded-Client-Cert`),
+			assert: func(t *testing.T, err error, _ *x509Authenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "'trust_store' is a required field")
+			},
+		},
+		{
+			uc: "with unsupported properties",
+			config: []byte(`
+trust_store: ` + trustStorePath + `
+foo: bar
+`),
+			assert: func(t *testing.T, err error, _ *x509Authenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "invalid keys: foo")
+			},
+		},
+		{
+			uc: "with certificate header but without trusted proxies",
+			config: []byte(`
+trust_store: ` + trustStorePath + `
+certificate_header: X-Forwarded-Client-Cert
+`),
+			assert: func(t *testing.T, err error, _ *x509Authenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "trusted_proxies")
+			},
+		},
+		{
+			uc: "with trusted proxies but without certificate header",
+			config: []byte(`
+trust_store: ` + trustStorePath + `
+trusted_proxies:
+  - 10.0.0.0/8
+`),
+			assert: func(t *testing.T, err error, _ *x509Authenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "trusted_proxies")
+			},
+		},
+		{
+			uc: "with malformed trusted proxy",
+			config: []byte(`
+trust_store: ` + trustStorePath + `
+certificate_header: X-Forwarded-Client-Cert
+trusted_proxies:
+  - 10.0.0.0/88
+`),
+			assert: func(t *testing.T, err error, _ *x509Authenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed parsing trusted proxy")
+			},
+		},
+		{
+			uc:     "with minimal configuration",
+			id:     "auth1",
+			config: []byte(`trust_store: ` + trustStorePath),
+			assert: func(t *testing.T, err error, auth *x509Authenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				assert.Equal(t, "auth1", auth.ID())
+				assert.Len(t, auth.trustStore, 1)
+				assert.Empty(t, auth.certificateHeader)
+				assert.Equal(t, &SubjectInfo{IDFrom: "subject.dn"}, auth.sf)
+				assert.False(t, auth.IsFallbackOnErrorAllowed())
+			},
+		},
+		{
+			uc: "with full configuration",
+			id: "auth2",
+			config: []byte(`
+trust_store: ` + trustStorePath + `
+certificate_header: X-Forwarded-Client-Cert
+trusted_proxies:
+  - 10.0.0.0/8
+  - 192.168.1.1
+subject:
+  id: spiffe_id
+  attributes: sans
+allow_fallback_on_error: true
+`),
+			assert: func(t *testing.T, err error, auth *x509Authenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				assert.Equal(t, "auth2", auth.ID())
+				assert.Len(t, auth.trustStore, 1)
+				assert.Equal(t, "X-Forwarded-Client-Cert", auth.certificateHeader)
+				assert.True(t, auth.fromTrustedProxy([]string{"10.1.2.3:1234"}))
+				assert.True(t, auth.fromTrustedProxy([]string{"192.168.1.1"}))
+				assert.False(t, auth.fromTrustedProxy([]string{"10.1.2.3", "192.168.1.2"}))
+				assert.False(t, auth.fromTrustedProxy(nil))
+				assert.Equal(t, &SubjectInfo{IDFrom: "spiffe_id", AttributesFrom: "sans"}, auth.sf)
+				assert.True(t, auth.IsFallbackOnErrorAllowed())
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			conf, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			// WHEN
+			auth, err := newX509Authenticator(tc.id, conf)
+
+			// THEN
+			tc.assert(t, err, auth)
+		})
+	}
+}
+
+func TestCreateX509AuthenticatorFromPrototypeConfig(t *testing.T) {
+	t.Parallel()
+
+	rootCA, err := testsupport.NewRootCA("Test Root CA", time.Hour*24)
+	require.NoError(t, err)
+
+	trustStorePath := createX509TestTrustStore(t, rootCA)
+
+	for _, tc := range []struct {
+		uc     string
+		config []byte
+		assert func(t *testing.T, err error, prototype, configured *x509Authenticator)
+	}{
+		{
+			uc: "without target config",
+			assert: func(t *testing.T, err error, prototype, configured *x509Authenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, prototype, configured)
+			},
+		},
+		{
+			uc:     "with fallback redefined",
+			config: []byte(`allow_fallback_on_error: true`),
+			assert: func(t *testing.T, err error, prototype, configured *x509Authenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.NotEqual(t, prototype, configured)
+				assert.Equal(t, prototype.ID(), configured.ID())
+				assert.Equal(t, prototype.trustStore, configured.trustStore)
+				assert.Equal(t, prototype.certificateHeader, configured.certificateHeader)
+				assert.Equal(t, prototype.sf, configured.sf)
+				assert.False(t, prototype.IsFallbackOnErrorAllowed())
+				assert.True(t, configured.IsFallbackOnErrorAllowed())
+			},
+		},
+		{
+			uc:     "with trust store redefined",
+			config: []byte(`trust_store: ` + trustStorePath),
+			assert: func(t *testing.T, err error, _, _ *x509Authenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "invalid keys: trust_store")
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			conf, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			prototype, err := newX509Authenticator("x509", map[string]any{"trust_store": trustStorePath})
+			require.NoError(t, err)
+
+			// WHEN
+			auth, err := prototype.WithConfig(conf)
+
+			// THEN
+			var (
+				configured *x509Authenticator
+				ok         bool
+			)
+
+			if err == nil {
+				configured, ok = auth.(*x509Authenticator)
+				require.True(t, ok)
+			}
+
+			tc.assert(t, err, prototype, configured)
+		})
+	}
+}
+
+func TestX509AuthenticatorExecute(t *testing.T) {
+	t.Parallel()
+
+	// ROOT CAs
+	rootCA, err := testsupport.NewRootCA("Test Root CA", time.Hour*24)
+	require.NoError(t, err)
+
+	otherCA, err := testsupport.NewRootCA("Other Root CA", time.Hour*24)
+	require.NoError(t, err)
+
+	trustStorePath := createX509TestTrustStore(t, rootCA)
+
+	// INT CA
+	intCAPrivKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	intCACert, err := rootCA.IssueCertificate(
+		testsupport.WithSubject(pkix.Name{
+			CommonName:   "Test Int CA",
+			Organization: []string{"Test"},
+			Country:      []string{"EU"},
+		}),
+		testsupport.WithIsCA(),
+		testsupport.WithValidity(time.Now(), time.Hour*24),
+		testsupport.WithSubjectPubKey(&intCAPrivKey.PublicKey, x509.ECDSAWithSHA384))
+	require.NoError(t, err)
+
+	intCA := testsupport.NewCA(intCAPrivKey, intCACert)
+
+	// EE CERTS
+	spiffeID, err := url.Parse("spiffe://example.org/ns/default/sa/foo")
+	require.NoError(t, err)
+
+	eePrivKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	clientCert, err := intCA.IssueCertificate(
+		testsupport.WithSubject(pkix.Name{
+			CommonName:   "foo",
+			Organization: []string{"Test"},
+			Country:      []string{"EU"},
+		}),
+		testsupport.WithValidity(time.Now(), time.Hour),
+		testsupport.WithSubjectPubKey(&eePrivKey.PublicKey, x509.ECDSAWithSHA384),
+		testsupport.WithKeyUsage(x509.KeyUsageDigitalSignature),
+		testsupport.WithExtendedKeyUsage(x509.ExtKeyUsageClientAuth),
+		testsupport.WithDNSNames([]string{"foo.example.org"}),
+		testsupport.WithURIs([]*url.URL{spiffeID}))
+	require.NoError(t, err)
+
+	serverCert, err := intCA.IssueCertificate(
+		testsupport.WithSubject(pkix.Name{CommonName: "bar"}),
+		testsupport.WithValidity(time.Now(), time.Hour),
+		testsupport.WithSubjectPubKey(&eePrivKey.PublicKey, x509.ECDSAWithSHA384),
+		testsupport.WithExtendedKeyUsage(x509.ExtKeyUsageServerAuth))
+	require.NoError(t, err)
+
+	untrustedCert, err := otherCA.IssueCertificate(
+		testsupport.WithSubject(pkix.Name{CommonName: "baz"}),
+		testsupport.WithValidity(time.Now(), time.Hour),
+		testsupport.WithSubjectPubKey(&eePrivKey.PublicKey, x509.ECDSAWithSHA384))
+	require.NoError(t, err)
+
+	chainPEM, err := pemx.BuildPEM(pemx.WithX509Certificate(clientCert), pemx.WithX509Certificate(intCACert))
+	require.NoError(t, err)
+
+	leafPEM, err := pemx.BuildPEM(pemx.WithX509Certificate(clientCert))
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		uc             string
+		config         []byte
+		configureMocks func(t *testing.T, reqf *mocks.RequestFunctionsMock)
+		assert         func(t *testing.T, err error, sub *subject.Subject)
+	}{
+		{
+			uc: "no client certificate present",
+			configureMocks: func(t *testing.T, reqf *mocks.RequestFunctionsMock) {
+				t.Helper()
+
+				reqf.EXPECT().ClientCertificates().Return(nil)
+			},
+			assert: func(t *testing.T, err error, _ *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.Contains(t, err.Error(), "no client certificate present")
+			},
+		},
+		{
+			uc: "certificate issued by an untrusted ca",
+			configureMocks: func(t *testing.T, reqf *mocks.RequestFunctionsMock) {
+				t.Helper()
+
+				reqf.EXPECT().ClientCertificates().Return([]*x509.Certificate{untrustedCert})
+			},
+			assert: func(t *testing.T, err error, _ *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.Contains(t, err.Error(), "not trusted")
+			},
+		},
+		{
+			uc: "certificate chain without intermediate ca",
+			configureMocks: func(t *testing.T, reqf *mocks.RequestFunctionsMock) {
+				t.Helper()
+
+				reqf.EXPECT().ClientCertificates().Return([]*x509.Certificate{clientCert})
+			},
+			assert: func(t *testing.T, err error, _ *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.Contains(t, err.Error(), "not trusted")
+			},
+		},
+		{
+			uc: "certificate not usable for client authentication",
+			configureMocks: func(t *testing.T, reqf *mocks.RequestFunctionsMock) {
+				t.Helper()
+
+				reqf.EXPECT().ClientCertificates().Return([]*x509.Certificate{serverCert, intCACert})
+			},
+			assert: func(t *testing.T, err error, _ *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.Contains(t, err.Error(), "not trusted")
+			},
+		},
+		{
+			uc:     "subject id cannot be extracted",
+			config: []byte(`subject: { id: foo }`),
+			configureMocks: func(t *testing.T, reqf *mocks.RequestFunctionsMock) {
+				t.Helper()
+
+				reqf.EXPECT().ClientCertificates().Return([]*x509.Certificate{clientCert, intCACert})
+			},
+			assert: func(t *testing.T, err error, _ *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrInternal)
+				assert.Contains(t, err.Error(), "failed to extract subject information")
+			},
+		},
+		{
+			uc: "successful authentication using the certificate of the tls peer",
+			configureMocks: func(t *testing.T, reqf *mocks.RequestFunctionsMock) {
+				t.Helper()
+
+				reqf.EXPECT().ClientCertificates().Return([]*x509.Certificate{clientCert, intCACert})
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, sub)
+
+				assert.Equal(t, "CN=foo,O=Test,C=EU", sub.ID)
+				assert.Equal(t, "spiffe://example.org/ns/default/sa/foo", sub.Attributes["spiffe_id"])
+				assert.Equal(t, clientCert.SerialNumber.String(), sub.Attributes["serial_number"])
+				assert.Equal(t, map[string]any{
+					"dn":                  "CN=foo,O=Test,C=EU",
+					"common_name":         "foo",
+					"organization":        []any{"Test"},
+					"organizational_unit": nil,
+					"country":             []any{"EU"},
+				}, sub.Attributes["subject"])
+				assert.Equal(t, map[string]any{
+					"dn":          "CN=Test Int CA,O=Test,C=EU",
+					"common_name": "Test Int CA",
+				}, sub.Attributes["issuer"])
+				assert.Equal(t, map[string]any{
+					"dns":   []any{"foo.example.org"},
+					"email": nil,
+					"ip":    []any{},
+					"uri":   []any{"spiffe://example.org/ns/default/sa/foo"},
+				}, sub.Attributes["sans"])
+			},
+		},
+		{
+			uc: "successful authentication using a certificate forwarded in xfcc format",
+			config: []byte(`
+certificate_header: X-Forwarded-Client-Cert
+trusted_proxies:
+  - 10.0.0.0/8
+subject:
+  id: spiffe_id
+  attributes: sans
+`),
+			configureMocks: func(t *testing.T, reqf *mocks.RequestFunctionsMock) {
+				t.Helper()
+
+				reqf.EXPECT().Header("X-Forwarded-Client-Cert").Return(
+					`By=spiffe://example.org/other;Subject="CN=bar",` +
+						`By=spiffe://example.org/proxy;Hash=abc;Subject="CN=foo,O=Test,C=EU";` +
+						`Chain=` + url.PathEscape(string(chainPEM)) + `;URI=spiffe://example.org/ns/default/sa/foo`)
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, sub)
+
+				assert.Equal(t, "spiffe://example.org/ns/default/sa/foo", sub.ID)
+				assert.Equal(t, []any{"foo.example.org"}, sub.Attributes["dns"])
+			},
+		},
+		{
+			uc: "certificate forwarded in xfcc format in an element not added by the trusted proxy",
+			config: []byte(`
+certificate_header: X-Forwarded-Client-Cert
+trusted_proxies:
+  - 10.0.0.0/8
+`),
+			configureMocks: func(t *testing.T, reqf *mocks.RequestFunctionsMock) {
+				t.Helper()
+
+				reqf.EXPECT().Header("X-Forwarded-Client-Cert").Return(
+					`By=spiffe://example.org/proxy;Hash=abc;Chain=` + url.PathEscape(string(chainPEM)) + `,` +
+						`By=spiffe://example.org/proxy;Hash=def;Subject="CN=bar"`)
+			},
+			assert: func(t *testing.T, err error, _ *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.Contains(t, err.Error(), "no client certificate")
+			},
+		},
+		{
+			uc: "forwarded certificate without intermediate ca",
+			config: []byte(`
+certificate_header: X-Forwarded-Client-Cert
+trusted_proxies:
+  - 10.0.0.0/8
+`),
+			configureMocks: func(t *testing.T, reqf *mocks.RequestFunctionsMock) {
+				t.Helper()
+
+				reqf.EXPECT().Header("X-Forwarded-Client-Cert").
+					Return(`Hash=abc;Cert="` + url.PathEscape(string(leafPEM)) + `"`)
+			},
+			assert: func(t *testing.T, err error, _ *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.Contains(t, err.Error(), "not trusted")
+			},
+		},
+		{
+			uc:     "successful authentication using an url encoded pem forwarded certificate",
+			config: []byte(`{ certificate_header: X-SSL-Client-Cert, trusted_proxies: [ 10.1.2.3 ] }`),
+			configureMocks: func(t *testing.T, reqf *mocks.RequestFunctionsMock) {
+				t.Helper()
+
+				reqf.EXPECT().Header("X-SSL-Client-Cert").Return(url.PathEscape(string(chainPEM)))
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, sub)
+
+				assert.Equal(t, "CN=foo,O=Test,C=EU", sub.ID)
+			},
+		},
+		{
+			uc:     "header with forwarded certificate is not present",
+			config: []byte(`{ certificate_header: X-SSL-Client-Cert, trusted_proxies: [ 10.1.2.3 ] }`),
+			configureMocks: func(t *testing.T, reqf *mocks.RequestFunctionsMock) {
+				t.Helper()
+
+				reqf.EXPECT().Header("X-SSL-Client-Cert").Return("")
+			},
+			assert: func(t *testing.T, err error, _ *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.Contains(t, err.Error(), "no client certificate present")
+			},
+		},
+		{
+			uc:             "header with forwarded certificate received from an untrusted source",
+			config:         []byte(`{ certificate_header: X-SSL-Client-Cert, trusted_proxies: [ 192.168.1.0/24 ] }`),
+			configureMocks: func(t *testing.T, _ *mocks.RequestFunctionsMock) { t.Helper() },
+			assert: func(t *testing.T, err error, _ *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.Contains(t, err.Error(), "untrusted source")
+			},
+		},
+		{
+			uc:     "header with malformed forwarded certificate",
+			config: []byte(`{ certificate_header: X-SSL-Client-Cert, trusted_proxies: [ 10.1.2.3 ] }`),
+			configureMocks: func(t *testing.T, reqf *mocks.RequestFunctionsMock) {
+				t.Helper()
+
+				reqf.EXPECT().Header("X-SSL-Client-Cert").Return(url.PathEscape(
+					"-----BEGIN CERTIFICATE-----\nZm9vYmFy\n-----END CERTIFICATE-----\n"))
+			},
+			assert: func(t *testing.T, err error, _ *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.Contains(t, err.Error(), "failed to parse client certificate")
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			conf, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			if conf == nil {
+				conf = map[string]any{}
+			}
+
+			conf["trust_store"] = trustStorePath
+
+			auth, err := newX509Authenticator("x509", conf)
+			require.NoError(t, err)
+
+			reqf := mocks.NewRequestFunctionsMock(t)
+			tc.configureMocks(t, reqf)
+
+			ctx := mocks.NewContextMock(t)
+			ctx.EXPECT().AppContext().Return(context.Background())
+			ctx.EXPECT().Request().Return(&heimdall.Request{
+				RequestFunctions: reqf,
+				// the last entry is the address of the peer heimdall received the request from
+				ClientIPAddresses: []string{"192.168.1.10", "10.1.2.3"},
+			})
+
+			// WHEN
+			sub, err := auth.Execute(ctx)
+
+			// THEN
+			tc.assert(t, err, sub)
+		})
+	}
+}
@@ -27,7 +27,7 @@ func TestCreateAuthorizerPrototypeUsingKnowType(t *testing.T) {
 	t.Parallel()
 
 	// there are 5 authorizers implemented, which should have been registered
-	require.Len(t, authorizerTypeFactories, 4)
+	require.Len(t, authorizerTypeFactories, 7)
 
 	for _, tc := range []struct {
 		uc     string
@@ -17,13 +17,22 @@
 package authorizers
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
 	"github.com/google/cel-go/cel"
 	"github.com/rs/zerolog"
 
+	"github.com/dadrus/heimdall/internal/cache"
+	"github.com/dadrus/heimdall/internal/cache/encoding"
 	"github.com/dadrus/heimdall/internal/heimdall"
 	"github.com/dadrus/heimdall/internal/rules/mechanisms/cellib"
 	"github.com/dadrus/heimdall/internal/rules/mechanisms/subject"
+	"github.com/dadrus/heimdall/internal/rules/mechanisms/template"
+	"github.com/dadrus/heimdall/internal/x"
 	"github.com/dadrus/heimdall/internal/x/errorchain"
+	"github.com/dadrus/heimdall/internal/x/stringx"
 )
 
 // by intention. Used only during application bootstrap
@@ -40,16 +49,27 @@ func init() {
 
 			return true, auth, err
 		})
+
+	encoding.RegisterType[celDecision]("cel_authorizer.decision")
 }
 
+// celDecision is the cached outcome of the evaluation of the expressions. It holds the
+// number of the expression, which evaluated to false, or 0 if all expressions evaluated to true.
+type celDecision int
+
 type celAuthorizer struct {
 	id          string
-	expressions compiledExpressions
+	expressions []Expression
+	compiled    compiledExpressions
+	cacheKey    template.Template
+	ttl         time.Duration
 }
 
 func newCELAuthorizer(id string, rawConfig map[string]any) (*celAuthorizer, error) {
 	type Config struct {
-		Expressions []Expression `mapstructure:"expressions" validate:"required,gt=0,dive"`
+		Expressions []Expression      `mapstructure:"expressions" validate:"required,gt=0,dive"`
+		CacheKey    template.Template `mapstructure:"cache_key"`
+		CacheTTL    time.Duration     `mapstructure:"cache_ttl"`
 	}
 
 	var conf Config
@@ -57,25 +77,70 @@ func newCELAuthorizer(id string, rawConfig map[string]any) (*celAuthorizer, erro
 		return nil, err
 	}
 
+	return newCELAuthorizerFor(id, conf.Expressions, conf.CacheKey, conf.CacheTTL)
+}
+
+func newCELAuthorizerFor(
+	id string, expressions []Expression, cacheKey template.Template, ttl time.Duration,
+) (*celAuthorizer, error) {
+	if ttl > 0 && cacheKey == nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"cache_key is required if cache_ttl is configured")
+	}
+
 	env, err := cel.NewEnv(cellib.Library())
 	if err != nil {
 		return nil, errorchain.NewWithMessage(heimdall.ErrInternal,
 			"failed creating CEL environment").CausedBy(err)
 	}
 
-	expressions, err := compileExpressions(conf.Expressions, env)
+	compiled, err := compileExpressions(expressions, env)
 	if err != nil {
 		return nil, err
 	}
 
-	return &celAuthorizer{id: id, expressions: expressions}, nil
+	return &celAuthorizer{
+		id:          id,
+		expressions: expressions,
+		compiled:    compiled,
+		cacheKey:    cacheKey,
+		ttl:         ttl,
+	}, nil
 }
 
 func (a *celAuthorizer) Execute(ctx heimdall.Context, sub *subject.Subject) error {
 	logger := zerolog.Ctx(ctx.AppContext())
 	logger.Debug().Str("_id", a.id).Msg("Authorizing using CEL authorizer")
 
-	return a.expressions.eval(map[string]any{"Subject": sub, "Request": ctx.Request()}, a)
+	data := map[string]any{"Subject": sub, "Request": ctx.Request()}
+
+	if a.ttl <= 0 {
+		return a.compiled.eval(data, a)
+	}
+
+	key, err := a.calculateCacheKey(data)
+	if err != nil {
+		return err
+	}
+
+	cch := cache.Ctx(ctx.AppContext())
+
+	if decision, ok := cch.Get(ctx.AppContext(), key).(celDecision); ok {
+		logger.Debug().Msg("Reusing authorization decision from cache")
+
+		if decision == 0 {
+			return nil
+		}
+
+		return a.compiled.failure(int(decision), a)
+	}
+
+	failed, err := a.compiled.evalWithIndex(data, a)
+	if err == nil || failed != 0 {
+		cch.Set(ctx.AppContext(), key, celDecision(failed), a.ttl)
+	}
+
+	return err
 }
 
 func (a *celAuthorizer) WithConfig(rawConfig map[string]any) (Authorizer, error) {
@@ -83,9 +148,50 @@ func (a *celAuthorizer) WithConfig(rawConfig map[string]any) (Authorizer, error)
 		return a, nil
 	}
 
-	return newCELAuthorizer(a.id, rawConfig)
+	type Config struct {
+		Expressions []Expression      `mapstructure:"expressions" validate:"dive"`
+		CacheKey    template.Template `mapstructure:"cache_key"`
+		CacheTTL    *time.Duration    `mapstructure:"cache_ttl"`
+	}
+
+	var conf Config
+	if err := decodeConfig(AuthorizerCEL, rawConfig, &conf); err != nil {
+		return nil, err
+	}
+
+	ttl := a.ttl
+	if conf.CacheTTL != nil {
+		ttl = *conf.CacheTTL
+	}
+
+	return newCELAuthorizerFor(
+		a.id,
+		x.IfThenElse(len(conf.Expressions) != 0, conf.Expressions, a.expressions),
+		x.IfThenElse(conf.CacheKey != nil, conf.CacheKey, a.cacheKey),
+		ttl,
+	)
 }
 
 func (a *celAuthorizer) ID() string { return a.id }
 
 func (a *celAuthorizer) ContinueOnError() bool { return false }
+
+func (a *celAuthorizer) calculateCacheKey(data map[string]any) (string, error) {
+	key, err := a.cacheKey.Render(data)
+	if err != nil {
+		return "", errorchain.NewWithMessage(heimdall.ErrInternal, "failed to render cache key").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	hash := sha256.New()
+	hash.Write(stringx.ToBytes(a.id))
+
+	for _, expression := range a.expressions {
+		hash.Write(stringx.ToBytes(expression.cacheKey()))
+	}
+
+	hash.Write(stringx.ToBytes(key))
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -21,10 +21,13 @@ import (
 	"net/http"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/dadrus/heimdall/internal/cache"
+	"github.com/dadrus/heimdall/internal/cache/memory"
 	"github.com/dadrus/heimdall/internal/heimdall"
 	"github.com/dadrus/heimdall/internal/heimdall/mocks"
 	"github.com/dadrus/heimdall/internal/rules/mechanisms/subject"
@@ -136,6 +139,42 @@ expressions:
 				assert.False(t, auth.ContinueOnError())
 			},
 		},
+		{
+			uc: "with cache ttl, but without cache key",
+			config: []byte(`
+expressions:
+  - expression: "has(Subject.ID)"
+cache_ttl: 1m
+`),
+			assert: func(t *testing.T, err error, _ *celAuthorizer) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "cache_key is required")
+			},
+		},
+		{
+			uc: "with cache configuration and cost limit",
+			id: "authz",
+			config: []byte(`
+expressions:
+  - expression: "Subject.Attributes.groups.exists(g, g == 'admin')"
+    cost_limit: 100
+cache_key: "{{ .Subject.ID }}"
+cache_ttl: 1m
+`),
+			assert: func(t *testing.T, err error, auth *celAuthorizer) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.Len(t, auth.expressions, 1)
+				assert.Equal(t, uint64(100), auth.expressions[0].CostLimit)
+				assert.Len(t, auth.compiled, 1)
+				assert.NotNil(t, auth.cacheKey)
+				assert.Equal(t, time.Minute, auth.ttl)
+			},
+		},
 	} {
 		t.Run("case="+tc.uc, func(t *testing.T) {
 			conf, err := testsupport.DecodeTestConfig(tc.config)
@@ -210,6 +249,29 @@ expressions:
 				assert.False(t, configured.ContinueOnError())
 			},
 		},
+		{
+			uc: "only cache configuration provided",
+			id: "authz",
+			prototypeConfig: []byte(`
+expressions:
+  - expression: "Request.URL.Scheme == 'http'"
+`),
+			config: []byte(`
+cache_key: "{{ .Request.URL.Scheme }}"
+cache_ttl: 10m
+`),
+			assert: func(t *testing.T, err error, prototype *celAuthorizer, configured *celAuthorizer) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, configured)
+				assert.Equal(t, prototype.expressions, configured.expressions)
+				assert.Equal(t, prototype.compiled, configured.compiled)
+				assert.Nil(t, prototype.cacheKey)
+				assert.NotNil(t, configured.cacheKey)
+				assert.Equal(t, 10*time.Minute, configured.ttl)
+			},
+		},
 	} {
 		t.Run("case="+tc.uc, func(t *testing.T) {
 			pc, err := testsupport.DecodeTestConfig(tc.prototypeConfig)
@@ -225,8 +287,15 @@ expressions:
 			auth, err := prototype.WithConfig(conf)
 
 			// THEN
-			locAuth, ok := auth.(*celAuthorizer)
-			require.True(t, ok)
+			var (
+				locAuth *celAuthorizer
+				ok      bool
+			)
+
+			if err == nil {
+				locAuth, ok = auth.(*celAuthorizer)
+				require.True(t, ok)
+			}
 
 			tc.assert(t, err, prototype, locAuth)
 		})
@@ -308,12 +377,12 @@ expressions:
 				ctx.EXPECT().Request().Return(&heimdall.Request{
 					RequestFunctions: reqf,
 					Method:           http.MethodGet,
-					URL: &url.URL{
+					URL: &heimdall.URL{URL: url.URL{
 						Scheme:   "http",
 						Host:     "localhost",
 						Path:     "/test",
 						RawQuery: "foo=bar&baz=zab",
-					},
+					}},
 					ClientIPAddresses: []string{"127.0.0.1", "10.10.10.10"},
 				})
 			},
@@ -347,3 +416,105 @@ expressions:
 		})
 	}
 }
+
+func TestCELAuthorizerExecuteWithCostLimit(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	conf, err := testsupport.DecodeTestConfig([]byte(`
+expressions:
+  - expression: "Subject.Attributes.groups.exists(g, g.endsWith('admin'))"
+    cost_limit: 5
+`))
+	require.NoError(t, err)
+
+	auth, err := newCELAuthorizer("authz", conf)
+	require.NoError(t, err)
+
+	ctx := mocks.NewContextMock(t)
+	ctx.EXPECT().AppContext().Return(context.Background())
+	ctx.EXPECT().Request().Return(nil)
+
+	sub := &subject.Subject{
+		ID:         "foo",
+		Attributes: map[string]any{"groups": []any{"a", "b", "c", "d", "e", "f", "g", "h", "admin"}},
+	}
+
+	// WHEN
+	err = auth.Execute(ctx, sub)
+
+	// THEN
+	require.Error(t, err)
+	require.ErrorIs(t, err, heimdall.ErrInternal)
+	assert.Contains(t, err.Error(), "cost limit exceeded")
+}
+
+func TestCELAuthorizerExecuteWithDecisionCache(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc     string
+		header string
+		assert func(t *testing.T, first, second error)
+	}{
+		{
+			uc:     "allowed",
+			header: "admin",
+			assert: func(t *testing.T, first, second error) {
+				t.Helper()
+
+				require.NoError(t, first)
+				require.NoError(t, second)
+			},
+		},
+		{
+			uc:     "denied",
+			header: "guest",
+			assert: func(t *testing.T, first, second error) {
+				t.Helper()
+
+				require.ErrorIs(t, first, heimdall.ErrAuthorization)
+				require.ErrorIs(t, second, heimdall.ErrAuthorization)
+				assert.Contains(t, second.Error(), "role is not admin")
+				assert.Equal(t, first.Error(), second.Error())
+
+				var identifier interface{ ID() string }
+				require.ErrorAs(t, second, &identifier)
+				assert.Equal(t, "authz", identifier.ID())
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			conf, err := testsupport.DecodeTestConfig([]byte(`
+expressions:
+  - expression: "Subject.ID != ''"
+  - expression: "Request.Header('X-Role') == 'admin'"
+    message: role is not admin
+cache_key: "{{ .Subject.ID }}"
+cache_ttl: 1m
+`))
+			require.NoError(t, err)
+
+			auth, err := newCELAuthorizer("authz", conf)
+			require.NoError(t, err)
+
+			reqf := mocks.NewRequestFunctionsMock(t)
+			// the second execution is served from the cache
+			reqf.EXPECT().Header("X-Role").Return(tc.header).Once()
+
+			ctx := mocks.NewContextMock(t)
+			ctx.EXPECT().AppContext().Return(cache.WithContext(context.Background(), memory.New()))
+			ctx.EXPECT().Request().Return(&heimdall.Request{RequestFunctions: reqf})
+
+			sub := &subject.Subject{ID: "foo"}
+
+			// WHEN
+			first := auth.Execute(ctx, sub)
+			second := auth.Execute(ctx, sub)
+
+			// THEN
+			tc.assert(t, first, second)
+		})
+	}
+}
@@ -17,56 +17,113 @@
 package authorizers
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
 	"errors"
 	"fmt"
 
 	"github.com/google/cel-go/cel"
 
+	"github.com/dadrus/heimdall/internal/cache/memory"
 	"github.com/dadrus/heimdall/internal/heimdall"
 	"github.com/dadrus/heimdall/internal/rules/mechanisms/cellib"
 	"github.com/dadrus/heimdall/internal/x"
 	"github.com/dadrus/heimdall/internal/x/errorchain"
+	"github.com/dadrus/heimdall/internal/x/stringx"
 )
 
+const maxCachedPrograms = 1024
+
+// compiledPrograms is shared by all authorizers to avoid recompilation of the same expressions,
+// e.g. if rules override the configuration of an authorizer with the same expressions. That is
+// possible as all authorizers compile their expressions using the environment created from
+// cellib.Library(). The amount of cached programs is limited, with the least recently used
+// ones being evicted first.
+//
+//nolint:gochecknoglobals
+var compiledPrograms = memory.New(memory.WithMaxEntries(maxCachedPrograms))
+
 type Expression struct {
-	Value   string `mapstructure:"expression" validate:"required"`
-	Message string `mapstructure:"message"`
+	Value     string `mapstructure:"expression" validate:"required"`
+	Message   string `mapstructure:"message"`
+	CostLimit uint64 `mapstructure:"cost_limit"`
 }
 
 type compiledExpressions []*cellib.CompiledExpression
 
 func (ce compiledExpressions) eval(obj, ctx any) error {
+	_, err := ce.evalWithIndex(obj, ctx)
+
+	return err
+}
+
+// evalWithIndex works like eval, but returns in addition the number of the expression (starting
+// with 1), which evaluated to false. If all expressions evaluated to true, or the evaluation failed
+// for another reason, the returned number is 0.
+func (ce compiledExpressions) evalWithIndex(obj, ctx any) (int, error) {
 	for i, expression := range ce {
 		err := expression.Eval(obj)
 		if err != nil {
 			if errors.Is(err, &cellib.EvalError{}) {
-				return errorchain.New(heimdall.ErrAuthorization).CausedBy(err).WithErrorContext(ctx)
+				return i + 1, ce.failure(i+1, ctx)
 			}
 
-			return errorchain.NewWithMessagef(heimdall.ErrInternal, "failed evaluating expression %d", i+1).
+			return 0, errorchain.NewWithMessagef(heimdall.ErrInternal, "failed evaluating expression %d", i+1).
 				CausedBy(err).WithErrorContext(ctx)
 		}
 	}
 
-	return nil
+	return 0, nil
+}
+
+// failure returns the error signaling the expression with the given number evaluated to false.
+func (ce compiledExpressions) failure(number int, ctx any) error {
+	return errorchain.New(heimdall.ErrAuthorization).CausedBy(ce[number-1].Failure()).WithErrorContext(ctx)
 }
 
 func compileExpressions(expressions []Expression, env *cel.Env) (compiledExpressions, error) {
 	compiled := make([]*cellib.CompiledExpression, len(expressions))
 
 	for i, expression := range expressions {
-		exp, err := cellib.CompileExpression(
-			env,
-			expression.Value,
-			x.IfThenElse(len(expression.Message) != 0, expression.Message, fmt.Sprintf("expression %d failed", i+1)),
-		)
+		msg := x.IfThenElse(len(expression.Message) != 0, expression.Message, fmt.Sprintf("expression %d failed", i+1))
+		key := expression.cacheKey()
+
+		if exp, ok := compiledPrograms.Get(context.Background(), key).(*cellib.CompiledExpression); ok {
+			compiled[i] = exp.WithMessage(msg)
+
+			continue
+		}
+
+		var opts []cel.ProgramOption
+		if expression.CostLimit != 0 {
+			opts = append(opts, cel.CostLimit(expression.CostLimit))
+		}
+
+		exp, err := cellib.CompileExpression(env, expression.Value, msg, opts...)
 		if err != nil {
 			return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
 				"failed to compile expression %d (%s)", i+1, expression.Value).CausedBy(err)
 		}
 
+		compiledPrograms.Set(context.Background(), key, exp, 0)
+
 		compiled[i] = exp
 	}
 
 	return compiled, nil
 }
+
+func (e Expression) cacheKey() string {
+	const int64BytesCount = 8
+
+	costLimit := make([]byte, int64BytesCount)
+	binary.LittleEndian.PutUint64(costLimit, e.CostLimit)
+
+	hash := sha256.New()
+	hash.Write(costLimit)
+	hash.Write(stringx.ToBytes(e.Value))
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,234 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package authorizers
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/dadrus/heimdall/internal/cache"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/rules/mechanisms/subject"
+	"github.com/dadrus/heimdall/internal/rules/mechanisms/template"
+	"github.com/dadrus/heimdall/internal/x"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+	"github.com/dadrus/heimdall/internal/x/stringx"
+)
+
+// by intention. Used only during application bootstrap
+//
+//nolint:gochecknoinits
+func init() {
+	registerTypeFactory(
+		func(id string, typ string, conf map[string]any) (bool, Authorizer, error) {
+			if typ != AuthorizerRateLimit {
+				return false, nil, nil
+			}
+
+			auth, err := newRateLimitAuthorizer(id, conf)
+
+			return true, auth, err
+		})
+}
+
+type rateLimitAuthorizer struct {
+	id        string
+	key       template.Template
+	algorithm string
+	burst     int
+	limit     rateLimit
+}
+
+func newRateLimitAuthorizer(id string, rawConfig map[string]any) (*rateLimitAuthorizer, error) {
+	type Config struct {
+		Key       template.Template `mapstructure:"key"       validate:"required"`
+		Algorithm string            `mapstructure:"algorithm" validate:"omitempty,oneof=token_bucket sliding_window"`
+		Limit     int               `mapstructure:"limit"     validate:"required,gt=0"`
+		Period    time.Duration     `mapstructure:"period"    validate:"required,gt=0"`
+		Burst     int               `mapstructure:"burst"     validate:"gte=0"`
+	}
+
+	var conf Config
+	if err := decodeConfig(AuthorizerRateLimit, rawConfig, &conf); err != nil {
+		return nil, err
+	}
+
+	return newRateLimitAuthorizerFor(id, conf.Key,
+		x.IfThenElse(len(conf.Algorithm) != 0, conf.Algorithm, rateLimitAlgorithmTokenBucket),
+		rateLimit{Limit: conf.Limit, Period: conf.Period, Burst: conf.Burst},
+	)
+}
+
+func newRateLimitAuthorizerFor(
+	id string, key template.Template, algorithm string, limit rateLimit,
+) (*rateLimitAuthorizer, error) {
+	if limit.Burst != 0 && algorithm != rateLimitAlgorithmTokenBucket {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"burst is only supported by the token_bucket algorithm")
+	}
+
+	burst := limit.Burst
+	if algorithm == rateLimitAlgorithmTokenBucket && limit.Burst == 0 {
+		limit.Burst = limit.Limit
+	}
+
+	return &rateLimitAuthorizer{id: id, key: key, algorithm: algorithm, burst: burst, limit: limit}, nil
+}
+
+func (a *rateLimitAuthorizer) Execute(ctx heimdall.Context, sub *subject.Subject) error {
+	logger := zerolog.Ctx(ctx.AppContext())
+	logger.Debug().Str("_id", a.id).Msg("Authorizing using rate limit authorizer")
+
+	if sub == nil {
+		return errorchain.NewWithMessage(heimdall.ErrInternal,
+			"failed to execute rate limit authorizer due to 'nil' subject").
+			WithErrorContext(a)
+	}
+
+	key, err := a.key.Render(map[string]any{
+		"Request": ctx.Request(),
+		"Subject": sub,
+	})
+	if err != nil {
+		return errorchain.NewWithMessage(heimdall.ErrInternal, "failed to render rate limit key").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	allowed, retryAfter, err := a.take(ctx, a.calculateCacheKey(key))
+	if err != nil {
+		// fail closed, as the limit can't be enforced otherwise
+		return errorchain.NewWithMessage(heimdall.ErrInternal, "failed to update rate limiter state").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	if !allowed {
+		logger.Debug().Str("_key", key).Msg("Rate limit exceeded")
+
+		return errorchain.NewWithMessage(heimdall.ErrTooManyRequests, "rate limit exceeded").
+			WithErrorContext(a).
+			CausedBy(&heimdall.TooManyRequestsError{RetryAfter: retryAfter})
+	}
+
+	return nil
+}
+
+func (a *rateLimitAuthorizer) WithConfig(rawConfig map[string]any) (Authorizer, error) {
+	if len(rawConfig) == 0 {
+		return a, nil
+	}
+
+	type Config struct {
+		Key       template.Template `mapstructure:"key"`
+		Algorithm string            `mapstructure:"algorithm" validate:"omitempty,oneof=token_bucket sliding_window"`
+		Limit     int               `mapstructure:"limit"     validate:"gte=0"`
+		Period    time.Duration     `mapstructure:"period"    validate:"gte=0"`
+		Burst     *int              `mapstructure:"burst"     validate:"omitempty,gte=0"`
+	}
+
+	var conf Config
+	if err := decodeConfig(AuthorizerRateLimit, rawConfig, &conf); err != nil {
+		return nil, err
+	}
+
+	algorithm := x.IfThenElse(len(conf.Algorithm) != 0, conf.Algorithm, a.algorithm)
+	limit := rateLimit{
+		Limit:  x.IfThenElse(conf.Limit != 0, conf.Limit, a.limit.Limit),
+		Period: x.IfThenElse(conf.Period != 0, conf.Period, a.limit.Period),
+	}
+
+	if conf.Burst != nil {
+		limit.Burst = *conf.Burst
+	} else if algorithm == a.algorithm {
+		limit.Burst = a.burst
+	}
+
+	return newRateLimitAuthorizerFor(a.id, x.IfThenElse(conf.Key != nil, conf.Key, a.key), algorithm, limit)
+}
+
+func (a *rateLimitAuthorizer) ID() string { return a.id }
+
+func (a *rateLimitAuthorizer) ContinueOnError() bool { return false }
+
+// CacheDependency implements the cache dependency check done while loading the configuration,
+// as the limiter states are held in the cache.
+func (a *rateLimitAuthorizer) CacheDependency() string { return "rate limiting" }
+
+// take consumes a request from the limiter state held in the cache. The state is updated
+// atomically, so that concurrent requests, even if handled by different heimdall instances
+// sharing a distributed cache, can't consume the same capacity.
+func (a *rateLimitAuthorizer) take(ctx heimdall.Context, key string) (bool, time.Duration, error) {
+	var (
+		allowed    bool
+		retryAfter time.Duration
+	)
+
+	err := cache.Ctx(ctx.AppContext()).Update(ctx.AppContext(), key, func(current any) (any, time.Duration) {
+		now := time.Now()
+		// the update function might be called multiple times. So the current state is
+		// copied to not modify the instance, which might be held by the cache
+		state := a.copyState(current, now)
+
+		allowed, retryAfter = state.take(now, a.limit)
+
+		return state, state.ttl(a.limit)
+	})
+
+	return allowed, retryAfter, err
+}
+
+func (a *rateLimitAuthorizer) copyState(current any, now time.Time) rateLimiterState {
+	if a.algorithm == rateLimitAlgorithmTokenBucket {
+		if cached, ok := current.(*tokenBucketState); ok {
+			state := *cached
+
+			return &state
+		}
+
+		return newTokenBucketState(now, a.limit)
+	}
+
+	if cached, ok := current.(*slidingWindowState); ok {
+		state := *cached
+
+		return &state
+	}
+
+	return newSlidingWindowState(now, a.limit)
+}
+
+func (a *rateLimitAuthorizer) calculateCacheKey(key string) string {
+	const int64BytesCount = 8
+
+	limit := make([]byte, int64BytesCount*3) //nolint:gomnd
+	binary.LittleEndian.PutUint64(limit, uint64(a.limit.Limit))
+	binary.LittleEndian.PutUint64(limit[int64BytesCount:], uint64(a.limit.Period))
+	binary.LittleEndian.PutUint64(limit[2*int64BytesCount:], uint64(a.limit.Burst))
+
+	hash := sha256.New()
+	hash.Write(stringx.ToBytes(a.id))
+	hash.Write(stringx.ToBytes(a.algorithm))
+	hash.Write(limit)
+	hash.Write(stringx.ToBytes(key))
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
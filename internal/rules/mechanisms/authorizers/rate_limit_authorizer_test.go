@@ -0,0 +1,587 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package authorizers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/cache"
+	"github.com/dadrus/heimdall/internal/cache/memory"
+	mocks2 "github.com/dadrus/heimdall/internal/cache/mocks"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/heimdall/mocks"
+	"github.com/dadrus/heimdall/internal/rules/mechanisms/subject"
+	"github.com/dadrus/heimdall/internal/x"
+	"github.com/dadrus/heimdall/internal/x/testsupport"
+)
+
+func TestCreateRateLimitAuthorizer(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc     string
+		config []byte
+		assert func(t *testing.T, err error, auth *rateLimitAuthorizer)
+	}{
+		{
+			uc: "without configuration",
+			assert: func(t *testing.T, err error, _ *rateLimitAuthorizer) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "'key' is a required field")
+				assert.Contains(t, err.Error(), "'limit' is a required field")
+				assert.Contains(t, err.Error(), "'period' is a required field")
+			},
+		},
+		{
+			uc: "with unsupported algorithm",
+			config: []byte(`
+key: "{{ .Subject.ID }}"
+algorithm: leaky_bucket
+limit: 10
+period: 1m
+`),
+			assert: func(t *testing.T, err error, _ *rateLimitAuthorizer) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "'algorithm' must be one of")
+			},
+		},
+		{
+			uc: "with negative limit",
+			config: []byte(`
+key: "{{ .Subject.ID }}"
+limit: -1
+period: 1m
+`),
+			assert: func(t *testing.T, err error, _ *rateLimitAuthorizer) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "'limit' must be greater than 0")
+			},
+		},
+		{
+			uc: "with burst for sliding window algorithm",
+			config: []byte(`
+key: "{{ .Subject.ID }}"
+algorithm: sliding_window
+limit: 10
+period: 1m
+burst: 20
+`),
+			assert: func(t *testing.T, err error, _ *rateLimitAuthorizer) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "burst is only supported")
+			},
+		},
+		{
+			uc: "with unsupported properties",
+			config: []byte(`
+key: "{{ .Subject.ID }}"
+limit: 10
+period: 1m
+foo: bar
+`),
+			assert: func(t *testing.T, err error, _ *rateLimitAuthorizer) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "invalid keys")
+			},
+		},
+		{
+			uc: "with minimal configuration",
+			config: []byte(`
+key: "{{ .Subject.ID }}"
+limit: 10
+period: 1m
+`),
+			assert: func(t *testing.T, err error, auth *rateLimitAuthorizer) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "foo", auth.ID())
+				assert.False(t, auth.ContinueOnError())
+				assert.NotNil(t, auth.key)
+				assert.Equal(t, rateLimitAlgorithmTokenBucket, auth.algorithm)
+				assert.Equal(t, rateLimit{Limit: 10, Period: time.Minute, Burst: 10}, auth.limit)
+			},
+		},
+		{
+			uc: "with token bucket algorithm and burst",
+			config: []byte(`
+key: "{{ .Subject.ID }}"
+algorithm: token_bucket
+limit: 10
+period: 1s
+burst: 50
+`),
+			assert: func(t *testing.T, err error, auth *rateLimitAuthorizer) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, rateLimitAlgorithmTokenBucket, auth.algorithm)
+				assert.Equal(t, rateLimit{Limit: 10, Period: time.Second, Burst: 50}, auth.limit)
+			},
+		},
+		{
+			uc: "with sliding window algorithm",
+			config: []byte(`
+key: "{{ index .Request.ClientIPAddresses 0 }}"
+algorithm: sliding_window
+limit: 100
+period: 1h
+`),
+			assert: func(t *testing.T, err error, auth *rateLimitAuthorizer) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, rateLimitAlgorithmSlidingWindow, auth.algorithm)
+				assert.Equal(t, rateLimit{Limit: 100, Period: time.Hour}, auth.limit)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			conf, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			// WHEN
+			auth, err := newRateLimitAuthorizer("foo", conf)
+
+			// THEN
+			tc.assert(t, err, auth)
+		})
+	}
+}
+
+func TestCreateRateLimitAuthorizerFromPrototype(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc              string
+		prototypeConfig []byte
+		config          []byte
+		assert          func(t *testing.T, err error, prototype *rateLimitAuthorizer,
+			configured *rateLimitAuthorizer)
+	}{
+		{
+			uc: "without new configuration",
+			prototypeConfig: []byte(`
+key: "{{ .Subject.ID }}"
+limit: 10
+period: 1m
+`),
+			assert: func(t *testing.T, err error, prototype *rateLimitAuthorizer, configured *rateLimitAuthorizer) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, prototype, configured)
+			},
+		},
+		{
+			uc: "with unsupported properties",
+			prototypeConfig: []byte(`
+key: "{{ .Subject.ID }}"
+limit: 10
+period: 1m
+`),
+			config: []byte(`foo: bar`),
+			assert: func(t *testing.T, err error, _ *rateLimitAuthorizer, _ *rateLimitAuthorizer) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "invalid keys")
+			},
+		},
+		{
+			uc: "with burst for sliding window algorithm",
+			prototypeConfig: []byte(`
+key: "{{ .Subject.ID }}"
+algorithm: sliding_window
+limit: 10
+period: 1m
+`),
+			config: []byte(`burst: 20`),
+			assert: func(t *testing.T, err error, _ *rateLimitAuthorizer, _ *rateLimitAuthorizer) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "burst is only supported")
+			},
+		},
+		{
+			uc: "with overridden limit",
+			prototypeConfig: []byte(`
+key: "{{ .Subject.ID }}"
+limit: 10
+period: 1m
+`),
+			config: []byte(`limit: 20`),
+			assert: func(t *testing.T, err error, prototype *rateLimitAuthorizer, configured *rateLimitAuthorizer) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.NotEqual(t, prototype, configured)
+				assert.Equal(t, prototype.ID(), configured.ID())
+				assert.Equal(t, prototype.key, configured.key)
+				assert.Equal(t, prototype.algorithm, configured.algorithm)
+				assert.Equal(t, rateLimit{Limit: 20, Period: time.Minute, Burst: 20}, configured.limit)
+			},
+		},
+		{
+			uc: "with overridden key and period keeping the burst of the prototype",
+			prototypeConfig: []byte(`
+key: "{{ .Subject.ID }}"
+limit: 10
+period: 1m
+burst: 30
+`),
+			config: []byte(`
+key: "{{ .Subject.Attributes.tenant }}"
+period: 1s
+`),
+			assert: func(t *testing.T, err error, prototype *rateLimitAuthorizer, configured *rateLimitAuthorizer) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.NotEqual(t, prototype.key, configured.key)
+				assert.Equal(t, rateLimit{Limit: 10, Period: time.Second, Burst: 30}, configured.limit)
+			},
+		},
+		{
+			uc: "with switched algorithm",
+			prototypeConfig: []byte(`
+key: "{{ .Subject.ID }}"
+limit: 10
+period: 1m
+burst: 30
+`),
+			config: []byte(`algorithm: sliding_window`),
+			assert: func(t *testing.T, err error, _ *rateLimitAuthorizer, configured *rateLimitAuthorizer) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, rateLimitAlgorithmSlidingWindow, configured.algorithm)
+				assert.Equal(t, rateLimit{Limit: 10, Period: time.Minute}, configured.limit)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			pc, err := testsupport.DecodeTestConfig(tc.prototypeConfig)
+			require.NoError(t, err)
+
+			conf, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			prototype, err := newRateLimitAuthorizer("foo", pc)
+			require.NoError(t, err)
+
+			// WHEN
+			auth, err := prototype.WithConfig(conf)
+
+			// THEN
+			var (
+				configured *rateLimitAuthorizer
+				ok         bool
+			)
+
+			if err == nil {
+				configured, ok = auth.(*rateLimitAuthorizer)
+				require.True(t, ok)
+			}
+
+			tc.assert(t, err, prototype, configured)
+		})
+	}
+}
+
+func TestRateLimitAuthorizerExecute(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc       string
+		config   []byte
+		sub      *subject.Subject
+		requests int
+		cache    func(t *testing.T) cache.Cache
+		assert   func(t *testing.T, errs []error)
+	}{
+		{
+			uc: "without subject",
+			config: []byte(`
+key: "{{ .Subject.ID }}"
+limit: 1
+period: 1m
+`),
+			requests: 1,
+			assert: func(t *testing.T, errs []error) {
+				t.Helper()
+
+				require.Error(t, errs[0])
+				require.ErrorIs(t, errs[0], heimdall.ErrInternal)
+				assert.Contains(t, errs[0].Error(), "'nil' subject")
+			},
+		},
+		{
+			uc: "with failing key rendering",
+			config: []byte(`
+key: "{{ len .Subject.ID.Foo }}"
+limit: 1
+period: 1m
+`),
+			sub:      &subject.Subject{ID: "alice"},
+			requests: 1,
+			assert: func(t *testing.T, errs []error) {
+				t.Helper()
+
+				require.Error(t, errs[0])
+				require.ErrorIs(t, errs[0], heimdall.ErrInternal)
+				assert.Contains(t, errs[0].Error(), "failed to render rate limit key")
+			},
+		},
+		{
+			uc: "with failing cache",
+			config: []byte(`
+key: "{{ .Subject.ID }}"
+limit: 1
+period: 1m
+`),
+			sub:      &subject.Subject{ID: "alice"},
+			requests: 1,
+			cache: func(t *testing.T) cache.Cache {
+				t.Helper()
+
+				cch := mocks2.NewCacheMock(t)
+				cch.EXPECT().Update(mock.Anything, mock.Anything, mock.Anything).Return(errors.New("test error"))
+
+				return cch
+			},
+			assert: func(t *testing.T, errs []error) {
+				t.Helper()
+
+				require.Error(t, errs[0])
+				require.ErrorIs(t, errs[0], heimdall.ErrInternal)
+				assert.Contains(t, errs[0].Error(), "failed to update rate limiter state")
+			},
+		},
+		{
+			uc: "without cache",
+			config: []byte(`
+key: "{{ .Subject.ID }}"
+limit: 1
+period: 1m
+`),
+			sub:      &subject.Subject{ID: "alice"},
+			requests: 1,
+			cache: func(t *testing.T) cache.Cache {
+				t.Helper()
+
+				return cache.Ctx(context.Background())
+			},
+			assert: func(t *testing.T, errs []error) {
+				t.Helper()
+
+				require.Error(t, errs[0])
+				require.ErrorIs(t, errs[0], heimdall.ErrInternal)
+				require.ErrorIs(t, errs[0], cache.ErrUnsupportedOperation)
+			},
+		},
+		{
+			uc: "token bucket exhausted",
+			config: []byte(`
+key: "{{ .Subject.ID }}"
+limit: 2
+period: 1h
+`),
+			sub:      &subject.Subject{ID: "alice"},
+			requests: 3,
+			assert: func(t *testing.T, errs []error) {
+				t.Helper()
+
+				require.NoError(t, errs[0])
+				require.NoError(t, errs[1])
+				require.Error(t, errs[2])
+				require.ErrorIs(t, errs[2], heimdall.ErrTooManyRequests)
+
+				var limitErr *heimdall.TooManyRequestsError
+				require.ErrorAs(t, errs[2], &limitErr)
+				assert.Greater(t, limitErr.RetryAfter, 29*time.Minute)
+				assert.LessOrEqual(t, limitErr.RetryAfter, 30*time.Minute)
+
+				var identifier interface{ ID() string }
+				require.ErrorAs(t, errs[2], &identifier)
+				assert.Equal(t, "foo", identifier.ID())
+			},
+		},
+		{
+			uc: "sliding window exhausted",
+			config: []byte(`
+key: "{{ .Subject.Attributes.tenant }}"
+algorithm: sliding_window
+limit: 2
+period: 1h
+`),
+			sub:      &subject.Subject{ID: "alice", Attributes: map[string]any{"tenant": "acme"}},
+			requests: 3,
+			assert: func(t *testing.T, errs []error) {
+				t.Helper()
+
+				require.NoError(t, errs[0])
+				require.NoError(t, errs[1])
+				require.ErrorIs(t, errs[2], heimdall.ErrTooManyRequests)
+
+				var limitErr *heimdall.TooManyRequestsError
+				require.ErrorAs(t, errs[2], &limitErr)
+				assert.Greater(t, limitErr.RetryAfter, time.Duration(0))
+				assert.LessOrEqual(t, limitErr.RetryAfter, 90*time.Minute)
+			},
+		},
+		{
+			uc: "limit not exhausted",
+			config: []byte(`
+key: "{{ index .Request.ClientIPAddresses 0 }}"
+limit: 5
+period: 1m
+`),
+			sub:      &subject.Subject{ID: "alice"},
+			requests: 5,
+			assert: func(t *testing.T, errs []error) {
+				t.Helper()
+
+				for _, err := range errs {
+					require.NoError(t, err)
+				}
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			conf, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			auth, err := newRateLimitAuthorizer("foo", conf)
+			require.NoError(t, err)
+
+			cch := x.IfThenElseExec(tc.cache != nil,
+				func() cache.Cache { return tc.cache(t) },
+				func() cache.Cache { return memory.New() })
+
+			ctx := mocks.NewContextMock(t)
+			ctx.EXPECT().AppContext().Return(cache.WithContext(context.Background(), cch))
+			ctx.EXPECT().Request().Return(&heimdall.Request{ClientIPAddresses: []string{"10.1.2.3"}}).Maybe()
+
+			// WHEN
+			errs := make([]error, tc.requests)
+			for idx := range errs {
+				errs[idx] = auth.Execute(ctx, tc.sub)
+			}
+
+			// THEN
+			tc.assert(t, errs)
+		})
+	}
+}
+
+func TestTokenBucketStateTake(t *testing.T) {
+	t.Parallel()
+
+	limit := rateLimit{Limit: 2, Period: time.Second, Burst: 4}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	state := newTokenBucketState(now, limit)
+
+	// the whole burst is available immediately
+	for idx := 0; idx < limit.Burst; idx++ {
+		allowed, _ := state.take(now, limit)
+		require.True(t, allowed)
+	}
+
+	allowed, retryAfter := state.take(now, limit)
+	require.False(t, allowed)
+	assert.Equal(t, 500*time.Millisecond, retryAfter)
+
+	// one token is refilled every 500ms
+	allowed, _ = state.take(now.Add(500*time.Millisecond), limit)
+	require.True(t, allowed)
+
+	allowed, retryAfter = state.take(now.Add(600*time.Millisecond), limit)
+	require.False(t, allowed)
+	assert.Equal(t, 400*time.Millisecond, retryAfter)
+
+	// the bucket never holds more tokens than the burst
+	state.take(now.Add(time.Hour), limit)
+	assert.InDelta(t, float64(limit.Burst-1), state.Tokens, 0.001)
+	assert.Equal(t, 2*time.Second, state.ttl(limit))
+}
+
+func TestSlidingWindowStateTake(t *testing.T) {
+	t.Parallel()
+
+	limit := rateLimit{Limit: 4, Period: time.Minute}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	state := newSlidingWindowState(now, limit)
+
+	for idx := 0; idx < limit.Limit; idx++ {
+		allowed, _ := state.take(now, limit)
+		require.True(t, allowed)
+	}
+
+	// the current window is exhausted, so the next request is possible only after a
+	// quarter of the next window, as soon as one of the requests slid out of the window
+	allowed, retryAfter := state.take(now.Add(30*time.Second), limit)
+	require.False(t, allowed)
+	assert.Equal(t, 45*time.Second, retryAfter)
+
+	allowed, _ = state.take(now.Add(75*time.Second), limit)
+	require.True(t, allowed)
+	assert.Equal(t, 4, state.Previous)
+	assert.Equal(t, 1, state.Current)
+
+	// 4 * (1 - 0.5) + 1 = 3 requests in the sliding window, so one more is possible
+	allowed, _ = state.take(now.Add(90*time.Second), limit)
+	require.True(t, allowed)
+
+	// 4 * (1 - 0.5) + 2 = 4, so the previous window has to slide out by another 25%
+	allowed, retryAfter = state.take(now.Add(90*time.Second), limit)
+	require.False(t, allowed)
+	assert.Equal(t, 15*time.Second, retryAfter)
+
+	// windows older than the previous one are not taken into account
+	allowed, _ = state.take(now.Add(5*time.Minute), limit)
+	require.True(t, allowed)
+	assert.Equal(t, 0, state.Previous)
+	assert.Equal(t, 1, state.Current)
+	assert.Equal(t, 2*time.Minute, state.ttl(limit))
+}
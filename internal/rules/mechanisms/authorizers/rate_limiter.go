@@ -0,0 +1,141 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package authorizers
+
+import (
+	"math"
+	"time"
+
+	"github.com/dadrus/heimdall/internal/cache/encoding"
+)
+
+const (
+	rateLimitAlgorithmTokenBucket   = "token_bucket"
+	rateLimitAlgorithmSlidingWindow = "sliding_window"
+)
+
+// by intention. Used only during application bootstrap
+//
+//nolint:gochecknoinits
+func init() {
+	encoding.RegisterType[*tokenBucketState]("rate_limit_authorizer.token_bucket")
+	encoding.RegisterType[*slidingWindowState]("rate_limit_authorizer.sliding_window")
+}
+
+type rateLimit struct {
+	Limit  int
+	Period time.Duration
+	Burst  int
+}
+
+type rateLimiterState interface {
+	// take tries to consume a single request from the given limit. If the limit is exhausted
+	// it returns false together with the duration after which the next request will be accepted.
+	take(now time.Time, limit rateLimit) (bool, time.Duration)
+	// ttl returns for how long the state has to be kept to not lose any relevant information
+	ttl(limit rateLimit) time.Duration
+}
+
+// tokenBucketState implements the token bucket algorithm. The bucket holds up to Burst tokens
+// and is refilled with Limit tokens per Period. Each request consumes a single token.
+type tokenBucketState struct {
+	Tokens  float64   `json:"tokens"`
+	Updated time.Time `json:"updated"`
+}
+
+func newTokenBucketState(now time.Time, limit rateLimit) *tokenBucketState {
+	return &tokenBucketState{Tokens: float64(limit.Burst), Updated: now}
+}
+
+func (s *tokenBucketState) take(now time.Time, limit rateLimit) (bool, time.Duration) {
+	rate := float64(limit.Limit) / float64(limit.Period)
+
+	if elapsed := now.Sub(s.Updated); elapsed > 0 {
+		s.Tokens = math.Min(float64(limit.Burst), s.Tokens+float64(elapsed)*rate)
+		s.Updated = now
+	}
+
+	if s.Tokens >= 1 {
+		s.Tokens--
+
+		return true, 0
+	}
+
+	return false, time.Duration(math.Ceil((1 - s.Tokens) / rate))
+}
+
+func (s *tokenBucketState) ttl(limit rateLimit) time.Duration {
+	// time required to refill an empty bucket
+	return time.Duration(math.Ceil(float64(limit.Period) * float64(limit.Burst) / float64(limit.Limit)))
+}
+
+// slidingWindowState implements the sliding window counter algorithm. It counts the requests
+// in the current and in the previous fixed window and estimates the number of requests in the
+// sliding window by weighting the count of the previous window by its overlap with the latter.
+type slidingWindowState struct {
+	Start    time.Time `json:"start"`
+	Current  int       `json:"current"`
+	Previous int       `json:"previous"`
+}
+
+func newSlidingWindowState(now time.Time, limit rateLimit) *slidingWindowState {
+	return &slidingWindowState{Start: now.Truncate(limit.Period)}
+}
+
+func (s *slidingWindowState) take(now time.Time, limit rateLimit) (bool, time.Duration) {
+	start := now.Truncate(limit.Period)
+
+	switch {
+	case start.Equal(s.Start):
+	case start.Equal(s.Start.Add(limit.Period)):
+		s.Start, s.Previous, s.Current = start, s.Current, 0
+	case start.After(s.Start):
+		s.Start, s.Previous, s.Current = start, 0, 0
+	}
+
+	period := float64(limit.Period)
+	elapsed := float64(now.Sub(s.Start))
+	estimated := float64(s.Previous)*(1-elapsed/period) + float64(s.Current)
+
+	if estimated+1 <= float64(limit.Limit) {
+		s.Current++
+
+		return true, 0
+	}
+
+	// the number of requests in the previous window, which must slide out of the
+	// window until there is capacity for one more request again
+	allowed := float64(limit.Limit - 1)
+
+	if s.Current > limit.Limit-1 {
+		// even the requests of the current window alone exhaust the limit. So
+		// the next request is possible in the next window only, as soon as
+		// enough of the current requests slid out.
+		wait := period - elapsed + period*(1-allowed/float64(s.Current))
+
+		return false, time.Duration(math.Ceil(wait))
+	}
+
+	wait := period*(1-(allowed-float64(s.Current))/float64(s.Previous)) - elapsed
+
+	return false, time.Duration(math.Ceil(math.Max(wait, 1)))
+}
+
+func (s *slidingWindowState) ttl(limit rateLimit) time.Duration {
+	// the state of the current window is relevant during the next window as well
+	return 2 * limit.Period
+}
@@ -0,0 +1,224 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package authorizers
+
+import (
+	"context"
+
+	"github.com/open-policy-agent/opa/rego"
+	"github.com/rs/zerolog"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/rules/mechanisms/subject"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+// by intention. Used only during application bootstrap
+//
+//nolint:gochecknoinits
+func init() {
+	registerTypeFactory(
+		func(id string, typ string, conf map[string]any) (bool, Authorizer, error) {
+			if typ != AuthorizerRego {
+				return false, nil, nil
+			}
+
+			auth, err := newRegoAuthorizer(id, conf)
+
+			return true, auth, err
+		})
+}
+
+type regoAuthorizer struct {
+	id          string
+	query       *regoQuery
+	policy      *regoPolicy
+	includeBody bool
+}
+
+func newRegoAuthorizer(id string, rawConfig map[string]any) (*regoAuthorizer, error) {
+	type Config struct {
+		Policies    []string `mapstructure:"policies"     validate:"required_without=Bundles,dive,required"`
+		Bundles     []string `mapstructure:"bundles"      validate:"dive,required"`
+		Query       string   `mapstructure:"query"        validate:"required"`
+		IncludeBody bool     `mapstructure:"include_body"`
+	}
+
+	var conf Config
+	if err := decodeConfig(AuthorizerRego, rawConfig, &conf); err != nil {
+		return nil, err
+	}
+
+	policy, err := newRegoPolicy(conf.Policies, conf.Bundles)
+	if err != nil {
+		return nil, err
+	}
+
+	return newRegoAuthorizerForPolicy(id, conf.Query, policy, conf.IncludeBody)
+}
+
+func newRegoAuthorizerForPolicy(id, query string, policy *regoPolicy, includeBody bool) (*regoAuthorizer, error) {
+	prepared, err := policy.prepare(context.Background(), query)
+	if err != nil {
+		return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+			"failed to compile rego query '%s'", query).CausedBy(err)
+	}
+
+	return &regoAuthorizer{id: id, query: prepared, policy: policy, includeBody: includeBody}, nil
+}
+
+func (a *regoAuthorizer) Execute(ctx heimdall.Context, sub *subject.Subject) error {
+	logger := zerolog.Ctx(ctx.AppContext())
+	logger.Debug().Str("_id", a.id).Msg("Authorizing using rego authorizer")
+
+	if sub == nil {
+		return errorchain.NewWithMessage(heimdall.ErrInternal,
+			"failed to execute rego authorizer due to 'nil' subject").
+			WithErrorContext(a)
+	}
+
+	a.policy.reportReloadError(*logger)
+
+	results, err := a.query.eval(ctx.AppContext(), regoInput(ctx.Request(), sub, a.includeBody))
+	if err != nil {
+		return errorchain.NewWithMessage(heimdall.ErrInternal, "failed evaluating rego policy").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	headers, err := a.decide(results)
+	if err != nil {
+		return err
+	}
+
+	for name, value := range headers {
+		ctx.AddHeaderForUpstream(name, value)
+	}
+
+	return nil
+}
+
+func (a *regoAuthorizer) WithConfig(rawConfig map[string]any) (Authorizer, error) {
+	if len(rawConfig) == 0 {
+		return a, nil
+	}
+
+	type Config struct {
+		Query string `mapstructure:"query" validate:"required"`
+	}
+
+	var conf Config
+	if err := decodeConfig(AuthorizerRego, rawConfig, &conf); err != nil {
+		return nil, err
+	}
+
+	return newRegoAuthorizerForPolicy(a.id, conf.Query, a.policy, a.includeBody)
+}
+
+func (a *regoAuthorizer) ID() string { return a.id }
+
+func (a *regoAuthorizer) ContinueOnError() bool { return false }
+
+// decide maps the result of the query to an authorization decision. The result is expected to be
+// either a boolean, or an object with a boolean allow property and an optional headers object,
+// holding the headers to be forwarded to the upstream service.
+func (a *regoAuthorizer) decide(results rego.ResultSet) (map[string]string, error) {
+	if len(results) == 0 || len(results[0].Expressions) == 0 {
+		return nil, errorchain.NewWithMessage(heimdall.ErrAuthorization, "rego policy decision is undefined").
+			WithErrorContext(a)
+	}
+
+	var (
+		allowed bool
+		headers map[string]string
+	)
+
+	switch decision := results[0].Expressions[0].Value.(type) {
+	case bool:
+		allowed = decision
+	case map[string]any:
+		allow, ok := decision["allow"].(bool)
+		if !ok {
+			return nil, errorchain.NewWithMessage(heimdall.ErrInternal,
+				"rego policy decision does not contain a boolean allow property").
+				WithErrorContext(a)
+		}
+
+		allowed = allow
+
+		if rawHeaders, present := decision["headers"]; present {
+			values, ok := rawHeaders.(map[string]any)
+			if !ok {
+				return nil, errorchain.NewWithMessage(heimdall.ErrInternal,
+					"headers in rego policy decision must be an object").
+					WithErrorContext(a)
+			}
+
+			headers = make(map[string]string, len(values))
+
+			for name, value := range values {
+				strValue, ok := value.(string)
+				if !ok {
+					return nil, errorchain.NewWithMessagef(heimdall.ErrInternal,
+						"value of header %s in rego policy decision is not a string", name).
+						WithErrorContext(a)
+				}
+
+				headers[name] = strValue
+			}
+		}
+	default:
+		return nil, errorchain.NewWithMessagef(heimdall.ErrInternal,
+			"unexpected rego policy decision type %T", decision).
+			WithErrorContext(a)
+	}
+
+	if !allowed {
+		return nil, errorchain.NewWithMessage(heimdall.ErrAuthorization, "denied by rego policy").
+			WithErrorContext(a)
+	}
+
+	return headers, nil
+}
+
+func regoInput(req *heimdall.Request, sub *subject.Subject, includeBody bool) map[string]any {
+	request := map[string]any{
+		"Method":            req.Method,
+		"ClientIPAddresses": req.ClientIPAddresses,
+		"Headers":           req.Headers(),
+	}
+
+	if includeBody {
+		request["Body"] = req.Body()
+	}
+
+	if req.URL != nil {
+		request["URL"] = map[string]any{
+			"Scheme":   req.URL.Scheme,
+			"Host":     req.URL.Host,
+			"Path":     req.URL.Path,
+			"RawQuery": req.URL.RawQuery,
+			"Query":    map[string][]string(req.URL.Query()),
+			"Captures": req.URL.Captures,
+		}
+	}
+
+	return map[string]any{
+		"Subject": map[string]any{"ID": sub.ID, "Attributes": sub.Attributes},
+		"Request": request,
+	}
+}
@@ -0,0 +1,550 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package authorizers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/heimdall/mocks"
+	"github.com/dadrus/heimdall/internal/rules/mechanisms/subject"
+	"github.com/dadrus/heimdall/internal/x/testsupport"
+)
+
+const testRegoPolicy = `package heimdall.authz
+
+default allow := false
+
+allow {
+	input.Subject.ID == data.users[_]
+	input.Request.Method == "GET"
+}
+
+decision := {"allow": allow, "headers": {"X-User": input.Subject.ID}}
+
+body_allowed {
+	input.Request.Body.action == "read"
+}
+
+attributes_present {
+	input.Subject.Attributes.user.group == "admin"
+	not input.Outputs
+}
+
+invalid_decision := "foo"
+`
+
+func createRegoPolicy(t *testing.T, policy string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "policy.rego"), []byte(policy), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "data.json"), []byte(`{"users": ["foo"]}`), 0o600))
+
+	return dir
+}
+
+func TestCreateRegoAuthorizer(t *testing.T) {
+	t.Parallel()
+
+	policyDir := createRegoPolicy(t, testRegoPolicy)
+	invalidPolicyDir := createRegoPolicy(t, "package heimdall.authz\n\nallow {")
+
+	for _, tc := range []struct {
+		uc     string
+		config []byte
+		assert func(t *testing.T, err error, auth *regoAuthorizer)
+	}{
+		{
+			uc: "without configuration",
+			assert: func(t *testing.T, err error, _ *regoAuthorizer) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "'policies' is a required field")
+				assert.Contains(t, err.Error(), "'query' is a required field")
+			},
+		},
+		{
+			uc:     "with unsupported property",
+			config: []byte(`{ foo: bar, query: data.heimdall.authz.allow, policies: [ "` + policyDir + `" ] }`),
+			assert: func(t *testing.T, err error, _ *regoAuthorizer) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "invalid keys")
+			},
+		},
+		{
+			uc:     "with not existing policy path",
+			config: []byte(`{ query: data.heimdall.authz.allow, policies: [ /does/not/exist ] }`),
+			assert: func(t *testing.T, err error, _ *regoAuthorizer) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed to load rego policy")
+			},
+		},
+		{
+			uc:     "with invalid policy",
+			config: []byte(`{ query: data.heimdall.authz.allow, policies: [ "` + invalidPolicyDir + `" ] }`),
+			assert: func(t *testing.T, err error, _ *regoAuthorizer) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed to load rego policy")
+			},
+		},
+		{
+			uc:     "with invalid query",
+			config: []byte(`{ query: "data.heimdall.authz.allow[", policies: [ "` + policyDir + `" ] }`),
+			assert: func(t *testing.T, err error, _ *regoAuthorizer) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed to compile rego query")
+			},
+		},
+		{
+			uc:     "with policy loaded from a directory",
+			config: []byte(`{ query: data.heimdall.authz.allow, policies: [ "` + policyDir + `" ] }`),
+			assert: func(t *testing.T, err error, auth *regoAuthorizer) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, auth)
+				assert.Equal(t, "data.heimdall.authz.allow", auth.query.query)
+				assert.Len(t, auth.policy.modules, 1)
+				assert.Equal(t, map[string]any{"users": []any{"foo"}}, auth.policy.data)
+				assert.NotNil(t, auth.query.prepared.Load())
+				assert.False(t, auth.includeBody)
+				assert.False(t, auth.ContinueOnError())
+			},
+		},
+		{
+			uc: "with body included in the input",
+			config: []byte(`{ query: data.heimdall.authz.allow, policies: [ "` + policyDir + `" ],
+include_body: true }`),
+			assert: func(t *testing.T, err error, auth *regoAuthorizer) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, auth)
+				assert.True(t, auth.includeBody)
+			},
+		},
+		{
+			uc:     "with policy loaded from a bundle",
+			config: []byte(`{ query: data.heimdall.authz.allow, bundles: [ "` + policyDir + `" ] }`),
+			assert: func(t *testing.T, err error, auth *regoAuthorizer) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, auth)
+				assert.Len(t, auth.policy.modules, 1)
+				assert.Equal(t, map[string]any{"users": []any{"foo"}}, auth.policy.data)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			conf, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			// WHEN
+			auth, err := newRegoAuthorizer("foo", conf)
+
+			// THEN
+			tc.assert(t, err, auth)
+		})
+	}
+}
+
+func TestCreateRegoAuthorizerFromPrototype(t *testing.T) {
+	t.Parallel()
+
+	policyDir := createRegoPolicy(t, testRegoPolicy)
+
+	for _, tc := range []struct {
+		uc     string
+		config []byte
+		assert func(t *testing.T, err error, prototype *regoAuthorizer, configured *regoAuthorizer)
+	}{
+		{
+			uc: "without new configuration",
+			assert: func(t *testing.T, err error, prototype *regoAuthorizer, configured *regoAuthorizer) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, prototype, configured)
+			},
+		},
+		{
+			uc:     "with unsupported property",
+			config: []byte(`policies: [ foo ]`),
+			assert: func(t *testing.T, err error, _ *regoAuthorizer, _ *regoAuthorizer) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "invalid keys")
+			},
+		},
+		{
+			uc:     "with invalid query",
+			config: []byte(`query: "data.heimdall.authz.allow["`),
+			assert: func(t *testing.T, err error, _ *regoAuthorizer, _ *regoAuthorizer) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed to compile rego query")
+			},
+		},
+		{
+			uc:     "with new query",
+			config: []byte(`query: data.heimdall.authz.decision`),
+			assert: func(t *testing.T, err error, prototype *regoAuthorizer, configured *regoAuthorizer) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.NotEqual(t, prototype, configured)
+				assert.Equal(t, prototype.id, configured.id)
+				assert.Equal(t, prototype.policy, configured.policy)
+				assert.Equal(t, prototype.includeBody, configured.includeBody)
+				assert.Equal(t, "data.heimdall.authz.decision", configured.query.query)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			pc, err := testsupport.DecodeTestConfig([]byte(
+				`{ query: data.heimdall.authz.allow, policies: [ "` + policyDir + `" ] }`))
+			require.NoError(t, err)
+
+			conf, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			prototype, err := newRegoAuthorizer("foo", pc)
+			require.NoError(t, err)
+
+			// WHEN
+			auth, err := prototype.WithConfig(conf)
+
+			// THEN
+			var (
+				regoAuth *regoAuthorizer
+				ok       bool
+			)
+
+			if err == nil {
+				regoAuth, ok = auth.(*regoAuthorizer)
+				require.True(t, ok)
+			}
+
+			tc.assert(t, err, prototype, regoAuth)
+		})
+	}
+}
+
+func TestRegoAuthorizerExecute(t *testing.T) {
+	t.Parallel()
+
+	policyDir := createRegoPolicy(t, testRegoPolicy)
+
+	for _, tc := range []struct {
+		uc             string
+		query          string
+		includeBody    bool
+		method         string
+		sub            *subject.Subject
+		configureMocks func(t *testing.T, ctx *mocks.ContextMock)
+		assert         func(t *testing.T, err error)
+	}{
+		{
+			uc:    "with nil subject",
+			query: "data.heimdall.authz.allow",
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrInternal)
+				assert.Contains(t, err.Error(), "'nil' subject")
+			},
+		},
+		{
+			uc:     "with boolean decision allowing the request",
+			query:  "data.heimdall.authz.allow",
+			method: http.MethodGet,
+			sub:    &subject.Subject{ID: "foo"},
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.NoError(t, err)
+			},
+		},
+		{
+			uc:     "with boolean decision denying the request",
+			query:  "data.heimdall.authz.allow",
+			method: http.MethodPost,
+			sub:    &subject.Subject{ID: "foo"},
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrAuthorization)
+				assert.Contains(t, err.Error(), "denied by rego policy")
+			},
+		},
+		{
+			uc:     "with undefined decision",
+			query:  "data.heimdall.authz.does_not_exist",
+			method: http.MethodGet,
+			sub:    &subject.Subject{ID: "foo"},
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrAuthorization)
+				assert.Contains(t, err.Error(), "undefined")
+			},
+		},
+		{
+			uc:     "with unexpected decision type",
+			query:  "data.heimdall.authz.invalid_decision",
+			method: http.MethodGet,
+			sub:    &subject.Subject{ID: "foo"},
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrInternal)
+				assert.Contains(t, err.Error(), "unexpected rego policy decision type")
+			},
+		},
+		{
+			uc:     "with object decision denying the request",
+			query:  "data.heimdall.authz.decision",
+			method: http.MethodGet,
+			sub:    &subject.Subject{ID: "bar"},
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrAuthorization)
+			},
+		},
+		{
+			uc:          "with body included in the input",
+			query:       "data.heimdall.authz.body_allowed",
+			includeBody: true,
+			method:      http.MethodPost,
+			sub:         &subject.Subject{ID: "foo"},
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.NoError(t, err)
+			},
+		},
+		{
+			uc:     "without body in the input",
+			query:  "data.heimdall.authz.body_allowed",
+			method: http.MethodPost,
+			sub:    &subject.Subject{ID: "foo"},
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrAuthorization)
+				assert.Contains(t, err.Error(), "undefined")
+			},
+		},
+		{
+			uc:     "with outputs of previous mechanisms in the subject attributes",
+			query:  "data.heimdall.authz.attributes_present",
+			method: http.MethodGet,
+			sub: &subject.Subject{
+				ID:         "foo",
+				Attributes: map[string]any{"user": map[string]any{"group": "admin"}},
+			},
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.NoError(t, err)
+			},
+		},
+		{
+			uc:     "with object decision allowing the request and setting headers",
+			query:  "data.heimdall.authz.decision",
+			method: http.MethodGet,
+			sub:    &subject.Subject{ID: "foo"},
+			configureMocks: func(t *testing.T, ctx *mocks.ContextMock) {
+				t.Helper()
+
+				ctx.EXPECT().AddHeaderForUpstream("X-User", "foo")
+			},
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.NoError(t, err)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			conf, err := testsupport.DecodeTestConfig([]byte(
+				fmt.Sprintf(`{ query: %s, policies: [ "%s" ], include_body: %t }`, tc.query, policyDir, tc.includeBody)))
+			require.NoError(t, err)
+
+			ctx := mocks.NewContextMock(t)
+			ctx.EXPECT().AppContext().Return(context.Background())
+
+			if tc.sub != nil {
+				reqf := mocks.NewRequestFunctionsMock(t)
+				reqf.EXPECT().Headers().Return(map[string]string{"X-Foo": "bar"})
+
+				if tc.includeBody {
+					reqf.EXPECT().Body().Return(map[string]any{"action": "read"})
+				}
+
+				ctx.EXPECT().Request().Return(&heimdall.Request{
+					RequestFunctions: reqf,
+					Method:           tc.method,
+					URL: &heimdall.URL{URL: url.URL{
+						Scheme:   "http",
+						Host:     "localhost",
+						Path:     "/test",
+						RawQuery: "foo=bar",
+					}},
+					ClientIPAddresses: []string{"127.0.0.1"},
+				})
+			}
+
+			if tc.configureMocks != nil {
+				tc.configureMocks(t, ctx)
+			}
+
+			auth, err := newRegoAuthorizer("foo", conf)
+			require.NoError(t, err)
+
+			// WHEN
+			err = auth.Execute(ctx, tc.sub)
+
+			// THEN
+			tc.assert(t, err)
+		})
+	}
+}
+
+func TestRegoAuthorizerPolicyReload(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	policyDir := createRegoPolicy(t, testRegoPolicy)
+
+	conf, err := testsupport.DecodeTestConfig([]byte(
+		`{ query: data.heimdall.authz.allow, policies: [ "` + policyDir + `" ] }`))
+	require.NoError(t, err)
+
+	auth, err := newRegoAuthorizer("foo", conf)
+	require.NoError(t, err)
+
+	var logs bytes.Buffer
+
+	execute := func() error {
+		reqf := mocks.NewRequestFunctionsMock(t)
+		reqf.EXPECT().Headers().Return(map[string]string{})
+
+		ctx := mocks.NewContextMock(t)
+		ctx.EXPECT().AppContext().Return(zerolog.New(&logs).WithContext(context.Background()))
+		ctx.EXPECT().Request().Return(&heimdall.Request{
+			RequestFunctions: reqf,
+			Method:           http.MethodGet,
+			URL:              &heimdall.URL{URL: url.URL{Scheme: "http", Host: "localhost", Path: "/"}},
+		})
+
+		return auth.Execute(ctx, &subject.Subject{ID: "bar"})
+	}
+
+	require.Error(t, execute())
+
+	// WHEN
+	require.NoError(t, os.WriteFile(filepath.Join(policyDir, "data.json"), []byte(`{"users": ["bar"]}`), 0o600))
+
+	// THEN
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.NoError(c, execute())
+	}, 2*time.Second, 50*time.Millisecond)
+
+	// WHEN
+	require.NoError(t, os.WriteFile(filepath.Join(policyDir, "policy.rego"),
+		[]byte("package heimdall.authz\n\nallow {"), 0o600))
+
+	// THEN
+	require.Eventually(t, func() bool { return auth.policy.reloadErr.Load() != nil },
+		2*time.Second, 50*time.Millisecond)
+	require.NoError(t, execute())
+	assert.Contains(t, logs.String(), "Failed to reload rego policy")
+}
+
+func TestRegoPolicyReloadKeepsPolicyIfQueryCannotBePrepared(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	policyDir := createRegoPolicy(t, testRegoPolicy)
+
+	policy, err := newRegoPolicy([]string{policyDir}, nil)
+	require.NoError(t, err)
+
+	query, err := policy.prepare(context.Background(), "data.heimdall.authz.decision.allow")
+	require.NoError(t, err)
+
+	// the same query is shared
+	other, err := policy.prepare(context.Background(), "data.heimdall.authz.decision.allow")
+	require.NoError(t, err)
+	assert.Same(t, query, other)
+
+	prepared := query.prepared.Load()
+
+	// a policy, for which the query results in a type error
+	require.NoError(t, os.WriteFile(filepath.Join(policyDir, "policy.rego"),
+		[]byte("package heimdall.authz\n\ndecision := true\n"), 0o600))
+
+	// WHEN
+	err = policy.reload(context.Background())
+
+	// THEN
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "data.heimdall.authz.decision.allow")
+	assert.Same(t, prepared, query.prepared.Load())
+}
@@ -0,0 +1,333 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package authorizers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/loader"
+	"github.com/open-policy-agent/opa/rego"
+	"github.com/open-policy-agent/opa/storage/inmem"
+	"github.com/rs/zerolog"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+// modifications of the policy files usually result in several events. These are collected
+// for the given duration before the policy is reloaded.
+const regoPolicyReloadDelay = 100 * time.Millisecond
+
+var (
+	errNoPolicyModules   = errors.New("no policy modules found")
+	errConflictingPolicy = errors.New("conflicting data documents")
+)
+
+// regoPolicy holds the policy modules and data documents loaded from the configured paths and bundles.
+// All these are watched for changes and reloaded in the background if modified. On reload, all queries
+// prepared for the policy are prepared again, so that the evaluation of requests is not delayed by
+// the compilation. If the modified policy cannot be loaded or compiled, or any of the queries cannot
+// be prepared for it, the previously loaded policy and prepared queries are kept.
+type regoPolicy struct {
+	paths   []string
+	bundles []string
+
+	mu      sync.RWMutex
+	modules []*ast.Module
+	data    map[string]any
+	queries map[string]*regoQuery
+
+	// the error of the last failed reload. As there is no logger available in the background,
+	// it is logged on next use of the policy.
+	reloadErr atomic.Pointer[error]
+
+	w *fsnotify.Watcher
+}
+
+// regoQuery is a query prepared for the currently loaded policy.
+type regoQuery struct {
+	query    string
+	prepared atomic.Pointer[rego.PreparedEvalQuery]
+}
+
+func (q *regoQuery) eval(ctx context.Context, input any) (rego.ResultSet, error) {
+	return q.prepared.Load().Eval(ctx, rego.EvalInput(input))
+}
+
+func newRegoPolicy(paths, bundles []string) (*regoPolicy, error) {
+	policy := &regoPolicy{paths: paths, bundles: bundles, queries: make(map[string]*regoQuery)}
+
+	modules, data, err := policy.load()
+	if err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"failed to load rego policy").CausedBy(err)
+	}
+
+	policy.modules = modules
+	policy.data = data
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrInternal,
+			"failed to instantiate rego policy watcher").CausedBy(err)
+	}
+
+	for _, dir := range watchedDirectories(append(append([]string{}, paths...), bundles...)) {
+		if err = watcher.Add(dir); err != nil {
+			watcher.Close()
+
+			return nil, errorchain.NewWithMessagef(heimdall.ErrInternal,
+				"failed to watch %s", dir).CausedBy(err)
+		}
+	}
+
+	policy.w = watcher
+
+	go policy.watch()
+
+	return policy, nil
+}
+
+func (p *regoPolicy) watch() {
+	reload := time.NewTimer(regoPolicyReloadDelay)
+	reload.Stop()
+
+	defer reload.Stop()
+
+	for {
+		select {
+		case _, ok := <-p.w.Events:
+			if !ok {
+				return
+			}
+
+			reload.Reset(regoPolicyReloadDelay)
+		case _, ok := <-p.w.Errors:
+			if !ok {
+				return
+			}
+
+			reload.Reset(regoPolicyReloadDelay)
+		case <-reload.C:
+			if err := p.reload(context.Background()); err != nil {
+				p.reloadErr.Store(&err)
+			}
+		}
+	}
+}
+
+// reportReloadError logs the error of the last failed reload, if any.
+func (p *regoPolicy) reportReloadError(logger zerolog.Logger) {
+	if err := p.reloadErr.Swap(nil); err != nil {
+		logger.Warn().Err(*err).Msg("Failed to reload rego policy. Keeping the previously loaded one")
+	}
+}
+
+func (p *regoPolicy) reload(ctx context.Context) error {
+	modules, data, err := p.load()
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	prepared := make(map[*regoQuery]rego.PreparedEvalQuery, len(p.queries))
+
+	for _, query := range p.queries {
+		pq, err := prepareRegoQuery(ctx, query.query, modules, data)
+		if err != nil {
+			return fmt.Errorf("failed to prepare query '%s': %w", query.query, err)
+		}
+
+		prepared[query] = pq
+	}
+
+	p.modules = modules
+	p.data = data
+
+	for query, pq := range prepared {
+		query.prepared.Store(&pq)
+	}
+
+	return nil
+}
+
+func (p *regoPolicy) load() ([]*ast.Module, map[string]any, error) {
+	modules := make(map[string]*ast.Module)
+	data := make(map[string]any)
+
+	if len(p.paths) != 0 {
+		result, err := loader.NewFileLoader().All(p.paths)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for name, module := range result.ParsedModules() {
+			modules[name] = module
+		}
+
+		if result.Documents != nil {
+			data = result.Documents
+		}
+	}
+
+	for _, path := range p.bundles {
+		bundle, err := loader.NewFileLoader().AsBundle(path)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, module := range bundle.Modules {
+			modules[path+":"+module.Path] = module.Parsed
+		}
+
+		if err = mergeRegoDocuments(data, bundle.Data); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if len(modules) == 0 {
+		return nil, nil, errNoPolicyModules
+	}
+
+	compiler := ast.NewCompiler()
+	if compiler.Compile(modules); compiler.Failed() {
+		return nil, nil, compiler.Errors
+	}
+
+	names := make([]string, 0, len(modules))
+	for name := range modules {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	parsed := make([]*ast.Module, len(names))
+	for idx, name := range names {
+		parsed[idx] = modules[name]
+	}
+
+	return parsed, data, nil
+}
+
+// prepare returns the given query prepared for the currently loaded policy. Queries are shared,
+// so that each query is prepared only once on reload, regardless of how many authorizers use it.
+func (p *regoPolicy) prepare(ctx context.Context, query string) (*regoQuery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if existing, present := p.queries[query]; present {
+		return existing, nil
+	}
+
+	prepared, err := prepareRegoQuery(ctx, query, p.modules, p.data)
+	if err != nil {
+		return nil, err
+	}
+
+	rq := &regoQuery{query: query}
+	rq.prepared.Store(&prepared)
+	p.queries[query] = rq
+
+	return rq, nil
+}
+
+func prepareRegoQuery(
+	ctx context.Context, query string, modules []*ast.Module, data map[string]any,
+) (rego.PreparedEvalQuery, error) {
+	options := []func(*rego.Rego){
+		rego.Query(query),
+		rego.Store(inmem.NewFromObject(data)),
+	}
+
+	for _, module := range modules {
+		options = append(options, rego.ParsedModule(module))
+	}
+
+	return rego.New(options...).PrepareForEval(ctx)
+}
+
+func mergeRegoDocuments(dst, src map[string]any) error {
+	for key, value := range src {
+		existing, present := dst[key]
+		if !present {
+			dst[key] = value
+
+			continue
+		}
+
+		existingObj, ok1 := existing.(map[string]any)
+		valueObj, ok2 := value.(map[string]any)
+
+		if !ok1 || !ok2 {
+			return fmt.Errorf("%w: %s", errConflictingPolicy, key)
+		}
+
+		if err := mergeRegoDocuments(existingObj, valueObj); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// watchedDirectories returns the directories to watch for the given paths. For files, the
+// directory containing them is watched, as the files might be replaced, like it is the case
+// with kubernetes config maps mounted as volumes. Directories are watched recursively.
+func watchedDirectories(paths []string) []string {
+	seen := make(map[string]bool)
+
+	var dirs []string
+
+	add := func(dir string) {
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil || !info.IsDir() {
+			add(filepath.Dir(path))
+
+			continue
+		}
+
+		_ = filepath.WalkDir(path, func(path string, entry fs.DirEntry, err error) error {
+			if err == nil && entry.IsDir() {
+				add(path)
+			}
+
+			return nil
+		})
+	}
+
+	return dirs
+}
@@ -0,0 +1,289 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package authorizers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/dadrus/heimdall/internal/cache"
+	"github.com/dadrus/heimdall/internal/cache/encoding"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/rules/endpoint"
+	"github.com/dadrus/heimdall/internal/rules/mechanisms/subject"
+	"github.com/dadrus/heimdall/internal/rules/mechanisms/template"
+	"github.com/dadrus/heimdall/internal/x"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+	"github.com/dadrus/heimdall/internal/x/stringx"
+)
+
+// by intention. Used only during application bootstrap
+//
+//nolint:gochecknoinits
+func init() {
+	registerTypeFactory(
+		func(id string, typ string, conf map[string]any) (bool, Authorizer, error) {
+			if typ != AuthorizerRelationship {
+				return false, nil, nil
+			}
+
+			auth, err := newRelationshipAuthorizer(id, conf)
+
+			return true, auth, err
+		})
+
+	encoding.RegisterType[relationshipDecision]("relationship_authorizer.decision")
+}
+
+type relationshipDecision bool
+
+type RelationshipCheck struct {
+	Object   template.Template `mapstructure:"object"   validate:"required"`
+	Relation string            `mapstructure:"relation" validate:"required"`
+	User     template.Template `mapstructure:"user"     validate:"required"`
+}
+
+type relationshipAuthorizer struct {
+	id               string
+	e                endpoint.Endpoint
+	api              string
+	checker          relationshipChecker
+	checks           []RelationshipCheck
+	consistencyToken template.Template
+	ttl              time.Duration
+	hash             []byte
+}
+
+func newRelationshipAuthorizer(id string, rawConfig map[string]any) (*relationshipAuthorizer, error) {
+	type Config struct {
+		Endpoint             endpoint.Endpoint   `mapstructure:"endpoint"               validate:"required"`
+		API                  string              `mapstructure:"api"                    validate:"required,oneof=openfga spicedb"`                      //nolint:lll
+		StoreID              string              `mapstructure:"store_id"               validate:"required_if=API openfga,excluded_unless=API openfga"` //nolint:lll
+		AuthorizationModelID string              `mapstructure:"authorization_model_id" validate:"excluded_unless=API openfga"`                         //nolint:lll
+		Checks               []RelationshipCheck `mapstructure:"checks"                 validate:"required,gt=0,dive"`
+		ConsistencyToken     template.Template   `mapstructure:"consistency_token"`
+		CacheTTL             time.Duration       `mapstructure:"cache_ttl"`
+	}
+
+	var conf Config
+	if err := decodeConfig(AuthorizerRelationship, rawConfig, &conf); err != nil {
+		return nil, err
+	}
+
+	checker, err := newRelationshipChecker(conf.API, conf.Endpoint, conf.StoreID, conf.AuthorizationModelID)
+	if err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"failed to create relationship checker").CausedBy(err)
+	}
+
+	hash := sha256.New()
+	hash.Write(conf.Endpoint.Hash())
+	hash.Write(stringx.ToBytes(conf.API))
+	hash.Write(stringx.ToBytes(conf.StoreID))
+	hash.Write(stringx.ToBytes(conf.AuthorizationModelID))
+
+	return &relationshipAuthorizer{
+		id:               id,
+		e:                conf.Endpoint,
+		api:              conf.API,
+		checker:          checker,
+		checks:           conf.Checks,
+		consistencyToken: conf.ConsistencyToken,
+		ttl:              conf.CacheTTL,
+		hash:             hash.Sum(nil),
+	}, nil
+}
+
+func (a *relationshipAuthorizer) Execute(ctx heimdall.Context, sub *subject.Subject) error {
+	logger := zerolog.Ctx(ctx.AppContext())
+	logger.Debug().Str("_id", a.id).Msg("Authorizing using relationship authorizer")
+
+	if sub == nil {
+		return errorchain.NewWithMessage(heimdall.ErrInternal,
+			"failed to execute relationship authorizer due to 'nil' subject").
+			WithErrorContext(a)
+	}
+
+	tuples, token, err := a.renderTuples(ctx, sub)
+	if err != nil {
+		return err
+	}
+
+	cch := cache.Ctx(ctx.AppContext())
+	decisions := make([]bool, len(tuples))
+	keys := make([]string, len(tuples))
+	pending := make([]int, 0, len(tuples))
+
+	for idx, tuple := range tuples {
+		if a.ttl > 0 {
+			keys[idx] = a.calculateCacheKey(tuple, token)
+
+			if decision, ok := cch.Get(ctx.AppContext(), keys[idx]).(relationshipDecision); ok {
+				logger.Debug().Str("_tuple", tuple.String()).Msg("Reusing check decision from cache")
+
+				decisions[idx] = bool(decision)
+
+				continue
+			}
+		}
+
+		pending = append(pending, idx)
+	}
+
+	if len(pending) != 0 {
+		toCheck := make([]relationshipTuple, len(pending))
+		for idx, pos := range pending {
+			toCheck[idx] = tuples[pos]
+		}
+
+		checked, err := a.checker.check(ctx.AppContext(), toCheck, token)
+		if err != nil {
+			return errorchain.NewWithMessage(heimdall.ErrCommunication, "relationship check failed").
+				WithErrorContext(a).
+				CausedBy(err)
+		}
+
+		for idx, pos := range pending {
+			decisions[pos] = checked[idx]
+
+			if a.ttl > 0 {
+				cch.Set(ctx.AppContext(), keys[pos], relationshipDecision(checked[idx]), a.ttl)
+			}
+		}
+	}
+
+	for idx, allowed := range decisions {
+		if !allowed {
+			return errorchain.NewWithMessagef(heimdall.ErrAuthorization,
+				"relationship %s does not exist", tuples[idx]).
+				WithErrorContext(a)
+		}
+	}
+
+	return nil
+}
+
+func (a *relationshipAuthorizer) WithConfig(rawConfig map[string]any) (Authorizer, error) {
+	if len(rawConfig) == 0 {
+		return a, nil
+	}
+
+	type Config struct {
+		Checks           []RelationshipCheck `mapstructure:"checks"            validate:"dive"`
+		ConsistencyToken template.Template   `mapstructure:"consistency_token"`
+		CacheTTL         *time.Duration      `mapstructure:"cache_ttl"`
+	}
+
+	var conf Config
+	if err := decodeConfig(AuthorizerRelationship, rawConfig, &conf); err != nil {
+		return nil, err
+	}
+
+	ttl := a.ttl
+	if conf.CacheTTL != nil {
+		ttl = *conf.CacheTTL
+	}
+
+	return &relationshipAuthorizer{
+		id:               a.id,
+		e:                a.e,
+		api:              a.api,
+		checker:          a.checker,
+		checks:           x.IfThenElse(len(conf.Checks) != 0, conf.Checks, a.checks),
+		consistencyToken: x.IfThenElse(conf.ConsistencyToken != nil, conf.ConsistencyToken, a.consistencyToken),
+		ttl:              ttl,
+		hash:             a.hash,
+	}, nil
+}
+
+// Stop closes the connection to the authorization system, if its gRPC API is used.
+func (a *relationshipAuthorizer) Stop(_ context.Context) error {
+	if c, ok := a.checker.(interface{ close() error }); ok {
+		return c.close()
+	}
+
+	return nil
+}
+
+func (a *relationshipAuthorizer) ID() string { return a.id }
+
+func (a *relationshipAuthorizer) ContinueOnError() bool { return false }
+
+func (a *relationshipAuthorizer) renderTuples(
+	ctx heimdall.Context,
+	sub *subject.Subject,
+) ([]relationshipTuple, string, error) {
+	data := map[string]any{
+		"Request": ctx.Request(),
+		"Subject": sub,
+	}
+
+	tuples := make([]relationshipTuple, len(a.checks))
+
+	for idx, check := range a.checks {
+		object, err := check.Object.Render(data)
+		if err != nil {
+			return nil, "", errorchain.NewWithMessagef(heimdall.ErrInternal,
+				"failed to render object of check %d", idx+1).
+				WithErrorContext(a).
+				CausedBy(err)
+		}
+
+		user, err := check.User.Render(data)
+		if err != nil {
+			return nil, "", errorchain.NewWithMessagef(heimdall.ErrInternal,
+				"failed to render user of check %d", idx+1).
+				WithErrorContext(a).
+				CausedBy(err)
+		}
+
+		tuples[idx] = relationshipTuple{Object: object, Relation: check.Relation, User: user}
+	}
+
+	var token string
+
+	if a.consistencyToken != nil {
+		var err error
+
+		if token, err = a.consistencyToken.Render(data); err != nil {
+			return nil, "", errorchain.NewWithMessage(heimdall.ErrInternal,
+				"failed to render consistency token").
+				WithErrorContext(a).
+				CausedBy(err)
+		}
+	}
+
+	return tuples, token, nil
+}
+
+func (a *relationshipAuthorizer) calculateCacheKey(tuple relationshipTuple, token string) string {
+	hash := sha256.New()
+	hash.Write(a.hash)
+	hash.Write(stringx.ToBytes(a.id))
+	hash.Write(stringx.ToBytes(tuple.Object))
+	hash.Write(stringx.ToBytes("#"))
+	hash.Write(stringx.ToBytes(tuple.Relation))
+	hash.Write(stringx.ToBytes("@"))
+	hash.Write(stringx.ToBytes(tuple.User))
+	hash.Write(stringx.ToBytes(token))
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
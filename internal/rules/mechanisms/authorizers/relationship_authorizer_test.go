@@ -0,0 +1,756 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package authorizers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/cache"
+	"github.com/dadrus/heimdall/internal/cache/memory"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/heimdall/mocks"
+	"github.com/dadrus/heimdall/internal/rules/mechanisms/subject"
+	"github.com/dadrus/heimdall/internal/x/testsupport"
+)
+
+func TestCreateRelationshipAuthorizer(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc     string
+		config []byte
+		assert func(t *testing.T, err error, auth *relationshipAuthorizer)
+	}{
+		{
+			uc: "without configuration",
+			assert: func(t *testing.T, err error, _ *relationshipAuthorizer) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "'endpoint' is a required field")
+				assert.Contains(t, err.Error(), "'api' is a required field")
+				assert.Contains(t, err.Error(), "'checks' is a required field")
+			},
+		},
+		{
+			uc: "with unsupported api",
+			config: []byte(`
+endpoint:
+  url: http://foo.bar
+api: foo
+checks:
+  - object: "document:{{ .Request.URL.Captures.id }}"
+    relation: viewer
+    user: "user:{{ .Subject.ID }}"
+`),
+			assert: func(t *testing.T, err error, _ *relationshipAuthorizer) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "'api' must be one of")
+			},
+		},
+		{
+			uc: "with openfga api, but without store id",
+			config: []byte(`
+endpoint:
+  url: http://foo.bar
+api: openfga
+checks:
+  - object: "document:{{ .Request.URL.Captures.id }}"
+    relation: viewer
+    user: "user:{{ .Subject.ID }}"
+`),
+			assert: func(t *testing.T, err error, _ *relationshipAuthorizer) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "'store_id' is a required field")
+			},
+		},
+		{
+			uc: "with openfga api and consistency token",
+			config: []byte(`
+endpoint:
+  url: http://foo.bar
+api: openfga
+store_id: foo
+consistency_token: bar
+checks:
+  - object: "document:{{ .Request.URL.Captures.id }}"
+    relation: viewer
+    user: "user:{{ .Subject.ID }}"
+`),
+			assert: func(t *testing.T, err error, auth *relationshipAuthorizer) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.NotNil(t, auth.consistencyToken)
+			},
+		},
+		{
+			uc: "with spicedb api and store id",
+			config: []byte(`
+endpoint:
+  url: http://foo.bar
+api: spicedb
+store_id: foo
+checks:
+  - object: "document:{{ .Request.URL.Captures.id }}"
+    relation: viewer
+    user: "user:{{ .Subject.ID }}"
+`),
+			assert: func(t *testing.T, err error, _ *relationshipAuthorizer) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "'store_id' is an excluded field")
+			},
+		},
+		{
+			uc: "with check without relation",
+			config: []byte(`
+endpoint:
+  url: http://foo.bar
+api: spicedb
+checks:
+  - object: "document:{{ .Request.URL.Captures.id }}"
+    user: "user:{{ .Subject.ID }}"
+`),
+			assert: func(t *testing.T, err error, _ *relationshipAuthorizer) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "'relation' is a required field")
+			},
+		},
+		{
+			uc: "with valid openfga configuration",
+			config: []byte(`
+endpoint:
+  url: http://foo.bar
+api: openfga
+store_id: foo
+authorization_model_id: bar
+cache_ttl: 1m
+checks:
+  - object: "document:{{ .Request.URL.Captures.id }}"
+    relation: viewer
+    user: "user:{{ .Subject.ID }}"
+`),
+			assert: func(t *testing.T, err error, auth *relationshipAuthorizer) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, auth)
+				assert.Equal(t, "foo", auth.ID())
+				assert.Equal(t, relationshipAPIOpenFGA, auth.api)
+				assert.Len(t, auth.checks, 1)
+				assert.Nil(t, auth.consistencyToken)
+				assert.Equal(t, time.Minute, auth.ttl)
+				assert.NotEmpty(t, auth.hash)
+				assert.False(t, auth.ContinueOnError())
+
+				checker, ok := auth.checker.(*openFGAChecker)
+				require.True(t, ok)
+				assert.Equal(t, "foo", checker.storeID)
+				assert.Equal(t, "bar", checker.modelID)
+			},
+		},
+		{
+			uc: "with valid spicedb configuration",
+			config: []byte(`
+endpoint:
+  url: http://foo.bar
+api: spicedb
+consistency_token: '{{ .Request.Header "X-Zed-Token" }}'
+checks:
+  - object: "document:{{ .Request.URL.Captures.id }}"
+    relation: view
+    user: "user:{{ .Subject.ID }}"
+  - object: "folder:{{ .Request.URL.Captures.folder }}"
+    relation: view
+    user: "user:{{ .Subject.ID }}"
+`),
+			assert: func(t *testing.T, err error, auth *relationshipAuthorizer) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, auth)
+				assert.Equal(t, relationshipAPISpiceDB, auth.api)
+				assert.Len(t, auth.checks, 2)
+				assert.NotNil(t, auth.consistencyToken)
+				assert.Zero(t, auth.ttl)
+				assert.IsType(t, &spiceDBChecker{}, auth.checker)
+			},
+		},
+		{
+			uc: "with valid configuration using the grpc api",
+			config: []byte(`
+endpoint:
+  url: grpcs://foo.bar:50051
+api: openfga
+store_id: foo
+checks:
+  - object: "document:{{ .Request.URL.Captures.id }}"
+    relation: viewer
+    user: "user:{{ .Subject.ID }}"
+`),
+			assert: func(t *testing.T, err error, auth *relationshipAuthorizer) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, auth)
+
+				checker, ok := auth.checker.(*openFGAGRPCChecker)
+				require.True(t, ok)
+				assert.Equal(t, "foo", checker.storeID)
+				assert.Equal(t, "foo.bar:50051", checker.conn.Target())
+				require.NoError(t, auth.Stop(context.Background()))
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			conf, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			// WHEN
+			auth, err := newRelationshipAuthorizer("foo", conf)
+
+			// THEN
+			tc.assert(t, err, auth)
+		})
+	}
+}
+
+func TestCreateRelationshipAuthorizerFromPrototype(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc              string
+		prototypeConfig []byte
+		config          []byte
+		assert          func(t *testing.T, err error, prototype *relationshipAuthorizer,
+			configured *relationshipAuthorizer)
+	}{
+		{
+			uc: "without new configuration",
+			prototypeConfig: []byte(`
+endpoint:
+  url: http://foo.bar
+api: openfga
+store_id: foo
+checks:
+  - object: "document:{{ .Request.URL.Captures.id }}"
+    relation: viewer
+    user: "user:{{ .Subject.ID }}"
+`),
+			assert: func(t *testing.T, err error, prototype *relationshipAuthorizer,
+				configured *relationshipAuthorizer,
+			) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, prototype, configured)
+			},
+		},
+		{
+			uc: "with not overridable property",
+			prototypeConfig: []byte(`
+endpoint:
+  url: http://foo.bar
+api: openfga
+store_id: foo
+checks:
+  - object: "document:{{ .Request.URL.Captures.id }}"
+    relation: viewer
+    user: "user:{{ .Subject.ID }}"
+`),
+			config: []byte(`store_id: bar`),
+			assert: func(t *testing.T, err error, _ *relationshipAuthorizer, _ *relationshipAuthorizer) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "invalid keys")
+			},
+		},
+		{
+			uc: "with consistency token for openfga",
+			prototypeConfig: []byte(`
+endpoint:
+  url: http://foo.bar
+api: openfga
+store_id: foo
+checks:
+  - object: "document:{{ .Request.URL.Captures.id }}"
+    relation: viewer
+    user: "user:{{ .Subject.ID }}"
+`),
+			config: []byte(`consistency_token: foo`),
+			assert: func(t *testing.T, err error, prototype *relationshipAuthorizer,
+				configured *relationshipAuthorizer,
+			) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Nil(t, prototype.consistencyToken)
+				assert.NotNil(t, configured.consistencyToken)
+			},
+		},
+		{
+			uc: "with overridden checks, consistency token and disabled cache",
+			prototypeConfig: []byte(`
+endpoint:
+  url: http://foo.bar
+api: spicedb
+cache_ttl: 1m
+checks:
+  - object: "document:{{ .Request.URL.Captures.id }}"
+    relation: view
+    user: "user:{{ .Subject.ID }}"
+`),
+			config: []byte(`
+consistency_token: foo
+cache_ttl: 0s
+checks:
+  - object: "document:{{ .Request.URL.Captures.id }}"
+    relation: edit
+    user: "user:{{ .Subject.ID }}"
+`),
+			assert: func(t *testing.T, err error, prototype *relationshipAuthorizer,
+				configured *relationshipAuthorizer,
+			) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.NotEqual(t, prototype, configured)
+				assert.Equal(t, prototype.id, configured.id)
+				assert.Equal(t, prototype.checker, configured.checker)
+				assert.Equal(t, prototype.hash, configured.hash)
+				assert.Equal(t, time.Minute, prototype.ttl)
+				assert.Zero(t, configured.ttl)
+				assert.Nil(t, prototype.consistencyToken)
+				assert.NotNil(t, configured.consistencyToken)
+				require.Len(t, configured.checks, 1)
+				assert.Equal(t, "edit", configured.checks[0].Relation)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			pc, err := testsupport.DecodeTestConfig(tc.prototypeConfig)
+			require.NoError(t, err)
+
+			conf, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			prototype, err := newRelationshipAuthorizer("foo", pc)
+			require.NoError(t, err)
+
+			// WHEN
+			auth, err := prototype.WithConfig(conf)
+
+			// THEN
+			var (
+				relAuth *relationshipAuthorizer
+				ok      bool
+			)
+
+			if err == nil {
+				relAuth, ok = auth.(*relationshipAuthorizer)
+				require.True(t, ok)
+			}
+
+			tc.assert(t, err, prototype, relAuth)
+		})
+	}
+}
+
+func TestRelationshipAuthorizerExecute(t *testing.T) {
+	t.Parallel()
+
+	var (
+		requests     int
+		path         string
+		body         map[string]any
+		responseCode int
+		response     any
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		path = r.URL.Path
+
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		body = nil
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(responseCode)
+
+		assert.NoError(t, json.NewEncoder(w).Encode(response))
+	}))
+
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		uc           string
+		config       []byte
+		sub          *subject.Subject
+		withCache    bool
+		responseCode int
+		response     any
+		assert       func(t *testing.T, err error)
+	}{
+		{
+			uc: "with nil subject",
+			config: []byte(`
+api: openfga
+store_id: foo
+checks:
+  - object: "document:{{ .Request.URL.Captures.id }}"
+    relation: viewer
+    user: "user:{{ .Subject.ID }}"
+`),
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrInternal)
+				assert.Contains(t, err.Error(), "'nil' subject")
+				assert.Equal(t, 0, requests)
+			},
+		},
+		{
+			uc: "with failing template rendering",
+			config: []byte(`
+api: openfga
+store_id: foo
+checks:
+  - object: "document:{{ .Subject.Foo }}"
+    relation: viewer
+    user: "user:{{ .Subject.ID }}"
+`),
+			sub: &subject.Subject{ID: "alice"},
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrInternal)
+				assert.Contains(t, err.Error(), "failed to render object of check 1")
+				assert.Equal(t, 0, requests)
+			},
+		},
+		{
+			uc: "with openfga single check allowing the request",
+			config: []byte(`
+api: openfga
+store_id: foo
+authorization_model_id: bar
+checks:
+  - object: "document:{{ .Request.URL.Captures.id }}"
+    relation: viewer
+    user: "user:{{ .Subject.ID }}"
+`),
+			sub:          &subject.Subject{ID: "alice"},
+			responseCode: http.StatusOK,
+			response:     map[string]any{"allowed": true},
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, 1, requests)
+				assert.Equal(t, "/stores/foo/check", path)
+				assert.Equal(t, map[string]any{
+					"tuple_key": map[string]any{
+						"object":   "document:1",
+						"relation": "viewer",
+						"user":     "user:alice",
+					},
+					"authorization_model_id": "bar",
+				}, body)
+			},
+		},
+		{
+			uc: "with openfga single check using consistency token",
+			config: []byte(`
+api: openfga
+store_id: foo
+consistency_token: '{{ .Request.Header "X-Zed-Token" }}'
+checks:
+  - object: "document:{{ .Request.URL.Captures.id }}"
+    relation: viewer
+    user: "user:{{ .Subject.ID }}"
+`),
+			sub:          &subject.Subject{ID: "alice"},
+			responseCode: http.StatusOK,
+			response:     map[string]any{"allowed": true},
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, 1, requests)
+				assert.Equal(t, "HIGHER_CONSISTENCY", body["consistency"])
+			},
+		},
+		{
+			uc: "with openfga batch check denying the request",
+			config: []byte(`
+api: openfga
+store_id: foo
+checks:
+  - object: "document:{{ .Request.URL.Captures.id }}"
+    relation: viewer
+    user: "user:{{ .Subject.ID }}"
+  - object: "folder:{{ .Request.URL.Captures.folder }}"
+    relation: viewer
+    user: "user:{{ .Subject.ID }}"
+`),
+			sub:          &subject.Subject{ID: "alice"},
+			responseCode: http.StatusOK,
+			response: map[string]any{"result": map[string]any{
+				"0": map[string]any{"allowed": true},
+				"1": map[string]any{"allowed": false},
+			}},
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrAuthorization)
+				assert.Contains(t, err.Error(), "folder:2#viewer@user:alice")
+				assert.Equal(t, 1, requests)
+				assert.Equal(t, "/stores/foo/batch-check", path)
+				assert.Len(t, body["checks"], 2)
+				assert.NotContains(t, body, "consistency")
+			},
+		},
+		{
+			uc: "with openfga batch check result containing an error",
+			config: []byte(`
+api: openfga
+store_id: foo
+checks:
+  - object: "document:{{ .Request.URL.Captures.id }}"
+    relation: viewer
+    user: "user:{{ .Subject.ID }}"
+  - object: "folder:{{ .Request.URL.Captures.folder }}"
+    relation: viewer
+    user: "user:{{ .Subject.ID }}"
+`),
+			sub:          &subject.Subject{ID: "alice"},
+			responseCode: http.StatusOK,
+			response: map[string]any{"result": map[string]any{
+				"0": map[string]any{"allowed": true},
+				"1": map[string]any{"error": map[string]any{"message": "type not found"}},
+			}},
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrCommunication)
+				assert.Contains(t, err.Error(), "type not found")
+			},
+		},
+		{
+			uc: "with spicedb single check using consistency token",
+			config: []byte(`
+api: spicedb
+consistency_token: '{{ .Request.Header "X-Zed-Token" }}'
+checks:
+  - object: "document:{{ .Request.URL.Captures.id }}"
+    relation: view
+    user: "group:{{ .Subject.ID }}#member"
+`),
+			sub:          &subject.Subject{ID: "admins"},
+			responseCode: http.StatusOK,
+			response:     map[string]any{"permissionship": spiceDBHasPermission},
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "/v1/permissions/check", path)
+				assert.Equal(t, map[string]any{
+					"consistency": map[string]any{"atLeastAsFresh": map[string]any{"token": "zed-token"}},
+					"resource":    map[string]any{"objectType": "document", "objectId": "1"},
+					"permission":  "view",
+					"subject": map[string]any{
+						"object":           map[string]any{"objectType": "group", "objectId": "admins"},
+						"optionalRelation": "member",
+					},
+				}, body)
+			},
+		},
+		{
+			uc: "with spicedb bulk check allowing the request",
+			config: []byte(`
+api: spicedb
+checks:
+  - object: "document:{{ .Request.URL.Captures.id }}"
+    relation: view
+    user: "user:{{ .Subject.ID }}"
+  - object: "folder:{{ .Request.URL.Captures.folder }}"
+    relation: view
+    user: "user:{{ .Subject.ID }}"
+`),
+			sub:          &subject.Subject{ID: "alice"},
+			responseCode: http.StatusOK,
+			response: map[string]any{"pairs": []any{
+				map[string]any{"item": map[string]any{"permissionship": spiceDBHasPermission}},
+				map[string]any{"item": map[string]any{"permissionship": spiceDBHasPermission}},
+			}},
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "/v1/permissions/checkbulk", path)
+				assert.Equal(t, map[string]any{"minimizeLatency": true}, body["consistency"])
+				assert.Len(t, body["items"], 2)
+			},
+		},
+		{
+			uc: "with spicedb and malformed object reference",
+			config: []byte(`
+api: spicedb
+checks:
+  - object: "{{ .Request.URL.Captures.id }}"
+    relation: view
+    user: "user:{{ .Subject.ID }}"
+`),
+			sub: &subject.Subject{ID: "alice"},
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, errMalformedObjectReference)
+				assert.Equal(t, 0, requests)
+			},
+		},
+		{
+			uc: "with error response from the check endpoint",
+			config: []byte(`
+api: spicedb
+checks:
+  - object: "document:{{ .Request.URL.Captures.id }}"
+    relation: view
+    user: "user:{{ .Subject.ID }}"
+`),
+			sub:          &subject.Subject{ID: "alice"},
+			responseCode: http.StatusBadRequest,
+			response:     map[string]any{"message": "bad request"},
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrCommunication)
+				assert.Contains(t, err.Error(), "relationship check failed")
+			},
+		},
+		{
+			uc: "with cached decisions",
+			config: []byte(`
+api: openfga
+store_id: foo
+cache_ttl: 1m
+checks:
+  - object: "document:{{ .Request.URL.Captures.id }}"
+    relation: viewer
+    user: "user:{{ .Subject.ID }}"
+`),
+			sub:          &subject.Subject{ID: "alice"},
+			withCache:    true,
+			responseCode: http.StatusOK,
+			response:     map[string]any{"allowed": true},
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.NoError(t, err)
+				// second execution is served from cache
+				assert.Equal(t, 1, requests)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			requests = 0
+			path = ""
+			body = nil
+			responseCode = tc.responseCode
+			response = tc.response
+
+			conf, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			conf["endpoint"] = map[string]any{"url": srv.URL}
+
+			auth, err := newRelationshipAuthorizer("foo", conf)
+			require.NoError(t, err)
+
+			appCtx := context.Background()
+			if tc.withCache {
+				appCtx = cache.WithContext(appCtx, memory.New())
+			}
+
+			reqf := mocks.NewRequestFunctionsMock(t)
+			reqf.EXPECT().Header("X-Zed-Token").Return("zed-token").Maybe()
+
+			ctx := mocks.NewContextMock(t)
+			ctx.EXPECT().AppContext().Return(appCtx)
+			ctx.EXPECT().Request().Return(&heimdall.Request{
+				RequestFunctions: reqf,
+				Method:           http.MethodGet,
+				URL: &heimdall.URL{
+					URL:      url.URL{Scheme: "http", Host: "localhost", Path: "/folders/2/documents/1"},
+					Captures: map[string]string{"id": "1", "folder": "2"},
+				},
+			}).Maybe()
+
+			// WHEN
+			err = auth.Execute(ctx, tc.sub)
+			if err == nil && tc.withCache {
+				err = auth.Execute(ctx, tc.sub)
+			}
+
+			// THEN
+			tc.assert(t, err)
+		})
+	}
+}
+
+func TestRelationshipTupleString(t *testing.T) {
+	t.Parallel()
+
+	tuple := relationshipTuple{Object: "document:1", Relation: "viewer", User: "user:alice"}
+
+	assert.Equal(t, "document:1#viewer@user:alice", fmt.Sprint(tuple))
+}
@@ -0,0 +1,366 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package authorizers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/rules/endpoint"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+const (
+	relationshipAPIOpenFGA = "openfga"
+	relationshipAPISpiceDB = "spicedb"
+
+	spiceDBHasPermission = "PERMISSIONSHIP_HAS_PERMISSION"
+
+	openFGAHigherConsistency = "HIGHER_CONSISTENCY"
+)
+
+var (
+	errMalformedObjectReference = errors.New("malformed object reference")
+	errMalformedCheckResponse   = errors.New("malformed check response")
+)
+
+type relationshipTuple struct {
+	Object   string
+	Relation string
+	User     string
+}
+
+func (t relationshipTuple) String() string { return t.Object + "#" + t.Relation + "@" + t.User }
+
+// relationshipChecker implements the check API of a particular relationship based access control system.
+// The returned slice holds the decisions in the order of the given tuples.
+type relationshipChecker interface {
+	check(ctx context.Context, tuples []relationshipTuple, consistencyToken string) ([]bool, error)
+}
+
+// newRelationshipChecker creates a checker for the given api. The gRPC API is used if the scheme of
+// the endpoint URL is either grpc or grpcs. Otherwise, the HTTP API is used.
+func newRelationshipChecker(api string, ep endpoint.Endpoint, storeID, modelID string) (relationshipChecker, error) {
+	if !isGRPCEndpoint(ep) {
+		if api == relationshipAPIOpenFGA {
+			return &openFGAChecker{e: ep, storeID: storeID, modelID: modelID}, nil
+		}
+
+		return &spiceDBChecker{e: ep}, nil
+	}
+
+	client, err := newGRPCCheckClient(ep)
+	if err != nil {
+		return nil, err
+	}
+
+	if api == relationshipAPIOpenFGA {
+		return &openFGAGRPCChecker{grpcCheckClient: client, storeID: storeID, modelID: modelID}, nil
+	}
+
+	return &spiceDBGRPCChecker{grpcCheckClient: client}, nil
+}
+
+func sendCheckRequest(ctx context.Context, ep endpoint.Endpoint, path string, request, response any) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return errorchain.NewWithMessage(heimdall.ErrInternal, "failed to marshal check request").
+			CausedBy(err)
+	}
+
+	ep.URL = strings.TrimSuffix(ep.URL, "/") + path
+	ep.Method = http.MethodPost
+	ep.Headers = maps.Clone(ep.Headers)
+
+	if ep.Headers == nil {
+		ep.Headers = make(map[string]string)
+	}
+
+	ep.Headers["Content-Type"] = "application/json"
+	ep.Headers["Accept"] = "application/json"
+
+	rawResp, err := ep.SendRequest(ctx, bytes.NewReader(body), nil)
+	if err != nil {
+		return err
+	}
+
+	if err = json.Unmarshal(rawResp, response); err != nil {
+		return errorchain.NewWithMessage(heimdall.ErrInternal, "failed to unmarshal check response").
+			CausedBy(err)
+	}
+
+	return nil
+}
+
+type openFGAChecker struct {
+	e       endpoint.Endpoint
+	storeID string
+	modelID string
+}
+
+type openFGATupleKey struct {
+	User     string `json:"user"`
+	Relation string `json:"relation"`
+	Object   string `json:"object"`
+}
+
+type openFGACheckRequest struct {
+	TupleKey             openFGATupleKey `json:"tuple_key"`
+	AuthorizationModelID string          `json:"authorization_model_id,omitempty"`
+	Consistency          string          `json:"consistency,omitempty"`
+}
+
+type openFGACheckResponse struct {
+	Allowed bool `json:"allowed"`
+}
+
+type openFGABatchCheckItem struct {
+	TupleKey      openFGATupleKey `json:"tuple_key"`
+	CorrelationID string          `json:"correlation_id"`
+}
+
+type openFGABatchCheckRequest struct {
+	Checks               []openFGABatchCheckItem `json:"checks"`
+	AuthorizationModelID string                  `json:"authorization_model_id,omitempty"`
+	Consistency          string                  `json:"consistency,omitempty"`
+}
+
+type openFGABatchCheckResponse struct {
+	Result map[string]struct {
+		Allowed bool `json:"allowed"`
+		Error   *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	} `json:"result"`
+}
+
+// check uses the given consistency token only to decide about the consistency preference, as OpenFGA
+// does not support consistency tokens. If it is present, results with higher consistency are requested.
+// Otherwise, the default of OpenFGA applies.
+func (c *openFGAChecker) check(
+	ctx context.Context,
+	tuples []relationshipTuple,
+	consistencyToken string,
+) ([]bool, error) {
+	toKey := func(tuple relationshipTuple) openFGATupleKey {
+		return openFGATupleKey{User: tuple.User, Relation: tuple.Relation, Object: tuple.Object}
+	}
+
+	var consistency string
+	if len(consistencyToken) != 0 {
+		consistency = openFGAHigherConsistency
+	}
+
+	if len(tuples) == 1 {
+		var resp openFGACheckResponse
+
+		if err := sendCheckRequest(ctx, c.e, "/stores/"+c.storeID+"/check",
+			openFGACheckRequest{
+				TupleKey:             toKey(tuples[0]),
+				AuthorizationModelID: c.modelID,
+				Consistency:          consistency,
+			},
+			&resp,
+		); err != nil {
+			return nil, err
+		}
+
+		return []bool{resp.Allowed}, nil
+	}
+
+	req := openFGABatchCheckRequest{
+		Checks:               make([]openFGABatchCheckItem, len(tuples)),
+		AuthorizationModelID: c.modelID,
+		Consistency:          consistency,
+	}
+
+	for idx, tuple := range tuples {
+		req.Checks[idx] = openFGABatchCheckItem{TupleKey: toKey(tuple), CorrelationID: strconv.Itoa(idx)}
+	}
+
+	var resp openFGABatchCheckResponse
+
+	if err := sendCheckRequest(ctx, c.e, "/stores/"+c.storeID+"/batch-check", req, &resp); err != nil {
+		return nil, err
+	}
+
+	decisions := make([]bool, len(tuples))
+
+	for idx, tuple := range tuples {
+		result, present := resp.Result[strconv.Itoa(idx)]
+		if !present {
+			return nil, fmt.Errorf("%w: no result for %s", errMalformedCheckResponse, tuple)
+		}
+
+		if result.Error != nil {
+			return nil, fmt.Errorf("%w: check of %s failed: %s",
+				errMalformedCheckResponse, tuple, result.Error.Message)
+		}
+
+		decisions[idx] = result.Allowed
+	}
+
+	return decisions, nil
+}
+
+type spiceDBChecker struct {
+	e endpoint.Endpoint
+}
+
+type spiceDBObjectReference struct {
+	ObjectType string `json:"objectType"`
+	ObjectID   string `json:"objectId"`
+}
+
+type spiceDBSubjectReference struct {
+	Object           spiceDBObjectReference `json:"object"`
+	OptionalRelation string                 `json:"optionalRelation,omitempty"`
+}
+
+type spiceDBCheckItem struct {
+	Resource   spiceDBObjectReference  `json:"resource"`
+	Permission string                  `json:"permission"`
+	Subject    spiceDBSubjectReference `json:"subject"`
+}
+
+type spiceDBCheckRequest struct {
+	spiceDBCheckItem
+
+	Consistency map[string]any `json:"consistency"`
+}
+
+type spiceDBCheckResponse struct {
+	Permissionship string `json:"permissionship"`
+}
+
+type spiceDBBulkCheckRequest struct {
+	Consistency map[string]any     `json:"consistency"`
+	Items       []spiceDBCheckItem `json:"items"`
+}
+
+type spiceDBBulkCheckResponse struct {
+	Pairs []struct {
+		Item *struct {
+			Permissionship string `json:"permissionship"`
+		} `json:"item"`
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	} `json:"pairs"`
+}
+
+func (c *spiceDBChecker) check(
+	ctx context.Context,
+	tuples []relationshipTuple,
+	consistencyToken string,
+) ([]bool, error) {
+	consistency := map[string]any{"minimizeLatency": true}
+	if len(consistencyToken) != 0 {
+		consistency = map[string]any{"atLeastAsFresh": map[string]any{"token": consistencyToken}}
+	}
+
+	items := make([]spiceDBCheckItem, len(tuples))
+
+	for idx, tuple := range tuples {
+		item, err := newSpiceDBCheckItem(tuple)
+		if err != nil {
+			return nil, err
+		}
+
+		items[idx] = item
+	}
+
+	if len(items) == 1 {
+		var resp spiceDBCheckResponse
+
+		if err := sendCheckRequest(ctx, c.e, "/v1/permissions/check",
+			spiceDBCheckRequest{spiceDBCheckItem: items[0], Consistency: consistency},
+			&resp,
+		); err != nil {
+			return nil, err
+		}
+
+		return []bool{resp.Permissionship == spiceDBHasPermission}, nil
+	}
+
+	var resp spiceDBBulkCheckResponse
+
+	if err := sendCheckRequest(ctx, c.e, "/v1/permissions/checkbulk",
+		spiceDBBulkCheckRequest{Consistency: consistency, Items: items},
+		&resp,
+	); err != nil {
+		return nil, err
+	}
+
+	if len(resp.Pairs) != len(tuples) {
+		return nil, fmt.Errorf("%w: expected %d results, got %d",
+			errMalformedCheckResponse, len(tuples), len(resp.Pairs))
+	}
+
+	decisions := make([]bool, len(tuples))
+
+	for idx, pair := range resp.Pairs {
+		switch {
+		case pair.Error != nil:
+			return nil, fmt.Errorf("%w: check of %s failed: %s",
+				errMalformedCheckResponse, tuples[idx], pair.Error.Message)
+		case pair.Item == nil:
+			return nil, fmt.Errorf("%w: no result for %s", errMalformedCheckResponse, tuples[idx])
+		default:
+			decisions[idx] = pair.Item.Permissionship == spiceDBHasPermission
+		}
+	}
+
+	return decisions, nil
+}
+
+func newSpiceDBCheckItem(tuple relationshipTuple) (spiceDBCheckItem, error) {
+	resource, err := parseSpiceDBObjectReference(tuple.Object)
+	if err != nil {
+		return spiceDBCheckItem{}, err
+	}
+
+	user, relation, _ := strings.Cut(tuple.User, "#")
+
+	subject, err := parseSpiceDBObjectReference(user)
+	if err != nil {
+		return spiceDBCheckItem{}, err
+	}
+
+	return spiceDBCheckItem{
+		Resource:   resource,
+		Permission: tuple.Relation,
+		Subject:    spiceDBSubjectReference{Object: subject, OptionalRelation: relation},
+	}, nil
+}
+
+func parseSpiceDBObjectReference(value string) (spiceDBObjectReference, error) {
+	objectType, objectID, found := strings.Cut(value, ":")
+	if !found || len(objectType) == 0 || len(objectID) == 0 {
+		return spiceDBObjectReference{}, fmt.Errorf("%w: %s", errMalformedObjectReference, value)
+	}
+
+	return spiceDBObjectReference{ObjectType: objectType, ObjectID: objectID}, nil
+}
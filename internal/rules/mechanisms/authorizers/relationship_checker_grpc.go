@@ -0,0 +1,526 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package authorizers
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/rules/endpoint"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+// The messages of the gRPC APIs are encoded by hand to not depend on the SDKs of the authorization
+// systems. Only the fields used by heimdall are encoded and decoded. The field numbers are taken from
+// https://github.com/openfga/api/blob/main/openfga/v1/openfga_service.proto and
+// https://github.com/authzed/api/blob/main/authzed/api/v1/permission_service.proto.
+const (
+	grpcScheme       = "grpc"
+	grpcSecureScheme = "grpcs"
+
+	openFGACheckMethod      = "/openfga.v1.OpenFGAService/Check"
+	openFGABatchCheckMethod = "/openfga.v1.OpenFGAService/BatchCheck"
+	spiceDBCheckMethod      = "/authzed.api.v1.PermissionsService/CheckPermission"
+	spiceDBBulkCheckMethod  = "/authzed.api.v1.PermissionsService/CheckBulkPermissions"
+
+	// values of the openfga.v1.ConsistencyPreference and authzed.api.v1.CheckPermissionResponse.Permissionship enums
+	openFGAHigherConsistencyValue = 2
+	spiceDBHasPermissionValue     = 2
+
+	protoTrue uint64 = 1
+)
+
+var errUnexpectedMessageType = errors.New("unexpected message type")
+
+func isGRPCEndpoint(ep endpoint.Endpoint) bool {
+	endpointURL, err := url.Parse(ep.URL)
+
+	return err == nil && (endpointURL.Scheme == grpcScheme || endpointURL.Scheme == grpcSecureScheme)
+}
+
+// rawCodec passes the already encoded messages through.
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v any) ([]byte, error) {
+	msg, ok := v.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T", errUnexpectedMessageType, v)
+	}
+
+	return msg, nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v any) error {
+	msg, ok := v.(*[]byte)
+	if !ok {
+		return fmt.Errorf("%w: %T", errUnexpectedMessageType, v)
+	}
+
+	*msg = append((*msg)[:0], data...)
+
+	return nil
+}
+
+func (rawCodec) Name() string { return "proto" }
+
+type grpcCheckClient struct {
+	e    endpoint.Endpoint
+	conn *grpc.ClientConn
+}
+
+func newGRPCCheckClient(ep endpoint.Endpoint) (*grpcCheckClient, error) {
+	endpointURL, err := url.Parse(ep.URL)
+	if err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration, "failed to parse endpoint url").
+			CausedBy(err)
+	}
+
+	creds := insecure.NewCredentials()
+	if endpointURL.Scheme == grpcSecureScheme {
+		creds = credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+	}
+
+	conn, err := grpc.Dial(endpointURL.Host,
+		grpc.WithTransportCredentials(creds),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+		grpc.WithDefaultCallOptions(grpc.ForceCodec(rawCodec{})),
+	)
+	if err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration, "failed to create grpc client").
+			CausedBy(err)
+	}
+
+	return &grpcCheckClient{e: ep, conn: conn}, nil
+}
+
+func (c *grpcCheckClient) invoke(ctx context.Context, method string, request []byte) ([]byte, error) {
+	// the headers, including the ones set by the authentication strategy, are sent as metadata
+	req, err := c.e.CreateRequest(ctx, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	md := metadata.MD{}
+	for name, values := range req.Header {
+		md.Append(name, values...)
+	}
+
+	var response []byte
+
+	if err = c.conn.Invoke(metadata.NewOutgoingContext(ctx, md), method, request, &response); err != nil {
+		if status.Code(err) == codes.DeadlineExceeded {
+			return nil, errorchain.New(heimdall.ErrCommunicationTimeout).CausedBy(err)
+		}
+
+		return nil, errorchain.New(heimdall.ErrCommunication).CausedBy(err)
+	}
+
+	return response, nil
+}
+
+func (c *grpcCheckClient) close() error { return c.conn.Close() }
+
+type openFGAGRPCChecker struct {
+	*grpcCheckClient
+
+	storeID string
+	modelID string
+}
+
+func (c *openFGAGRPCChecker) check(
+	ctx context.Context,
+	tuples []relationshipTuple,
+	consistencyToken string,
+) ([]bool, error) {
+	tupleKey := func(tuple relationshipTuple) []byte {
+		key := appendProtoString(nil, 1, tuple.User)
+		key = appendProtoString(key, 2, tuple.Relation)
+
+		return appendProtoString(key, 3, tuple.Object)
+	}
+
+	var consistency uint64
+	if len(consistencyToken) != 0 {
+		consistency = openFGAHigherConsistencyValue
+	}
+
+	if len(tuples) == 1 {
+		req := appendProtoString(nil, 1, c.storeID)
+		req = appendProtoMessage(req, 2, tupleKey(tuples[0]))
+		req = appendProtoString(req, 4, c.modelID)
+		req = appendProtoVarint(req, 7, consistency)
+
+		resp, err := c.invoke(ctx, openFGACheckMethod, req)
+		if err != nil {
+			return nil, err
+		}
+
+		var allowed bool
+
+		err = rangeProtoFields(resp, func(num protowire.Number, varint uint64, _ []byte) error {
+			if num == 1 {
+				allowed = varint == protoTrue
+			}
+
+			return nil
+		})
+
+		return []bool{allowed}, err
+	}
+
+	req := appendProtoString(nil, 1, c.storeID)
+	for idx, tuple := range tuples {
+		item := appendProtoMessage(nil, 1, tupleKey(tuple))
+		item = appendProtoString(item, 4, strconv.Itoa(idx))
+
+		req = appendProtoMessage(req, 2, item)
+	}
+
+	req = appendProtoString(req, 3, c.modelID)
+	req = appendProtoVarint(req, 4, consistency)
+
+	resp, err := c.invoke(ctx, openFGABatchCheckMethod, req)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := decodeOpenFGABatchCheckResults(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	decisions := make([]bool, len(tuples))
+
+	for idx, tuple := range tuples {
+		result, present := results[strconv.Itoa(idx)]
+		if !present {
+			return nil, fmt.Errorf("%w: no result for %s", errMalformedCheckResponse, tuple)
+		}
+
+		if result.err != nil {
+			return nil, fmt.Errorf("%w: check of %s failed: %s", errMalformedCheckResponse, tuple, *result.err)
+		}
+
+		decisions[idx] = result.allowed
+	}
+
+	return decisions, nil
+}
+
+type grpcCheckResult struct {
+	allowed bool
+	err     *string
+}
+
+func decodeOpenFGABatchCheckResults(resp []byte) (map[string]grpcCheckResult, error) {
+	results := make(map[string]grpcCheckResult)
+
+	// the results are encoded as map entries with the correlation id as key (1)
+	// and the result as value (2)
+	err := rangeProtoFields(resp, func(num protowire.Number, _ uint64, entry []byte) error {
+		if num != 1 {
+			return nil
+		}
+
+		var (
+			key    string
+			result grpcCheckResult
+		)
+
+		if err := rangeProtoFields(entry, func(num protowire.Number, _ uint64, value []byte) error {
+			switch num {
+			case 1:
+				key = string(value)
+			case 2:
+				return decodeOpenFGABatchCheckResult(value, &result)
+			}
+
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		results[key] = result
+
+		return nil
+	})
+
+	return results, err
+}
+
+func decodeOpenFGABatchCheckResult(data []byte, result *grpcCheckResult) error {
+	return rangeProtoFields(data, func(num protowire.Number, varint uint64, value []byte) error {
+		switch num {
+		case 1:
+			result.allowed = varint == protoTrue
+		case 2:
+			var message string
+
+			if err := rangeProtoFields(value, func(num protowire.Number, _ uint64, value []byte) error {
+				if num == 3 {
+					message = string(value)
+				}
+
+				return nil
+			}); err != nil {
+				return err
+			}
+
+			result.err = &message
+		}
+
+		return nil
+	})
+}
+
+type spiceDBGRPCChecker struct {
+	*grpcCheckClient
+}
+
+func (c *spiceDBGRPCChecker) check(
+	ctx context.Context,
+	tuples []relationshipTuple,
+	consistencyToken string,
+) ([]bool, error) {
+	consistency := appendProtoVarint(nil, 1, protoTrue)
+	if len(consistencyToken) != 0 {
+		consistency = appendProtoMessage(nil, 2, appendProtoString(nil, 1, consistencyToken))
+	}
+
+	items := make([]spiceDBCheckItem, len(tuples))
+
+	for idx, tuple := range tuples {
+		item, err := newSpiceDBCheckItem(tuple)
+		if err != nil {
+			return nil, err
+		}
+
+		items[idx] = item
+	}
+
+	if len(items) == 1 {
+		req := appendProtoMessage(nil, 1, consistency)
+		req = items[0].appendProto(req, 2)
+
+		resp, err := c.invoke(ctx, spiceDBCheckMethod, req)
+		if err != nil {
+			return nil, err
+		}
+
+		var permissionship uint64
+
+		err = rangeProtoFields(resp, func(num protowire.Number, varint uint64, _ []byte) error {
+			if num == 2 {
+				permissionship = varint
+			}
+
+			return nil
+		})
+
+		return []bool{permissionship == spiceDBHasPermissionValue}, err
+	}
+
+	req := appendProtoMessage(nil, 1, consistency)
+	for _, item := range items {
+		req = appendProtoMessage(req, 2, item.appendProto(nil, 1))
+	}
+
+	resp, err := c.invoke(ctx, spiceDBBulkCheckMethod, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []grpcCheckResult
+
+	// the pairs (2) are in the order of the request items
+	if err = rangeProtoFields(resp, func(num protowire.Number, _ uint64, pair []byte) error {
+		if num != 2 {
+			return nil
+		}
+
+		result, err := decodeSpiceDBBulkCheckPair(pair)
+		results = append(results, result)
+
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	if len(results) != len(tuples) {
+		return nil, fmt.Errorf("%w: expected %d results, got %d",
+			errMalformedCheckResponse, len(tuples), len(results))
+	}
+
+	decisions := make([]bool, len(tuples))
+
+	for idx, result := range results {
+		if result.err != nil {
+			return nil, fmt.Errorf("%w: check of %s failed: %s",
+				errMalformedCheckResponse, tuples[idx], *result.err)
+		}
+
+		decisions[idx] = result.allowed
+	}
+
+	return decisions, nil
+}
+
+func decodeSpiceDBBulkCheckPair(data []byte) (grpcCheckResult, error) {
+	var (
+		result    grpcCheckResult
+		itemFound bool
+	)
+
+	err := rangeProtoFields(data, func(num protowire.Number, _ uint64, value []byte) error {
+		switch num {
+		case 2:
+			itemFound = true
+
+			return rangeProtoFields(value, func(num protowire.Number, varint uint64, _ []byte) error {
+				if num == 1 {
+					result.allowed = varint == spiceDBHasPermissionValue
+				}
+
+				return nil
+			})
+		case 3:
+			// google.rpc.Status with the message in field 2
+			message := "unknown error"
+
+			if err := rangeProtoFields(value, func(num protowire.Number, _ uint64, value []byte) error {
+				if num == 2 {
+					message = string(value)
+				}
+
+				return nil
+			}); err != nil {
+				return err
+			}
+
+			result.err = &message
+		}
+
+		return nil
+	})
+	if err == nil && !itemFound && result.err == nil {
+		err = fmt.Errorf("%w: no result in bulk check pair", errMalformedCheckResponse)
+	}
+
+	return result, err
+}
+
+// appendProto appends the resource, the permission and the subject of the check item as fields
+// with consecutive numbers starting with the given one.
+func (i spiceDBCheckItem) appendProto(b []byte, first protowire.Number) []byte {
+	objectReference := func(ref spiceDBObjectReference) []byte {
+		return appendProtoString(appendProtoString(nil, 1, ref.ObjectType), 2, ref.ObjectID)
+	}
+
+	subject := appendProtoMessage(nil, 1, objectReference(i.Subject.Object))
+	subject = appendProtoString(subject, 2, i.Subject.OptionalRelation)
+
+	b = appendProtoMessage(b, first, objectReference(i.Resource))
+	b = appendProtoString(b, first+1, i.Permission)
+
+	return appendProtoMessage(b, first+2, subject)
+}
+
+// appendProtoString appends a string field. Like with proto3, empty values are not encoded.
+func appendProtoString(b []byte, num protowire.Number, value string) []byte {
+	if len(value) == 0 {
+		return b
+	}
+
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+
+	return protowire.AppendString(b, value)
+}
+
+func appendProtoMessage(b []byte, num protowire.Number, msg []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+
+	return protowire.AppendBytes(b, msg)
+}
+
+// appendProtoVarint appends a varint field, like a bool or an enum. Like with proto3, zero values are
+// not encoded.
+func appendProtoVarint(b []byte, num protowire.Number, value uint64) []byte {
+	if value == 0 {
+		return b
+	}
+
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+
+	return protowire.AppendVarint(b, value)
+}
+
+// rangeProtoFields calls fn for each varint and length-delimited field of the given message. Fields of
+// other types are skipped.
+func rangeProtoFields(data []byte, fn func(num protowire.Number, varint uint64, value []byte) error) error {
+	for len(data) != 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return fmt.Errorf("%w: %w", errMalformedCheckResponse, protowire.ParseError(n))
+		}
+
+		data = data[n:]
+
+		var (
+			varint uint64
+			value  []byte
+		)
+
+		switch typ { //nolint:exhaustive
+		case protowire.VarintType:
+			varint, n = protowire.ConsumeVarint(data)
+		case protowire.BytesType:
+			value, n = protowire.ConsumeBytes(data)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, data)
+		}
+
+		if n < 0 {
+			return fmt.Errorf("%w: %w", errMalformedCheckResponse, protowire.ParseError(n))
+		}
+
+		data = data[n:]
+
+		if typ != protowire.VarintType && typ != protowire.BytesType {
+			continue
+		}
+
+		if err := fn(num, varint, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,454 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package authorizers
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/heimdall/mocks"
+	"github.com/dadrus/heimdall/internal/rules/mechanisms/subject"
+	"github.com/dadrus/heimdall/internal/x/testsupport"
+)
+
+type grpcCheckServer struct {
+	method   string
+	request  []byte
+	md       metadata.MD
+	response []byte
+	err      error
+}
+
+func (s *grpcCheckServer) handle(_ any, stream grpc.ServerStream) error {
+	s.method, _ = grpc.MethodFromServerStream(stream)
+	s.md, _ = metadata.FromIncomingContext(stream.Context())
+
+	if err := stream.RecvMsg(&s.request); err != nil {
+		return err
+	}
+
+	if s.err != nil {
+		return s.err
+	}
+
+	return stream.SendMsg(s.response)
+}
+
+func startGRPCCheckServer(t *testing.T, handler *grpcCheckServer) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	srv := grpc.NewServer(
+		grpc.ForceServerCodec(rawCodec{}),
+		grpc.UnknownServiceHandler(handler.handle),
+	)
+
+	go func() { _ = srv.Serve(listener) }()
+
+	t.Cleanup(srv.Stop)
+
+	return listener.Addr().String()
+}
+
+func protoString(num protowire.Number, value string) []byte {
+	return protowire.AppendString(protowire.AppendTag(nil, num, protowire.BytesType), value)
+}
+
+func protoVarint(num protowire.Number, value uint64) []byte {
+	return protowire.AppendVarint(protowire.AppendTag(nil, num, protowire.VarintType), value)
+}
+
+func protoMessage(num protowire.Number, fields ...[]byte) []byte {
+	return protowire.AppendBytes(protowire.AppendTag(nil, num, protowire.BytesType), bytes.Join(fields, nil))
+}
+
+func TestRelationshipAuthorizerExecuteUsingGRPC(t *testing.T) {
+	t.Parallel()
+
+	handler := &grpcCheckServer{}
+	addr := startGRPCCheckServer(t, handler)
+
+	openFGATupleKey := func(object string) []byte {
+		return protoMessage(1,
+			protoString(1, "user:alice"),
+			protoString(2, "viewer"),
+			protoString(3, object),
+		)
+	}
+
+	spiceDBItem := func(first protowire.Number, objectType, objectID string) []byte {
+		return bytes.Join([][]byte{
+			protoMessage(first, protoString(1, objectType), protoString(2, objectID)),
+			protoString(first+1, "view"),
+			protoMessage(first+2, protoMessage(1, protoString(1, "user"), protoString(2, "alice"))),
+		}, nil)
+	}
+
+	for _, tc := range []struct {
+		uc       string
+		config   []byte
+		response []byte
+		err      error
+		assert   func(t *testing.T, err error, srv *grpcCheckServer)
+	}{
+		{
+			uc: "with openfga single check using consistency token",
+			config: []byte(`
+api: openfga
+store_id: foo
+authorization_model_id: bar
+consistency_token: '{{ .Request.Header "X-Zed-Token" }}'
+checks:
+  - object: "document:{{ .Request.URL.Captures.id }}"
+    relation: viewer
+    user: "user:{{ .Subject.ID }}"
+`),
+			response: protoVarint(1, 1),
+			assert: func(t *testing.T, err error, srv *grpcCheckServer) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "/openfga.v1.OpenFGAService/Check", srv.method)
+				assert.Equal(t, bytes.Join([][]byte{
+					protoString(1, "foo"),
+					protoMessage(2,
+						protoString(1, "user:alice"),
+						protoString(2, "viewer"),
+						protoString(3, "document:1"),
+					),
+					protoString(4, "bar"),
+					protoVarint(7, 2),
+				}, nil), srv.request)
+				assert.Equal(t, []string{"Bearer foo"}, srv.md.Get("authorization"))
+			},
+		},
+		{
+			uc: "with openfga single check denying the request",
+			config: []byte(`
+api: openfga
+store_id: foo
+checks:
+  - object: "document:{{ .Request.URL.Captures.id }}"
+    relation: viewer
+    user: "user:{{ .Subject.ID }}"
+`),
+			response: []byte{},
+			assert: func(t *testing.T, err error, _ *grpcCheckServer) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrAuthorization)
+			},
+		},
+		{
+			uc: "with openfga batch check denying the request",
+			config: []byte(`
+api: openfga
+store_id: foo
+checks:
+  - object: "document:{{ .Request.URL.Captures.id }}"
+    relation: viewer
+    user: "user:{{ .Subject.ID }}"
+  - object: "folder:{{ .Request.URL.Captures.folder }}"
+    relation: viewer
+    user: "user:{{ .Subject.ID }}"
+`),
+			response: bytes.Join([][]byte{
+				protoMessage(1, protoString(1, "0"), protoMessage(2, protoVarint(1, 1))),
+				protoMessage(1, protoString(1, "1"), protoMessage(2)),
+			}, nil),
+			assert: func(t *testing.T, err error, srv *grpcCheckServer) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrAuthorization)
+				assert.Contains(t, err.Error(), "folder:2#viewer@user:alice")
+				assert.Equal(t, "/openfga.v1.OpenFGAService/BatchCheck", srv.method)
+				assert.Equal(t, bytes.Join([][]byte{
+					protoString(1, "foo"),
+					protoMessage(2, openFGATupleKey("document:1"), protoString(4, "0")),
+					protoMessage(2, openFGATupleKey("folder:2"), protoString(4, "1")),
+				}, nil), srv.request)
+			},
+		},
+		{
+			uc: "with openfga batch check result containing an error",
+			config: []byte(`
+api: openfga
+store_id: foo
+checks:
+  - object: "document:{{ .Request.URL.Captures.id }}"
+    relation: viewer
+    user: "user:{{ .Subject.ID }}"
+  - object: "folder:{{ .Request.URL.Captures.folder }}"
+    relation: viewer
+    user: "user:{{ .Subject.ID }}"
+`),
+			response: bytes.Join([][]byte{
+				protoMessage(1, protoString(1, "0"), protoMessage(2, protoVarint(1, 1))),
+				protoMessage(1, protoString(1, "1"), protoMessage(2,
+					protoMessage(2, protoVarint(1, 1), protoString(3, "type not found")))),
+			}, nil),
+			assert: func(t *testing.T, err error, _ *grpcCheckServer) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrCommunication)
+				assert.Contains(t, err.Error(), "type not found")
+			},
+		},
+		{
+			uc: "with spicedb single check using consistency token",
+			config: []byte(`
+api: spicedb
+consistency_token: '{{ .Request.Header "X-Zed-Token" }}'
+checks:
+  - object: "document:{{ .Request.URL.Captures.id }}"
+    relation: view
+    user: "user:{{ .Subject.ID }}"
+`),
+			response: bytes.Join([][]byte{
+				protoMessage(1, protoString(1, "new-zed-token")),
+				protoVarint(2, 2),
+			}, nil),
+			assert: func(t *testing.T, err error, srv *grpcCheckServer) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "/authzed.api.v1.PermissionsService/CheckPermission", srv.method)
+				assert.Equal(t, bytes.Join([][]byte{
+					protoMessage(1, protoMessage(2, protoString(1, "zed-token"))),
+					spiceDBItem(2, "document", "1"),
+				}, nil), srv.request)
+			},
+		},
+		{
+			uc: "with spicedb bulk check denying the request",
+			config: []byte(`
+api: spicedb
+checks:
+  - object: "document:{{ .Request.URL.Captures.id }}"
+    relation: view
+    user: "user:{{ .Subject.ID }}"
+  - object: "folder:{{ .Request.URL.Captures.folder }}"
+    relation: view
+    user: "user:{{ .Subject.ID }}"
+`),
+			response: bytes.Join([][]byte{
+				protoMessage(2, protoMessage(2, protoVarint(1, 2))),
+				protoMessage(2, protoMessage(2, protoVarint(1, 1))),
+			}, nil),
+			assert: func(t *testing.T, err error, srv *grpcCheckServer) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrAuthorization)
+				assert.Contains(t, err.Error(), "folder:2#view@user:alice")
+				assert.Equal(t, "/authzed.api.v1.PermissionsService/CheckBulkPermissions", srv.method)
+				assert.Equal(t, bytes.Join([][]byte{
+					protoMessage(1, protoVarint(1, 1)),
+					protoMessage(2, spiceDBItem(1, "document", "1")),
+					protoMessage(2, spiceDBItem(1, "folder", "2")),
+				}, nil), srv.request)
+			},
+		},
+		{
+			uc: "with spicedb bulk check result containing an error",
+			config: []byte(`
+api: spicedb
+checks:
+  - object: "document:{{ .Request.URL.Captures.id }}"
+    relation: view
+    user: "user:{{ .Subject.ID }}"
+  - object: "folder:{{ .Request.URL.Captures.folder }}"
+    relation: view
+    user: "user:{{ .Subject.ID }}"
+`),
+			response: bytes.Join([][]byte{
+				protoMessage(2, protoMessage(2, protoVarint(1, 2))),
+				protoMessage(2, protoMessage(3, protoVarint(1, 5), protoString(2, "object definition not found"))),
+			}, nil),
+			assert: func(t *testing.T, err error, _ *grpcCheckServer) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrCommunication)
+				assert.Contains(t, err.Error(), "object definition not found")
+			},
+		},
+		{
+			uc: "with spicedb bulk check result missing pairs",
+			config: []byte(`
+api: spicedb
+checks:
+  - object: "document:{{ .Request.URL.Captures.id }}"
+    relation: view
+    user: "user:{{ .Subject.ID }}"
+  - object: "folder:{{ .Request.URL.Captures.folder }}"
+    relation: view
+    user: "user:{{ .Subject.ID }}"
+`),
+			response: protoMessage(2, protoMessage(2, protoVarint(1, 2))),
+			assert: func(t *testing.T, err error, _ *grpcCheckServer) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrCommunication)
+				assert.Contains(t, err.Error(), "expected 2 results, got 1")
+			},
+		},
+		{
+			uc: "with error status",
+			config: []byte(`
+api: spicedb
+checks:
+  - object: "document:{{ .Request.URL.Captures.id }}"
+    relation: view
+    user: "user:{{ .Subject.ID }}"
+`),
+			err: status.Error(codes.PermissionDenied, "invalid preshared key"),
+			assert: func(t *testing.T, err error, _ *grpcCheckServer) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrCommunication)
+				assert.Contains(t, err.Error(), "invalid preshared key")
+			},
+		},
+		{
+			uc: "with malformed response",
+			config: []byte(`
+api: spicedb
+checks:
+  - object: "document:{{ .Request.URL.Captures.id }}"
+    relation: view
+    user: "user:{{ .Subject.ID }}"
+`),
+			response: []byte{0xff},
+			assert: func(t *testing.T, err error, _ *grpcCheckServer) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrCommunication)
+				require.ErrorIs(t, err, errMalformedCheckResponse)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			*handler = grpcCheckServer{response: tc.response, err: tc.err}
+
+			conf, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			conf["endpoint"] = map[string]any{
+				"url":     "grpc://" + addr,
+				"headers": map[string]any{"Authorization": "Bearer foo"},
+			}
+
+			auth, err := newRelationshipAuthorizer("foo", conf)
+			require.NoError(t, err)
+
+			t.Cleanup(func() { _ = auth.Stop(context.Background()) })
+
+			reqf := mocks.NewRequestFunctionsMock(t)
+			reqf.EXPECT().Header("X-Zed-Token").Return("zed-token").Maybe()
+
+			ctx := mocks.NewContextMock(t)
+			ctx.EXPECT().AppContext().Return(context.Background())
+			ctx.EXPECT().Request().Return(&heimdall.Request{
+				RequestFunctions: reqf,
+				Method:           http.MethodGet,
+				URL: &heimdall.URL{
+					URL:      url.URL{Scheme: "http", Host: "localhost", Path: "/folders/2/documents/1"},
+					Captures: map[string]string{"id": "1", "folder": "2"},
+				},
+			})
+
+			// WHEN
+			err = auth.Execute(ctx, &subject.Subject{ID: "alice"})
+
+			// THEN
+			tc.assert(t, err, handler)
+		})
+	}
+}
+
+func TestRelationshipAuthorizerStop(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc     string
+		url    string
+		assert func(t *testing.T, auth *relationshipAuthorizer)
+	}{
+		{
+			uc:  "using http api",
+			url: "http://foo.bar",
+			assert: func(t *testing.T, _ *relationshipAuthorizer) {
+				t.Helper()
+			},
+		},
+		{
+			uc:  "using grpc api",
+			url: "grpc://foo.bar:50051",
+			assert: func(t *testing.T, auth *relationshipAuthorizer) {
+				t.Helper()
+
+				checker, ok := auth.checker.(*spiceDBGRPCChecker)
+				require.True(t, ok)
+
+				// the connection has already been closed
+				require.Error(t, checker.conn.Close())
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			auth, err := newRelationshipAuthorizer("foo", map[string]any{
+				"endpoint": map[string]any{"url": tc.url},
+				"api":      "spicedb",
+				"checks": []any{
+					map[string]any{"object": "document:1", "relation": "view", "user": "user:alice"},
+				},
+			})
+			require.NoError(t, err)
+
+			// WHEN
+			err = auth.Stop(context.Background())
+
+			// THEN
+			require.NoError(t, err)
+			tc.assert(t, auth)
+		})
+	}
+}
@@ -17,9 +17,11 @@
 package authorizers
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/binary"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"io"
 	"net/http"
@@ -31,6 +33,7 @@ import (
 	"github.com/rs/zerolog"
 
 	"github.com/dadrus/heimdall/internal/cache"
+	"github.com/dadrus/heimdall/internal/cache/encoding"
 	"github.com/dadrus/heimdall/internal/heimdall"
 	"github.com/dadrus/heimdall/internal/rules/endpoint"
 	"github.com/dadrus/heimdall/internal/rules/mechanisms/cellib"
@@ -59,6 +62,8 @@ func init() {
 
 			return true, auth, err
 		})
+
+	encoding.RegisterType[*authorizationInformation]("remote_authorizer.authorization_information")
 }
 
 type remoteAuthorizer struct {
@@ -77,6 +82,28 @@ type authorizationInformation struct {
 	payload any
 }
 
+type authorizationInformationJSON struct {
+	Headers http.Header `json:"headers,omitempty"`
+	Payload any         `json:"payload,omitempty"`
+}
+
+func (ai *authorizationInformation) MarshalJSON() ([]byte, error) {
+	return json.Marshal(authorizationInformationJSON{Headers: ai.headers, Payload: ai.payload})
+}
+
+func (ai *authorizationInformation) UnmarshalJSON(data []byte) error {
+	var aij authorizationInformationJSON
+
+	if err := json.Unmarshal(data, &aij); err != nil {
+		return err
+	}
+
+	ai.headers = aij.Headers
+	ai.payload = aij.Payload
+
+	return nil
+}
+
 func (ai *authorizationInformation) addHeadersTo(headerNames []string, ctx heimdall.Context) {
 	for _, headerName := range headerNames {
 		headerValue := ai.headers.Get(headerName)
@@ -140,44 +167,28 @@ func (a *remoteAuthorizer) Execute(ctx heimdall.Context, sub *subject.Subject) e
 			WithErrorContext(a)
 	}
 
-	cch := cache.Ctx(ctx.AppContext())
-
-	var (
-		cacheKey   string
-		cacheEntry any
-		authInfo   *authorizationInformation
-		err        error
-		ok         bool
-	)
-
 	vals, payload, err := a.renderTemplates(ctx, sub)
 	if err != nil {
 		return err
 	}
 
-	if a.ttl > 0 {
-		cacheKey = a.calculateCacheKey(sub, vals, payload)
-		cacheEntry = cch.Get(ctx.AppContext(), cacheKey)
-	}
+	load := func(_ context.Context) (*authorizationInformation, time.Duration, error) {
+		authInfo, err := a.doAuthorize(ctx, sub, vals, payload)
 
-	if cacheEntry != nil {
-		if authInfo, ok = cacheEntry.(*authorizationInformation); !ok {
-			logger.Warn().Msg("Wrong object type from cache")
-			cch.Delete(ctx.AppContext(), cacheKey)
-		} else {
-			logger.Debug().Msg("Reusing authorization information from cache")
-		}
+		return authInfo, a.ttl, err
 	}
 
-	if authInfo == nil {
-		authInfo, err = a.doAuthorize(ctx, sub, vals, payload)
-		if err != nil {
-			return err
-		}
+	var authInfo *authorizationInformation
 
-		if a.ttl > 0 && len(cacheKey) != 0 {
-			cch.Set(ctx.AppContext(), cacheKey, authInfo, a.ttl)
-		}
+	if a.ttl > 0 {
+		authInfo, err = cache.GetOrLoad(ctx.AppContext(), cache.Ctx(ctx.AppContext()),
+			a.calculateCacheKey(sub, vals, payload), load)
+	} else {
+		authInfo, _, err = load(ctx.AppContext())
+	}
+
+	if err != nil {
+		return err
 	}
 
 	authInfo.addHeadersTo(a.headersForUpstream, ctx)
@@ -35,6 +35,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/dadrus/heimdall/internal/cache"
+	"github.com/dadrus/heimdall/internal/cache/encoding"
 	"github.com/dadrus/heimdall/internal/cache/mocks"
 	"github.com/dadrus/heimdall/internal/heimdall"
 	heimdallmocks "github.com/dadrus/heimdall/internal/heimdall/mocks"
@@ -201,7 +202,7 @@ values:
 					"Subject": &subject.Subject{ID: "bar"},
 					"Request": &heimdall.Request{
 						RequestFunctions: rfunc,
-						URL:              &url.URL{Scheme: "http", Host: "foo.bar", Path: "/foo/bar"},
+						URL:              &heimdall.URL{URL: url.URL{Scheme: "http", Host: "foo.bar", Path: "/foo/bar"}},
 					},
 				})
 				require.NoError(t, err)
@@ -1318,3 +1319,23 @@ func TestRemoteAuthorizerExecute(t *testing.T) {
 		})
 	}
 }
+
+func TestAuthorizationInformationCanBeCachedInSerializedForm(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	authInfo := &authorizationInformation{
+		headers: http.Header{"X-Foo-Bar": []string{"baz"}},
+		payload: map[string]any{"foo": "bar"},
+	}
+
+	// WHEN
+	raw, err := encoding.Marshal(authInfo)
+	require.NoError(t, err)
+
+	res, err := encoding.Unmarshal(raw)
+
+	// THEN
+	require.NoError(t, err)
+	assert.Equal(t, authInfo, res)
+}
@@ -0,0 +1,76 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package mechanisms
+
+import (
+	"github.com/rs/zerolog"
+
+	"github.com/dadrus/heimdall/internal/cache"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+// cacheDependent is implemented by mechanisms, which rely on the cache for their correct
+// functioning and not only to improve performance.
+type cacheDependent interface {
+	// CacheDependency describes what the mechanism relies on the cache for. An empty string
+	// means, the mechanism does not rely on the cache in its current configuration.
+	CacheDependency() string
+}
+
+// checkCacheDependency fails if the given mechanism relies on the cache, which is however
+// disabled.
+func checkCacheDependency(id string, mechanism any, kind cache.Kind) error {
+	dependent, ok := mechanism.(cacheDependent)
+	if !ok {
+		return nil
+	}
+
+	if purpose := dependent.CacheDependency(); len(purpose) != 0 && kind == cache.KindDisabled {
+		return errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+			"'%s' relies on the cache for %s, but caching is disabled", id, purpose)
+	}
+
+	return nil
+}
+
+// warnAboutCacheDependency logs a warning if the given mechanism relies on the cache, which
+// is however not shared between heimdall instances, or may evict entries before they expire.
+func warnAboutCacheDependency(id string, mechanism any, cch cache.Cache, logger zerolog.Logger) {
+	dependent, ok := mechanism.(cacheDependent)
+	if !ok {
+		return
+	}
+
+	purpose := dependent.CacheDependency()
+	if len(purpose) == 0 {
+		return
+	}
+
+	if cache.KindOf(cch) == cache.KindLocal {
+		logger.Warn().Str("_id", id).
+			Msgf("Mechanism relies on the cache for %s. With the in-memory cache, this is done "+
+				"per heimdall instance only. Configure a shared cache if you operate multiple instances", purpose)
+	}
+
+	if cache.EvictsEntries(cch) {
+		logger.Warn().Str("_id", id).
+			Msgf("Mechanism relies on the cache for %s, but the cache is configured with limits and may "+
+				"evict the required entries before they expire. Remove the max_entries and max_memory "+
+				"settings or configure a shared cache", purpose)
+	}
+}
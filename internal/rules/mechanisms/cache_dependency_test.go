@@ -0,0 +1,97 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package mechanisms
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dadrus/heimdall/internal/cache"
+	"github.com/dadrus/heimdall/internal/cache/memory"
+)
+
+type testCacheDependent string
+
+func (d testCacheDependent) CacheDependency() string { return string(d) }
+
+func TestWarnAboutCacheDependency(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc        string
+		mechanism any
+		cch       cache.Cache
+		assert    func(t *testing.T, logs string)
+	}{
+		{
+			uc:        "mechanism not depending on the cache",
+			mechanism: "foo",
+			cch:       memory.New(memory.WithMaxEntries(10)),
+			assert: func(t *testing.T, logs string) {
+				t.Helper()
+
+				assert.Empty(t, logs)
+			},
+		},
+		{
+			uc:        "mechanism not depending on the cache in its configuration",
+			mechanism: testCacheDependent(""),
+			cch:       memory.New(memory.WithMaxEntries(10)),
+			assert: func(t *testing.T, logs string) {
+				t.Helper()
+
+				assert.Empty(t, logs)
+			},
+		},
+		{
+			uc:        "mechanism depending on an unbounded in-memory cache",
+			mechanism: testCacheDependent("back-channel logout"),
+			cch:       memory.New(),
+			assert: func(t *testing.T, logs string) {
+				t.Helper()
+
+				assert.Contains(t, logs, "per heimdall instance only")
+				assert.NotContains(t, logs, "evict")
+			},
+		},
+		{
+			uc:        "mechanism depending on a bounded in-memory cache",
+			mechanism: testCacheDependent("back-channel logout"),
+			cch:       memory.New(memory.WithMaxEntries(10)),
+			assert: func(t *testing.T, logs string) {
+				t.Helper()
+
+				assert.Contains(t, logs, "per heimdall instance only")
+				assert.Contains(t, logs, "evict the required entries before they expire")
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			var logs bytes.Buffer
+
+			// WHEN
+			warnAboutCacheDependency("foo", tc.mechanism, tc.cch, zerolog.New(&logs))
+
+			// THEN
+			tc.assert(t, logs.String())
+		})
+	}
+}
@@ -53,6 +53,7 @@ func TestErrors(t *testing.T) {
 		{expr: `type(Error) != precondition_error`},
 		{expr: `precondition_error != type(Error)`},
 		{expr: `type(Error) != communication_error`},
+		{expr: `type(Error) != too_many_requests_error`},
 		{expr: `internal_error == internal_error`},
 		{expr: `Error.Source == "test"`},
 		{expr: `Error == Error`},
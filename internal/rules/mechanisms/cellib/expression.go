@@ -26,7 +26,9 @@ import (
 
 var errCELResultType = errors.New("result type error")
 
-func CompileExpression(env *cel.Env, expr, errMsg string) (*CompiledExpression, error) {
+// CompileExpression compiles the given expression, which must evaluate to a bool. Additional
+// program options, like cel.CostLimit, are applied to the resulting program.
+func CompileExpression(env *cel.Env, expr, errMsg string, opts ...cel.ProgramOption) (*CompiledExpression, error) {
 	ast, iss := env.Compile(expr)
 	if iss.Err() != nil {
 		return nil, iss.Err()
@@ -41,7 +43,7 @@ func CompileExpression(env *cel.Env, expr, errMsg string) (*CompiledExpression,
 		return nil, fmt.Errorf("%w: wanted bool, got %v", errCELResultType, ast.OutputType())
 	}
 
-	prg, err := env.Program(ast, cel.EvalOptions(cel.OptOptimize))
+	prg, err := env.Program(ast, append([]cel.ProgramOption{cel.EvalOptions(cel.OptOptimize)}, opts...)...)
 	if err != nil {
 		return nil, err
 	}
@@ -77,5 +79,14 @@ func (e *CompiledExpression) Eval(obj any) error {
 		return nil
 	}
 
-	return &EvalError{msg: e.msg}
+	return e.Failure()
+}
+
+// Failure returns the error, Eval responds with, if the expression evaluates to false.
+func (e *CompiledExpression) Failure() error { return &EvalError{msg: e.msg} }
+
+// WithMessage returns a copy of the expression, which shares the compiled program, but
+// uses the given message for the error returned if the expression evaluates to false.
+func (e *CompiledExpression) WithMessage(msg string) *CompiledExpression {
+	return &CompiledExpression{p: e.p, msg: msg}
 }
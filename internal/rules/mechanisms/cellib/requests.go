@@ -26,6 +26,8 @@ import (
 	"github.com/google/cel-go/ext"
 
 	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/x/pkix"
+	"github.com/dadrus/heimdall/internal/x/slicex"
 )
 
 func Requests() cel.EnvOption {
@@ -83,5 +85,17 @@ func (requestsLib) CompileOptions() []cel.EnvOption {
 				}),
 			),
 		),
+		cel.Function("ClientCertificates",
+			cel.MemberOverload("request_ClientCertificates",
+				[]*cel.Type{requestType}, cel.ListType(cel.DynType),
+				cel.UnaryBinding(func(lhs ref.Val) ref.Val {
+					// nolint: forcetypeassert
+					req := lhs.Value().(*heimdall.Request)
+
+					return types.DefaultTypeAdapter.NativeToValue(
+						slicex.Map(req.ClientCertificates(), pkix.CertificateInfo))
+				}),
+			),
+		),
 	}
 }
@@ -17,15 +17,19 @@
 package cellib
 
 import (
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"net/http"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/google/cel-go/cel"
 	"github.com/stretchr/testify/require"
 
 	"github.com/dadrus/heimdall/internal/heimdall"
 	"github.com/dadrus/heimdall/internal/heimdall/mocks"
+	"github.com/dadrus/heimdall/internal/x/testsupport"
 )
 
 func TestRequests(t *testing.T) {
@@ -42,7 +46,21 @@ func TestRequests(t *testing.T) {
 	uri, err := url.Parse("http://localhost/foo/bar?foo=bar&foo=baz&bar=foo")
 	require.NoError(t, err)
 
+	spiffeID, err := url.Parse("spiffe://example.org/foo")
+	require.NoError(t, err)
+
+	ca, err := testsupport.NewRootCA("Test CA", time.Hour)
+	require.NoError(t, err)
+
+	clientCert, err := ca.IssueCertificate(
+		testsupport.WithSubject(pkix.Name{CommonName: "foo"}),
+		testsupport.WithValidity(time.Now(), time.Hour),
+		testsupport.WithSubjectPubKey(&ca.PrivKey.PublicKey, x509.ECDSAWithSHA384),
+		testsupport.WithURIs([]*url.URL{spiffeID}))
+	require.NoError(t, err)
+
 	reqf := mocks.NewRequestFunctionsMock(t)
+	reqf.EXPECT().ClientCertificates().Return([]*x509.Certificate{clientCert, ca.Certificate})
 	reqf.EXPECT().Cookie("foo").Return("bar")
 	reqf.EXPECT().Header("bar").Return("baz")
 	reqf.EXPECT().Header("zab").Return("bar;charset=utf-8")
@@ -52,7 +70,7 @@ func TestRequests(t *testing.T) {
 	req := &heimdall.Request{
 		RequestFunctions:  reqf,
 		Method:            http.MethodHead,
-		URL:               uri,
+		URL:               &heimdall.URL{URL: *uri},
 		ClientIPAddresses: []string{"127.0.0.1"},
 	}
 
@@ -68,6 +86,10 @@ func TestRequests(t *testing.T) {
 		{expr: `["text/html", "application/xml", "application/json"].exists(v, Request.Header("accept").contains(v))`},
 		{expr: `Request.ClientIPAddresses in networks("127.0.0.0/24")`},
 		{expr: `Request.Body().foo[0] == "bar"`},
+		{expr: `Request.ClientCertificates().size() == 2`},
+		{expr: `Request.ClientCertificates()[0].spiffe_id == "spiffe://example.org/foo"`},
+		{expr: `Request.ClientCertificates()[0].subject.common_name == "foo"`},
+		{expr: `Request.ClientCertificates()[1].issuer.common_name == "Test CA"`},
 	} {
 		t.Run(tc.expr, func(t *testing.T) {
 			ast, iss := env.Compile(tc.expr)
@@ -25,6 +25,8 @@ import (
 	"github.com/google/cel-go/common/types/ref"
 	"github.com/google/cel-go/common/types/traits"
 	"github.com/google/cel-go/ext"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
 )
 
 func Urls() cel.EnvOption {
@@ -42,16 +44,16 @@ func (urlsLib) ProgramOptions() []cel.ProgramOption {
 }
 
 func (urlsLib) CompileOptions() []cel.EnvOption {
-	urlType := cel.ObjectType(reflect.TypeOf(url.URL{}).String(), traits.ReceiverType)
+	urlType := cel.ObjectType(reflect.TypeOf(heimdall.URL{}).String(), traits.ReceiverType)
 
 	return []cel.EnvOption{
-		ext.NativeTypes(reflect.TypeOf(&url.URL{})),
+		ext.NativeTypes(reflect.TypeOf(&url.URL{}), reflect.TypeOf(&heimdall.URL{})),
 		cel.Function("String",
 			cel.MemberOverload("url_String",
 				[]*cel.Type{urlType}, cel.StringType,
 				cel.UnaryBinding(func(value ref.Val) ref.Val {
 					// nolint: forcetypeassert
-					return types.String(value.Value().(*url.URL).String())
+					return types.String(value.Value().(*heimdall.URL).String())
 				}),
 			),
 		),
@@ -60,7 +62,7 @@ func (urlsLib) CompileOptions() []cel.EnvOption {
 				[]*cel.Type{urlType}, cel.MapType(types.StringType, cel.ListType(cel.StringType)),
 				cel.UnaryBinding(func(value ref.Val) ref.Val {
 					// nolint: forcetypeassert
-					return types.NewDynamicMap(types.DefaultTypeAdapter, value.Value().(*url.URL).Query())
+					return types.NewDynamicMap(types.DefaultTypeAdapter, value.Value().(*heimdall.URL).Query())
 				}),
 			),
 		),
@@ -17,11 +17,14 @@
 package contextualizers
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/binary"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"io"
+	"maps"
 	"net/http"
 	"net/url"
 	"strings"
@@ -30,9 +33,11 @@ import (
 	"github.com/rs/zerolog"
 
 	"github.com/dadrus/heimdall/internal/cache"
+	"github.com/dadrus/heimdall/internal/cache/encoding"
 	"github.com/dadrus/heimdall/internal/heimdall"
 	"github.com/dadrus/heimdall/internal/rules/endpoint"
 	"github.com/dadrus/heimdall/internal/rules/mechanisms/contenttype"
+	"github.com/dadrus/heimdall/internal/rules/mechanisms/forwarding"
 	"github.com/dadrus/heimdall/internal/rules/mechanisms/subject"
 	"github.com/dadrus/heimdall/internal/rules/mechanisms/template"
 	"github.com/dadrus/heimdall/internal/rules/mechanisms/values"
@@ -61,16 +66,26 @@ func init() {
 
 			return true, eh, err
 		})
+
+	encoding.RegisterType[*contextualizerData]("generic_contextualizer.data")
 }
 
 type contextualizerData struct {
 	payload any
 }
 
+func (c *contextualizerData) MarshalJSON() ([]byte, error) { return json.Marshal(c.payload) }
+
+func (c *contextualizerData) UnmarshalJSON(data []byte) error {
+	return json.Unmarshal(data, &c.payload)
+}
+
 type genericContextualizer struct {
 	id              string
 	e               endpoint.Endpoint
 	ttl             time.Duration
+	staleIfError    time.Duration
+	refreshAhead    time.Duration
 	payload         template.Template
 	fwdHeaders      []string
 	fwdCookies      []string
@@ -85,6 +100,8 @@ func newGenericContextualizer(id string, rawConfig map[string]any) (*genericCont
 		ForwardCookies  []string          `mapstructure:"forward_cookies"`
 		Payload         template.Template `mapstructure:"payload"`
 		CacheTTL        *time.Duration    `mapstructure:"cache_ttl"`
+		StaleIfError    time.Duration     `mapstructure:"stale_if_error"`
+		RefreshAhead    time.Duration     `mapstructure:"refresh_ahead"`
 		ContinueOnError bool              `mapstructure:"continue_pipeline_on_error"`
 		Values          values.Values     `mapstructure:"values"`
 	}
@@ -106,6 +123,8 @@ func newGenericContextualizer(id string, rawConfig map[string]any) (*genericCont
 		fwdHeaders:      conf.ForwardHeaders,
 		fwdCookies:      conf.ForwardCookies,
 		ttl:             ttl,
+		staleIfError:    conf.StaleIfError,
+		refreshAhead:    conf.RefreshAhead,
 		continueOnError: conf.ContinueOnError,
 		v:               conf.Values,
 	}, nil
@@ -121,44 +140,36 @@ func (h *genericContextualizer) Execute(ctx heimdall.Context, sub *subject.Subje
 			WithErrorContext(h)
 	}
 
-	cch := cache.Ctx(ctx.AppContext())
-
-	var (
-		cacheKey   string
-		err        error
-		ok         bool
-		cacheEntry any
-		response   *contextualizerData
-	)
-
 	vals, payload, err := h.renderTemplates(ctx, sub)
 	if err != nil {
 		return err
 	}
 
-	if h.ttl > 0 {
-		cacheKey = h.calculateCacheKey(sub, vals, payload)
-		cacheEntry = cch.Get(ctx.AppContext(), cacheKey)
+	// the values to forward are taken from the request up front, as the load
+	// might happen in the background, after the request has been completed
+	fwd := forwarding.Collect(ctx, h.fwdHeaders, h.fwdCookies)
+	// the same applies to the subject, which is updated by further mechanisms
+	loadSub := x.IfThenElseExec(h.refreshAhead > 0,
+		func() *subject.Subject { return &subject.Subject{ID: sub.ID, Attributes: maps.Clone(sub.Attributes)} },
+		func() *subject.Subject { return sub })
+	load := func(appCtx context.Context) (*contextualizerData, time.Duration, error) {
+		response, err := h.callEndpoint(appCtx, loadSub, vals, payload, fwd)
+
+		return response, h.ttl, err
 	}
 
-	if cacheEntry != nil {
-		if response, ok = cacheEntry.(*contextualizerData); !ok {
-			logger.Warn().Msg("Wrong object type from cache")
-			cch.Delete(ctx.AppContext(), cacheKey)
-		} else {
-			logger.Debug().Msg("Reusing contextualizer response from cache")
-		}
-	}
+	var response *contextualizerData
 
-	if response == nil {
-		response, err = h.callEndpoint(ctx, sub, vals, payload)
-		if err != nil {
-			return err
-		}
+	if h.ttl > 0 {
+		response, err = cache.GetOrLoad(ctx.AppContext(), cache.Ctx(ctx.AppContext()),
+			h.calculateCacheKey(sub, vals, payload), load,
+			cache.WithStaleIfError(h.staleIfError), cache.WithRefreshAhead(h.refreshAhead))
+	} else {
+		response, _, err = load(ctx.AppContext())
+	}
 
-		if h.ttl > 0 && len(cacheKey) != 0 {
-			cch.Set(ctx.AppContext(), cacheKey, response, h.ttl)
-		}
+	if err != nil {
+		return err
 	}
 
 	if response.payload != nil {
@@ -178,6 +189,8 @@ func (h *genericContextualizer) WithConfig(rawConfig map[string]any) (Contextual
 		ForwardCookies  []string          `mapstructure:"forward_cookies"`
 		Payload         template.Template `mapstructure:"payload"`
 		CacheTTL        *time.Duration    `mapstructure:"cache_ttl"`
+		StaleIfError    *time.Duration    `mapstructure:"stale_if_error"`
+		RefreshAhead    *time.Duration    `mapstructure:"refresh_ahead"`
 		ContinueOnError *bool             `mapstructure:"continue_pipeline_on_error"`
 		Values          values.Values     `mapstructure:"values"`
 	}
@@ -196,6 +209,12 @@ func (h *genericContextualizer) WithConfig(rawConfig map[string]any) (Contextual
 		ttl: x.IfThenElseExec(conf.CacheTTL != nil,
 			func() time.Duration { return *conf.CacheTTL },
 			func() time.Duration { return h.ttl }),
+		staleIfError: x.IfThenElseExec(conf.StaleIfError != nil,
+			func() time.Duration { return *conf.StaleIfError },
+			func() time.Duration { return h.staleIfError }),
+		refreshAhead: x.IfThenElseExec(conf.RefreshAhead != nil,
+			func() time.Duration { return *conf.RefreshAhead },
+			func() time.Duration { return h.refreshAhead }),
 		continueOnError: x.IfThenElseExec(conf.ContinueOnError != nil,
 			func() bool { return *conf.ContinueOnError },
 			func() bool { return h.continueOnError }),
@@ -208,15 +227,16 @@ func (h *genericContextualizer) ID() string { return h.id }
 func (h *genericContextualizer) ContinueOnError() bool { return h.continueOnError }
 
 func (h *genericContextualizer) callEndpoint(
-	ctx heimdall.Context,
+	ctx context.Context,
 	sub *subject.Subject,
 	values map[string]string,
 	payload string,
+	fwd *forwarding.Values,
 ) (*contextualizerData, error) {
-	logger := zerolog.Ctx(ctx.AppContext())
+	logger := zerolog.Ctx(ctx)
 	logger.Debug().Msg("Calling contextualizer endpoint")
 
-	req, err := h.createRequest(ctx, sub, values, payload)
+	req, err := h.createRequest(ctx, sub, values, payload, fwd)
 	if err != nil {
 		return nil, err
 	}
@@ -248,13 +268,12 @@ func (h *genericContextualizer) callEndpoint(
 }
 
 func (h *genericContextualizer) createRequest(
-	ctx heimdall.Context,
+	ctx context.Context,
 	sub *subject.Subject,
 	values map[string]string,
 	payload string,
+	fwd *forwarding.Values,
 ) (*http.Request, error) {
-	logger := zerolog.Ctx(ctx.AppContext())
-
 	endpointRenderer := endpoint.RenderFunc(func(value string) (string, error) {
 		tpl, err := template.New(value)
 		if err != nil {
@@ -269,43 +288,25 @@ func (h *genericContextualizer) createRequest(
 		})
 	})
 
-	req, err := h.e.CreateRequest(ctx.AppContext(), strings.NewReader(payload), endpointRenderer)
+	req, err := h.e.CreateRequest(ctx, strings.NewReader(payload), endpointRenderer)
 	if err != nil {
 		return nil, errorchain.NewWithMessage(heimdall.ErrInternal, "failed creating request").
 			WithErrorContext(h).
 			CausedBy(err)
 	}
 
-	for _, headerName := range h.fwdHeaders {
-		headerValue := ctx.Request().Header(headerName)
-		if len(headerValue) == 0 {
-			logger.Warn().Str("_header", headerName).
-				Msg("Header not present in the request but configured to be forwarded")
-		} else {
-			req.Header.Add(headerName, headerValue)
-		}
-	}
-
-	for _, cookieName := range h.fwdCookies {
-		cookieValue := ctx.Request().Cookie(cookieName)
-		if len(cookieValue) == 0 {
-			logger.Warn().Str("_cookie", cookieName).
-				Msg("Cookie not present in the request but configured to be forwarded")
-		} else {
-			req.AddCookie(&http.Cookie{Name: cookieName, Value: cookieValue})
-		}
-	}
+	fwd.AddTo(ctx, req)
 
 	return req, nil
 }
 
-func (h *genericContextualizer) readResponse(ctx heimdall.Context, resp *http.Response) (any, error) {
-	logger := zerolog.Ctx(ctx.AppContext())
+func (h *genericContextualizer) readResponse(ctx context.Context, resp *http.Response) (any, error) {
+	logger := zerolog.Ctx(ctx)
 
 	if !(resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices) {
-		return nil, errorchain.NewWithMessagef(heimdall.ErrCommunication,
-			"unexpected response code: %v", resp.StatusCode).
-			WithErrorContext(h)
+		return nil, errorchain.New(heimdall.ErrCommunication).
+			WithErrorContext(h).
+			CausedBy(&heimdall.UnexpectedResponseError{StatusCode: resp.StatusCode})
 	}
 
 	if resp.ContentLength == 0 {
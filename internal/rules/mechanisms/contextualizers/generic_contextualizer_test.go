@@ -31,6 +31,8 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/dadrus/heimdall/internal/cache"
+	"github.com/dadrus/heimdall/internal/cache/encoding"
+	"github.com/dadrus/heimdall/internal/cache/memory"
 	"github.com/dadrus/heimdall/internal/cache/mocks"
 	"github.com/dadrus/heimdall/internal/heimdall"
 	heimdallmocks "github.com/dadrus/heimdall/internal/heimdall/mocks"
@@ -124,6 +126,8 @@ forward_cookies:
   - My-Foo-Session
 payload: "{{ .Subject.ID }}"
 cache_ttl: 5s
+stale_if_error: 1m
+refresh_ahead: 2s
 values:
   foo: "{{ .Subject.ID }}"
 continue_pipeline_on_error: true
@@ -147,6 +151,8 @@ continue_pipeline_on_error: true
 				assert.Contains(t, contextualizer.fwdHeaders, "X-User-ID")
 				assert.Contains(t, contextualizer.fwdHeaders, "X-Foo-Bar")
 				assert.Equal(t, 5*time.Second, contextualizer.ttl)
+				assert.Equal(t, time.Minute, contextualizer.staleIfError)
+				assert.Equal(t, 2*time.Second, contextualizer.refreshAhead)
 
 				res, err := contextualizer.v.Render(map[string]any{
 					"Subject": &subject.Subject{ID: "bar"},
@@ -416,6 +422,8 @@ forward_headers:
 forward_cookies:
   - My-Foo-Session
 cache_ttl: 5s
+stale_if_error: 1m
+refresh_ahead: 1s
 values:
   foo: bar
 continue_pipeline_on_error: true
@@ -427,6 +435,8 @@ forward_headers:
 forward_cookies:
   - Foo-Session
 cache_ttl: 15s
+stale_if_error: 5m
+refresh_ahead: 3s
 values:
   bar: foo
 continue_pipeline_on_error: false
@@ -458,6 +468,10 @@ continue_pipeline_on_error: false
 				assert.Contains(t, configured.fwdCookies, "Foo-Session")
 				assert.NotEqual(t, prototype.ttl, configured.ttl)
 				assert.Equal(t, 15*time.Second, configured.ttl)
+				assert.Equal(t, time.Minute, prototype.staleIfError)
+				assert.Equal(t, 5*time.Minute, configured.staleIfError)
+				assert.Equal(t, time.Second, prototype.refreshAhead)
+				assert.Equal(t, 3*time.Second, configured.refreshAhead)
 				assert.Equal(t, "contextualizer5", configured.ID())
 				assert.True(t, prototype.ContinueOnError())
 				assert.False(t, configured.ContinueOnError())
@@ -911,7 +925,7 @@ func TestGenericContextualizerExecute(t *testing.T) {
 					&heimdall.Request{
 						RequestFunctions: reqf,
 						Method:           http.MethodPost,
-						URL:              &url.URL{Scheme: "http", Host: "foobar.baz", Path: "zab"},
+						URL:              &heimdall.URL{URL: url.URL{Scheme: "http", Host: "foobar.baz", Path: "zab"}},
 					})
 			},
 			assert: func(t *testing.T, err error, sub *subject.Subject) {
@@ -967,3 +981,63 @@ func TestGenericContextualizerExecute(t *testing.T) {
 		})
 	}
 }
+
+func TestGenericContextualizerExecuteWithStaleIfError(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	var responseCode int
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(responseCode)
+		_, err := w.Write([]byte(`{ "baz": "foo" }`))
+		require.NoError(t, err)
+	}))
+	defer srv.Close()
+
+	contextualizer := &genericContextualizer{
+		id:           "contextualizer",
+		e:            endpoint.Endpoint{URL: srv.URL, Method: http.MethodPost},
+		ttl:          50 * time.Millisecond,
+		staleIfError: time.Minute,
+	}
+
+	ctx := heimdallmocks.NewContextMock(t)
+	ctx.EXPECT().AppContext().Return(cache.WithContext(context.Background(), memory.New()))
+	ctx.EXPECT().Request().Return(nil).Maybe()
+
+	responseCode = http.StatusOK
+
+	err := contextualizer.Execute(ctx, &subject.Subject{ID: "foo", Attributes: map[string]any{}})
+	require.NoError(t, err)
+
+	time.Sleep(100 * time.Millisecond)
+
+	responseCode = http.StatusBadGateway
+	sub := &subject.Subject{ID: "foo", Attributes: map[string]any{}}
+
+	// WHEN
+	err = contextualizer.Execute(ctx, sub)
+
+	// THEN
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"baz": "foo"}, sub.Attributes["contextualizer"])
+}
+
+func TestContextualizerDataCanBeCachedInSerializedForm(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	data := &contextualizerData{payload: map[string]any{"foo": "bar", "baz": []any{"zab"}}}
+
+	// WHEN
+	raw, err := encoding.Marshal(data)
+	require.NoError(t, err)
+
+	res, err := encoding.Unmarshal(raw)
+
+	// THEN
+	require.NoError(t, err)
+	assert.Equal(t, data, res)
+}
@@ -17,9 +17,16 @@
 package mechanisms
 
 import (
+	"context"
+	"errors"
+
 	"github.com/rs/zerolog"
 
+	"github.com/dadrus/heimdall/internal/backchannel"
+	"github.com/dadrus/heimdall/internal/cache"
+	"github.com/dadrus/heimdall/internal/callback"
 	"github.com/dadrus/heimdall/internal/config"
+	"github.com/dadrus/heimdall/internal/heimdall"
 	"github.com/dadrus/heimdall/internal/rules/mechanisms/authenticators"
 	"github.com/dadrus/heimdall/internal/rules/mechanisms/authorizers"
 	"github.com/dadrus/heimdall/internal/rules/mechanisms/contextualizers"
@@ -28,21 +35,84 @@ import (
 	"github.com/dadrus/heimdall/internal/x/errorchain"
 )
 
-func NewFactory(conf *config.Configuration, logger zerolog.Logger) (Factory, error) {
+// logoutTokenHandlerProvider is implemented by mechanisms, which can process back-channel
+// logout tokens.
+type logoutTokenHandlerProvider interface {
+	// LogoutTokenHandler returns nil, if the mechanism is not configured to process logout tokens.
+	LogoutTokenHandler() backchannel.LogoutTokenHandler
+}
+
+// endpointProvider is implemented by mechanisms, which require endpoints to be served by
+// heimdall itself.
+type endpointProvider interface {
+	// Endpoints returns the required endpoints together with their handlers.
+	Endpoints() []callback.Endpoint
+}
+
+// stoppable is implemented by mechanisms, which hold resources to be released on shutdown.
+type stoppable interface {
+	Stop(ctx context.Context) error
+}
+
+func NewFactory(
+	conf *config.Configuration,
+	logger zerolog.Logger,
+	cch cache.Cache,
+	registry *backchannel.Registry,
+	endpoints *callback.Registry,
+) (Factory, error) {
 	logger.Info().Msg("Loading pipeline definitions")
 
-	repository, err := newPrototypeRepository(conf, logger)
+	repository, err := newPrototypeRepository(conf, logger, cch)
 	if err != nil {
 		logger.Error().Err(err).Msg("Failed loading pipeline definitions")
 
 		return nil, err
 	}
 
-	return &mechanismsFactory{r: repository}, nil
+	for _, authenticator := range repository.authenticators {
+		if provider, ok := authenticator.(logoutTokenHandlerProvider); ok {
+			if handler := provider.LogoutTokenHandler(); handler != nil {
+				registry.Register(handler)
+			}
+		}
+
+		if provider, ok := authenticator.(endpointProvider); ok {
+			for _, endpoint := range provider.Endpoints() {
+				if err = endpoints.Register(endpoint.URL, endpoint.Handler); err != nil {
+					logger.Error().Err(err).Msg("Failed registering endpoints")
+
+					return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+						"failed registering endpoints").CausedBy(err)
+				}
+			}
+		}
+	}
+
+	return &mechanismsFactory{r: repository, cacheKind: cache.KindOf(cch)}, nil
 }
 
 type mechanismsFactory struct {
-	r *prototypeRepository
+	r         *prototypeRepository
+	cacheKind cache.Kind
+}
+
+func (hf *mechanismsFactory) stop(ctx context.Context) error {
+	var errs []error
+
+	for _, authenticator := range hf.r.authenticators {
+		if mechanism, ok := authenticator.(stoppable); ok {
+			errs = append(errs, mechanism.Stop(ctx))
+		}
+	}
+
+	for _, authorizer := range hf.r.authorizers {
+		if mechanism, ok := authorizer.(stoppable); ok {
+			errs = append(errs, mechanism.Stop(ctx))
+		}
+	}
+
+	return errors.Join(errs...)
 }
 
 func (hf *mechanismsFactory) CreateAuthenticator(_, id string, conf config.MechanismConfig) (
@@ -59,6 +129,10 @@ func (hf *mechanismsFactory) CreateAuthenticator(_, id string, conf config.Mecha
 			return nil, errorchain.New(ErrAuthenticatorCreation).CausedBy(err)
 		}
 
+		if err = checkCacheDependency(id, authenticator, hf.cacheKind); err != nil {
+			return nil, errorchain.New(ErrAuthenticatorCreation).CausedBy(err)
+		}
+
 		return authenticator, nil
 	}
 
@@ -79,6 +153,10 @@ func (hf *mechanismsFactory) CreateAuthorizer(_, id string, conf config.Mechanis
 			return nil, errorchain.New(ErrAuthorizerCreation).CausedBy(err)
 		}
 
+		if err = checkCacheDependency(id, authorizer, hf.cacheKind); err != nil {
+			return nil, errorchain.New(ErrAuthorizerCreation).CausedBy(err)
+		}
+
 		return authorizer, nil
 	}
 
@@ -99,6 +177,10 @@ func (hf *mechanismsFactory) CreateContextualizer(_, id string, conf config.Mech
 			return nil, errorchain.New(ErrContextualizerCreation).CausedBy(err)
 		}
 
+		if err = checkCacheDependency(id, contextualizer, hf.cacheKind); err != nil {
+			return nil, errorchain.New(ErrContextualizerCreation).CausedBy(err)
+		}
+
 		return contextualizer, nil
 	}
 
@@ -119,6 +201,10 @@ func (hf *mechanismsFactory) CreateFinalizer(_, id string, conf config.Mechanism
 			return nil, errorchain.New(ErrFinalizerCreation).CausedBy(err)
 		}
 
+		if err = checkCacheDependency(id, finalizer, hf.cacheKind); err != nil {
+			return nil, errorchain.New(ErrFinalizerCreation).CausedBy(err)
+		}
+
 		return finalizer, nil
 	}
 
@@ -139,6 +225,10 @@ func (hf *mechanismsFactory) CreateErrorHandler(_, id string, conf config.Mechan
 			return nil, errorchain.New(ErrErrorHandlerCreation).CausedBy(err)
 		}
 
+		if err = checkCacheDependency(id, errorHandler, hf.cacheKind); err != nil {
+			return nil, errorchain.New(ErrErrorHandlerCreation).CausedBy(err)
+		}
+
 		return errorHandler, nil
 	}
 
@@ -17,6 +17,9 @@
 package mechanisms
 
 import (
+	"context"
+	"errors"
+	"net/url"
 	"testing"
 
 	"github.com/rs/zerolog/log"
@@ -24,6 +27,10 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	"github.com/dadrus/heimdall/internal/backchannel"
+	"github.com/dadrus/heimdall/internal/cache"
+	"github.com/dadrus/heimdall/internal/cache/memory"
+	"github.com/dadrus/heimdall/internal/callback"
 	"github.com/dadrus/heimdall/internal/config"
 	"github.com/dadrus/heimdall/internal/heimdall"
 	"github.com/dadrus/heimdall/internal/rules/mechanisms/authenticators"
@@ -524,6 +531,97 @@ func TestCreateHandlerFactory(t *testing.T) {
 				assert.Empty(t, factory.r.authorizers)
 			},
 		},
+		{
+			uc: "authenticator relying on a disabled cache",
+			conf: &config.Configuration{
+				Cache: config.CacheConfig{Type: "noop"},
+				Prototypes: &config.MechanismPrototypes{
+					Authenticators: []config.Mechanism{
+						{
+							ID:     "foo",
+							Type:   authenticators.AuthenticatorJwt,
+							Config: dpopJWTAuthenticatorConfig(),
+						},
+					},
+				},
+			},
+			assert: func(t *testing.T, err error, _ *mechanismsFactory) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "DPoP proof replay protection")
+			},
+		},
+		{
+			uc: "authenticator relying on a cache of an unsupported type",
+			conf: &config.Configuration{
+				Cache: config.CacheConfig{Type: "memcached"},
+				Prototypes: &config.MechanismPrototypes{
+					Authenticators: []config.Mechanism{
+						{
+							ID:     "foo",
+							Type:   authenticators.AuthenticatorJwt,
+							Config: dpopJWTAuthenticatorConfig(),
+						},
+					},
+				},
+			},
+			assert: func(t *testing.T, err error, _ *mechanismsFactory) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "DPoP proof replay protection")
+			},
+		},
+		{
+			uc: "authorizer relying on a disabled cache",
+			conf: &config.Configuration{
+				Cache: config.CacheConfig{Type: "noop"},
+				Prototypes: &config.MechanismPrototypes{
+					Authorizers: []config.Mechanism{
+						{
+							ID:   "foo",
+							Type: authorizers.AuthorizerRateLimit,
+							Config: map[string]any{
+								"key":    "{{ .Subject.ID }}",
+								"limit":  10,
+								"period": "1m",
+							},
+						},
+					},
+				},
+			},
+			assert: func(t *testing.T, err error, _ *mechanismsFactory) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "rate limiting")
+			},
+		},
+		{
+			uc: "authenticator relying on an in-memory cache",
+			conf: &config.Configuration{
+				Prototypes: &config.MechanismPrototypes{
+					Authenticators: []config.Mechanism{
+						{
+							ID:     "foo",
+							Type:   authenticators.AuthenticatorJwt,
+							Config: dpopJWTAuthenticatorConfig(),
+						},
+					},
+				},
+			},
+			assert: func(t *testing.T, err error, factory *mechanismsFactory) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, factory)
+				assert.Len(t, factory.r.authenticators, 1)
+			},
+		},
 		{
 			uc: "fails",
 			conf: &config.Configuration{
@@ -550,8 +648,11 @@ func TestCreateHandlerFactory(t *testing.T) {
 				ok   bool
 			)
 
+			cch, err := cache.NewCache(tc.conf, log.Logger)
+			require.NoError(t, err)
+
 			// WHEN
-			factory, err := NewFactory(tc.conf, log.Logger)
+			factory, err := NewFactory(tc.conf, log.Logger, cch, backchannel.NewRegistry(), callback.NewRegistry())
 
 			// THEN
 			if err == nil {
@@ -563,3 +664,180 @@ func TestCreateHandlerFactory(t *testing.T) {
 		})
 	}
 }
+
+func TestCreateHandlerFactoryRegistersLogoutTokenHandlers(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	registry := backchannel.NewRegistry()
+	conf := &config.Configuration{
+		Prototypes: &config.MechanismPrototypes{
+			Authenticators: []config.Mechanism{
+				{
+					ID:   "foo",
+					Type: authenticators.AuthenticatorJwt,
+					Config: map[string]any{
+						"jwks_endpoint": map[string]any{"url": "http://test.com"},
+						"assertions":    map[string]any{"issuers": []string{"foo"}},
+						"revocation": map[string]any{
+							"backchannel_logout": map[string]any{"audience": []string{"bar"}},
+						},
+					},
+				},
+				{
+					ID:   "bar",
+					Type: authenticators.AuthenticatorJwt,
+					Config: map[string]any{
+						"jwks_endpoint": map[string]any{"url": "http://test.com"},
+						"assertions":    map[string]any{"issuers": []string{"foo"}},
+					},
+				},
+			},
+		},
+	}
+
+	// WHEN
+	_, err := NewFactory(conf, log.Logger, memory.New(), registry, callback.NewRegistry())
+
+	// THEN
+	require.NoError(t, err)
+
+	err = registry.HandleLogoutToken(context.Background(), "foo.bar.baz")
+	require.Error(t, err)
+	require.NotErrorIs(t, err, backchannel.ErrNoLogoutTokenHandler)
+
+	var joined interface{ Unwrap() []error }
+	require.ErrorAs(t, err, &joined)
+	assert.Len(t, joined.Unwrap(), 1)
+
+	var identifier interface{ ID() string }
+	require.ErrorAs(t, err, &identifier)
+	assert.Equal(t, "foo", identifier.ID())
+}
+
+func TestCreateHandlerFactoryRegistersEndpoints(t *testing.T) {
+	t.Parallel()
+
+	oidcConfig := func() map[string]any {
+		return map[string]any{
+			"metadata_endpoint": map[string]any{"url": "http://test.com"},
+			"client":            map[string]any{"id": "foo"},
+			"redirect_uri":      "https://app.local/oauth2/callback",
+			"session":           map[string]any{"secret": "a-not-so-secret-secret-with-32-or-more-characters"},
+		}
+	}
+
+	for _, tc := range []struct {
+		uc     string
+		ids    []string
+		assert func(t *testing.T, err error, endpoints *callback.Registry)
+	}{
+		{
+			uc:  "single authenticator",
+			ids: []string{"foo"},
+			assert: func(t *testing.T, err error, endpoints *callback.Registry) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				_, ok := endpoints.Handler(&url.URL{Scheme: "https", Host: "app.local", Path: "/oauth2/callback"})
+				assert.True(t, ok)
+
+				_, ok = endpoints.Handler(&url.URL{Scheme: "https", Host: "other.local", Path: "/oauth2/callback"})
+				assert.False(t, ok)
+			},
+		},
+		{
+			uc:  "authenticators with conflicting endpoints",
+			ids: []string{"foo", "bar"},
+			assert: func(t *testing.T, err error, _ *callback.Registry) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				require.ErrorIs(t, err, callback.ErrEndpointAlreadyRegistered)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			endpoints := callback.NewRegistry()
+			conf := &config.Configuration{Prototypes: &config.MechanismPrototypes{}}
+
+			for _, id := range tc.ids {
+				conf.Prototypes.Authenticators = append(conf.Prototypes.Authenticators, config.Mechanism{
+					ID: id, Type: authenticators.AuthenticatorOIDC, Config: oidcConfig(),
+				})
+			}
+
+			// WHEN
+			_, err := NewFactory(conf, log.Logger, memory.New(), backchannel.NewRegistry(), endpoints)
+
+			// THEN
+			tc.assert(t, err, endpoints)
+		})
+	}
+}
+
+func dpopJWTAuthenticatorConfig() map[string]any {
+	return map[string]any{
+		"jwks_endpoint": map[string]any{"url": "http://test.com"},
+		"assertions":    map[string]any{"issuers": []string{"foo"}},
+		"dpop":          map[string]any{"required": true},
+	}
+}
+
+type stoppableAuthenticator struct {
+	authenticators.Authenticator
+
+	stopped bool
+	err     error
+}
+
+func (a *stoppableAuthenticator) Stop(_ context.Context) error {
+	a.stopped = true
+
+	return a.err
+}
+
+type stoppableAuthorizer struct {
+	authorizers.Authorizer
+
+	stopped bool
+}
+
+func (a *stoppableAuthorizer) Stop(_ context.Context) error {
+	a.stopped = true
+
+	return nil
+}
+
+func TestMechanismsFactoryStop(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	foo := &stoppableAuthenticator{}
+	bar := &stoppableAuthenticator{err: errors.New("test error")}
+	authz := &stoppableAuthorizer{}
+	factory := &mechanismsFactory{r: &prototypeRepository{
+		authenticators: map[string]authenticators.Authenticator{
+			"foo": foo,
+			"bar": bar,
+			"baz": mocks.NewAuthenticatorMock(t),
+		},
+		authorizers: map[string]authorizers.Authorizer{
+			"foo": authz,
+			"bar": mocks3.NewAuthorizerMock(t),
+		},
+	}}
+
+	// WHEN
+	err := factory.stop(context.Background())
+
+	// THEN
+	require.Error(t, err)
+	require.ErrorContains(t, err, "test error")
+	assert.True(t, foo.stopped)
+	assert.True(t, bar.stopped)
+	assert.True(t, authz.stopped)
+}
@@ -0,0 +1,86 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package forwarding
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/rs/zerolog"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+)
+
+type value struct {
+	name  string
+	value string
+}
+
+// Values holds the headers and cookies of a request, which are configured to be forwarded
+// to an endpoint. These are taken from the request up front, so that they can still be
+// used after the request has been completed, like by a refresh happening in the background.
+type Values struct {
+	headers []value
+	cookies []value
+}
+
+// Collect takes the given headers and cookies from the request of the given context.
+func Collect(ctx heimdall.Context, headers, cookies []string) *Values {
+	vals := &Values{
+		headers: make([]value, len(headers)),
+		cookies: make([]value, len(cookies)),
+	}
+
+	if len(headers) == 0 && len(cookies) == 0 {
+		return vals
+	}
+
+	req := ctx.Request()
+
+	for idx, name := range headers {
+		vals.headers[idx] = value{name: name, value: req.Header(name)}
+	}
+
+	for idx, name := range cookies {
+		vals.cookies[idx] = value{name: name, value: req.Cookie(name)}
+	}
+
+	return vals
+}
+
+// AddTo adds the collected headers and cookies to the given request.
+func (v *Values) AddTo(ctx context.Context, req *http.Request) {
+	logger := zerolog.Ctx(ctx)
+
+	for _, header := range v.headers {
+		if len(header.value) == 0 {
+			logger.Warn().Str("_header", header.name).
+				Msg("Header not present in the request but configured to be forwarded")
+		} else {
+			req.Header.Add(header.name, header.value)
+		}
+	}
+
+	for _, cookie := range v.cookies {
+		if len(cookie.value) == 0 {
+			logger.Warn().Str("_cookie", cookie.name).
+				Msg("Cookie not present in the request but configured to be forwarded")
+		} else {
+			req.AddCookie(&http.Cookie{Name: cookie.name, Value: cookie.value})
+		}
+	}
+}
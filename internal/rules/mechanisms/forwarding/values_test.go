@@ -0,0 +1,94 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package forwarding
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/heimdall/mocks"
+)
+
+func TestValues(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc             string
+		headers        []string
+		cookies        []string
+		configureMocks func(t *testing.T, ctx *mocks.ContextMock)
+		assert         func(t *testing.T, req *http.Request)
+	}{
+		{
+			uc: "nothing to forward",
+			assert: func(t *testing.T, req *http.Request) {
+				t.Helper()
+
+				assert.Empty(t, req.Header)
+			},
+		},
+		{
+			uc:      "headers and cookies to forward",
+			headers: []string{"X-Foo", "X-Bar"},
+			cookies: []string{"foo", "bar"},
+			configureMocks: func(t *testing.T, ctx *mocks.ContextMock) {
+				t.Helper()
+
+				reqf := mocks.NewRequestFunctionsMock(t)
+				reqf.EXPECT().Header("X-Foo").Return("foo")
+				reqf.EXPECT().Header("X-Bar").Return("")
+				reqf.EXPECT().Cookie("foo").Return("")
+				reqf.EXPECT().Cookie("bar").Return("bar")
+
+				ctx.EXPECT().Request().Return(&heimdall.Request{RequestFunctions: reqf})
+			},
+			assert: func(t *testing.T, req *http.Request) {
+				t.Helper()
+
+				assert.Equal(t, "foo", req.Header.Get("X-Foo"))
+				assert.Empty(t, req.Header.Values("X-Bar"))
+
+				cookies := req.Cookies()
+				require.Len(t, cookies, 1)
+				assert.Equal(t, "bar", cookies[0].Name)
+				assert.Equal(t, "bar", cookies[0].Value)
+			},
+		},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			// GIVEN
+			ctx := mocks.NewContextMock(t)
+			if tc.configureMocks != nil {
+				tc.configureMocks(t, ctx)
+			}
+
+			req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "http://foo.bar", nil)
+			require.NoError(t, err)
+
+			// WHEN
+			Collect(ctx, tc.headers, tc.cookies).AddTo(context.Background(), req)
+
+			// THEN
+			tc.assert(t, req)
+		})
+	}
+}
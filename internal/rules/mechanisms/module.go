@@ -17,9 +17,26 @@
 package mechanisms
 
 import (
+	"context"
+
 	"go.uber.org/fx"
 )
 
 var Module = fx.Options( //nolint:gochecknoglobals
-	fx.Provide(NewFactory),
+	fx.Provide(
+		fx.Annotate(
+			NewFactory,
+			fx.OnStop(func(ctx context.Context, factory Factory) error {
+				if lc, ok := factory.(lifecycle); ok {
+					return lc.stop(ctx)
+				}
+
+				return nil
+			}),
+		),
+	),
 )
+
+type lifecycle interface {
+	stop(ctx context.Context) error
+}
@@ -95,6 +95,9 @@ func (e *MetadataEndpoint) decodeResponse(resp *http.Response) (ServerMetadata,
 		Issuer                   string `json:"issuer"`
 		JWKSEndpointURL          string `json:"jwks_uri"`
 		IntrospectionEndpointURL string `json:"introspection_endpoint"`
+		AuthorizationEndpointURL string `json:"authorization_endpoint"`
+		TokenEndpointURL         string `json:"token_endpoint"`
+		EndSessionEndpointURL    string `json:"end_session_endpoint"`
 	}
 
 	var spec metadata
@@ -115,9 +118,21 @@ func (e *MetadataEndpoint) decodeResponse(resp *http.Response) (ServerMetadata,
 			"received introspection_endpoint contains a template, which is not allowed")
 	}
 
+	for name, value := range map[string]string{
+		"authorization_endpoint": spec.AuthorizationEndpointURL,
+		"token_endpoint":         spec.TokenEndpointURL,
+		"end_session_endpoint":   spec.EndSessionEndpointURL,
+	} {
+		if strings.Contains(value, "{{") && strings.Contains(value, "}}") {
+			return ServerMetadata{}, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+				"received %s contains a template, which is not allowed", name)
+		}
+	}
+
 	var (
 		jwksEP          *endpoint.Endpoint
 		introspectionEP *endpoint.Endpoint
+		tokenEP         *endpoint.Endpoint
 	)
 
 	if len(spec.JWKSEndpointURL) != 0 {
@@ -139,9 +154,23 @@ func (e *MetadataEndpoint) decodeResponse(resp *http.Response) (ServerMetadata,
 		}
 	}
 
+	if len(spec.TokenEndpointURL) != 0 {
+		tokenEP = &endpoint.Endpoint{
+			URL:    spec.TokenEndpointURL,
+			Method: http.MethodPost,
+			Headers: map[string]string{
+				"Content-Type": "application/x-www-form-urlencoded",
+				"Accept":       "application/json",
+			},
+		}
+	}
+
 	return ServerMetadata{
 		Issuer:                spec.Issuer,
 		JWKSEndpoint:          jwksEP,
 		IntrospectionEndpoint: introspectionEP,
+		AuthorizationEndpoint: spec.AuthorizationEndpointURL,
+		TokenEndpoint:         tokenEP,
+		EndSessionEndpoint:    spec.EndSessionEndpointURL,
 	}, nil
 }
@@ -13,6 +13,9 @@ type ServerMetadata struct {
 	Issuer                string
 	JWKSEndpoint          *endpoint.Endpoint
 	IntrospectionEndpoint *endpoint.Endpoint
+	AuthorizationEndpoint string
+	TokenEndpoint         *endpoint.Endpoint
+	EndSessionEndpoint    string
 }
 
 func (sm ServerMetadata) verify(usedMetadataURL string) error {
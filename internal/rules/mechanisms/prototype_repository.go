@@ -21,6 +21,7 @@ import (
 
 	"github.com/rs/zerolog"
 
+	"github.com/dadrus/heimdall/internal/cache"
 	"github.com/dadrus/heimdall/internal/config"
 	"github.com/dadrus/heimdall/internal/rules/mechanisms/authenticators"
 	"github.com/dadrus/heimdall/internal/rules/mechanisms/authorizers"
@@ -35,11 +36,12 @@ var ErrNoSuchPipelineObject = errors.New("pipeline object not found")
 func newPrototypeRepository(
 	conf *config.Configuration,
 	logger zerolog.Logger,
+	cch cache.Cache,
 ) (*prototypeRepository, error) {
 	logger.Debug().Msg("Loading definitions for authenticators")
 
 	authenticatorMap, err := createPipelineObjects(conf.Prototypes.Authenticators, logger,
-		authenticators.CreatePrototype)
+		cch, authenticators.CreatePrototype)
 	if err != nil {
 		logger.Error().Err(err).Msg("Failed loading authenticators definitions")
 
@@ -49,7 +51,7 @@ func newPrototypeRepository(
 	logger.Debug().Msg("Loading definitions for authorizers")
 
 	authorizerMap, err := createPipelineObjects(conf.Prototypes.Authorizers, logger,
-		authorizers.CreatePrototype)
+		cch, authorizers.CreatePrototype)
 	if err != nil {
 		logger.Error().Err(err).Msg("Failed loading authorizers definitions")
 
@@ -59,7 +61,7 @@ func newPrototypeRepository(
 	logger.Debug().Msg("Loading definitions for contextualizer")
 
 	contextualizerMap, err := createPipelineObjects(conf.Prototypes.Contextualizers, logger,
-		contextualizers.CreatePrototype)
+		cch, contextualizers.CreatePrototype)
 	if err != nil {
 		logger.Error().Err(err).Msg("Failed loading contextualizer definitions")
 
@@ -69,7 +71,7 @@ func newPrototypeRepository(
 	logger.Debug().Msg("Loading definitions for finalizers")
 
 	finalizerMap, err := createPipelineObjects(conf.Prototypes.Finalizers, logger,
-		finalizers.CreatePrototype)
+		cch, finalizers.CreatePrototype)
 	if err != nil {
 		logger.Error().Err(err).Msg("Failed loading finalizer definitions")
 
@@ -79,7 +81,7 @@ func newPrototypeRepository(
 	logger.Debug().Msg("Loading definitions for error handler")
 
 	ehMap, err := createPipelineObjects(conf.Prototypes.ErrorHandlers, logger,
-		errorhandlers.CreatePrototype)
+		cch, errorhandlers.CreatePrototype)
 	if err != nil {
 		logger.Error().Err(err).Msg("Failed loading error handler definitions")
 
@@ -98,6 +100,7 @@ func newPrototypeRepository(
 func createPipelineObjects[T any](
 	pObjects []config.Mechanism,
 	logger zerolog.Logger,
+	cch cache.Cache,
 	create func(id string, typ string, c map[string]any) (T, error),
 ) (map[string]T, error) {
 	objects := make(map[string]T)
@@ -109,11 +112,18 @@ func createPipelineObjects[T any](
 			pe.Config["if"] = pe.Condition
 		}
 
-		if r, err := create(pe.ID, pe.Type, pe.Config); err == nil {
-			objects[pe.ID] = r
-		} else {
+		r, err := create(pe.ID, pe.Type, pe.Config)
+		if err != nil {
 			return nil, err
 		}
+
+		if err = checkCacheDependency(pe.ID, r, cache.KindOf(cch)); err != nil {
+			return nil, err
+		}
+
+		warnAboutCacheDependency(pe.ID, r, cch, logger)
+
+		objects[pe.ID] = r
 	}
 
 	return objects, nil
@@ -54,45 +54,24 @@ type Config struct {
 }
 
 func (c *Config) Token(ctx context.Context) (*TokenInfo, error) {
-	logger := zerolog.Ctx(ctx)
-	cch := cache.Ctx(ctx)
-
-	var (
-		ok         bool
-		err        error
-		cacheKey   string
-		cacheEntry any
-		tokenInfo  *TokenInfo
-	)
-
-	if c.isCacheEnabled() {
-		cacheKey = c.calculateCacheKey()
-		cacheEntry = cch.Get(ctx, cacheKey)
-	}
+	load := func(ctx context.Context) (*TokenInfo, time.Duration, error) {
+		zerolog.Ctx(ctx).Debug().Msg("Requesting new access token")
 
-	if cacheEntry != nil {
-		if tokenInfo, ok = cacheEntry.(*TokenInfo); !ok {
-			logger.Warn().Msg("Wrong object type from cache")
-			cch.Delete(ctx, cacheKey)
-		} else {
-			logger.Debug().Msg("Reusing access token from cache")
+		tokenInfo, err := c.fetchToken(ctx)
+		if err != nil {
+			return nil, 0, err
 		}
-	}
 
-	if tokenInfo == nil {
-		logger.Debug().Msg("Requesting new access token")
+		return tokenInfo, c.getCacheTTL(tokenInfo), nil
+	}
 
-		tokenInfo, err = c.fetchToken(ctx)
-		if err != nil {
-			return nil, err
-		}
+	if !c.isCacheEnabled() {
+		tokenInfo, _, err := load(ctx)
 
-		if cacheTTL := c.getCacheTTL(tokenInfo); cacheTTL > 0 {
-			cch.Set(ctx, cacheKey, tokenInfo, cacheTTL)
-		}
+		return tokenInfo, err
 	}
 
-	return tokenInfo, nil
+	return cache.GetOrLoad(ctx, cache.Ctx(ctx), c.calculateCacheKey(), load)
 }
 
 func (c *Config) calculateCacheKey() string {
@@ -32,6 +32,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/dadrus/heimdall/internal/cache"
+	"github.com/dadrus/heimdall/internal/cache/encoding"
 	"github.com/dadrus/heimdall/internal/cache/mocks"
 	"github.com/dadrus/heimdall/internal/heimdall"
 	"github.com/dadrus/heimdall/internal/x"
@@ -661,3 +662,26 @@ func TestClientCredentialsHash(t *testing.T) {
 	assert.NotEmpty(t, hash2)
 	assert.NotEqual(t, hash1, hash2)
 }
+
+func TestTokenInfoCanBeCachedInSerializedForm(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	tokenInfo := (&TokenInfo{
+		AccessToken: "foo",
+		TokenType:   "Bearer",
+		Expiry:      time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Scopes:      []string{"bar", "baz"},
+	}).WithExtra(map[string]any{"foo": "bar"})
+
+	// WHEN
+	raw, err := encoding.Marshal(tokenInfo)
+	require.NoError(t, err)
+
+	res, err := encoding.Unmarshal(raw)
+
+	// THEN
+	require.NoError(t, err)
+	assert.Equal(t, tokenInfo, res)
+	assert.Equal(t, "bar", res.(*TokenInfo).Extra("foo")) //nolint:forcetypeassert
+}
@@ -19,8 +19,19 @@ package clientcredentials
 import (
 	"strings"
 	"time"
+
+	"github.com/goccy/go-json"
+
+	"github.com/dadrus/heimdall/internal/cache/encoding"
 )
 
+// by intention. Used only during application bootstrap
+//
+//nolint:gochecknoinits
+func init() {
+	encoding.RegisterType[*TokenInfo]("oauth2.token_info")
+}
+
 type TokenInfo struct {
 	AccessToken  string
 	RefreshToken string
@@ -41,6 +52,45 @@ func (t *TokenInfo) WithExtra(extra map[string]any) *TokenInfo {
 
 func (t *TokenInfo) Extra(key string) any { return t.raw[key] }
 
+type tokenInfoJSON struct {
+	AccessToken  string         `json:"access_token"`
+	RefreshToken string         `json:"refresh_token,omitempty"`
+	TokenType    string         `json:"token_type,omitempty"`
+	Expiry       time.Time      `json:"expiry"`
+	Scopes       []string       `json:"scopes,omitempty"`
+	Extra        map[string]any `json:"extra,omitempty"`
+}
+
+func (t *TokenInfo) MarshalJSON() ([]byte, error) {
+	return json.Marshal(tokenInfoJSON{
+		AccessToken:  t.AccessToken,
+		RefreshToken: t.RefreshToken,
+		TokenType:    t.TokenType,
+		Expiry:       t.Expiry,
+		Scopes:       t.Scopes,
+		Extra:        t.raw,
+	})
+}
+
+func (t *TokenInfo) UnmarshalJSON(data []byte) error {
+	var tij tokenInfoJSON
+
+	if err := json.Unmarshal(data, &tij); err != nil {
+		return err
+	}
+
+	*t = TokenInfo{
+		AccessToken:  tij.AccessToken,
+		RefreshToken: tij.RefreshToken,
+		TokenType:    tij.TokenType,
+		Expiry:       tij.Expiry,
+		Scopes:       tij.Scopes,
+		raw:          tij.Extra,
+	}
+
+	return nil
+}
+
 type TokenInfoResponse struct {
 	AccessToken  string `json:"access_token,omitempty"`
 	RefreshToken string `json:"refresh_token,omitempty"`
@@ -0,0 +1,312 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package rules
+
+import (
+	"context"
+	"crypto/x509"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/rules/mechanisms/subject"
+	"github.com/dadrus/heimdall/internal/x/slicex"
+)
+
+// parallelSubjectHandler executes the configured handlers concurrently. Each handler operates on its
+// own deep copy of the subject and records the headers and cookies it wants to add for the upstream
+// service. All these modifications are merged into the actual subject and context in the order the
+// handlers have been defined, after all of them have finished. If several handlers modify the same
+// top-level attribute, the modification of the later one wins.
+type parallelSubjectHandler []subjectHandler
+
+func (ph parallelSubjectHandler) Execute(ctx heimdall.Context, sub *subject.Subject) error {
+	logger := zerolog.Ctx(ctx.AppContext())
+
+	group, groupCtx := errgroup.WithContext(ctx.AppContext())
+	req := synchronizedRequest(ctx.Request())
+	steps := make([]*parallelStepContext, len(ph))
+	base := copyAttributes(sub.Attributes)
+
+	for idx, handler := range ph {
+		handler := handler
+		step := &parallelStepContext{
+			Context: ctx,
+			ctx:     groupCtx,
+			req:     req,
+			handler: handler,
+			sub:     &subject.Subject{ID: sub.ID, Attributes: copyAttributes(sub.Attributes)},
+		}
+		steps[idx] = step
+
+		group.Go(func() error {
+			err := handler.Execute(step, step.sub)
+			if err == nil {
+				return nil
+			}
+
+			logger.Info().Err(err).Str("_id", handler.ID()).Msg("Pipeline step execution failed")
+
+			if handler.ContinueOnError() {
+				logger.Info().Msg("Error ignored. Continuing pipeline execution")
+
+				return nil
+			}
+
+			return err
+		})
+	}
+
+	if err := group.Wait(); err != nil {
+		return err
+	}
+
+	modifiedBy := make(map[string]subjectHandler)
+
+	for _, step := range steps {
+		step.mergeInto(ctx, base, sub, modifiedBy)
+	}
+
+	return nil
+}
+
+func (ph parallelSubjectHandler) ID() string {
+	return "parallel(" + strings.Join(slicex.Map(ph, func(h subjectHandler) string { return h.ID() }), ",") + ")"
+}
+
+// ContinueOnError returns always false, as the errors of the particular handlers are already
+// taken care of in Execute.
+func (ph parallelSubjectHandler) ContinueOnError() bool { return false }
+
+type parallelStepContext struct {
+	heimdall.Context
+
+	ctx     context.Context //nolint:containedctx
+	req     *heimdall.Request
+	handler subjectHandler
+	sub     *subject.Subject
+	headers http.Header
+	cookies map[string]string
+}
+
+func (c *parallelStepContext) AppContext() context.Context { return c.ctx }
+
+func (c *parallelStepContext) Request() *heimdall.Request { return c.req }
+
+func (c *parallelStepContext) AddHeaderForUpstream(name, value string) {
+	if c.headers == nil {
+		c.headers = make(http.Header)
+	}
+
+	c.headers.Add(name, value)
+}
+
+func (c *parallelStepContext) AddCookieForUpstream(name, value string) {
+	if c.cookies == nil {
+		c.cookies = make(map[string]string)
+	}
+
+	c.cookies[name] = value
+}
+
+// SetPipelineError is a noop, as the error is anyway returned by the corresponding handler
+// and will be set by the rule execution logic.
+func (c *parallelStepContext) SetPipelineError(_ error) {}
+
+// mergeInto applies the modifications done by the step relative to the given base attributes, which
+// reflect the state of the subject before the execution of the parallel steps. Modifications are
+// detected on the level of top-level attributes only. The modifiedBy map keeps track of the steps,
+// which modified these, to log conflicting modifications.
+func (c *parallelStepContext) mergeInto(
+	ctx heimdall.Context, base map[string]any, sub *subject.Subject, modifiedBy map[string]subjectHandler,
+) {
+	for key, value := range c.sub.Attributes {
+		if old, present := base[key]; !present || !reflect.DeepEqual(old, value) {
+			if sub.Attributes == nil {
+				sub.Attributes = make(map[string]any)
+			}
+
+			c.recordModification(ctx, key, modifiedBy)
+			sub.Attributes[key] = value
+		}
+	}
+
+	for key := range base {
+		if _, present := c.sub.Attributes[key]; !present {
+			c.recordModification(ctx, key, modifiedBy)
+			delete(sub.Attributes, key)
+		}
+	}
+
+	for name, values := range c.headers {
+		for _, value := range values {
+			ctx.AddHeaderForUpstream(name, value)
+		}
+	}
+
+	for name, value := range c.cookies {
+		ctx.AddCookieForUpstream(name, value)
+	}
+}
+
+func (c *parallelStepContext) recordModification(
+	ctx heimdall.Context, key string, modifiedBy map[string]subjectHandler,
+) {
+	if previous, present := modifiedBy[key]; present {
+		zerolog.Ctx(ctx.AppContext()).Warn().
+			Str("_attribute", key).
+			Str("_overridden", previous.ID()).
+			Str("_id", c.handler.ID()).
+			Msg("Subject attribute modified by multiple parallel pipeline steps. Using the later modification")
+	}
+
+	modifiedBy[key] = c.handler
+}
+
+// copyAttributes creates a deep copy of the given attributes, so that parallel steps can modify
+// nested values without affecting each other.
+func copyAttributes(attributes map[string]any) map[string]any {
+	if attributes == nil {
+		return nil
+	}
+
+	res := make(map[string]any, len(attributes))
+	for key, value := range attributes {
+		res[key] = deepCopy(value)
+	}
+
+	return res
+}
+
+func deepCopy(value any) any {
+	switch val := value.(type) {
+	case map[string]any:
+		return copyAttributes(val)
+	case []any:
+		res := make([]any, len(val))
+		for idx, elem := range val {
+			res[idx] = deepCopy(elem)
+		}
+
+		return res
+	}
+
+	// other maps and slices, like map[string]string or []string, are rare, but possible
+	// if set by a mechanism directly
+	orig := reflect.ValueOf(value)
+
+	switch orig.Kind() { //nolint:exhaustive
+	case reflect.Map:
+		if orig.IsNil() {
+			return value
+		}
+
+		res := reflect.MakeMapWithSize(orig.Type(), orig.Len())
+		for iter := orig.MapRange(); iter.Next(); {
+			res.SetMapIndex(iter.Key(), deepCopyValue(iter.Value()))
+		}
+
+		return res.Interface()
+	case reflect.Slice:
+		if orig.IsNil() {
+			return value
+		}
+
+		res := reflect.MakeSlice(orig.Type(), orig.Len(), orig.Len())
+		for idx := 0; idx < orig.Len(); idx++ {
+			res.Index(idx).Set(deepCopyValue(orig.Index(idx)))
+		}
+
+		return res.Interface()
+	default:
+		return value
+	}
+}
+
+func deepCopyValue(value reflect.Value) reflect.Value {
+	copied := deepCopy(value.Interface())
+	if copied == nil {
+		return reflect.Zero(value.Type())
+	}
+
+	return reflect.ValueOf(copied)
+}
+
+// synchronizedRequest returns a copy of the given request, with access to the request functions
+// serialized, as the implementations of these are not safe for concurrent use.
+func synchronizedRequest(req *heimdall.Request) *heimdall.Request {
+	if req == nil || req.RequestFunctions == nil {
+		return req
+	}
+
+	clone := *req
+	clone.RequestFunctions = &synchronizedRequestFunctions{rf: req.RequestFunctions}
+
+	return &clone
+}
+
+type synchronizedRequestFunctions struct {
+	mut sync.Mutex
+	rf  heimdall.RequestFunctions
+}
+
+func (s *synchronizedRequestFunctions) Header(name string) string {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	return s.rf.Header(name)
+}
+
+func (s *synchronizedRequestFunctions) Cookie(name string) string {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	return s.rf.Cookie(name)
+}
+
+func (s *synchronizedRequestFunctions) Headers() map[string]string {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	return s.rf.Headers()
+}
+
+func (s *synchronizedRequestFunctions) Body() any {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	return s.rf.Body()
+}
+
+func (s *synchronizedRequestFunctions) RawBody() []byte {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	return s.rf.RawBody()
+}
+
+func (s *synchronizedRequestFunctions) ClientCertificates() []*x509.Certificate {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	return s.rf.ClientCertificates()
+}
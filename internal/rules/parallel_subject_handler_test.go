@@ -0,0 +1,314 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package rules
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/heimdall/mocks"
+	"github.com/dadrus/heimdall/internal/rules/mechanisms/subject"
+	rulemocks "github.com/dadrus/heimdall/internal/rules/mocks"
+)
+
+func TestParallelSubjectHandlerExecution(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc             string
+		configureMocks func(t *testing.T, ctx *mocks.ContextMock, first *rulemocks.SubjectHandlerMock,
+			second *rulemocks.SubjectHandlerMock)
+		assert func(t *testing.T, err error, sub *subject.Subject)
+	}{
+		{
+			uc: "all succeed and are executed concurrently",
+			configureMocks: func(t *testing.T, ctx *mocks.ContextMock, first *rulemocks.SubjectHandlerMock,
+				second *rulemocks.SubjectHandlerMock,
+			) {
+				t.Helper()
+
+				var wg sync.WaitGroup
+
+				wg.Add(2)
+
+				first.EXPECT().Execute(mock.Anything, mock.Anything).RunAndReturn(
+					func(ctx heimdall.Context, sub *subject.Subject) error {
+						wg.Done()
+						wg.Wait()
+
+						sub.Attributes["first"] = "foo"
+						ctx.AddHeaderForUpstream("X-First", "foo")
+						ctx.AddCookieForUpstream("first", "foo")
+
+						return nil
+					})
+				second.EXPECT().Execute(mock.Anything, mock.Anything).RunAndReturn(
+					func(ctx heimdall.Context, sub *subject.Subject) error {
+						wg.Done()
+						wg.Wait()
+
+						sub.Attributes["second"] = "bar"
+						delete(sub.Attributes, "initial")
+						ctx.AddHeaderForUpstream("X-Second", "bar")
+
+						return nil
+					})
+
+				ctx.EXPECT().AddHeaderForUpstream("X-First", "foo")
+				ctx.EXPECT().AddCookieForUpstream("first", "foo")
+				ctx.EXPECT().AddHeaderForUpstream("X-Second", "bar")
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, map[string]any{"first": "foo", "second": "bar"}, sub.Attributes)
+			},
+		},
+		{
+			uc: "later handler does not override modifications of an earlier one",
+			configureMocks: func(t *testing.T, _ *mocks.ContextMock, first *rulemocks.SubjectHandlerMock,
+				second *rulemocks.SubjectHandlerMock,
+			) {
+				t.Helper()
+
+				first.EXPECT().Execute(mock.Anything, mock.Anything).RunAndReturn(
+					func(_ heimdall.Context, sub *subject.Subject) error {
+						sub.Attributes["initial"] = "updated"
+
+						return nil
+					})
+				second.EXPECT().Execute(mock.Anything, mock.Anything).Return(nil)
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, map[string]any{"initial": "updated"}, sub.Attributes)
+			},
+		},
+		{
+			uc: "first fails without pipeline continuation and cancels the second one",
+			configureMocks: func(t *testing.T, _ *mocks.ContextMock, first *rulemocks.SubjectHandlerMock,
+				second *rulemocks.SubjectHandlerMock,
+			) {
+				t.Helper()
+
+				first.EXPECT().ID().Return("first")
+				first.EXPECT().Execute(mock.Anything, mock.Anything).Return(errors.New("first fails"))
+				first.EXPECT().ContinueOnError().Return(false)
+
+				second.EXPECT().ID().Return("second")
+				second.EXPECT().Execute(mock.Anything, mock.Anything).RunAndReturn(
+					func(ctx heimdall.Context, sub *subject.Subject) error {
+						<-ctx.AppContext().Done()
+
+						sub.Attributes["second"] = "bar"
+
+						return ctx.AppContext().Err()
+					})
+				second.EXPECT().ContinueOnError().Return(false)
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.Equal(t, "first fails", err.Error())
+				assert.Equal(t, map[string]any{"initial": "value"}, sub.Attributes)
+			},
+		},
+		{
+			uc: "first fails with pipeline continuation, second succeeds",
+			configureMocks: func(t *testing.T, _ *mocks.ContextMock, first *rulemocks.SubjectHandlerMock,
+				second *rulemocks.SubjectHandlerMock,
+			) {
+				t.Helper()
+
+				first.EXPECT().ID().Return("first")
+				first.EXPECT().Execute(mock.Anything, mock.Anything).Return(errors.New("first fails"))
+				first.EXPECT().ContinueOnError().Return(true)
+
+				second.EXPECT().Execute(mock.Anything, mock.Anything).RunAndReturn(
+					func(_ heimdall.Context, sub *subject.Subject) error {
+						sub.Attributes["second"] = "bar"
+
+						return nil
+					})
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, map[string]any{"initial": "value", "second": "bar"}, sub.Attributes)
+			},
+		},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			// GIVEN
+			sub := &subject.Subject{ID: "foo", Attributes: map[string]any{"initial": "value"}}
+
+			ctx := mocks.NewContextMock(t)
+			ctx.EXPECT().AppContext().Return(context.Background())
+			ctx.EXPECT().Request().Return(&heimdall.Request{RequestFunctions: mocks.NewRequestFunctionsMock(t)})
+
+			handler1 := rulemocks.NewSubjectHandlerMock(t)
+			handler2 := rulemocks.NewSubjectHandlerMock(t)
+			tc.configureMocks(t, ctx, handler1, handler2)
+
+			handler := parallelSubjectHandler{handler1, handler2}
+
+			// WHEN
+			err := handler.Execute(ctx, sub)
+
+			// THEN
+			tc.assert(t, err, sub)
+		})
+	}
+}
+
+func TestParallelSubjectHandlerMergesNestedModifications(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	var logs bytes.Buffer
+
+	sub := &subject.Subject{ID: "foo", Attributes: map[string]any{
+		"nested": map[string]any{"foo": "bar", "list": []any{"a"}},
+		"other":  map[string]any{"baz": "qux"},
+	}}
+
+	ctx := mocks.NewContextMock(t)
+	ctx.EXPECT().AppContext().Return(zerolog.New(&logs).WithContext(context.Background()))
+	ctx.EXPECT().Request().Return(&heimdall.Request{RequestFunctions: mocks.NewRequestFunctionsMock(t)})
+
+	first := rulemocks.NewSubjectHandlerMock(t)
+	first.EXPECT().Execute(mock.Anything, mock.Anything).RunAndReturn(
+		func(_ heimdall.Context, sub *subject.Subject) error {
+			nested := sub.Attributes["nested"].(map[string]any) //nolint:forcetypeassert
+			nested["foo"] = "changed"
+			nested["list"] = append(nested["list"].([]any), "b") //nolint:forcetypeassert
+
+			return nil
+		})
+
+	second := rulemocks.NewSubjectHandlerMock(t)
+	second.EXPECT().Execute(mock.Anything, mock.Anything).RunAndReturn(
+		func(_ heimdall.Context, sub *subject.Subject) error {
+			// the modification of the first handler is not visible here
+			nested := sub.Attributes["nested"].(map[string]any) //nolint:forcetypeassert
+			assert.Equal(t, "bar", nested["foo"])
+
+			sub.Attributes["other"].(map[string]any)["baz"] = "changed" //nolint:forcetypeassert
+
+			return nil
+		})
+
+	handler := parallelSubjectHandler{first, second}
+
+	// WHEN
+	err := handler.Execute(ctx, sub)
+
+	// THEN
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"nested": map[string]any{"foo": "changed", "list": []any{"a", "b"}},
+		"other":  map[string]any{"baz": "changed"},
+	}, sub.Attributes)
+	assert.Empty(t, logs.String())
+}
+
+func TestParallelSubjectHandlerLogsConflictingModifications(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	var logs bytes.Buffer
+
+	sub := &subject.Subject{ID: "foo", Attributes: map[string]any{"initial": "value"}}
+
+	ctx := mocks.NewContextMock(t)
+	ctx.EXPECT().AppContext().Return(zerolog.New(&logs).WithContext(context.Background()))
+	ctx.EXPECT().Request().Return(&heimdall.Request{RequestFunctions: mocks.NewRequestFunctionsMock(t)})
+
+	first := rulemocks.NewSubjectHandlerMock(t)
+	first.EXPECT().ID().Return("first")
+	first.EXPECT().Execute(mock.Anything, mock.Anything).RunAndReturn(
+		func(_ heimdall.Context, sub *subject.Subject) error {
+			sub.Attributes["initial"] = "first"
+
+			return nil
+		})
+
+	second := rulemocks.NewSubjectHandlerMock(t)
+	second.EXPECT().ID().Return("second")
+	second.EXPECT().Execute(mock.Anything, mock.Anything).RunAndReturn(
+		func(_ heimdall.Context, sub *subject.Subject) error {
+			delete(sub.Attributes, "initial")
+
+			return nil
+		})
+
+	handler := parallelSubjectHandler{first, second}
+
+	// WHEN
+	err := handler.Execute(ctx, sub)
+
+	// THEN
+	require.NoError(t, err)
+	assert.Empty(t, sub.Attributes)
+	assert.Contains(t, logs.String(), "modified by multiple parallel pipeline steps")
+	assert.Contains(t, logs.String(), `"_attribute":"initial"`)
+	assert.Contains(t, logs.String(), `"_overridden":"first"`)
+	assert.Contains(t, logs.String(), `"_id":"second"`)
+}
+
+func TestCopyAttributes(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	orig := map[string]any{
+		"map":     map[string]any{"list": []any{map[string]any{"foo": "bar"}}},
+		"strings": []string{"a", "b"},
+		"extra":   map[string][]string{"foo": {"bar"}},
+		"nil":     nil,
+		"nilMap":  map[string]string(nil),
+		"scalar":  42,
+	}
+
+	// WHEN
+	res := copyAttributes(orig)
+
+	// THEN
+	assert.Equal(t, orig, res)
+
+	res["map"].(map[string]any)["list"].([]any)[0].(map[string]any)["foo"] = "baz" //nolint:forcetypeassert
+	res["strings"].([]string)[0] = "c"                                             //nolint:forcetypeassert
+	res["extra"].(map[string][]string)["foo"][0] = "baz"                           //nolint:forcetypeassert
+
+	assert.Equal(t, "bar", orig["map"].(map[string]any)["list"].([]any)[0].(map[string]any)["foo"])
+	assert.Equal(t, []string{"a", "b"}, orig["strings"])
+	assert.Equal(t, map[string][]string{"foo": {"bar"}}, orig["extra"])
+	assert.Nil(t, copyAttributes(nil))
+}
@@ -19,28 +19,73 @@ package patternmatcher
 import (
 	"bytes"
 	"errors"
+	"regexp"
+	"strings"
 
 	"github.com/gobwas/glob"
+	"github.com/gobwas/glob/syntax"
+	"github.com/gobwas/glob/syntax/ast"
+
+	"github.com/dadrus/heimdall/internal/x"
 )
 
 var (
-	ErrUnbalancedPattern    = errors.New("unbalanced pattern")
-	ErrNoGlobPatternDefined = errors.New("no glob pattern defined")
+	ErrUnbalancedPattern         = errors.New("unbalanced pattern")
+	ErrNoGlobPatternDefined      = errors.New("no glob pattern defined")
+	ErrUnsupportedGlobExpression = errors.New("unsupported glob expression")
 )
 
+// globMatcher uses a regular expression translated from the glob pattern instead of
+// the compiled glob, if the pattern contains named segments (like "<:id>"), as globs
+// do not support captures.
 type globMatcher struct {
 	compiled glob.Glob
+	capturer *regexp.Regexp
 }
 
 func (m *globMatcher) Match(value string) bool {
+	if m.capturer != nil {
+		return m.capturer.MatchString(value)
+	}
+
 	return m.compiled.Match(value)
 }
 
+func (m *globMatcher) Captures(value string) map[string]string {
+	if m.capturer == nil {
+		return nil
+	}
+
+	match := m.capturer.FindStringSubmatch(value)
+	if match == nil {
+		return nil
+	}
+
+	captures := make(map[string]string)
+
+	for idx, name := range m.capturer.SubexpNames() {
+		if len(name) != 0 {
+			captures[name] = match[idx]
+		}
+	}
+
+	return captures
+}
+
 func newGlobMatcher(pattern string) (*globMatcher, error) {
 	if len(pattern) == 0 {
 		return nil, ErrNoGlobPatternDefined
 	}
 
+	if expandNamedSegments(pattern, func(string) string { return "" }) != pattern {
+		capturer, err := compileGlobToRegexp(pattern, '<', '>')
+		if err != nil {
+			return nil, err
+		}
+
+		return &globMatcher{capturer: capturer}, nil
+	}
+
 	compiled, err := compileGlob(pattern, '<', '>')
 	if err != nil {
 		return nil, err
@@ -77,6 +122,116 @@ func compileGlob(pattern string, delimiterStart, delimiterEnd rune) (glob.Glob,
 	return glob.Compile(buffer.String(), '.', '/')
 }
 
+// compileGlobToRegexp translates the given glob pattern into a regular expression, with
+// named segments translated into named groups matching a single non-empty path segment.
+// All other wildcard expressions are parsed by the same parser used by compileGlob to
+// ensure both accept the same syntax and match the same values.
+func compileGlobToRegexp(pattern string, delimiterStart, delimiterEnd rune) (*regexp.Regexp, error) {
+	idxs, err := delimiterIndices(pattern, delimiterStart, delimiterEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	buffer := bytes.NewBufferString("(?s)^")
+
+	var end int
+	for ind := 0; ind < len(idxs); ind += 2 {
+		raw := pattern[end:idxs[ind]]
+		end = idxs[ind+1]
+		patt := pattern[idxs[ind]+1 : end-1]
+
+		buffer.WriteString(regexp.QuoteMeta(raw))
+
+		if name, ok := namedSegment(patt); ok {
+			buffer.WriteString("(?P<" + name + ">[^/]+)")
+
+			continue
+		}
+
+		tree, err := syntax.Parse(patt)
+		if err != nil {
+			return nil, err
+		}
+
+		if err = writeGlobAsRegexp(buffer, tree); err != nil {
+			return nil, err
+		}
+	}
+
+	buffer.WriteString(regexp.QuoteMeta(pattern[end:]))
+	buffer.WriteString("$")
+
+	return regexp.Compile(buffer.String())
+}
+
+// writeGlobAsRegexp writes the regular expression equivalent of the given glob syntax tree,
+// using '.' and '/' as separators, like done by compileGlob.
+//
+//nolint:cyclop
+func writeGlobAsRegexp(buffer *bytes.Buffer, node *ast.Node) error {
+	switch node.Kind {
+	case ast.KindNothing:
+	case ast.KindPattern:
+		for _, child := range node.Children {
+			if err := writeGlobAsRegexp(buffer, child); err != nil {
+				return err
+			}
+		}
+	case ast.KindAnyOf:
+		buffer.WriteString("(?:")
+
+		for idx, child := range node.Children {
+			if idx != 0 {
+				buffer.WriteString("|")
+			}
+
+			if err := writeGlobAsRegexp(buffer, child); err != nil {
+				return err
+			}
+		}
+
+		buffer.WriteString(")")
+	case ast.KindText:
+		text, _ := node.Value.(ast.Text)
+
+		buffer.WriteString(regexp.QuoteMeta(text.Text))
+	case ast.KindList:
+		list, _ := node.Value.(ast.List)
+
+		buffer.WriteString(x.IfThenElse(list.Not, "[^", "["))
+
+		for _, chr := range list.Chars {
+			buffer.WriteString(quoteClassChar(chr))
+		}
+
+		buffer.WriteString("]")
+	case ast.KindRange:
+		rng, _ := node.Value.(ast.Range)
+
+		buffer.WriteString(x.IfThenElse(rng.Not, "[^", "["))
+		buffer.WriteString(quoteClassChar(rng.Lo) + "-" + quoteClassChar(rng.Hi))
+		buffer.WriteString("]")
+	case ast.KindAny:
+		buffer.WriteString("[^./]*")
+	case ast.KindSuper:
+		buffer.WriteString(".*")
+	case ast.KindSingle:
+		buffer.WriteString("[^./]")
+	default:
+		return ErrUnsupportedGlobExpression
+	}
+
+	return nil
+}
+
+func quoteClassChar(chr rune) string {
+	if strings.ContainsRune(`\]^-[`, chr) {
+		return `\` + string(chr)
+	}
+
+	return string(chr)
+}
+
 // delimiterIndices returns the first level delimiter indices from a string.
 // It returns an error in case of unbalanced delimiters.
 func delimiterIndices(value string, delimiterStart, delimiterEnd rune) ([]int, error) {
@@ -183,3 +183,41 @@ func TestIsMatch(t *testing.T) {
 		})
 	}
 }
+
+func TestCompileGlobToRegexpParity(t *testing.T) {
+	t.Parallel()
+
+	// the empty value is not used, as gobwas/glob matches it against patterns consisting of a
+	// single character matcher only (like "?"), which is not the case if preceded by a literal
+	values := []string{
+		"a", "b", "z", "^", "-", "]", "\\", ".", "/", ",", "{", "}", "ab", "a.b", "a/b", "aa", "abc",
+		"a\nb", "ü", "foo", "foo.bar", "foo/bar", "foo.bar/baz", "foo/bar/baz", "foo,bar", "foo{bar}",
+		"http://foo.bar/baz", "https://foo.bar/baz/", "http://foo.bar.baz/a/b/c", "urn:foo:user",
+	}
+
+	for _, pattern := range []string{
+		"<*>", "<**>", "<?>", "<a?>", "<?.?>", "<*/*>", "<**/*>", "<*.*>",
+		"<[abc]>", "<[!abc]>", "<[^a]>", "<[a-z]>", "<[!a-z]>", "<[a\\]]>", "<[\\\\]>", "<[-a]>", "<[a-]>",
+		"<[]>", "<[!]>", "<[a-z0-9]>", "<[a>", "<[.]>", "<[!.]>", "<[/]>", "<[ü]>",
+		"<{}>", "<{a}>", "<{a,b}>", "<a{,b}>", "<{a,{b,c}}>", "<{a*,b?}>", "<{foo,foo.bar}/*>", "<{a>",
+		"<a}>", "<a,b>", "<\\*>", "<\\?>", "<\\{a,b\\}>", "<a\\>", "foo<.>bar", "foo<\\>>",
+		"<{http,https}>://<*>.bar/<**>", "http://foo.bar/<*>", "http://foo.bar/<**>", "urn:foo:<*>",
+		"<*>{a,b}<*>", "*.<*>", "[a]<*>", "a<?>", "a<[!b]>", "a<[!b-c]>",
+	} {
+		t.Run(pattern, func(t *testing.T) {
+			compiled, globErr := compileGlob(pattern, '<', '>')
+			translated, regexErr := compileGlobToRegexp(pattern, '<', '>')
+
+			require.Equal(t, globErr == nil, regexErr == nil, "glob error: %v, regexp error: %v", globErr, regexErr)
+
+			if globErr != nil {
+				return
+			}
+
+			for _, value := range values {
+				assert.Equal(t, compiled.Match(value), translated.MatchString(value),
+					"value: %q, regexp: %s", value, translated)
+			}
+		})
+	}
+}
@@ -18,12 +18,17 @@ package patternmatcher
 
 import (
 	"errors"
+	"strings"
 )
 
 var ErrUnsupportedPatternMatcher = errors.New("unsupported pattern matcher")
 
 type PatternMatcher interface {
 	Match(value string) bool
+	// Captures returns the values captured by the named expressions of the pattern, if
+	// the given value matches it. Otherwise, or if the pattern does not define any named
+	// expressions, nil is returned.
+	Captures(value string) map[string]string
 }
 
 func NewPatternMatcher(typ, pattern string) (PatternMatcher, error) {
@@ -36,3 +41,85 @@ func NewPatternMatcher(typ, pattern string) (PatternMatcher, error) {
 		return nil, ErrUnsupportedPatternMatcher
 	}
 }
+
+// LiteralPrefix returns the part of the given pattern preceding the first
+// wildcard expression, which every value matched by the pattern must start with.
+func LiteralPrefix(pattern string) string {
+	if idx := strings.IndexByte(pattern, '<'); idx != -1 {
+		return pattern[:idx]
+	}
+
+	return pattern
+}
+
+// LiteralLength returns the number of characters of the given pattern, which are not
+// part of any wildcard expression. It serves as a measure of the pattern specificity.
+func LiteralLength(pattern string) int {
+	idxs, err := delimiterIndices(pattern, '<', '>')
+	if err != nil {
+		return len(LiteralPrefix(pattern))
+	}
+
+	length := len(pattern)
+	for idx := 0; idx < len(idxs); idx += 2 {
+		length -= idxs[idx+1] - idxs[idx]
+	}
+
+	return length
+}
+
+// expandNamedSegments replaces the named segments, like "<:id>", of the given pattern
+// with the result of the expand function. A named segment is a wildcard expression
+// consisting solely of a colon followed by the name. All other parts of the pattern,
+// including colons in its literal parts, are left untouched.
+func expandNamedSegments(pattern string, expand func(name string) string) string {
+	idxs, err := delimiterIndices(pattern, '<', '>')
+	if err != nil {
+		return pattern
+	}
+
+	var (
+		buf strings.Builder
+		end int
+	)
+
+	for idx := 0; idx < len(idxs); idx += 2 {
+		buf.WriteString(pattern[end:idxs[idx]])
+
+		if name, ok := namedSegment(pattern[idxs[idx]+1 : idxs[idx+1]-1]); ok {
+			buf.WriteString(expand(name))
+		} else {
+			buf.WriteString(pattern[idxs[idx]:idxs[idx+1]])
+		}
+
+		end = idxs[idx+1]
+	}
+
+	buf.WriteString(pattern[end:])
+
+	return buf.String()
+}
+
+// namedSegment returns the name of the named segment, if the given wildcard expression
+// (without delimiters) is one. The name must start with a letter or an underscore,
+// optionally followed by letters, digits and underscores.
+func namedSegment(expr string) (string, bool) {
+	name, found := strings.CutPrefix(expr, ":")
+	if !found || len(name) == 0 || !isNameStart(name[0]) {
+		return "", false
+	}
+
+	for idx := 1; idx < len(name); idx++ {
+		if !isNameChar(name[idx]) {
+			return "", false
+		}
+	}
+
+	return name, true
+}
+
+func isNameStart(chr byte) bool {
+	return chr == '_' || (chr >= 'a' && chr <= 'z') || (chr >= 'A' && chr <= 'Z')
+}
+
+func isNameChar(chr byte) bool { return isNameStart(chr) || (chr >= '0' && chr <= '9') }
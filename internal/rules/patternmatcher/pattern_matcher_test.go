@@ -0,0 +1,186 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package patternmatcher
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLiteralPrefix(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		pattern string
+		prefix  string
+	}{
+		{pattern: "", prefix: ""},
+		{pattern: "<**>", prefix: ""},
+		{pattern: "http://foo.bar/baz", prefix: "http://foo.bar/baz"},
+		{pattern: "http://foo.bar/<*>/baz", prefix: "http://foo.bar/"},
+		{pattern: "<{http,https}>://foo.bar/baz", prefix: ""},
+		{pattern: "https://<*>.foo.bar/<**>", prefix: "https://"},
+		{pattern: "http://foo.bar/users/<:id>/orders", prefix: "http://foo.bar/users/"},
+		{pattern: "http://foo.bar:8080/users/:id", prefix: "http://foo.bar:8080/users/:id"},
+	} {
+		t.Run(tc.pattern, func(t *testing.T) {
+			assert.Equal(t, tc.prefix, LiteralPrefix(tc.pattern))
+		})
+	}
+}
+
+func TestLiteralLength(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		pattern string
+		length  int
+	}{
+		{pattern: "", length: 0},
+		{pattern: "<**>", length: 0},
+		{pattern: "http://foo.bar/baz", length: 18},
+		{pattern: "http://foo.bar/<*>/baz", length: 19},
+		{pattern: "<{http,https}>://foo.bar/<**>", length: 11},
+		{pattern: "http://foo.bar/<*", length: 15},
+		{pattern: "http://foo.bar/users/<:id>/orders/<:orderId>", length: 29},
+		{pattern: "http://foo.bar/users/:id", length: 24},
+	} {
+		t.Run(tc.pattern, func(t *testing.T) {
+			assert.Equal(t, tc.length, LiteralLength(tc.pattern))
+		})
+	}
+}
+
+func TestPatternMatcherCaptures(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc       string
+		strategy string
+		pattern  string
+		value    string
+		matches  bool
+		captures map[string]string
+	}{
+		{
+			uc:       "glob without named segments",
+			strategy: "glob",
+			pattern:  "http://foo.bar/users/<*>",
+			value:    "http://foo.bar/users/1",
+			matches:  true,
+		},
+		{
+			uc:       "glob with named segments",
+			strategy: "glob",
+			pattern:  "http://foo.bar/users/<:id>/orders/<:orderId>",
+			value:    "http://foo.bar/users/1.2/orders/foo",
+			matches:  true,
+			captures: map[string]string{"id": "1.2", "orderId": "foo"},
+		},
+		{
+			uc:       "glob with named segments and wildcards",
+			strategy: "glob",
+			pattern:  "<{http,https}>://<*>.bar/<**>/<:id>/<[!0-9]?>",
+			value:    "https://foo.bar/a/b/c/zz",
+			matches:  true,
+			captures: map[string]string{"id": "c"},
+		},
+		{
+			uc:       "glob with named segment not matching multiple path segments",
+			strategy: "glob",
+			pattern:  "http://foo.bar/users/<:id>",
+			value:    "http://foo.bar/users/1/orders",
+		},
+		{
+			uc:       "glob with named segment not matching empty path segment",
+			strategy: "glob",
+			pattern:  "http://foo.bar/users/<:id>/orders",
+			value:    "http://foo.bar/users//orders",
+		},
+		{
+			uc:       "glob with named segment and wildcard not crossing separators",
+			strategy: "glob",
+			pattern:  "http://foo.bar/<*>/<:id>",
+			value:    "http://foo.bar/a.b/1",
+		},
+		{
+			uc:       "glob with literal colon segment",
+			strategy: "glob",
+			pattern:  "http://foo.bar/users/:id",
+			value:    "http://foo.bar/users/:id",
+			matches:  true,
+		},
+		{
+			uc:       "glob with literal colon segment not matching other values",
+			strategy: "glob",
+			pattern:  "http://foo.bar/users/:id",
+			value:    "http://foo.bar/users/1",
+		},
+		{
+			uc:       "glob with wildcard expression not being a named segment",
+			strategy: "glob",
+			pattern:  "http://foo.bar/users/<{:id,:1d}>",
+			value:    "http://foo.bar/users/:1d",
+			matches:  true,
+		},
+		{
+			uc:       "regex with named segments",
+			strategy: "regex",
+			pattern:  "http://foo.bar/users/<:id>/orders/<:orderId>",
+			value:    "http://foo.bar/users/1/orders/2",
+			matches:  true,
+			captures: map[string]string{"id": "1", "orderId": "2"},
+		},
+		{
+			uc:       "regex with literal colon segment",
+			strategy: "regex",
+			pattern:  "http://foo.bar/users/:id",
+			value:    "http://foo.bar/users/:id",
+			matches:  true,
+		},
+		{
+			uc:       "regex with named groups",
+			strategy: "regex",
+			pattern:  "http://foo.bar/users/<(?P<id>[0-9]+)>/<(.*)>",
+			value:    "http://foo.bar/users/12/foo",
+			matches:  true,
+			captures: map[string]string{"id": "12"},
+		},
+		{
+			uc:       "regex with named groups not matching",
+			strategy: "regex",
+			pattern:  "http://foo.bar/users/<(?P<id>[0-9]+)>",
+			value:    "http://foo.bar/users/foo",
+		},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			// GIVEN
+			matcher, err := NewPatternMatcher(tc.strategy, tc.pattern)
+			require.NoError(t, err)
+
+			// WHEN
+			matches := matcher.Match(tc.value)
+			captures := matcher.Captures(tc.value)
+
+			// THEN
+			assert.Equal(t, tc.matches, matches)
+			assert.Equal(t, tc.captures, captures)
+		})
+	}
+}
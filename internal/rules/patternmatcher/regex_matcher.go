@@ -18,6 +18,7 @@ package patternmatcher
 
 import (
 	"errors"
+	"strconv"
 
 	"github.com/dlclark/regexp2"
 	"github.com/ory/ladon/compiler"
@@ -34,6 +35,8 @@ func newRegexMatcher(pattern string) (*regexpMatcher, error) {
 		return nil, ErrNoRegexPatternDefined
 	}
 
+	pattern = expandNamedSegments(pattern, func(name string) string { return "<(?P<" + name + ">[^/]+)>" })
+
 	compiled, err := compiler.CompileRegex(pattern, '<', '>')
 	if err != nil {
 		return nil, err
@@ -48,3 +51,28 @@ func (m *regexpMatcher) Match(matchAgainst string) bool {
 
 	return ok
 }
+
+func (m *regexpMatcher) Captures(value string) map[string]string {
+	// ignoring error as it will be set on timeouts, which basically is the same as match miss
+	match, _ := m.compiled.FindStringMatch(value)
+	if match == nil {
+		return nil
+	}
+
+	var captures map[string]string
+
+	for _, name := range m.compiled.GetGroupNames() {
+		if _, err := strconv.Atoi(name); err == nil {
+			// unnamed group
+			continue
+		}
+
+		if captures == nil {
+			captures = make(map[string]string)
+		}
+
+		captures[name] = match.GroupByName(name).String()
+	}
+
+	return captures
+}
@@ -141,7 +141,7 @@ func verifyRuleSetList(t *testing.T, rls *RuleSetList) {
 		Host:     "bar.foo:8888",
 		Path:     "/foo/bar",
 		RawQuery: url.Values{"boo": []string{"foo"}, "foo": []string{"bar"}}.Encode(),
-	}).String())
+	}, nil).String())
 	assert.Len(t, rule.Execute, 2)
 	assert.Equal(t, "test_authn", rule.Execute[0]["authenticator"])
 	assert.Equal(t, "test_authz", rule.Execute[1]["authorizer"])
@@ -27,7 +27,9 @@ func decodeConfig(input any, output any) error {
 		&mapstructure.DecoderConfig{
 			DecodeHook: mapstructure.ComposeDecodeHookFunc(
 				config.DecodeTLSMinVersionHookFunc,
+				config.DecodeClientAuthModeHookFunc,
 				config.DecodeTLSCipherSuiteHookFunc,
+				mapstructure.StringToTimeDurationHookFunc(),
 			),
 			Result:      output,
 			ErrorUnused: true,
@@ -19,11 +19,12 @@ package rules
 import (
 	"bytes"
 	"context"
-	"net/url"
+	"slices"
 	"sync"
 
 	"github.com/rs/zerolog"
 
+	"github.com/dadrus/heimdall/internal/config"
 	"github.com/dadrus/heimdall/internal/heimdall"
 	"github.com/dadrus/heimdall/internal/rules/event"
 	"github.com/dadrus/heimdall/internal/rules/rule"
@@ -35,45 +36,85 @@ import (
 func newRepository(
 	queue event.RuleSetChangedEventQueue,
 	ruleFactory rule.Factory,
+	conf *config.Configuration,
 	logger zerolog.Logger,
 ) *repository {
 	return &repository{
+		allowHeader: conf.Rules.AllowHeader,
 		dr: x.IfThenElseExec(ruleFactory.HasDefaultRule(),
 			func() rule.Rule { return ruleFactory.DefaultRule() },
 			func() rule.Rule { return nil }),
 		logger: logger,
+		index:  newRuleIndex(),
 		queue:  queue,
 		quit:   make(chan bool),
 	}
 }
 
 type repository struct {
-	dr     rule.Rule
-	logger zerolog.Logger
+	dr          rule.Rule
+	logger      zerolog.Logger
+	allowHeader bool
 
 	rules []rule.Rule
+	index *ruleIndex
 	mutex sync.RWMutex
 
 	queue event.RuleSetChangedEventQueue
 	quit  chan bool
 }
 
-func (r *repository) FindRule(requestURL *url.URL) (rule.Rule, error) {
+// FindRule returns the first rule matching the given request, including its method.
+// If there are rules matching the request, but none of them accepts its method, an
+// ErrMethodNotAllowed error is returned. If the Allow header is enabled, it is caused by
+// a MethodNotAllowedError listing the methods accepted by these rules. The default rule,
+// if configured, is only used if no rule matches the request at all.
+func (r *repository) FindRule(req *heimdall.Request) (rule.Rule, error) {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
-	for _, rul := range r.rules {
-		if rul.MatchesURL(requestURL) {
-			return rul, nil
+	var (
+		matched bool
+		allowed []string
+	)
+
+	for _, entry := range r.index.candidates(&req.URL.URL) {
+		if !entry.rul.Matches(req) {
+			continue
+		}
+
+		if entry.rul.MatchesMethod(req.Method) {
+			return entry.rul, nil
+		}
+
+		matched = true
+		allowed = append(allowed, entry.rul.AllowedMethods()...)
+	}
+
+	if !matched && r.dr != nil {
+		if r.dr.MatchesMethod(req.Method) {
+			return r.dr, nil
 		}
+
+		matched = true
+		allowed = append(allowed, r.dr.AllowedMethods()...)
 	}
 
-	if r.dr != nil {
-		return r.dr, nil
+	if matched {
+		err := errorchain.NewWithMessagef(heimdall.ErrMethodNotAllowed,
+			"no rule accepting %s method found for %s", req.Method, req.URL.String())
+
+		if !r.allowHeader {
+			return nil, err
+		}
+
+		slices.Sort(allowed)
+
+		return nil, err.CausedBy(&heimdall.MethodNotAllowedError{AllowedMethods: slices.Compact(allowed)})
 	}
 
 	return nil, errorchain.NewWithMessagef(heimdall.ErrNoRuleFound,
-		"no applicable rule found for %s", requestURL.String())
+		"no applicable rule found for %s", req.URL.String())
 }
 
 func (r *repository) Start(_ context.Context) error {
@@ -220,6 +261,7 @@ func (r *repository) deleteRuleSet(srcID string) {
 func (r *repository) addRules(rules []rule.Rule) {
 	for _, rul := range rules {
 		r.rules = append(r.rules, rul)
+		r.index.add(rul)
 
 		r.logger.Debug().Str("_src", rul.SrcID()).Str("_id", rul.ID()).Msg("Rule added")
 	}
@@ -233,6 +275,7 @@ func (r *repository) removeRules(rules []rule.Rule) {
 		for _, tbd := range rules {
 			if rul.ID() == tbd.ID() {
 				idxs = append(idxs, idx)
+				r.index.remove(rul)
 
 				r.logger.Debug().Str("_src", rul.SrcID()).Str("_id", rul.ID()).Msg("Rule removed")
 			}
@@ -267,6 +310,7 @@ func (r *repository) replaceRules(rules []rule.Rule) {
 		for idx, existing := range r.rules {
 			if existing.ID() == updated.ID() {
 				r.rules[idx] = updated
+				r.index.replace(existing, updated)
 
 				r.logger.Debug().
 					Str("_src", existing.SrcID()).
@@ -18,6 +18,9 @@ package rules
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/http"
 	"net/url"
 	"testing"
 	"time"
@@ -27,7 +30,9 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/dadrus/heimdall/internal/config"
 	"github.com/dadrus/heimdall/internal/heimdall"
+	config2 "github.com/dadrus/heimdall/internal/rules/config"
 	"github.com/dadrus/heimdall/internal/rules/event"
 	"github.com/dadrus/heimdall/internal/rules/patternmatcher"
 	"github.com/dadrus/heimdall/internal/rules/rule"
@@ -39,7 +44,7 @@ func TestRepositoryAddAndRemoveRulesFromSameRuleSet(t *testing.T) {
 	t.Parallel()
 
 	// GIVEN
-	repo := newRepository(nil, &ruleFactory{}, *zerolog.Ctx(context.Background()))
+	repo := newRepository(nil, &ruleFactory{}, &config.Configuration{}, *zerolog.Ctx(context.Background()))
 
 	// WHEN
 	repo.addRuleSet("bar", []rule.Rule{
@@ -65,6 +70,7 @@ func TestRepositoryFindRule(t *testing.T) {
 	for _, tc := range []struct {
 		uc               string
 		requestURL       *url.URL
+		noAllowHeader    bool
 		addRules         func(t *testing.T, repo *repository)
 		configureFactory func(t *testing.T, factory *mocks.FactoryMock)
 		assert           func(t *testing.T, err error, rul rule.Rule)
@@ -91,13 +97,13 @@ func TestRepositoryFindRule(t *testing.T) {
 				t.Helper()
 
 				factory.EXPECT().HasDefaultRule().Return(true)
-				factory.EXPECT().DefaultRule().Return(&ruleImpl{id: "test", isDefault: true})
+				factory.EXPECT().DefaultRule().Return(&ruleImpl{id: "test", isDefault: true, methods: []string{http.MethodGet}})
 			},
 			assert: func(t *testing.T, err error, rul rule.Rule) {
 				t.Helper()
 
 				require.NoError(t, err)
-				require.Equal(t, &ruleImpl{id: "test", isDefault: true}, rul)
+				require.Equal(t, &ruleImpl{id: "test", isDefault: true, methods: []string{http.MethodGet}}, rul)
 			},
 		},
 		{
@@ -111,7 +117,7 @@ func TestRepositoryFindRule(t *testing.T) {
 			addRules: func(t *testing.T, repo *repository) {
 				t.Helper()
 
-				repo.rules = append(repo.rules,
+				repo.addRules([]rule.Rule{
 					&ruleImpl{
 						id:    "test1",
 						srcID: "bar",
@@ -121,6 +127,8 @@ func TestRepositoryFindRule(t *testing.T) {
 
 							return matcher
 						}(),
+						urlPattern: "http://heimdall.test.local/baz",
+						methods:    []string{http.MethodGet},
 					},
 					&ruleImpl{
 						id:    "test2",
@@ -131,8 +139,10 @@ func TestRepositoryFindRule(t *testing.T) {
 
 							return matcher
 						}(),
+						urlPattern: "http://foo.bar/baz",
+						methods:    []string{http.MethodGet},
 					},
-				)
+				})
 			},
 			assert: func(t *testing.T, err error, rul rule.Rule) {
 				t.Helper()
@@ -146,6 +156,238 @@ func TestRepositoryFindRule(t *testing.T) {
 				require.Equal(t, "baz", impl.srcID)
 			},
 		},
+		{
+			uc:         "multiple matching rules, the most specific one wins",
+			requestURL: &url.URL{Scheme: "http", Host: "foo.bar", Path: "/baz/bar"},
+			configureFactory: func(t *testing.T, factory *mocks.FactoryMock) {
+				t.Helper()
+
+				factory.EXPECT().HasDefaultRule().Return(false)
+			},
+			addRules: func(t *testing.T, repo *repository) {
+				t.Helper()
+
+				repo.addRules([]rule.Rule{
+					newTestRule(t, "test1", "bar", config2.EncodedSlashesOff, "http://foo.bar/foo/<**>"),
+					newTestRule(t, "test2", "bar", config2.EncodedSlashesOff, "<{http,https}>://foo.bar/<**>"),
+					newTestRule(t, "test3", "baz", config2.EncodedSlashesOff, "http://foo.bar/baz/<**>"),
+				})
+			},
+			assert: func(t *testing.T, err error, rul rule.Rule) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.Equal(t, "test3", rul.ID())
+			},
+		},
+		{
+			uc:         "multiple matching rules, the one with the highest priority wins",
+			requestURL: &url.URL{Scheme: "http", Host: "foo.bar", Path: "/baz/bar"},
+			configureFactory: func(t *testing.T, factory *mocks.FactoryMock) {
+				t.Helper()
+
+				factory.EXPECT().HasDefaultRule().Return(false)
+			},
+			addRules: func(t *testing.T, repo *repository) {
+				t.Helper()
+
+				rul := newTestRule(t, "test2", "bar", config2.EncodedSlashesOff, "<{http,https}>://foo.bar/<**>")
+				rul.priority = 10
+
+				repo.addRules([]rule.Rule{
+					newTestRule(t, "test1", "bar", config2.EncodedSlashesOff, "http://foo.bar/baz/bar"),
+					rul,
+				})
+			},
+			assert: func(t *testing.T, err error, rul rule.Rule) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.Equal(t, "test2", rul.ID())
+			},
+		},
+		{
+			uc:         "multiple equally specific rules, the order is independent of the load order",
+			requestURL: &url.URL{Scheme: "http", Host: "foo.bar", Path: "/baz/bar"},
+			configureFactory: func(t *testing.T, factory *mocks.FactoryMock) {
+				t.Helper()
+
+				factory.EXPECT().HasDefaultRule().Return(false)
+			},
+			addRules: func(t *testing.T, repo *repository) {
+				t.Helper()
+
+				repo.addRuleSet("src2", []rule.Rule{
+					newTestRule(t, "test", "src2", config2.EncodedSlashesOff, "http://foo.bar/baz/<**>"),
+				})
+				repo.addRuleSet("src1", []rule.Rule{
+					newTestRule(t, "test", "src1", config2.EncodedSlashesOff, "http://foo.bar/baz/<**>"),
+				})
+			},
+			assert: func(t *testing.T, err error, rul rule.Rule) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.Equal(t, "src1", rul.SrcID())
+			},
+		},
+		{
+			uc: "matching rule with encoded slashes not decoded",
+			requestURL: &url.URL{
+				Scheme: "http", Host: "foo.bar", Path: "/baz/foo/bar", RawPath: "/baz/foo%2Fbar",
+			},
+			configureFactory: func(t *testing.T, factory *mocks.FactoryMock) {
+				t.Helper()
+
+				factory.EXPECT().HasDefaultRule().Return(false)
+			},
+			addRules: func(t *testing.T, repo *repository) {
+				t.Helper()
+
+				repo.addRules([]rule.Rule{
+					newTestRule(t, "test1", "bar", config2.EncodedSlashesOn, "http://foo.bar/baz/foo/zab"),
+					newTestRule(t, "test2", "bar", config2.EncodedSlashesNoDecode, "http://foo.bar/baz/foo%2Fbar"),
+				})
+			},
+			assert: func(t *testing.T, err error, rul rule.Rule) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.Equal(t, "test2", rul.ID())
+			},
+		},
+		{
+			uc:         "rule removed from the index is not matched any more",
+			requestURL: &url.URL{Scheme: "http", Host: "foo.bar", Path: "/baz"},
+			configureFactory: func(t *testing.T, factory *mocks.FactoryMock) {
+				t.Helper()
+
+				factory.EXPECT().HasDefaultRule().Return(false)
+			},
+			addRules: func(t *testing.T, repo *repository) {
+				t.Helper()
+
+				repo.addRules([]rule.Rule{
+					newTestRule(t, "test1", "bar", config2.EncodedSlashesOff, "http://foo.bar/baz"),
+					newTestRule(t, "test2", "baz", config2.EncodedSlashesOff, "http://foo.bar/<*>"),
+				})
+				repo.deleteRuleSet("bar")
+			},
+			assert: func(t *testing.T, err error, rul rule.Rule) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.Equal(t, "test2", rul.ID())
+			},
+		},
+		{
+			uc:         "rule not accepting the method is skipped in favour of a less specific one",
+			requestURL: &url.URL{Scheme: "http", Host: "foo.bar", Path: "/baz/bar"},
+			configureFactory: func(t *testing.T, factory *mocks.FactoryMock) {
+				t.Helper()
+
+				factory.EXPECT().HasDefaultRule().Return(false)
+			},
+			addRules: func(t *testing.T, repo *repository) {
+				t.Helper()
+
+				rul := newTestRule(t, "test1", "bar", config2.EncodedSlashesOff, "http://foo.bar/baz/bar")
+				rul.methods = []string{http.MethodPost}
+
+				repo.addRules([]rule.Rule{
+					rul,
+					newTestRule(t, "test2", "bar", config2.EncodedSlashesOff, "http://foo.bar/<**>"),
+				})
+			},
+			assert: func(t *testing.T, err error, rul rule.Rule) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.Equal(t, "test2", rul.ID())
+			},
+		},
+		{
+			uc:         "matching rules not accepting the method",
+			requestURL: &url.URL{Scheme: "http", Host: "foo.bar", Path: "/baz/bar"},
+			configureFactory: func(t *testing.T, factory *mocks.FactoryMock) {
+				t.Helper()
+
+				factory.EXPECT().HasDefaultRule().Return(true)
+				factory.EXPECT().DefaultRule().Return(&ruleImpl{id: "test", isDefault: true, methods: []string{http.MethodGet}})
+			},
+			addRules: func(t *testing.T, repo *repository) {
+				t.Helper()
+
+				rul1 := newTestRule(t, "test1", "bar", config2.EncodedSlashesOff, "http://foo.bar/baz/bar")
+				rul1.methods = []string{http.MethodPost, http.MethodDelete}
+
+				rul2 := newTestRule(t, "test2", "bar", config2.EncodedSlashesOff, "http://foo.bar/<**>")
+				rul2.methods = []string{http.MethodPost, http.MethodPatch}
+
+				repo.addRules([]rule.Rule{rul1, rul2})
+			},
+			assert: func(t *testing.T, err error, rul rule.Rule) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrMethodNotAllowed)
+				require.Nil(t, rul)
+
+				var methodErr *heimdall.MethodNotAllowedError
+				require.ErrorAs(t, err, &methodErr)
+				assert.Equal(t,
+					[]string{http.MethodDelete, http.MethodPatch, http.MethodPost}, methodErr.AllowedMethods)
+			},
+		},
+		{
+			uc:            "matching rule not accepting the method with disabled allow header",
+			requestURL:    &url.URL{Scheme: "http", Host: "foo.bar", Path: "/baz/bar"},
+			noAllowHeader: true,
+			configureFactory: func(t *testing.T, factory *mocks.FactoryMock) {
+				t.Helper()
+
+				factory.EXPECT().HasDefaultRule().Return(false)
+			},
+			addRules: func(t *testing.T, repo *repository) {
+				t.Helper()
+
+				rul := newTestRule(t, "test1", "bar", config2.EncodedSlashesOff, "http://foo.bar/baz/bar")
+				rul.methods = []string{http.MethodPost}
+
+				repo.addRules([]rule.Rule{rul})
+			},
+			assert: func(t *testing.T, err error, rul rule.Rule) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrMethodNotAllowed)
+				require.Nil(t, rul)
+
+				var methodErr *heimdall.MethodNotAllowedError
+				require.False(t, errors.As(err, &methodErr))
+			},
+		},
+		{
+			uc:         "default rule not accepting the method",
+			requestURL: &url.URL{Scheme: "http", Host: "foo.bar", Path: "/baz"},
+			configureFactory: func(t *testing.T, factory *mocks.FactoryMock) {
+				t.Helper()
+
+				factory.EXPECT().HasDefaultRule().Return(true)
+				factory.EXPECT().DefaultRule().Return(&ruleImpl{id: "test", isDefault: true, methods: []string{http.MethodPost}})
+			},
+			assert: func(t *testing.T, err error, rul rule.Rule) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrMethodNotAllowed)
+				require.Nil(t, rul)
+
+				var methodErr *heimdall.MethodNotAllowedError
+				require.ErrorAs(t, err, &methodErr)
+				assert.Equal(t, []string{http.MethodPost}, methodErr.AllowedMethods)
+			},
+		},
 	} {
 		t.Run("case="+tc.uc, func(t *testing.T) {
 			// GIVEN
@@ -156,12 +398,13 @@ func TestRepositoryFindRule(t *testing.T) {
 			factory := mocks.NewFactoryMock(t)
 			tc.configureFactory(t, factory)
 
-			repo := newRepository(nil, factory, *zerolog.Ctx(context.Background()))
+			conf := &config.Configuration{Rules: config.RulesConfig{AllowHeader: !tc.noAllowHeader}}
+			repo := newRepository(nil, factory, conf, *zerolog.Ctx(context.Background()))
 
 			addRules(t, repo)
 
 			// WHEN
-			rul, err := repo.FindRule(tc.requestURL)
+			rul, err := repo.FindRule(&heimdall.Request{Method: http.MethodGet, URL: &heimdall.URL{URL: *tc.requestURL}})
 
 			// THEN
 			tc.assert(t, err, rul)
@@ -173,7 +416,7 @@ func TestRepositoryAddAndRemoveRulesFromDifferentRuleSets(t *testing.T) {
 	t.Parallel()
 
 	// GIVEN
-	repo := newRepository(nil, &ruleFactory{}, *zerolog.Ctx(context.Background()))
+	repo := newRepository(nil, &ruleFactory{}, &config.Configuration{}, *zerolog.Ctx(context.Background()))
 
 	// WHEN
 	repo.addRules([]rule.Rule{
@@ -341,7 +584,7 @@ func TestRepositoryRuleSetLifecycleManagement(t *testing.T) {
 			queue := make(event.RuleSetChangedEventQueue, 10)
 			defer close(queue)
 
-			repo := newRepository(queue, &ruleFactory{}, log.Logger)
+			repo := newRepository(queue, &ruleFactory{}, &config.Configuration{}, log.Logger)
 			require.NoError(t, repo.Start(ctx))
 
 			defer repo.Stop(ctx)
@@ -358,3 +601,116 @@ func TestRepositoryRuleSetLifecycleManagement(t *testing.T) {
 		})
 	}
 }
+
+func newTestRule(t *testing.T, id, srcID string, esh config2.EncodedSlashesHandling, pattern string) *ruleImpl {
+	t.Helper()
+
+	matcher, err := patternmatcher.NewPatternMatcher("glob", pattern)
+	require.NoError(t, err)
+
+	return &ruleImpl{
+		id:                     id,
+		srcID:                  srcID,
+		encodedSlashesHandling: esh,
+		urlMatcher:             matcher,
+		urlPattern:             pattern,
+		methods:                []string{http.MethodGet},
+	}
+}
+
+func BenchmarkRepositoryFindRule(b *testing.B) {
+	const (
+		hosts         = 20
+		rulesPerHost  = 250
+		requestedHost = hosts / 2
+	)
+
+	repo := newRepository(nil, &ruleFactory{}, &config.Configuration{}, zerolog.Nop())
+
+	rules := make([]rule.Rule, 0, hosts*rulesPerHost)
+
+	for host := 0; host < hosts; host++ {
+		for path := 0; path < rulesPerHost; path++ {
+			pattern := fmt.Sprintf("https://host-%d.example.com/api/v1/resource-%d/<**>", host, path)
+			matcher, _ := patternmatcher.NewPatternMatcher("glob", pattern)
+
+			rules = append(rules, &ruleImpl{
+				id:         fmt.Sprintf("rule-%d-%d", host, path),
+				srcID:      "bench",
+				urlMatcher: matcher,
+				urlPattern: pattern,
+				methods:    []string{http.MethodGet},
+			})
+		}
+	}
+
+	repo.addRules(rules)
+
+	req := &heimdall.Request{Method: http.MethodGet, URL: &heimdall.URL{URL: url.URL{
+		Scheme: "https",
+		Host:   fmt.Sprintf("host-%d.example.com", requestedHost),
+		Path:   fmt.Sprintf("/api/v1/resource-%d/foo/bar", rulesPerHost-1),
+	}}}
+
+	b.Run("linear scan", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			for _, rul := range repo.rules {
+				if rul.Matches(req) {
+					break
+				}
+			}
+		}
+	})
+
+	b.Run("indexed", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			if _, err := repo.FindRule(req); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkFindOverlaps(b *testing.B) {
+	const (
+		hosts        = 20
+		rulesPerHost = 250
+	)
+
+	createRules := func(srcID string) []rule.Rule {
+		rules := make([]rule.Rule, 0, hosts*rulesPerHost)
+
+		for host := 0; host < hosts; host++ {
+			for path := 0; path < rulesPerHost; path++ {
+				pattern := fmt.Sprintf("https://host-%d.example.com/api/v1/%s/resource-%d/<**>", host, srcID, path)
+				matcher, _ := patternmatcher.NewPatternMatcher("glob", pattern)
+
+				rules = append(rules, &ruleImpl{
+					id:         fmt.Sprintf("rule-%d-%d", host, path),
+					srcID:      srcID,
+					urlMatcher: matcher,
+					urlPattern: pattern,
+					methods:    []string{http.MethodGet},
+				})
+			}
+		}
+
+		return rules
+	}
+
+	rules := createRules("updated")
+	others := createRules("other")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if overlaps := findOverlaps(rules, others); len(overlaps) != 0 {
+			b.Fatal("unexpected overlaps")
+		}
+	}
+}
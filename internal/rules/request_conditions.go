@@ -0,0 +1,273 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package rules
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+	"github.com/yl2chen/cidranger"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/rules/config"
+	"github.com/dadrus/heimdall/internal/rules/mechanisms/cellib"
+	"github.com/dadrus/heimdall/internal/rules/patternmatcher"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+	"github.com/dadrus/heimdall/internal/x/httpx"
+	"github.com/dadrus/heimdall/internal/x/slicex"
+)
+
+// requestCondition is a condition a request must fulfill, in addition to matching the
+// url pattern, for a rule to be applicable.
+type requestCondition interface {
+	fmt.Stringer
+
+	Matches(req *heimdall.Request) bool
+}
+
+// requestConditions are combined using a logical AND. The conditions are always kept
+// in the same order, so that the conditions of different rules can be compared by
+// their definitions.
+type requestConditions []requestCondition
+
+func (c requestConditions) Matches(req *heimdall.Request) bool {
+	for _, condition := range c {
+		if !condition.Matches(req) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (c requestConditions) definitions() []string {
+	return slicex.Map[requestCondition, string](c, func(cond requestCondition) string { return cond.String() })
+}
+
+//nolint:cyclop
+func newRequestConditions(matcher config.Matcher) (requestConditions, error) {
+	var conditions requestConditions
+
+	if len(matcher.Host) != 0 {
+		hm, err := patternmatcher.NewPatternMatcher(matcher.Strategy, matcher.Host)
+		if err != nil {
+			return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration, "bad host pattern").CausedBy(err)
+		}
+
+		conditions = append(conditions, &hostCondition{pattern: matcher.Host, m: hm})
+	}
+
+	for _, name := range sortedKeys(matcher.Headers) {
+		vm, err := newValueMatchers(matcher.Strategy, matcher.Headers[name])
+		if err != nil {
+			return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+				"bad value pattern for header %s", name).CausedBy(err)
+		}
+
+		conditions = append(conditions, &headerCondition{name: name, patterns: matcher.Headers[name], m: vm})
+	}
+
+	for _, name := range sortedKeys(matcher.QueryParams) {
+		vm, err := newValueMatchers(matcher.Strategy, matcher.QueryParams[name])
+		if err != nil {
+			return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+				"bad value pattern for query parameter %s", name).CausedBy(err)
+		}
+
+		conditions = append(conditions, &queryParamCondition{name: name, patterns: matcher.QueryParams[name], m: vm})
+	}
+
+	if len(matcher.ClientNetworks) != 0 {
+		cond, err := newClientNetworkCondition(matcher.ClientNetworks)
+		if err != nil {
+			return nil, err
+		}
+
+		conditions = append(conditions, cond)
+	}
+
+	if len(matcher.Expression) != 0 {
+		cond, err := newExpressionCondition(matcher.Expression)
+		if err != nil {
+			return nil, err
+		}
+
+		conditions = append(conditions, cond)
+	}
+
+	return conditions, nil
+}
+
+type hostCondition struct {
+	pattern string
+	m       patternmatcher.PatternMatcher
+}
+
+// Matches matches the host of the request without the port, so that e.g. the pattern
+// example.com matches requests to example.com:8443 as well.
+func (c *hostCondition) Matches(req *heimdall.Request) bool { return c.m.Match(req.URL.Hostname()) }
+func (c *hostCondition) String() string                     { return "host=" + c.pattern }
+
+type headerCondition struct {
+	name     string
+	patterns []string
+	m        valueMatchers
+}
+
+func (c *headerCondition) Matches(req *heimdall.Request) bool {
+	value := req.Header(c.name)
+
+	return len(value) != 0 && c.m.Match(value)
+}
+
+func (c *headerCondition) String() string {
+	return fmt.Sprintf("header:%s=%s", c.name, strings.Join(c.patterns, ","))
+}
+
+type queryParamCondition struct {
+	name     string
+	patterns []string
+	m        valueMatchers
+}
+
+func (c *queryParamCondition) Matches(req *heimdall.Request) bool {
+	return slices.ContainsFunc(req.URL.Query()[c.name], c.m.Match)
+}
+
+func (c *queryParamCondition) String() string {
+	return fmt.Sprintf("query:%s=%s", c.name, strings.Join(c.patterns, ","))
+}
+
+type clientNetworkCondition struct {
+	cidrs  []string
+	ranger cidranger.Ranger
+}
+
+func newClientNetworkCondition(cidrs []string) (*clientNetworkCondition, error) {
+	ranger := cidranger.NewPCTrieRanger()
+
+	for _, cidr := range cidrs {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+				"failed parsing client network %s", cidr).CausedBy(err)
+		}
+
+		if err = ranger.Insert(cidranger.NewBasicRangerEntry(*ipNet)); err != nil {
+			return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+				"failed to add client network %s", cidr).CausedBy(err)
+		}
+	}
+
+	return &clientNetworkCondition{cidrs: cidrs, ranger: ranger}, nil
+}
+
+// Matches checks the address of the client, the request originates from. That is
+// the first entry of the client ip addresses.
+func (c *clientNetworkCondition) Matches(req *heimdall.Request) bool {
+	if len(req.ClientIPAddresses) == 0 {
+		return false
+	}
+
+	ip := net.ParseIP(clientIP(req.ClientIPAddresses[0]))
+	if ip == nil {
+		return false
+	}
+
+	res, _ := c.ranger.Contains(ip)
+
+	return res
+}
+
+func (c *clientNetworkCondition) String() string {
+	return "client_networks=" + strings.Join(c.cidrs, ",")
+}
+
+type expressionCondition struct {
+	expression string
+	e          *cellib.CompiledExpression
+}
+
+func newExpressionCondition(expression string) (*expressionCondition, error) {
+	env, err := cel.NewEnv(cellib.Library())
+	if err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrInternal,
+			"failed creating CEL environment").CausedBy(err)
+	}
+
+	expr, err := cellib.CompileExpression(env, expression, "expression evaluated to false")
+	if err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"failed compiling cel expression").CausedBy(err)
+	}
+
+	return &expressionCondition{expression: expression, e: expr}, nil
+}
+
+func (c *expressionCondition) Matches(req *heimdall.Request) bool {
+	return c.e.Eval(map[string]any{"Request": req}) == nil
+}
+
+func (c *expressionCondition) String() string { return "expression=" + c.expression }
+
+// valueMatchers match if any of the contained matchers matches.
+type valueMatchers []patternmatcher.PatternMatcher
+
+func newValueMatchers(strategy string, patterns []string) (valueMatchers, error) {
+	matchers := make(valueMatchers, len(patterns))
+
+	for idx, pattern := range patterns {
+		matcher, err := patternmatcher.NewPatternMatcher(strategy, pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		matchers[idx] = matcher
+	}
+
+	return matchers, nil
+}
+
+func (m valueMatchers) Match(value string) bool {
+	return slices.ContainsFunc(m, func(matcher patternmatcher.PatternMatcher) bool { return matcher.Match(value) })
+}
+
+func sortedKeys(values map[string][]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	return keys
+}
+
+// clientIP removes the optional quotes and port from addresses taken from the
+// Forwarded header, like "[2001:db8:cafe::17]:4711".
+func clientIP(addr string) string {
+	addr = strings.Trim(addr, `"`)
+
+	if ip := httpx.IPFromHostPort(addr); len(ip) != 0 {
+		return ip
+	}
+
+	return strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]")
+}
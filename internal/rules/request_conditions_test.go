@@ -0,0 +1,256 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package rules
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/heimdall/mocks"
+	"github.com/dadrus/heimdall/internal/rules/config"
+)
+
+func TestNewRequestConditions(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc     string
+		conf   config.Matcher
+		assert func(t *testing.T, err error, conditions requestConditions)
+	}{
+		{
+			uc:   "without any conditions",
+			conf: config.Matcher{URL: "http://foo.bar/<**>", Strategy: "glob"},
+			assert: func(t *testing.T, err error, conditions requestConditions) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Empty(t, conditions)
+			},
+		},
+		{
+			uc:   "with bad host pattern",
+			conf: config.Matcher{Strategy: "regex", Host: "<(foo>"},
+			assert: func(t *testing.T, err error, _ requestConditions) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "host pattern")
+			},
+		},
+		{
+			uc:   "with bad header value pattern",
+			conf: config.Matcher{Strategy: "regex", Headers: map[string][]string{"X-Foo": {"<(foo>"}}},
+			assert: func(t *testing.T, err error, _ requestConditions) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "header X-Foo")
+			},
+		},
+		{
+			uc:   "with bad query parameter value pattern",
+			conf: config.Matcher{Strategy: "regex", QueryParams: map[string][]string{"foo": {"<(foo>"}}},
+			assert: func(t *testing.T, err error, _ requestConditions) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "query parameter foo")
+			},
+		},
+		{
+			uc:   "with bad client network",
+			conf: config.Matcher{Strategy: "glob", ClientNetworks: []string{"10.0.0.0"}},
+			assert: func(t *testing.T, err error, _ requestConditions) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "10.0.0.0")
+			},
+		},
+		{
+			uc:   "with malformed expression",
+			conf: config.Matcher{Strategy: "glob", Expression: "Request.URL.Host =="},
+			assert: func(t *testing.T, err error, _ requestConditions) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed compiling")
+			},
+		},
+		{
+			uc: "with all conditions",
+			conf: config.Matcher{
+				Strategy:       "glob",
+				Host:           "<*>.foo.bar",
+				Headers:        map[string][]string{"X-Foo": {"bar"}, "Content-Type": {"application/<*>"}},
+				QueryParams:    map[string][]string{"version": {"1", "2"}},
+				ClientNetworks: []string{"10.0.0.0/8", "192.168.1.0/24"},
+				Expression:     "Request.Method == 'GET'",
+			},
+			assert: func(t *testing.T, err error, conditions requestConditions) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, []string{
+					"host=<*>.foo.bar",
+					"header:Content-Type=application/<*>",
+					"header:X-Foo=bar",
+					"query:version=1,2",
+					"client_networks=10.0.0.0/8,192.168.1.0/24",
+					"expression=Request.Method == 'GET'",
+				}, conditions.definitions())
+			},
+		},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			// WHEN
+			conditions, err := newRequestConditions(tc.conf)
+
+			// THEN
+			tc.assert(t, err, conditions)
+		})
+	}
+}
+
+func TestRequestConditionsMatches(t *testing.T) {
+	t.Parallel()
+
+	conditions, err := newRequestConditions(config.Matcher{
+		Strategy:       "glob",
+		Host:           "<*>.foo.bar",
+		Headers:        map[string][]string{"Content-Type": {"application/json", "application/<*>+json"}},
+		QueryParams:    map[string][]string{"version": {"2"}},
+		ClientNetworks: []string{"10.0.0.0/8", "2001:db8::/32"},
+		Expression:     "Request.Method == 'GET'",
+	})
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		uc          string
+		host        string
+		contentType string
+		query       string
+		clientIP    string
+		method      string
+		matches     bool
+	}{
+		{
+			uc:          "all conditions are fulfilled",
+			host:        "api.foo.bar",
+			contentType: "application/json",
+			query:       "version=2",
+			clientIP:    "10.1.2.3",
+			method:      http.MethodGet,
+			matches:     true,
+		},
+		{
+			uc:          "all conditions are fulfilled with client ip taken from a forwarded header",
+			host:        "api.foo.bar",
+			contentType: "application/problem+json",
+			query:       "foo=bar&version=2",
+			clientIP:    `"[2001:db8:cafe::17]:4711"`,
+			method:      http.MethodGet,
+			matches:     true,
+		},
+		{
+			uc:          "host with port matches",
+			host:        "api.foo.bar:8443",
+			contentType: "application/json",
+			query:       "version=2",
+			clientIP:    "10.1.2.3",
+			method:      http.MethodGet,
+			matches:     true,
+		},
+		{
+			uc:          "host does not match",
+			host:        "api.foo.baz",
+			contentType: "application/json",
+			query:       "version=2",
+			clientIP:    "10.1.2.3",
+			method:      http.MethodGet,
+		},
+		{
+			uc:          "header does not match",
+			host:        "api.foo.bar",
+			contentType: "text/html",
+			query:       "version=2",
+			clientIP:    "10.1.2.3",
+			method:      http.MethodGet,
+		},
+		{
+			uc:       "header is not present",
+			host:     "api.foo.bar",
+			query:    "version=2",
+			clientIP: "10.1.2.3",
+			method:   http.MethodGet,
+		},
+		{
+			uc:          "query parameter does not match",
+			host:        "api.foo.bar",
+			contentType: "application/json",
+			query:       "version=1",
+			clientIP:    "10.1.2.3",
+			method:      http.MethodGet,
+		},
+		{
+			uc:          "client ip is not in the configured networks",
+			host:        "api.foo.bar",
+			contentType: "application/json",
+			query:       "version=2",
+			clientIP:    "192.168.1.1",
+			method:      http.MethodGet,
+		},
+		{
+			uc:          "expression evaluates to false",
+			host:        "api.foo.bar",
+			contentType: "application/json",
+			query:       "version=2",
+			clientIP:    "10.1.2.3",
+			method:      http.MethodPost,
+		},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			// GIVEN
+			reqf := mocks.NewRequestFunctionsMock(t)
+			reqf.EXPECT().Header("Content-Type").Return(tc.contentType).Maybe()
+
+			req := &heimdall.Request{
+				RequestFunctions:  reqf,
+				Method:            tc.method,
+				URL:               &heimdall.URL{URL: url.URL{Scheme: "http", Host: tc.host, Path: "/", RawQuery: tc.query}},
+				ClientIPAddresses: []string{tc.clientIP},
+			}
+
+			// WHEN
+			matches := conditions.Matches(req)
+
+			// THEN
+			assert.Equal(t, tc.matches, matches)
+		})
+	}
+}
@@ -7,8 +7,6 @@ import (
 	mock "github.com/stretchr/testify/mock"
 
 	rule "github.com/dadrus/heimdall/internal/rules/rule"
-
-	url "net/url"
 )
 
 // RuleMock is an autogenerated mock type for the Rule type
@@ -24,6 +22,49 @@ func (_m *RuleMock) EXPECT() *RuleMock_Expecter {
 	return &RuleMock_Expecter{mock: &_m.Mock}
 }
 
+// AllowedMethods provides a mock function with given fields:
+func (_m *RuleMock) AllowedMethods() []string {
+	ret := _m.Called()
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func() []string); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	return r0
+}
+
+// RuleMock_AllowedMethods_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AllowedMethods'
+type RuleMock_AllowedMethods_Call struct {
+	*mock.Call
+}
+
+// AllowedMethods is a helper method to define mock.On call
+func (_e *RuleMock_Expecter) AllowedMethods() *RuleMock_AllowedMethods_Call {
+	return &RuleMock_AllowedMethods_Call{Call: _e.mock.On("AllowedMethods")}
+}
+
+func (_c *RuleMock_AllowedMethods_Call) Run(run func()) *RuleMock_AllowedMethods_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *RuleMock_AllowedMethods_Call) Return(_a0 []string) *RuleMock_AllowedMethods_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *RuleMock_AllowedMethods_Call) RunAndReturn(run func() []string) *RuleMock_AllowedMethods_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Execute provides a mock function with given fields: _a0
 func (_m *RuleMock) Execute(_a0 heimdall.Context) (rule.Backend, error) {
 	ret := _m.Called(_a0)
@@ -119,12 +160,12 @@ func (_c *RuleMock_ID_Call) RunAndReturn(run func() string) *RuleMock_ID_Call {
 	return _c
 }
 
-// MatchesMethod provides a mock function with given fields: _a0
-func (_m *RuleMock) MatchesMethod(_a0 string) bool {
+// Matches provides a mock function with given fields: _a0
+func (_m *RuleMock) Matches(_a0 *heimdall.Request) bool {
 	ret := _m.Called(_a0)
 
 	var r0 bool
-	if rf, ok := ret.Get(0).(func(string) bool); ok {
+	if rf, ok := ret.Get(0).(func(*heimdall.Request) bool); ok {
 		r0 = rf(_a0)
 	} else {
 		r0 = ret.Get(0).(bool)
@@ -133,40 +174,40 @@ func (_m *RuleMock) MatchesMethod(_a0 string) bool {
 	return r0
 }
 
-// RuleMock_MatchesMethod_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'MatchesMethod'
-type RuleMock_MatchesMethod_Call struct {
+// RuleMock_Matches_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Matches'
+type RuleMock_Matches_Call struct {
 	*mock.Call
 }
 
-// MatchesMethod is a helper method to define mock.On call
-//   - _a0 string
-func (_e *RuleMock_Expecter) MatchesMethod(_a0 interface{}) *RuleMock_MatchesMethod_Call {
-	return &RuleMock_MatchesMethod_Call{Call: _e.mock.On("MatchesMethod", _a0)}
+// Matches is a helper method to define mock.On call
+//   - _a0 *heimdall.Request
+func (_e *RuleMock_Expecter) Matches(_a0 interface{}) *RuleMock_Matches_Call {
+	return &RuleMock_Matches_Call{Call: _e.mock.On("Matches", _a0)}
 }
 
-func (_c *RuleMock_MatchesMethod_Call) Run(run func(_a0 string)) *RuleMock_MatchesMethod_Call {
+func (_c *RuleMock_Matches_Call) Run(run func(_a0 *heimdall.Request)) *RuleMock_Matches_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string))
+		run(args[0].(*heimdall.Request))
 	})
 	return _c
 }
 
-func (_c *RuleMock_MatchesMethod_Call) Return(_a0 bool) *RuleMock_MatchesMethod_Call {
+func (_c *RuleMock_Matches_Call) Return(_a0 bool) *RuleMock_Matches_Call {
 	_c.Call.Return(_a0)
 	return _c
 }
 
-func (_c *RuleMock_MatchesMethod_Call) RunAndReturn(run func(string) bool) *RuleMock_MatchesMethod_Call {
+func (_c *RuleMock_Matches_Call) RunAndReturn(run func(*heimdall.Request) bool) *RuleMock_Matches_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// MatchesURL provides a mock function with given fields: _a0
-func (_m *RuleMock) MatchesURL(_a0 *url.URL) bool {
+// MatchesMethod provides a mock function with given fields: _a0
+func (_m *RuleMock) MatchesMethod(_a0 string) bool {
 	ret := _m.Called(_a0)
 
 	var r0 bool
-	if rf, ok := ret.Get(0).(func(*url.URL) bool); ok {
+	if rf, ok := ret.Get(0).(func(string) bool); ok {
 		r0 = rf(_a0)
 	} else {
 		r0 = ret.Get(0).(bool)
@@ -175,30 +216,30 @@ func (_m *RuleMock) MatchesURL(_a0 *url.URL) bool {
 	return r0
 }
 
-// RuleMock_MatchesURL_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'MatchesURL'
-type RuleMock_MatchesURL_Call struct {
+// RuleMock_MatchesMethod_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'MatchesMethod'
+type RuleMock_MatchesMethod_Call struct {
 	*mock.Call
 }
 
-// MatchesURL is a helper method to define mock.On call
-//   - _a0 *url.URL
-func (_e *RuleMock_Expecter) MatchesURL(_a0 interface{}) *RuleMock_MatchesURL_Call {
-	return &RuleMock_MatchesURL_Call{Call: _e.mock.On("MatchesURL", _a0)}
+// MatchesMethod is a helper method to define mock.On call
+//   - _a0 string
+func (_e *RuleMock_Expecter) MatchesMethod(_a0 interface{}) *RuleMock_MatchesMethod_Call {
+	return &RuleMock_MatchesMethod_Call{Call: _e.mock.On("MatchesMethod", _a0)}
 }
 
-func (_c *RuleMock_MatchesURL_Call) Run(run func(_a0 *url.URL)) *RuleMock_MatchesURL_Call {
+func (_c *RuleMock_MatchesMethod_Call) Run(run func(_a0 string)) *RuleMock_MatchesMethod_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(*url.URL))
+		run(args[0].(string))
 	})
 	return _c
 }
 
-func (_c *RuleMock_MatchesURL_Call) Return(_a0 bool) *RuleMock_MatchesURL_Call {
+func (_c *RuleMock_MatchesMethod_Call) Return(_a0 bool) *RuleMock_MatchesMethod_Call {
 	_c.Call.Return(_a0)
 	return _c
 }
 
-func (_c *RuleMock_MatchesURL_Call) RunAndReturn(run func(*url.URL) bool) *RuleMock_MatchesURL_Call {
+func (_c *RuleMock_MatchesMethod_Call) RunAndReturn(run func(string) bool) *RuleMock_MatchesMethod_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -19,17 +19,19 @@ package rules
 import (
 	"github.com/rs/zerolog"
 
+	"github.com/dadrus/heimdall/internal/callback"
 	"github.com/dadrus/heimdall/internal/heimdall"
 	"github.com/dadrus/heimdall/internal/rules/rule"
 	"github.com/dadrus/heimdall/internal/x/errorchain"
 )
 
 type ruleExecutor struct {
-	r rule.Repository
+	r         rule.Repository
+	endpoints *callback.Registry
 }
 
-func newRuleExecutor(repository rule.Repository) rule.Executor {
-	return &ruleExecutor{r: repository}
+func newRuleExecutor(repository rule.Repository, endpoints *callback.Registry) rule.Executor {
+	return &ruleExecutor{r: repository, endpoints: endpoints}
 }
 
 func (e *ruleExecutor) Execute(ctx heimdall.Context) (rule.Backend, error) {
@@ -41,15 +43,19 @@ func (e *ruleExecutor) Execute(ctx heimdall.Context) (rule.Backend, error) {
 		Str("_url", req.URL.String()).
 		Msg("Analyzing request")
 
-	rul, err := e.r.FindRule(req.URL)
-	if err != nil {
-		return nil, err
+	// endpoints served by heimdall itself take precedence over the rules
+	if handler, ok := e.endpoints.Handler(&req.URL.URL); ok {
+		if err := handler.HandleRequest(ctx); err != nil {
+			return nil, err
+		}
+
+		return nil, errorchain.NewWithMessagef(heimdall.ErrInternal,
+			"handler of the %s endpoint did not answer the request", req.URL.Path)
 	}
 
-	method := ctx.Request().Method
-	if !rul.MatchesMethod(method) {
-		return nil, errorchain.NewWithMessagef(heimdall.ErrMethodNotAllowed,
-			"rule (id=%s, src=%s) doesn't match %s method", rul.ID(), rul.SrcID(), method)
+	rul, err := e.r.FindRule(req)
+	if err != nil {
+		return nil, err
 	}
 
 	return rul.Execute(ctx)
@@ -18,12 +18,15 @@ package rules
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"net/url"
 	"testing"
 
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	"github.com/dadrus/heimdall/internal/callback"
 	"github.com/dadrus/heimdall/internal/heimdall"
 	mocks2 "github.com/dadrus/heimdall/internal/heimdall/mocks"
 	mocks4 "github.com/dadrus/heimdall/internal/rules/rule/mocks"
@@ -49,22 +52,10 @@ func TestRuleExecutorExecute(t *testing.T) {
 				t.Helper()
 
 				ctx.EXPECT().AppContext().Return(context.Background())
-				ctx.EXPECT().Request().Return(&heimdall.Request{Method: http.MethodPost, URL: matchingURL})
-				repo.EXPECT().FindRule(matchingURL).Return(nil, heimdall.ErrNoRuleFound)
-			},
-		},
-		{
-			uc:     "rule doesn't match method",
-			expErr: heimdall.ErrMethodNotAllowed,
-			configureMocks: func(t *testing.T, ctx *mocks2.ContextMock, repo *mocks4.RepositoryMock, rule *mocks4.RuleMock) {
-				t.Helper()
+				req := &heimdall.Request{Method: http.MethodPost, URL: &heimdall.URL{URL: *matchingURL}}
 
-				ctx.EXPECT().AppContext().Return(context.Background())
-				ctx.EXPECT().Request().Return(&heimdall.Request{Method: http.MethodPost, URL: matchingURL})
-				rule.EXPECT().MatchesMethod(http.MethodPost).Return(false)
-				rule.EXPECT().ID().Return("test_id")
-				rule.EXPECT().SrcID().Return("test_src")
-				repo.EXPECT().FindRule(matchingURL).Return(rule, nil)
+				ctx.EXPECT().Request().Return(req)
+				repo.EXPECT().FindRule(req).Return(nil, heimdall.ErrNoRuleFound)
 			},
 		},
 		{
@@ -74,10 +65,11 @@ func TestRuleExecutorExecute(t *testing.T) {
 				t.Helper()
 
 				ctx.EXPECT().AppContext().Return(context.Background())
-				ctx.EXPECT().Request().Return(&heimdall.Request{Method: http.MethodGet, URL: matchingURL})
-				rule.EXPECT().MatchesMethod(http.MethodGet).Return(true)
+				req := &heimdall.Request{Method: http.MethodGet, URL: &heimdall.URL{URL: *matchingURL}}
+
+				ctx.EXPECT().Request().Return(req)
 				rule.EXPECT().Execute(ctx).Return(nil, heimdall.ErrAuthentication)
-				repo.EXPECT().FindRule(matchingURL).Return(rule, nil)
+				repo.EXPECT().FindRule(req).Return(rule, nil)
 			},
 		},
 		{
@@ -88,10 +80,11 @@ func TestRuleExecutorExecute(t *testing.T) {
 				upstream := mocks4.NewBackendMock(t)
 
 				ctx.EXPECT().AppContext().Return(context.Background())
-				ctx.EXPECT().Request().Return(&heimdall.Request{Method: http.MethodGet, URL: matchingURL})
-				rule.EXPECT().MatchesMethod(http.MethodGet).Return(true)
+				req := &heimdall.Request{Method: http.MethodGet, URL: &heimdall.URL{URL: *matchingURL}}
+
+				ctx.EXPECT().Request().Return(req)
 				rule.EXPECT().Execute(ctx).Return(upstream, nil)
-				repo.EXPECT().FindRule(matchingURL).Return(rule, nil)
+				repo.EXPECT().FindRule(req).Return(rule, nil)
 			},
 		},
 	} {
@@ -103,7 +96,7 @@ func TestRuleExecutorExecute(t *testing.T) {
 
 			tc.configureMocks(t, ctx, repo, rule)
 
-			exec := newRuleExecutor(repo)
+			exec := newRuleExecutor(repo, callback.NewRegistry())
 
 			// WHEN
 			mut, err := exec.Execute(ctx)
@@ -118,3 +111,97 @@ func TestRuleExecutorExecute(t *testing.T) {
 		})
 	}
 }
+
+func TestRuleExecutorExecuteServesRegisteredEndpoints(t *testing.T) {
+	t.Parallel()
+
+	endpointURL, err := url.Parse("https://foo.bar/callback")
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		uc         string
+		requestURL string
+		handler    callback.Handler
+		setupRule  func(t *testing.T, repo *mocks4.RepositoryMock, req *heimdall.Request)
+		expErr     error
+	}{
+		{
+			uc:         "handler answers the request",
+			requestURL: "https://foo.bar/callback?code=foo",
+			handler: callback.HandlerFunc(func(_ heimdall.Context) error {
+				return &heimdall.RedirectError{Message: "test", Code: http.StatusFound, RedirectTo: "https://foo.bar"}
+			}),
+			expErr: &heimdall.RedirectError{},
+		},
+		{
+			uc:         "handler does not answer the request",
+			requestURL: "https://foo.bar/callback",
+			handler:    callback.HandlerFunc(func(_ heimdall.Context) error { return nil }),
+			expErr:     heimdall.ErrInternal,
+		},
+		{
+			uc:         "rule for another host with the same path",
+			requestURL: "https://bar.foo/callback",
+			handler: callback.HandlerFunc(func(_ heimdall.Context) error {
+				return &heimdall.RedirectError{Message: "test", Code: http.StatusFound, RedirectTo: "https://foo.bar"}
+			}),
+			setupRule: func(t *testing.T, repo *mocks4.RepositoryMock, req *heimdall.Request) {
+				t.Helper()
+
+				rule := mocks4.NewRuleMock(t)
+				rule.EXPECT().Execute(mock.Anything).Return(mocks4.NewBackendMock(t), nil)
+				repo.EXPECT().FindRule(req).Return(rule, nil)
+			},
+		},
+		{
+			uc:         "rule for another scheme with the same host and path",
+			requestURL: "http://foo.bar/callback",
+			handler: callback.HandlerFunc(func(_ heimdall.Context) error {
+				return &heimdall.RedirectError{Message: "test", Code: http.StatusFound, RedirectTo: "https://foo.bar"}
+			}),
+			setupRule: func(t *testing.T, repo *mocks4.RepositoryMock, req *heimdall.Request) {
+				t.Helper()
+
+				repo.EXPECT().FindRule(req).Return(nil, heimdall.ErrNoRuleFound)
+			},
+			expErr: heimdall.ErrNoRuleFound,
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			requestURL, err := url.Parse(tc.requestURL)
+			require.NoError(t, err)
+
+			req := &heimdall.Request{Method: http.MethodGet, URL: &heimdall.URL{URL: *requestURL}}
+			repo := mocks4.NewRepositoryMock(t)
+			ctx := mocks2.NewContextMock(t)
+
+			ctx.EXPECT().AppContext().Return(context.Background())
+			ctx.EXPECT().Request().Return(req)
+
+			if tc.setupRule != nil {
+				tc.setupRule(t, repo, req)
+			}
+
+			endpoints := callback.NewRegistry()
+			require.NoError(t, endpoints.Register(endpointURL, tc.handler))
+
+			exec := newRuleExecutor(repo, endpoints)
+
+			// WHEN
+			_, err = exec.Execute(ctx)
+
+			// THEN
+			var redirectErr *heimdall.RedirectError
+
+			switch {
+			case tc.expErr == nil:
+				require.NoError(t, err)
+			case errors.As(tc.expErr, &redirectErr):
+				require.ErrorAs(t, err, &redirectErr)
+			default:
+				require.ErrorIs(t, err, tc.expErr)
+			}
+		})
+	}
+}
@@ -114,6 +114,17 @@ func (f *ruleFactory) createExecutePipeline(
 			continue
 		}
 
+		if steps, found := pipelineStep["parallel"]; found {
+			handler, err := f.createParallelHandler(version, steps, authorizersCheck, contextualizersCheck)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+
+			subjectHandlers = append(subjectHandlers, handler)
+
+			continue
+		}
+
 		handler, err := createHandler(version, "authorizer", pipelineStep, authorizersCheck,
 			f.hf.CreateAuthorizer)
 		if err != nil && !errors.Is(err, errHandlerNotFound) {
@@ -151,6 +162,54 @@ func (f *ruleFactory) createExecutePipeline(
 	return authenticators, subjectHandlers, finalizers, nil
 }
 
+func (f *ruleFactory) createParallelHandler(
+	version string,
+	steps any,
+	authorizersCheck CheckFunc,
+	contextualizersCheck CheckFunc,
+) (subjectHandler, error) {
+	stepConfigs, ok := steps.([]any)
+	if !ok || len(stepConfigs) == 0 {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"parallel must be a non empty list of authorizers and contextualizers")
+	}
+
+	handlers := make(parallelSubjectHandler, 0, len(stepConfigs))
+
+	for _, stepConfig := range stepConfigs {
+		pipelineStep, ok := stepConfig.(map[string]any)
+		if !ok {
+			return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+				"unsupported configuration in parallel")
+		}
+
+		handler, err := createHandler(version, "authorizer", pipelineStep, authorizersCheck,
+			f.hf.CreateAuthorizer)
+		if err != nil && !errors.Is(err, errHandlerNotFound) {
+			return nil, err
+		} else if handler != nil {
+			handlers = append(handlers, handler)
+
+			continue
+		}
+
+		handler, err = createHandler(version, "contextualizer", pipelineStep, contextualizersCheck,
+			f.hf.CreateContextualizer)
+		if err != nil && !errors.Is(err, errHandlerNotFound) {
+			return nil, err
+		} else if handler != nil {
+			handlers = append(handlers, handler)
+
+			continue
+		}
+
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"only authorizers and contextualizers are supported in parallel")
+	}
+
+	return handlers, nil
+}
+
 func (f *ruleFactory) DefaultRule() rule.Rule { return f.defaultRule }
 func (f *ruleFactory) HasDefaultRule() bool   { return f.hasDefaultRule }
 
@@ -177,6 +236,12 @@ func (f *ruleFactory) CreateRule(version, srcID string, ruleConfig config2.Rule)
 			ruleConfig.RuleMatcher.Strategy, ruleConfig.ID, srcID).CausedBy(err)
 	}
 
+	conditions, err := newRequestConditions(ruleConfig.RuleMatcher)
+	if err != nil {
+		return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+			"bad match definition in rule ID=%s from %s", ruleConfig.ID, srcID).CausedBy(err)
+	}
+
 	authenticators, subHandlers, finalizers, err := f.createExecutePipeline(version, ruleConfig.Execute)
 	if err != nil {
 		return nil, err
@@ -225,6 +290,9 @@ func (f *ruleFactory) CreateRule(version, srcID string, ruleConfig config2.Rule)
 			config2.EncodedSlashesOff,
 		),
 		urlMatcher: matcher,
+		urlPattern: ruleConfig.RuleMatcher.URL,
+		conditions: conditions,
+		priority:   ruleConfig.Priority,
 		backend:    ruleConfig.Backend,
 		methods:    methods,
 		srcID:      srcID,
@@ -455,6 +455,121 @@ func TestRuleFactoryNew(t *testing.T) {
 				assert.Empty(t, defRule.eh)
 			},
 		},
+		{
+			uc: "new factory with default rule with empty parallel definition",
+			config: &config.Configuration{
+				Default: &config.DefaultRule{
+					Execute: []config.MechanismConfig{
+						{"authenticator": "bar"},
+						{"parallel": []any{}},
+					},
+				},
+			},
+			configureMocks: func(t *testing.T, mhf *mocks3.FactoryMock) {
+				t.Helper()
+
+				mhf.EXPECT().CreateAuthenticator(mock.Anything, "bar", mock.Anything).Return(nil, nil)
+			},
+			assert: func(t *testing.T, err error, _ *ruleFactory) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				require.ErrorContains(t, err, "non empty list")
+			},
+		},
+		{
+			uc: "new factory with default rule with finalizer in parallel definition",
+			config: &config.Configuration{
+				Default: &config.DefaultRule{
+					Execute: []config.MechanismConfig{
+						{"authenticator": "bar"},
+						{"parallel": []any{
+							map[string]any{"contextualizer": "foo"},
+							map[string]any{"finalizer": "baz"},
+						}},
+					},
+				},
+			},
+			configureMocks: func(t *testing.T, mhf *mocks3.FactoryMock) {
+				t.Helper()
+
+				mhf.EXPECT().CreateAuthenticator(mock.Anything, "bar", mock.Anything).Return(nil, nil)
+				mhf.EXPECT().CreateContextualizer(mock.Anything, "foo", mock.Anything).Return(nil, nil)
+			},
+			assert: func(t *testing.T, err error, _ *ruleFactory) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				require.ErrorContains(t, err, "only authorizers and contextualizers")
+			},
+		},
+		{
+			uc: "new factory with malformed default rule, where parallel definition happens after finalizers",
+			config: &config.Configuration{
+				Default: &config.DefaultRule{
+					Execute: []config.MechanismConfig{
+						{"authenticator": "bar"},
+						{"finalizer": "baz"},
+						{"parallel": []any{
+							map[string]any{"contextualizer": "foo"},
+						}},
+					},
+				},
+			},
+			configureMocks: func(t *testing.T, mhf *mocks3.FactoryMock) {
+				t.Helper()
+
+				mhf.EXPECT().CreateAuthenticator(mock.Anything, "bar", mock.Anything).Return(nil, nil)
+				mhf.EXPECT().CreateFinalizer(mock.Anything, "baz", mock.Anything).Return(nil, nil)
+			},
+			assert: func(t *testing.T, err error, _ *ruleFactory) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				require.ErrorContains(t, err, "before a contextualizer")
+			},
+		},
+		{
+			uc: "new factory with default rule, consisting of authenticator and parallel authorizer and contextualizers",
+			config: &config.Configuration{
+				Default: &config.DefaultRule{
+					Execute: []config.MechanismConfig{
+						{"authenticator": "bar"},
+						{"parallel": []any{
+							map[string]any{"contextualizer": "foo"},
+							map[string]any{"contextualizer": "baz", "if": "true"},
+							map[string]any{"authorizer": "zab"},
+						}},
+						{"authorizer": "zab"},
+					},
+					Methods: []string{"FOO"},
+				},
+			},
+			configureMocks: func(t *testing.T, mhf *mocks3.FactoryMock) {
+				t.Helper()
+
+				mhf.EXPECT().CreateAuthenticator(mock.Anything, "bar", mock.Anything).Return(nil, nil)
+				mhf.EXPECT().CreateContextualizer(mock.Anything, "foo", mock.Anything).Return(nil, nil)
+				mhf.EXPECT().CreateContextualizer(mock.Anything, "baz", mock.Anything).Return(nil, nil)
+				mhf.EXPECT().CreateAuthorizer(mock.Anything, "zab", mock.Anything).Return(nil, nil).Twice()
+			},
+			assert: func(t *testing.T, err error, ruleFactory *ruleFactory) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, ruleFactory)
+
+				defRule := ruleFactory.defaultRule
+				require.Len(t, defRule.sh, 2)
+
+				group, ok := defRule.sh[0].(parallelSubjectHandler)
+				require.True(t, ok)
+				assert.Len(t, group, 3)
+			},
+		},
 		{
 			uc: "new factory with default rule, configured with all possible elements",
 			config: &config.Configuration{
@@ -618,6 +733,20 @@ func TestRuleFactoryCreateRule(t *testing.T) {
 				assert.Contains(t, err.Error(), "bad URL pattern")
 			},
 		},
+		{
+			uc: "without default rule, with id, but bad match condition",
+			config: config2.Rule{
+				ID:          "foobar",
+				RuleMatcher: config2.Matcher{URL: "http://foo.bar", Strategy: "glob", ClientNetworks: []string{"foo"}},
+			},
+			assert: func(t *testing.T, err error, rul *ruleImpl) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "bad match definition")
+			},
+		},
 		{
 			uc: "with error while creating execute pipeline",
 			config: config2.Rule{
@@ -1017,7 +1146,7 @@ func TestRuleFactoryCreateRule(t *testing.T) {
 					Host:     "foo.bar:8888",
 					Path:     "/foo/bar",
 					RawQuery: url.Values{"bar": []string{"foo"}, "foo": []string{"bar"}}.Encode(),
-				}).String())
+				}, nil).String())
 
 				// nil checks above mean the responses from the mockHandlerFactory are used
 				// and not the values from the default rule
@@ -17,7 +17,6 @@
 package rules
 
 import (
-	"fmt"
 	"net/url"
 	"slices"
 	"strings"
@@ -34,6 +33,9 @@ type ruleImpl struct {
 	id                     string
 	encodedSlashesHandling config.EncodedSlashesHandling
 	urlMatcher             patternmatcher.PatternMatcher
+	urlPattern             string
+	conditions             requestConditions
+	priority               int
 	backend                *config.Backend
 	methods                []string
 	srcID                  string
@@ -54,6 +56,9 @@ func (r *ruleImpl) Execute(ctx heimdall.Context) (rule.Backend, error) {
 		logger.Info().Str("_src", r.srcID).Str("_id", r.id).Msg("Executing rule")
 	}
 
+	req := ctx.Request()
+	req.URL.Captures = r.captureURLValues(&req.URL.URL)
+
 	// authenticators
 	sub, err := r.sc.Execute(ctx)
 	if err != nil {
@@ -73,34 +78,55 @@ func (r *ruleImpl) Execute(ctx heimdall.Context) (rule.Backend, error) {
 	var upstream rule.Backend
 
 	if r.backend != nil {
-		targetURL := *ctx.Request().URL
+		targetURL := req.URL.URL
 		if r.encodedSlashesHandling == config.EncodedSlashesOn && len(targetURL.RawPath) != 0 {
 			targetURL.RawPath = ""
 		}
 
 		upstream = &backend{
-			targetURL: r.backend.CreateURL(&targetURL),
+			targetURL: r.backend.CreateURL(&targetURL, req.URL.Captures),
 		}
 	}
 
 	return upstream, nil
 }
 
-func (r *ruleImpl) MatchesURL(requestURL *url.URL) bool {
+func (r *ruleImpl) Matches(req *heimdall.Request) bool {
+	return r.matchesURL(&req.URL.URL) && r.conditions.Matches(req)
+}
+
+func (r *ruleImpl) matchesURL(requestURL *url.URL) bool {
+	value, ok := r.matchValue(requestURL)
+
+	return ok && r.urlMatcher.Match(value)
+}
+
+// captureURLValues returns the values captured by the named expressions of the url
+// pattern. The default rule has no url pattern and captures nothing.
+func (r *ruleImpl) captureURLValues(requestURL *url.URL) map[string]string {
+	value, ok := r.matchValue(requestURL)
+	if !ok || r.urlMatcher == nil {
+		return nil
+	}
+
+	return r.urlMatcher.Captures(value)
+}
+
+// matchValue returns the value, the url pattern is matched against, taking the configured
+// handling of encoded slashes into account.
+func (r *ruleImpl) matchValue(requestURL *url.URL) (string, bool) {
 	var path string
 
 	switch r.encodedSlashesHandling {
 	case config.EncodedSlashesOff:
 		if strings.Contains(requestURL.RawPath, "%2F") {
-			return false
+			return "", false
 		}
 
 		path = requestURL.Path
 	case config.EncodedSlashesNoDecode:
 		if len(requestURL.RawPath) != 0 {
-			path = strings.ReplaceAll(requestURL.RawPath, "%2F", "$$$escaped-slash$$$")
-			path, _ = url.PathUnescape(path)
-			path = strings.ReplaceAll(path, "$$$escaped-slash$$$", "%2F")
+			path = unescapePathKeepingSlashes(requestURL.RawPath)
 
 			break
 		}
@@ -110,11 +136,20 @@ func (r *ruleImpl) MatchesURL(requestURL *url.URL) bool {
 		path = requestURL.Path
 	}
 
-	return r.urlMatcher.Match(fmt.Sprintf("%s://%s%s", requestURL.Scheme, requestURL.Host, path))
+	return requestURL.Scheme + "://" + requestURL.Host + path, true
+}
+
+func unescapePathKeepingSlashes(rawPath string) string {
+	path := strings.ReplaceAll(rawPath, "%2F", "$$$escaped-slash$$$")
+	path, _ = url.PathUnescape(path)
+
+	return strings.ReplaceAll(path, "$$$escaped-slash$$$", "%2F")
 }
 
 func (r *ruleImpl) MatchesMethod(method string) bool { return slices.Contains(r.methods, method) }
 
+func (r *ruleImpl) AllowedMethods() []string { return r.methods }
+
 func (r *ruleImpl) ID() string { return r.id }
 
 func (r *ruleImpl) SrcID() string { return r.srcID }
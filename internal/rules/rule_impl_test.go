@@ -221,7 +221,7 @@ func TestRuleMatchURL(t *testing.T) {
 			require.NoError(t, err)
 
 			// WHEN
-			matched := rul.MatchesURL(tbmu)
+			matched := rul.Matches(&heimdall.Request{URL: &heimdall.URL{URL: *tbmu}})
 
 			// THEN
 			tc.assert(t, matched)
@@ -236,6 +236,7 @@ func TestRuleExecute(t *testing.T) {
 		uc             string
 		backend        *config.Backend
 		slashHandling  config.EncodedSlashesHandling
+		urlPattern     string
 		configureMocks func(
 			t *testing.T,
 			ctx *heimdallmocks.ContextMock,
@@ -401,7 +402,7 @@ func TestRuleExecute(t *testing.T) {
 				finalizer.EXPECT().Execute(ctx, sub).Return(nil)
 
 				targetURL, _ := url.Parse("http://foo.local/api/v1/foo%5Bid%5D")
-				ctx.EXPECT().Request().Return(&heimdall.Request{URL: targetURL})
+				ctx.EXPECT().Request().Return(&heimdall.Request{URL: &heimdall.URL{URL: *targetURL}})
 			},
 			assert: func(t *testing.T, err error, backend rule.Backend) {
 				t.Helper()
@@ -431,7 +432,7 @@ func TestRuleExecute(t *testing.T) {
 				finalizer.EXPECT().Execute(ctx, sub).Return(nil)
 
 				targetURL, _ := url.Parse("http://foo.local/api/v1/foo%5Bid%5D")
-				ctx.EXPECT().Request().Return(&heimdall.Request{URL: targetURL})
+				ctx.EXPECT().Request().Return(&heimdall.Request{URL: &heimdall.URL{URL: *targetURL}})
 			},
 			assert: func(t *testing.T, err error, backend rule.Backend) {
 				t.Helper()
@@ -461,7 +462,7 @@ func TestRuleExecute(t *testing.T) {
 				finalizer.EXPECT().Execute(ctx, sub).Return(nil)
 
 				targetURL, _ := url.Parse("http://foo.local/api%2Fv1/foo%5Bid%5D")
-				ctx.EXPECT().Request().Return(&heimdall.Request{URL: targetURL})
+				ctx.EXPECT().Request().Return(&heimdall.Request{URL: &heimdall.URL{URL: *targetURL}})
 			},
 			assert: func(t *testing.T, err error, backend rule.Backend) {
 				t.Helper()
@@ -491,7 +492,7 @@ func TestRuleExecute(t *testing.T) {
 				finalizer.EXPECT().Execute(ctx, sub).Return(nil)
 
 				targetURL, _ := url.Parse("http://foo.local/api%2Fv1/foo%5Bid%5D")
-				ctx.EXPECT().Request().Return(&heimdall.Request{URL: targetURL})
+				ctx.EXPECT().Request().Return(&heimdall.Request{URL: &heimdall.URL{URL: *targetURL}})
 			},
 			assert: func(t *testing.T, err error, backend rule.Backend) {
 				t.Helper()
@@ -521,7 +522,7 @@ func TestRuleExecute(t *testing.T) {
 				finalizer.EXPECT().Execute(ctx, sub).Return(nil)
 
 				targetURL, _ := url.Parse("http://foo.local/api/v1/foo")
-				ctx.EXPECT().Request().Return(&heimdall.Request{URL: targetURL})
+				ctx.EXPECT().Request().Return(&heimdall.Request{URL: &heimdall.URL{URL: *targetURL}})
 			},
 			assert: func(t *testing.T, err error, backend rule.Backend) {
 				t.Helper()
@@ -532,6 +533,42 @@ func TestRuleExecute(t *testing.T) {
 				assert.Equal(t, expectedURL, backend.URL())
 			},
 		},
+		{
+			uc:         "all handler succeed with captures used for the backend url",
+			urlPattern: "http://foo.local/tenants/<:tenant>/<**>",
+			backend: &config.Backend{
+				Host:        "foo.bar",
+				URLRewriter: &config.URLRewriter{PathPrefixToCut: "/tenants/<:tenant>", PathPrefixToAdd: "/<:tenant>"},
+			},
+			configureMocks: func(t *testing.T, ctx *heimdallmocks.ContextMock, authenticator *mocks.SubjectCreatorMock,
+				authorizer *mocks.SubjectHandlerMock, finalizer *mocks.SubjectHandlerMock,
+				_ *mocks.ErrorHandlerMock,
+			) {
+				t.Helper()
+
+				sub := &subject.Subject{ID: "Foo"}
+				req := &heimdall.Request{
+					URL: &heimdall.URL{URL: url.URL{Scheme: "http", Host: "foo.local", Path: "/tenants/acme/api/v1/foo"}},
+				}
+
+				ctx.EXPECT().Request().Return(req)
+				authenticator.EXPECT().Execute(ctx).Return(sub, nil)
+				authorizer.EXPECT().Execute(ctx, sub).
+					Run(func(_ heimdall.Context, _ *subject.Subject) {
+						assert.Equal(t, map[string]string{"tenant": "acme"}, req.URL.Captures)
+					}).
+					Return(nil)
+				finalizer.EXPECT().Execute(ctx, sub).Return(nil)
+			},
+			assert: func(t *testing.T, err error, backend rule.Backend) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				expectedURL, _ := url.Parse("http://foo.bar/acme/api/v1/foo")
+				assert.Equal(t, expectedURL, backend.URL())
+			},
+		},
 	} {
 		t.Run("case="+tc.uc, func(t *testing.T) {
 			// GIVEN
@@ -552,8 +589,20 @@ func TestRuleExecute(t *testing.T) {
 				eh:                     compositeErrorHandler{errHandler},
 			}
 
+			if len(tc.urlPattern) != 0 {
+				matcher, err := patternmatcher.NewPatternMatcher("glob", tc.urlPattern)
+				require.NoError(t, err)
+
+				rul.urlMatcher = matcher
+				rul.urlPattern = tc.urlPattern
+			}
+
 			tc.configureMocks(t, ctx, authenticator, authorizer, finalizer, errHandler)
 
+			ctx.EXPECT().Request().Return(&heimdall.Request{
+				URL: &heimdall.URL{URL: url.URL{Scheme: "http", Host: "foo.local", Path: "/foo"}},
+			}).Maybe()
+
 			// WHEN
 			upstream, err := rul.Execute(ctx)
 
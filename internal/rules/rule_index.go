@@ -0,0 +1,237 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package rules
+
+import (
+	"cmp"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/dadrus/heimdall/internal/rules/patternmatcher"
+	"github.com/dadrus/heimdall/internal/rules/rule"
+	"github.com/dadrus/heimdall/internal/x"
+)
+
+// ruleIndex is a radix tree built from the literal prefixes of the url patterns of
+// the loaded rules (everything preceding the first wildcard expression, like scheme,
+// host and leading path segments). It is used to narrow down the set of rules, which
+// have to be matched against a request, to those, whose literal prefix is a prefix of
+// the request url. The candidates are always returned in the order defined by
+// compareEntries.
+type ruleIndex struct {
+	root indexNode
+}
+
+type indexEntry struct {
+	rul         rule.Rule
+	prefix      string
+	priority    int
+	specificity int
+	conditions  int
+}
+
+type indexNode struct {
+	prefix   string
+	children []*indexNode
+	entries  []indexEntry
+}
+
+func newRuleIndex() *ruleIndex { return &ruleIndex{} }
+
+func newIndexEntry(rul rule.Rule) indexEntry {
+	impl, ok := rul.(*ruleImpl)
+	if !ok {
+		return indexEntry{rul: rul}
+	}
+
+	return indexEntry{
+		rul:         rul,
+		prefix:      patternmatcher.LiteralPrefix(impl.urlPattern),
+		priority:    impl.priority,
+		specificity: patternmatcher.LiteralLength(impl.urlPattern),
+		conditions:  len(impl.conditions),
+	}
+}
+
+// compareEntries defines the order in which rules are matched. Rules with higher
+// priority come first. Rules with equal priority are ordered by the specificity of
+// their url patterns (the amount of literal characters and the length of the literal
+// prefix) and by the amount of additional request conditions, with more specific rules
+// coming first. If that does not help either, the source and the id of the rules are
+// taken into account, which makes the ordering independent of the order, the rule
+// sets have been loaded in.
+func compareEntries(a, b indexEntry) int {
+	for _, res := range []int{
+		cmp.Compare(b.priority, a.priority),
+		cmp.Compare(b.specificity, a.specificity),
+		cmp.Compare(len(b.prefix), len(a.prefix)),
+		cmp.Compare(b.conditions, a.conditions),
+		cmp.Compare(a.rul.SrcID(), b.rul.SrcID()),
+	} {
+		if res != 0 {
+			return res
+		}
+	}
+
+	return cmp.Compare(a.rul.ID(), b.rul.ID())
+}
+
+func (i *ruleIndex) add(rul rule.Rule) {
+	entry := newIndexEntry(rul)
+
+	i.root.insert(entry.prefix, entry)
+}
+
+func (i *ruleIndex) remove(rul rule.Rule) {
+	i.root.remove(newIndexEntry(rul).prefix, rul)
+}
+
+func (i *ruleIndex) replace(existing, updated rule.Rule) {
+	i.remove(existing)
+	i.add(updated)
+}
+
+// candidates returns the rules, which might match the given url, in the order these
+// should be matched.
+func (i *ruleIndex) candidates(requestURL *url.URL) []indexEntry {
+	schemeAndHost := requestURL.Scheme + "://" + requestURL.Host
+
+	entries := i.root.collect(schemeAndHost+requestURL.Path, nil)
+
+	if len(requestURL.RawPath) != 0 {
+		// rules allowing encoded slashes without decoding them are matched against
+		// a different representation of the path
+		entries = i.root.collect(schemeAndHost+unescapePathKeepingSlashes(requestURL.RawPath), entries)
+	}
+
+	slices.SortFunc(entries, compareEntries)
+
+	return slices.CompactFunc(entries, func(a, b indexEntry) bool { return a.rul == b.rul })
+}
+
+func (n *indexNode) insert(key string, entry indexEntry) {
+	node := n
+
+	for len(key) != 0 {
+		idx := node.childIndex(key[0])
+		if idx == -1 {
+			node.children = append(node.children, &indexNode{prefix: key, entries: []indexEntry{entry}})
+			slices.SortFunc(node.children, func(a, b *indexNode) int { return cmp.Compare(a.prefix[0], b.prefix[0]) })
+
+			return
+		}
+
+		child := node.children[idx]
+
+		common := commonPrefixLength(key, child.prefix)
+		if common < len(child.prefix) {
+			// split the child node at the end of the common prefix
+			split := &indexNode{prefix: child.prefix[:common], children: []*indexNode{child}}
+			child.prefix = child.prefix[common:]
+			node.children[idx] = split
+			child = split
+		}
+
+		key = key[common:]
+		node = child
+	}
+
+	pos, _ := slices.BinarySearchFunc(node.entries, entry, compareEntries)
+	node.entries = slices.Insert(node.entries, pos, entry)
+}
+
+func (n *indexNode) remove(key string, rul rule.Rule) bool {
+	if len(key) == 0 {
+		idx := slices.IndexFunc(n.entries, func(e indexEntry) bool { return e.rul == rul })
+		if idx == -1 {
+			return false
+		}
+
+		n.entries = slices.Delete(n.entries, idx, idx+1)
+
+		return true
+	}
+
+	idx := n.childIndex(key[0])
+	if idx == -1 {
+		return false
+	}
+
+	child := n.children[idx]
+	if !strings.HasPrefix(key, child.prefix) || !child.remove(key[len(child.prefix):], rul) {
+		return false
+	}
+
+	// keep the tree compact
+	if len(child.entries) == 0 {
+		switch len(child.children) {
+		case 0:
+			n.children = slices.Delete(n.children, idx, idx+1)
+		case 1:
+			grandChild := child.children[0]
+			grandChild.prefix = child.prefix + grandChild.prefix
+			n.children[idx] = grandChild
+		}
+	}
+
+	return true
+}
+
+func (n *indexNode) collect(value string, entries []indexEntry) []indexEntry {
+	node := n
+
+	for {
+		entries = append(entries, node.entries...)
+
+		if len(value) == 0 {
+			return entries
+		}
+
+		idx := node.childIndex(value[0])
+		if idx == -1 {
+			return entries
+		}
+
+		child := node.children[idx]
+		if !strings.HasPrefix(value, child.prefix) {
+			return entries
+		}
+
+		value = value[len(child.prefix):]
+		node = child
+	}
+}
+
+func (n *indexNode) childIndex(first byte) int {
+	idx, found := slices.BinarySearchFunc(n.children, first,
+		func(child *indexNode, b byte) int { return cmp.Compare(child.prefix[0], b) })
+
+	return x.IfThenElse(found, idx, -1)
+}
+
+func commonPrefixLength(a, b string) int {
+	length := min(len(a), len(b))
+
+	for idx := 0; idx < length; idx++ {
+		if a[idx] != b[idx] {
+			return idx
+		}
+	}
+
+	return length
+}
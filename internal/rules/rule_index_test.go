@@ -0,0 +1,134 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package rules
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/rules/rule"
+	"github.com/dadrus/heimdall/internal/x/slicex"
+)
+
+func TestRuleIndexCandidates(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	index := newRuleIndex()
+
+	for _, rul := range []*ruleImpl{
+		{id: "1", srcID: "a", urlPattern: "http://foo.bar/api/v1/<**>"},
+		{id: "2", srcID: "a", urlPattern: "http://foo.bar/api/v2/<**>"},
+		{id: "3", srcID: "a", urlPattern: "<**>"},
+		{id: "4", srcID: "a", urlPattern: "http://foo.bar/api/<**>"},
+		{id: "5", srcID: "a", urlPattern: "http://foo.bar/api/v1/users<**>"},
+		{id: "6", srcID: "a", urlPattern: "https://foo.bar/api/v1/<**>"},
+		{id: "7", srcID: "a", urlPattern: "http://<**>"},
+		{id: "8", srcID: "a", urlPattern: "http://<*>/api/v1/users/<*>", priority: 1},
+		{id: "9", srcID: "a", urlPattern: "http://foo.bar/<*>/v1/users/<*>"},
+		{id: "10", srcID: "b", urlPattern: "http://foo.bar/api/<**>"},
+	} {
+		index.add(rul)
+	}
+
+	for _, tc := range []struct {
+		uc       string
+		url      *url.URL
+		expected []string
+	}{
+		{
+			uc:       "candidates ordered by priority and specificity",
+			url:      &url.URL{Scheme: "http", Host: "foo.bar", Path: "/api/v1/users/1"},
+			expected: []string{"8", "5", "9", "1", "4", "10", "7", "3"},
+		},
+		{
+			uc:       "other scheme",
+			url:      &url.URL{Scheme: "https", Host: "foo.bar", Path: "/api/v1/users/1"},
+			expected: []string{"6", "3"},
+		},
+		{
+			uc:       "other host",
+			url:      &url.URL{Scheme: "http", Host: "bar.foo", Path: "/api/v1/users/1"},
+			expected: []string{"8", "7", "3"},
+		},
+		{
+			uc:       "partial match of a tree node",
+			url:      &url.URL{Scheme: "http", Host: "foo.bar", Path: "/ap"},
+			expected: []string{"8", "9", "7", "3"},
+		},
+		{
+			uc: "path with encoded slashes",
+			url: &url.URL{
+				Scheme: "http", Host: "foo.bar", Path: "/api/v2/foo/bar", RawPath: "/api/v2/foo%2Fbar",
+			},
+			expected: []string{"8", "9", "2", "4", "10", "7", "3"},
+		},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			// WHEN
+			candidates := index.candidates(tc.url)
+
+			// THEN
+			assert.Equal(t, tc.expected, slicex.Map(candidates, func(e indexEntry) string { return e.rul.ID() }))
+		})
+	}
+}
+
+func TestRuleIndexRemoveAndReplace(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	index := newRuleIndex()
+	requestURL := &url.URL{Scheme: "http", Host: "foo.bar", Path: "/api/v1/users"}
+
+	rul1 := &ruleImpl{id: "1", urlPattern: "http://foo.bar/api/v1/<**>"}
+	rul2 := &ruleImpl{id: "2", urlPattern: "http://foo.bar/api/v1/users"}
+	rul3 := &ruleImpl{id: "3", urlPattern: "http://foo.bar/api/v2/<**>"}
+
+	ids := func() []string {
+		return slicex.Map(index.candidates(requestURL), func(e indexEntry) string { return e.rul.ID() })
+	}
+
+	for _, rul := range []rule.Rule{rul1, rul2, rul3} {
+		index.add(rul)
+	}
+
+	// WHEN
+	index.replace(rul1, &ruleImpl{id: "1", urlPattern: "http://foo.bar/api/v1/users", priority: 1})
+
+	// THEN
+	assert.Equal(t, []string{"1", "2"}, ids())
+
+	// WHEN
+	index.remove(rul1)
+
+	// THEN
+	assert.Equal(t, []string{"1", "2"}, ids())
+
+	// WHEN
+	index.remove(rul2)
+	index.remove(rul3)
+
+	// THEN
+	assert.Equal(t, []string{"1"}, ids())
+	require.Len(t, index.root.children, 1)
+	assert.Equal(t, "http://foo.bar/api/v1/users", index.root.children[0].prefix)
+	assert.Empty(t, index.root.children[0].children)
+}
@@ -0,0 +1,139 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package rules
+
+import (
+	"slices"
+
+	"github.com/dadrus/heimdall/internal/rules/rule"
+)
+
+type ruleOverlap struct {
+	rul   rule.Rule
+	other rule.Rule
+	// provable is set if both rules are known to address exactly the same requests, which is
+	// the case for equal url patterns. Otherwise, the overlap is just a guess (see overlap).
+	provable bool
+}
+
+// overlapGroup holds the rules, which share the same literal prefix, priority and specificity.
+// Only rules from the same group can overlap (see overlap), so these are the only ones, which
+// have to be checked against each other.
+type overlapGroup struct {
+	rules  []*ruleImpl
+	others []*ruleImpl
+}
+
+type overlapGroupKey struct {
+	prefix      string
+	priority    int
+	specificity int
+}
+
+// findOverlaps returns all pairs of rules, which are applicable to the same requests
+// and can only be ordered by their sources and ids, as these have the same priority
+// and are equally specific. The given rules are checked against each other and against
+// the other rules.
+func findOverlaps(rules []rule.Rule, others []rule.Rule) []ruleOverlap {
+	var overlaps []ruleOverlap
+
+	groups := make(map[overlapGroupKey]*overlapGroup)
+	memberships := make([]*overlapGroup, len(rules))
+	positions := make([]int, len(rules))
+
+	group := func(rul rule.Rule) (*overlapGroup, *ruleImpl) {
+		impl, ok := rul.(*ruleImpl)
+		if !ok {
+			return nil, nil
+		}
+
+		entry := newIndexEntry(impl)
+		key := overlapGroupKey{prefix: entry.prefix, priority: entry.priority, specificity: entry.specificity}
+
+		grp, ok := groups[key]
+		if !ok {
+			grp = &overlapGroup{}
+			groups[key] = grp
+		}
+
+		return grp, impl
+	}
+
+	for idx, rul := range rules {
+		if grp, impl := group(rul); grp != nil {
+			memberships[idx] = grp
+			positions[idx] = len(grp.rules)
+			grp.rules = append(grp.rules, impl)
+		}
+	}
+
+	for _, rul := range others {
+		if grp, impl := group(rul); grp != nil {
+			grp.others = append(grp.others, impl)
+		}
+	}
+
+	check := func(rul, other *ruleImpl) {
+		if found, provable := overlap(rul, other); found {
+			overlaps = append(overlaps, ruleOverlap{rul: rul, other: other, provable: provable})
+		}
+	}
+
+	for idx, grp := range memberships {
+		if grp == nil {
+			continue
+		}
+
+		rul := grp.rules[positions[idx]]
+
+		for _, other := range grp.rules[positions[idx]+1:] {
+			check(rul, other)
+		}
+
+		for _, other := range grp.others {
+			check(rul, other)
+		}
+	}
+
+	return overlaps
+}
+
+// overlap reports whether both rules, which are expected to have the same literal prefix,
+// priority and specificity, might be applicable to the same requests. The second return value
+// is set, if that is not just a guess, but provable, which is the case if both rules use the
+// same url pattern.
+func overlap(first, second *ruleImpl) (bool, bool) {
+	if !slices.ContainsFunc(first.methods, second.MatchesMethod) {
+		return false, false
+	}
+
+	// rules with different request conditions are considered to address different
+	// requests, even if the conditions of both could be fulfilled by the same request.
+	if !slices.Equal(first.conditions.definitions(), second.conditions.definitions()) {
+		return false, false
+	}
+
+	if first.urlPattern == second.urlPattern {
+		return true, true
+	}
+
+	// there is no generic way to compute the intersection of two patterns. So the check
+	// is done by matching the patterns against each other, which covers patterns, in which
+	// the wildcard of one covers the wildcard of the other. Since the pattern syntax is
+	// matched as if it were a url, this is a heuristic with false positives and negatives.
+	return first.urlMatcher.Match(second.urlPattern) || second.urlMatcher.Match(first.urlPattern), false
+}
@@ -0,0 +1,138 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package rules
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dadrus/heimdall/internal/rules/config"
+	"github.com/dadrus/heimdall/internal/rules/rule"
+	"github.com/dadrus/heimdall/internal/rules/rule/mocks"
+)
+
+func TestFindOverlaps(t *testing.T) {
+	t.Parallel()
+
+	createRule := func(t *testing.T, id, pattern string, priority int, methods ...string) *ruleImpl {
+		t.Helper()
+
+		rul := newTestRule(t, id, "test", config.EncodedSlashesOff, pattern)
+		rul.priority = priority
+		rul.methods = methods
+
+		return rul
+	}
+
+	for _, tc := range []struct {
+		uc       string
+		rules    func(t *testing.T) []rule.Rule
+		others   func(t *testing.T) []rule.Rule
+		expected [][]string
+		provable []bool
+	}{
+		{
+			uc: "no overlaps",
+			rules: func(t *testing.T) []rule.Rule {
+				t.Helper()
+
+				return []rule.Rule{
+					createRule(t, "1", "http://foo.bar/<**>", 0, http.MethodGet),
+					createRule(t, "2", "http://foo.bar/<**>", 1, http.MethodGet),
+					createRule(t, "3", "http://foo.bar/<**>", 0, http.MethodPost),
+					createRule(t, "4", "http://foo.bar/baz/<**>", 0, http.MethodGet),
+					createRule(t, "5", "http://foo.bar/<*>/bar", 0, http.MethodGet),
+					createRule(t, "6", "http://foo.bar/<*>/baz", 0, http.MethodGet),
+				}
+			},
+			others: func(t *testing.T) []rule.Rule {
+				t.Helper()
+
+				return []rule.Rule{
+					&mocks.RuleMock{},
+					createRule(t, "7", "http://foo.baz/<**>", 0, http.MethodGet),
+				}
+			},
+		},
+		{
+			uc: "overlaps within the same rule set and with other rules",
+			rules: func(t *testing.T) []rule.Rule {
+				t.Helper()
+
+				return []rule.Rule{
+					createRule(t, "1", "http://foo.bar/<**>", 0, http.MethodGet, http.MethodPost),
+					createRule(t, "2", "http://foo.bar/<*>", 0, http.MethodPost),
+				}
+			},
+			others: func(t *testing.T) []rule.Rule {
+				t.Helper()
+
+				return []rule.Rule{
+					createRule(t, "3", "http://foo.bar/<**>", 0, http.MethodGet),
+					createRule(t, "4", "http://foo.bar/<**>", 1, http.MethodGet),
+				}
+			},
+			expected: [][]string{{"1", "2"}, {"1", "3"}},
+			provable: []bool{false, true},
+		},
+		{
+			uc: "rules with different request conditions do not overlap",
+			rules: func(t *testing.T) []rule.Rule {
+				t.Helper()
+
+				first := createRule(t, "1", "http://foo.bar/<**>", 0, http.MethodGet)
+				first.conditions = requestConditions{&hostCondition{pattern: "foo.bar"}}
+
+				second := createRule(t, "2", "http://foo.bar/<**>", 0, http.MethodGet)
+				second.conditions = requestConditions{&hostCondition{pattern: "foo.baz"}}
+
+				third := createRule(t, "3", "http://foo.bar/<**>", 0, http.MethodGet)
+				third.conditions = requestConditions{&hostCondition{pattern: "foo.bar"}}
+
+				return []rule.Rule{first, second, third}
+			},
+			others: func(t *testing.T) []rule.Rule {
+				t.Helper()
+
+				return []rule.Rule{createRule(t, "4", "http://foo.bar/<**>", 0, http.MethodGet)}
+			},
+			expected: [][]string{{"1", "3"}},
+			provable: []bool{true},
+		},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			// WHEN
+			overlaps := findOverlaps(tc.rules(t), tc.others(t))
+
+			// THEN
+			var (
+				ids      [][]string
+				provable []bool
+			)
+
+			for _, overlap := range overlaps {
+				ids = append(ids, []string{overlap.rul.ID(), overlap.other.ID()})
+				provable = append(provable, overlap.provable)
+			}
+
+			assert.Equal(t, tc.expected, ids)
+			assert.Equal(t, tc.provable, provable)
+		})
+	}
+}
@@ -18,31 +18,42 @@ package rules
 
 import (
 	"errors"
+	"slices"
+	"sync"
 
 	"github.com/rs/zerolog"
 
+	config2 "github.com/dadrus/heimdall/internal/config"
 	"github.com/dadrus/heimdall/internal/heimdall"
 	"github.com/dadrus/heimdall/internal/rules/config"
 	"github.com/dadrus/heimdall/internal/rules/event"
 	"github.com/dadrus/heimdall/internal/rules/rule"
+	"github.com/dadrus/heimdall/internal/x"
 	"github.com/dadrus/heimdall/internal/x/errorchain"
 )
 
 var ErrUnsupportedRuleSetVersion = errors.New("unsupported rule set version")
 
 type ruleSetProcessor struct {
-	q event.RuleSetChangedEventQueue
-	f rule.Factory
-	l zerolog.Logger
+	q      event.RuleSetChangedEventQueue
+	f      rule.Factory
+	l      zerolog.Logger
+	strict bool
+
+	// rules of all known rule sets by their sources. Used for overlap detection
+	rules map[string][]rule.Rule
+	mutex sync.Mutex
 }
 
 func NewRuleSetProcessor(
-	queue event.RuleSetChangedEventQueue, factory rule.Factory, logger zerolog.Logger,
+	queue event.RuleSetChangedEventQueue, factory rule.Factory, conf *config2.Configuration, logger zerolog.Logger,
 ) rule.SetProcessor {
 	return &ruleSetProcessor{
-		q: queue,
-		f: factory,
-		l: logger,
+		q:      queue,
+		f:      factory,
+		l:      logger,
+		strict: conf.Rules.StrictMode,
+		rules:  make(map[string][]rule.Rule),
 	}
 }
 
@@ -75,6 +86,10 @@ func (p *ruleSetProcessor) OnCreated(ruleSet *config.RuleSet) error {
 		return err
 	}
 
+	if err = p.registerRules(ruleSet.Source, rules); err != nil {
+		return err
+	}
+
 	evt := event.RuleSetChanged{
 		Source:     ruleSet.Source,
 		Name:       ruleSet.Name,
@@ -97,6 +112,10 @@ func (p *ruleSetProcessor) OnUpdated(ruleSet *config.RuleSet) error {
 		return err
 	}
 
+	if err = p.registerRules(ruleSet.Source, rules); err != nil {
+		return err
+	}
+
 	evt := event.RuleSetChanged{
 		Source:     ruleSet.Source,
 		Name:       ruleSet.Name,
@@ -110,6 +129,8 @@ func (p *ruleSetProcessor) OnUpdated(ruleSet *config.RuleSet) error {
 }
 
 func (p *ruleSetProcessor) OnDeleted(ruleSet *config.RuleSet) error {
+	p.unregisterRules(ruleSet.Source)
+
 	evt := event.RuleSetChanged{
 		Source:     ruleSet.Source,
 		Name:       ruleSet.Name,
@@ -121,6 +142,63 @@ func (p *ruleSetProcessor) OnDeleted(ruleSet *config.RuleSet) error {
 	return nil
 }
 
+// registerRules checks the given rules for ambiguous overlaps with each other and with the
+// rules from other sources. Detected overlaps are reported as warnings, or, if strict mode
+// is enabled, lead to an error, in which case the rules are not registered.
+func (p *ruleSetProcessor) registerRules(srcID string, rules []rule.Rule) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	sources := make([]string, 0, len(p.rules))
+	for src := range p.rules {
+		if src != srcID {
+			sources = append(sources, src)
+		}
+	}
+
+	slices.Sort(sources)
+
+	var others []rule.Rule
+	for _, src := range sources {
+		others = append(others, p.rules[src]...)
+	}
+
+	var provable []ruleOverlap
+
+	for _, overlap := range findOverlaps(rules, others) {
+		if overlap.provable {
+			provable = append(provable, overlap)
+		}
+
+		evt := x.IfThenElseExec(p.strict && overlap.provable, p.l.Error, p.l.Warn)
+
+		evt.Str("_src", overlap.rul.SrcID()).
+			Str("_id", overlap.rul.ID()).
+			Str("_overlapping_src", overlap.other.SrcID()).
+			Str("_overlapping_id", overlap.other.ID()).
+			Msg(x.IfThenElse(overlap.provable,
+				"Rule overlaps with another rule having the same priority and specificity",
+				"Rule might overlap with another rule having the same priority and specificity"))
+	}
+
+	if p.strict && len(provable) != 0 {
+		return errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+			"rule ID=%s from %s overlaps with rule ID=%s from %s",
+			provable[0].rul.ID(), provable[0].rul.SrcID(), provable[0].other.ID(), provable[0].other.SrcID())
+	}
+
+	p.rules[srcID] = rules
+
+	return nil
+}
+
+func (p *ruleSetProcessor) unregisterRules(srcID string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	delete(p.rules, srcID)
+}
+
 func (p *ruleSetProcessor) sendEvent(evt event.RuleSetChanged) {
 	p.l.Info().
 		Str("_src", evt.Source).
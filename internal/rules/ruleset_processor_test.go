@@ -17,6 +17,7 @@
 package rules
 
 import (
+	"net/http"
 	"testing"
 
 	"github.com/rs/zerolog/log"
@@ -24,6 +25,8 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	config2 "github.com/dadrus/heimdall/internal/config"
+	"github.com/dadrus/heimdall/internal/heimdall"
 	"github.com/dadrus/heimdall/internal/rules/config"
 	"github.com/dadrus/heimdall/internal/rules/event"
 	"github.com/dadrus/heimdall/internal/rules/rule/mocks"
@@ -107,7 +110,7 @@ func TestRuleSetProcessorOnCreated(t *testing.T) {
 			factory := mocks.NewFactoryMock(t)
 			configureFactory(t, factory)
 
-			processor := NewRuleSetProcessor(queue, factory, log.Logger)
+			processor := NewRuleSetProcessor(queue, factory, &config2.Configuration{}, log.Logger)
 
 			// WHEN
 			err := processor.OnCreated(tc.ruleset)
@@ -195,7 +198,7 @@ func TestRuleSetProcessorOnUpdated(t *testing.T) {
 			factory := mocks.NewFactoryMock(t)
 			configureFactory(t, factory)
 
-			processor := NewRuleSetProcessor(queue, factory, log.Logger)
+			processor := NewRuleSetProcessor(queue, factory, &config2.Configuration{}, log.Logger)
 
 			// WHEN
 			err := processor.OnUpdated(tc.ruleset)
@@ -237,7 +240,7 @@ func TestRuleSetProcessorOnDeleted(t *testing.T) {
 		t.Run(tc.uc, func(t *testing.T) {
 			// GIVEM
 			queue := make(event.RuleSetChangedEventQueue, 10)
-			processor := NewRuleSetProcessor(queue, mocks.NewFactoryMock(t), log.Logger)
+			processor := NewRuleSetProcessor(queue, mocks.NewFactoryMock(t), &config2.Configuration{}, log.Logger)
 
 			// WHEN
 			err := processor.OnDeleted(tc.ruleset)
@@ -247,3 +250,89 @@ func TestRuleSetProcessorOnDeleted(t *testing.T) {
 		})
 	}
 }
+
+func TestRuleSetProcessorOverlapDetection(t *testing.T) {
+	t.Parallel()
+
+	createRule := func(t *testing.T, id, srcID, pattern string) *ruleImpl {
+		t.Helper()
+
+		rul := newTestRule(t, id, srcID, config.EncodedSlashesOff, pattern)
+		rul.methods = []string{http.MethodGet}
+
+		return rul
+	}
+
+	for _, tc := range []struct {
+		uc      string
+		strict  bool
+		pattern string
+		assert  func(t *testing.T, err error, queue event.RuleSetChangedEventQueue)
+	}{
+		{
+			uc:      "overlaps are reported as warnings only",
+			pattern: "http://foo.bar/<**>",
+			assert: func(t *testing.T, err error, queue event.RuleSetChangedEventQueue) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.Len(t, queue, 2)
+			},
+		},
+		{
+			uc:      "possible overlaps are reported as warnings only in strict mode",
+			strict:  true,
+			pattern: "http://foo.bar/<*>",
+			assert: func(t *testing.T, err error, queue event.RuleSetChangedEventQueue) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.Len(t, queue, 2)
+			},
+		},
+		{
+			uc:      "provable overlaps result in errors in strict mode",
+			strict:  true,
+			pattern: "http://foo.bar/<**>",
+			assert: func(t *testing.T, err error, queue event.RuleSetChangedEventQueue) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "rule ID=bar from test2 overlaps with rule ID=foo from test1")
+				require.Len(t, queue, 1)
+			},
+		},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			// GIVEN
+			queue := make(event.RuleSetChangedEventQueue, 10)
+			factory := mocks.NewFactoryMock(t)
+
+			factory.EXPECT().CreateRule(mock.Anything, "test1", mock.Anything).
+				Return(createRule(t, "foo", "test1", "http://foo.bar/<**>"), nil)
+			factory.EXPECT().CreateRule(mock.Anything, "test2", mock.Anything).
+				Return(createRule(t, "bar", "test2", tc.pattern), nil)
+
+			conf := &config2.Configuration{Rules: config2.RulesConfig{StrictMode: tc.strict}}
+			processor := NewRuleSetProcessor(queue, factory, conf, log.Logger)
+
+			err := processor.OnCreated(&config.RuleSet{
+				MetaData: config.MetaData{Source: "test1"},
+				Version:  config.CurrentRuleSetVersion,
+				Rules:    []config.Rule{{ID: "foo"}},
+			})
+			require.NoError(t, err)
+
+			// WHEN
+			err = processor.OnCreated(&config.RuleSet{
+				MetaData: config.MetaData{Source: "test2"},
+				Version:  config.CurrentRuleSetVersion,
+				Rules:    []config.Rule{{ID: "bar"}},
+			})
+
+			// THEN
+			tc.assert(t, err, queue)
+		})
+	}
+}
@@ -17,14 +17,21 @@
 package signer
 
 import (
+	"bytes"
+	"context"
 	"crypto"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/sha256"
 	"crypto/x509"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
 	"time"
 
+	"github.com/fsnotify/fsnotify"
 	"github.com/go-jose/go-jose/v3"
 	"github.com/go-jose/go-jose/v3/jwt"
 	"github.com/google/uuid"
@@ -34,18 +41,42 @@ import (
 	"github.com/dadrus/heimdall/internal/config"
 	"github.com/dadrus/heimdall/internal/heimdall"
 	"github.com/dadrus/heimdall/internal/keystore"
+	"github.com/dadrus/heimdall/internal/x"
 	"github.com/dadrus/heimdall/internal/x/errorchain"
 	"github.com/dadrus/heimdall/internal/x/pkix"
 	"github.com/dadrus/heimdall/internal/x/stringx"
 )
 
+const defaultGracePeriod = 1 * time.Hour
+
 func NewJWTSigner(conf *config.Configuration, logger zerolog.Logger) (heimdall.JWTSigner, error) {
 	var (
-		ks  keystore.KeyStore
-		kse *keystore.Entry
-		err error
+		ks     keystore.KeyStore
+		digest []byte
+		err    error
 	)
 
+	signer := &jwtSigner{
+		iss:    conf.Signer.Name,
+		keyID:  conf.Signer.KeyID,
+		path:   conf.Signer.KeyStore.Path,
+		pwd:    conf.Signer.KeyStore.Password,
+		grace:  defaultGracePeriod,
+		logger: logger,
+	}
+
+	if rotation := conf.Signer.Rotation; rotation != nil {
+		signer.watch = rotation.Watch
+		signer.nextKeyID = rotation.NextKeyID
+		signer.activateAt = rotation.ActivateAt
+		signer.grace = x.IfThenElse(rotation.GracePeriod > 0, rotation.GracePeriod, defaultGracePeriod)
+
+		if len(signer.nextKeyID) != 0 && signer.activateAt.IsZero() {
+			return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+				"activation time for the next signer key is not configured")
+		}
+	}
+
 	if len(conf.Signer.KeyStore.Path) == 0 {
 		logger.Warn().
 			Msg("Key store is not configured. NEVER DO IT IN PRODUCTION!!!! Generating an ECDSA P-384 key pair.")
@@ -60,7 +91,7 @@ func NewJWTSigner(conf *config.Configuration, logger zerolog.Logger) (heimdall.J
 
 		ks, err = keystore.NewKeyStoreFromKey(privateKey)
 	} else {
-		ks, err = keystore.NewKeyStoreFromPEMFile(conf.Signer.KeyStore.Path, conf.Signer.KeyStore.Password)
+		ks, digest, err = readKeyStore(conf.Signer.KeyStore.Path, conf.Signer.KeyStore.Password)
 	}
 
 	if err != nil {
@@ -79,47 +110,53 @@ func NewJWTSigner(conf *config.Configuration, logger zerolog.Logger) (heimdall.J
 
 	if len(conf.Signer.KeyID) == 0 {
 		logger.Warn().Msg("No key id for signer configured. Taking first entry from the key store")
-
-		kse, err = ks.Entries()[0], nil
-	} else {
-		kse, err = ks.GetKey(conf.Signer.KeyID)
 	}
 
-	if err != nil {
+	if err = signer.update(ks, digest, time.Now()); err != nil {
 		return nil, err
 	}
 
-	if len(kse.CertChain) != 0 {
-		if err = pkix.ValidateCertificate(kse.CertChain[0],
-			pkix.WithKeyUsage(x509.KeyUsageDigitalSignature),
-			pkix.WithRootCACertificates([]*x509.Certificate{kse.CertChain[len(kse.CertChain)-1]}),
-			pkix.WithCurrentTime(time.Now()),
-		); err != nil {
-			logger.Error().Err(err).Msg("Failed validating certificate")
+	logger.Info().Str("_key_id", signer.jwk.KeyID).Msg("Signer configured")
 
-			return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
-				"configured certificate cannot be used for JWT signing purposes").CausedBy(err)
-		}
-	}
-
-	logger.Info().Str("_key_id", kse.KeyID).Msg("Signer configured")
+	return signer, nil
+}
 
-	return &jwtSigner{
-		iss: conf.Signer.Name,
-		jwk: kse.JWK(),
-		key: kse.PrivateKey,
-		ks:  ks,
-	}, nil
+type retiredKey struct {
+	jwk   jose.JSONWebKey
+	until time.Time
 }
 
 type jwtSigner struct {
-	iss string
-	jwk jose.JSONWebKey
-	key crypto.Signer
-	ks  keystore.KeyStore
+	iss        string
+	keyID      string
+	nextKeyID  string
+	activateAt time.Time
+	grace      time.Duration
+	path       string
+	pwd        string
+	watch      bool
+	logger     zerolog.Logger
+
+	// serializes reload and activateNextKey, so that neither overwrites the key store set by
+	// the other one with an outdated one
+	updateMu sync.Mutex
+
+	mu      sync.RWMutex
+	jwk     jose.JSONWebKey
+	key     crypto.Signer
+	ks      keystore.KeyStore
+	digest  []byte
+	retired []retiredKey
+
+	w     *fsnotify.Watcher
+	done  chan struct{}
+	timer *time.Timer
 }
 
 func (s *jwtSigner) Hash() []byte {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	hash := sha256.New()
 	hash.Write(stringx.ToBytes(s.jwk.KeyID))
 	hash.Write(stringx.ToBytes(s.jwk.Algorithm))
@@ -129,14 +166,18 @@ func (s *jwtSigner) Hash() []byte {
 }
 
 func (s *jwtSigner) Sign(sub string, ttl time.Duration, custClaims map[string]any) (string, error) {
+	s.mu.RLock()
+	jwk, key := s.jwk, s.key
+	s.mu.RUnlock()
+
 	signerOpts := jose.SignerOptions{}
 	signerOpts.
 		WithType("JWT").
-		WithHeader("kid", s.jwk.KeyID).
-		WithHeader("alg", s.jwk.Algorithm)
+		WithHeader("kid", jwk.KeyID).
+		WithHeader("alg", jwk.Algorithm)
 
 	signer, err := jose.NewSigner(
-		jose.SigningKey{Algorithm: jose.SignatureAlgorithm(s.jwk.Algorithm), Key: s.key},
+		jose.SigningKey{Algorithm: jose.SignatureAlgorithm(jwk.Algorithm), Key: key},
 		&signerOpts)
 	if err != nil {
 		return "", errorchain.NewWithMessage(heimdall.ErrInternal, "failed to create JWT signer").CausedBy(err)
@@ -164,12 +205,302 @@ func (s *jwtSigner) Sign(sub string, ttl time.Duration, custClaims map[string]an
 	return rawJwt, nil
 }
 
+// Keys returns the keys from the key store, which includes the active and the next key,
+// as well as the retired keys for which the grace period has not yet passed.
 func (s *jwtSigner) Keys() []jose.JSONWebKey {
-	keys := make([]jose.JSONWebKey, len(s.ks.Entries()))
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 
-	for idx, entry := range s.ks.Entries() {
-		keys[idx] = entry.JWK()
+	now := time.Now()
+	entries := s.ks.Entries()
+	keys := make([]jose.JSONWebKey, 0, len(entries)+len(s.retired))
+
+	for _, entry := range entries {
+		if idx := s.retiredKeyIndex(entry.KeyID); idx == -1 || now.Before(s.retired[idx].until) {
+			keys = append(keys, entry.JWK())
+		}
+	}
+
+	for _, key := range s.retired {
+		if _, err := s.ks.GetKey(key.jwk.KeyID); err != nil && now.Before(key.until) {
+			keys = append(keys, key.jwk)
+		}
 	}
 
 	return keys
 }
+
+func (s *jwtSigner) start(_ context.Context) error {
+	if len(s.nextKeyID) != 0 {
+		if wait := time.Until(s.activateAt); wait > 0 {
+			s.logger.Info().
+				Str("_key_id", s.nextKeyID).
+				Time("_activate_at", s.activateAt).
+				Msg("Scheduling activation of the next signer key")
+
+			s.timer = time.AfterFunc(wait, s.activateNextKey)
+		}
+	}
+
+	if !s.watch {
+		return nil
+	}
+
+	if len(s.path) == 0 {
+		s.logger.Warn().Msg("Key store is not configured. Watching it for changes is not possible")
+
+		return nil
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return errorchain.NewWithMessage(heimdall.ErrInternal, "failed to instantiate key store watcher").
+			CausedBy(err)
+	}
+
+	// the directory is watched, as the file itself might be replaced, like it is the
+	// case with kubernetes secrets mounted as volumes
+	if err = watcher.Add(filepath.Dir(s.path)); err != nil {
+		watcher.Close()
+
+		return errorchain.NewWithMessage(heimdall.ErrInternal, "failed to watch key store").
+			CausedBy(err)
+	}
+
+	s.w = watcher
+	s.done = make(chan struct{})
+
+	go s.watchKeyStore()
+
+	return nil
+}
+
+func (s *jwtSigner) stop(ctx context.Context) error {
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+
+	if s.w == nil {
+		return nil
+	}
+
+	err := s.w.Close()
+
+	// closing the watcher terminates the watching goroutine, which might however be
+	// reloading the key store right now
+	select {
+	case <-s.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	return err
+}
+
+func (s *jwtSigner) watchKeyStore() {
+	defer close(s.done)
+
+	s.logger.Debug().Msg("Watching key store for changes")
+
+	for {
+		select {
+		case _, ok := <-s.w.Events:
+			if !ok {
+				s.logger.Debug().Msg("Key store watcher closed")
+
+				return
+			}
+
+			s.reload()
+		case err, ok := <-s.w.Errors:
+			if !ok {
+				s.logger.Debug().Msg("Key store watcher error channel closed")
+
+				return
+			}
+
+			s.logger.Warn().Err(err).Msg("Key store watcher error received")
+		}
+	}
+}
+
+func (s *jwtSigner) reload() {
+	s.updateMu.Lock()
+	defer s.updateMu.Unlock()
+
+	ks, digest, err := readKeyStore(s.path, s.pwd)
+	if err != nil {
+		s.logger.Warn().Err(err).Msg("Failed to reload key store. Keeping the current one")
+
+		return
+	}
+
+	s.mu.RLock()
+	unchanged := bytes.Equal(s.digest, digest)
+	s.mu.RUnlock()
+
+	if unchanged {
+		return
+	}
+
+	s.logger.Info().Msg("Key store changed")
+
+	if err = s.update(ks, digest, time.Now()); err != nil {
+		s.logger.Warn().Err(err).Msg("Failed to apply key store changes. Keeping the current key store")
+	}
+}
+
+func (s *jwtSigner) activateNextKey() {
+	s.updateMu.Lock()
+	defer s.updateMu.Unlock()
+
+	s.mu.RLock()
+	ks, digest := s.ks, s.digest
+	s.mu.RUnlock()
+
+	if err := s.update(ks, digest, time.Now()); err != nil {
+		s.logger.Error().Err(err).Str("_key_id", s.nextKeyID).Msg("Failed to activate next signer key")
+	}
+}
+
+func (s *jwtSigner) update(ks keystore.KeyStore, digest []byte, now time.Time) error {
+	active, err := s.selectKey(ks, now)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ks != nil {
+		// keys removed from the key store remain published for the grace period
+		for _, entry := range s.ks.Entries() {
+			if _, err := ks.GetKey(entry.KeyID); err != nil {
+				s.retire(entry.JWK(), now)
+			}
+		}
+
+		if s.jwk.KeyID != active.KeyID {
+			s.logger.Info().
+				Str("_previous_key_id", s.jwk.KeyID).
+				Str("_key_id", active.KeyID).
+				Msg("Signer key rotated")
+
+			s.retire(s.jwk, now)
+		}
+	}
+
+	// a previously retired key might have become the active one again. Retired keys still present
+	// in the key store are kept even after the grace period to not publish these again.
+	s.retired = slices.DeleteFunc(s.retired, func(key retiredKey) bool {
+		if key.jwk.KeyID == active.KeyID {
+			return true
+		}
+
+		_, err := ks.GetKey(key.jwk.KeyID)
+
+		return err != nil && !now.Before(key.until)
+	})
+
+	s.ks = ks
+	s.digest = digest
+	s.jwk = active.JWK()
+	s.key = active.PrivateKey
+
+	return nil
+}
+
+func (s *jwtSigner) retire(jwk jose.JSONWebKey, now time.Time) {
+	if s.retiredKeyIndex(jwk.KeyID) != -1 {
+		return
+	}
+
+	s.retired = append(s.retired, retiredKey{jwk: jwk, until: now.Add(s.grace)})
+}
+
+func (s *jwtSigner) retiredKeyIndex(keyID string) int {
+	return slices.IndexFunc(s.retired, func(key retiredKey) bool { return key.jwk.KeyID == keyID })
+}
+
+// selectKey returns the key to be used for signing at the given time. It also ensures,
+// the next key, if configured, is present in the given key store and can be used.
+func (s *jwtSigner) selectKey(ks keystore.KeyStore, now time.Time) (*keystore.Entry, error) {
+	var (
+		active *keystore.Entry
+		err    error
+	)
+
+	if len(ks.Entries()) == 0 {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration, "key store does not contain any keys")
+	}
+
+	if len(s.keyID) == 0 {
+		active = ks.Entries()[0]
+	} else if active, err = ks.GetKey(s.keyID); err != nil {
+		return nil, err
+	}
+
+	if err = validateEntry(active); err != nil {
+		return nil, err
+	}
+
+	if len(s.nextKeyID) == 0 {
+		return active, nil
+	}
+
+	next, err := ks.GetKey(s.nextKeyID)
+	if err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"next signer key is not present in the key store").CausedBy(err)
+	}
+
+	if err = validateEntry(next); err != nil {
+		return nil, err
+	}
+
+	return x.IfThenElse(now.Before(s.activateAt), active, next), nil
+}
+
+func validateEntry(kse *keystore.Entry) error {
+	if len(kse.CertChain) == 0 {
+		return nil
+	}
+
+	if err := pkix.ValidateCertificate(kse.CertChain[0],
+		pkix.WithKeyUsage(x509.KeyUsageDigitalSignature),
+		pkix.WithRootCACertificates([]*x509.Certificate{kse.CertChain[len(kse.CertChain)-1]}),
+		pkix.WithCurrentTime(time.Now()),
+	); err != nil {
+		return errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"configured certificate cannot be used for JWT signing purposes").CausedBy(err)
+	}
+
+	return nil
+}
+
+func readKeyStore(path, password string) (keystore.KeyStore, []byte, error) {
+	fInfo, err := os.Stat(path)
+	if err != nil {
+		return nil, nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+			"failed to get information about %s", path).CausedBy(err)
+	}
+
+	if fInfo.IsDir() {
+		return nil, nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration, "'%s' is not a file", path)
+	}
+
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration, "failed to read %s", path).
+			CausedBy(err)
+	}
+
+	ks, err := keystore.NewKeyStoreFromPEMBytes(contents, password)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	digest := sha256.Sum256(contents)
+
+	return ks, digest[:], nil
+}
@@ -17,6 +17,7 @@
 package signer
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
@@ -511,3 +512,274 @@ func TestJwtSignerKeys(t *testing.T) {
 	assert.Equal(t, "PS256", keys[0].Algorithm)
 	assert.Equal(t, "ES256", keys[1].Algorithm)
 }
+
+func TestJWTSignerRotationConfiguration(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	privKey1, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	privKey2, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	pemBytes, err := pemx.BuildPEM(
+		pemx.WithECDSAPrivateKey(privKey1, pemx.WithHeader("X-Key-ID", "key1")),
+		pemx.WithECDSAPrivateKey(privKey2, pemx.WithHeader("X-Key-ID", "key2")),
+	)
+	require.NoError(t, err)
+
+	keyFile := filepath.Join(t.TempDir(), "keys.pem")
+	require.NoError(t, os.WriteFile(keyFile, pemBytes, 0o600))
+
+	for _, tc := range []struct {
+		uc       string
+		rotation *config.KeyRotation
+		assert   func(t *testing.T, err error, signer *jwtSigner)
+	}{
+		{
+			uc:       "next key without activation time",
+			rotation: &config.KeyRotation{NextKeyID: "key2"},
+			assert: func(t *testing.T, err error, _ *jwtSigner) {
+				t.Helper()
+
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				require.ErrorContains(t, err, "activation time")
+			},
+		},
+		{
+			uc:       "next key not present in the key store",
+			rotation: &config.KeyRotation{NextKeyID: "key3", ActivateAt: time.Now().Add(time.Hour)},
+			assert: func(t *testing.T, err error, _ *jwtSigner) {
+				t.Helper()
+
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				require.ErrorIs(t, err, keystore.ErrNoSuchKey)
+			},
+		},
+		{
+			uc:       "next key to be activated in the future",
+			rotation: &config.KeyRotation{NextKeyID: "key2", ActivateAt: time.Now().Add(time.Hour)},
+			assert: func(t *testing.T, err error, signer *jwtSigner) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "key1", signer.jwk.KeyID)
+				assert.Equal(t, defaultGracePeriod, signer.grace)
+				assert.Len(t, signer.Keys(), 2)
+			},
+		},
+		{
+			uc: "activation time of the next key already passed",
+			rotation: &config.KeyRotation{
+				NextKeyID:   "key2",
+				ActivateAt:  time.Now().Add(-time.Hour),
+				GracePeriod: 10 * time.Minute,
+			},
+			assert: func(t *testing.T, err error, signer *jwtSigner) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "key2", signer.jwk.KeyID)
+				assert.Equal(t, 10*time.Minute, signer.grace)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// WHEN
+			signer, err := NewJWTSigner(&config.Configuration{
+				Signer: config.SignerConfig{
+					KeyStore: config.KeyStore{Path: keyFile},
+					KeyID:    "key1",
+					Rotation: tc.rotation,
+				},
+			}, log.Logger)
+
+			// THEN
+			var impl *jwtSigner
+
+			if err == nil {
+				var ok bool
+
+				impl, ok = signer.(*jwtSigner)
+				require.True(t, ok)
+			}
+
+			tc.assert(t, err, impl)
+		})
+	}
+}
+
+func TestJWTSignerActivatesNextKeyOnSchedule(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	privKey1, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	privKey2, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	pemBytes, err := pemx.BuildPEM(
+		pemx.WithECDSAPrivateKey(privKey1, pemx.WithHeader("X-Key-ID", "key1")),
+		pemx.WithECDSAPrivateKey(privKey2, pemx.WithHeader("X-Key-ID", "key2")),
+	)
+	require.NoError(t, err)
+
+	keyFile := filepath.Join(t.TempDir(), "keys.pem")
+	require.NoError(t, os.WriteFile(keyFile, pemBytes, 0o600))
+
+	signer, err := NewJWTSigner(&config.Configuration{
+		Signer: config.SignerConfig{
+			KeyStore: config.KeyStore{Path: keyFile},
+			KeyID:    "key1",
+			Rotation: &config.KeyRotation{
+				NextKeyID:   "key2",
+				ActivateAt:  time.Now().Add(200 * time.Millisecond),
+				GracePeriod: 500 * time.Millisecond,
+			},
+		},
+	}, log.Logger)
+	require.NoError(t, err)
+
+	impl, ok := signer.(*jwtSigner)
+	require.True(t, ok)
+
+	hash := signer.Hash()
+
+	// WHEN
+	require.NoError(t, impl.start(context.Background()))
+
+	defer impl.stop(context.Background())
+
+	// THEN
+	assert.Eventually(t, func() bool {
+		token, err := signer.Sign("foo", time.Minute, nil)
+		require.NoError(t, err)
+
+		parsed, err := jwt.ParseSigned(token)
+		require.NoError(t, err)
+
+		return parsed.Headers[0].KeyID == "key2"
+	}, 2*time.Second, 20*time.Millisecond)
+
+	assert.NotEqual(t, hash, signer.Hash())
+	assert.Len(t, signer.Keys(), 2)
+
+	// the retired key is not published after the grace period
+	assert.Eventually(t, func() bool {
+		keys := signer.Keys()
+
+		return len(keys) == 1 && keys[0].KeyID == "key2"
+	}, 2*time.Second, 20*time.Millisecond)
+
+	// and not published again on later updates as long as it is present in the key store
+	impl.mu.RLock()
+	ks, digest := impl.ks, impl.digest
+	impl.mu.RUnlock()
+
+	require.NoError(t, impl.update(ks, digest, time.Now()))
+
+	keys := signer.Keys()
+	require.Len(t, keys, 1)
+	assert.Equal(t, "key2", keys[0].KeyID)
+}
+
+func TestJWTSignerStopWaitsForKeyStoreWatcher(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	pemBytes, err := pemx.BuildPEM(pemx.WithECDSAPrivateKey(privKey, pemx.WithHeader("X-Key-ID", "key1")))
+	require.NoError(t, err)
+
+	keyFile := filepath.Join(t.TempDir(), "keys.pem")
+	require.NoError(t, os.WriteFile(keyFile, pemBytes, 0o600))
+
+	signer, err := NewJWTSigner(&config.Configuration{
+		Signer: config.SignerConfig{
+			KeyStore: config.KeyStore{Path: keyFile},
+			Rotation: &config.KeyRotation{Watch: true},
+		},
+	}, log.Logger)
+	require.NoError(t, err)
+
+	impl, ok := signer.(*jwtSigner)
+	require.True(t, ok)
+
+	require.NoError(t, impl.start(context.Background()))
+
+	// WHEN
+	err = impl.stop(context.Background())
+
+	// THEN
+	require.NoError(t, err)
+
+	select {
+	case <-impl.done:
+	default:
+		t.Fatal("key store watcher is still running")
+	}
+}
+
+func TestJWTSignerReloadsKeyStoreOnChange(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	privKey1, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	privKey2, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	pemBytes1, err := pemx.BuildPEM(pemx.WithECDSAPrivateKey(privKey1, pemx.WithHeader("X-Key-ID", "key1")))
+	require.NoError(t, err)
+
+	pemBytes2, err := pemx.BuildPEM(pemx.WithECDSAPrivateKey(privKey2, pemx.WithHeader("X-Key-ID", "key2")))
+	require.NoError(t, err)
+
+	keyFile := filepath.Join(t.TempDir(), "keys.pem")
+	require.NoError(t, os.WriteFile(keyFile, pemBytes1, 0o600))
+
+	signer, err := NewJWTSigner(&config.Configuration{
+		Signer: config.SignerConfig{
+			KeyStore: config.KeyStore{Path: keyFile},
+			Rotation: &config.KeyRotation{Watch: true, GracePeriod: time.Hour},
+		},
+	}, log.Logger)
+	require.NoError(t, err)
+
+	impl, ok := signer.(*jwtSigner)
+	require.True(t, ok)
+
+	require.NoError(t, impl.start(context.Background()))
+
+	defer impl.stop(context.Background())
+
+	// WHEN
+	require.NoError(t, os.WriteFile(keyFile, []byte("garbage"), 0o600))
+	time.Sleep(200 * time.Millisecond)
+
+	// THEN
+	impl.mu.RLock()
+	assert.Equal(t, "key1", impl.jwk.KeyID)
+	impl.mu.RUnlock()
+
+	// WHEN
+	require.NoError(t, os.WriteFile(keyFile, pemBytes2, 0o600))
+
+	// THEN
+	assert.Eventually(t, func() bool {
+		impl.mu.RLock()
+		defer impl.mu.RUnlock()
+
+		return impl.jwk.KeyID == "key2"
+	}, 2*time.Second, 20*time.Millisecond)
+
+	keys := signer.Keys()
+	require.Len(t, keys, 2)
+	assert.Equal(t, "key2", keys[0].KeyID)
+	assert.Equal(t, "key1", keys[1].KeyID)
+}
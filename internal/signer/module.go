@@ -16,10 +16,39 @@
 
 package signer
 
-import "go.uber.org/fx"
+import (
+	"context"
+
+	"go.uber.org/fx"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+)
 
 // Module is used on app bootstrap.
 // nolint: gochecknoglobals
 var Module = fx.Options(
-	fx.Provide(NewJWTSigner),
+	fx.Provide(
+		fx.Annotate(
+			NewJWTSigner,
+			fx.OnStart(func(ctx context.Context, signer heimdall.JWTSigner) error {
+				if lc, ok := signer.(lifecycle); ok {
+					return lc.start(ctx)
+				}
+
+				return nil
+			}),
+			fx.OnStop(func(ctx context.Context, signer heimdall.JWTSigner) error {
+				if lc, ok := signer.(lifecycle); ok {
+					return lc.stop(ctx)
+				}
+
+				return nil
+			}),
+		),
+	),
 )
+
+type lifecycle interface {
+	start(ctx context.Context) error
+	stop(ctx context.Context) error
+}
@@ -0,0 +1,72 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package pkix
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"net"
+	"net/url"
+
+	"github.com/dadrus/heimdall/internal/x/slicex"
+)
+
+const spiffeScheme = "spiffe"
+
+// CertificateInfo returns the information from the given certificate, which is relevant for
+// authentication and authorization purposes, like the subject, the issuer, the subject
+// alternative names, or the SPIFFE ID.
+func CertificateInfo(cert *x509.Certificate) map[string]any {
+	uris := slicex.Map(cert.URIs, func(uri *url.URL) string { return uri.String() })
+	ips := slicex.Map(cert.IPAddresses, func(ip net.IP) string { return ip.String() })
+	fingerprint := sha256.Sum256(cert.Raw)
+
+	info := map[string]any{
+		"subject": map[string]any{
+			"dn":                  cert.Subject.String(),
+			"common_name":         cert.Subject.CommonName,
+			"organization":        cert.Subject.Organization,
+			"organizational_unit": cert.Subject.OrganizationalUnit,
+			"country":             cert.Subject.Country,
+		},
+		"issuer": map[string]any{
+			"dn":          cert.Issuer.String(),
+			"common_name": cert.Issuer.CommonName,
+		},
+		"serial_number": cert.SerialNumber.String(),
+		"not_before":    cert.NotBefore.Unix(),
+		"not_after":     cert.NotAfter.Unix(),
+		"fingerprint":   hex.EncodeToString(fingerprint[:]),
+		"sans": map[string]any{
+			"dns":   cert.DNSNames,
+			"email": cert.EmailAddresses,
+			"ip":    ips,
+			"uri":   uris,
+		},
+	}
+
+	for _, uri := range cert.URIs {
+		if uri.Scheme == spiffeScheme {
+			info["spiffe_id"] = uri.String()
+
+			break
+		}
+	}
+
+	return info
+}
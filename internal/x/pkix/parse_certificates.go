@@ -0,0 +1,51 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package pkix
+
+import (
+	"crypto/x509"
+
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+	"github.com/dadrus/heimdall/internal/x/pkix/pemx"
+)
+
+const pemBlockTypeCertificate = "CERTIFICATE"
+
+// ParseCertificates parses the PEM encoded certificates from the given bytes. Blocks of other
+// types are not expected and result in an error.
+func ParseCertificates(pemBytes []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+
+	err := pemx.ReadPEM(pemBytes, func(idx int, blockType string, _ map[string]string, content []byte) error {
+		if blockType != pemBlockTypeCertificate {
+			return errorchain.NewWithMessagef(ErrCertificateParsing,
+				"unexpected entry '%s' at position %d", blockType, idx)
+		}
+
+		cert, err := x509.ParseCertificate(content)
+		if err != nil {
+			return errorchain.NewWithMessagef(ErrCertificateParsing,
+				"failed to parse entry at position %d", idx).CausedBy(err)
+		}
+
+		certs = append(certs, cert)
+
+		return nil
+	})
+
+	return certs, err
+}
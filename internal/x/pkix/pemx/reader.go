@@ -28,6 +28,10 @@ func ReadPEM(pemBytes []byte, callback PEMBlockCallback) error {
 
 	for {
 		block, next = pem.Decode(next)
+		if block == nil {
+			break
+		}
+
 		if err := callback(idx, block.Type, block.Headers, block.Bytes); err != nil {
 			return err
 		}
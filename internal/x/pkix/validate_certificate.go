@@ -27,6 +27,7 @@ import (
 var (
 	ErrCertificateValidation = errors.New("certificate validation error")
 	ErrMissingKeyUsage       = errors.New("missing key usage")
+	ErrCertificateParsing    = errors.New("certificate parsing error")
 )
 
 type keyUsageCheck func(setUsage x509.KeyUsage) error
@@ -0,0 +1,361 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package structuredfields
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const maxIntegerDigits = 15
+
+// ParseDictionary parses the given field value as a dictionary as defined in RFC 8941,
+// section 4.2.2. The order of the members is preserved. If a key is present multiple
+// times, the value of its last occurrence is used.
+func ParseDictionary(value string) ([]DictionaryMember, error) {
+	var members []DictionaryMember
+
+	parser := &parser{data: value}
+	parser.skipSpaces()
+
+	for !parser.eof() {
+		key, err := parser.parseKey()
+		if err != nil {
+			return nil, err
+		}
+
+		var member any
+
+		if !parser.eof() && parser.peek() == '=' {
+			parser.pos++
+
+			member, err = parser.parseItemOrInnerList()
+		} else {
+			// a member without value is a boolean true
+			var params Params
+
+			params, err = parser.parseParams()
+			member = Item{Value: true, Params: params}
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		members = setMember(members, key, member)
+
+		parser.skipOWS()
+
+		if parser.eof() {
+			return members, nil
+		}
+
+		if parser.peek() != ',' {
+			return nil, fmt.Errorf("%w: unexpected character at position %d", ErrMalformed, parser.pos)
+		}
+
+		parser.pos++
+		parser.skipOWS()
+
+		if parser.eof() {
+			return nil, fmt.Errorf("%w: trailing comma", ErrMalformed)
+		}
+	}
+
+	return members, nil
+}
+
+func setMember(members []DictionaryMember, key string, value any) []DictionaryMember {
+	for idx := range members {
+		if members[idx].Key == key {
+			members[idx].Value = value
+
+			return members
+		}
+	}
+
+	return append(members, DictionaryMember{Key: key, Value: value})
+}
+
+type parser struct {
+	data string
+	pos  int
+}
+
+func (p *parser) eof() bool { return p.pos >= len(p.data) }
+
+func (p *parser) peek() byte { return p.data[p.pos] }
+
+func (p *parser) skipSpaces() {
+	for !p.eof() && p.peek() == ' ' {
+		p.pos++
+	}
+}
+
+func (p *parser) skipOWS() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.pos++
+	}
+}
+
+func (p *parser) parseKey() (string, error) {
+	start := p.pos
+
+	if p.eof() || !(isLCAlpha(p.peek()) || p.peek() == '*') {
+		return "", fmt.Errorf("%w: invalid key at position %d", ErrMalformed, p.pos)
+	}
+
+	for !p.eof() && (isLCAlpha(p.peek()) || isDigit(p.peek()) || strings.IndexByte("_-.*", p.peek()) >= 0) {
+		p.pos++
+	}
+
+	return p.data[start:p.pos], nil
+}
+
+func (p *parser) parseItemOrInnerList() (any, error) {
+	if !p.eof() && p.peek() == '(' {
+		return p.parseInnerList()
+	}
+
+	return p.parseItem()
+}
+
+func (p *parser) parseInnerList() (InnerList, error) {
+	p.pos++
+
+	var items []Item
+
+	for !p.eof() {
+		p.skipSpaces()
+
+		if p.eof() {
+			break
+		}
+
+		if p.peek() == ')' {
+			p.pos++
+
+			params, err := p.parseParams()
+			if err != nil {
+				return InnerList{}, err
+			}
+
+			return InnerList{Items: items, Params: params}, nil
+		}
+
+		item, err := p.parseItem()
+		if err != nil {
+			return InnerList{}, err
+		}
+
+		items = append(items, item)
+
+		if !p.eof() && p.peek() != ' ' && p.peek() != ')' {
+			return InnerList{}, fmt.Errorf("%w: unexpected character in inner list at position %d",
+				ErrMalformed, p.pos)
+		}
+	}
+
+	return InnerList{}, fmt.Errorf("%w: unterminated inner list", ErrMalformed)
+}
+
+func (p *parser) parseItem() (Item, error) {
+	value, err := p.parseBareItem()
+	if err != nil {
+		return Item{}, err
+	}
+
+	params, err := p.parseParams()
+	if err != nil {
+		return Item{}, err
+	}
+
+	return Item{Value: value, Params: params}, nil
+}
+
+func (p *parser) parseParams() (Params, error) {
+	var params Params
+
+	for !p.eof() && p.peek() == ';' {
+		p.pos++
+		p.skipSpaces()
+
+		key, err := p.parseKey()
+		if err != nil {
+			return nil, err
+		}
+
+		var value any = true
+
+		if !p.eof() && p.peek() == '=' {
+			p.pos++
+
+			if value, err = p.parseBareItem(); err != nil {
+				return nil, err
+			}
+		}
+
+		params = setParam(params, key, value)
+	}
+
+	return params, nil
+}
+
+func setParam(params Params, key string, value any) Params {
+	for idx := range params {
+		if params[idx].Key == key {
+			params[idx].Value = value
+
+			return params
+		}
+	}
+
+	return append(params, Param{Key: key, Value: value})
+}
+
+func (p *parser) parseBareItem() (any, error) {
+	if p.eof() {
+		return nil, fmt.Errorf("%w: unexpected end of input", ErrMalformed)
+	}
+
+	switch chr := p.peek(); {
+	case chr == '"':
+		return p.parseString()
+	case chr == ':':
+		return p.parseByteSequence()
+	case chr == '?':
+		return p.parseBoolean()
+	case chr == '-' || isDigit(chr):
+		return p.parseInteger()
+	case isAlpha(chr) || chr == '*':
+		return p.parseToken(), nil
+	default:
+		return nil, fmt.Errorf("%w: unexpected character at position %d", ErrMalformed, p.pos)
+	}
+}
+
+func (p *parser) parseString() (string, error) {
+	var builder strings.Builder
+
+	p.pos++
+
+	for !p.eof() {
+		chr := p.peek()
+		p.pos++
+
+		switch {
+		case chr == '\\':
+			if p.eof() || (p.peek() != '"' && p.peek() != '\\') {
+				return "", fmt.Errorf("%w: invalid escape sequence in string", ErrMalformed)
+			}
+
+			builder.WriteByte(p.peek())
+			p.pos++
+		case chr == '"':
+			return builder.String(), nil
+		case chr < 0x20 || chr > 0x7e:
+			return "", fmt.Errorf("%w: invalid character in string", ErrMalformed)
+		default:
+			builder.WriteByte(chr)
+		}
+	}
+
+	return "", fmt.Errorf("%w: unterminated string", ErrMalformed)
+}
+
+func (p *parser) parseByteSequence() ([]byte, error) {
+	p.pos++
+
+	end := strings.IndexByte(p.data[p.pos:], ':')
+	if end < 0 {
+		return nil, fmt.Errorf("%w: unterminated byte sequence", ErrMalformed)
+	}
+
+	encoded := p.data[p.pos : p.pos+end]
+	p.pos += end + 1
+
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid byte sequence: %w", ErrMalformed, err)
+	}
+
+	return data, nil
+}
+
+func (p *parser) parseBoolean() (bool, error) {
+	p.pos++
+
+	if p.eof() || (p.peek() != '0' && p.peek() != '1') {
+		return false, fmt.Errorf("%w: invalid boolean", ErrMalformed)
+	}
+
+	value := p.peek() == '1'
+	p.pos++
+
+	return value, nil
+}
+
+func (p *parser) parseInteger() (int64, error) {
+	start := p.pos
+
+	if p.peek() == '-' {
+		p.pos++
+	}
+
+	digitsStart := p.pos
+
+	for !p.eof() && isDigit(p.peek()) {
+		p.pos++
+	}
+
+	if !p.eof() && p.peek() == '.' {
+		return 0, fmt.Errorf("%w: decimals are not supported", ErrMalformed)
+	}
+
+	if digits := p.pos - digitsStart; digits == 0 || digits > maxIntegerDigits {
+		return 0, fmt.Errorf("%w: integers must have between 1 and %d digits", ErrMalformed, maxIntegerDigits)
+	}
+
+	value, err := strconv.ParseInt(p.data[start:p.pos], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid integer: %w", ErrMalformed, err)
+	}
+
+	return value, nil
+}
+
+func (p *parser) parseToken() Token {
+	start := p.pos
+
+	for !p.eof() && isTokenChar(p.peek()) {
+		p.pos++
+	}
+
+	return Token(p.data[start:p.pos])
+}
+
+func isLCAlpha(chr byte) bool { return chr >= 'a' && chr <= 'z' }
+
+func isAlpha(chr byte) bool { return isLCAlpha(chr) || (chr >= 'A' && chr <= 'Z') }
+
+func isDigit(chr byte) bool { return chr >= '0' && chr <= '9' }
+
+func isTokenChar(chr byte) bool {
+	return isAlpha(chr) || isDigit(chr) || strings.IndexByte("!#$%&'*+-.^_`|~:/", chr) >= 0
+}
@@ -0,0 +1,253 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package structuredfields
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDictionary(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc     string
+		value  string
+		assert func(t *testing.T, err error, members []DictionaryMember)
+	}{
+		{
+			uc:    "empty value",
+			value: "  ",
+			assert: func(t *testing.T, err error, members []DictionaryMember) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Empty(t, members)
+			},
+		},
+		{
+			uc:    "members of all supported types with optional whitespaces",
+			value: `a=:Zm9v:,  b="b\"a\\r";x=1 ,	c=tok/en, d=-42;y;z=?0, e=?1, f=(1 "two" three;p=:YmFy:);q`,
+			assert: func(t *testing.T, err error, members []DictionaryMember) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, []DictionaryMember{
+					{Key: "a", Value: Item{Value: []byte("foo")}},
+					{Key: "b", Value: Item{Value: `b"a\r`, Params: Params{{Key: "x", Value: int64(1)}}}},
+					{Key: "c", Value: Item{Value: Token("tok/en")}},
+					{Key: "d", Value: Item{Value: int64(-42), Params: Params{{Key: "y", Value: true}, {Key: "z", Value: false}}}},
+					{Key: "e", Value: Item{Value: true}},
+					{Key: "f", Value: InnerList{
+						Items: []Item{
+							{Value: int64(1)},
+							{Value: "two"},
+							{Value: Token("three"), Params: Params{{Key: "p", Value: []byte("bar")}}},
+						},
+						Params: Params{{Key: "q", Value: true}},
+					}},
+				}, members)
+			},
+		},
+		{
+			uc:    "member without value",
+			value: "a;foo=bar, b=()",
+			assert: func(t *testing.T, err error, members []DictionaryMember) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, []DictionaryMember{
+					{Key: "a", Value: Item{Value: true, Params: Params{{Key: "foo", Value: Token("bar")}}}},
+					{Key: "b", Value: InnerList{}},
+				}, members)
+			},
+		},
+		{
+			uc:    "duplicate keys",
+			value: "a=1;x=1;x=2, b=2, a=3",
+			assert: func(t *testing.T, err error, members []DictionaryMember) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, []DictionaryMember{
+					{Key: "a", Value: Item{Value: int64(3)}},
+					{Key: "b", Value: Item{Value: int64(2)}},
+				}, members)
+			},
+		},
+		{
+			uc:    "invalid key",
+			value: "A=1",
+			assert: func(t *testing.T, err error, _ []DictionaryMember) {
+				t.Helper()
+
+				require.ErrorIs(t, err, ErrMalformed)
+				require.ErrorContains(t, err, "invalid key")
+			},
+		},
+		{
+			uc:    "invalid base64 encoding",
+			value: "a=:Zm9v!:",
+			assert: func(t *testing.T, err error, _ []DictionaryMember) {
+				t.Helper()
+
+				require.ErrorIs(t, err, ErrMalformed)
+				require.ErrorContains(t, err, "invalid byte sequence")
+			},
+		},
+		{
+			uc:    "unterminated byte sequence",
+			value: "a=:Zm9v",
+			assert: func(t *testing.T, err error, _ []DictionaryMember) {
+				t.Helper()
+
+				require.ErrorIs(t, err, ErrMalformed)
+				require.ErrorContains(t, err, "unterminated byte sequence")
+			},
+		},
+		{
+			uc:    "unterminated string",
+			value: `a="foo`,
+			assert: func(t *testing.T, err error, _ []DictionaryMember) {
+				t.Helper()
+
+				require.ErrorIs(t, err, ErrMalformed)
+				require.ErrorContains(t, err, "unterminated string")
+			},
+		},
+		{
+			uc:    "invalid escape sequence",
+			value: `a="f\oo"`,
+			assert: func(t *testing.T, err error, _ []DictionaryMember) {
+				t.Helper()
+
+				require.ErrorIs(t, err, ErrMalformed)
+				require.ErrorContains(t, err, "invalid escape sequence")
+			},
+		},
+		{
+			uc:    "invalid character in string",
+			value: "a=\"f\too\"",
+			assert: func(t *testing.T, err error, _ []DictionaryMember) {
+				t.Helper()
+
+				require.ErrorIs(t, err, ErrMalformed)
+				require.ErrorContains(t, err, "invalid character in string")
+			},
+		},
+		{
+			uc:    "invalid boolean",
+			value: "a=?2",
+			assert: func(t *testing.T, err error, _ []DictionaryMember) {
+				t.Helper()
+
+				require.ErrorIs(t, err, ErrMalformed)
+				require.ErrorContains(t, err, "invalid boolean")
+			},
+		},
+		{
+			uc:    "decimal",
+			value: "a=1.5",
+			assert: func(t *testing.T, err error, _ []DictionaryMember) {
+				t.Helper()
+
+				require.ErrorIs(t, err, ErrMalformed)
+				require.ErrorContains(t, err, "decimals are not supported")
+			},
+		},
+		{
+			uc:    "integer with too many digits",
+			value: "a=1234567890123456",
+			assert: func(t *testing.T, err error, _ []DictionaryMember) {
+				t.Helper()
+
+				require.ErrorIs(t, err, ErrMalformed)
+				require.ErrorContains(t, err, "between 1 and 15 digits")
+			},
+		},
+		{
+			uc:    "minus without digits",
+			value: "a=-",
+			assert: func(t *testing.T, err error, _ []DictionaryMember) {
+				t.Helper()
+
+				require.ErrorIs(t, err, ErrMalformed)
+				require.ErrorContains(t, err, "between 1 and 15 digits")
+			},
+		},
+		{
+			uc:    "unterminated inner list",
+			value: `a=(1 2`,
+			assert: func(t *testing.T, err error, _ []DictionaryMember) {
+				t.Helper()
+
+				require.ErrorIs(t, err, ErrMalformed)
+				require.ErrorContains(t, err, "unterminated inner list")
+			},
+		},
+		{
+			uc:    "inner list items not separated by spaces",
+			value: `a=("foo""bar")`,
+			assert: func(t *testing.T, err error, _ []DictionaryMember) {
+				t.Helper()
+
+				require.ErrorIs(t, err, ErrMalformed)
+				require.ErrorContains(t, err, "unexpected character in inner list")
+			},
+		},
+		{
+			uc:    "missing value",
+			value: "a=",
+			assert: func(t *testing.T, err error, _ []DictionaryMember) {
+				t.Helper()
+
+				require.ErrorIs(t, err, ErrMalformed)
+				require.ErrorContains(t, err, "unexpected end of input")
+			},
+		},
+		{
+			uc:    "trailing comma",
+			value: "a=1,",
+			assert: func(t *testing.T, err error, _ []DictionaryMember) {
+				t.Helper()
+
+				require.ErrorIs(t, err, ErrMalformed)
+				require.ErrorContains(t, err, "trailing comma")
+			},
+		},
+		{
+			uc:    "garbage after member",
+			value: "a=1 foo",
+			assert: func(t *testing.T, err error, _ []DictionaryMember) {
+				t.Helper()
+
+				require.ErrorIs(t, err, ErrMalformed)
+				require.ErrorContains(t, err, "unexpected character")
+			},
+		},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			// WHEN
+			members, err := ParseDictionary(tc.value)
+
+			// THEN
+			tc.assert(t, err, members)
+		})
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package structuredfields implements the parts of Structured Field Values for HTTP
+// (RFC 8941) required by heimdall, which are dictionaries, inner lists, items and
+// parameters with all bare item types except decimals.
+package structuredfields
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var ErrMalformed = errors.New("malformed structured field")
+
+// Token represents a token as defined in RFC 8941, section 3.3.4. It is used to
+// distinguish tokens from strings.
+type Token string
+
+// Param is a parameter of an item or of an inner list as defined in RFC 8941, section 3.1.2.
+// Its value is either a string, a Token, an int64, a []byte or a bool.
+type Param struct {
+	Key   string
+	Value any
+}
+
+// Params holds the parameters in the order they appeared in the field value.
+type Params []Param
+
+// Get returns the value of the parameter with the given key.
+func (p Params) Get(key string) (any, bool) {
+	for _, param := range p {
+		if param.Key == key {
+			return param.Value, true
+		}
+	}
+
+	return nil, false
+}
+
+// Serialize writes the parameters as defined in RFC 8941, section 4.1.1.2.
+func (p Params) Serialize(builder *strings.Builder) {
+	for _, param := range p {
+		builder.WriteByte(';')
+		builder.WriteString(param.Key)
+
+		if value, ok := param.Value.(bool); ok && value {
+			continue
+		}
+
+		builder.WriteByte('=')
+		SerializeBareItem(builder, param.Value)
+	}
+}
+
+// Item is an item as defined in RFC 8941, section 3.3. Its value is either a string,
+// a Token, an int64, a []byte or a bool.
+type Item struct {
+	Value  any
+	Params Params
+}
+
+// Serialize writes the item as defined in RFC 8941, section 4.1.3.
+func (i Item) Serialize(builder *strings.Builder) {
+	SerializeBareItem(builder, i.Value)
+	i.Params.Serialize(builder)
+}
+
+// InnerList is an inner list as defined in RFC 8941, section 3.1.1.
+type InnerList struct {
+	Items  []Item
+	Params Params
+}
+
+// Serialize writes the inner list as defined in RFC 8941, section 4.1.1.1.
+func (l InnerList) Serialize(builder *strings.Builder) {
+	builder.WriteByte('(')
+
+	for idx, item := range l.Items {
+		if idx != 0 {
+			builder.WriteByte(' ')
+		}
+
+		item.Serialize(builder)
+	}
+
+	builder.WriteByte(')')
+	l.Params.Serialize(builder)
+}
+
+// DictionaryMember is a member of a dictionary as defined in RFC 8941, section 3.2. Its
+// value is either an Item or an InnerList.
+type DictionaryMember struct {
+	Key   string
+	Value any
+}
+
+// SerializeBareItem writes the given value as defined in RFC 8941, section 4.1.3.1.
+// Values of unsupported types are ignored.
+func SerializeBareItem(builder *strings.Builder, value any) {
+	switch val := value.(type) {
+	case string:
+		builder.WriteByte('"')
+		builder.WriteString(strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(val))
+		builder.WriteByte('"')
+	case Token:
+		builder.WriteString(string(val))
+	case int64:
+		builder.WriteString(strconv.FormatInt(val, 10))
+	case []byte:
+		builder.WriteByte(':')
+		builder.WriteString(base64.StdEncoding.EncodeToString(val))
+		builder.WriteByte(':')
+	case bool:
+		if val {
+			builder.WriteString("?1")
+		} else {
+			builder.WriteString("?0")
+		}
+	}
+}
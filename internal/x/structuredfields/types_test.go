@@ -0,0 +1,83 @@
+// Copyright 2024 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package structuredfields
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSerializeBareItem(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		value    any
+		expected string
+	}{
+		{value: `foo"bar\baz`, expected: `"foo\"bar\\baz"`},
+		{value: Token("foo/bar"), expected: "foo/bar"},
+		{value: int64(-42), expected: "-42"},
+		{value: []byte("foo"), expected: ":Zm9v:"},
+		{value: true, expected: "?1"},
+		{value: false, expected: "?0"},
+	} {
+		t.Run(tc.expected, func(t *testing.T) {
+			var builder strings.Builder
+
+			// WHEN
+			SerializeBareItem(&builder, tc.value)
+
+			// THEN
+			assert.Equal(t, tc.expected, builder.String())
+		})
+	}
+}
+
+func TestSerializeInnerList(t *testing.T) {
+	t.Parallel()
+
+	var builder strings.Builder
+
+	list := InnerList{
+		Items: []Item{
+			{Value: "@method"},
+			{Value: "@query-param", Params: Params{{Key: "name", Value: "foo"}}},
+		},
+		Params: Params{{Key: "created", Value: int64(1618884473)}, {Key: "foo", Value: false}, {Key: "bar", Value: true}},
+	}
+
+	// WHEN
+	list.Serialize(&builder)
+
+	// THEN
+	assert.Equal(t, `("@method" "@query-param";name="foo");created=1618884473;foo=?0;bar`, builder.String())
+}
+
+func TestParamsGet(t *testing.T) {
+	t.Parallel()
+
+	params := Params{{Key: "foo", Value: "bar"}}
+
+	value, ok := params.Get("foo")
+	assert.True(t, ok)
+	assert.Equal(t, "bar", value)
+
+	_, ok = params.Get("bar")
+	assert.False(t, ok)
+}
@@ -150,6 +150,12 @@ func WithURIs(uris []*url.URL) CertificateBuilderOption {
 	}
 }
 
+func WithOCSPServers(servers []string) CertificateBuilderOption {
+	return func(builder *CertificateBuilder) {
+		builder.tmpl.OCSPServer = servers
+	}
+}
+
 func WithGeneratedSubjectKeyID() CertificateBuilderOption {
 	return func(builder *CertificateBuilder) {
 		builder.generateKeyIdentifier = true